@@ -16,6 +16,7 @@ import (
 	"github.com/qri-io/apiutil"
 	"github.com/qri-io/qfs/cafs"
 	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/token"
 	"github.com/qri-io/qri/version"
 )
 
@@ -226,92 +227,103 @@ func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(`{ "meta": { "code": 200, "status": "ok", "versionzz":"` + APIVersion + `" }, "data": [] }`))
 }
 
-// NewServerRoutes returns a Muxer that has all API routes
+// NewServerRoutes returns a Muxer that has all API routes. Each route lists
+// the token scope its request methods require
 func NewServerRoutes(s Server) *http.ServeMux {
 	node := s.Node()
 	cfg := s.Config()
 
 	m := http.NewServeMux()
 
-	m.Handle("/health", s.middleware(HealthCheckHandler))
-	m.Handle("/ipfs/", s.middleware(s.HandleIPFSPath))
-	m.Handle("/ipns/", s.middleware(s.HandleIPNSPath))
+	m.Handle("/health", s.middleware(scopes(""), HealthCheckHandler))
+	m.Handle("/ipfs/", s.middleware(scopes(token.ScopeRead), s.HandleIPFSPath))
+	m.Handle("/ipns/", s.middleware(scopes(token.ScopeRead), s.HandleIPNSPath))
 
 	proh := NewProfileHandlers(s.Instance, cfg.API.ReadOnly)
-	m.Handle("/me", s.middleware(proh.ProfileHandler))
-	m.Handle("/profile", s.middleware(proh.ProfileHandler))
-	m.Handle("/profile/photo", s.middleware(proh.ProfilePhotoHandler))
-	m.Handle("/profile/poster", s.middleware(proh.PosterHandler))
+	m.Handle("/me", s.middleware(readGETs(token.ScopeWrite), proh.ProfileHandler))
+	m.Handle("/profile", s.middleware(readGETs(token.ScopeWrite), proh.ProfileHandler))
+	m.Handle("/profile/photo", s.middleware(readGETs(token.ScopeWrite), proh.ProfilePhotoHandler))
+	m.Handle("/profile/poster", s.middleware(readGETs(token.ScopeWrite), proh.PosterHandler))
 
 	ph := NewPeerHandlers(node, cfg.API.ReadOnly)
-	m.Handle("/peers", s.middleware(ph.PeersHandler))
-	m.Handle("/peers/", s.middleware(ph.PeerHandler))
-	m.Handle("/connect/", s.middleware(ph.ConnectToPeerHandler))
-	m.Handle("/connections", s.middleware(ph.ConnectionsHandler))
+	m.Handle("/peers", s.middleware(scopes(token.ScopeRead), ph.PeersHandler))
+	m.Handle("/peers/", s.middleware(scopes(token.ScopeRead), ph.PeerHandler))
+	m.Handle("/connect/", s.middleware(scopes(token.ScopeAdmin), ph.ConnectToPeerHandler))
+	m.Handle("/connections", s.middleware(scopes(token.ScopeRead), ph.ConnectionsHandler))
 
 	if cfg.Remote != nil && cfg.Remote.Enabled {
 		log.Info("running in `remote` mode")
 
+		// remote routes serve other peers, which don't hold API tokens, so they
+		// intentionally skip token checks. the remote authenticates requests
+		// itself: dsync & refs requests carry signed params, and logsync
+		// requests are signed by the key of the sender
 		remh := NewRemoteHandlers(s.Instance)
-		m.Handle("/remote/dsync", s.middleware(remh.DsyncHandler))
-		m.Handle("/remote/logsync", s.middleware(remh.LogsyncHandler))
-		m.Handle("/remote/refs", s.middleware(remh.RefsHandler))
+		m.Handle("/remote/dsync", s.middleware(scopes(""), remh.DsyncHandler))
+		m.Handle("/remote/logsync", s.middleware(scopes(""), remh.LogsyncHandler))
+		m.Handle("/remote/refs", s.middleware(scopes(""), remh.RefsHandler))
 	}
 
 	dsh := NewDatasetHandlers(s.Instance, cfg.API.ReadOnly)
-	m.Handle("/list", s.middleware(dsh.ListHandler))
-	m.Handle("/list/", s.middleware(dsh.PeerListHandler))
-	m.Handle("/save", s.middleware(dsh.SaveHandler))
-	m.Handle("/save/", s.middleware(dsh.SaveHandler))
-	m.Handle("/remove/", s.middleware(dsh.RemoveHandler))
-	m.Handle("/me/", s.middleware(dsh.GetHandler))
-	m.Handle("/add/", s.middleware(dsh.AddHandler))
-	m.Handle("/rename", s.middleware(dsh.RenameHandler))
-	m.Handle("/export/", s.middleware(dsh.ZipDatasetHandler))
-	m.Handle("/diff", s.middleware(dsh.DiffHandler))
-	m.Handle("/body/", s.middleware(dsh.BodyHandler))
-	m.Handle("/stats/", s.middleware(dsh.StatsHandler))
-	m.Handle("/unpack/", s.middleware(dsh.UnpackHandler))
+	m.Handle("/list", s.middleware(scopes(token.ScopeRead), dsh.ListHandler))
+	m.Handle("/list/", s.middleware(scopes(token.ScopeRead), dsh.PeerListHandler))
+	m.Handle("/save", s.middleware(scopes(token.ScopeWrite), dsh.SaveHandler))
+	m.Handle("/save/", s.middleware(scopes(token.ScopeWrite), dsh.SaveHandler))
+	m.Handle("/remove/", s.middleware(scopes(token.ScopeWrite), dsh.RemoveHandler))
+	m.Handle("/me/", s.middleware(scopes(token.ScopeRead), dsh.GetHandler))
+	m.Handle("/add/", s.middleware(scopes(token.ScopeWrite), dsh.AddHandler))
+	m.Handle("/rename", s.middleware(scopes(token.ScopeWrite), dsh.RenameHandler))
+	m.Handle("/export/", s.middleware(scopes(token.ScopeRead), dsh.ZipDatasetHandler))
+	m.Handle("/diff", s.middleware(scopes(token.ScopeRead), dsh.DiffHandler))
+	m.Handle("/body/", s.middleware(scopes(token.ScopeRead), dsh.BodyHandler))
+	m.Handle("/stats/", s.middleware(scopes(token.ScopeRead), dsh.StatsHandler))
+	m.Handle("/unpack/", s.middleware(scopes(token.ScopeRead), dsh.UnpackHandler))
 
 	remClientH := NewRemoteClientHandlers(s.Instance, cfg.API.ReadOnly)
-	m.Handle("/publish/", s.middleware(remClientH.PublishHandler))
-	m.Handle("/fetch/", s.middleware(remClientH.NewFetchHandler("/fetch")))
-	m.Handle("/feeds", s.middleware(remClientH.FeedsHandler))
-	m.Handle("/preview/", s.middleware(remClientH.DatasetPreviewHandler))
+	m.Handle("/publish/", s.middleware(readGETs(token.ScopePublish), remClientH.PublishHandler))
+	m.Handle("/fetch/", s.middleware(scopes(token.ScopeWrite), remClientH.NewFetchHandler("/fetch")))
+	m.Handle("/feeds", s.middleware(scopes(token.ScopeRead), remClientH.FeedsHandler))
+	m.Handle("/preview/", s.middleware(scopes(token.ScopeRead), remClientH.DatasetPreviewHandler))
 
 	uh := UpdateHandlers{
 		UpdateMethods: lib.NewUpdateMethods(s.Instance),
 		ReadOnly:      cfg.API.ReadOnly,
 	}
-	m.Handle("/update", s.middleware(uh.UpdatesHandler))
-	m.Handle("/update/run", s.middleware(uh.RunHandler))
-	m.Handle("/update/logs", s.middleware(uh.LogsHandler))
-	m.Handle("/update/logs/file", s.middleware(uh.LogFileHandler))
-	m.Handle("/update/service", s.middleware(uh.ServiceHandler))
+	m.Handle("/update", s.middleware(readGETs(token.ScopeWrite), uh.UpdatesHandler))
+	m.Handle("/update/run", s.middleware(scopes(token.ScopeWrite), uh.RunHandler))
+	m.Handle("/update/dag", s.middleware(scopes(token.ScopeRead), uh.DependenciesHandler))
+	m.Handle("/update/pause", s.middleware(scopes(token.ScopeWrite), uh.PauseHandler))
+	m.Handle("/update/resume", s.middleware(scopes(token.ScopeWrite), uh.ResumeHandler))
+	m.Handle("/update/logs", s.middleware(scopes(token.ScopeRead), uh.LogsHandler))
+	m.Handle("/update/logs/file", s.middleware(scopes(token.ScopeRead), uh.LogFileHandler))
+	m.Handle("/update/service", s.middleware(scopes(token.ScopeAdmin), uh.ServiceHandler))
 
 	fsih := NewFSIHandlers(s.Instance, cfg.API.ReadOnly)
-	m.Handle("/status/", s.middleware(fsih.StatusHandler("/status")))
-	m.Handle("/init/", s.middleware(fsih.InitHandler("/init")))
-	m.Handle("/checkout/", s.middleware(fsih.CheckoutHandler("/checkout")))
-	m.Handle("/restore/", s.middleware(fsih.RestoreHandler("/restore")))
-	m.Handle("/fsi/write/", s.middleware(fsih.WriteHandler("/fsi/write")))
+	m.Handle("/status/", s.middleware(scopes(token.ScopeRead), fsih.StatusHandler("/status")))
+	m.Handle("/init/", s.middleware(scopes(token.ScopeWrite), fsih.InitHandler("/init")))
+	m.Handle("/checkout/", s.middleware(scopes(token.ScopeWrite), fsih.CheckoutHandler("/checkout")))
+	m.Handle("/restore/", s.middleware(scopes(token.ScopeWrite), fsih.RestoreHandler("/restore")))
+	m.Handle("/fsi/write/", s.middleware(scopes(token.ScopeWrite), fsih.WriteHandler("/fsi/write")))
 
 	renderh := NewRenderHandlers(node.Repo)
-	m.Handle("/render", s.middleware(renderh.RenderHandler))
-	m.Handle("/render/", s.middleware(renderh.RenderHandler))
+	m.Handle("/render", s.middleware(scopes(token.ScopeRead), renderh.RenderHandler))
+	m.Handle("/render/", s.middleware(scopes(token.ScopeRead), renderh.RenderHandler))
 
 	lh := NewLogHandlers(node)
-	m.Handle("/history/", s.middleware(lh.LogHandler))
+	m.Handle("/history/", s.middleware(scopes(token.ScopeRead), lh.LogHandler))
 
 	rch := NewRegistryClientHandlers(s.Instance, cfg.API.ReadOnly)
-	m.Handle("/registry/profile/new", s.middleware(rch.CreateProfileHandler))
-	m.Handle("/registry/profile/prove", s.middleware(rch.ProveProfileKeyHandler))
+	m.Handle("/registry/profile/new", s.middleware(scopes(token.ScopeAdmin), rch.CreateProfileHandler))
+	m.Handle("/registry/profile/prove", s.middleware(scopes(token.ScopeAdmin), rch.ProveProfileKeyHandler))
 
 	sh := NewSearchHandlers(s.Instance)
-	m.Handle("/search", s.middleware(sh.SearchHandler))
+	m.Handle("/search", s.middleware(scopes(token.ScopeRead), sh.SearchHandler))
+
+	sqlh := NewSQLHandlers(s.Instance)
+	m.Handle("/sql", s.middleware(scopes(token.ScopeRead), sqlh.SQLHandler))
 
 	rh := NewRootHandler(dsh, ph)
-	m.Handle("/", s.datasetRefMiddleware(s.middleware(rh.Handler)))
+	m.Handle("/", s.datasetRefMiddleware(s.middleware(scopes(token.ScopeRead), rh.Handler)))
 
 	return m
 }
@@ -278,6 +278,97 @@ func TestServerReadOnlyRoutes(t *testing.T) {
 	}
 }
 
+func TestServerTokenRoutes(t *testing.T) {
+	if err := confirmQriNotRunning(); err != nil {
+		t.Skip(err.Error())
+	}
+
+	golog.SetLogLevel("qriapi", "error")
+	defer golog.SetLogLevel("qriapi", "info")
+
+	r, err := test.NewTestRepo()
+	if err != nil {
+		t.Fatalf("error allocating test repo: %s", err.Error())
+	}
+	cfg := config.DefaultConfigForTesting()
+	cfg.API.RequireToken = true
+	node, err := p2p.NewQriNode(r, cfg.P2P)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	inst := lib.NewInstanceFromConfigAndNode(cfg, node)
+	server := httptest.NewServer(NewServerRoutes(New(inst)))
+	defer server.Close()
+
+	tm := lib.NewTokenMethods(inst)
+	issue := func(scopes ...string) *lib.IssuedToken {
+		res := &lib.IssuedToken{}
+		if err := tm.Issue(&lib.IssueTokenParams{Scopes: scopes}, res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	read := issue("read")
+	write := issue("write")
+	revoked := issue("admin")
+	ok := false
+	if err := tm.Revoke(&revoked.Claims.ID, &ok); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		method    string
+		endpoint  string
+		token     string
+		resStatus int
+	}{
+		// routes that don't need a token
+		{"GET", "/health", "", 200},
+		{"OPTIONS", "/peer/movies", "", 200},
+
+		{"GET", "/peer/movies", "", 401},
+		{"GET", "/peer/movies", "not.a.token", 401},
+		{"GET", "/peer/movies", revoked.Token, 401},
+		{"GET", "/peer/movies", read.Token, 200},
+		{"GET", "/history/peer/movies", write.Token, 200},
+		{"GET", "/me", read.Token, 200},
+		{"POST", "/rename", read.Token, 403},
+		{"POST", "/publish/peer/movies", write.Token, 403},
+		{"GET", "/connect/QmZePf5LeXow3RW5U1AgEiNbW46YnRGhZ7HPvm1UmPFPwt", write.Token, 403},
+		{"POST", "/me", read.Token, 403},
+		{"GET", "/save", read.Token, 403},
+		// fetching writes to the logbook, so it needs write scope for any method
+		{"GET", "/fetch/peer/movies", read.Token, 403},
+		{"POST", "/fetch/peer/movies", read.Token, 403},
+	}
+
+	client := &http.Client{}
+	for i, c := range cases {
+		req, err := http.NewRequest(c.method, server.URL+c.endpoint, nil)
+		if err != nil {
+			t.Errorf("case %d error creating request: %s", i, err.Error())
+			continue
+		}
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			t.Errorf("case %d error performing request: %s", i, err.Error())
+			continue
+		}
+		res.Body.Close()
+
+		if res.StatusCode != c.resStatus {
+			t.Errorf("case %d: %s - %s status code mismatch. expected: %d, got: %d", i, c.method, c.endpoint, c.resStatus, res.StatusCode)
+			continue
+		}
+		if c.resStatus == 401 && res.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("case %d: %s - %s expected a WWW-Authenticate header", i, c.method, c.endpoint)
+		}
+	}
+}
+
 type handlerMimeMultipartTestCase struct {
 	method    string
 	endpoint  string
@@ -11,6 +11,7 @@ import (
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
 	util "github.com/qri-io/apiutil"
 	"github.com/qri-io/dataset"
@@ -218,7 +219,12 @@ func (h *DatasetHandlers) zipDatasetHandler(w http.ResponseWriter, r *http.Reque
 		util.WriteErrResponse(w, http.StatusInternalServerError, err)
 		return
 	}
-	params := lib.ExportParams{Ref: ref, TargetDir: tmpDir, Format: format, Zipped: zipped}
+	asOf, err := asOfFromRequest(r)
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	params := lib.ExportParams{Ref: ref, TargetDir: tmpDir, Format: format, Zipped: zipped, AsOf: asOf}
 
 	var fileWritten string
 	req := lib.NewExportRequests(h.node, nil)
@@ -250,6 +256,10 @@ func extensionToMimeType(ext string) string {
 		return "application/json"
 	case ".yaml":
 		return "application/x-yaml"
+	case ".csv":
+		return "text/csv"
+	case ".cbor":
+		return "application/cbor"
 	case ".xlsx":
 		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
 	case ".zip":
@@ -282,14 +292,20 @@ func (h *DatasetHandlers) listHandler(w http.ResponseWriter, r *http.Request) {
 // if we are in read-only mode, we should error,
 // otherwise, resolve the peername and proceed as normal
 func (h *DatasetHandlers) getHandler(w http.ResponseWriter, r *http.Request) {
+	asOf, err := asOfFromRequest(r)
+	if err != nil {
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
 	p := lib.GetParams{
 		Path:   HTTPPathToQriPath(r.URL.Path),
 		UseFSI: r.FormValue("fsi") == "true",
+		AsOf:   asOf,
 	}
 	res := lib.GetResult{}
-	err := h.Get(&p, &res)
+	err = h.Get(&p, &res)
 	if err != nil {
-		if err == repo.ErrNoHistory || err == fsi.ErrNoLink {
+		if err == repo.ErrNoHistory || err == fsi.ErrNoLink || errors.Is(err, repo.ErrNoVersionAsOf) {
 			util.WriteErrResponse(w, http.StatusUnprocessableEntity, err)
 			return
 		}
@@ -324,10 +340,20 @@ func (h *DatasetHandlers) diffHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	default:
+		if rng := r.FormValue("range"); rng != "" {
+			h.diffRangeHandler(w, r, rng)
+			return
+		}
+		asOf, err := asOfFromRequest(r)
+		if err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
 		req = &lib.DiffParams{
 			LeftPath:  r.FormValue("left_path"),
 			RightPath: r.FormValue("right_path"),
 			Selector:  r.FormValue("selector"),
+			AsOf:      asOf,
 		}
 	}
 
@@ -341,6 +367,21 @@ func (h *DatasetHandlers) diffHandler(w http.ResponseWriter, r *http.Request) {
 	util.WritePageResponse(w, res, r, util.Page{})
 }
 
+// diffRangeHandler responds with the changes each version made over a range of
+// history of the dataset at left_path
+func (h *DatasetHandlers) diffRangeHandler(w http.ResponseWriter, r *http.Request, rng string) {
+	p := &lib.DiffRangeParams{
+		Ref:   r.FormValue("left_path"),
+		Range: rng,
+	}
+	res := []*lib.VersionChanges{}
+	if err := h.DiffRange(p, &res); err != nil {
+		util.WriteErrResponse(w, http.StatusInternalServerError, fmt.Errorf("error generating diff: %s", err.Error()))
+		return
+	}
+	util.WriteResponse(w, res)
+}
+
 func (h *DatasetHandlers) peerListHandler(w http.ResponseWriter, r *http.Request) {
 	log.Info(r.URL.Path)
 	p := lib.ListParamsFromRequest(r)
@@ -571,6 +612,15 @@ type DataResponse struct {
 	Data json.RawMessage `json:"data"`
 }
 
+// asOfFromRequest reads the optional "as_of" timestamp parameter. a missing
+// parameter returns the zero time
+func asOfFromRequest(r *http.Request) (time.Time, error) {
+	if str := r.FormValue("as_of"); str != "" {
+		return repo.ParseAsOf(str)
+	}
+	return time.Time{}, nil
+}
+
 // getParamsFromRequest creates getParams from a request. It's currently only used for paginating dataset bodies
 func getParamsFromRequest(r *http.Request, readOnly bool, path string) (*lib.GetParams, error) {
 	listParams := lib.ListParamsFromRequest(r)
@@ -585,6 +635,11 @@ func getParamsFromRequest(r *http.Request, readOnly bool, path string) (*lib.Get
 		return nil, fmt.Errorf("the format must be json if used without the download parameter")
 	}
 
+	asOf, err := asOfFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
 	p := &lib.GetParams{
 		Path:     path,
 		Format:   format,
@@ -593,6 +648,10 @@ func getParamsFromRequest(r *http.Request, readOnly bool, path string) (*lib.Get
 		Limit:    listParams.Limit,
 		Offset:   listParams.Offset,
 		All:      r.FormValue("all") == "true" && !readOnly,
+		Fields:   listFormValue(r, "fields"),
+		Where:    r.FormValue("where"),
+		Sort:     listFormValue(r, "sort"),
+		AsOf:     asOf,
 	}
 
 	if !readOnly {
@@ -619,6 +678,17 @@ func getParamsFromRequest(r *http.Request, readOnly bool, path string) (*lib.Get
 	return p, nil
 }
 
+// listFormValue splits a comma separated form value
+func listFormValue(r *http.Request, key string) []string {
+	var list []string
+	for _, s := range strings.Split(r.FormValue(key), ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			list = append(list, s)
+		}
+	}
+	return list
+}
+
 func (h DatasetHandlers) bodyHandler(w http.ResponseWriter, r *http.Request) {
 	refStr := HTTPPathToQriPath(r.URL.Path[len("/body/"):])
 	p, err := getParamsFromRequest(r, h.ReadOnly, refStr)
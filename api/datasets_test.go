@@ -1,9 +1,16 @@
 package api
 
 import (
+	"bytes"
+	"encoding/json"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"strconv"
+	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestGetParamsFromRequest(t *testing.T) {
@@ -138,3 +145,46 @@ func TestGetParamsFromRequest(t *testing.T) {
 		}
 	}
 }
+
+func TestBodyHandlerQuery(t *testing.T) {
+	run := NewAPITestRunner(t)
+	defer run.Delete()
+
+	h := NewDatasetHandlers(run.Inst, false)
+	where := url.QueryEscape(`pop > 100000 and city ~ "^[cn]"`)
+	cases := []struct {
+		endpoint string
+		expect   string
+	}{
+		{"/body/peer/cities?fields=city,pop&where=" + where + "&sort=-pop",
+			`[["new york",8500000],["chicago",300000]]`},
+		{"/body/peer/cities?download=true&format=csv&fields=pop,city&where=" + where,
+			"pop,city\n8500000,new york\n300000,chicago\n"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest("GET", c.endpoint, nil)
+		w := httptest.NewRecorder()
+		h.BodyHandler(w, req)
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d: %s", c.endpoint, w.Code, w.Body.String())
+			continue
+		}
+		got := w.Body.Bytes()
+		if strings.HasPrefix(c.expect, "[") {
+			res := struct {
+				Data DataResponse
+			}{}
+			if err := json.Unmarshal(got, &res); err != nil {
+				t.Fatal(err)
+			}
+			buf := &bytes.Buffer{}
+			if err := json.Compact(buf, res.Data.Data); err != nil {
+				t.Fatal(err)
+			}
+			got = buf.Bytes()
+		}
+		if diff := cmp.Diff(c.expect, string(got)); diff != "" {
+			t.Errorf("%s: response mismatch (-want +got):\n%s", c.endpoint, diff)
+		}
+	}
+}
@@ -4,13 +4,47 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	util "github.com/qri-io/apiutil"
+	"github.com/qri-io/qri/token"
 )
 
-// middleware handles request logging
-func (s Server) middleware(handler http.HandlerFunc) http.HandlerFunc {
+// anyMethod keys the scope routeScopes require for methods they don't list
+const anyMethod = "*"
+
+// routeScopes lists the token scope each request method on a route requires.
+// Methods a route doesn't list require the scope listed for anyMethod, HEAD
+// requests fall back to the scope for GET. An empty scope doesn't require a
+// token
+type routeScopes map[string]token.Scope
+
+// scopes requires the same token scope for every method on a route
+func scopes(scope token.Scope) routeScopes {
+	return routeScopes{anyMethod: scope}
+}
+
+// readGETs requires read scope for GET requests to a route, and scope for
+// every other method. Only use it for routes that don't change state on GET
+func readGETs(scope token.Scope) routeScopes {
+	return routeScopes{"GET": token.ScopeRead, anyMethod: scope}
+}
+
+// scope returns the token scope a request method requires
+func (rs routeScopes) scope(method string) token.Scope {
+	if scope, ok := rs[method]; ok {
+		return scope
+	}
+	if scope, ok := rs["GET"]; ok && method == "HEAD" {
+		return scope
+	}
+	return rs[anyMethod]
+}
+
+// middleware handles request logging & authorization. rs lists the token
+// scope each request method on the route requires
+func (s Server) middleware(rs routeScopes, handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Infof("%s %s %s", r.Method, r.URL.Path, time.Now())
 
@@ -33,12 +67,40 @@ func (s Server) middleware(handler http.HandlerFunc) http.HandlerFunc {
 		// }
 		s.addCORSHeaders(w, r)
 
-		if ok := s.readOnlyCheck(r); ok {
-			handler(w, r)
-		} else {
+		if ok := s.readOnlyCheck(r); !ok {
 			util.WriteErrResponse(w, http.StatusForbidden, fmt.Errorf("qri server is in read-only mode, only certain GET requests are allowed"))
+			return
 		}
+		if status, err := s.tokenCheck(rs.scope(r.Method), r); err != nil {
+			if status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="qri"`)
+			}
+			util.WriteErrResponse(w, status, err)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// tokenCheck confirms a request carries a token with the scope a route
+// requires, returning the http status to respond with if not
+func (s *Server) tokenCheck(scope token.Scope, r *http.Request) (int, error) {
+	if !s.Config().API.RequireToken || scope == "" || r.Method == "OPTIONS" {
+		return http.StatusOK, nil
+	}
+
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return http.StatusUnauthorized, fmt.Errorf("a bearer token is required")
+	}
+	claims, err := s.Authenticate(strings.TrimPrefix(auth, "Bearer "))
+	if err != nil {
+		return http.StatusUnauthorized, err
+	}
+	if !claims.Allows(scope) {
+		return http.StatusForbidden, fmt.Errorf("token doesn't have %s scope", scope)
 	}
+	return http.StatusOK, nil
 }
 
 func (s *Server) readOnlyCheck(r *http.Request) bool {
@@ -38,6 +38,7 @@ func (h *SearchHandlers) searchHandler(w http.ResponseWriter, r *http.Request) {
 		QueryString: r.FormValue("q"),
 		Limit:       listParams.Limit,
 		Offset:      listParams.Offset,
+		Local:       r.FormValue("local") == "true",
 	}
 
 	if r.Header.Get("Content-Type") == "application/json" {
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	util "github.com/qri-io/apiutil"
+	"github.com/qri-io/qri/lib"
+)
+
+// SQLHandlers wraps a requests struct to interface with http.HandlerFunc
+type SQLHandlers struct {
+	lib.SQLMethods
+}
+
+// NewSQLHandlers allocates a SQLHandlers pointer
+func NewSQLHandlers(inst *lib.Instance) *SQLHandlers {
+	m := lib.NewSQLMethods(inst)
+	return &SQLHandlers{*m}
+}
+
+// SQLHandler runs SQL queries against datasets
+func (h *SQLHandlers) SQLHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET", "POST":
+		h.sqlHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *SQLHandlers) sqlHandler(w http.ResponseWriter, r *http.Request) {
+	p := &lib.SQLParams{
+		Query:  r.FormValue("q"),
+		Format: r.FormValue("format"),
+	}
+	if r.Header.Get("Content-Type") == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	// json results are wrapped in a standard response, other formats are
+	// written as-is
+	sw := &sqlResponseWriter{w: w}
+	if p.Format == "" || p.Format == "json" {
+		sw.contentType = "application/json"
+		sw.prefix = []byte(`{"data":`)
+		sw.suffix = []byte(`,"meta":{"code":200}}`)
+	} else {
+		sw.contentType = extensionToMimeType("." + p.Format)
+	}
+
+	p.Output = sw
+	var res []byte
+	if err := h.Exec(p, &res); err != nil {
+		if !sw.wrote {
+			util.WriteErrResponse(w, http.StatusBadRequest, err)
+			return
+		}
+		// rows have already been sent, the response can only be cut short
+		log.Infof("error writing response: %s", err.Error())
+		return
+	}
+	if err := sw.Close(); err != nil {
+		log.Infof("error writing response: %s", err.Error())
+	}
+}
+
+// sqlResponseWriter streams query results to an http response. headers are
+// sent with the first write, so errors that happen before any rows are
+// produced can still be reported with an error status
+type sqlResponseWriter struct {
+	w              http.ResponseWriter
+	contentType    string
+	prefix, suffix []byte
+	wrote          bool
+}
+
+func (s *sqlResponseWriter) Write(p []byte) (int, error) {
+	if !s.wrote {
+		s.wrote = true
+		if s.contentType != "" {
+			s.w.Header().Set("Content-Type", s.contentType)
+		}
+		s.w.WriteHeader(http.StatusOK)
+		if _, err := s.w.Write(s.prefix); err != nil {
+			return 0, err
+		}
+	}
+	return s.w.Write(p)
+}
+
+// Close finishes the response
+func (s *sqlResponseWriter) Close() error {
+	if _, err := s.Write(nil); err != nil {
+		return err
+	}
+	_, err := s.w.Write(s.suffix)
+	return err
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSQLHandler(t *testing.T) {
+	run := NewAPITestRunner(t)
+	defer run.Delete()
+
+	h := NewSQLHandlers(run.Inst)
+	query := url.QueryEscape("SELECT city, pop FROM peer/cities WHERE in_usa ORDER BY pop DESC LIMIT 2")
+	cases := []struct {
+		method, endpoint, contentType, body string
+		status                              int
+		expect                              string
+	}{
+		{"GET", "/sql?q=" + query, "", "", http.StatusOK,
+			`{"data":[["new york",8500000],["chicago",300000]],"meta":{"code":200}}`},
+		{"GET", "/sql?format=csv&q=" + query, "", "", http.StatusOK,
+			"city,pop\nnew york,8500000\nchicago,300000\n"},
+		{"POST", "/sql", "application/json", `{"Query": "SELECT COUNT(*) FROM peer/cities"}`, http.StatusOK,
+			`{"data":[[5]],"meta":{"code":200}}`},
+		{"GET", "/sql?q=" + url.QueryEscape("SELECT nope FROM peer/cities"), "", "", http.StatusBadRequest,
+			`{"meta":{"code":400,"error":"no such column: nope"}}`},
+	}
+
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.endpoint, bytes.NewBufferString(c.body))
+		if c.contentType != "" {
+			req.Header.Set("Content-Type", c.contentType)
+		}
+		w := httptest.NewRecorder()
+		h.SQLHandler(w, req)
+
+		if w.Code != c.status {
+			t.Errorf("%s %s: expected status %d, got %d", c.method, c.endpoint, c.status, w.Code)
+		}
+		got := w.Body.Bytes()
+		if strings.HasPrefix(c.expect, "{") {
+			buf := &bytes.Buffer{}
+			if err := json.Compact(buf, got); err != nil {
+				t.Fatal(err)
+			}
+			got = buf.Bytes()
+		}
+		if diff := cmp.Diff(c.expect, string(got)); diff != "" {
+			t.Errorf("%s %s: response mismatch (-want +got):\n%s", c.method, c.endpoint, diff)
+		}
+	}
+}
@@ -76,6 +76,8 @@ func (h UpdateHandlers) scheduleUpdateHandler(w http.ResponseWriter, r *http.Req
 			// TODO (b5) - support setting dataset params via form values
 			// we should ensure sure pointer is nil if no values are specified
 		}
+		// FormValue parses the request form
+		p.Upstream = r.Form["upstream"]
 	}
 
 	if err := h.Schedule(p, res); err != nil {
@@ -95,6 +97,100 @@ func (h UpdateHandlers) unscheduleUpdateHandler(w http.ResponseWriter, r *http.R
 	}
 }
 
+// PauseHandler stops a scheduled update from running until it's resumed
+func (h *UpdateHandlers) PauseHandler(w http.ResponseWriter, r *http.Request) {
+	if h.ReadOnly {
+		util.NotFoundHandler(w, r)
+		return
+	}
+
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.pauseHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *UpdateHandlers) pauseHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.FormValue("name")
+	res := false
+	if err := h.Pause(&name, &res); err != nil {
+		log.Errorf("pausing update: %s", err)
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	h.writeJob(w, name)
+}
+
+// ResumeHandler restarts a paused update
+func (h *UpdateHandlers) ResumeHandler(w http.ResponseWriter, r *http.Request) {
+	if h.ReadOnly {
+		util.NotFoundHandler(w, r)
+		return
+	}
+
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "POST":
+		h.resumeHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *UpdateHandlers) resumeHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.FormValue("name")
+	res := false
+	if err := h.Resume(&name, &res); err != nil {
+		log.Errorf("resuming update: %s", err)
+		util.WriteErrResponse(w, http.StatusBadRequest, err)
+		return
+	}
+	h.writeJob(w, name)
+}
+
+// writeJob responds with the current state of a scheduled update
+func (h *UpdateHandlers) writeJob(w http.ResponseWriter, name string) {
+	res := &lib.Job{}
+	if err := h.Job(&name, res); err != nil {
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := util.WriteResponse(w, res); err != nil {
+		log.Errorf("update job response: %s", err.Error())
+	}
+}
+
+// DependenciesHandler shows the graph of scheduled updates that trigger each
+// other
+func (h *UpdateHandlers) DependenciesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "OPTIONS":
+		util.EmptyOkHandler(w, r)
+	case "GET":
+		h.dependenciesHandler(w, r)
+	default:
+		util.NotFoundHandler(w, r)
+	}
+}
+
+func (h *UpdateHandlers) dependenciesHandler(w http.ResponseWriter, r *http.Request) {
+	in := false
+	res := &lib.DependencyGraph{}
+	if err := h.Dependencies(&in, res); err != nil {
+		log.Errorf("getting update dependencies: %s", err.Error())
+		util.WriteErrResponse(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := util.WriteResponse(w, res); err != nil {
+		log.Errorf("update dependencies response: %s", err.Error())
+	}
+}
+
 // LogsHandler shows the log of previously run updates
 func (h *UpdateHandlers) LogsHandler(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
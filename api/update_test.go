@@ -51,6 +51,13 @@ func TestUpdateHandlers(t *testing.T) {
 	}
 	runHandlerTestCases(t, "update log", h.LogsHandler, logCases, false)
 
+	pauseCases := []handlerTestCase{
+		{"OPTIONS", "/", nil},
+		{"GET", "/", nil},
+	}
+	runHandlerTestCases(t, "pause", h.PauseHandler, pauseCases, false)
+	runHandlerTestCases(t, "resume", h.ResumeHandler, pauseCases, false)
+
 	runUpdateCases := []handlerMimeMultipartTestCase{
 		{"OPTIONS", "/update/run", nil, nil},
 		{"GET", "/update/run", nil, nil},
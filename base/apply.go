@@ -0,0 +1,144 @@
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/startf"
+)
+
+// ErrBodyMismatch indicates re-running a transform didn't reproduce the
+// committed body
+var ErrBodyMismatch = fmt.Errorf("transform result doesn't match the committed body")
+
+// ApplyTransform re-runs the transform of a saved version without saving the
+// result. The transform sees the same previous version it was saved with.
+// replay serves download requests from the fixtures recorded when the version
+// was saved instead of the network
+func ApplyTransform(ctx context.Context, r repo.Repo, ref *reporef.DatasetRef, replay bool, secrets map[string]string, scriptOut io.Writer) (*dataset.Dataset, error) {
+	if err := ReadDataset(ctx, r, ref); err != nil {
+		return nil, err
+	}
+	ds := ref.Dataset
+	if ds.Transform == nil || ds.Transform.ScriptPath == "" {
+		return nil, dsfs.ErrNoTransform
+	}
+
+	script, err := dsfs.LoadTransformScript(ctx, r.Store(), ref.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	prev := &dataset.Dataset{}
+	if ds.PreviousPath != "" {
+		if prev, _, err = loadPreviousForSave(ctx, r, ds.PreviousPath); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []func(*startf.ExecOpts){
+		startf.AddQriRepo(r),
+		startf.AddQriReader(NewQriModuleReader(r)),
+		startf.SetOutWriter(scriptOut),
+		startf.SetSecrets(secrets),
+	}
+	if replay {
+		fixtures, err := dsfs.LoadTransformFixtures(ctx, r.Store(), ds.Transform)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, startf.ReplayFixtures(fixtures))
+	}
+
+	config := map[string]interface{}{}
+	for key, val := range ds.Transform.Config {
+		config[key] = val
+	}
+	next := &dataset.Dataset{
+		Peername:     ds.Peername,
+		Name:         ds.Name,
+		PreviousPath: ds.PreviousPath,
+		Transform: &dataset.Transform{
+			Config:    config,
+			Resources: ds.Transform.Resources,
+		},
+	}
+	next.Transform.SetScriptFile(script)
+
+	if err = startf.ExecScript(ctx, next, prev, opts...); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// CompareBodies checks if the body of a dataset matches the body of a saved
+// version, returning ErrBodyMismatch if entries differ. Bodies are compared
+// entry-by-entry, so differences in format don't count as changes
+func CompareBodies(ctx context.Context, r repo.Repo, saved, ds *dataset.Dataset) error {
+	if ds.BodyFile() == nil {
+		return fmt.Errorf("%w: no body", ErrBodyMismatch)
+	}
+	pro, err := r.Profile()
+	if err != nil {
+		return err
+	}
+	if err = InferValues(pro, ds); err != nil {
+		return err
+	}
+
+	f, err := dsfs.LoadBody(ctx, r.Store(), saved)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	want, err := readBody(saved.Structure, f)
+	if err != nil {
+		return err
+	}
+
+	body := ds.BodyFile()
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes(body.FileName(), data))
+	got, err := readBody(ds.Structure, qfs.NewMemfileBytes(body.FileName(), data))
+	if err != nil {
+		return err
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		return fmt.Errorf("%w: %s", ErrBodyMismatch, saved.Path)
+	}
+	return nil
+}
+
+// readBody reads all entries of a body, normalizing values by way of JSON so
+// bodies read from different formats can be compared
+func readBody(st *dataset.Structure, f qfs.File) (interface{}, error) {
+	rdr, err := dsio.NewEntryReader(st, f)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ReadEntries(rdr)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return nil, err
+	}
+	var body interface{}
+	err = json.Unmarshal(data, &body)
+	return body, err
+}
@@ -0,0 +1,71 @@
+package base
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+)
+
+func TestApplyTransformReplay(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"city":"toronto","pop":40000000},{"city":"new york","pop":8500000}]`))
+	}))
+
+	ds := &dataset.Dataset{
+		Peername: "peer",
+		Name:     "replay",
+		Transform: &dataset.Transform{
+			ScriptBytes: []byte(`load("http.star", "http")
+
+def download(ctx):
+  return http.get("` + s.URL + `").json()
+
+def transform(ds, ctx):
+  ds.set_body([[row["city"], row["pop"]] for row in ctx.download])`),
+		},
+	}
+	ds.Transform.OpenScriptFile(ctx, nil)
+
+	ref, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true, RecordFixtures: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ref.Dataset.Transform.Config[dsfs.FixturesConfigKey].(string); !ok {
+		t.Fatalf("expected saved transform to reference a fixtures path. got: %v", ref.Dataset.Transform.Config)
+	}
+	fixtures, err := dsfs.LoadTransformFixtures(ctx, r.Store(), ref.Dataset.Transform)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixtures.Len() != 1 {
+		t.Errorf("expected 1 recorded response. got: %d", fixtures.Len())
+	}
+
+	s.Close()
+	ref.Dataset = nil
+	next, err := ApplyTransform(ctx, r, &ref, true, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = CompareBodies(ctx, r, ref.Dataset, next); err != nil {
+		t.Errorf("expected replayed transform to reproduce the committed body. got: %s", err)
+	}
+
+	if _, err = ApplyTransform(ctx, r, &ref, false, nil, nil); err == nil {
+		t.Error("expected applying a transform without replay to fail with the server closed")
+	}
+
+	changed := &dataset.Dataset{Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}}
+	changed.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[["toronto",40000000]]`)))
+	if err = CompareBodies(ctx, r, ref.Dataset, changed); !errors.Is(err, ErrBodyMismatch) {
+		t.Errorf("expected ErrBodyMismatch comparing a different body. got: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -16,10 +17,30 @@ import (
 	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/qfs"
 	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/parquet"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/sql"
 )
 
-// ReadBody grabs some or all of a dataset's body, writing an output in the desired format
-func ReadBody(ds *dataset.Dataset, format dataset.DataFormat, fcfg dataset.FormatConfig, limit, offset int, all bool) (data []byte, err error) {
+// BodyQuery selects fields of a body, filters & sorts entries while the body
+// is read. Fields lists the fields of each entry to keep, entries must match
+// the Where expression, and are ordered by the fields listed in Sort. A sort
+// field prefixed with "-" sorts descending. see sql.Filter for details
+type BodyQuery struct {
+	Fields []string
+	Where  string
+	Sort   []string
+}
+
+// IsEmpty checks if a query leaves a body as-is
+func (q *BodyQuery) IsEmpty() bool {
+	return q == nil || (len(q.Fields) == 0 && q.Where == "" && len(q.Sort) == 0)
+}
+
+// ReadBody grabs some or all of a dataset's body, writing an output in the desired format.
+// a non-empty query is applied before limit & offset
+func ReadBody(ds *dataset.Dataset, format dataset.DataFormat, fcfg dataset.FormatConfig, q *BodyQuery, limit, offset int, all bool) (data []byte, err error) {
 	if ds == nil {
 		return nil, fmt.Errorf("can't load body from a nil dataset")
 	}
@@ -40,7 +61,7 @@ func ReadBody(ds *dataset.Dataset, format dataset.DataFormat, fcfg dataset.Forma
 	}
 	st.Assign(ds.Structure, assign)
 
-	data, err = ConvertBodyFile(file, ds.Structure, st, limit, offset, all)
+	data, err = QueryBodyFile(file, ds.Structure, st, q, limit, offset, all)
 	if err != nil {
 		log.Debug(err.Error())
 		return nil, err
@@ -118,6 +139,30 @@ func InlineJSONBody(ds *dataset.Dataset) error {
 // ConvertBodyFile takes an input file & structure, and converts a specified selection
 // to the structure specified by out
 func ConvertBodyFile(file qfs.File, in, out *dataset.Structure, limit, offset int, all bool) (data []byte, err error) {
+	return QueryBodyFile(file, in, out, nil, limit, offset, all)
+}
+
+// QueryBodyFile is ConvertBodyFile with a query applied to entries before
+// limit & offset. When the query selects fields, the schema of out is replaced
+// with one describing the selected fields
+func QueryBodyFile(file qfs.File, in, out *dataset.Structure, q *BodyQuery, limit, offset int, all bool) (data []byte, err error) {
+	rr, err := dsio.NewEntryReader(in, file)
+	if err != nil {
+		err = fmt.Errorf("error allocating data reader: %s", err)
+		return
+	}
+
+	if !q.IsEmpty() {
+		if rr, err = sql.Filter(rr, q.Fields, q.Where, q.Sort); err != nil {
+			return
+		}
+		if len(q.Fields) > 0 && rr.Structure() != nil {
+			st := &dataset.Structure{}
+			st.Assign(out, &dataset.Structure{Schema: rr.Structure().Schema})
+			out = st
+		}
+	}
+
 	buf := &bytes.Buffer{}
 
 	w, err := dsio.NewEntryWriter(out, buf)
@@ -136,12 +181,6 @@ func ConvertBodyFile(file qfs.File, in, out *dataset.Structure, limit, offset in
 		return
 	}
 
-	rr, err := dsio.NewEntryReader(in, file)
-	if err != nil {
-		err = fmt.Errorf("error allocating data reader: %s", err)
-		return
-	}
-
 	if !all {
 		rr = &dsio.PagedReader{
 			Reader: rr,
@@ -194,7 +233,7 @@ func DatasetBodyFile(ctx context.Context, store cafs.Filestore, ds *dataset.Data
 	}
 
 	if strings.HasPrefix(ds.BodyPath, "/ipfs") || strings.HasPrefix(ds.BodyPath, "/cafs") || strings.HasPrefix(ds.BodyPath, "/map") {
-		return store.Get(ctx, ds.BodyPath)
+		return dsfs.LoadBody(ctx, store, ds)
 	}
 
 	// convert yaml input to json as a hack to support yaml input for now
@@ -248,3 +287,105 @@ func ConvertBodyFormat(bodyFile qfs.File, fromSt, toSt *dataset.Structure) (qfs.
 
 	return qfs.NewMemfileReader(fmt.Sprintf("body.%s", toSt.Format), buffer), nil
 }
+
+// ConvertParquetBody replaces a parquet body file with the same rows written
+// in the dataset's structure format. If the structure doesn't set a format,
+// rows are written in the format of the version the save to branch builds on,
+// or json for new datasets. parquet isn't a body format datasets can be stored
+// in, so parquet bodies are converted before saving. A schema set on the
+// structure is kept, otherwise the schema is read from the parquet file
+func ConvertParquetBody(ctx context.Context, r repo.Repo, ds *dataset.Dataset, branch string) error {
+	file := ds.BodyFile()
+	if file == nil {
+		return nil
+	}
+	ext := filepath.Ext(file.FileName())
+	if strings.ToLower(ext) != ".parquet" {
+		return nil
+	}
+
+	if ds.Structure == nil {
+		ds.Structure = &dataset.Structure{}
+	}
+	if ds.Structure.Format == "" || ds.Structure.Format == "parquet" {
+		prev, err := PreviousStructure(ctx, r, ds.Peername, ds.Name, branch)
+		if err != nil {
+			return err
+		}
+		ds.Structure.Format = "json"
+		ds.Structure.FormatConfig = nil
+		if prev != nil && prev.Format != "" {
+			ds.Structure.Format = prev.Format
+			ds.Structure.FormatConfig = prev.FormatConfig
+		}
+	}
+
+	// parquet metadata is at the end of the file, parquet files are read from
+	// disk instead of a stream
+	src, size, cleanup, err := parquetSource(file)
+	if err != nil {
+		return fmt.Errorf("reading parquet body: %s", err)
+	}
+	defer cleanup()
+	pr, err := parquet.NewEntryReader(src, size)
+	if err != nil {
+		return fmt.Errorf("reading parquet body: %s", err)
+	}
+	if ds.Structure.Schema == nil {
+		ds.Structure.Schema = pr.Structure().Schema
+	}
+
+	spool := &dsfs.BodySpool{}
+	w, err := dsio.NewEntryWriter(ds.Structure, spool)
+	if err != nil {
+		spool.Close()
+		return err
+	}
+	if err = dsio.Copy(pr, w); err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		spool.Close()
+		return fmt.Errorf("converting parquet body: %s", err)
+	}
+
+	filename := fmt.Sprintf("%s.%s", strings.TrimSuffix(filepath.Base(file.FileName()), ext), ds.Structure.Format)
+	body, err := spool.File(filename)
+	if err != nil {
+		return err
+	}
+	ds.SetBodyFile(body)
+	return nil
+}
+
+// parquetSource gives random access to a parquet body file. files on disk are
+// read in place, other files are copied to a temp file. cleanup closes the
+// body & removes any temp file
+func parquetSource(file qfs.File) (src io.ReaderAt, size int64, cleanup func(), err error) {
+	if f, ok := file.(interface {
+		io.ReaderAt
+		Stat() (os.FileInfo, error)
+	}); ok {
+		fi, err := f.Stat()
+		if err != nil {
+			file.Close()
+			return nil, 0, nil, err
+		}
+		return f, fi.Size(), func() { file.Close() }, nil
+	}
+
+	defer file.Close()
+	tmp, err := ioutil.TempFile("", "qri_parquet_*")
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	cleanup = func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+	if size, err = io.Copy(tmp, file); err != nil {
+		cleanup()
+		return nil, 0, nil, err
+	}
+	return tmp, size, cleanup, nil
+}
@@ -7,10 +7,17 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/localfs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/parquet"
 )
 
 func TestReadBody(t *testing.T) {
@@ -23,7 +30,7 @@ func TestReadBody(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	data, err := ReadBody(ds, dataset.JSONDataFormat, nil, 1, 1, false)
+	data, err := ReadBody(ds, dataset.JSONDataFormat, nil, nil, 1, 1, false)
 	if err != nil {
 		t.Error(err.Error())
 	}
@@ -34,6 +41,39 @@ func TestReadBody(t *testing.T) {
 	if ds.BodyPath != "/map/QmcCcPTqmckdXLBwPQXxfyW2BbFcUT6gqv9oGeWDkrNTyD" {
 		t.Errorf("bodypath mismatch")
 	}
+
+	// body files can only be read once, reload the dataset before each read
+	reload := func() *dataset.Dataset {
+		ds, err := ReadDatasetPath(ctx, r, ref.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ds
+	}
+
+	// queries apply before paging
+	q := &BodyQuery{Fields: []string{"city", "pop"}, Where: "in_usa", Sort: []string{"-pop"}}
+	data, err = ReadBody(reload(), dataset.JSONDataFormat, nil, q, 2, 1, false)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	if !bytes.Equal(data, []byte(`[["chicago",300000],["raleigh",250000]]`)) {
+		t.Errorf("queried byte response mismatch. got: %s", string(data))
+	}
+
+	// selected fields are written as the header of csv output
+	q = &BodyQuery{Fields: []string{"avg_age", "city"}, Where: `city ~ "^c"`}
+	data, err = ReadBody(reload(), dataset.CSVDataFormat, &dataset.CSVOptions{HeaderRow: true}, q, 0, 0, true)
+	if err != nil {
+		t.Error(err.Error())
+	}
+	if expect := "avg_age,city\n44.4,chicago\n65.25,chatham\n"; string(data) != expect {
+		t.Errorf("csv response mismatch. expected: %q, got: %q", expect, string(data))
+	}
+
+	if _, err = ReadBody(reload(), dataset.JSONDataFormat, nil, &BodyQuery{Where: "nope = 1"}, 0, 0, true); err == nil || err.Error() != "no such column: nope" {
+		t.Errorf("expected an unknown column error, got: %v", err)
+	}
 }
 
 func TestDatasetBodyFile(t *testing.T) {
@@ -177,3 +217,158 @@ func TestConvertBodyFormat(t *testing.T) {
 		t.Error(fmt.Errorf("converted body didn't match, got: %s", data))
 	}
 }
+
+func TestConvertParquetBody(t *testing.T) {
+	sch := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "city", "type": "string"},
+				map[string]interface{}{"title": "pop", "type": "integer"},
+			},
+		},
+	}
+	buf := &bytes.Buffer{}
+	w, err := parquet.NewEntryWriter(&dataset.Structure{Format: "json", Schema: sch}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range []interface{}{[]interface{}{"toronto", 40000000}, []interface{}{"chatham", nil}} {
+		if err := w.WriteEntry(dsio.Entry{Index: i, Value: row}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		st       *dataset.Structure
+		filename string
+		body     string
+	}{
+		{nil, "cities.json", `[["toronto",40000000],["chatham",null]]`},
+		{&dataset.Structure{Format: "csv"}, "cities.csv", "toronto,40000000\nchatham,\n"},
+	}
+	ctx := context.Background()
+	r := newTestRepo(t)
+	for _, c := range cases {
+		ds := &dataset.Dataset{Structure: c.st}
+		ds.SetBodyFile(qfs.NewMemfileBytes("cities.parquet", buf.Bytes()))
+		if err := ConvertParquetBody(ctx, r, ds, ""); err != nil {
+			t.Fatal(err)
+		}
+		if ds.BodyFile().FileName() != c.filename {
+			t.Errorf("expected filename %q, got %q", c.filename, ds.BodyFile().FileName())
+		}
+		data, err := ioutil.ReadAll(ds.BodyFile())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != c.body {
+			t.Errorf("body mismatch. expected: %q, got: %q", c.body, data)
+		}
+		if diff := cmp.Diff(sch, ds.Structure.Schema); diff != "" {
+			t.Errorf("schema mismatch (-want +got):\n%s", diff)
+		}
+	}
+
+	// other bodies are left alone
+	ds := &dataset.Dataset{}
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(`[]`)))
+	if err := ConvertParquetBody(ctx, r, ds, ""); err != nil {
+		t.Fatal(err)
+	}
+	if ds.Structure != nil {
+		t.Errorf("expected structure of a json body to be unchanged")
+	}
+
+	ds.SetBodyFile(qfs.NewMemfileBytes("body.parquet", []byte(`[]`)))
+	if err := ConvertParquetBody(ctx, r, ds, ""); err == nil {
+		t.Errorf("expected error converting invalid parquet body")
+	}
+}
+
+func TestSaveParquetBodyToCSVDataset(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	ref := addCitiesDataset(t, r)
+
+	sch := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "city", "type": "string"},
+				map[string]interface{}{"title": "pop", "type": "integer"},
+				map[string]interface{}{"title": "avg_age", "type": "number"},
+				map[string]interface{}{"title": "in_usa", "type": "boolean"},
+			},
+		},
+	}
+	buf := &bytes.Buffer{}
+	w, err := parquet.NewEntryWriter(&dataset.Structure{Format: "json", Schema: sch}, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := []interface{}{
+		[]interface{}{"toronto", 40000000, 55.5, false},
+		[]interface{}{"chatham", 35000, 65.25, true},
+	}
+	for i, row := range rows {
+		if err := w.WriteEntry(dsio.Entry{Index: i, Value: row}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// parquet files on disk are read in place
+	dir, err := ioutil.TempDir("", "save_parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cities.parquet")
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := localfs.NewFS().Get(ctx, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := &dataset.Dataset{Peername: ref.Peername, Name: ref.Name}
+	changes.SetBodyFile(f)
+	if err := ConvertParquetBody(ctx, r, changes, ""); err != nil {
+		t.Fatal(err)
+	}
+	if changes.Structure.Format != "csv" {
+		t.Errorf("expected body to be converted to the previous version's format, got %q", changes.Structure.Format)
+	}
+	if changes.BodyFile().FileName() != "cities.csv" {
+		t.Errorf("expected filename %q, got %q", "cities.csv", changes.BodyFile().FileName())
+	}
+
+	saved, err := SaveDataset(ctx, r, devNull, changes, nil, nil, SaveDatasetSwitches{Pin: true})
+	if err != nil {
+		t.Fatalf("saving a parquet body onto a csv dataset: %s", err)
+	}
+	ds, err := dsfs.LoadDataset(ctx, r.Store(), saved.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := dsfs.LoadBody(ctx, r.Store(), ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "city,pop,avg_age,in_usa\ntoronto,40000000,55.5,false\nchatham,35000,65.25,true\n"
+	if string(data) != expect {
+		t.Errorf("body mismatch. expected: %q, got: %q", expect, data)
+	}
+}
@@ -0,0 +1,218 @@
+package base
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/deepdiff"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/base/friendly"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// VersionChanges describes the changes a version of a dataset made to the
+// body & schema of the version before it
+type VersionChanges struct {
+	Path         string    `json:"path"`
+	PreviousPath string    `json:"previousPath"`
+	CommitTime   time.Time `json:"commitTime"`
+	CommitTitle  string    `json:"commitTitle,omitempty"`
+
+	// row-level body changes
+	RowsAdded    int `json:"rowsAdded"`
+	RowsRemoved  int `json:"rowsRemoved"`
+	RowsModified int `json:"rowsModified"`
+
+	Stat   *deepdiff.Stats `json:"stat,omitempty"`
+	Body   deepdiff.Deltas `json:"body,omitempty"`
+	Schema deepdiff.Deltas `json:"schema,omitempty"`
+
+	// Summary is a one-line description of the changes, Message is a longer
+	// description
+	Summary string `json:"summary,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// DiffRange diffs each version in a range of dataset history against the
+// version before it by walking PreviousPath links back from ref.Path, which
+// must be set. Changes are ordered newest first. A range that starts before
+// the first version is clamped to the first version
+func DiffRange(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, from, to *dsref.Rev) ([]*VersionChanges, error) {
+	if ref.Path == "" {
+		return nil, repo.ErrNoHistory
+	}
+
+	// load versions back to the start of the range, newest first
+	store := r.Store()
+	history := []*dataset.Dataset{}
+	for path := ref.Path; path != "" && (from.Gen == dsref.AllGenerations || len(history) <= from.Gen); {
+		ds, err := dsfs.LoadDataset(ctx, store, path)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, ds)
+		path = ds.PreviousPath
+	}
+	if to.Gen >= len(history) {
+		return nil, fmt.Errorf("%s has %d versions, can't start a range %d versions back", ref.AliasString(), len(history), to.Gen)
+	}
+
+	changes := []*VersionChanges{}
+	var doc, prevDoc map[string]interface{}
+	for i := to.Gen; i < len(history)-1; i++ {
+		ds, prev := history[i], history[i+1]
+		var err error
+		if doc == nil {
+			if doc, err = changelogDocument(ctx, store, ds); err != nil {
+				return nil, err
+			}
+		}
+		if prevDoc, err = changelogDocument(ctx, store, prev); err != nil {
+			return nil, err
+		}
+
+		vc, err := diffVersions(ctx, doc, prevDoc)
+		if err != nil {
+			return nil, err
+		}
+		vc.Path = ds.Path
+		vc.PreviousPath = ds.PreviousPath
+		if ds.Commit != nil {
+			vc.CommitTime = ds.Commit.Timestamp
+			vc.CommitTitle = ds.Commit.Title
+		}
+		changes = append(changes, vc)
+
+		// the previous version is the next step's newer side
+		doc = prevDoc
+	}
+	return changes, nil
+}
+
+// changelogDocument projects the schema & body of a dataset into a single
+// value for diffing
+func changelogDocument(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	if ds.Structure != nil {
+		sch, err := toMergeValue(ds.Structure.Schema)
+		if err != nil {
+			return nil, err
+		}
+		doc["structure"] = map[string]interface{}{"schema": sch}
+	}
+	if ds.BodyPath != "" {
+		f, err := dsfs.LoadBody(ctx, store, ds)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		rdr, err := dsio.NewEntryReader(ds.Structure, f)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := ReadEntries(rdr)
+		if err != nil {
+			return nil, err
+		}
+		// normalize to JSON types so bodies read from different formats compare
+		if doc["body"], err = toMergeValue(entries); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// diffVersions describes the changes between two changelog documents
+func diffVersions(ctx context.Context, doc, prevDoc map[string]interface{}) (*VersionChanges, error) {
+	deltas, stat, err := deepdiff.New().StatDiff(ctx, prevDoc, doc)
+	if err != nil {
+		return nil, err
+	}
+
+	vc := &VersionChanges{Stat: stat}
+	for _, d := range deltas {
+		switch d.Path.String() {
+		case "body":
+			if d.Type == deepdiff.DTContext {
+				vc.Body = d.Deltas
+				vc.RowsAdded, vc.RowsRemoved, vc.RowsModified = countRowChanges(d.Deltas)
+				continue
+			}
+			// the entire body was added or removed
+			vc.Body = deepdiff.Deltas{d}
+			switch d.Type {
+			case deepdiff.DTInsert:
+				vc.RowsAdded = entryCount(d.Value)
+			case deepdiff.DTDelete:
+				vc.RowsRemoved = entryCount(d.Value)
+			}
+		case "structure":
+			if d.Type == deepdiff.DTContext {
+				vc.Schema = d.Deltas
+			} else {
+				vc.Schema = deepdiff.Deltas{d}
+			}
+		}
+	}
+
+	// friendly descriptions rewrite the deltas they're given, pass a copy
+	vc.Summary, vc.Message = friendly.DiffDescriptions(copyDeltas(deltas), stat)
+	return vc, nil
+}
+
+// countRowChanges tallies the top-level entries of a body diff. A removal
+// immediately followed by an insert at the same position replaces the row,
+// and counts as a modification
+func countRowChanges(deltas deepdiff.Deltas) (added, removed, modified int) {
+	for i := 0; i < len(deltas); i++ {
+		d := deltas[i]
+		switch d.Type {
+		case deepdiff.DTInsert:
+			added++
+		case deepdiff.DTDelete:
+			if i+1 < len(deltas) && deltas[i+1].Type == deepdiff.DTInsert && deltas[i+1].Path.String() == d.Path.String() {
+				modified++
+				i++
+				continue
+			}
+			removed++
+		case deepdiff.DTUpdate:
+			modified++
+		case deepdiff.DTContext:
+			if len(d.Deltas) > 0 {
+				modified++
+			}
+		}
+	}
+	return added, removed, modified
+}
+
+// entryCount returns the number of entries in a body value
+func entryCount(v interface{}) int {
+	switch body := v.(type) {
+	case []interface{}:
+		return len(body)
+	case map[string]interface{}:
+		return len(body)
+	}
+	return 0
+}
+
+func copyDeltas(deltas deepdiff.Deltas) deepdiff.Deltas {
+	if deltas == nil {
+		return nil
+	}
+	cp := make(deepdiff.Deltas, len(deltas))
+	for i, d := range deltas {
+		c := *d
+		c.Deltas = copyDeltas(d.Deltas)
+		cp[i] = &c
+	}
+	return cp
+}
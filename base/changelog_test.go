@@ -0,0 +1,91 @@
+package base
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/dsref"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestDiffRange(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	save := func(title, body string, schema map[string]interface{}) reporef.DatasetRef {
+		ds := &dataset.Dataset{
+			Peername:  "peer",
+			Name:      "changelog_test",
+			Commit:    &dataset.Commit{Title: title},
+			Structure: &dataset.Structure{Format: "json", Schema: schema},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(body)))
+		ref, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ref
+	}
+
+	schema := func(column string) map[string]interface{} {
+		return map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "id", "type": "integer"},
+					map[string]interface{}{"title": column, "type": "string"},
+				},
+			},
+		}
+	}
+
+	save("created", `[[1,"a"],[2,"b"]]`, schema("name"))
+	save("add a row", `[[1,"a"],[2,"b"],[3,"c"]]`, schema("name"))
+	save("modify a row", `[[1,"a"],[2,"B"],[3,"c"]]`, schema("name"))
+	head := save("drop a row", `[[2,"B"],[3,"c"]]`, schema("label"))
+
+	type counts struct {
+		Title                   string
+		Added, Removed, Changed int
+		SchemaChanged           bool
+	}
+
+	changes, err := DiffRange(ctx, r, head, &dsref.Rev{Gen: dsref.AllGenerations}, &dsref.Rev{Gen: 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []counts{}
+	for _, c := range changes {
+		got = append(got, counts{c.CommitTitle, c.RowsAdded, c.RowsRemoved, c.RowsModified, len(c.Schema) > 0})
+		if c.Summary == "" {
+			t.Errorf("expected %q to have a summary", c.CommitTitle)
+		}
+	}
+	expect := []counts{
+		{"drop a row", 0, 1, 0, true},
+		{"modify a row", 0, 0, 1, false},
+		{"add a row", 1, 0, 0, false},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("changelog mismatch (-want +got):\n%s", diff)
+	}
+	if changes[0].Path != head.Path || changes[0].PreviousPath != changes[1].Path {
+		t.Errorf("expected changes to follow previous path links")
+	}
+
+	changes, err = DiffRange(ctx, r, head, &dsref.Rev{Gen: 2}, &dsref.Rev{Gen: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 1 || changes[0].CommitTitle != "modify a row" {
+		t.Errorf("expected range 2..1 to describe the second-to-last version. got: %v", changes)
+	}
+
+	if _, err = DiffRange(ctx, r, head, &dsref.Rev{Gen: 10}, &dsref.Rev{Gen: 5}); err == nil {
+		t.Errorf("expected range past the start of history to error")
+	}
+}
@@ -2,6 +2,7 @@ package base
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -17,29 +18,46 @@ import (
 // for populated Path or Byte suffixed fields, consuming those fields to
 // set File handlers that are ready for reading
 func OpenDataset(ctx context.Context, fsys qfs.Filesystem, ds *dataset.Dataset) (err error) {
+	var f qfs.File
 	if ds.BodyFile() == nil {
 		if err = ds.OpenBodyFile(ctx, fsys); err != nil {
 			log.Debug(err)
 			return
 		}
+		if f, err = dsfs.DecryptFile(ctx, ds.BodyFile()); err != nil {
+			return
+		}
+		ds.SetBodyFile(f)
 	}
 	if ds.Transform != nil && ds.Transform.ScriptFile() == nil {
 		if err = ds.Transform.OpenScriptFile(ctx, fsys); err != nil {
 			log.Debug(err)
 			return
 		}
+		if f, err = dsfs.DecryptFile(ctx, ds.Transform.ScriptFile()); err != nil {
+			return
+		}
+		ds.Transform.SetScriptFile(f)
 	}
 	if ds.Viz != nil && ds.Viz.ScriptFile() == nil {
 		if err = ds.Viz.OpenScriptFile(ctx, fsys); err != nil {
 			log.Debug(err)
 			return
 		}
+		if f, err = dsfs.DecryptFile(ctx, ds.Viz.ScriptFile()); err != nil {
+			return
+		}
+		ds.Viz.SetScriptFile(f)
 	}
 	if ds.Readme != nil && ds.Readme.ScriptFile() == nil {
 		if err = ds.Readme.OpenScriptFile(ctx, fsys); err != nil {
 			log.Debug(err)
 			return
 		}
+		if f, err = dsfs.DecryptFile(ctx, ds.Readme.ScriptFile()); err != nil {
+			return
+		}
+		ds.Readme.SetScriptFile(f)
 	}
 
 	// TODO (b5) - this is an error sometimes caused by failing to properly pin the
@@ -124,7 +142,8 @@ func ListDatasets(ctx context.Context, r repo.Repo, term string, limit, offset i
 		pub := make([]reporef.DatasetRef, len(res))
 		i := 0
 		for _, ref := range res {
-			if ref.Published {
+			// private datasets are never listed to others, even if published
+			if ref.Published && !IsPrivate(ctx, r, ref) {
 				pub[i] = ref
 				i++
 			}
@@ -155,6 +174,10 @@ func ListDatasets(ctx context.Context, r repo.Repo, term string, limit, offset i
 					err = nil
 					continue
 				}
+				if errors.Is(err, dsfs.ErrNoDataKey) {
+					// private datasets we don't have the key to are listed by reference
+					continue
+				}
 				return nil, fmt.Errorf("error loading ref: %s, err: %s", ref.String(), err.Error())
 			}
 			ds.Peername = res[i].Peername
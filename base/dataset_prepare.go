@@ -12,6 +12,7 @@ import (
 	"github.com/qri-io/qfs"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
 	reporef "github.com/qri-io/qri/repo/ref"
@@ -29,20 +30,82 @@ import (
 // through canonicalization (looking the name up in the repo). The value given by the input dataset
 // document may differ, and we should probably respect that value if it does
 func PrepareDatasetSave(ctx context.Context, r repo.Repo, peername, name string) (prev, mutable *dataset.Dataset, prevPath string, err error) {
+	return PrepareDatasetBranchSave(ctx, r, peername, name, "")
+}
+
+// PrepareDatasetBranchSave is the branch-aware variant of PrepareDatasetSave.
+// the previous dataset is resolved from the head of the named logbook branch
+// instead of the repo's reference store. saving to a branch that has no
+// history is treated as creating a new dataset
+func PrepareDatasetBranchSave(ctx context.Context, r repo.Repo, peername, name, branch string) (prev, mutable *dataset.Dataset, prevPath string, err error) {
+	if prevPath, err = previousPath(ctx, r, peername, name, branch); err != nil {
+		return nil, nil, "", err
+	}
+	if prevPath == "" {
+		return &dataset.Dataset{}, &dataset.Dataset{}, "", nil
+	}
+	prev, mutable, err = loadPreviousForSave(ctx, r, prevPath)
+	return
+}
+
+// previousPath resolves the path of the version a save to branch builds on,
+// returning "" if the save creates a new dataset
+func previousPath(ctx context.Context, r repo.Repo, peername, name, branch string) (string, error) {
 	// Though a name is not required (it may be inferred), a peername must be set
 	if peername == "" {
-		return nil, nil, "", fmt.Errorf("peername required to prepare dataset")
+		return "", fmt.Errorf("peername required to prepare dataset")
 	}
 
-	// Determine if the save is creating a new dataset or updating an existing dataset by
-	// seeing if the name can canonicalize to a repo that we know about
 	lookup := &reporef.DatasetRef{Name: name, Peername: peername}
-	if err = repo.CanonicalizeDatasetRef(r, lookup); err == repo.ErrNotFound || lookup.Path == "" {
-		return &dataset.Dataset{}, &dataset.Dataset{}, "", nil
+	if branch == "" || branch == logbook.DefaultBranchName {
+		// Determine if the save is creating a new dataset or updating an existing dataset by
+		// seeing if the name can canonicalize to a repo that we know about
+		if err := repo.CanonicalizeDatasetRef(r, lookup); err == repo.ErrNotFound || lookup.Path == "" {
+			return "", nil
+		}
+		return lookup.Path, nil
+	}
+
+	if err := repo.CanonicalizeProfile(r, lookup); err != nil {
+		return "", err
+	}
+	book := r.Logbook()
+	if book == nil {
+		return "", logbook.ErrNoLogbook
+	}
+	versions, err := book.BranchVersions(ctx, reporef.ConvertToDsref(*lookup), branch, 0, 1)
+	if err != nil {
+		return "", fmt.Errorf("branch '%s' not found for dataset %s", branch, lookup.AliasString())
 	}
+	if len(versions) == 0 {
+		return "", nil
+	}
+	return versions[0].Path, nil
+}
 
-	prevPath = lookup.Path
+// PreviousStructure loads the structure of the version a save to branch builds
+// on, returning nil if the save creates a new dataset
+func PreviousStructure(ctx context.Context, r repo.Repo, peername, name, branch string) (*dataset.Structure, error) {
+	if peername == "" {
+		peername = "me"
+	}
+	if name == "" {
+		return nil, nil
+	}
+	prevPath, err := previousPath(ctx, r, peername, name, branch)
+	if err != nil || prevPath == "" {
+		return nil, err
+	}
+	prev, err := dsfs.LoadDataset(ctx, r.Store(), prevPath)
+	if err != nil {
+		return nil, err
+	}
+	return prev.Structure, nil
+}
 
+// loadPreviousForSave loads the previous version of a dataset with its body
+// file open, and a mutable copy with the transform & commit removed
+func loadPreviousForSave(ctx context.Context, r repo.Repo, prevPath string) (prev, mutable *dataset.Dataset, err error) {
 	if prev, err = dsfs.LoadDataset(ctx, r.Store(), prevPath); err != nil {
 		return
 	}
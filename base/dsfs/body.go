@@ -1,14 +1,156 @@
 package dsfs
 
 import (
+	"bytes"
 	"context"
+	"io"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+	"time"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qfs"
 	"github.com/qri-io/qfs/cafs"
 )
 
-// LoadBody loads the data this dataset points to from the store
+// LoadBody loads the data this dataset points to from the store, decrypting
+// the body of private datasets
 func LoadBody(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset) (qfs.File, error) {
-	return store.Get(ctx, ds.BodyPath)
+	return decryptFile(ctx, store, ds.BodyPath)
+}
+
+// MaxMemBodySize is the number of bytes of a body that's held in memory while
+// a dataset is prepared for writing. Larger bodies are spooled to a temp file
+var MaxMemBodySize = 32 << 20
+
+// BodySpool accumulates body bytes in memory, moving them to a temp file once
+// they exceed MaxMemBodySize. The zero value is an empty spool
+type BodySpool struct {
+	buf  bytes.Buffer
+	f    *os.File
+	size int
+}
+
+// Write implements the io.Writer interface
+func (s *BodySpool) Write(p []byte) (int, error) {
+	if s.f == nil && s.buf.Len()+len(p) > MaxMemBodySize {
+		f, err := ioutil.TempFile("", "qri_body_*")
+		if err != nil {
+			return 0, err
+		}
+		s.f = f
+		if _, err := s.buf.WriteTo(f); err != nil {
+			return 0, err
+		}
+	}
+
+	var (
+		n   int
+		err error
+	)
+	if s.f != nil {
+		n, err = s.f.Write(p)
+	} else {
+		n, err = s.buf.Write(p)
+	}
+	s.size += n
+	return n, err
+}
+
+// File returns the spooled bytes as a file. Spools backed by a temp file
+// remove it once the file is read to the end or closed
+func (s *BodySpool) File(name string) (qfs.File, error) {
+	if s.f == nil {
+		return qfs.NewMemfileBytes(name, s.buf.Bytes()), nil
+	}
+	if _, err := s.f.Seek(0, io.SeekStart); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return NewTempBodyFile(s.f, name), nil
+}
+
+// Close drops spooled bytes
+func (s *BodySpool) Close() error {
+	s.buf.Reset()
+	if s.f == nil {
+		return nil
+	}
+	f := NewTempBodyFile(s.f, "")
+	s.f = nil
+	return f.Close()
+}
+
+// TempBodyFile is a body file backed by a temp file that's removed once the
+// file is read to the end or closed
+type TempBodyFile struct {
+	f       *os.File
+	name    string
+	modTime time.Time
+}
+
+var _ qfs.File = (*TempBodyFile)(nil)
+
+// NewTempBodyFile creates a body file named name that reads a temp file from
+// its current offset. TempBodyFile takes ownership of f
+func NewTempBodyFile(f *os.File, name string) *TempBodyFile {
+	return &TempBodyFile{f: f, name: name, modTime: time.Now()}
+}
+
+// Read implements the io.Reader interface
+func (f *TempBodyFile) Read(p []byte) (int, error) {
+	if f.f == nil {
+		return 0, io.EOF
+	}
+	n, err := f.f.Read(p)
+	if err == io.EOF {
+		f.Close()
+	}
+	return n, err
+}
+
+// Close closes & removes the temp file
+func (f *TempBodyFile) Close() error {
+	if f.f == nil {
+		return nil
+	}
+	path := f.f.Name()
+	err := f.f.Close()
+	f.f = nil
+	if rmErr := os.Remove(path); err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// FileName returns the body filename, eg: "body.csv"
+func (f *TempBodyFile) FileName() string {
+	return f.name
+}
+
+// FullPath returns the body filename
+func (f *TempBodyFile) FullPath() string {
+	return f.name
+}
+
+// IsDirectory is always false
+func (f *TempBodyFile) IsDirectory() bool {
+	return false
+}
+
+// NextFile always returns an error
+func (f *TempBodyFile) NextFile() (qfs.File, error) {
+	return nil, qfs.ErrNotDirectory
+}
+
+// ModTime returns the time the body file was created
+func (f *TempBodyFile) ModTime() time.Time {
+	return f.modTime
+}
+
+// MediaType returns a mime type based on the body file extension
+func (f *TempBodyFile) MediaType() string {
+	return mime.TypeByExtension(filepath.Ext(f.name))
 }
@@ -10,7 +10,7 @@ import (
 
 // loadCommit assumes the provided path is valid
 func loadCommit(ctx context.Context, store cafs.Filestore, path string) (st *dataset.Commit, err error) {
-	data, err := fileBytes(store.Get(ctx, path))
+	data, err := fileBytes(ctx, store, path)
 	if err != nil {
 		log.Debug(err.Error())
 		return nil, fmt.Errorf("error loading commit file: %s", err.Error())
@@ -1,8 +1,8 @@
 package dsfs
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -31,7 +31,7 @@ func LoadDataset(ctx context.Context, store cafs.Filestore, path string) (*datas
 	ds, err := LoadDatasetRefs(ctx, store, path)
 	if err != nil {
 		log.Debug(err.Error())
-		return nil, fmt.Errorf("error loading dataset: %s", err.Error())
+		return nil, fmt.Errorf("error loading dataset: %w", err)
 	}
 	if err := DerefDataset(ctx, store, ds); err != nil {
 		log.Debug(err.Error())
@@ -47,7 +47,7 @@ func LoadDatasetRefs(ctx context.Context, store cafs.Filestore, path string) (*d
 	ds := dataset.NewDatasetRef(path)
 
 	pathWithBasename := PackageFilepath(store, path, PackageFileDataset)
-	data, err := fileBytes(store.Get(ctx, pathWithBasename))
+	data, err := fileBytes(ctx, store, pathWithBasename)
 	// if err != nil {
 	// 	return nil, fmt.Errorf("error getting file bytes: %s", err.Error())
 	// }
@@ -55,10 +55,10 @@ func LoadDatasetRefs(ctx context.Context, store cafs.Filestore, path string) (*d
 	// TODO - for some reason files are sometimes coming back empty from IPFS,
 	// every now & then. In the meantime, let's give a second try if data is empty
 	if err != nil || len(data) == 0 {
-		data, err = fileBytes(store.Get(ctx, pathWithBasename))
+		data, err = fileBytes(ctx, store, pathWithBasename)
 		if err != nil {
 			log.Debug(err.Error())
-			return nil, fmt.Errorf("error getting file bytes: %s", err.Error())
+			return nil, fmt.Errorf("error getting file bytes: %w", err)
 		}
 	}
 
@@ -248,8 +248,8 @@ func prepareDataset(store cafs.Filestore, ds, dsPrev *dataset.Dataset, privKey c
 		err error
 		// lock for parallel edits to ds pointer
 		mu sync.Mutex
-		// accumulate reader into a buffer for shasum calculation & passing out another qfs.File
-		buf    bytes.Buffer
+		// accumulate reader into a spool for passing out another qfs.File
+		spool  = &BodySpool{}
 		bf     = ds.BodyFile()
 		bfPrev qfs.File
 	)
@@ -275,7 +275,7 @@ func prepareDataset(store cafs.Filestore, ds, dsPrev *dataset.Dataset, privKey c
 
 	go setErrCount(ds, qfs.NewMemfileReader(bf.FileName(), errR), &mu, done, valChan)
 	go setDepthAndEntryCount(ds, qfs.NewMemfileReader(bf.FileName(), entryR), &mu, done)
-	go setChecksumAndLength(ds, qfs.NewMemfileReader(bf.FileName(), hashR), &buf, &mu, done)
+	go setChecksumAndLength(ds, qfs.NewMemfileReader(bf.FileName(), hashR), spool, &mu, done)
 
 	go func() {
 		// pipes must be manually closed to trigger EOF
@@ -296,10 +296,14 @@ func prepareDataset(store cafs.Filestore, ds, dsPrev *dataset.Dataset, privKey c
 
 	// Join the outstanding tasks, wait until all are cmoplete.
 	for i := 0; i < tasks; i++ {
-		if err := <-done; err != nil {
-			return err
+		if e := <-done; e != nil && err == nil {
+			err = e
 		}
 	}
+	if err != nil {
+		spool.Close()
+		return err
+	}
 
 	// If in strict mode, fail if there were any errors.
 	if ds.Structure.Strict && ds.Structure.ErrCount > 0 {
@@ -307,14 +311,20 @@ func prepareDataset(store cafs.Filestore, ds, dsPrev *dataset.Dataset, privKey c
 		for i, v := range validationErrors {
 			fmt.Fprintf(os.Stderr, "%d) %v\n", i, v)
 		}
+		spool.Close()
 		return fmt.Errorf("strict mode: dataset body did not validate against its schema")
 	}
 
 	if err = generateCommit(dsPrev, ds, privKey, force); err != nil {
+		spool.Close()
 		return err
 	}
 
-	ds.SetBodyFile(qfs.NewMemfileBytes("body."+ds.Structure.Format, buf.Bytes()))
+	body, err := spool.File("body." + ds.Structure.Format)
+	if err != nil {
+		return err
+	}
+	ds.SetBodyFile(body)
 
 	if shouldRender && ds.Viz != nil && ds.Viz.ScriptFile() != nil {
 		// render the viz
@@ -369,7 +379,7 @@ func setErrCount(ds *dataset.Dataset, data qfs.File, mu *sync.Mutex, done chan e
 	}
 
 	// Send validation errors immediately, before main thread blocks.
-	validationErrors, err := validate.EntryReader(er)
+	validationErrors, err := ValidateEntries(er)
 	valChan <- validationErrors
 
 	if err != nil {
@@ -446,15 +456,16 @@ func getDepth(x interface{}) (depth int) {
 }
 
 // setChecksumAndLength
-func setChecksumAndLength(ds *dataset.Dataset, data qfs.File, buf *bytes.Buffer, mu *sync.Mutex, done chan error) {
+func setChecksumAndLength(ds *dataset.Dataset, data qfs.File, spool *BodySpool, mu *sync.Mutex, done chan error) {
 	defer data.Close()
 
-	if _, err := io.Copy(buf, data); err != nil {
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(spool, hash), data); err != nil {
 		done <- err
 		return
 	}
 
-	shasum, err := multihash.Sum(buf.Bytes(), multihash.SHA2_256, -1)
+	shasum, err := multihash.Encode(hash.Sum(nil), multihash.SHA2_256)
 	if err != nil {
 		log.Debug(err.Error())
 		done <- fmt.Errorf("error calculating hash: %s", err.Error())
@@ -462,8 +473,8 @@ func setChecksumAndLength(ds *dataset.Dataset, data qfs.File, buf *bytes.Buffer,
 	}
 
 	mu.Lock()
-	ds.Structure.Checksum = shasum.B58String()
-	ds.Structure.Length = len(buf.Bytes())
+	ds.Structure.Checksum = multihash.Multihash(shasum).B58String()
+	ds.Structure.Length = spool.size
 	mu.Unlock()
 
 	done <- nil
@@ -592,6 +603,8 @@ func WriteDataset(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset
 		adder.AddFile(ctx, mdf)
 	}
 
+	// the transform script is added after recorded fixtures, if any
+	var tsFile qfs.File
 	if ds.Transform != nil {
 		// TODO (b5): this is validation logic, should happen before WriteDataset is ever called
 		// all resources must be references
@@ -604,8 +617,19 @@ func WriteDataset(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset
 		sr := ds.Transform.ScriptFile()
 		ds.Transform.DropTransientValues()
 		if sr != nil {
-			tsFile := qfs.NewMemfileReader(transformScriptFilename, sr)
+			tsFile = qfs.NewMemfileReader(transformScriptFilename, sr)
 			defer tsFile.Close()
+		}
+		if fixtures, ok := ds.Transform.Config[FixturesConfigKey].(*Fixtures); ok {
+			fxdata, err := json.Marshal(fixtures)
+			if err != nil {
+				return "", fmt.Errorf("error marshalling transform fixtures to json: %s", err.Error())
+			}
+			// fixtures are added first, transform.json is written once the
+			// fixtures path is known
+			fileTasks++
+			adder.AddFile(ctx, qfs.NewMemfileBytes(PackageFileFixtures.String(), fxdata))
+		} else if tsFile != nil {
 			fileTasks++
 			adder.AddFile(ctx, tsFile)
 			// NOTE - add wg for the transform.json file ahead of time, which isn't completed
@@ -663,6 +687,22 @@ func WriteDataset(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset
 			case bodyFile.FileName():
 				ds.BodyPath = ao.Path
 				// ds.SetBodyFile(qfs.NewMemfileBytes(bodyFile.FileName(), bodyBytesBuf.Bytes()))
+			case PackageFileFixtures.String():
+				ds.Transform.Config[FixturesConfigKey] = ao.Path
+				if tsFile != nil {
+					// Add the transform script, which adds transform.json once
+					// scriptPath is known
+					fileTasks++
+					adder.AddFile(ctx, tsFile)
+					break
+				}
+				tfdata, err := json.Marshal(ds.Transform)
+				if err != nil {
+					done <- err
+					return
+				}
+				fileTasks++
+				adder.AddFile(ctx, qfs.NewMemfileBytes(PackageFileTransform.String(), tfdata))
 			case transformScriptFilename:
 				ds.Transform.ScriptPath = ao.Path
 				tfdata, err := json.Marshal(ds.Transform)
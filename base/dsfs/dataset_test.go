@@ -277,6 +277,53 @@ func TestCreateDataset(t *testing.T) {
 	// case: previous dataset isn't valid
 }
 
+func TestCreateDatasetSpooledBody(t *testing.T) {
+	ctx := context.Background()
+	privKey, err := crypto.UnmarshalPrivateKey(testPk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	create := func() *dataset.Dataset {
+		tc, err := dstest.NewTestCaseFromDir("testdata/cities")
+		if err != nil {
+			t.Fatal(err)
+		}
+		store := cafs.NewMapstore()
+		path, err := CreateDataset(ctx, store, tc.Input, nil, privKey, false, false, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ds, err := LoadDataset(ctx, store, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := LoadBody(ctx, store, ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != string(tc.Body) {
+			t.Errorf("body mismatch. expected: %q, got: %q", tc.Body, data)
+		}
+		return ds
+	}
+
+	inMem := create()
+	prev := MaxMemBodySize
+	defer func() { MaxMemBodySize = prev }()
+	MaxMemBodySize = 10
+	spooled := create()
+
+	a, b := inMem.Structure, spooled.Structure
+	if a.Checksum != b.Checksum || a.Length != b.Length || a.Entries != b.Entries || a.ErrCount != b.ErrCount {
+		t.Errorf("spooled body structure mismatch.\nin memory: %s %d %d %d\nspooled:   %s %d %d %d", a.Checksum, a.Length, a.Entries, a.ErrCount, b.Checksum, b.Length, b.Entries, b.ErrCount)
+	}
+}
+
 func TestWriteDataset(t *testing.T) {
 	ctx := context.Background()
 	store := cafs.NewMapstore()
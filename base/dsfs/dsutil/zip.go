@@ -13,10 +13,20 @@ import (
 
 	"github.com/ghodss/yaml"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
 	"github.com/qri-io/qfs/cafs"
 	"github.com/qri-io/qri/base/dsfs"
 )
 
+// getDecrypted reads a file from a store, decrypting it if it's encrypted
+func getDecrypted(ctx context.Context, store cafs.Filestore, path string) (qfs.File, error) {
+	f, err := store.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return dsfs.DecryptFile(ctx, f)
+}
+
 // WriteZipArchive generates a zip archive of a dataset and writes it to w
 func WriteZipArchive(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset, format string, ref string, w io.Writer) error {
 	zw := zip.NewWriter(w)
@@ -62,7 +72,7 @@ func WriteZipArchive(ctx context.Context, store cafs.Filestore, ds *dataset.Data
 
 	// Transform script
 	if ds.Transform != nil && ds.Transform.ScriptPath != "" {
-		script, err := store.Get(ctx, ds.Transform.ScriptPath)
+		script, err := getDecrypted(ctx, store, ds.Transform.ScriptPath)
 		if err != nil {
 			return err
 		}
@@ -79,7 +89,7 @@ func WriteZipArchive(ctx context.Context, store cafs.Filestore, ds *dataset.Data
 	// Viz template
 	if ds.Viz != nil {
 		if ds.Viz.ScriptPath != "" {
-			script, err := store.Get(ctx, ds.Viz.ScriptPath)
+			script, err := getDecrypted(ctx, store, ds.Viz.ScriptPath)
 			if err != nil {
 				return err
 			}
@@ -100,7 +110,7 @@ func WriteZipArchive(ctx context.Context, store cafs.Filestore, ds *dataset.Data
 			// long. We should come up with a more permanent fix for this.
 			withTimeout, done := context.WithTimeout(ctx, time.Millisecond*250)
 			defer done()
-			rendered, err := store.Get(withTimeout, ds.Viz.RenderedPath)
+			rendered, err := getDecrypted(withTimeout, store, ds.Viz.RenderedPath)
 			if err != nil {
 				return err
 			}
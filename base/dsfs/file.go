@@ -1,10 +1,12 @@
 package dsfs
 
 import (
+	"context"
 	"encoding/json"
 	"io/ioutil"
 
 	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
 )
 
 // JSONFile is a convenenience method for creating a file from a json.Marshaller
@@ -17,10 +19,16 @@ func JSONFile(name string, m json.Marshaler) (qfs.File, error) {
 	return qfs.NewMemfileBytes(name, data), nil
 }
 
-func fileBytes(file qfs.File, err error) ([]byte, error) {
+func fileBytes(ctx context.Context, store cafs.Filestore, path string) ([]byte, error) {
+	file, err := store.Get(ctx, path)
 	if err != nil {
 		log.Debug(err.Error())
 		return nil, err
 	}
-	return ioutil.ReadAll(file)
+	defer file.Close()
+	data, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return Decrypt(ctx, data)
 }
@@ -0,0 +1,135 @@
+package dsfs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs/cafs"
+)
+
+// FixturesConfigKey is the transform config key that references the bundle
+// of HTTP responses recorded while running a transform. Before a dataset is
+// written the value is a *Fixtures, WriteDataset replaces it with the path
+// of the written bundle
+const FixturesConfigKey = "fixtures"
+
+// ErrNoFixtures is the error for asking a transform that didn't record
+// fixtures for its fixture bundle
+var ErrNoFixtures = fmt.Errorf("this transform has no recorded fixtures")
+
+// Fixtures is a content-addressed bundle of HTTP responses recorded while
+// running a transform, making it possible to re-run the transform without
+// network access
+type Fixtures struct {
+	// Responses maps request keys to recorded responses, see FixtureKey
+	Responses map[string]*FixtureResponse `json:"responses"`
+	// Bodies maps hex-encoded sha256 hashes to response bodies
+	Bodies map[string][]byte `json:"bodies"`
+}
+
+// FixtureResponse is a recorded HTTP response. the body is stored by hash in
+// the fixture bundle
+type FixtureResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// fixtureHeaders lists the response headers kept in fixtures. Fixtures are
+// published with a dataset, so headers that can carry credentials or session
+// state, like Set-Cookie, are never recorded
+var fixtureHeaders = []string{
+	"Content-Disposition",
+	"Content-Language",
+	"Content-Type",
+	"Etag",
+	"Last-Modified",
+}
+
+// NewFixtures creates an empty fixture bundle
+func NewFixtures() *Fixtures {
+	return &Fixtures{
+		Responses: map[string]*FixtureResponse{},
+		Bodies:    map[string][]byte{},
+	}
+}
+
+// FixtureKey identifies a request in a fixture bundle
+func FixtureKey(method, url string) string {
+	if method == "" {
+		method = http.MethodGet
+	}
+	return method + " " + url
+}
+
+// Add records a response. Responses to repeated requests replace earlier
+// responses, bodies are only stored once. Only headers that describe the
+// response body are kept
+func (f *Fixtures) Add(method, url string, status int, header http.Header, body []byte) {
+	sum := sha256.Sum256(body)
+	hash := hex.EncodeToString(sum[:])
+	f.Bodies[hash] = body
+
+	var kept http.Header
+	for _, key := range fixtureHeaders {
+		if vals, ok := header[key]; ok {
+			if kept == nil {
+				kept = http.Header{}
+			}
+			kept[key] = vals
+		}
+	}
+	f.Responses[FixtureKey(method, url)] = &FixtureResponse{
+		Status: status,
+		Header: kept,
+		Body:   hash,
+	}
+}
+
+// Get looks up a recorded response & its body
+func (f *Fixtures) Get(method, url string) (*FixtureResponse, []byte, bool) {
+	res, ok := f.Responses[FixtureKey(method, url)]
+	if !ok {
+		return nil, nil, false
+	}
+	body, ok := f.Bodies[res.Body]
+	return res, body, ok
+}
+
+// Len is the number of recorded responses
+func (f *Fixtures) Len() int {
+	return len(f.Responses)
+}
+
+// LoadFixtures reads a fixture bundle from a store
+func LoadFixtures(ctx context.Context, store cafs.Filestore, path string) (*Fixtures, error) {
+	data, err := fileBytes(ctx, store, path)
+	if err != nil {
+		return nil, fmt.Errorf("error loading fixtures: %s", err.Error())
+	}
+	f := NewFixtures()
+	if err := json.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("error unmarshaling fixtures: %s", err.Error())
+	}
+	return f, nil
+}
+
+// LoadTransformFixtures loads the fixture bundle a transform references,
+// returning ErrNoFixtures if the transform didn't record fixtures
+func LoadTransformFixtures(ctx context.Context, store cafs.Filestore, tf *dataset.Transform) (*Fixtures, error) {
+	if tf == nil {
+		return nil, ErrNoTransform
+	}
+	switch v := tf.Config[FixturesConfigKey].(type) {
+	case *Fixtures:
+		return v, nil
+	case string:
+		return LoadFixtures(ctx, store, v)
+	}
+	return nil, ErrNoFixtures
+}
@@ -10,7 +10,7 @@ import (
 
 // loadMeta assumes the provided path is valid
 func loadMeta(ctx context.Context, store cafs.Filestore, path string) (md *dataset.Meta, err error) {
-	data, err := fileBytes(store.Get(ctx, path))
+	data, err := fileBytes(ctx, store, path)
 	if err != nil {
 		log.Debug(err.Error())
 		return nil, fmt.Errorf("error loading metadata file: %s", err.Error())
@@ -57,6 +57,9 @@ const (
 	PackageFileReadme
 	// PackageFileRenderedReadme is the rendered readme of the dataset
 	PackageFileRenderedReadme
+	// PackageFileFixtures is the bundle of HTTP responses recorded while
+	// running the transform
+	PackageFileFixtures
 )
 
 // filenames maps PackageFile to their filename counterparts
@@ -74,6 +77,7 @@ var filenames = map[PackageFile]string{
 	PackageFileRenderedViz:       "index.html",
 	PackageFileReadme:            "readme.md",
 	PackageFileRenderedReadme:    "readme.html",
+	PackageFileFixtures:          "fixtures.json",
 }
 
 // String implements the io.Stringer interface for PackageFile
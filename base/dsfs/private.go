@@ -0,0 +1,403 @@
+package dsfs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sync"
+
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
+)
+
+// privateFileHeader prefixes every encrypted block. It's followed by the ID of
+// the data key the block is encrypted with, a newline, a random nonce prefix,
+// and a sequence of AES-GCM sealed chunks. Chunks are sealed separately so
+// blocks can be encrypted & decrypted as streams
+var privateFileHeader = []byte("qri-private\n")
+
+const (
+	// privateChunkSize is the size of plaintext sealed in each chunk. Only the
+	// final chunk of a block is shorter, which may be empty
+	privateChunkSize = 64 * 1024
+	// privateNoncePrefixSize is the length of the random part of chunk nonces.
+	// The remaining four bytes count chunks
+	privateNoncePrefixSize = 8
+)
+
+// DataKeySize is the length of data keys in bytes
+const DataKeySize = 32
+
+var (
+	// ErrNoDataKey indicates no keyring in context holds the key an encrypted
+	// block was written with
+	ErrNoDataKey = fmt.Errorf("no key to decrypt private dataset")
+	// ErrInvalidPrivateFile indicates an encrypted block is malformed
+	ErrInvalidPrivateFile = fmt.Errorf("invalid private dataset file")
+)
+
+// Keyring supplies the keys private datasets are encrypted with
+type Keyring interface {
+	// DataKey returns the key for a key identifier, or ErrNoDataKey if the
+	// keyring doesn't have it
+	DataKey(keyID string) ([]byte, error)
+}
+
+// MemKeyring is an in-memory Keyring
+type MemKeyring struct {
+	lk   sync.Mutex
+	keys map[string][]byte
+}
+
+// NewMemKeyring creates a keyring holding a set of data keys
+func NewMemKeyring(keys ...[]byte) *MemKeyring {
+	kr := &MemKeyring{keys: map[string][]byte{}}
+	for _, key := range keys {
+		kr.AddKey(key)
+	}
+	return kr
+}
+
+// AddKey adds a data key to the keyring
+func (kr *MemKeyring) AddKey(key []byte) {
+	kr.lk.Lock()
+	defer kr.lk.Unlock()
+	kr.keys[DataKeyID(key)] = key
+}
+
+// DataKey implements the Keyring interface
+func (kr *MemKeyring) DataKey(keyID string) ([]byte, error) {
+	kr.lk.Lock()
+	defer kr.lk.Unlock()
+	if key, ok := kr.keys[keyID]; ok {
+		return key, nil
+	}
+	return nil, ErrNoDataKey
+}
+
+type keyringsCtxKey struct{}
+
+// WithKeyring returns a context that carries a keyring for decrypting private
+// datasets as they're read, in addition to any keyrings ctx already carries.
+// Encrypted blocks can only be read with a context that holds their key
+func WithKeyring(ctx context.Context, kr Keyring) context.Context {
+	prev, _ := ctx.Value(keyringsCtxKey{}).([]Keyring)
+	krs := make([]Keyring, len(prev), len(prev)+1)
+	copy(krs, prev)
+	return context.WithValue(ctx, keyringsCtxKey{}, append(krs, kr))
+}
+
+func dataKey(ctx context.Context, keyID string) ([]byte, error) {
+	krs, _ := ctx.Value(keyringsCtxKey{}).([]Keyring)
+	for _, kr := range krs {
+		if key, err := kr.DataKey(keyID); err == nil {
+			return key, nil
+		}
+	}
+	return nil, ErrNoDataKey
+}
+
+// NewDataKey generates a random data key, returning the key and its identifier
+func NewDataKey() (keyID string, key []byte, err error) {
+	key = make([]byte, DataKeySize)
+	if _, err = io.ReadFull(rand.Reader, key); err != nil {
+		return "", nil, err
+	}
+	return DataKeyID(key), key, nil
+}
+
+// DataKeyID derives the identifier of a data key. IDs are safe to store in the
+// clear
+func DataKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:16])
+}
+
+// IsEncrypted checks if data is an encrypted block
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, privateFileHeader)
+}
+
+// Encrypt seals plaintext with a data key
+func Encrypt(keyID string, key, plaintext []byte) ([]byte, error) {
+	rdr, err := newEncryptReader(keyID, key, bytes.NewReader(plaintext))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(rdr)
+}
+
+// Decrypt opens an encrypted block with a key from the keyrings ctx carries.
+// Data that isn't encrypted is returned as-is
+func Decrypt(ctx context.Context, data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	rdr, err := newDecryptReader(ctx, bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(rdr)
+}
+
+// DecryptFile wraps a file read from a store, decrypting it if it's an
+// encrypted block. Files are decrypted a chunk at a time as they're read
+func DecryptFile(ctx context.Context, f qfs.File) (qfs.File, error) {
+	if f == nil || f.IsDirectory() {
+		return f, nil
+	}
+
+	rdr := bufio.NewReader(f)
+	if head, _ := rdr.Peek(len(privateFileHeader)); !IsEncrypted(head) {
+		return wrappedFile{File: f, rdr: rdr}, nil
+	}
+
+	dr, err := newDecryptReader(ctx, rdr)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return wrappedFile{File: f, rdr: dr}, nil
+}
+
+// decryptFile gets a file from a store, decrypting it with DecryptFile
+func decryptFile(ctx context.Context, store cafs.Filestore, path string) (qfs.File, error) {
+	f, err := store.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return DecryptFile(ctx, f)
+}
+
+// wrappedFile is a file read through another reader, like a buffer that
+// peeked at its header or a decrypter
+type wrappedFile struct {
+	qfs.File
+	rdr io.Reader
+}
+
+// Read implements the io.Reader interface
+func (f wrappedFile) Read(p []byte) (int, error) {
+	return f.rdr.Read(p)
+}
+
+func dataCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce derives the nonce of the nth chunk in a block. The last chunk is
+// additionally authenticated as final, so truncating a block at a chunk
+// boundary fails to decrypt
+func chunkNonce(prefix []byte, n uint32) []byte {
+	nonce := make([]byte, len(prefix)+4)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[len(prefix):], n)
+	return nonce
+}
+
+func chunkData(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// encryptReader encrypts a stream as it's read
+type encryptReader struct {
+	src    io.Reader
+	gcm    cipher.AEAD
+	prefix []byte
+	n      uint32
+	plain  []byte
+	sealed []byte
+	out    []byte
+	done   bool
+}
+
+func newEncryptReader(keyID string, key []byte, src io.Reader) (*encryptReader, error) {
+	gcm, err := dataCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, privateNoncePrefixSize)
+	if _, err = io.ReadFull(rand.Reader, prefix); err != nil {
+		return nil, err
+	}
+
+	head := make([]byte, 0, len(privateFileHeader)+len(keyID)+1+len(prefix))
+	head = append(head, privateFileHeader...)
+	head = append(head, keyID...)
+	head = append(head, '\n')
+	head = append(head, prefix...)
+
+	return &encryptReader{
+		src:    src,
+		gcm:    gcm,
+		prefix: prefix,
+		plain:  make([]byte, privateChunkSize),
+		out:    head,
+	}, nil
+}
+
+// Read implements the io.Reader interface
+func (r *encryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.seal(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// seal encrypts the next chunk of the source stream. Full chunks are never
+// final, so a stream that ends on a chunk boundary closes with an empty chunk
+func (r *encryptReader) seal() error {
+	n, err := io.ReadFull(r.src, r.plain)
+	final := err == io.EOF || err == io.ErrUnexpectedEOF
+	if err != nil && !final {
+		return err
+	}
+	if r.n == math.MaxUint32 {
+		return fmt.Errorf("private file is too large to encrypt")
+	}
+
+	r.sealed = r.gcm.Seal(r.sealed[:0], chunkNonce(r.prefix, r.n), r.plain[:n], chunkData(final))
+	r.out = r.sealed
+	r.n++
+	r.done = final
+	return nil
+}
+
+// decryptReader decrypts an encrypted block as it's read
+type decryptReader struct {
+	src    io.Reader
+	gcm    cipher.AEAD
+	prefix []byte
+	n      uint32
+	chunk  []byte
+	plain  []byte
+	out    []byte
+	done   bool
+}
+
+// newDecryptReader reads the header of an encrypted block, looking up its
+// key in the keyrings ctx carries
+func newDecryptReader(ctx context.Context, src *bufio.Reader) (*decryptReader, error) {
+	head := make([]byte, len(privateFileHeader))
+	if _, err := io.ReadFull(src, head); err != nil || !IsEncrypted(head) {
+		return nil, ErrInvalidPrivateFile
+	}
+	keyID, err := src.ReadString('\n')
+	if err != nil {
+		return nil, ErrInvalidPrivateFile
+	}
+	key, err := dataKey(ctx, keyID[:len(keyID)-1])
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := dataCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, privateNoncePrefixSize)
+	if _, err := io.ReadFull(src, prefix); err != nil {
+		return nil, ErrInvalidPrivateFile
+	}
+
+	return &decryptReader{
+		src:    src,
+		gcm:    gcm,
+		prefix: prefix,
+		chunk:  make([]byte, privateChunkSize+gcm.Overhead()),
+	}, nil
+}
+
+// Read implements the io.Reader interface
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// open decrypts the next chunk. A short chunk is the final one, and a block
+// that ends without a final chunk has been truncated
+func (r *decryptReader) open() error {
+	n, err := io.ReadFull(r.src, r.chunk)
+	final := err == io.ErrUnexpectedEOF
+	if err == io.EOF {
+		return ErrInvalidPrivateFile
+	} else if err != nil && !final {
+		return err
+	}
+
+	if r.plain, err = r.gcm.Open(r.plain[:0], chunkNonce(r.prefix, r.n), r.chunk[:n], chunkData(final)); err != nil {
+		return ErrInvalidPrivateFile
+	}
+	r.out = r.plain
+	r.n++
+	r.done = final
+	return nil
+}
+
+// NewPrivateStore wraps a store, encrypting every file added to it with a data
+// key. Reads pass through to the underlying store
+func NewPrivateStore(store cafs.Filestore, keyID string, key []byte) cafs.Filestore {
+	return privateStore{Filestore: store, keyID: keyID, key: key}
+}
+
+type privateStore struct {
+	cafs.Filestore
+	keyID string
+	key   []byte
+}
+
+// NewAdder implements the cafs.Filestore interface
+func (ps privateStore) NewAdder(pin, wrap bool) (cafs.Adder, error) {
+	adder, err := ps.Filestore.NewAdder(pin, wrap)
+	if err != nil {
+		return nil, err
+	}
+	return privateAdder{Adder: adder, keyID: ps.keyID, key: ps.key}, nil
+}
+
+type privateAdder struct {
+	cafs.Adder
+	keyID string
+	key   []byte
+}
+
+// AddFile encrypts a file as it's added
+func (pa privateAdder) AddFile(ctx context.Context, f qfs.File) error {
+	rdr, err := newEncryptReader(pa.keyID, pa.key, f)
+	if err != nil {
+		return err
+	}
+	return pa.Adder.AddFile(ctx, qfs.NewMemfileReader(f.FullPath(), rdr))
+}
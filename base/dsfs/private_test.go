@@ -0,0 +1,175 @@
+package dsfs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/qri-io/dataset/dstest"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	keyID, key, err := NewDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext := []byte("licensed data")
+
+	ciphertext, err := Encrypt(keyID, key, plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsEncrypted(ciphertext) || bytes.Contains(ciphertext, plaintext) {
+		t.Fatalf("expected plaintext to be encrypted. got: %q", ciphertext)
+	}
+
+	if _, err := Decrypt(context.Background(), ciphertext); !errors.Is(err, ErrNoDataKey) {
+		t.Errorf("expected decrypting without a keyring to fail with ErrNoDataKey. got: %v", err)
+	}
+	if _, err := Decrypt(WithKeyring(context.Background(), NewMemKeyring()), ciphertext); !errors.Is(err, ErrNoDataKey) {
+		t.Errorf("expected decrypting with a keyring that doesn't hold the key to fail with ErrNoDataKey. got: %v", err)
+	}
+
+	ctx := WithKeyring(WithKeyring(context.Background(), NewMemKeyring()), NewMemKeyring(key))
+	got, err := Decrypt(ctx, ciphertext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plaintext, got) {
+		t.Errorf("decrypted mismatch. expected: %q, got: %q", plaintext, got)
+	}
+
+	f, err := DecryptFile(ctx, qfs.NewMemfileBytes("body.csv", ciphertext))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = ioutil.ReadAll(f); !bytes.Equal(plaintext, got) || f.FileName() != "body.csv" {
+		t.Errorf("decrypted file mismatch. expected: body.csv %q, got: %s %q", plaintext, f.FileName(), got)
+	}
+
+	// files that aren't encrypted pass through
+	if f, err = DecryptFile(ctx, qfs.NewMemfileBytes("body.csv", plaintext)); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = ioutil.ReadAll(f); !bytes.Equal(plaintext, got) {
+		t.Errorf("plain file mismatch. expected: %q, got: %q", plaintext, got)
+	}
+
+	if _, err := Decrypt(ctx, append([]byte("qri-private\n"), keyID...)); err != ErrInvalidPrivateFile {
+		t.Errorf("expected truncated block to be invalid. got: %v", err)
+	}
+}
+
+func TestEncryptChunks(t *testing.T) {
+	keyID, key, err := NewDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithKeyring(context.Background(), NewMemKeyring(key))
+
+	sizes := []int{0, 1, privateChunkSize - 1, privateChunkSize, privateChunkSize + 1, 2 * privateChunkSize, 2*privateChunkSize + privateChunkSize/2}
+	for _, size := range sizes {
+		plaintext := bytes.Repeat([]byte("abcdefg"), size/7+1)[:size]
+		ciphertext, err := Encrypt(keyID, key, plaintext)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		f, err := DecryptFile(ctx, qfs.NewMemfileBytes("body.csv", ciphertext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadAll(f)
+		if err != nil {
+			t.Fatalf("size %d: %s", size, err)
+		}
+		if !bytes.Equal(plaintext, got) {
+			t.Errorf("size %d: decrypted mismatch", size)
+		}
+
+		// dropping the final chunk leaves a block that ends on a chunk boundary
+		chunks := size/privateChunkSize + 1
+		if chunks > 1 {
+			truncated := ciphertext[:len(ciphertext)-(size%privateChunkSize+16)]
+			if _, err := Decrypt(ctx, truncated); err != ErrInvalidPrivateFile {
+				t.Errorf("size %d: expected truncated block to be invalid. got: %v", size, err)
+			}
+		}
+		if size > 0 {
+			if _, err := Decrypt(ctx, ciphertext[:len(ciphertext)-1]); err != ErrInvalidPrivateFile {
+				t.Errorf("size %d: expected short block to be invalid. got: %v", size, err)
+			}
+			tampered := append([]byte{}, ciphertext...)
+			tampered[len(tampered)-size%privateChunkSize-17] ^= 1
+			if _, err := Decrypt(ctx, tampered); err != ErrInvalidPrivateFile {
+				t.Errorf("size %d: expected tampered block to be invalid. got: %v", size, err)
+			}
+		}
+	}
+}
+
+func TestCreatePrivateDataset(t *testing.T) {
+	noKeyCtx := context.Background()
+	store := cafs.NewMapstore()
+	privKey, err := crypto.UnmarshalPrivateKey(testPk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tc, err := dstest.NewTestCaseFromDir("testdata/cities")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyID, key, err := NewDataKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithKeyring(noKeyCtx, NewMemKeyring(key))
+
+	path, err := CreateDataset(ctx, NewPrivateStore(store, keyID, key), tc.Input, nil, privKey, false, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ds, err := LoadDataset(ctx, store, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{PackageFilepath(store, path, PackageFileDataset), ds.BodyPath} {
+		data, err := fileBytesRaw(store.Get(ctx, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !IsEncrypted(data) {
+			t.Errorf("expected %s to be encrypted at rest", p)
+		}
+	}
+
+	body, err := LoadBody(ctx, store, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tc.Body, got) {
+		t.Errorf("body mismatch. expected: %q, got: %q", tc.Body, got)
+	}
+
+	if _, err := LoadDataset(noKeyCtx, store, path); !errors.Is(err, ErrNoDataKey) {
+		t.Errorf("expected loading without the key to fail with ErrNoDataKey. got: %v", err)
+	}
+}
+
+func fileBytesRaw(f qfs.File, err error) ([]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(f)
+}
@@ -11,7 +11,7 @@ import (
 
 // loadReadme assumes the provided path is valid
 func loadReadme(ctx context.Context, store cafs.Filestore, path string) (st *dataset.Readme, err error) {
-	data, err := fileBytes(store.Get(ctx, path))
+	data, err := fileBytes(ctx, store, path)
 	if err != nil {
 		log.Debug(err.Error())
 		return nil, fmt.Errorf("error loading readme file: %s", err.Error())
@@ -34,5 +34,5 @@ func LoadReadmeScript(ctx context.Context, store cafs.Filestore, dspath string)
 		return nil, ErrNoReadme
 	}
 
-	return store.Get(ctx, ds.Readme.ScriptPath)
+	return decryptFile(ctx, store, ds.Readme.ScriptPath)
 }
@@ -10,7 +10,7 @@ import (
 
 // loadStructure assumes path is valid
 func loadStructure(ctx context.Context, store cafs.Filestore, path string) (st *dataset.Structure, err error) {
-	data, err := fileBytes(store.Get(ctx, path))
+	data, err := fileBytes(ctx, store, path)
 	if err != nil {
 		log.Debug(err.Error())
 		return nil, fmt.Errorf("error loading structure file: %s", err.Error())
@@ -11,7 +11,7 @@ import (
 
 // loadTransform assumes the provided path is correct
 func loadTransform(ctx context.Context, store cafs.Filestore, path string) (q *dataset.Transform, err error) {
-	data, err := fileBytes(store.Get(ctx, path))
+	data, err := fileBytes(ctx, store, path)
 	if err != nil {
 		log.Debug(err.Error())
 		return nil, fmt.Errorf("error loading transform raw data: %s", err.Error())
@@ -36,5 +36,5 @@ func LoadTransformScript(ctx context.Context, store cafs.Filestore, dspath strin
 		return nil, ErrNoTransform
 	}
 
-	return store.Get(ctx, ds.Transform.ScriptPath)
+	return decryptFile(ctx, store, ds.Transform.ScriptPath)
 }
@@ -0,0 +1,133 @@
+package dsfs
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/validate"
+	"github.com/qri-io/jsonschema"
+)
+
+// ValidateEntries checks each entry of a body against the structure schema.
+// Bodies with an array top level type that only constrain their items are
+// validated one entry at a time, so validation doesn't hold the body in
+// memory. Other bodies are validated as a whole. Empty CSV cells are null in
+// columns that allow null, CSV has no other way to express a null value
+func ValidateEntries(r dsio.EntryReader) ([]jsonschema.ValError, error) {
+	st := r.Structure()
+	if st.DataFormat() == dataset.CSVDataFormat {
+		if nullable := nullableColumns(st.Schema); nullable != nil {
+			r = nullCellsReader{EntryReader: r, nullable: nullable}
+		}
+	}
+
+	items, ok := itemsSchema(st.Schema)
+	if !ok {
+		return validate.EntryReader(r)
+	}
+
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	jsch := &jsonschema.RootSchema{}
+	if err := json.Unmarshal(data, jsch); err != nil {
+		return nil, err
+	}
+
+	errs := []jsonschema.ValError{}
+	err = dsio.EachEntry(r, func(i int, ent dsio.Entry, err error) error {
+		if err != nil {
+			return fmt.Errorf("error reading row %d: %s", i, err.Error())
+		}
+		// validate values as they'd be read back from JSON
+		data, err := json.Marshal(ent.Value)
+		if err != nil {
+			return fmt.Errorf("error writing row %d: %s", i, err.Error())
+		}
+		var v interface{}
+		if err := json.Unmarshal(data, &v); err != nil {
+			return fmt.Errorf("error writing row %d: %s", i, err.Error())
+		}
+		jsch.Validate("/"+strconv.Itoa(i), v, &errs)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error reading values: %s", err.Error())
+	}
+	return errs, nil
+}
+
+// itemsSchema returns the schema of every entry for schemas of an array that
+// have no constraints other than the schema of their items
+func itemsSchema(sch map[string]interface{}) (map[string]interface{}, bool) {
+	if sch["type"] != "array" {
+		return nil, false
+	}
+	for key := range sch {
+		switch key {
+		case "type", "items", "title", "description", "$schema":
+		default:
+			return nil, false
+		}
+	}
+	items, ok := sch["items"].(map[string]interface{})
+	return items, ok
+}
+
+// nullableColumns reports which columns of a tabular schema allow null values.
+// It returns nil for schemas that don't describe columns
+func nullableColumns(sch map[string]interface{}) []bool {
+	row, ok := sch["items"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	cols, ok := row["items"].([]interface{})
+	if !ok {
+		return nil
+	}
+
+	nullable := make([]bool, len(cols))
+	for i, c := range cols {
+		col, _ := c.(map[string]interface{})
+		if types, ok := col["type"].([]interface{}); ok {
+			for _, t := range types {
+				if t == "null" {
+					nullable[i] = true
+				}
+			}
+		}
+	}
+	return nullable
+}
+
+// nullEmptyCells sets empty cells of a row to null in nullable columns
+func nullEmptyCells(row interface{}, nullable []bool) {
+	cells, ok := row.([]interface{})
+	if !ok {
+		return
+	}
+	for i, cell := range cells {
+		if i < len(nullable) && nullable[i] && cell == "" {
+			cells[i] = nil
+		}
+	}
+}
+
+// nullCellsReader reads rows with empty cells set to null in nullable columns
+type nullCellsReader struct {
+	dsio.EntryReader
+	nullable []bool
+}
+
+// ReadEntry implements the dsio.EntryReader interface
+func (r nullCellsReader) ReadEntry() (dsio.Entry, error) {
+	ent, err := r.EntryReader.ReadEntry()
+	if err == nil {
+		nullEmptyCells(ent.Value, r.nullable)
+	}
+	return ent, err
+}
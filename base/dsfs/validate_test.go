@@ -0,0 +1,74 @@
+package dsfs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/dataset/dstest"
+	"github.com/qri-io/dataset/validate"
+)
+
+func TestValidateEntries(t *testing.T) {
+	for _, dir := range []string{"cities", "craigslist", "movies", "strict_fail"} {
+		tc, err := dstest.NewTestCaseFromDir("testdata/" + dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		st := tc.Input.Structure
+
+		r, err := dsio.NewEntryReader(st, bytes.NewReader(tc.Body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expect, err := validate.EntryReader(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if r, err = dsio.NewEntryReader(st, bytes.NewReader(tc.Body)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ValidateEntries(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(expect, got); diff != "" {
+			t.Errorf("%s: validation errors mismatch (-want +got):\n%s", dir, diff)
+		}
+	}
+}
+
+func TestValidateEntriesEmptyCells(t *testing.T) {
+	st := &dataset.Structure{
+		Format:       "csv",
+		FormatConfig: map[string]interface{}{"headerRow": true},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "pop", "type": []interface{}{"integer", "null"}},
+					map[string]interface{}{"title": "founded", "type": []interface{}{"string", "null"}, "format": "date"},
+					map[string]interface{}{"title": "city", "type": "string", "minLength": 1},
+				},
+			},
+		},
+	}
+	body := "pop,founded,city\n1,1793-08-27,toronto\n,,\n"
+	r, err := dsio.NewEntryReader(st, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs, err := ValidateEntries(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// only the empty cell of the column that doesn't allow null is invalid
+	if len(errs) != 1 || errs[0].PropertyPath != "/1/2" {
+		t.Errorf("expected one error at /1/2. got: %v", errs)
+	}
+}
@@ -10,7 +10,7 @@ import (
 
 // loadViz assumes the provided path is valid
 func loadViz(ctx context.Context, store cafs.Filestore, path string) (st *dataset.Viz, err error) {
-	data, err := fileBytes(store.Get(ctx, path))
+	data, err := fileBytes(ctx, store, path)
 	if err != nil {
 		log.Debug(err.Error())
 		return nil, fmt.Errorf("error loading viz file: %s", err.Error())
@@ -2,11 +2,14 @@ package base
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/logbook/oplog"
 	"github.com/qri-io/qri/repo"
 	reporef "github.com/qri-io/qri/repo/ref"
 )
@@ -15,30 +18,7 @@ import (
 func DatasetLog(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, limit, offset int, loadDatasets bool) ([]dsref.VersionInfo, error) {
 	if book := r.Logbook(); book != nil {
 		if versions, err := book.Versions(ctx, reporef.ConvertToDsref(ref), offset, limit); err == nil {
-			// logs are ok with history not existing. This keeps FSI interaction behaviour consistent
-			// TODO (b5) - we should consider having "empty history" be an ok state, instead of marking as an error
-			if len(versions) == 0 {
-				return nil, repo.ErrNoHistory
-			}
-			// Logbook doesn't store the CommitMessage (see infoFromOp in logbook/logbook.go), so we
-			// need to load each dataset, and assign the CommitMessage field.
-			for i, v := range versions {
-				if v.Path != "" {
-					local, err := r.Store().Has(ctx, v.Path)
-					if err != nil {
-						continue
-					}
-					if local {
-						if ds, err := dsfs.LoadDataset(ctx, r.Store(), v.Path); err == nil {
-							if ds.Commit != nil {
-								versions[i].CommitMessage = ds.Commit.Message
-							}
-						}
-					}
-					versions[i].Foreign = !local
-				}
-			}
-			return versions, nil
+			return fillLogbookVersions(ctx, r, versions)
 		}
 	}
 
@@ -63,6 +43,56 @@ func DatasetLog(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, limit,
 	return items, err
 }
 
+// DatasetBranchLog fetches the history of a named logbook branch. Branch
+// history is only kept in the logbook
+func DatasetBranchLog(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, branch string, limit, offset int) ([]dsref.VersionInfo, error) {
+	if branch == "" || branch == logbook.DefaultBranchName {
+		return DatasetLog(ctx, r, ref, limit, offset, true)
+	}
+	book := r.Logbook()
+	if book == nil {
+		return nil, logbook.ErrNoLogbook
+	}
+
+	versions, err := book.BranchVersions(ctx, reporef.ConvertToDsref(ref), branch, offset, limit)
+	if err != nil {
+		if err == oplog.ErrNotFound {
+			return nil, fmt.Errorf("branch '%s' not found", branch)
+		}
+		return nil, err
+	}
+	return fillLogbookVersions(ctx, r, versions)
+}
+
+// fillLogbookVersions adds details to versions read from logbook that only
+// exist in the store
+func fillLogbookVersions(ctx context.Context, r repo.Repo, versions []dsref.VersionInfo) ([]dsref.VersionInfo, error) {
+	// logs are ok with history not existing. This keeps FSI interaction behaviour consistent
+	// TODO (b5) - we should consider having "empty history" be an ok state, instead of marking as an error
+	if len(versions) == 0 {
+		return nil, repo.ErrNoHistory
+	}
+	// Logbook doesn't store the CommitMessage (see infoFromOp in logbook/logbook.go), so we
+	// need to load each dataset, and assign the CommitMessage field.
+	for i, v := range versions {
+		if v.Path != "" {
+			local, err := r.Store().Has(ctx, v.Path)
+			if err != nil {
+				continue
+			}
+			if local {
+				if ds, err := dsfs.LoadDataset(ctx, r.Store(), v.Path); err == nil {
+					if ds.Commit != nil {
+						versions[i].CommitMessage = ds.Commit.Message
+					}
+				}
+			}
+			versions[i].Foreign = !local
+		}
+	}
+	return versions, nil
+}
+
 // DatasetLogFromHistory fetches the history of changes to a dataset by walking
 // backwards through dataset commits. if loadDatasets is true, dataset
 // information will be populated
@@ -0,0 +1,405 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/deepdiff"
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/base/merge"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// ErrNoCommonAncestor indicates two versions don't share any history
+var ErrNoCommonAncestor = fmt.Errorf("versions have no common ancestor")
+
+// CommonAncestor finds the nearest version that both a and b descend from.
+// History is followed through each version's PreviousPath, and through the
+// merge parents of merge versions. mergeParents maps the path of a merge
+// version to the paths merged into it, and may be nil
+func CommonAncestor(ctx context.Context, store cafs.Filestore, a, b string, mergeParents map[string][]string) (string, error) {
+	ancestors := map[string]bool{}
+	err := walkHistory(ctx, store, a, mergeParents, func(path string) bool {
+		ancestors[path] = true
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+
+	found := ""
+	err = walkHistory(ctx, store, b, mergeParents, func(path string) bool {
+		if ancestors[path] {
+			found = path
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+	if found == "" {
+		return "", ErrNoCommonAncestor
+	}
+	return found, nil
+}
+
+// walkHistory visits the history of a version breadth-first, starting with the
+// version itself. Walking stops when visit returns false
+func walkHistory(ctx context.Context, store cafs.Filestore, path string, mergeParents map[string][]string, visit func(path string) bool) error {
+	seen := map[string]bool{}
+	queue := []string{path}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if p == "" || p == "/" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		if !visit(p) {
+			return nil
+		}
+
+		ds, err := dsfs.LoadDatasetRefs(ctx, store, p)
+		if err != nil {
+			return err
+		}
+		queue = append(queue, ds.PreviousPath)
+		queue = append(queue, mergeParents[p]...)
+	}
+	return nil
+}
+
+// MergeResult is the outcome of a three-way merge of two dataset versions
+type MergeResult struct {
+	// Paths of the common ancestor and the two merged versions
+	Ancestor, Ours, Theirs string
+	// Dataset is the merged dataset, ready to save as the version that follows
+	// Ours. Conflicting values are replaced with conflict markers, conflicts in
+	// fields that can't hold a marker, like readme & viz scripts, take the
+	// value from Ours
+	Dataset *dataset.Dataset
+	// Prev is the dataset at Ours
+	Prev *dataset.Dataset
+	// Conflicts lists values both sides changed in different ways. Paths are
+	// rooted at the component name, eg: "/meta/title", "/body/2/0"
+	Conflicts []merge.Conflict
+	// OursStat and TheirsStat summarize the changes each side made since the
+	// common ancestor
+	OursStat, TheirsStat *deepdiff.Stats
+}
+
+// mergeableStructure is the subset of a structure component that merges
+// operate on. All other structure fields are derived from the body
+type mergeableStructure struct {
+	Format       string                 `json:"format,omitempty"`
+	FormatConfig map[string]interface{} `json:"formatConfig,omitempty"`
+	Schema       map[string]interface{} `json:"schema,omitempty"`
+	Strict       bool                   `json:"strict,omitempty"`
+}
+
+// MergeDatasets three-way merges the versions at ours and theirs against their
+// common ancestor. meta, structure and body components merge value-by-value,
+// readme & viz components are merged whole. Transforms are not carried into
+// the merged dataset, a transform must be created from scratch with each new
+// version
+func MergeDatasets(ctx context.Context, r repo.Repo, ancestor, ours, theirs string) (*MergeResult, error) {
+	res := &MergeResult{Ancestor: ancestor, Ours: ours, Theirs: theirs}
+
+	ancestorDs, err := dsfs.LoadDataset(ctx, r.Store(), ancestor)
+	if err != nil {
+		return nil, err
+	}
+	theirsDs, err := dsfs.LoadDataset(ctx, r.Store(), theirs)
+	if err != nil {
+		return nil, err
+	}
+	prev, mutable, err := loadPreviousForSave(ctx, r, ours)
+	if err != nil {
+		return nil, err
+	}
+	res.Prev = prev
+
+	baseDoc, err := mergeDocument(ctx, r.Store(), ancestorDs)
+	if err != nil {
+		return nil, err
+	}
+	oursDoc, err := mergeDocument(ctx, r.Store(), prev)
+	if err != nil {
+		return nil, err
+	}
+	theirsDoc, err := mergeDocument(ctx, r.Store(), theirsDs)
+	if err != nil {
+		return nil, err
+	}
+
+	dd := deepdiff.New()
+	if res.OursStat, err = dd.Stat(ctx, baseDoc, oursDoc); err != nil {
+		return nil, err
+	}
+	if res.TheirsStat, err = dd.Stat(ctx, baseDoc, theirsDoc); err != nil {
+		return nil, err
+	}
+
+	merged, conflicts := merge.Values("", baseDoc, oursDoc, theirsDoc)
+	res.Conflicts = conflicts
+	doc, ok := merged.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected merge result type: %T", merged)
+	}
+
+	if len(conflicts) > 0 {
+		doc = markConflicts(doc, conflicts)
+	}
+	if err = applyMergeDocument(mutable, doc, oursDoc, theirsDs); err != nil {
+		return nil, err
+	}
+	res.Dataset = mutable
+	return res, nil
+}
+
+// markConflicts replaces conflicting values of a merged document with conflict
+// markers. markers in meta & structure components are only kept if the
+// component still decodes
+func markConflicts(doc map[string]interface{}, conflicts []merge.Conflict) map[string]interface{} {
+	marked := make(map[string]interface{}, len(doc))
+	for k, v := range doc {
+		marked[k] = v
+	}
+	if body, ok := doc["body"]; ok {
+		switch b := merge.Mark("/body", body, conflicts).(type) {
+		case []interface{}, map[string]interface{}:
+			marked["body"] = b
+		}
+	}
+
+	decoders := map[string]func() interface{}{
+		"meta":      func() interface{} { return &dataset.Meta{} },
+		"structure": func() interface{} { return &mergeableStructure{} },
+	}
+	for name, newComponent := range decoders {
+		v, ok := doc[name]
+		if !ok {
+			continue
+		}
+		prefix := "/" + name
+		for i := len(conflicts) - 1; i >= 0; i-- {
+			candidate := merge.Mark(prefix, v, conflicts[i:i+1])
+			if fromMergeValue(candidate, newComponent()) == nil {
+				v = candidate
+			}
+		}
+		marked[name] = v
+	}
+	return marked
+}
+
+// mergeDocument projects the mergeable parts of a dataset into a single value.
+// readme & viz components are represented by the content-addressed path of
+// their script
+func mergeDocument(ctx context.Context, store cafs.Filestore, ds *dataset.Dataset) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	if ds.Meta != nil {
+		v, err := toMergeValue(ds.Meta)
+		if err != nil {
+			return nil, err
+		}
+		doc["meta"] = v
+	}
+	if ds.Structure != nil {
+		v, err := toMergeValue(mergeableStructure{
+			Format:       ds.Structure.Format,
+			FormatConfig: ds.Structure.FormatConfig,
+			Schema:       ds.Structure.Schema,
+			Strict:       ds.Structure.Strict,
+		})
+		if err != nil {
+			return nil, err
+		}
+		doc["structure"] = v
+	}
+	if ds.Readme != nil {
+		doc["readme"] = ds.Readme.ScriptPath
+	}
+	if ds.Viz != nil {
+		doc["viz"] = ds.Viz.ScriptPath
+	}
+	if ds.BodyPath != "" {
+		f, err := dsfs.LoadBody(ctx, store, ds)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		rdr, err := dsio.NewEntryReader(ds.Structure, f)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := ReadEntries(rdr)
+		if err != nil {
+			return nil, err
+		}
+		// normalize to JSON types so bodies read from different formats compare
+		if doc["body"], err = toMergeValue(entries); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// applyMergeDocument writes a merged document to ds, which starts as a copy of
+// the ours side of the merge
+func applyMergeDocument(ds *dataset.Dataset, doc, oursDoc map[string]interface{}, theirs *dataset.Dataset) error {
+	ds.Meta = nil
+	if v, ok := doc["meta"]; ok {
+		ds.Meta = &dataset.Meta{}
+		if err := fromMergeValue(v, ds.Meta); err != nil {
+			return err
+		}
+	}
+
+	v, ok := doc["structure"]
+	if !ok || ds.Structure == nil {
+		return fmt.Errorf("merged dataset has no structure")
+	}
+	st := mergeableStructure{}
+	if err := fromMergeValue(v, &st); err != nil {
+		return err
+	}
+	ds.Structure.Format = st.Format
+	ds.Structure.FormatConfig = st.FormatConfig
+	ds.Structure.Schema = st.Schema
+	ds.Structure.Strict = st.Strict
+
+	switch doc["readme"] {
+	case oursDoc["readme"]:
+		// keep our readme
+	case nil:
+		ds.Readme = nil
+	default:
+		ds.Readme = theirs.Readme
+	}
+
+	switch doc["viz"] {
+	case oursDoc["viz"]:
+		// keep our viz
+	case nil:
+		ds.Viz = nil
+	default:
+		ds.Viz = theirs.Viz
+	}
+
+	if !reflect.DeepEqual(doc["body"], oursDoc["body"]) {
+		data, err := encodeBody(ds.Structure, doc["body"])
+		if err != nil {
+			return err
+		}
+		ds.BodyPath = ""
+		ds.SetBodyFile(qfs.NewMemfileBytes("body."+ds.Structure.Format, data))
+	}
+	return nil
+}
+
+// encodeBody writes a body value in the format described by st
+func encodeBody(st *dataset.Structure, body interface{}) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w, err := dsio.NewEntryWriter(st, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	switch b := body.(type) {
+	case []interface{}:
+		for i, v := range b {
+			if err = w.WriteEntry(dsio.Entry{Index: i, Value: v}); err != nil {
+				return nil, err
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(b))
+		for k := range b {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err = w.WriteEntry(dsio.Entry{Key: k, Value: b[k]}); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("merged body must be an array or object, got %T", body)
+	}
+
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func toMergeValue(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var res interface{}
+	err = json.Unmarshal(data, &res)
+	return res, err
+}
+
+func fromMergeValue(v interface{}, dst interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dst)
+}
+
+// SaveMerge writes the dataset of a conflict-free merge as a new version on a
+// logbook branch. The new version follows res.Ours, and records res.Theirs as a
+// merge parent
+func SaveMerge(ctx context.Context, r repo.Repo, streams ioes.IOStreams, res *MergeResult, branch, title, message string, dryRun bool) (ref reporef.DatasetRef, err error) {
+	if len(res.Conflicts) > 0 {
+		return ref, fmt.Errorf("cannot save a merge with %d unresolved conflicts", len(res.Conflicts))
+	}
+
+	pro, err := r.Profile()
+	if err != nil {
+		return ref, err
+	}
+
+	ds := res.Dataset
+	ds.Commit = &dataset.Commit{Title: title, Message: message}
+	ds.PreviousPath = res.Ours
+	if err = InferValues(pro, ds); err != nil {
+		return ref, err
+	}
+
+	if dryRun {
+		streams.PrintErr("🏃🏽‍♀️ dry run\n")
+		// dry-runs store to an in-memory repo
+		if r, err = repo.NewMemRepo(pro, cafs.NewMapstore(), r.Filesystem(), profile.NewMemStore()); err != nil {
+			return ref, err
+		}
+	}
+
+	// merges are always saved with force, a merge that brings no new changes
+	// still records the merged history
+	sw := SaveDatasetSwitches{
+		Branch:      branch,
+		MergeParent: res.Theirs,
+		DryRun:      dryRun,
+		Pin:         true,
+		Force:       true,
+	}
+	return createDataset(ctx, r, streams, ds, res.Prev, sw)
+}
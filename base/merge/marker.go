@@ -0,0 +1,120 @@
+package merge
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MarkerPrefix begins every conflict marker. Merged results that contain it
+// have unresolved conflicts. Markers avoid characters that JSON encoders
+// escape, so they can be found in the text of any format
+const MarkerPrefix = "======= conflict"
+
+// Marker formats a conflict as a single line of text that shows the value of
+// each side, for use in place of the conflicting value
+func (c Conflict) Marker() string {
+	return fmt.Sprintf("%s: ours %s, base %s, theirs %s =======", MarkerPrefix, markerValue(c.Ours), markerValue(c.Base), markerValue(c.Theirs))
+}
+
+func markerValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// Mark replaces the conflicting values of a merged result with conflict
+// markers. Block conflicts replace the elements of ours with a single marker
+// element, a row of the same width when elements are arrays. Conflict paths are
+// trimmed of prefix before they're resolved against merged, conflicts with
+// paths that don't resolve are skipped. merged is not modified, values along
+// the path to each marker are copied
+func Mark(prefix string, merged interface{}, conflicts []Conflict) interface{} {
+	// mark in reverse so replacing a block doesn't move the positions of
+	// earlier conflicts
+	for i := len(conflicts) - 1; i >= 0; i-- {
+		c := conflicts[i]
+		if !strings.HasPrefix(c.Path, prefix) {
+			continue
+		}
+		var segs []string
+		if rel := strings.TrimPrefix(c.Path, prefix); rel != "" {
+			segs = strings.Split(strings.TrimPrefix(rel, "/"), "/")
+		}
+		merged = mark(merged, segs, c)
+	}
+	return merged
+}
+
+func mark(v interface{}, segs []string, c Conflict) interface{} {
+	if len(segs) == 0 {
+		if c.Block {
+			return v
+		}
+		return c.Marker()
+	}
+
+	switch x := v.(type) {
+	case map[string]interface{}:
+		key := unescapeKey(segs[0])
+		child, ok := x[key]
+		if !ok && len(segs) > 1 {
+			return v
+		}
+		cp := make(map[string]interface{}, len(x))
+		for k, val := range x {
+			cp[k] = val
+		}
+		cp[key] = mark(child, segs[1:], c)
+		return cp
+	case []interface{}:
+		i, err := strconv.Atoi(segs[0])
+		if err != nil || i < 0 || i > len(x) {
+			return v
+		}
+		if len(segs) == 1 && c.Block {
+			ours, _ := c.Ours.([]interface{})
+			if i+len(ours) > len(x) {
+				return v
+			}
+			cp := make([]interface{}, 0, len(x)-len(ours)+1)
+			cp = append(cp, x[:i]...)
+			cp = append(cp, markerElement(c))
+			return append(cp, x[i+len(ours):]...)
+		}
+		if i == len(x) {
+			return v
+		}
+		cp := make([]interface{}, len(x))
+		copy(cp, x)
+		cp[i] = mark(x[i], segs[1:], c)
+		return cp
+	}
+	return v
+}
+
+// markerElement creates the array element that replaces a block conflict.
+// when elements are arrays the marker is the first value of a row as wide as
+// the conflicting rows, so tabular bodies keep their shape
+func markerElement(c Conflict) interface{} {
+	for _, side := range []interface{}{c.Ours, c.Theirs, c.Base} {
+		elements, _ := side.([]interface{})
+		if len(elements) == 0 {
+			continue
+		}
+		if row, ok := elements[0].([]interface{}); ok && len(row) > 0 {
+			marked := make([]interface{}, len(row))
+			marked[0] = c.Marker()
+			return marked
+		}
+		break
+	}
+	return c.Marker()
+}
+
+func unescapeKey(key string) string {
+	return strings.Replace(strings.Replace(key, "~1", "/", -1), "~0", "~", -1)
+}
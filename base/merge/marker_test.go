@@ -0,0 +1,60 @@
+package merge
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMark(t *testing.T) {
+	cases := []struct {
+		description        string
+		base, ours, theirs string
+		expect             string
+	}{
+		{"conflicting field",
+			`{"a":{"b/c":1}}`, `{"a":{"b/c":2}}`, `{"a":{"b/c":3}}`,
+			`{"a":{"b/c":"======= conflict: ours 2, base 1, theirs 3 ======="}}`},
+		{"delete vs modify",
+			`{"a":1}`, `{}`, `{"a":2}`,
+			`{"a":"======= conflict: ours null, base 1, theirs 2 ======="}`},
+		{"same field of the same row",
+			`[[1,"a"],[2,"b"]]`, `[[1,"a"],[2,"c"]]`, `[[1,"a"],[2,"d"]]`,
+			`[[1,"a"],[2,"======= conflict: ours \"c\", base \"b\", theirs \"d\" ======="]]`},
+		{"rows inserted at the same position",
+			`[[1],[3]]`, `[[1],[2],[3]]`, `[[1],[2.5],[2.6],[3]]`,
+			`[[1],["======= conflict: ours [[2]], base [], theirs [[2.5],[2.6]] ======="],[3]]`},
+		{"row removed & edited",
+			`[[1,"a"],[2,"b"],[3,"c"]]`, `[[1,"a"],[3,"c"]]`, `[[1,"a"],[2,"z"],[3,"c"]]`,
+			`[[1,"a"],["======= conflict: ours [], base [[2,\"b\"]], theirs [[2,\"z\"]] =======",null],[3,"c"]]`},
+		{"conflicts in rows before & after a block",
+			`[[1,"a"],[3,"c"],[4,"d"]]`, `[[1,"A"],[2,"b"],[3,"c"],[4,"D"]]`, `[[1,"Z"],[2.5,"x"],[3,"c"],[4,"X"]]`,
+			`[["======= conflict: ours [[1,\"A\"],[2,\"b\"]], base [[1,\"a\"]], theirs [[1,\"Z\"],[2.5,\"x\"]] =======",null],[3,"c"],[4,"======= conflict: ours \"D\", base \"d\", theirs \"X\" ======="]]`},
+	}
+
+	for _, c := range cases {
+		var base, ours, theirs, expect interface{}
+		mustUnmarshal(t, c.base, &base)
+		mustUnmarshal(t, c.ours, &ours)
+		mustUnmarshal(t, c.theirs, &theirs)
+		mustUnmarshal(t, c.expect, &expect)
+
+		merged, conflicts := Values("/body", base, ours, theirs)
+		before, err := json.Marshal(merged)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := Mark("/body", merged, conflicts)
+		if diff := cmp.Diff(expect, got); diff != "" {
+			t.Errorf("case %q marked result mismatch (-want +got):\n%s", c.description, diff)
+		}
+		after, err := json.Marshal(merged)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(before) != string(after) {
+			t.Errorf("case %q: marking modified the merged result", c.description)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+// Package merge combines concurrent changes to structured data. Merges are
+// three-way: changes on each side are found by comparing against a common
+// ancestor. Changes that don't overlap are combined, overlapping changes that
+// disagree are reported as conflicts
+package merge
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maxLCSCells caps the size of the table used to align array elements. Arrays
+// that would need a larger table are merged as a single block after trimming
+// common leading & trailing elements
+const maxLCSCells = 1 << 24
+
+// Conflict is a location where both sides of a merge changed the same value in
+// different ways
+type Conflict struct {
+	// Path is a slash-delimited path to the conflicting value in the merged
+	// result, eg: "/body/3/1". array indices refer to positions in the merged
+	// result
+	Path string `json:"path"`
+	// Values from each side of the merge. Deleted values are nil. When a
+	// conflict spans multiple array elements, each field is a slice of those
+	// elements
+	Base   interface{} `json:"base"`
+	Ours   interface{} `json:"ours"`
+	Theirs interface{} `json:"theirs"`
+	// Block is true for conflicts between runs of array elements. Base, Ours &
+	// Theirs are slices of elements, and Path is the position in the merged
+	// result where the elements of Ours begin
+	Block bool `json:"block,omitempty"`
+}
+
+// String implements the fmt.Stringer interface
+func (c Conflict) String() string {
+	return fmt.Sprintf("%s: base=%s ours=%s theirs=%s", c.Path, short(c.Base), short(c.Ours), short(c.Theirs))
+}
+
+func short(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	if len(data) > 60 {
+		return string(data[:57]) + "..."
+	}
+	return string(data)
+}
+
+// absent marks a map key that doesn't exist on one side of a merge
+type absent struct{}
+
+var missing = absent{}
+
+// Values three-way merges ours and theirs, which are both derived from base.
+// Values must be composed of the types produced by decoding JSON into an
+// interface{}, or values produced by a dsio.EntryReader. Where both sides
+// conflict the merged result takes the value from ours, prefix is prepended to
+// the path of all returned conflicts
+func Values(prefix string, base, ours, theirs interface{}) (merged interface{}, conflicts []Conflict) {
+	return mergeValues(prefix, base, ours, theirs)
+}
+
+func mergeValues(path string, base, ours, theirs interface{}) (interface{}, []Conflict) {
+	switch {
+	case equal(ours, theirs):
+		return ours, nil
+	case equal(base, ours):
+		return theirs, nil
+	case equal(base, theirs):
+		return ours, nil
+	}
+
+	if o, ok := ours.(map[string]interface{}); ok {
+		if t, ok := theirs.(map[string]interface{}); ok {
+			b, ok := base.(map[string]interface{})
+			if !ok {
+				b = map[string]interface{}{}
+			}
+			return mergeMaps(path, b, o, t)
+		}
+	}
+	if o, ok := ours.([]interface{}); ok {
+		if t, ok := theirs.([]interface{}); ok {
+			b, ok := base.([]interface{})
+			if !ok {
+				b = []interface{}{}
+			}
+			return mergeSlices(path, b, o, t)
+		}
+	}
+
+	return ours, []Conflict{newConflict(path, base, ours, theirs)}
+}
+
+func newConflict(path string, base, ours, theirs interface{}) Conflict {
+	return Conflict{
+		Path:   path,
+		Base:   present(base),
+		Ours:   present(ours),
+		Theirs: present(theirs),
+	}
+}
+
+func present(v interface{}) interface{} {
+	if v == missing {
+		return nil
+	}
+	return v
+}
+
+func mergeMaps(path string, base, ours, theirs map[string]interface{}) (interface{}, []Conflict) {
+	keys := map[string]struct{}{}
+	for _, m := range []map[string]interface{}{base, ours, theirs} {
+		for k := range m {
+			keys[k] = struct{}{}
+		}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var conflicts []Conflict
+	merged := make(map[string]interface{}, len(sorted))
+	for _, k := range sorted {
+		v, cs := mergeValues(path+"/"+escapeKey(k), lookup(base, k), lookup(ours, k), lookup(theirs, k))
+		conflicts = append(conflicts, cs...)
+		if v != missing {
+			merged[k] = v
+		}
+	}
+	return merged, conflicts
+}
+
+func lookup(m map[string]interface{}, key string) interface{} {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	return missing
+}
+
+// escapeKey escapes a map key for use in a path, following JSON pointer rules
+func escapeKey(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
+
+// mergeSlices performs a diff3-style merge of array elements. elements of base
+// that both sides left untouched act as stable anchors, runs of elements
+// between anchors are resolved as blocks
+func mergeSlices(path string, base, ours, theirs []interface{}) (interface{}, []Conflict) {
+	bk, ok, tk := elementKeys(base), elementKeys(ours), elementKeys(theirs)
+	toOurs := alignKeys(bk, ok)
+	toTheirs := alignKeys(bk, tk)
+
+	var (
+		conflicts []Conflict
+		merged    = make([]interface{}, 0, len(ours))
+		i, o, t   int
+	)
+	for {
+		k := i
+		for k < len(base) && (toOurs[k] == -1 || toTheirs[k] == -1) {
+			k++
+		}
+		oEnd, tEnd := len(ours), len(theirs)
+		if k < len(base) {
+			oEnd, tEnd = toOurs[k], toTheirs[k]
+		}
+
+		var cs []Conflict
+		merged, cs = mergeBlock(path, merged, base[i:k], ours[o:oEnd], theirs[t:tEnd], bk[i:k], ok[o:oEnd], tk[t:tEnd])
+		conflicts = append(conflicts, cs...)
+
+		if k == len(base) {
+			break
+		}
+		merged = append(merged, ours[oEnd])
+		i, o, t = k+1, oEnd+1, tEnd+1
+	}
+	return merged, conflicts
+}
+
+// mergeBlock resolves a run of elements that changed on at least one side,
+// appending the result to merged
+func mergeBlock(path string, merged, base, ours, theirs []interface{}, bk, ok, tk []string) ([]interface{}, []Conflict) {
+	switch {
+	case keysEqual(ok, tk):
+		return append(merged, ours...), nil
+	case keysEqual(bk, ok):
+		return append(merged, theirs...), nil
+	case keysEqual(bk, tk):
+		return append(merged, ours...), nil
+	}
+
+	if len(base) == len(ours) && len(base) == len(theirs) {
+		// same number of elements modified on both sides, merge element-wise to
+		// find field-level conflicts
+		var conflicts []Conflict
+		for j := range base {
+			v, cs := mergeValues(fmt.Sprintf("%s/%d", path, len(merged)), base[j], ours[j], theirs[j])
+			conflicts = append(conflicts, cs...)
+			merged = append(merged, v)
+		}
+		return merged, conflicts
+	}
+
+	if len(base) == len(theirs) && isSubsequence(ok, bk) {
+		return mergeDeletions(path, merged, base, theirs, bk, ok, tk, true)
+	}
+	if len(base) == len(ours) && isSubsequence(tk, bk) {
+		return mergeDeletions(path, merged, base, ours, bk, tk, ok, false)
+	}
+
+	c := Conflict{
+		Path:   fmt.Sprintf("%s/%d", path, len(merged)),
+		Base:   base,
+		Ours:   ours,
+		Theirs: theirs,
+		Block:  true,
+	}
+	return append(merged, ours...), []Conflict{c}
+}
+
+// mergeDeletions combines a block where one side only removed elements and the
+// other side modified elements in place. Removing an element the other side
+// modified is a conflict
+func mergeDeletions(path string, merged, base, modified []interface{}, bk, dk, mk []string, oursDeleted bool) ([]interface{}, []Conflict) {
+	var conflicts []Conflict
+	kept := alignKeys(bk, dk)
+	for j := range base {
+		if kept[j] != -1 {
+			merged = append(merged, modified[j])
+			continue
+		}
+		if mk[j] == bk[j] {
+			continue
+		}
+		c := Conflict{
+			Path:   fmt.Sprintf("%s/%d", path, len(merged)),
+			Base:   []interface{}{base[j]},
+			Ours:   []interface{}{},
+			Theirs: []interface{}{},
+			Block:  true,
+		}
+		if oursDeleted {
+			c.Theirs = []interface{}{modified[j]}
+		} else {
+			c.Ours = []interface{}{modified[j]}
+			merged = append(merged, modified[j])
+		}
+		conflicts = append(conflicts, c)
+	}
+	return merged, conflicts
+}
+
+// isSubsequence reports whether every element of sub appears in seq, in order
+func isSubsequence(sub, seq []string) bool {
+	i := 0
+	for _, el := range seq {
+		if i < len(sub) && sub[i] == el {
+			i++
+		}
+	}
+	return i == len(sub)
+}
+
+// elementKeys creates comparable keys for array elements. encoding/json sorts
+// map keys, so equal values produce equal keys
+func elementKeys(elements []interface{}) []string {
+	keys := make([]string, len(elements))
+	for i, el := range elements {
+		data, err := json.Marshal(el)
+		if err != nil {
+			data = []byte(fmt.Sprintf("%#v", el))
+		}
+		keys[i] = string(data)
+	}
+	return keys
+}
+
+// alignKeys matches elements of a with elements of b, returning the index in
+// b of each element of a, or -1 for elements of a that have no match. Matches
+// are found with a longest-common-subsequence
+func alignKeys(a, b []string) []int {
+	match := make([]int, len(a))
+	for i := range match {
+		match[i] = -1
+	}
+
+	// match common leading & trailing elements
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		match[pre] = pre
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		match[len(a)-1-suf] = len(b) - 1 - suf
+		suf++
+	}
+
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+	if len(ma) == 0 || len(mb) == 0 || len(ma)*len(mb) > maxLCSCells {
+		return match
+	}
+
+	// lengths[i][j] is the length of the LCS of ma[i:] and mb[j:]
+	lengths := make([][]int, len(ma)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+	for i, j := 0, 0; i < len(ma) && j < len(mb); {
+		switch {
+		case ma[i] == mb[j]:
+			match[pre+i] = pre + j
+			i++
+			j++
+		case lengths[i+1][j] >= lengths[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return match
+}
+
+func keysEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equal(a, b interface{}) bool {
+	if a == missing || b == missing {
+		return a == b
+	}
+	return reflect.DeepEqual(a, b)
+}
@@ -0,0 +1,94 @@
+package merge
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValues(t *testing.T) {
+	cases := []struct {
+		description         string
+		base, ours, theirs  string
+		expect              string
+		expectConflictPaths []string
+	}{
+		{"no changes",
+			`{"a":1}`, `{"a":1}`, `{"a":1}`,
+			`{"a":1}`, nil},
+		{"ours only",
+			`{"a":1}`, `{"a":2}`, `{"a":1}`,
+			`{"a":2}`, nil},
+		{"theirs only",
+			`{"a":1}`, `{"a":1}`, `{"a":2}`,
+			`{"a":2}`, nil},
+		{"same change on both sides",
+			`{"a":1}`, `{"a":2}`, `{"a":2}`,
+			`{"a":2}`, nil},
+		{"disjoint keys",
+			`{"a":1,"b":1}`, `{"a":2,"b":1}`, `{"a":1,"b":2,"c":3}`,
+			`{"a":2,"b":2,"c":3}`, nil},
+		{"deleted key",
+			`{"a":1,"b":1}`, `{"a":1}`, `{"a":2,"b":1}`,
+			`{"a":2}`, nil},
+		{"conflicting field",
+			`{"a":{"b/c":1}}`, `{"a":{"b/c":2}}`, `{"a":{"b/c":3}}`,
+			`{"a":{"b/c":2}}`, []string{"/body/a/b~1c"}},
+		{"delete vs modify",
+			`{"a":1}`, `{}`, `{"a":2}`,
+			`{}`, []string{"/body/a"}},
+		{"rows appended & prepended",
+			`[[1],[2],[3]]`, `[[0],[1],[2],[3]]`, `[[1],[2],[3],[4]]`,
+			`[[0],[1],[2],[3],[4]]`, nil},
+		{"row removed & other row edited",
+			`[[1,"a"],[2,"b"],[3,"c"]]`, `[[1,"a"],[3,"c"]]`, `[[1,"a"],[2,"b"],[3,"z"]]`,
+			`[[1,"a"],[3,"z"]]`, nil},
+		{"row removed & edited",
+			`[[1,"a"],[2,"b"],[3,"c"]]`, `[[1,"a"],[3,"c"]]`, `[[1,"a"],[2,"z"],[3,"c"]]`,
+			`[[1,"a"],[3,"c"]]`, []string{"/body/1"}},
+		{"different fields of the same row",
+			`[[1,"a","x"],[2,"b","y"]]`, `[[1,"A","x"],[2,"b","y"]]`, `[[1,"a","X"],[2,"b","y"]]`,
+			`[[1,"A","X"],[2,"b","y"]]`, nil},
+		{"same field of the same row",
+			`[[1,"a"],[2,"b"]]`, `[[1,"a"],[2,"c"]]`, `[[1,"a"],[2,"d"]]`,
+			`[[1,"a"],[2,"c"]]`, []string{"/body/1/1"}},
+		{"rows inserted at the same position",
+			`[[1],[3]]`, `[[1],[2],[3]]`, `[[1],[2.5],[2.6],[3]]`,
+			`[[1],[2],[3]]`, []string{"/body/1"}},
+	}
+
+	for _, c := range cases {
+		var base, ours, theirs, expect interface{}
+		mustUnmarshal(t, c.base, &base)
+		mustUnmarshal(t, c.ours, &ours)
+		mustUnmarshal(t, c.theirs, &theirs)
+		mustUnmarshal(t, c.expect, &expect)
+
+		got, conflicts := Values("/body", base, ours, theirs)
+		if diff := cmp.Diff(expect, got); diff != "" {
+			t.Errorf("case %q merged result mismatch (-want +got):\n%s", c.description, diff)
+		}
+		paths := []string(nil)
+		for _, c := range conflicts {
+			paths = append(paths, c.Path)
+		}
+		if diff := cmp.Diff(c.expectConflictPaths, paths); diff != "" {
+			t.Errorf("case %q conflict paths mismatch (-want +got):\n%s", c.description, diff)
+		}
+	}
+}
+
+func TestAlignKeys(t *testing.T) {
+	got := alignKeys([]string{"a", "b", "c", "d", "e"}, []string{"a", "c", "x", "d", "e"})
+	expect := []int{0, -1, 1, 3, 4}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func mustUnmarshal(t *testing.T, data string, v interface{}) {
+	if err := json.Unmarshal([]byte(data), v); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,133 @@
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestMergeDatasets(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	save := func(title, body, branch string) reporef.DatasetRef {
+		ds := &dataset.Dataset{
+			Peername:  "peer",
+			Name:      "merge_test",
+			Meta:      &dataset.Meta{Title: title},
+			Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(body)))
+		ref, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true, Branch: branch})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ref
+	}
+
+	ancestor := save("base", `[[1,"a"],[2,"b"],[3,"c"]]`, "")
+	dsr := reporef.ConvertToDsref(ancestor)
+	if err := r.Logbook().WriteBranchInit(ctx, dsr, "feature", ""); err != nil {
+		t.Fatal(err)
+	}
+	ours := save("base", `[[1,"a"],[2,"B"],[3,"c"]]`, "")
+	theirs := save("theirs", `[[1,"a"],[2,"b"],[3,"c"],[4,"d"]]`, "feature")
+
+	got, err := CommonAncestor(ctx, r.Store(), ours.Path, theirs.Path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != ancestor.Path {
+		t.Errorf("common ancestor mismatch. expected: %q, got: %q", ancestor.Path, got)
+	}
+
+	res, err := MergeDatasets(ctx, r, ancestor.Path, ours.Path, theirs.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Conflicts) != 0 {
+		t.Fatalf("expected no conflicts, got: %v", res.Conflicts)
+	}
+	if res.Dataset.Meta.Title != "theirs" {
+		t.Errorf("expected merged title to be %q, got: %q", "theirs", res.Dataset.Meta.Title)
+	}
+
+	res.Dataset.Peername = "peer"
+	res.Dataset.Name = "merge_test"
+	merged, err := SaveMerge(ctx, r, devNull, res, "", "merge feature", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectBody := `[[1,"a"],[2,"B"],[3,"c"],[4,"d"]]`
+	if diff := cmp.Diff(expectBody, loadBodyString(ctx, t, r, merged.Path)); diff != "" {
+		t.Errorf("merged body mismatch (-want +got):\n%s", diff)
+	}
+
+	parents, err := r.Logbook().MergeParents(ctx, dsr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{theirs.Path}, parents[merged.Path]); diff != "" {
+		t.Errorf("merge parents mismatch (-want +got):\n%s", diff)
+	}
+	// once merged, the head of the feature branch is the nearest common ancestor
+	if got, err = CommonAncestor(ctx, r.Store(), merged.Path, theirs.Path, parents); err != nil {
+		t.Fatal(err)
+	}
+	if got != theirs.Path {
+		t.Errorf("common ancestor after merge mismatch. expected: %q, got: %q", theirs.Path, got)
+	}
+
+	// change the same value on both sides
+	ours = save("base", `[[1,"a"],[2,"Y"],[3,"c"],[4,"d"]]`, "")
+	theirs = save("theirs", `[[1,"a"],[2,"X"],[3,"c"],[4,"d"]]`, "feature")
+	if res, err = MergeDatasets(ctx, r, merged.Path, ours.Path, theirs.Path); err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got: %v", res.Conflicts)
+	}
+	if res.Conflicts[0].Path != "/body/1/1" {
+		t.Errorf("conflict path mismatch. expected: %q, got: %q", "/body/1/1", res.Conflicts[0].Path)
+	}
+	data, err := ioutil.ReadAll(res.Dataset.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectBody = `[[1,"a"],[2,"======= conflict: ours \"Y\", base \"B\", theirs \"X\" ======="],[3,"c"],[4,"d"]]`
+	if diff := cmp.Diff(expectBody, string(data)); diff != "" {
+		t.Errorf("marked body mismatch (-want +got):\n%s", diff)
+	}
+	if _, err = SaveMerge(ctx, r, devNull, res, "", "merge feature", "", false); err == nil {
+		t.Error("expected saving a merge with conflicts to error")
+	}
+}
+
+func loadBodyString(ctx context.Context, t *testing.T, r repo.Repo, path string) string {
+	ds, err := dsfs.LoadDataset(ctx, r.Store(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := dsfs.LoadBody(ctx, r.Store(), ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v interface{}
+	if err = json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	data, _ = json.Marshal(v)
+	return string(data)
+}
@@ -27,10 +27,12 @@ func CreatePreview(ctx context.Context, r repo.Repo, ref dsref.Ref) (ds *dataset
 		return nil, err
 	}
 
-	if err = ds.OpenBodyFile(ctx, r.Store()); err != nil {
+	body, err := dsfs.LoadBody(ctx, r.Store(), ds)
+	if err != nil {
 		log.Errorf("CreatePreview opening body file: %s", err.Error())
 		return nil, err
 	}
+	ds.SetBodyFile(body)
 
 	st := &dataset.Structure{
 		Format: "json",
@@ -0,0 +1,53 @@
+package base
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// ErrPrivateDataset indicates an action would expose a private dataset
+var ErrPrivateDataset = fmt.Errorf("dataset is private")
+
+// IsPrivate returns true if a dataset is encrypted at rest. Datasets without
+// a logbook are never private
+func IsPrivate(ctx context.Context, r repo.Repo, ref reporef.DatasetRef) bool {
+	book := r.Logbook()
+	if book == nil {
+		return false
+	}
+	private, err := book.IsPrivate(ctx, reporef.ConvertToDsref(ref))
+	return err == nil && private
+}
+
+// datasetKey gets the data key a new version of a dataset is encrypted with.
+// Versions of a private dataset stay private. Making a dataset private
+// generates a new key that must be shared with the owner once the version is
+// written. Public saves return a nil key
+func datasetKey(ctx context.Context, r repo.Repo, ref dsref.Ref, private bool) (keyID string, key []byte, isNew bool, err error) {
+	book := r.Logbook()
+	if book == nil {
+		if private {
+			return "", nil, false, fmt.Errorf("private datasets require a logbook")
+		}
+		return "", nil, false, nil
+	}
+
+	// datasets without a log or a key share don't have a key we can use
+	if keyID, key, err = book.DatasetKey(ctx, ref); err == nil {
+		return keyID, key, false, nil
+	}
+	if isPrivate, _ := book.IsPrivate(ctx, ref); isPrivate {
+		return "", nil, false, fmt.Errorf("%w: no key to save a new version of %s", ErrPrivateDataset, ref.Alias())
+	}
+	if !private {
+		return "", nil, false, nil
+	}
+
+	keyID, key, err = dsfs.NewDataKey()
+	return keyID, key, true, err
+}
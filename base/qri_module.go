@@ -0,0 +1,172 @@
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/startf"
+	skyqri "github.com/qri-io/qri/startf/qri"
+	"github.com/qri-io/qri/stats"
+)
+
+func init() {
+	startf.RepoReader = NewQriModuleReader
+}
+
+// NewQriModuleReader gives builtins in the 'qri.star' starlark module
+// read-only access to the datasets in a repo
+func NewQriModuleReader(r repo.Repo) skyqri.Reader {
+	return qriModuleReader{r: r}
+}
+
+type qriModuleReader struct {
+	r repo.Repo
+}
+
+var _ skyqri.Reader = (*qriModuleReader)(nil)
+
+// ListDatasets lists references to all local datasets
+func (mr qriModuleReader) ListDatasets(ctx context.Context) ([]string, error) {
+	count, err := mr.r.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := mr.r.References(0, count)
+	if err != nil {
+		return nil, err
+	}
+	strs := make([]string, len(refs))
+	for i, ref := range refs {
+		strs[i] = ref.String()
+	}
+	return strs, nil
+}
+
+// GetDataset loads a dataset without its body
+func (mr qriModuleReader) GetDataset(ctx context.Context, refstr string, at time.Time) (*dataset.Dataset, error) {
+	ref, err := mr.resolve(ctx, refstr, at)
+	if err != nil {
+		return nil, err
+	}
+	return ref.Dataset, nil
+}
+
+// GetBody reads entries from a dataset body
+func (mr qriModuleReader) GetBody(ctx context.Context, refstr string, offset, limit int) (interface{}, error) {
+	ref, err := mr.resolve(ctx, refstr, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	ds := ref.Dataset
+	f, err := dsfs.LoadBody(ctx, mr.r.Store(), ds)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rdr, err := dsio.NewEntryReader(ds.Structure, f)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 || limit >= 0 {
+		rdr = &dsio.PagedReader{Reader: rdr, Offset: offset, Limit: limit}
+	}
+	return ReadEntries(rdr)
+}
+
+// Log lists the versions of a dataset, newest first
+func (mr qriModuleReader) Log(ctx context.Context, refstr string) ([]dsref.VersionInfo, error) {
+	ref, err := repo.ParseDatasetRef(refstr)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.CanonicalizeDatasetRef(mr.r, &ref); err != nil {
+		return nil, err
+	}
+	return DatasetLog(ctx, mr.r, ref, -1, 0, false)
+}
+
+// Diff describes the changes to the body & schema from dataset a to b
+func (mr qriModuleReader) Diff(ctx context.Context, a, b string) (interface{}, error) {
+	refA, err := mr.resolve(ctx, a, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	refB, err := mr.resolve(ctx, b, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	store := mr.r.Store()
+	docA, err := changelogDocument(ctx, store, refA.Dataset)
+	if err != nil {
+		return nil, err
+	}
+	docB, err := changelogDocument(ctx, store, refB.Dataset)
+	if err != nil {
+		return nil, err
+	}
+	vc, err := diffVersions(ctx, docB, docA)
+	if err != nil {
+		return nil, err
+	}
+	vc.Path = refB.Path
+	vc.PreviousPath = refA.Path
+	if cm := refB.Dataset.Commit; cm != nil {
+		vc.CommitTime = cm.Timestamp
+		vc.CommitTitle = cm.Title
+	}
+	return vc, nil
+}
+
+// Stats calculates statistics about a dataset body
+func (mr qriModuleReader) Stats(ctx context.Context, refstr string) (interface{}, error) {
+	ref, err := mr.resolve(ctx, refstr, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	ds := ref.Dataset
+	f, err := dsfs.LoadBody(ctx, mr.r.Store(), ds)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	ds.SetBodyFile(f)
+
+	rdr, err := stats.New(nil).JSON(ctx, ds)
+	if err != nil {
+		return nil, err
+	}
+	var sts interface{}
+	err = json.NewDecoder(rdr).Decode(&sts)
+	return sts, err
+}
+
+// resolve reads the dataset a reference string points to. A non-zero at time
+// resolves the version that was the latest at that time
+func (mr qriModuleReader) resolve(ctx context.Context, refstr string, at time.Time) (reporef.DatasetRef, error) {
+	ref, err := repo.ParseDatasetRef(refstr)
+	if err != nil {
+		return ref, err
+	}
+	if err := repo.CheckAsOf(ref, at); err != nil {
+		return ref, err
+	}
+	if err := repo.CanonicalizeDatasetRef(mr.r, &ref); err != nil {
+		return ref, err
+	}
+	if !at.IsZero() {
+		if err := repo.ResolveAsOf(ctx, mr.r, &ref, at); err != nil {
+			return ref, err
+		}
+	}
+	err = ReadDataset(ctx, mr.r, &ref)
+	return ref, err
+}
@@ -0,0 +1,155 @@
+package base
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/startf"
+)
+
+func TestQriModuleReader(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	save := func(title, body string) reporef.DatasetRef {
+		ds := &dataset.Dataset{
+			Peername:  "peer",
+			Name:      "qri_module",
+			Commit:    &dataset.Commit{Title: title},
+			Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(body)))
+		ref, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ref
+	}
+	first := save("created", `[[1,"a"],[2,"b"]]`)
+	save("add a row", `[[1,"a"],[2,"b"],[3,"c"]]`)
+
+	mr := NewQriModuleReader(r)
+	refs, err := mr.ListDatasets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 {
+		t.Errorf("expected 1 dataset. got: %v", refs)
+	}
+
+	ds, err := mr.GetDataset(ctx, "peer/qri_module", time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Commit.Title != "add a row" || ds.Name != "qri_module" {
+		t.Errorf("expected latest version of peer/qri_module. got: %s %q", ds.Name, ds.Commit.Title)
+	}
+
+	body, err := mr.GetBody(ctx, "peer/qri_module", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := json.Marshal(body); string(data) != `[[2,"b"]]` {
+		t.Errorf("body page mismatch. expected: %s, got: %s", `[[2,"b"]]`, data)
+	}
+
+	versions, err := mr.Log(ctx, "peer/qri_module")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Errorf("expected 2 versions. got: %d", len(versions))
+	}
+
+	diff, err := mr.Diff(ctx, "peer/qri_module@"+first.Path, "peer/qri_module")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vc := diff.(*VersionChanges); vc.RowsAdded != 1 || vc.PreviousPath != first.Path {
+		t.Errorf("expected 1 row added since %s. got: %d rows added since %s", first.Path, vc.RowsAdded, vc.PreviousPath)
+	}
+
+	sts, err := mr.Stats(ctx, "peer/qri_module")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cols, ok := sts.([]interface{}); !ok || len(cols) != 2 {
+		t.Errorf("expected stats for 2 columns. got: %v", sts)
+	}
+}
+
+func TestSaveTransformQriModule(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	addCitiesDataset(t, r)
+
+	ds := &dataset.Dataset{
+		Peername: "peer",
+		Name:     "city_names",
+		Transform: &dataset.Transform{
+			ScriptBytes: []byte(`load("qri.star", "qri")
+
+def transform(ds, ctx):
+  cities = qri.get_dataset("peer/cities")
+  rows = qri.get_body("peer/cities", limit=2)
+  ds.set_body([cities["meta"]["title"]] + [row[0] for row in rows])`),
+		},
+	}
+	ds.Transform.OpenScriptFile(ctx, nil)
+
+	ref, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := r.Store().Get(ctx, ref.Dataset.BodyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `["example city data","toronto","new york"]`
+	if string(data) != expect {
+		t.Errorf("body mismatch. expected: %s, got: %s", expect, data)
+	}
+}
+
+func TestExecScriptQriRepo(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	addCitiesDataset(t, r)
+
+	ds := &dataset.Dataset{
+		Transform: &dataset.Transform{
+			ScriptBytes: []byte(`load("qri.star", "qri")
+
+def transform(ds, ctx):
+  ds.set_body(qri.list_datasets())`),
+		},
+	}
+	ds.Transform.OpenScriptFile(ctx, nil)
+
+	// embedders that only supply a repo get a reader for the repo
+	if err := startf.ExecScript(ctx, ds, nil, startf.AddQriRepo(r)); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs := []string{}
+	if err := json.Unmarshal(data, &refs); err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 || !strings.HasPrefix(refs[0], "peer/cities@") {
+		t.Errorf("expected peer/cities to be listed. got: %v", refs)
+	}
+}
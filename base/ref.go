@@ -27,6 +27,9 @@ func SetPublishStatus(r repo.Repo, ref *reporef.DatasetRef, published bool) erro
 	if !InLocalNamespace(r, ref) {
 		return fmt.Errorf("can't publish datasets that are not in your namespace")
 	}
+	if published && IsPrivate(context.TODO(), r, *ref) {
+		return fmt.Errorf("%w: private datasets can't be published", ErrPrivateDataset)
+	}
 
 	ref.Published = published
 	return r.PutRef(*ref)
@@ -2,6 +2,7 @@ package base
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/base/dsfs"
@@ -33,6 +34,27 @@ func Recall(ctx context.Context, r repo.Repo, str string, ref reporef.DatasetRef
 	return res, nil
 }
 
+// GenerationPath gives the path of the nth-generational ancestor of a dataset
+// version, where 0 is the version itself and dsref.AllGenerations is the first
+// version in the history
+func GenerationPath(ctx context.Context, r repo.Repo, path string, gen int) (string, error) {
+	start := path
+	for i := 0; gen == dsref.AllGenerations || i < gen; i++ {
+		ds, err := dsfs.LoadDataset(ctx, r.Store(), path)
+		if err != nil {
+			return "", err
+		}
+		if ds.PreviousPath == "" {
+			if gen == dsref.AllGenerations {
+				break
+			}
+			return "", fmt.Errorf("%s has %d previous versions, can't go back %d versions", start, i, gen)
+		}
+		path = ds.PreviousPath
+	}
+	return path, nil
+}
+
 // LoadRevs grabs a component of a dataset that exists <n>th generation ancestor
 // of the referenced version, where presence of a component in a previous snapshot constitutes ancestry
 func LoadRevs(ctx context.Context, r repo.Repo, ref reporef.DatasetRef, revs []*dsref.Rev) (res *dataset.Dataset, err error) {
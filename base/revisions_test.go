@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/dsref"
 	reporef "github.com/qri-io/qri/repo/ref"
@@ -70,3 +71,48 @@ func TestLoadRevisions(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerationPath(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	paths := []string{}
+	for _, body := range []string{`[1]`, `[1,2]`, `[1,2,3]`} {
+		ds := &dataset.Dataset{
+			Peername:  "peer",
+			Name:      "generations",
+			Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray},
+		}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(body)))
+		ref, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, ref.Path)
+	}
+	head := paths[2]
+
+	cases := []struct {
+		gen    int
+		expect string
+	}{
+		{0, paths[2]},
+		{1, paths[1]},
+		{2, paths[0]},
+		{dsref.AllGenerations, paths[0]},
+	}
+	for _, c := range cases {
+		got, err := GenerationPath(ctx, r, head, c.gen)
+		if err != nil {
+			t.Errorf("generation %d: unexpected error: %s", c.gen, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("generation %d: expected path %s. got: %s", c.gen, c.expect, got)
+		}
+	}
+
+	if _, err := GenerationPath(ctx, r, head, 3); err == nil {
+		t.Error("expected going back past the first version to error")
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/qri-io/qfs"
 	"github.com/qri-io/qfs/cafs"
 	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
@@ -27,6 +28,23 @@ type SaveDatasetSwitches struct {
 	Force               bool
 	ShouldRender        bool
 	NewName             bool
+	// Branch names the logbook branch to save to. saves to branches other than
+	// the default branch don't move the dataset reference in the repo
+	Branch string
+	// MergeParent is the path of a version merged into this save. set when
+	// saving the resolution of a merge
+	MergeParent string
+	// Private encrypts the version before it's written to the store. New
+	// versions of a private dataset are always private
+	Private bool
+	// RecordFixtures saves all responses read during the transform download
+	// step alongside the transform, for replaying the transform offline
+	RecordFixtures bool
+	// TransformLimits caps the resources a transform can use
+	TransformLimits startf.Limits
+	// InferSchema replaces the body schema with one inferred from body values.
+	// Inference runs after the transform, saves without a body are left as-is
+	InferSchema bool
 }
 
 // SaveDataset initializes a dataset from a dataset pointer and data file
@@ -46,7 +64,7 @@ func SaveDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, changes *
 
 	isInferredName := MaybeInferName(changes)
 
-	prev, mutable, prevPath, err := PrepareDatasetSave(ctx, r, changes.Peername, changes.Name)
+	prev, mutable, prevPath, err := PrepareDatasetBranchSave(ctx, r, changes.Peername, changes.Name, sw.Branch)
 	if err != nil {
 		return
 	}
@@ -57,7 +75,7 @@ func SaveDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, changes *
 			// flag was given, user is requesting we invent a unique name. Increment a counter
 			// on the name until we find something that's available.
 			changes.Name = GenerateAvailableName(r, changes.Peername, changes.Name)
-			prev, mutable, prevPath, err = PrepareDatasetSave(ctx, r, changes.Peername, changes.Name)
+			prev, mutable, prevPath, err = PrepareDatasetBranchSave(ctx, r, changes.Peername, changes.Name, sw.Branch)
 			if err != nil {
 				return
 			}
@@ -96,9 +114,12 @@ func SaveDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, changes *
 
 		opts := []func(*startf.ExecOpts){
 			startf.AddQriRepo(r),
+			startf.AddQriReader(NewQriModuleReader(r)),
 			startf.AddMutateFieldCheck(mutateCheck),
 			startf.SetOutWriter(scriptOut),
 			startf.SetSecrets(secrets),
+			startf.RecordFixtures(sw.RecordFixtures),
+			startf.SetLimits(sw.TransformLimits),
 		}
 
 		if err = startf.ExecScript(ctx, changes, prev, opts...); err != nil {
@@ -130,6 +151,14 @@ func SaveDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, changes *
 		}
 	}
 
+	if sw.InferSchema && changes.BodyFile() != nil {
+		var sch map[string]interface{}
+		if sch, err = InferSchema(changes); err != nil {
+			return
+		}
+		changes.Structure.Schema = sch
+	}
+
 	if !sw.Replace {
 		// Treat the changes as a set of patches applied to the previous dataset
 		mutable.Assign(changes)
@@ -150,12 +179,27 @@ func SaveDataset(ctx context.Context, r repo.Repo, str ioes.IOStreams, changes *
 	// let's make history, if it exists
 	changes.PreviousPath = prevPath
 
-	return CreateDataset(ctx, r, str, changes, prev, sw.DryRun, sw.Pin, sw.Force, sw.ShouldRender)
+	return createDataset(ctx, r, str, changes, prev, sw)
 }
 
 // CreateDataset uses dsfs to add a dataset to a repo's store, updating all
 // references within the repo if successful
 func CreateDataset(ctx context.Context, r repo.Repo, streams ioes.IOStreams, ds, dsPrev *dataset.Dataset, dryRun, pin, force, shouldRender bool) (ref reporef.DatasetRef, err error) {
+	sw := SaveDatasetSwitches{
+		Branch:       logbook.DefaultBranchName,
+		DryRun:       dryRun,
+		Pin:          pin,
+		Force:        force,
+		ShouldRender: shouldRender,
+	}
+	return createDataset(ctx, r, streams, ds, dsPrev, sw)
+}
+
+// createDataset is CreateDataset configured by save switches. Only saves to
+// the default branch update references in the repo. A non-empty MergeParent
+// records the new version as a merge of MergeParent into the branch. Private
+// versions are encrypted with the dataset's key
+func createDataset(ctx context.Context, r repo.Repo, streams ioes.IOStreams, ds, dsPrev *dataset.Dataset, sw SaveDatasetSwitches) (ref reporef.DatasetRef, err error) {
 	var (
 		pro     *profile.Profile
 		path    string
@@ -173,14 +217,32 @@ func CreateDataset(ctx context.Context, r repo.Repo, streams ioes.IOStreams, ds,
 		return
 	}
 
-	if path, err = dsfs.CreateDataset(ctx, r.Store(), ds, dsPrev, r.PrivateKey(), pin, force, shouldRender); err != nil {
+	ownerID, ownerName := versionOwner(ctx, r, pro, ds)
+	dsr := dsref.Ref{Username: ownerName, Name: ds.Name}
+	keyID, key, newKey, err := datasetKey(ctx, r, dsr, sw.Private)
+	if err != nil {
+		return
+	}
+	if newKey && ownerID != pro.ID {
+		err = fmt.Errorf("%w: only the owner of a dataset can make it private", logbook.ErrAccessDenied)
+		return
+	}
+	store := r.Store()
+	if key != nil {
+		store = dsfs.NewPrivateStore(store, keyID, key)
+		// keep the key on hand while this version is read back
+		ctx = dsfs.WithKeyring(ctx, dsfs.NewMemKeyring(key))
+	}
+
+	if path, err = dsfs.CreateDataset(ctx, store, ds, dsPrev, r.PrivateKey(), sw.Pin, sw.Force, sw.ShouldRender); err != nil {
 		log.Debugf("dsfs.CreateDataset: %s", err)
 		return
 	}
-	if ds.PreviousPath != "" && ds.PreviousPath != "/" {
+	onDefaultBranch := sw.Branch == "" || sw.Branch == logbook.DefaultBranchName
+	if onDefaultBranch && ds.PreviousPath != "" && ds.PreviousPath != "/" {
 		prev := reporef.DatasetRef{
-			ProfileID: pro.ID,
-			Peername:  pro.Peername,
+			ProfileID: ownerID,
+			Peername:  ownerName,
 			Name:      ds.Name,
 			Path:      ds.PreviousPath,
 		}
@@ -190,8 +252,8 @@ func CreateDataset(ctx context.Context, r repo.Repo, streams ioes.IOStreams, ds,
 		_ = r.DeleteRef(prev)
 	}
 	ref = reporef.DatasetRef{
-		ProfileID: pro.ID,
-		Peername:  pro.Peername,
+		ProfileID: ownerID,
+		Peername:  ownerName,
 		Name:      ds.Name,
 		Path:      path,
 	}
@@ -200,21 +262,33 @@ func CreateDataset(ctx context.Context, r repo.Repo, streams ioes.IOStreams, ds,
 	// a blank in-memory repo, and is needed by the ReadDataset call below. I'd
 	// prefer this move into the `if !dryRun` clause below, or be dropped entirely
 	// in favour of dscache
-	if err = r.PutRef(ref); err != nil {
-		log.Debugf("r.PutRef: %s", err)
-		return
+	if onDefaultBranch || sw.DryRun {
+		if err = r.PutRef(ref); err != nil {
+			log.Debugf("r.PutRef: %s", err)
+			return
+		}
 	}
 
 	// TODO (b5): confirm these assignments happen in dsfs.CreateDataset with tests
-	ds.ProfileID = pro.ID.String()
-	ds.Peername = pro.Peername
+	ds.ProfileID = ownerID.String()
+	ds.Peername = ownerName
 	ds.Path = path
 
-	if !dryRun {
-		err := r.Logbook().WriteVersionSave(ctx, ds)
+	if !sw.DryRun {
+		var err error
+		if sw.MergeParent != "" {
+			err = r.Logbook().WriteBranchVersionMerge(ctx, ds, sw.Branch, sw.MergeParent)
+		} else {
+			err = r.Logbook().WriteBranchVersionSave(ctx, ds, sw.Branch)
+		}
 		if err != nil && err != logbook.ErrNoLogbook {
 			return ref, err
 		}
+		if newKey {
+			if err = r.Logbook().WriteKeyShare(ctx, dsr, ownerID.String(), r.PrivateKey().GetPublic(), keyID, key); err != nil {
+				return ref, err
+			}
+		}
 	}
 
 	if err = ReadDataset(ctx, r, &ref); err != nil {
@@ -226,13 +300,33 @@ func CreateDataset(ctx context.Context, r repo.Repo, streams ioes.IOStreams, ds,
 	// references to files in a store that won't exist after this function call
 	// TODO (b5): this should be replaced with a call to OpenDataset with a qfs that
 	// knows about the store
-	if resBody, err = r.Store().Get(ctx, ref.Dataset.BodyPath); err != nil {
+	if resBody, err = dsfs.LoadBody(ctx, r.Store(), ref.Dataset); err != nil {
 		log.Error("error getting from store:", err.Error())
 	}
 	ref.Dataset.SetBodyFile(resBody)
 	return
 }
 
+// versionOwner returns the profile a new version of a dataset belongs to.
+// Versions of another profile's dataset stay with the owner if the logbook
+// grants the local profile write access. Otherwise new versions belong to the
+// local profile
+func versionOwner(ctx context.Context, r repo.Repo, pro *profile.Profile, ds *dataset.Dataset) (profile.ID, string) {
+	book := r.Logbook()
+	if book == nil || ds.Peername == "" || ds.Peername == "me" || ds.Peername == pro.Peername {
+		return pro.ID, pro.Peername
+	}
+
+	ref := reporef.DatasetRef{Peername: ds.Peername, Name: ds.Name}
+	if err := repo.CanonicalizeDatasetRef(r, &ref); err != nil || ref.ProfileID == "" || ref.ProfileID == pro.ID {
+		return pro.ID, pro.Peername
+	}
+	if ok, err := book.HasPermission(ctx, reporef.ConvertToDsref(ref), pro.ID.String(), logbook.PermissionWrite); err != nil || !ok {
+		return pro.ID, pro.Peername
+	}
+	return ref.ProfileID, ref.Peername
+}
+
 // GenerateAvailableName creates a name for the dataset that is not currently in use
 func GenerateAvailableName(r repo.Repo, peername, prefix string) string {
 	counter := 0
@@ -0,0 +1,172 @@
+package base
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/detect"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/stats"
+)
+
+// inferSampleSize caps how much of a body is used to detect its format config
+// & columns when the structure doesn't supply them
+const inferSampleSize = 64 * 1024
+
+// InferSchema proposes a schema for a dataset body from statistics of its
+// values, picking the tightest types that describe every value: integers
+// instead of numbers, enums for low-cardinality strings, date & date-time
+// formats, and nullable columns. The body is read once & replaced with a
+// spooled copy. Missing structure format & format config values are filled
+// in by detection
+func InferSchema(ds *dataset.Dataset) (map[string]interface{}, error) {
+	body := ds.BodyFile()
+	if body == nil {
+		return nil, fmt.Errorf("inferring a schema requires a body")
+	}
+	defer body.Close()
+	buf := bufio.NewReaderSize(body, inferSampleSize)
+
+	if ds.Structure == nil {
+		ds.Structure = &dataset.Structure{}
+	}
+	st := ds.Structure
+	if st.Format == "" || st.Schema == nil {
+		df, err := detect.ExtensionDataFormat(body.FileName())
+		if st.Format != "" {
+			df, err = dataset.ParseDataFormatString(st.Format)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid data format: %s", err)
+		}
+		sample, err := buf.Peek(inferSampleSize)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		if df == dataset.CSVDataFormat && err == nil {
+			// drop the partial row at the end of the sample
+			if i := bytes.LastIndexByte(sample, '\n'); i >= 0 {
+				sample = sample[:i+1]
+			}
+		}
+		guessed, _, err := detect.FromReader(df, bytes.NewReader(sample))
+		if err != nil {
+			return nil, fmt.Errorf("determining dataset structure: %s", err)
+		}
+		if st.Format == "" {
+			st.Format = guessed.Format
+		}
+		if st.Schema == nil {
+			st.Schema = guessed.Schema
+		}
+		if st.FormatConfig == nil {
+			st.FormatConfig = guessed.FormatConfig
+		}
+	}
+
+	read := &dataset.Structure{
+		Format:       st.Format,
+		FormatConfig: st.FormatConfig,
+		Schema:       st.Schema,
+	}
+	var opts []func(*stats.Options)
+	if st.DataFormat() == dataset.CSVDataFormat {
+		// read every cell as a string so types are inferred from the cell text
+		// instead of the types in the current schema
+		read.Schema = stringColumns(st.Schema)
+		opts = append(opts, stats.OptParseStrings())
+	}
+
+	// copy the body as it's read, it replaces the consumed body file
+	spool := &dsfs.BodySpool{}
+	tee := io.TeeReader(buf, spool)
+	r, err := dsio.NewEntryReader(read, tee)
+	if err != nil {
+		spool.Close()
+		return nil, err
+	}
+	acc := stats.NewAccumulator(r, opts...)
+	if err = dsio.EachEntry(acc, func(int, dsio.Entry, error) error { return nil }); err != nil {
+		spool.Close()
+		return nil, err
+	}
+	if err = acc.Close(); err != nil {
+		spool.Close()
+		return nil, err
+	}
+	if _, err = io.Copy(ioutil.Discard, tee); err != nil {
+		spool.Close()
+		return nil, err
+	}
+
+	f, err := spool.File(body.FileName())
+	if err != nil {
+		return nil, err
+	}
+	ds.SetBodyFile(f)
+	return acc.Schema(), nil
+}
+
+// ErrNoColumns indicates a schema doesn't describe a tabular body, an array of
+// rows with a schema for each column
+var ErrNoColumns = fmt.Errorf("schema doesn't describe the columns of a tabular body")
+
+// ColumnTitles gives the title of each column described by a tabular schema.
+// Columns without a title have an empty title
+func ColumnTitles(sch map[string]interface{}) ([]string, error) {
+	row, ok := sch["items"].(map[string]interface{})
+	if !ok {
+		return nil, ErrNoColumns
+	}
+	cols, ok := row["items"].([]interface{})
+	if !ok {
+		return nil, ErrNoColumns
+	}
+
+	titles := make([]string, len(cols))
+	for i, c := range cols {
+		col, ok := c.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%w: column %d isn't a schema", ErrNoColumns, i)
+		}
+		titles[i], _ = col["title"].(string)
+	}
+	return titles, nil
+}
+
+// stringColumns copies a tabular schema, setting the type of every column to
+// string
+func stringColumns(sch map[string]interface{}) map[string]interface{} {
+	row, ok := sch["items"].(map[string]interface{})
+	if !ok {
+		return sch
+	}
+	cols, ok := row["items"].([]interface{})
+	if !ok {
+		return sch
+	}
+
+	strCols := make([]interface{}, len(cols))
+	for i, c := range cols {
+		col := map[string]interface{}{}
+		if m, ok := c.(map[string]interface{}); ok {
+			for k, v := range m {
+				col[k] = v
+			}
+		}
+		col["type"] = "string"
+		strCols[i] = col
+	}
+	return map[string]interface{}{
+		"type": sch["type"],
+		"items": map[string]interface{}{
+			"type":  row["type"],
+			"items": strCols,
+		},
+	}
+}
@@ -0,0 +1,186 @@
+package base
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+)
+
+func TestInferSchema(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+
+	body := []byte(`city,pop,avg_age,in_usa,founded,region
+toronto,40000000,55.5,false,1793-08-27,north
+new york,8500000,44.4,true,1624-01-01,east
+chicago,300000,44.4,true,1833-08-12,
+chatham,35000,65.25,true,1778-01-01,east
+raleigh,250000,50.65,true,1792-01-01,east
+`)
+	ds := &dataset.Dataset{}
+	ds.SetBodyFile(qfs.NewMemfileBytes("cities.csv", body))
+
+	sch, err := InferSchema(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Structure.Format != "csv" {
+		t.Errorf("expected detected format to be csv, got: %q", ds.Structure.Format)
+	}
+	if ds.Structure.FormatConfig["headerRow"] != true {
+		t.Errorf("expected detected format config to have a header row")
+	}
+
+	expect := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "city", "type": "string"},
+				map[string]interface{}{"title": "pop", "type": "integer"},
+				map[string]interface{}{"title": "avg_age", "type": "number"},
+				map[string]interface{}{"title": "in_usa", "type": "boolean"},
+				map[string]interface{}{"title": "founded", "type": "string", "format": "date"},
+				map[string]interface{}{"title": "region", "type": []interface{}{"string", "null"}, "enum": []interface{}{"east", "north", nil}},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, sch); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+
+	// the body should still be readable, and valid against the inferred schema
+	ds.Structure.Schema = sch
+	errs, err := Validate(ctx, r, ds.BodyFile(), ds.Structure)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != 0 {
+		t.Errorf("expected body to be valid against inferred schema, got errors: %v", errs)
+	}
+
+	if _, err := InferSchema(&dataset.Dataset{}); err == nil {
+		t.Errorf("expected inferring a schema without a body to error")
+	}
+}
+
+func TestInferSchemaLargeBody(t *testing.T) {
+	buf := &bytes.Buffer{}
+	buf.WriteString("id,label\n")
+	for i := 0; buf.Len() < 3*inferSampleSize; i++ {
+		fmt.Fprintf(buf, "%d,\"label number %d\"\n", i, i)
+	}
+	body := buf.Bytes()
+
+	ds := &dataset.Dataset{}
+	ds.SetBodyFile(qfs.NewMemfileBytes("ids.csv", body))
+	sch, err := InferSchema(ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Structure.FormatConfig["variadicFields"] != nil {
+		t.Errorf("expected the sample used for detection to end on a whole row. got format config: %v", ds.Structure.FormatConfig)
+	}
+	titles, err := ColumnTitles(sch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"id", "label"}, titles); diff != "" {
+		t.Errorf("titles mismatch (-want +got):\n%s", diff)
+	}
+
+	got, err := ioutil.ReadAll(ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, got) {
+		t.Errorf("expected body to be readable in full after inference. got %d of %d bytes", len(got), len(body))
+	}
+}
+
+func TestSaveInferSchema(t *testing.T) {
+	ctx := context.Background()
+	r := newTestRepo(t)
+	ref := addCitiesDataset(t, r)
+	prevSchema := ref.Dataset.Structure.Schema
+
+	// saves without a body keep their schema
+	ds := &dataset.Dataset{
+		Peername: ref.Peername,
+		Name:     ref.Name,
+		Meta:     &dataset.Meta{Title: "new title"},
+	}
+	saved, err := SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true, InferSchema: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(prevSchema, saved.Dataset.Structure.Schema); diff != "" {
+		t.Errorf("expected metadata-only save to keep its schema (-want +got):\n%s", diff)
+	}
+
+	// transform bodies are inferred once the transform runs
+	ds = &dataset.Dataset{
+		Peername: "peer",
+		Name:     "inferred_tf",
+		Transform: &dataset.Transform{
+			ScriptBytes: []byte(`def transform(ds, ctx):
+  ds.set_body([[1, "a"], [2, "b"]])`),
+		},
+	}
+	ds.Transform.OpenScriptFile(ctx, nil)
+	saved, err = SaveDataset(ctx, r, devNull, ds, nil, nil, SaveDatasetSwitches{Pin: true, InferSchema: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"type": "integer"},
+				map[string]interface{}{"type": "string"},
+			},
+		},
+	}
+	if diff := cmp.Diff(expect, saved.Dataset.Structure.Schema); diff != "" {
+		t.Errorf("transform schema mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestColumnTitles(t *testing.T) {
+	sch := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "city", "type": "string"},
+				map[string]interface{}{"type": "integer"},
+			},
+		},
+	}
+	titles, err := ColumnTitles(sch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"city", ""}, titles); diff != "" {
+		t.Errorf("titles mismatch (-want +got):\n%s", diff)
+	}
+
+	bad := []map[string]interface{}{
+		dataset.BaseSchemaObject,
+		{"type": "array", "items": map[string]interface{}{"type": "object"}},
+		{"type": "array", "items": map[string]interface{}{"type": "array", "items": []interface{}{"city"}}},
+	}
+	for i, sch := range bad {
+		if _, err := ColumnTitles(sch); !errors.Is(err, ErrNoColumns) {
+			t.Errorf("case %d: expected ErrNoColumns. got: %v", i, err)
+		}
+	}
+}
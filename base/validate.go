@@ -3,11 +3,12 @@ package base
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/jsonschema"
 	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/repo"
 )
 
@@ -20,27 +21,10 @@ func Validate(ctx context.Context, r repo.Repo, body qfs.File, st *dataset.Struc
 		return nil, fmt.Errorf("st passed to Validate must not be nil")
 	}
 
-	// jsonschema assumes body is json, convert the format if necessary
-	if st.Format != "json" {
-		convert := dataset.Structure{
-			Format: "json",
-			Schema: st.Schema,
-		}
-		file, err := ConvertBodyFormat(body, st, &convert)
-		if err != nil {
-			return nil, err
-		}
-		body = file
-	}
-
-	// jsonschema does not handle data streams, have to read the whole body
-	data, err := ioutil.ReadAll(body)
-	if err != nil {
-		return nil, err
-	}
-	jsch, err := st.JSONSchema()
+	// validate the same way saving does
+	er, err := dsio.NewEntryReader(st, body)
 	if err != nil {
 		return nil, err
 	}
-	return jsch.ValidateBytes(data)
+	return dsfs.ValidateEntries(er)
 }
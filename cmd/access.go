@@ -0,0 +1,369 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/token"
+	"github.com/spf13/cobra"
+)
+
+// NewAccessCommand creates a new `qri access` cobra command
+func NewAccessCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &AccessOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "access [DATASET] [PEER]",
+		Short: "List, grant, or revoke collaborator access to a dataset",
+		Long: `
+Access controls which peers can work on a dataset you own. Permissions are
+granted to a peer by peername or profile ID:
+
+  read     pull the dataset. until read access is granted to someone, anyone
+           can read the dataset
+  write    push new versions of the dataset to its logs & remotes
+  publish  unpublish versions of the dataset from a remote
+
+Access changes are recorded in the dataset's log. Remotes enforce access once
+the log is published, run ` + "`qri publish`" + ` after changing access. Collaborators
+with write access can save new versions of a dataset they've added & publish
+them. Removing versions from a remote needs publish access.
+
+Private datasets are encrypted, and can only be read by peers their key has
+been shared with. Share the key to a private dataset you own with --share-key.
+The peer's public key is looked up from peers you've connected to, or can be
+given with --pubkey. Shared keys can't be taken back. Instead, --rotate-key
+replaces the key of a dataset with a new one, shared with everyone who holds
+the current key. New versions are encrypted with the new key. Give a peer with
+--rotate-key to leave them out of the new key. They can still read versions
+written before the rotation.
+
+With only a dataset argument, access lists peers that have been granted
+access.
+
+To control access to this node's HTTP API instead, issue bearer tokens with
+` + "`qri access token`" + `.`,
+		Example: `  list peers with access to a dataset:
+  $ qri access me/annual_pop
+
+  let b5 publish new versions of a dataset:
+  $ qri access me/annual_pop b5 --grant write,publish
+
+  stop b5 from publishing:
+  $ qri access me/annual_pop b5 --revoke publish
+
+  remove all of b5's access:
+  $ qri access me/annual_pop b5 --revoke all
+
+  let b5 read a private dataset:
+  $ qri access me/licensed_data b5 --grant read --share-key
+
+  stop b5 from reading new versions of a private dataset:
+  $ qri access me/licensed_data b5 --revoke all --rotate-key`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		Args: cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&o.Grant, "grant", nil, "permissions to grant: read, write, publish")
+	cmd.Flags().StringSliceVar(&o.Revoke, "revoke", nil, "permissions to revoke: read, write, publish, or all")
+	cmd.Flags().BoolVar(&o.ShareKey, "share-key", false, "share the key to a private dataset")
+	cmd.Flags().StringVar(&o.PubKey, "pubkey", "", "base64-encoded public key of the peer to share a key with")
+	cmd.Flags().BoolVar(&o.RotateKey, "rotate-key", false, "replace the key to a private dataset, leaving out the given peer")
+
+	cmd.AddCommand(NewAccessTokenCommand(f, ioStreams))
+
+	return cmd
+}
+
+// AccessOptions encapsulates state for the access command
+type AccessOptions struct {
+	ioes.IOStreams
+
+	Refs   *RefSelect
+	Peer   string
+	Grant  []string
+	Revoke []string
+
+	ShareKey  bool
+	PubKey    string
+	RotateKey bool
+
+	LogRequests *lib.LogRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *AccessOptions) Complete(f Factory, args []string) (err error) {
+	if len(args) > 1 {
+		o.Peer = args[1]
+		args = args[:1]
+	}
+	if o.Refs, err = GetCurrentRefSelect(f, args, 1, nil); err != nil {
+		return err
+	}
+	o.LogRequests, err = f.LogRequests()
+	return
+}
+
+// Run executes the access command
+func (o *AccessOptions) Run() error {
+	printRefSelect(o.ErrOut, o.Refs)
+
+	if len(o.Grant) > 0 && len(o.Revoke) > 0 {
+		return fmt.Errorf("cannot use --grant and --revoke at the same time")
+	}
+	if o.ShareKey && len(o.Revoke) > 0 {
+		return fmt.Errorf("cannot use --share-key and --revoke at the same time")
+	}
+	if o.RotateKey && (o.ShareKey || len(o.Grant) > 0) {
+		return fmt.Errorf("--rotate-key can't be used with --share-key or --grant")
+	}
+	if o.PubKey != "" && !o.ShareKey {
+		return fmt.Errorf("--pubkey requires --share-key")
+	}
+	if o.Peer == "" && (len(o.Grant) > 0 || len(o.Revoke) > 0 || o.ShareKey) {
+		return fmt.Errorf("a peer is required to change access")
+	}
+	if o.Peer != "" && len(o.Grant) == 0 && len(o.Revoke) == 0 && !o.ShareKey && !o.RotateKey {
+		return fmt.Errorf("use --grant, --revoke, --share-key, or --rotate-key to change the access of %s", o.Peer)
+	}
+
+	if o.RotateKey {
+		rp := &lib.RotateKeyParams{Ref: o.Refs.Ref()}
+		if o.Peer != "" {
+			rp.Revoke = []string{o.Peer}
+		}
+		holders := []string{}
+		if err := o.LogRequests.RotateKey(rp, &holders); err != nil {
+			return err
+		}
+		printSuccess(o.Out, "rotated the key to %s, %d peers hold the new key", o.Refs.Ref(), len(holders))
+		if len(o.Revoke) == 0 {
+			return nil
+		}
+	}
+
+	if o.ShareKey {
+		sp := &lib.ShareKeyParams{
+			Ref:     o.Refs.Ref(),
+			Profile: o.Peer,
+			PubKey:  o.PubKey,
+		}
+		holders := []string{}
+		if err := o.LogRequests.ShareKey(sp, &holders); err != nil {
+			return err
+		}
+		printSuccess(o.Out, "shared the key to %s with %s", o.Refs.Ref(), o.Peer)
+		if len(o.Grant) == 0 {
+			return nil
+		}
+	}
+
+	p := &lib.AccessParams{
+		Ref:     o.Refs.Ref(),
+		Profile: o.Peer,
+	}
+	res := []lib.Collaborator{}
+
+	switch {
+	case len(o.Grant) > 0:
+		p.Permissions = o.Grant
+		if err := o.LogRequests.GrantAccess(p, &res); err != nil {
+			return err
+		}
+		printSuccess(o.Out, "granted %s access to %s", strings.Join(o.Grant, ", "), o.Peer)
+		return nil
+	case len(o.Revoke) > 0:
+		if !(len(o.Revoke) == 1 && o.Revoke[0] == "all") {
+			p.Permissions = o.Revoke
+		}
+		if err := o.LogRequests.RevokeAccess(p, &res); err != nil {
+			return err
+		}
+		printSuccess(o.Out, "revoked %s access from %s", strings.Join(o.Revoke, ", "), o.Peer)
+		return nil
+	}
+
+	if err := o.LogRequests.Access(p, &res); err != nil {
+		if err == repo.ErrEmptyRef {
+			return lib.NewError(err, "please provide a dataset reference")
+		}
+		return err
+	}
+	if len(res) == 0 {
+		printInfo(o.Out, "only the owner has access")
+		return nil
+	}
+	for _, c := range res {
+		name := c.ProfileID
+		if c.Peername != "" {
+			name = fmt.Sprintf("%s (%s)", c.Peername, c.ProfileID)
+		}
+		fmt.Fprintf(o.Out, "%s\t%s\n", name, strings.Join(c.Permissions, ", "))
+	}
+	return nil
+}
+
+// NewAccessTokenCommand creates a new `qri access token` cobra command
+func NewAccessTokenCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &AccessTokenOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "token",
+		Short: "Issue, list, or revoke tokens for the HTTP API",
+		Long: `
+Tokens grant scoped access to this node's HTTP API. They're signed with the
+node's private key, and are sent in the Authorization header of a request:
+
+  Authorization: Bearer <token>
+
+Tokens are only checked when the api.requiretoken config value is true:
+
+  $ qri config set api.requiretoken true
+
+Each token grants one or more scopes:
+
+  read     list, get & diff datasets, read peers & profiles
+  write    save, remove, rename & update datasets, edit the profile
+  publish  publish & unpublish datasets
+  admin    everything, including connecting to peers & registry actions
+
+Write and publish both imply read. Tokens don't expire unless --expires is
+given. A token is only shown once when it's issued, keep it secret. Lost or
+leaked tokens can be revoked by ID, and revoked tokens stop working right
+away, even on a running server.`,
+		Example: `  issue a read-only token for a dashboard:
+  $ qri access token --scope read --subject dashboard
+
+  issue a token for a build server that expires in 30 days:
+  $ qri access token --scope write,publish --subject ci --expires 720h
+
+  list issued tokens:
+  $ qri access token --list
+
+  revoke a token:
+  $ qri access token --revoke 9f86d081884c7d659a2feaa0c55ad015`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&o.Scopes, "scope", nil, "scopes to grant: read, write, publish, admin")
+	cmd.Flags().StringVar(&o.Subject, "subject", "", "who or what the token is for")
+	cmd.Flags().DurationVar(&o.Expires, "expires", 0, "how long the token is valid for, eg: 24h. defaults to never expiring")
+	cmd.Flags().BoolVar(&o.List, "list", false, "list issued tokens")
+	cmd.Flags().StringVar(&o.Revoke, "revoke", "", "ID of a token to revoke")
+	cmd.Flags().StringVarP(&o.Format, "format", "f", "", "output format. one of: [json]")
+
+	return cmd
+}
+
+// AccessTokenOptions encapsulates state for the access token command
+type AccessTokenOptions struct {
+	ioes.IOStreams
+
+	Scopes  []string
+	Subject string
+	Expires time.Duration
+	List    bool
+	Revoke  string
+	Format  string
+
+	TokenMethods *lib.TokenMethods
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *AccessTokenOptions) Complete(f Factory, args []string) (err error) {
+	o.TokenMethods, err = f.TokenMethods()
+	return
+}
+
+// Validate checks that all user input is valid
+func (o *AccessTokenOptions) Validate() error {
+	actions := 0
+	if len(o.Scopes) > 0 {
+		actions++
+	}
+	if o.List {
+		actions++
+	}
+	if o.Revoke != "" {
+		actions++
+	}
+	if actions > 1 {
+		return fmt.Errorf("use only one of --scope, --list, or --revoke")
+	}
+	if actions == 0 {
+		return fmt.Errorf("use --scope to issue a token, --list to list tokens, or --revoke to revoke one")
+	}
+	if len(o.Scopes) == 0 && (o.Subject != "" || o.Expires != 0) {
+		return fmt.Errorf("--subject and --expires can only be used when issuing a token")
+	}
+	if o.Format != "" && o.Format != "json" {
+		return fmt.Errorf("invalid format %q, only json is supported", o.Format)
+	}
+	return nil
+}
+
+// Run executes the access token command
+func (o *AccessTokenOptions) Run() error {
+	switch {
+	case o.Revoke != "":
+		ok := false
+		if err := o.TokenMethods.Revoke(&o.Revoke, &ok); err != nil {
+			return err
+		}
+		printSuccess(o.Out, "revoked token %s", o.Revoke)
+		return nil
+	case o.List:
+		list := true
+		res := []token.Record{}
+		if err := o.TokenMethods.List(&list, &res); err != nil {
+			return err
+		}
+		if o.Format == "json" {
+			return json.NewEncoder(o.Out).Encode(res)
+		}
+		if len(res) == 0 {
+			printInfo(o.Out, "no tokens have been issued")
+			return nil
+		}
+		for _, r := range res {
+			fmt.Fprint(o.Out, tokenRecordStringer(r))
+		}
+		return nil
+	}
+
+	p := &lib.IssueTokenParams{
+		Subject: o.Subject,
+		Scopes:  o.Scopes,
+		TTL:     o.Expires,
+	}
+	res := &lib.IssuedToken{}
+	if err := o.TokenMethods.Issue(p, res); err != nil {
+		return err
+	}
+	if o.Format == "json" {
+		return json.NewEncoder(o.Out).Encode(res)
+	}
+	printSuccess(o.ErrOut, "issued token %s", res.Claims.ID)
+	fmt.Fprintln(o.Out, res.Token)
+	return nil
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+// Test granting & revoking collaborator access to a dataset
+func TestAccessGrantRevoke(t *testing.T) {
+	run := NewTestRunner(t, "test_peer", "qri_test_access")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save --body=testdata/movies/body_ten.csv me/access_ds")
+
+	output := run.MustExec(t, "qri access me/access_ds")
+	if !strings.Contains(output, "only the owner has access") {
+		t.Errorf("expected new dataset to have no collaborators, got: %s", output)
+	}
+
+	collab := "QmSyDX5LYTiwQi861F5NAwdHrrnd1iRGsoEvCyzQMUyZ4W"
+	run.MustExec(t, "qri access me/access_ds "+collab+" --grant publish,write")
+	output = run.MustExec(t, "qri access me/access_ds")
+	expect := collab + "\twrite, publish\n"
+	if diff := cmpTextLines(expect, output); diff != "" {
+		t.Errorf("access after grant (-want +got):\n%s", diff)
+	}
+
+	if err := run.ExecCommand("qri access me/access_ds " + collab + " --grant fly"); err == nil {
+		t.Error("expected granting an invalid permission to fail")
+	}
+
+	run.MustExec(t, "qri access me/access_ds "+collab+" --revoke all")
+	output = run.MustExec(t, "qri access me/access_ds")
+	if !strings.Contains(output, "only the owner has access") {
+		t.Errorf("expected revoking all access to remove the collaborator, got: %s", output)
+	}
+}
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewApplyCommand creates a new `qri apply` cobra command for re-running the
+// transform of a dataset version without saving
+func NewApplyCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &ApplyOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Re-run a dataset transform and check the result",
+		Long: `
+Apply re-runs the transform of a dataset version without saving, checking if
+the transform still produces the body that was committed. Apply exits with an
+error if the body differs.
+
+Transforms that download data depend on upstream sources that change over
+time. Save with the ` + "`--record-fixtures`" + ` flag to store every response
+the transform downloads alongside the transform. Running apply with
+` + "`--replay`" + ` serves those recorded responses instead of the network, so a
+transform can be verified offline, in CI for example.`,
+		Example: `  # save a transform, recording the responses it downloads:
+  qri save --file transform.star --record-fixtures me/tf_dataset
+
+  # check the transform still produces the committed body, offline:
+  qri apply --replay me/tf_dataset`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().BoolVar(&o.Replay, "replay", false, "serve downloads from fixtures recorded on save instead of the network")
+	cmd.Flags().StringSliceVar(&o.Secrets, "secrets", nil, "transform secrets as comma separated key,value,key,value,... sequence")
+
+	return cmd
+}
+
+// ApplyOptions encapsulates state for the apply command
+type ApplyOptions struct {
+	ioes.IOStreams
+
+	Refs    *RefSelect
+	Replay  bool
+	Secrets []string
+
+	DatasetRequests *lib.DatasetRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *ApplyOptions) Complete(f Factory, args []string) (err error) {
+	if o.DatasetRequests, err = f.DatasetRequests(); err != nil {
+		return
+	}
+	o.Refs, err = GetCurrentRefSelect(f, args, 1, nil)
+	return
+}
+
+// Validate checks that all user input is valid
+func (o *ApplyOptions) Validate() error {
+	if o.Refs.Ref() == "" {
+		return lib.NewError(lib.ErrBadArgs, "please provide a dataset reference")
+	}
+	return nil
+}
+
+// Run executes the apply command
+func (o *ApplyOptions) Run() (err error) {
+	printRefSelect(o.ErrOut, o.Refs)
+
+	p := &lib.ApplyParams{
+		Ref:          o.Refs.Ref(),
+		Replay:       o.Replay,
+		ScriptOutput: o.ErrOut,
+	}
+
+	if o.Secrets != nil {
+		if !confirm(o.ErrOut, o.In, `
+Warning: You are providing secrets to a dataset transformation.
+Never provide secrets to a transformation you do not trust.
+continue?`, true) {
+			return
+		}
+		if p.Secrets, err = parseSecrets(o.Secrets...); err != nil {
+			return err
+		}
+	}
+
+	res := &lib.ApplyResult{}
+	if err = o.DatasetRequests.Apply(p, res); err != nil {
+		return err
+	}
+
+	if !res.BodyMatches {
+		return fmt.Errorf("transform result doesn't match the committed body of %s", res.Path)
+	}
+	printSuccess(o.ErrOut, "transform reproduced the committed body of %s", res.Path)
+	return nil
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+// NewBranchCommand creates a new `qri branch` cobra command
+func NewBranchCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &BranchOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "branch [DATASET] [BRANCH]",
+		Short: "List, create, switch, or delete dataset branches",
+		Long: `
+Branches are named lines of history within a dataset. Every dataset starts
+with a "main" branch. New branches start from the newest version of an existing
+branch, and saves to a branch don't affect any other branch.
+
+Each dataset has one active branch. Saves that don't specify a branch with the
+--branch flag are written to the active branch. The published version of a
+dataset is always the head of the main branch.
+
+With only a dataset argument, branch lists the branches of that dataset. Adding
+a branch name creates a new branch.`,
+		Example: `  list branches of a dataset:
+  $ qri branch me/annual_pop
+
+  create a branch named cleanup:
+  $ qri branch me/annual_pop cleanup
+
+  make cleanup the active branch:
+  $ qri branch me/annual_pop --switch cleanup
+
+  delete the cleanup branch:
+  $ qri branch me/annual_pop --delete cleanup`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		Args: cobra.MaximumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVar(&o.From, "from", "", "branch to start a new branch from, defaults to main")
+	cmd.Flags().StringVar(&o.Switch, "switch", "", "make a branch the active branch")
+	cmd.Flags().StringVar(&o.Delete, "delete", "", "delete a branch")
+
+	return cmd
+}
+
+// BranchOptions encapsulates state for the branch command
+type BranchOptions struct {
+	ioes.IOStreams
+
+	Refs   *RefSelect
+	Branch string
+	From   string
+	Switch string
+	Delete string
+
+	LogRequests *lib.LogRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *BranchOptions) Complete(f Factory, args []string) (err error) {
+	if len(args) > 1 {
+		o.Branch = args[1]
+		args = args[:1]
+	}
+	if o.Refs, err = GetCurrentRefSelect(f, args, 1, nil); err != nil {
+		return err
+	}
+	o.LogRequests, err = f.LogRequests()
+	return
+}
+
+// Run executes the branch command
+func (o *BranchOptions) Run() error {
+	printRefSelect(o.ErrOut, o.Refs)
+
+	if o.Switch != "" && o.Delete != "" {
+		return fmt.Errorf("cannot use --switch and --delete at the same time")
+	}
+
+	p := &lib.BranchParams{
+		Ref:  o.Refs.Ref(),
+		From: o.From,
+	}
+
+	switch {
+	case o.Switch != "":
+		p.Branch = o.Switch
+		res := lib.BranchInfo{}
+		if err := o.LogRequests.SwitchBranch(p, &res); err != nil {
+			return err
+		}
+		printSuccess(o.Out, "switched to branch '%s'", res.Name)
+		return nil
+	case o.Delete != "":
+		p.Branch = o.Delete
+		var res bool
+		if err := o.LogRequests.DeleteBranch(p, &res); err != nil {
+			return err
+		}
+		printSuccess(o.Out, "deleted branch '%s'", o.Delete)
+		return nil
+	case o.Branch != "":
+		p.Branch = o.Branch
+		res := lib.BranchInfo{}
+		if err := o.LogRequests.CreateBranch(p, &res); err != nil {
+			return err
+		}
+		printSuccess(o.Out, "created branch '%s'", res.Name)
+		return nil
+	}
+
+	branches := []lib.BranchInfo{}
+	if err := o.LogRequests.Branches(p, &branches); err != nil {
+		if err == repo.ErrEmptyRef {
+			return lib.NewError(err, "please provide a dataset reference")
+		}
+		return err
+	}
+	for _, b := range branches {
+		marker := " "
+		if b.Active {
+			marker = "*"
+		}
+		fmt.Fprintf(o.Out, "%s %s\n", marker, b.Name)
+	}
+	return nil
+}
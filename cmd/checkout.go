@@ -16,10 +16,17 @@ import (
 func NewCheckoutCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 	o := &CheckoutOptions{IOStreams: ioStreams}
 	cmd := &cobra.Command{
-		Use:     "checkout",
-		Short:   "checkout creates a linked directory and writes dataset files to that directory",
-		Long:    ``,
-		Example: ``,
+		Use:   "checkout",
+		Short: "checkout creates a linked directory and writes dataset files to that directory",
+		Long: `Checkout creates a working directory for a dataset, writing the latest
+version of each dataset component as a file. Adding an "@branch" suffix to the
+dataset reference checks out the head of that branch and makes it the active
+branch of the dataset. Saves that don't specify a branch go to the active branch.`,
+		Example: `  checkout a dataset:
+  $ qri checkout me/annual_pop
+
+  checkout the cleanup branch of a dataset:
+  $ qri checkout me/annual_pop@cleanup`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -65,12 +72,16 @@ func (o *CheckoutOptions) Run() (err error) {
 	}
 	ref := o.Refs.Ref()
 
-	// Derive directory name from the dataset name.
+	// Derive directory name from the dataset name, ignoring any branch name
 	pos := strings.Index(ref, "/")
 	if pos == -1 {
 		return fmt.Errorf("expect '/' in dataset ref")
 	}
-	folderName := varName.CreateVarNameFromString(ref[pos+1:])
+	name := ref[pos+1:]
+	if at := strings.Index(name, "@"); at != -1 {
+		name = name[:at]
+	}
+	folderName := varName.CreateVarNameFromString(name)
 
 	if err = qfs.AbsPath(&folderName); err != nil {
 		return err
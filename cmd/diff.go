@@ -1,11 +1,14 @@
 package cmd
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 
 	"github.com/qri-io/ioes"
 	"github.com/qri-io/qri/base/component"
 	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo"
 	"github.com/spf13/cobra"
 )
 
@@ -45,7 +48,13 @@ Each change has a path that locates it within the document`,
   $ qri diff a.json b.json
 
   diff a json & csv file
-  $ qri diff some_table.csv b.json`,
+  $ qri diff some_table.csv b.json
+
+  diff the version that was current at the start of 2020 against the latest
+  $ qri diff me/annual_pop --as-of 2020-01-01
+
+  list the changes each of the last ten versions made
+  $ qri diff me/annual_pop --range 10..HEAD`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -59,6 +68,8 @@ Each change has a path that locates it within the document`,
 
 	cmd.Flags().StringVarP(&o.Format, "format", "f", "pretty", "output format. one of [json,pretty]")
 	cmd.Flags().BoolVar(&o.Summary, "summary", false, "just output the summary")
+	cmd.Flags().StringVar(&o.AsOf, "as-of", "", "compare from the version that was the latest at a point in time")
+	cmd.Flags().StringVar(&o.Range, "range", "", "describe the changes each version made over a range of history, like 10..HEAD")
 
 	return cmd
 }
@@ -71,6 +82,8 @@ type DiffOptions struct {
 	Selector string
 	Format   string
 	Summary  bool
+	AsOf     string
+	Range    string
 
 	DatasetRequests *lib.DatasetRequests
 }
@@ -98,9 +111,18 @@ func (o *DiffOptions) Complete(f Factory, args []string) (err error) {
 func (o *DiffOptions) Run() (err error) {
 	printRefSelect(o.ErrOut, o.Refs)
 
+	if o.Range != "" {
+		return o.runRange()
+	}
+
 	p := &lib.DiffParams{
 		Selector: o.Selector,
 	}
+	if o.AsOf != "" {
+		if p.AsOf, err = repo.ParseAsOf(o.AsOf); err != nil {
+			return err
+		}
+	}
 
 	if o.Refs.IsLinked() {
 		// > qri diff
@@ -109,6 +131,11 @@ func (o *DiffOptions) Run() (err error) {
 		// left = me/example_ds@head   right = me/example_ds@working_dir
 		p.LeftPath = o.Refs.Ref()
 		p.WorkingDir = o.Refs.Dir()
+	} else if len(o.Refs.RefList()) == 1 && !p.AsOf.IsZero() {
+		// > qri diff me/example_ds --as-of 2020-01-01
+		//
+		// left = me/example_ds@2020-01-01   right = me/example_ds@head
+		p.LeftPath = o.Refs.Ref()
 	} else if len(o.Refs.RefList()) == 1 {
 		// > qri diff me/example_ds
 		//
@@ -138,3 +165,32 @@ func (o *DiffOptions) Run() (err error) {
 
 	return printDiff(o.Out, res, o.Summary)
 }
+
+// runRange prints a changelog for a range of history of a single dataset
+func (o *DiffOptions) runRange() error {
+	if len(o.Refs.RefList()) != 1 {
+		return fmt.Errorf("--range requires exactly one dataset reference")
+	}
+	if o.Selector != "" || o.AsOf != "" {
+		return fmt.Errorf("--range can't be combined with a selector or --as-of")
+	}
+
+	p := &lib.DiffRangeParams{
+		Ref:   o.Refs.Ref(),
+		Range: o.Range,
+	}
+	res := []*lib.VersionChanges{}
+	if err := o.DatasetRequests.DiffRange(p, &res); err != nil {
+		return err
+	}
+
+	if o.Format == "json" {
+		return json.NewEncoder(o.Out).Encode(res)
+	}
+
+	buf := &bytes.Buffer{}
+	for _, vc := range res {
+		buf.WriteString(versionChangesStringer(*vc).String())
+	}
+	return printToPager(o.Out, buf)
+}
@@ -24,12 +24,31 @@ Export gets datasets out of qri. By default it exports the dataset body, as ` +
 To export to a specific directory, use the --output flag.
 
 If you want an empty dataset that can be filled in with details to create a
-new dataset, use --blank.`,
+new dataset, use --blank.
+
+The sqlite format writes dataset bodies as typed tables of a SQLite database,
+with a qri_metadata table listing the meta & commit of each exported version.
+Pass more than one dataset reference to export them into the same database,
+or --all to export every dataset in the repo, and use --history to add a table
+for every previous version. With --as-of, history stops at the version that
+was the latest at that time.`,
 		Example: `  # export dataset
   qri export me/annual_pop
 
   # export to a specific directory
-  qri export -o ~/new_directory me/annual_pop`,
+  qri export -o ~/new_directory me/annual_pop
+
+  # export the version that was the latest at the start of 2020
+  qri export --as-of 2020-01-01 me/annual_pop
+
+  # export the body as a parquet file
+  qri export --format parquet me/annual_pop
+
+  # export two datasets & their history into one sqlite database
+  qri export --format sqlite --history -o pop.sqlite me/annual_pop me/births
+
+  # export every dataset in the repo, as of the start of 2020
+  qri export --all --as-of 2020-01-01 -o repo.sqlite`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -43,8 +62,11 @@ new dataset, use --blank.`,
 
 	cmd.Flags().BoolVarP(&o.Blank, "blank", "", false, "export a blank dataset YAML file, overrides all other flags except output")
 	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "path to write to, default is current directory")
-	cmd.Flags().StringVarP(&o.Format, "format", "f", "", "format for the exported dataset, such as native, json, xlsx. default: json")
+	cmd.Flags().StringVarP(&o.Format, "format", "f", "", "format for the exported dataset, such as native, json, xlsx, parquet, sqlite. default: json")
 	cmd.Flags().BoolVarP(&o.Zipped, "zip", "z", false, "export as a zip file")
+	cmd.Flags().StringVar(&o.AsOf, "as-of", "", "export the version of each dataset that was the latest at a point in time")
+	cmd.Flags().BoolVar(&o.History, "history", false, "export every version of each dataset, sqlite format only")
+	cmd.Flags().BoolVar(&o.All, "all", false, "export every dataset in the repo, sqlite format only")
 
 	return cmd
 }
@@ -53,11 +75,14 @@ new dataset, use --blank.`,
 type ExportOptions struct {
 	ioes.IOStreams
 
-	Refs   *RefSelect
-	Blank  bool
-	Output string
-	Format string
-	Zipped bool
+	Refs    *RefSelect
+	Blank   bool
+	Output  string
+	Format  string
+	Zipped  bool
+	AsOf    string
+	History bool
+	All     bool
 
 	UsingRPC       bool
 	ExportRequests *lib.ExportRequests
@@ -65,7 +90,12 @@ type ExportOptions struct {
 
 // Complete adds any missing configuration that can only be added just before calling Run
 func (o *ExportOptions) Complete(f Factory, args []string) (err error) {
-	if o.Refs, err = GetCurrentRefSelect(f, args, 1, nil); err != nil {
+	if o.All && len(args) > 0 {
+		return fmt.Errorf("can't export the whole repo and a list of datasets")
+	}
+	if o.All {
+		o.Refs = NewEmptyRefSelect()
+	} else if o.Refs, err = GetCurrentRefSelect(f, args, 2, nil); err != nil {
 		if err != repo.ErrEmptyRef {
 			return err
 		}
@@ -97,10 +127,21 @@ func (o *ExportOptions) Run() error {
 	}
 
 	p := &lib.ExportParams{
-		Ref:    o.Refs.Ref(),
-		Output: path,
-		Format: format,
-		Zipped: o.Zipped,
+		Ref:     o.Refs.Ref(),
+		Output:  path,
+		Format:  format,
+		Zipped:  o.Zipped,
+		History: o.History,
+		All:     o.All,
+	}
+	if refs := o.Refs.RefList(); len(refs) > 1 {
+		p.Refs = refs[1:]
+	}
+	if o.AsOf != "" {
+		var err error
+		if p.AsOf, err = repo.ParseAsOf(o.AsOf); err != nil {
+			return err
+		}
 	}
 
 	var fileWritten string
@@ -191,6 +191,16 @@ func (t TestFactory) FSIMethods() (*lib.FSIMethods, error) {
 	return lib.NewFSIMethods(t.inst), nil
 }
 
+// TokenMethods generates a lib.TokenMethods from internal state
+func (t TestFactory) TokenMethods() (*lib.TokenMethods, error) {
+	return lib.NewTokenMethods(t.inst), nil
+}
+
+// SQLMethods generates a lib.SQLMethods from internal state
+func (t TestFactory) SQLMethods() (*lib.SQLMethods, error) {
+	return lib.NewSQLMethods(t.inst), nil
+}
+
 // SearchMethods generates a lib.SearchMethods from internal state
 func (t TestFactory) SearchMethods() (*lib.SearchMethods, error) {
 	return lib.NewSearchMethods(t.inst), nil
@@ -3,12 +3,14 @@ package cmd
 import (
 	"bytes"
 	"fmt"
+	"time"
 
 	util "github.com/qri-io/apiutil"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/ioes"
 	"github.com/qri-io/qri/base/component"
 	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo"
 	"github.com/spf13/cobra"
 )
 
@@ -38,7 +40,14 @@ dataset and its fields.`,
   qri get structure.length me/annual_pop
 
   # print the dataset body size for two different datasets
-  qri get structure.length me/annual_pop me/annual_gdp`,
+  qri get structure.length me/annual_pop me/annual_gdp
+
+  # print the body as it was at the start of 2020
+  qri get body me/annual_pop --as-of 2020-01-01T00:00Z
+
+  # print the country & pop of body entries that match a condition, largest
+  # pop first
+  qri get body me/annual_pop --fields country,pop --where 'pop > 10 and country ~ "^A"' --sort -pop`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -59,6 +68,10 @@ dataset and its fields.`,
 	cmd.Flags().IntVar(&o.PageSize, "page-size", -1, "for body, limit how many entries to get per page")
 	cmd.Flags().IntVar(&o.Page, "page", -1, "for body, page at which to get entries")
 	cmd.Flags().BoolVarP(&o.All, "all", "a", true, "for body, whether to get all entries")
+	cmd.Flags().StringVar(&o.AsOf, "as-of", "", "get the version that was the latest at a point in time")
+	cmd.Flags().StringSliceVar(&o.Fields, "fields", nil, "for body, comma separated fields to include in each entry")
+	cmd.Flags().StringVar(&o.Where, "where", "", "for body, only get entries that match an expression")
+	cmd.Flags().StringSliceVar(&o.Sort, "sort", nil, "for body, fields to sort entries by. prefix a field with - to sort descending")
 
 	return cmd
 }
@@ -78,6 +91,12 @@ type GetOptions struct {
 	Pretty    bool
 	HasPretty bool
 
+	AsOf string
+
+	Fields []string
+	Where  string
+	Sort   []string
+
 	DatasetRequests *lib.DatasetRequests
 }
 
@@ -116,6 +135,15 @@ func (o *GetOptions) Complete(f Factory, args []string) (err error) {
 		if !o.All {
 			return fmt.Errorf("can only use --all flag when getting body")
 		}
+		if len(o.Fields) > 0 {
+			return fmt.Errorf("can only use --fields flag when getting body")
+		}
+		if o.Where != "" {
+			return fmt.Errorf("can only use --where flag when getting body")
+		}
+		if len(o.Sort) > 0 {
+			return fmt.Errorf("can only use --sort flag when getting body")
+		}
 	}
 
 	return nil
@@ -133,18 +161,29 @@ func (o *GetOptions) Run() (err error) {
 		fc = &opt
 	}
 
+	var asOf time.Time
+	if o.AsOf != "" {
+		if asOf, err = repo.ParseAsOf(o.AsOf); err != nil {
+			return err
+		}
+	}
+
 	// convert Page and PageSize to Limit and Offset
 	page := util.NewPage(o.Page, o.PageSize)
 	// TODO(dlong): Restore ability to `get` from multiple datasets at once.
 	p := lib.GetParams{
 		Path:         o.Refs.Ref(),
 		Selector:     o.Selector,
-		UseFSI:       o.Refs.IsLinked(),
+		UseFSI:       o.Refs.IsLinked() && asOf.IsZero(),
 		Format:       o.Format,
 		FormatConfig: fc,
 		Offset:       page.Offset(),
 		Limit:        page.Limit(),
 		All:          o.All,
+		Fields:       o.Fields,
+		Where:        o.Where,
+		Sort:         o.Sort,
+		AsOf:         asOf,
 	}
 	res := lib.GetResult{}
 	if err = o.DatasetRequests.Get(&p, &res); err != nil {
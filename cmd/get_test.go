@@ -67,3 +67,35 @@ func TestGetComplete(t *testing.T) {
 		ioReset(in, out, errs)
 	}
 }
+
+func TestGetBodyQuery(t *testing.T) {
+	run := NewTestRunner(t, "test_peer", "qri_test_get_body_query")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save --body=testdata/movies/body_ten.csv me/get_movies")
+
+	run.CmdR = run.CreateCommandRunner(run.Context)
+	if err := executeCommandC(run.CmdR, "get", "body", "--format", "csv", "--fields", "duration,movie_title", "--where", `duration > 150 and movie_title ~ "^[AS]"`, "--sort", "-duration", "me/get_movies"); err != nil {
+		t.Fatal(err)
+	}
+	expect := "duration,movie_title\n178,Avatar \n156,Spider-Man 3 \n\n"
+	if output := run.GetCommandOutput(); output != expect {
+		t.Errorf("output mismatch. expected:\n%q\ngot:\n%q", expect, output)
+	}
+
+	// paging applies to matching entries
+	run.CmdR = run.CreateCommandRunner(run.Context)
+	if err := executeCommandC(run.CmdR, "get", "body", "--fields", "movie_title", "--sort", "duration", "--where", "duration", "--page-size", "2", "--page", "2", "me/get_movies"); err != nil {
+		t.Fatal(err)
+	}
+	expect = "movie_title\nSpectre \nSpider-Man 3 \n\n"
+	if output := run.GetCommandOutput(); output != expect {
+		t.Errorf("output mismatch. expected:\n%q\ngot:\n%q", expect, output)
+	}
+
+	run.CmdR = run.CreateCommandRunner(run.Context)
+	err := executeCommandC(run.CmdR, "get", "meta", "--where", "duration > 1", "me/get_movies")
+	if err == nil || err.Error() != "can only use --where flag when getting body" {
+		t.Errorf("expected an error using --where without body, got: %v", err)
+	}
+}
@@ -45,6 +45,7 @@ working backwards in time.`,
 	// cmd.Flags().StringVarP(&o.Format, "format", "f", "", "set output format [json]")
 	cmd.Flags().IntVar(&o.PageSize, "page-size", 25, "page size of results, default 25")
 	cmd.Flags().IntVar(&o.Page, "page", 1, "page number of results, default 1")
+	cmd.Flags().StringVar(&o.Branch, "branch", "", "show history of a branch")
 
 	return cmd
 }
@@ -55,6 +56,7 @@ type LogOptions struct {
 
 	PageSize int
 	Page     int
+	Branch   string
 	Refs     *RefSelect
 
 	LogRequests *lib.LogRequests
@@ -77,7 +79,8 @@ func (o *LogOptions) Run() error {
 	page := util.NewPage(o.Page, o.PageSize)
 
 	p := &lib.LogParams{
-		Ref: o.Refs.Ref(),
+		Ref:    o.Refs.Ref(),
+		Branch: o.Branch,
 		ListParams: lib.ListParams{
 			Limit:  page.Limit(),
 			Offset: page.Offset(),
@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/fatih/color"
+	"github.com/qri-io/deepdiff"
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/repo"
+	"github.com/spf13/cobra"
+)
+
+// NewMergeCommand creates a new `qri merge` cobra command
+func NewMergeCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &MergeOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "merge [DATASET] THEIRS",
+		Short: "Combine two histories of a dataset",
+		Long: `
+Merge combines changes from another history of a dataset into a branch. THEIRS
+is either the name of another branch of the dataset, or a dataset reference
+with a version path, like a version fetched from another peer.
+
+Merge compares both histories against their nearest common ancestor. Changes
+made on only one side are combined, down to individual values in the body.
+When both sides change the same value in different ways, the merge has
+conflicts and no version is saved. If the dataset is checked out to a working
+directory, the merged components are written there with a conflict marker in
+place of each conflicting value, like:
+
+  ======= conflict: ours "Y", base "B", theirs "X" =======
+
+Replace each marker with the resolved value, then run ` + "`qri save`" + ` to save the
+merge. To abandon the merge, run ` + "`qri restore`" + `.
+
+Merges are saved to the active branch, add an "@branch" suffix to the
+dataset reference to merge into a different branch.`,
+		Example: `  merge the cleanup branch into the active branch:
+  $ qri merge me/annual_pop cleanup
+
+  merge the main branch into the cleanup branch:
+  $ qri merge me/annual_pop@cleanup main
+
+  merge a version fetched from another peer:
+  $ qri merge me/annual_pop b5/annual_pop@/ipfs/QmFoo`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Title, "title", "t", "", "title of the merge commit")
+	cmd.Flags().StringVarP(&o.Message, "message", "m", "", "commit message for the merge")
+	cmd.Flags().BoolVarP(&o.DryRun, "dry-run", "n", false, "merge without saving")
+
+	return cmd
+}
+
+// MergeOptions encapsulates state for the merge command
+type MergeOptions struct {
+	ioes.IOStreams
+
+	Refs    *RefSelect
+	Theirs  string
+	Title   string
+	Message string
+	DryRun  bool
+
+	DatasetRequests *lib.DatasetRequests
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *MergeOptions) Complete(f Factory, args []string) (err error) {
+	o.Theirs = args[len(args)-1]
+	if o.Refs, err = GetCurrentRefSelect(f, args[:len(args)-1], 1, nil); err != nil {
+		return err
+	}
+	o.DatasetRequests, err = f.DatasetRequests()
+	return
+}
+
+// Run executes the merge command
+func (o *MergeOptions) Run() error {
+	printRefSelect(o.ErrOut, o.Refs)
+
+	p := &lib.MergeParams{
+		Ref:     o.Refs.Ref(),
+		Theirs:  o.Theirs,
+		Title:   o.Title,
+		Message: o.Message,
+		DryRun:  o.DryRun,
+	}
+	res := &lib.MergeResponse{}
+	if err := o.DatasetRequests.Merge(p, res); err != nil {
+		if err == repo.ErrEmptyRef {
+			return lib.NewError(err, "please provide a dataset reference")
+		}
+		return err
+	}
+
+	if res.UpToDate {
+		printInfo(o.Out, "already up to date")
+		return nil
+	}
+
+	if res.OursStat != nil && res.TheirsStat != nil {
+		printInfo(o.Out, "ours:   %s", deepdiff.FormatPrettyStatsString(res.OursStat, !color.NoColor))
+		printInfo(o.Out, "theirs: %s", deepdiff.FormatPrettyStatsString(res.TheirsStat, !color.NoColor))
+	}
+
+	if len(res.Conflicts) > 0 {
+		printWarning(o.ErrOut, "%d conflicts:", len(res.Conflicts))
+		for _, c := range res.Conflicts {
+			printInfo(o.ErrOut, "  %s", c)
+		}
+		if res.WorkingDir != "" {
+			return fmt.Errorf("merge has conflicts. replace the conflict markers in %s with resolved values, then run `qri save`", res.WorkingDir)
+		}
+		return fmt.Errorf("merge has conflicts, check out the dataset to resolve them")
+	}
+
+	if o.DryRun {
+		printSuccess(o.Out, "merge has no conflicts")
+		return nil
+	}
+	printSuccess(o.Out, "dataset merged: %s", res.Ref.String())
+	return nil
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/qri/fsi"
+)
+
+// Test that branches with changes to different rows merge cleanly
+func TestMergeBranches(t *testing.T) {
+	run := NewFSITestRunner(t, "qri_test_merge_branches")
+	defer run.Delete()
+
+	run.ChdirToRoot()
+
+	run.MustWriteFile(t, "base.json", `[[1,"a"],[2,"b"],[3,"c"]]`)
+	run.MustExec(t, "qri save --body=base.json me/merge_ds")
+	run.MustExec(t, "qri branch me/merge_ds feature")
+
+	run.MustWriteFile(t, "ours.json", `[[1,"a"],[2,"B"],[3,"c"]]`)
+	run.MustExec(t, "qri save --body=ours.json me/merge_ds")
+	run.MustWriteFile(t, "theirs.json", `[[1,"a"],[2,"b"],[3,"c"],[4,"d"]]`)
+	run.MustExec(t, "qri save --body=theirs.json --branch=feature me/merge_ds")
+
+	output := run.MustExec(t, "qri branch me/merge_ds")
+	expect := "* main\n  feature\n"
+	if diff := cmpTextLines(expect, output); diff != "" {
+		t.Errorf("qri branch (-want +got):\n%s", diff)
+	}
+
+	output = run.MustExec(t, "qri merge me/merge_ds feature")
+	if !strings.Contains(output, "dataset merged") {
+		t.Errorf("expected merge to succeed, got: %s", output)
+	}
+
+	output = run.MustExec(t, "qri get body --format=json me/merge_ds")
+	expect = `[[1,"a"],[2,"B"],[3,"c"],[4,"d"]]`
+	if diff := cmpTextLines(expect, strings.TrimSpace(output)); diff != "" {
+		t.Errorf("merged body (-want +got):\n%s", diff)
+	}
+
+	output = run.MustExec(t, "qri log me/merge_ds")
+	if !strings.Contains(output, "merge feature into main") {
+		t.Errorf("expected log to contain merge commit, got: %s", output)
+	}
+
+	output = run.MustExec(t, "qri merge me/merge_ds feature")
+	if !strings.Contains(output, "already up to date") {
+		t.Errorf("expected second merge to be up to date, got: %s", output)
+	}
+}
+
+// Test that conflicts are written to a linked working directory, and that
+// saving the directory completes the merge
+func TestMergeConflictsInWorkingDirectory(t *testing.T) {
+	run := NewFSITestRunner(t, "qri_test_merge_conflicts")
+	defer run.Delete()
+
+	run.ChdirToRoot()
+
+	run.MustWriteFile(t, "base.json", `[[1,"a"],[2,"b"],[3,"c"]]`)
+	run.MustExec(t, "qri save --body=base.json me/merge_ds")
+	run.MustExec(t, "qri branch me/merge_ds feature")
+
+	run.MustWriteFile(t, "ours.json", `[[1,"a"],[2,"Y"],[3,"c"]]`)
+	run.MustExec(t, "qri save --body=ours.json me/merge_ds")
+	run.MustWriteFile(t, "theirs.json", `[[1,"a"],[2,"X"],[3,"c"]]`)
+	run.MustExec(t, "qri save --body=theirs.json --branch=feature me/merge_ds")
+
+	run.MustExec(t, "qri checkout me/merge_ds")
+	workDir := run.ChdirToWorkDir("merge_ds")
+
+	err := run.ExecCommand("qri merge me/merge_ds feature")
+	if err == nil {
+		t.Fatal("expected merge with conflicts to error")
+	}
+	if !strings.Contains(err.Error(), "merge has conflicts") {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	state, err := fsi.ReadMergeState(workDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Conflicts) != 1 || state.Conflicts[0].Path != "/body/1/1" {
+		t.Errorf("unexpected conflicts: %v", state.Conflicts)
+	}
+
+	body := run.MustReadFile(t, "body.json")
+	if !strings.Contains(body, `======= conflict: ours \"Y\", base \"b\", theirs \"X\" =======`) {
+		t.Errorf("expected body to mark the conflict, got: %s", body)
+	}
+	err = run.ExecCommand("qri save")
+	if err == nil || !strings.Contains(err.Error(), "remove the conflict markers from body.json") {
+		t.Errorf("expected saving unresolved conflicts to error, got: %v", err)
+	}
+
+	run.MustWriteFile(t, "body.json", `[[1,"a"],[2,"XY"],[3,"c"]]`)
+	run.MustExec(t, "qri save")
+
+	if _, err := os.Stat(filepath.Join(workDir, fsi.MergeStateFilename)); !os.IsNotExist(err) {
+		t.Errorf("expected saving to remove merge state")
+	}
+
+	output := run.MustExec(t, "qri merge me/merge_ds feature")
+	if !strings.Contains(output, "already up to date") {
+		t.Errorf("expected resolved merge to be up to date, got: %s", output)
+	}
+}
@@ -41,7 +41,10 @@ https://github.com/qri-io/qri/issues`,
 	cmd.PersistentFlags().BoolVarP(&opt.LogAll, "log-all", "", false, "log all activity")
 
 	cmd.AddCommand(
+		NewAccessCommand(opt, ioStreams),
+		NewApplyCommand(opt, ioStreams),
 		NewAddCommand(opt, ioStreams),
+		NewBranchCommand(opt, ioStreams),
 		NewCheckoutCommand(opt, ioStreams),
 		NewConfigCommand(opt, ioStreams),
 		NewConnectCommand(opt, ioStreams),
@@ -55,6 +58,7 @@ https://github.com/qri-io/qri/issues`,
 		NewListCommand(opt, ioStreams),
 		NewLogCommand(opt, ioStreams),
 		NewLogbookCommand(opt, ioStreams),
+		NewMergeCommand(opt, ioStreams),
 		NewPublishCommand(opt, ioStreams),
 		NewPeersCommand(opt, ioStreams),
 		NewRegistryCommand(opt, ioStreams),
@@ -65,6 +69,7 @@ https://github.com/qri-io/qri/issues`,
 		NewSaveCommand(opt, ioStreams),
 		NewSearchCommand(opt, ioStreams),
 		NewSetupCommand(opt, ioStreams),
+		NewSQLCommand(opt, ioStreams),
 		NewStatsCommand(opt, ioStreams),
 		NewStatusCommand(opt, ioStreams),
 		NewUseCommand(opt, ioStreams),
@@ -271,3 +276,21 @@ func (o *QriOptions) FSIMethods() (m *lib.FSIMethods, err error) {
 
 	return lib.NewFSIMethods(o.inst), nil
 }
+
+// TokenMethods generates a lib.TokenMethods from internal state
+func (o *QriOptions) TokenMethods() (m *lib.TokenMethods, err error) {
+	if err = o.Init(); err != nil {
+		return
+	}
+
+	return lib.NewTokenMethods(o.inst), nil
+}
+
+// SQLMethods generates a lib.SQLMethods from internal state
+func (o *QriOptions) SQLMethods() (m *lib.SQLMethods, err error) {
+	if err = o.Init(); err != nil {
+		return
+	}
+
+	return lib.NewSQLMethods(o.inst), nil
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/qri-io/qri/lib"
 	"github.com/qri-io/qri/repo"
 	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/startf"
 	"github.com/spf13/cobra"
 )
 
@@ -35,7 +36,19 @@ When you make an update and save a dataset that you originally added from a diff
 peer, the dataset gets renamed from ` + "`peers_name/dataset_name`" + ` to ` + "`my_name/dataset_name`" + `.
 
 The ` + "`--message`" + `" and ` + "`--title`" + ` flags allow you to add a 
-commit message and title to the save.`,
+commit message and title to the save.
+
+The ` + "`--infer-schema`" + ` flag replaces the body schema with one inferred from
+the body values, using the tightest type that fits every value in a column:
+integer or number, enums for strings with few distinct values, date and
+date-time formats, and allowing null for columns with empty cells. Bodies
+produced by a transform are inferred once the transform runs, and saves
+without a body keep their schema. Use ` + "`--dry-run`" + ` to review the inferred
+schema before saving.
+
+Parquet body files are converted to the format of the dataset's previous
+version when saved, or json for new datasets, keeping the column types of the
+parquet file as the body schema.`,
 		Example: `  # save updated data to dataset annual_pop:
   qri save --body /path/to/data.csv me/annual_pop
 
@@ -43,7 +56,16 @@ commit message and title to the save.`,
   qri save --file /path/to/dataset.yaml me/annual_pop
   
   # re-execute a dataset that has a transform:
-  qri save me/tf_dataset`,
+  qri save me/tf_dataset
+
+  # re-execute a transform, recording downloaded responses for replay:
+  qri save --record-fixtures me/tf_dataset
+
+  # save csv data with a schema inferred from the data:
+  qri save --body /path/to/data.csv --infer-schema me/annual_pop
+
+  # save the rows of a parquet file:
+  qri save --body /path/to/data.parquet me/annual_pop`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -66,6 +88,7 @@ commit message and title to the save.`,
 	// cmd.Flags().BoolVarP(&o.ShowValidation, "show-validation", "s", false, "display a list of validation errors upon adding")
 	cmd.Flags().StringSliceVar(&o.Secrets, "secrets", nil, "transform secrets as comma separated key,value,key,value,... sequence")
 	cmd.Flags().BoolVarP(&o.Publish, "publish", "p", false, "publish this dataset to the registry")
+	cmd.Flags().BoolVar(&o.Private, "private", false, "encrypt this dataset at rest. private datasets can't be published")
 	cmd.Flags().BoolVar(&o.DryRun, "dry-run", false, "simulate saving a dataset")
 	cmd.Flags().BoolVar(&o.Force, "force", false, "force a new commit, even if no changes are detected")
 	cmd.Flags().BoolVarP(&o.KeepFormat, "keep-format", "k", false, "convert incoming data to stored data format")
@@ -73,6 +96,12 @@ commit message and title to the save.`,
 	cmd.Flags().BoolVar(&o.NoRender, "no-render", false, "don't store a rendered version of the the vizualization ")
 	cmd.Flags().BoolVarP(&o.NewName, "new", "n", false, "save a new dataset only, using an available name")
 	cmd.Flags().BoolVarP(&o.UseDscache, "use-dscache", "", false, "build and use dscache if none exists")
+	cmd.Flags().StringVar(&o.Branch, "branch", "", "branch to save to, defaults to the active branch")
+	cmd.Flags().BoolVar(&o.RecordFixtures, "record-fixtures", false, "save responses the transform downloads, for use with apply --replay")
+	cmd.Flags().BoolVar(&o.InferSchema, "infer-schema", false, "replace the body schema with one inferred from body values")
+	cmd.Flags().DurationVar(&o.TransformLimits.Timeout, "transform-timeout", 0, "stop the transform if it runs longer than this, eg: 10m. defaults to no limit")
+	cmd.Flags().Uint64Var(&o.TransformLimits.MaxSteps, "transform-max-steps", 0, "stop the transform if it runs more execution steps than this. defaults to no limit")
+	cmd.Flags().IntVar(&o.TransformLimits.MaxBodyEntries, "transform-max-body-entries", 0, "stop the transform if it produces more body entries than this. defaults to no limit")
 
 	return cmd
 }
@@ -93,6 +122,7 @@ type SaveOptions struct {
 	Replace        bool
 	ShowValidation bool
 	Publish        bool
+	Private        bool
 	DryRun         bool
 	KeepFormat     bool
 	Force          bool
@@ -100,6 +130,12 @@ type SaveOptions struct {
 	Secrets        []string
 	NewName        bool
 	UseDscache     bool
+	Branch         string
+	RecordFixtures bool
+	InferSchema    bool
+
+	// TransformLimits caps the resources the transform can use
+	TransformLimits startf.Limits
 
 	DatasetRequests *lib.DatasetRequests
 	FSIMethods      *lib.FSIMethods
@@ -163,7 +199,7 @@ func (o *SaveOptions) Run() (err error) {
 		ReadFSI:             o.UsingFSI,
 		WriteFSI:            o.UsingFSI,
 		FilePaths:           o.FilePaths,
-		Private:             false,
+		Private:             o.Private,
 		Publish:             o.Publish,
 		DryRun:              o.DryRun,
 		Recall:              o.Recall,
@@ -173,6 +209,10 @@ func (o *SaveOptions) Run() (err error) {
 		ShouldRender:        !o.NoRender,
 		NewName:             o.NewName,
 		UseDscache:          o.UseDscache,
+		Branch:              o.Branch,
+		RecordFixtures:      o.RecordFixtures,
+		InferSchema:         o.InferSchema,
+		TransformLimits:     o.TransformLimits,
 	}
 
 	if o.Secrets != nil {
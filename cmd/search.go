@@ -21,10 +21,18 @@ func NewSearchCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 		Long: `
 Search datasets & peers that match your query. Search pings the qri registry. 
 
-Any dataset that has been published to the registry is available for search.`,
+Any dataset that has been published to the registry is available for search.
+
+Use --local to search datasets in your repo without a registry. Local search
+matches dataset names, meta titles, descriptions, keywords & themes, schema
+field names, and readme text. Private datasets aren't included in local
+search results.`,
 		Example: `
   # search 
-  $ qri search "annual population"`,
+  $ qri search "annual population"
+
+  # search datasets in your repo
+  $ qri search --local rainfall`,
 		Annotations: map[string]string{
 			"group": "network",
 		},
@@ -42,6 +50,7 @@ Any dataset that has been published to the registry is available for search.`,
 	cmd.Flags().StringVarP(&o.Format, "format", "f", "", "set output format [json]")
 	cmd.Flags().IntVar(&o.PageSize, "page-size", 25, "page size of results, default 25")
 	cmd.Flags().IntVar(&o.Page, "page", 1, "page number of results, default 1")
+	cmd.Flags().BoolVar(&o.Local, "local", false, "search datasets in the local repo instead of the registry")
 
 	return cmd
 }
@@ -54,6 +63,7 @@ type SearchOptions struct {
 	Format   string
 	PageSize int
 	Page     int
+	Local    bool
 	// Reindex bool
 
 	SearchMethods *lib.SearchMethods
@@ -90,6 +100,7 @@ func (o *SearchOptions) Run() (err error) {
 		QueryString: o.Query,
 		Limit:       page.Limit(),
 		Offset:      page.Offset(),
+		Local:       o.Local,
 	}
 
 	results := []lib.SearchResult{}
@@ -0,0 +1,123 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/qri-io/ioes"
+	"github.com/qri-io/qri/lib"
+	"github.com/spf13/cobra"
+)
+
+// NewSQLCommand creates a new `qri sql` command that queries datasets
+func NewSQLCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
+	o := &SQLOptions{IOStreams: ioStreams}
+	cmd := &cobra.Command{
+		Use:   "sql QUERY",
+		Short: "Query dataset bodies with SQL",
+		Long: `
+Run a SQL SELECT statement against the bodies of datasets. Datasets are named
+by reference in FROM & JOIN clauses, and can be read at a specific version by
+adding @path to the reference. Each dataset is a table named after the dataset,
+use AS to give it another name.
+
+Queries support selecting columns & expressions, WHERE, GROUP BY with COUNT,
+SUM, AVG, MIN & MAX, HAVING, ORDER BY, LIMIT & OFFSET, DISTINCT, and inner,
+left & cross joins. Rows of the first dataset are streamed, joined datasets are
+read into memory.
+
+Results print as csv by default. Use --format to choose json, cbor or xlsx,
+and --output to write results to a file.`,
+		Example: `  # select the first ten rows of a dataset
+  qri sql "SELECT * FROM me/annual_pop LIMIT 10"
+
+  # join two datasets
+  qri sql "SELECT p.country, p.pop, g.gdp FROM me/annual_pop p JOIN me/annual_gdp g ON p.country = g.country"
+
+  # compare a dataset with a previous version
+  qri sql "SELECT new.country, new.pop - old.pop FROM me/annual_pop new JOIN me/annual_pop@/ipfs/QmHash old ON new.country = old.country"
+
+  # write aggregated results to an excel file
+  qri sql --format xlsx -o totals.xlsx "SELECT region, SUM(pop) FROM me/annual_pop GROUP BY region"`,
+		Annotations: map[string]string{
+			"group": "dataset",
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			if err := o.Validate(); err != nil {
+				return err
+			}
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Format, "format", "f", "csv", "output format [csv, json, cbor, xlsx]")
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "", "path to write results to")
+
+	return cmd
+}
+
+// SQLOptions encapsulates state for the sql command
+type SQLOptions struct {
+	ioes.IOStreams
+
+	Query  string
+	Format string
+	Output string
+
+	SQLMethods *lib.SQLMethods
+}
+
+// Complete adds any missing configuration that can only be added just before calling Run
+func (o *SQLOptions) Complete(f Factory, args []string) (err error) {
+	o.Query = strings.Join(args, " ")
+	o.SQLMethods, err = f.SQLMethods()
+	return
+}
+
+// Validate checks that all user input is valid
+func (o *SQLOptions) Validate() error {
+	if strings.TrimSpace(o.Query) == "" {
+		return lib.NewError(lib.ErrBadArgs, "please provide a query, for example:\n    $ qri sql \"SELECT * FROM me/dataset LIMIT 10\"\nsee `qri sql --help` for more information")
+	}
+	if o.Output == "" && (o.Format == "cbor" || o.Format == "xlsx") {
+		return fmt.Errorf("%s results must be written to a file, use --output", o.Format)
+	}
+	return nil
+}
+
+// Run executes the sql command
+func (o *SQLOptions) Run() error {
+	p := &lib.SQLParams{
+		Query:  o.Query,
+		Format: o.Format,
+	}
+	if o.Output != "" {
+		f, err := os.Create(o.Output)
+		if err != nil {
+			return err
+		}
+		p.Output = f
+		var res []byte
+		err = o.SQLMethods.Exec(p, &res)
+		if e := f.Close(); e != nil && err == nil {
+			err = e
+		}
+		if err != nil {
+			os.Remove(o.Output)
+			return err
+		}
+		printSuccess(o.ErrOut, "query results written to %s", o.Output)
+		return nil
+	}
+
+	var res []byte
+	if err := o.SQLMethods.Exec(p, &res); err != nil {
+		return err
+	}
+	return printToPager(o.Out, bytes.NewBuffer(res))
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSQLRun(t *testing.T) {
+	run := NewTestRunner(t, "test_peer", "qri_test_sql")
+	defer run.Delete()
+
+	run.MustExec(t, "qri save --body=testdata/movies/body_ten.csv me/sql_movies")
+
+	run.CmdR = run.CreateCommandRunner(run.Context)
+	if err := executeCommandC(run.CmdR, "sql", "SELECT movie_title, duration FROM me/sql_movies WHERE duration > 160 ORDER BY duration DESC"); err != nil {
+		t.Fatal(err)
+	}
+	expect := "movie_title,duration\nAvatar ,178\nPirates of the Caribbean: At World's End ,169\nThe Dark Knight Rises ,164\n"
+	if output := run.GetCommandOutput(); output != expect {
+		t.Errorf("output mismatch. expected:\n%s\ngot:\n%s", expect, output)
+	}
+
+	path := filepath.Join(run.RepoRoot.RootPath, "count.json")
+	run.CmdR = run.CreateCommandRunner(run.Context)
+	if err := executeCommandC(run.CmdR, "sql", "--format", "json", "-o", path, "SELECT", "COUNT(*)", "FROM", "me/sql_movies"); err != nil {
+		t.Fatal(err)
+	}
+	if data := run.MustReadFile(t, path); data != "[[8]]" {
+		t.Errorf("expected a count of 8, got: %s", data)
+	}
+
+	run.CmdR = run.CreateCommandRunner(run.Context)
+	err := executeCommandC(run.CmdR, "sql", "--format", "xlsx", "SELECT * FROM me/sql_movies")
+	if err == nil || !strings.Contains(err.Error(), "xlsx results must be written to a file") {
+		t.Errorf("expected an error writing xlsx to the terminal, got: %v", err)
+	}
+}
@@ -1,10 +1,12 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/qri-io/ioes"
 	"github.com/qri-io/qri/lib"
+	"github.com/qri-io/qri/stats"
 	"github.com/spf13/cobra"
 )
 
@@ -15,9 +17,22 @@ func NewStatsCommand(f Factory, ioStreams ioes.IOStreams) *cobra.Command {
 		Use:   "stats",
 		Short: "Get aggregated stats for a dataset",
 		Long: `
-Run the ` + "`stats`" + ` to generate and view stats for a dataset using a dataset reference.`,
+Run the ` + "`stats`" + ` to generate and view stats for a dataset using a dataset reference.
+
+Use --compare to check the stats of a dataset version against an earlier
+version. Compare reports per-column changes in mean, null ratio, distinct
+count, and value distribution (as a population stability index), flagging
+columns that changed more than the --max thresholds allow. --compare accepts a
+revision relative to the given reference like HEAD~1, or another dataset
+reference.`,
 		Example: `  # get stats for me/dataset_name:
-  qri stats me/dataset_name`,
+  qri stats me/dataset_name
+
+  # check the latest version of me/dataset_name for drift from the previous:
+  qri stats me/dataset_name --compare HEAD~1
+
+  # flag columns whose null ratio changed by more than 1%:
+  qri stats me/dataset_name --compare HEAD~1 --max-null-ratio-change 0.01`,
 		Annotations: map[string]string{
 			"group": "dataset",
 		},
@@ -34,6 +49,12 @@ Run the ` + "`stats`" + ` to generate and view stats for a dataset using a datas
 	}
 
 	cmd.Flags().BoolVarP(&o.Pretty, "pretty", "p", true, "clear the current selection")
+	cmd.Flags().StringVar(&o.Compare, "compare", "", "version to compare stats against, eg: HEAD~1")
+	cmd.Flags().StringVarP(&o.Format, "format", "f", "pretty", "output format for --compare. one of [json,pretty]")
+	cmd.Flags().Float64Var(&o.Thresholds.MeanChange, "max-mean-change", stats.DefaultDriftThresholds.MeanChange, "largest allowed change in a column mean, relative to the previous mean")
+	cmd.Flags().Float64Var(&o.Thresholds.NullRatioChange, "max-null-ratio-change", stats.DefaultDriftThresholds.NullRatioChange, "largest allowed change in the fraction of null values in a column")
+	cmd.Flags().Float64Var(&o.Thresholds.DistinctChange, "max-distinct-change", stats.DefaultDriftThresholds.DistinctChange, "largest allowed change in a column distinct count, relative to the previous count")
+	cmd.Flags().Float64Var(&o.Thresholds.PSI, "max-psi", stats.DefaultDriftThresholds.PSI, "largest allowed population stability index of a column value distribution")
 
 	return cmd
 }
@@ -45,6 +66,10 @@ type StatsOptions struct {
 	Ref    string
 	Pretty bool
 
+	Compare    string
+	Format     string
+	Thresholds stats.DriftThresholds
+
 	DatasetRequests *lib.DatasetRequests
 }
 
@@ -63,11 +88,21 @@ func (o *StatsOptions) Complete(f Factory, args []string) (err error) {
 
 // Validate checks that any user input is valide
 func (o *StatsOptions) Validate() error {
+	if o.Compare == "" {
+		return nil
+	}
+	if o.Format != "" && o.Format != "json" && o.Format != "pretty" {
+		return fmt.Errorf("invalid format %q, must be one of [json,pretty]", o.Format)
+	}
 	return nil
 }
 
 // Run executes the search command
 func (o *StatsOptions) Run() (err error) {
+	if o.Compare != "" {
+		return o.runCompare()
+	}
+
 	p := &lib.StatsParams{Ref: o.Ref}
 	r := &lib.StatsResponse{}
 	if err = o.DatasetRequests.Stats(p, r); err != nil {
@@ -81,3 +116,23 @@ func (o *StatsOptions) Run() (err error) {
 	printInfo(o.Out, string(r.StatsBytes))
 	return nil
 }
+
+// runCompare prints the changes in stats between two versions of a dataset
+func (o *StatsOptions) runCompare() error {
+	th := o.Thresholds
+	p := &lib.StatsDiffParams{
+		Ref:        o.Ref,
+		Compare:    o.Compare,
+		Thresholds: &th,
+	}
+	res := &stats.DriftReport{}
+	if err := o.DatasetRequests.StatsDiff(p, res); err != nil {
+		return err
+	}
+
+	if o.Format == "json" {
+		return json.NewEncoder(o.Out).Encode(res)
+	}
+	printInfo(o.Out, driftReportStringer(*res).String())
+	return nil
+}
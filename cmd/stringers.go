@@ -13,6 +13,8 @@ import (
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/lib"
 	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/stats"
+	"github.com/qri-io/qri/token"
 	"github.com/qri-io/qri/update/cron"
 )
 
@@ -220,9 +222,25 @@ type jobStringer cron.Job
 func (j jobStringer) String() string {
 	w := &bytes.Buffer{}
 	name := color.New(color.Bold).SprintFunc()
-	t := j.Periodicity.After(j.PrevRunStart)
-	relTime := humanize.RelTime(time.Now().In(time.UTC), t, "", "")
-	fmt.Fprintf(w, "%s\nin %sat %s | %s\n", name(j.Name), relTime, t.In(StringerLocation).Format(time.Kitchen), j.Type)
+	if j.Paused {
+		fmt.Fprintf(w, "%s\npaused | %s\n", name(j.Name), j.Type)
+	} else if t := (*cron.Job)(&j).NextExec(); !t.IsZero() {
+		relTime := humanize.RelTime(time.Now().In(time.UTC), t, "", "")
+		fmt.Fprintf(w, "%s\nin %sat %s | %s\n", name(j.Name), relTime, t.In(StringerLocation).Format(time.Kitchen), j.Type)
+	} else {
+		fmt.Fprintf(w, "%s\nafter upstream updates | %s\n", name(j.Name), j.Type)
+	}
+	if j.Failures > 0 {
+		failing := color.New(color.FgRed).SprintFunc()
+		fmt.Fprintf(w, "\n%s\n", failing(fmt.Sprintf("failed %d time(s) in a row: %s", j.Failures, oneLiner(j.RunError, 40))))
+	}
+	if !j.NextRetry.IsZero() && !j.Paused {
+		relTime := humanize.RelTime(time.Now().In(time.UTC), j.NextRetry, "", "")
+		fmt.Fprintf(w, "retry %d in %sat %s\n", j.Retries+1, relTime, j.NextRetry.In(StringerLocation).Format(time.Kitchen))
+	}
+	if len(j.Upstream) > 0 {
+		fmt.Fprintf(w, "\nafter: %s\n", strings.Join(j.Upstream, ", "))
+	}
 	if j.RepoPath != "" {
 		fmt.Fprintf(w, "\nrepo: %s\n", j.RepoPath)
 	}
@@ -248,6 +266,8 @@ func (j finishedJobStringer) String() string {
 			msg = "dataset updated"
 		} else if j.Type == cron.JTShellScript {
 			msg = "script ran successfully"
+		} else if j.Type == cron.JTTransform {
+			msg = "transform ran successfully"
 		}
 	}
 
@@ -310,3 +330,127 @@ func (s dslogItemStringer) String() string {
 
 	return msg
 }
+
+type versionChangesStringer lib.VersionChanges
+
+func (s versionChangesStringer) String() string {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	faint := color.New(color.Faint).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	msg := fmt.Sprintf("%s%s\n%s%s\n%s%s %s %s\n",
+		faint("Commit:  "),
+		yellow(s.Path),
+		faint("Date:    "),
+		s.CommitTime.In(StringerLocation).Format(time.UnixDate),
+		faint("Rows:    "),
+		green(fmt.Sprintf("+%d added", s.RowsAdded)),
+		red(fmt.Sprintf("-%d removed", s.RowsRemoved)),
+		yellow(fmt.Sprintf("~%d modified", s.RowsModified)),
+	)
+	if len(s.Schema) > 0 {
+		msg += fmt.Sprintf("%s%s\n", faint("Schema:  "), yellow("changed"))
+	}
+	msg += fmt.Sprintf("\n%s\n", s.CommitTitle)
+	if s.Message != "" {
+		msg += fmt.Sprintf("%s\n", s.Message)
+	} else if len(s.Schema) == 0 && s.RowsAdded+s.RowsRemoved+s.RowsModified == 0 {
+		msg += "no body or schema changes\n"
+	}
+	msg += "\n"
+
+	return msg
+}
+
+type driftReportStringer stats.DriftReport
+
+func (s driftReportStringer) String() string {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	faint := color.New(color.Faint).SprintFunc()
+	green := color.New(color.FgGreen).SprintFunc()
+	red := color.New(color.FgRed).SprintFunc()
+
+	change := func(name string, c *stats.Change, flag string, flags []string) string {
+		if c == nil {
+			return ""
+		}
+		line := fmt.Sprintf("  %s%v -> %v", faint(fmt.Sprintf("%-11s", name+":")), c.Prev, c.Next)
+		for _, f := range flags {
+			if f == flag {
+				return red(line) + "\n"
+			}
+		}
+		return line + "\n"
+	}
+
+	msg := ""
+	for _, col := range s.Columns {
+		typ := col.Type
+		if typ == "" {
+			typ = col.PrevType
+		}
+		name := fmt.Sprintf("%s %s", yellow(col.Column), faint(typ))
+		if len(col.Flags) == 0 {
+			msg += fmt.Sprintf("%s\n", name)
+		} else {
+			msg += fmt.Sprintf("%s %s\n", name, red(strings.Join(col.Flags, ", ")))
+		}
+		if col.PrevType != "" && col.Type != "" {
+			msg += fmt.Sprintf("  %s%s -> %s\n", faint(fmt.Sprintf("%-11s", "type:")), col.PrevType, col.Type)
+		}
+		msg += change("mean", col.Mean, stats.DriftMean, col.Flags)
+		msg += change("null ratio", col.NullRatio, stats.DriftNullRatio, col.Flags)
+		msg += change("distinct", col.Distinct, stats.DriftDistinct, col.Flags)
+		if col.PSI != nil {
+			line := fmt.Sprintf("  %s%.4f", faint(fmt.Sprintf("%-11s", "psi:")), *col.PSI)
+			if *col.PSI > s.Thresholds.PSI {
+				line = red(line)
+			}
+			msg += line + "\n"
+		}
+	}
+
+	if s.Flagged == 0 {
+		msg += fmt.Sprintf("\n%s\n", green(fmt.Sprintf("no columns changed past threshold (%d compared)", len(s.Columns))))
+	} else {
+		msg += fmt.Sprintf("\n%s\n", red(fmt.Sprintf("%d of %d columns changed past threshold", s.Flagged, len(s.Columns))))
+	}
+	return msg
+}
+
+type tokenRecordStringer token.Record
+
+// String assumes ID, IssuedAt and Scopes are present
+func (r tokenRecordStringer) String() string {
+	w := &bytes.Buffer{}
+	name := color.New(color.Bold).SprintFunc()
+	rec := token.Record(r)
+	ts := func(sec int64) string {
+		return time.Unix(sec, 0).In(StringerLocation).Format("Jan _2 2006 15:04")
+	}
+
+	status := color.New(color.FgGreen).Sprint("active")
+	if rec.RevokedAt != 0 {
+		status = color.New(color.FgRed).Sprintf("revoked %s", ts(rec.RevokedAt))
+	} else if rec.Expired() {
+		status = color.New(color.FgRed).Sprint("expired")
+	}
+
+	scopes := make([]string, len(rec.Scopes))
+	for i, s := range rec.Scopes {
+		scopes[i] = string(s)
+	}
+
+	fmt.Fprintf(w, "%s\n", name(rec.ID))
+	if rec.Subject != "" {
+		fmt.Fprintf(w, "subject: %s\n", rec.Subject)
+	}
+	fmt.Fprintf(w, "scopes:  %s\n", strings.Join(scopes, ", "))
+	fmt.Fprintf(w, "issued:  %s\n", ts(rec.IssuedAt))
+	if rec.ExpiresAt != 0 {
+		fmt.Fprintf(w, "expires: %s\n", ts(rec.ExpiresAt))
+	}
+	fmt.Fprintf(w, "status:  %s\n\n", status)
+	return w.String()
+}
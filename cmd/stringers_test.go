@@ -10,6 +10,8 @@ import (
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/lib"
 	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/stats"
+	"github.com/qri-io/qri/token"
 )
 
 func TestPeerStringer(t *testing.T) {
@@ -185,6 +187,30 @@ func TestJobStringer(t *testing.T) {
 				PrevRunStart: time,
 			}, "\u001b[1mJob\u001b[0m\n",
 		},
+		{"JobStringer - upstream jobs",
+			&lib.Job{
+				Name:     "Job",
+				Type:     "dataset",
+				Upstream: []string{"me/upstream", "me/other_upstream"},
+			}, "after upstream updates | dataset\n\nafter: me/upstream, me/other_upstream\n",
+		},
+		{"JobStringer - paused job",
+			&lib.Job{
+				Name:        "Job",
+				Type:        "dataset",
+				Periodicity: p,
+				Paused:      true,
+			}, "paused | dataset\n",
+		},
+		{"JobStringer - failing job",
+			&lib.Job{
+				Name:        "Job",
+				Type:        "dataset",
+				Periodicity: p,
+				Failures:    3,
+				RunError:    "oh noes",
+			}, "failed 3 time(s) in a row: oh noes",
+		},
 	}
 	for _, c := range cases {
 		jobStr := jobStringer(*c.job).String()
@@ -193,3 +219,92 @@ func TestJobStringer(t *testing.T) {
 		}
 	}
 }
+
+func TestVersionChangesStringer(t *testing.T) {
+	setNoColor(true)
+	defer setNoColor(false)
+	prevLoc := StringerLocation
+	StringerLocation = time.UTC
+	defer func() { StringerLocation = prevLoc }()
+
+	vc := lib.VersionChanges{
+		Path:         "/ipfs/QmNew",
+		PreviousPath: "/ipfs/QmOld",
+		CommitTime:   time.Date(2001, 01, 01, 01, 01, 01, 01, time.UTC),
+		CommitTitle:  "body changed",
+		RowsAdded:    2,
+		RowsRemoved:  1,
+		Message:      "body:\n\tadded row 3",
+	}
+	expect := "Commit:  /ipfs/QmNew\nDate:    Mon Jan  1 01:01:01 UTC 2001\nRows:    +2 added -1 removed ~0 modified\n\nbody changed\nbody:\n\tadded row 3\n\n"
+	if got := versionChangesStringer(vc).String(); got != expect {
+		t.Errorf("result mismatch. expected:\n%q\ngot:\n%q", expect, got)
+	}
+
+	vc.Schema = []*lib.Delta{{}}
+	vc.Message = ""
+	expect = "Commit:  /ipfs/QmNew\nDate:    Mon Jan  1 01:01:01 UTC 2001\nRows:    +2 added -1 removed ~0 modified\nSchema:  changed\n\nbody changed\n\n"
+	if got := versionChangesStringer(vc).String(); got != expect {
+		t.Errorf("result mismatch. expected:\n%q\ngot:\n%q", expect, got)
+	}
+}
+
+func TestDriftReportStringer(t *testing.T) {
+	setNoColor(true)
+	defer setNoColor(false)
+	psi := 0.5
+	report := stats.DriftReport{
+		Thresholds: stats.DefaultDriftThresholds,
+		Columns: []*stats.ColumnDrift{
+			{
+				Column: "pop",
+				Type:   "numeric",
+				Mean:   &stats.Change{Prev: 10, Next: 20, Delta: 1},
+				PSI:    &psi,
+				Flags:  []string{stats.DriftMean, stats.DriftPSI},
+			},
+			{Column: "zip", Type: "string", Flags: []string{stats.DriftAdded}},
+		},
+		Flagged: 2,
+	}
+	expect := "pop numeric mean, psi\n  mean:      10 -> 20\n  psi:       0.5000\nzip string added\n\n2 of 2 columns changed past threshold\n"
+	if got := driftReportStringer(report).String(); got != expect {
+		t.Errorf("result mismatch. expected:\n%q\ngot:\n%q", expect, got)
+	}
+
+	report = stats.DriftReport{Columns: []*stats.ColumnDrift{{Column: "city", Type: "string"}}}
+	expect = "city string\n\nno columns changed past threshold (1 compared)\n"
+	if got := driftReportStringer(report).String(); got != expect {
+		t.Errorf("result mismatch. expected:\n%q\ngot:\n%q", expect, got)
+	}
+}
+
+func TestTokenRecordStringer(t *testing.T) {
+	setNoColor(true)
+	defer setNoColor(false)
+	prevLoc := StringerLocation
+	StringerLocation = time.UTC
+	defer func() { StringerLocation = prevLoc }()
+
+	issued := time.Date(2019, 1, 1, 10, 0, 0, 0, time.UTC).Unix()
+	rec := token.Record{
+		Claims: token.Claims{
+			ID:        "abc",
+			Subject:   "ci",
+			IssuedAt:  issued,
+			ExpiresAt: issued + 3600,
+			Scopes:    []token.Scope{token.ScopeWrite, token.ScopePublish},
+		},
+		RevokedAt: issued + 60,
+	}
+	expect := "abc\nsubject: ci\nscopes:  write, publish\nissued:  Jan  1 2019 10:00\nexpires: Jan  1 2019 11:00\nstatus:  revoked Jan  1 2019 10:01\n\n"
+	if got := tokenRecordStringer(rec).String(); got != expect {
+		t.Errorf("result mismatch. expected:\n%q\ngot:\n%q", expect, got)
+	}
+
+	rec = token.Record{Claims: token.Claims{ID: "def", IssuedAt: issued, Scopes: []token.Scope{token.ScopeRead}}}
+	expect = "def\nscopes:  read\nissued:  Jan  1 2019 10:00\nstatus:  active\n\n"
+	if got := tokenRecordStringer(rec).String(); got != expect {
+		t.Errorf("result mismatch. expected:\n%q\ngot:\n%q", expect, got)
+	}
+}
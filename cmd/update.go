@@ -57,6 +57,13 @@ responsible for executing your scheduled updates is currently active.
 	time:
 	$ qri update schedule --file dataset.yaml b5/my_dataset R/P1D
 	qri scheduled b5/my_dataset, next update: 2019-05-08 20:15:13.191602 +0000 UTC
+
+	schedule an hourly run of a dataset's transform, executed by the update
+	service itself:
+	$ qri update schedule --transform b5/my_dataset R/PT1H
+
+	update a derived dataset each time the datasets it loads are updated:
+	$ qri update schedule --after b5/my_dataset,b5/other_dataset b5/derived
 	`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if err := o.Complete(f, args); err != nil {
@@ -78,6 +85,8 @@ responsible for executing your scheduled updates is currently active.
 	scheduleCmd.Flags().BoolVar(&o.Force, "force", false, "force a new commit, even if no changes are detected")
 	scheduleCmd.Flags().BoolVarP(&o.KeepFormat, "keep-format", "k", false, "convert incoming data to stored data format")
 	scheduleCmd.Flags().StringVar(&o.RepoPath, "use-repo", "", "experiment. run update on behalf of another repo")
+	scheduleCmd.Flags().StringSliceVar(&o.Upstream, "after", nil, "names of updates that trigger this update when they succeed. periodicity is optional with --after")
+	scheduleCmd.Flags().BoolVar(&o.Transform, "transform", false, "run the dataset transform within the update service instead of calling qri save")
 
 	unscheduleCmd := &cobra.Command{
 		Use:   "unschedule",
@@ -97,6 +106,44 @@ scheduled updates.
 			return o.Unschedule(args)
 		},
 	}
+	pauseCmd := &cobra.Command{
+		Use:   "pause",
+		Short: "Pause a scheduled update",
+		Long: `Pausing an update stops it from running, either on schedule or when
+triggered by upstream updates, until it's resumed. Paused updates can still be
+started with "qri update run". Updates are paused automatically after too many
+consecutive failures, see the update.maxfailures config setting.
+	`,
+		Example: `  pause an update using the dataset name
+	$ qri update pause b5/my_dataset
+	update paused: b5/my_dataset
+	`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Pause(args)
+		},
+	}
+
+	resumeCmd := &cobra.Command{
+		Use:   "resume",
+		Short: "Resume a paused update",
+		Long: `Resuming a paused update clears any record of failed runs, and
+runs the update again at its next scheduled time.
+	`,
+		Example: `  resume a paused update using the dataset name
+	$ qri update resume b5/my_dataset
+	update resumed: b5/my_dataset
+	`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := o.Complete(f, args); err != nil {
+				return err
+			}
+			return o.Resume(args)
+		},
+	}
+
 	listCmd := &cobra.Command{
 		Use:     "list",
 		Aliases: []string{"ls"},
@@ -246,6 +293,8 @@ your scheduled updates will not run.`,
 	cmd.AddCommand(
 		scheduleCmd,
 		unscheduleCmd,
+		pauseCmd,
+		resumeCmd,
 		listCmd,
 		logsCmd,
 		runCmd,
@@ -274,6 +323,8 @@ type UpdateOptions struct {
 	KeepFormat bool
 	Secrets    []string
 
+	Upstream  []string
+	Transform bool
 	Daemonize bool
 	Page      int
 	PageSize  int
@@ -305,6 +356,8 @@ func (o *UpdateOptions) Schedule(args []string) (err error) {
 		Name:       args[0],
 		SaveParams: o.saveParams(),
 		RepoPath:   o.RepoPath,
+		Upstream:   o.Upstream,
+		Transform:  o.Transform,
 	}
 	if len(args) > 1 {
 		p.Periodicity = args[1]
@@ -337,6 +390,42 @@ func (o *UpdateOptions) Unschedule(args []string) (err error) {
 	return nil
 }
 
+// Pause stops a scheduled job from running until it's resumed
+func (o *UpdateOptions) Pause(args []string) (err error) {
+	if len(args) < 1 {
+		return lib.NewError(lib.ErrBadArgs, "please provide the name of an update to pause")
+	}
+
+	var (
+		name = args[0]
+		res  bool
+	)
+	if err := o.updateMethods.Pause(&name, &res); err != nil {
+		return err
+	}
+
+	printSuccess(o.ErrOut, "update paused: %s\n", args[0])
+	return nil
+}
+
+// Resume restarts a paused job
+func (o *UpdateOptions) Resume(args []string) (err error) {
+	if len(args) < 1 {
+		return lib.NewError(lib.ErrBadArgs, "please provide the name of an update to resume")
+	}
+
+	var (
+		name = args[0]
+		res  bool
+	)
+	if err := o.updateMethods.Resume(&name, &res); err != nil {
+		return err
+	}
+
+	printSuccess(o.ErrOut, "update resumed: %s\n", args[0])
+	return nil
+}
+
 // List shows scheduled update jobs
 func (o *UpdateOptions) List() (err error) {
 	// convert Page and PageSize to Limit and Offset
@@ -458,6 +547,19 @@ func (o *UpdateOptions) RunUpdate(args []string) (err error) {
 	o.StartSpinner()
 	defer o.StopSpinner()
 
+	// scheduled updates run through the scheduler, which numbers & logs runs
+	if scheduled := (&lib.Job{}); o.updateMethods.Job(&name, scheduled) == nil {
+		run := &lib.Job{}
+		if err := o.updateMethods.RunScheduled(&name, run); err != nil {
+			return err
+		}
+		if run.RunError != "" {
+			return fmt.Errorf("update %s run %d failed: %s", args[0], run.RunNumber, run.RunError)
+		}
+		printSuccess(o.Out, "update %s run %d complete", args[0], run.RunNumber)
+		return nil
+	}
+
 	res := &reporef.DatasetRef{}
 	if err := o.updateMethods.Run(job, res); err != nil {
 		return err
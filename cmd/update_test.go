@@ -113,16 +113,45 @@ func TestUpdateMethods(t *testing.T) {
 		t.Errorf("list response mismatch. stdOut doesn't contain:\n%s\ngot:\n%s", listStdOutContains, out.String())
 	}
 
-	// TODO (b5) - need to actually run an update here that generates a log entry
-	// ideally by manually calling o.Run
+	ioReset(in, out, errs)
+	if err := o.Pause([]string{"testdata/hello.sh"}); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(errs.String(), "update paused") {
+		t.Errorf("pause response mismatch. errOut doesn't contain 'update paused'. got:\n%s", errs.String())
+	}
+	ioReset(in, out, errs)
+	if err := o.List(); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(out.String(), "paused | shell") {
+		t.Errorf("list response mismatch. stdOut doesn't contain 'paused | shell'. got:\n%s", out.String())
+	}
+	ioReset(in, out, errs)
+	if err := o.Resume([]string{"testdata/hello.sh"}); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(errs.String(), "update resumed") {
+		t.Errorf("resume response mismatch. errOut doesn't contain 'update resumed'. got:\n%s", errs.String())
+	}
+	if err := o.Pause(nil); err == nil {
+		t.Error("expected pause without a name to error")
+	}
+
+	ioReset(in, out, errs)
+	if err := o.RunUpdate([]string{"testdata/hello.sh"}); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(out.String(), "run 1 complete") {
+		t.Errorf("run response mismatch. stdOut doesn't contain 'run 1 complete'. got:\n%s", out.String())
+	}
 
 	ioReset(in, out, errs)
 	if err := o.Logs([]string{}); err != nil {
 		t.Error(err)
 	}
-	logsStdOut := "" // should be empty b/c this test runs no updates
-	if logsStdOut != out.String() {
-		t.Errorf("logs response mismatch. expected errOut:\n%s\ngot:\n%s", logsStdOut, out.String())
+	if !strings.Contains(out.String(), "hello.sh") {
+		t.Errorf("logs response mismatch. expected the manual run to be logged. got:\n%s", out.String())
 	}
 
 	ioReset(in, out, errs)
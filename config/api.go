@@ -44,6 +44,9 @@ type API struct {
 	AllowedOrigins []string `json:"allowedorigins"`
 	// whether to allow requests from addresses other than localhost
 	ServeRemoteTraffic bool `json:"serveremotetraffic"`
+	// if true, requests must carry a bearer token issued by this node with a
+	// scope that allows the request
+	RequireToken bool `json:"requiretoken,omitempty"`
 }
 
 // Validate validates all fields of api returning all errors found.
@@ -89,6 +92,10 @@ func (a API) Validate() error {
         "items": {
           "type": "string"
         }
+      },
+      "requiretoken": {
+        "description": "When true, requests must carry a bearer token issued by this node with a scope that allows the request",
+        "type": "boolean"
       }
     }
   }`)
@@ -121,6 +128,7 @@ func (a *API) Copy() *API {
 		DisconnectAfter:    a.DisconnectAfter,
 		ProxyForceHTTPS:    a.ProxyForceHTTPS,
 		ServeRemoteTraffic: a.ServeRemoteTraffic,
+		RequireToken:       a.RequireToken,
 	}
 	if a.AllowedOrigins != nil {
 		res.AllowedOrigins = make([]string, len(a.AllowedOrigins))
@@ -24,6 +24,7 @@ func TestAPICopy(t *testing.T) {
 			TLS:                true,
 			ProxyForceHTTPS:    true,
 			ServeRemoteTraffic: true,
+			RequireToken:       true,
 		}},
 	}
 	for i, c := range cases {
@@ -7,6 +7,9 @@ import (
 // Stats configures qri statistical metadata calculation
 type Stats struct {
 	Cache cache `json:"cache"`
+	// HistogramBins is the number of bins in numeric & string length
+	// histograms. 0 uses the stats package default
+	HistogramBins int `json:"histogrambins,omitempty"`
 	// For later addition:
 	// StopFreqCountThreshold int
 }
@@ -24,7 +27,7 @@ func DefaultStats() *Stats {
 		Cache: cache{
 			Type: "fs",
 			// Default to 25MiB
-			MaxSize: 1024 * 25,
+			MaxSize: 1024 * 1024 * 25,
 		},
 	}
 }
@@ -40,6 +43,11 @@ func (cfg Stats) Validate() error {
       "cache"
     ],
     "properties": {
+      "histogrambins": {
+        "description": "The number of bins in numeric & string length histograms",
+        "type": "integer",
+        "minimum": 0
+      },
       "cache": {
         "description": "The configuration for the cache that stores recent calculated stats.",
         "type": "object",
@@ -80,5 +88,6 @@ func (cfg *Stats) Copy() *Stats {
 			MaxSize: cfg.Cache.MaxSize,
 			Path:    cfg.Cache.Path,
 		},
+		HistogramBins: cfg.HistogramBins,
 	}
 }
@@ -16,10 +16,13 @@ func TestStatsCopy(t *testing.T) {
 	// build off DefaultStats so we can test that the stats Copy
 	// actually copies over correctly
 	s := DefaultStats()
+	bins := DefaultStats()
+	bins.HistogramBins = 20
 	cases := []struct {
 		stats *Stats
 	}{
 		{s},
+		{bins},
 	}
 	for i, c := range cases {
 		cpy := c.stats.Copy()
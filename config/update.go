@@ -1,23 +1,57 @@
 package config
 
-import "github.com/qri-io/jsonschema"
+import (
+	"github.com/qri-io/jsonschema"
+)
 
 // Update configures a Remote Procedure Call (Update) listener
 type Update struct {
 	Type      string `json:"type"`
 	Daemonize bool   `json:"daemonize"`
 	Address   string `json:"address"`
+
+	// Retries is the number of times a failed update is retried before
+	// waiting for the next scheduled run. 0 doesn't retry
+	Retries int `json:"retries"`
+	// RetryBackoffMs is the delay before the first retry, in milliseconds.
+	// each following retry waits twice as long as the one before it
+	RetryBackoffMs int `json:"retrybackoffms"`
+	// MaxFailures is the number of consecutive failed runs that pauses an
+	// update. 0 never pauses updates
+	MaxFailures int `json:"maxfailures"`
+	// NotifyWebhook is a URL that receives a JSON POST when an update fails,
+	// recovers, or is paused
+	NotifyWebhook string `json:"notifywebhook"`
+	// NotifyCommand is a shell command to run when an update fails, recovers,
+	// or is paused
+	NotifyCommand string `json:"notifycommand"`
+
+	// TransformTimeoutMs limits how long the transform of an update can run,
+	// in milliseconds. defaults to DefaultTransformTimeoutMs, 0 is unlimited
+	TransformTimeoutMs int `json:"transformtimeoutms"`
+	// TransformMaxSteps limits the execution steps of an update transform.
+	// 0 is unlimited
+	TransformMaxSteps uint64 `json:"transformmaxsteps"`
+	// TransformMaxBodyEntries limits the number of body entries an update
+	// transform can produce. 0 is unlimited
+	TransformMaxBodyEntries int `json:"transformmaxbodyentries"`
 }
 
 // DefaultUpdateAddress is the local address Update serves on by default
 var DefaultUpdateAddress = "127.0.0.1:2506"
 
+// DefaultTransformTimeoutMs is the default limit on how long an update
+// transform can run, ten minutes
+const DefaultTransformTimeoutMs = 10 * 60 * 1000
+
 // DefaultUpdate creates a new default Update configuration
 func DefaultUpdate() *Update {
 	return &Update{
 		Type:      "fs",
 		Daemonize: true,
 		Address:   DefaultUpdateAddress,
+
+		TransformTimeoutMs: DefaultTransformTimeoutMs,
 	}
 }
 
@@ -43,6 +77,44 @@ func (cfg Update) Validate() error {
       "address": {
         "description": "address service will listen and dial on for inter-process communication",
         "type": "string"
+      },
+      "retries": {
+        "description": "number of times to retry a failed update before waiting for the next scheduled run",
+        "type": "integer",
+        "minimum": 0
+      },
+      "retrybackoffms": {
+        "description": "milliseconds to wait before the first retry, doubling for each following retry",
+        "type": "integer",
+        "minimum": 0
+      },
+      "maxfailures": {
+        "description": "consecutive failed runs that pause an update. 0 never pauses updates",
+        "type": "integer",
+        "minimum": 0
+      },
+      "notifywebhook": {
+        "description": "URL to POST to when an update fails, recovers, or is paused",
+        "type": "string"
+      },
+      "notifycommand": {
+        "description": "shell command to run when an update fails, recovers, or is paused",
+        "type": "string"
+      },
+      "transformtimeoutms": {
+        "description": "milliseconds an update transform can run before it's stopped. defaults to ten minutes, 0 is unlimited",
+        "type": "integer",
+        "minimum": 0
+      },
+      "transformmaxsteps": {
+        "description": "execution steps an update transform can run before it's stopped. 0 is unlimited",
+        "type": "integer",
+        "minimum": 0
+      },
+      "transformmaxbodyentries": {
+        "description": "body entries an update transform can produce. 0 is unlimited",
+        "type": "integer",
+        "minimum": 0
       }
     }
   }`)
@@ -55,6 +127,16 @@ func (cfg *Update) Copy() *Update {
 		Type:      cfg.Type,
 		Daemonize: cfg.Daemonize,
 		Address:   cfg.Address,
+
+		Retries:        cfg.Retries,
+		RetryBackoffMs: cfg.RetryBackoffMs,
+		MaxFailures:    cfg.MaxFailures,
+		NotifyWebhook:  cfg.NotifyWebhook,
+		NotifyCommand:  cfg.NotifyCommand,
+
+		TransformTimeoutMs:      cfg.TransformTimeoutMs,
+		TransformMaxSteps:       cfg.TransformMaxSteps,
+		TransformMaxBodyEntries: cfg.TransformMaxBodyEntries,
 	}
 
 	return res
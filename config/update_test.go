@@ -10,6 +10,12 @@ func TestUpdateValidate(t *testing.T) {
 	if err != nil {
 		t.Errorf("error validating default update: %s", err)
 	}
+
+	cfg := DefaultUpdate()
+	cfg.Retries = -1
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected negative retries to fail validation")
+	}
 }
 
 func TestUpdateCopy(t *testing.T) {
@@ -17,6 +23,7 @@ func TestUpdateCopy(t *testing.T) {
 		rpc *Update
 	}{
 		{DefaultUpdate()},
+		{&Update{Type: "mem", Daemonize: true, Retries: 1, NotifyWebhook: "http://example.com", NotifyCommand: "echo failed"}},
 	}
 	for i, c := range cases {
 		cpy := c.rpc.Copy()
@@ -104,6 +104,7 @@ func buildDscacheFlatbuffer(userPairList []userProfilePair, entryInfoList []*ent
 		commitMessage := builder.CreateString(ce.CommitMessage)
 		headRef := builder.CreateString(ce.Path)
 		fsiPath := builder.CreateString(ce.FSIPath)
+		branches := buildBranchHeadList(builder, ce.Branches)
 		dscachefb.RefEntryInfoStart(builder)
 		dscachefb.RefEntryInfoAddInitID(builder, initID)
 		dscachefb.RefEntryInfoAddProfileID(builder, profileID)
@@ -120,6 +121,7 @@ func buildDscacheFlatbuffer(userPairList []userProfilePair, entryInfoList []*ent
 		dscachefb.RefEntryInfoAddNumErrors(builder, int32(ce.NumErrors))
 		dscachefb.RefEntryInfoAddHeadRef(builder, headRef)
 		dscachefb.RefEntryInfoAddFsiPath(builder, fsiPath)
+		dscachefb.RefEntryInfoAddBranches(builder, branches)
 		ref := dscachefb.RefEntryInfoEnd(builder)
 		refList = append(refList, ref)
 	}
@@ -151,6 +153,38 @@ type entryInfo struct {
 	// Keys and indexing values
 	TopIndex    int
 	CursorIndex int
+	// Heads of all non-default branches
+	Branches []branchHead
+}
+
+// branchHead is the newest commit of a logbook branch. Maps directly to the
+// BranchHead flatbuffer defined in def.fbs
+type branchHead struct {
+	Name     string
+	TopIndex int
+	HeadRef  string
+}
+
+// buildBranchHeadList constructs a vector of branch heads. Must be called
+// before starting construction of the RefEntryInfo the vector belongs to
+func buildBranchHeadList(builder *flatbuffers.Builder, heads []branchHead) flatbuffers.UOffsetT {
+	branchList := make([]flatbuffers.UOffsetT, 0, len(heads))
+	for _, bh := range heads {
+		name := builder.CreateString(bh.Name)
+		headRef := builder.CreateString(bh.HeadRef)
+		dscachefb.BranchHeadStart(builder)
+		dscachefb.BranchHeadAddName(builder, name)
+		dscachefb.BranchHeadAddTopIndex(builder, int32(bh.TopIndex))
+		dscachefb.BranchHeadAddHeadRef(builder, headRef)
+		branchList = append(branchList, dscachefb.BranchHeadEnd(builder))
+	}
+
+	// Build branches vector, iterating backwards due to using prepend
+	dscachefb.RefEntryInfoStartBranchesVector(builder, len(branchList))
+	for i := len(branchList) - 1; i >= 0; i-- {
+		builder.PrependUOffsetT(branchList[i])
+	}
+	return builder.EndVector(len(branchList))
 }
 
 // convertLogbookAndRefs builds entryInfo from each dataset in the logbook, plus FSIPath from
@@ -215,6 +249,10 @@ func convertDatasetHistoryToDsInfo(dsLog oplog.Log) *entryInfo {
 	// Get the final pretty name, most recently ammended.
 	prettyName := ""
 	for _, op := range dsLog.Ops {
+		if op.Model == logbook.BranchModel {
+			// Branch switches are recorded in the dataset log, skip them.
+			continue
+		}
 		if op.Model != logbook.DatasetModel {
 			log.Errorf("expected to be at the dataset level, got model number %d", op.Model)
 			return nil
@@ -228,23 +266,38 @@ func convertDatasetHistoryToDsInfo(dsLog oplog.Log) *entryInfo {
 
 	// Get the init-id here, because this the log for the dataset model.
 	initID := dsLog.ID()
-	if len(dsLog.Logs) != 1 {
-		log.Errorf("expected only 1 branch, got %d\n", len(dsLog.Logs))
-		return nil
-	}
-
-	historyLog := dsLog.Logs[0]
-	topIndex, headRef := convertHistoryToIndexAndRef(*historyLog)
-	cursorIndex := topIndex
-	return &entryInfo{
+	info := &entryInfo{
 		VersionInfo: dsref.VersionInfo{
 			InitID: initID,
 			Name:   prettyName,
-			Path:   headRef,
 		},
-		TopIndex:    topIndex,
-		CursorIndex: cursorIndex,
 	}
+
+	foundDefault := false
+	for _, historyLog := range dsLog.Logs {
+		if historyLog.Removed() {
+			continue
+		}
+		topIndex, headRef := convertHistoryToIndexAndRef(*historyLog)
+		if historyLog.Name() == logbook.DefaultBranchName {
+			foundDefault = true
+			info.Path = headRef
+			info.TopIndex = topIndex
+			info.CursorIndex = topIndex
+			continue
+		}
+		info.Branches = append(info.Branches, branchHead{
+			Name:     historyLog.Name(),
+			TopIndex: topIndex,
+			HeadRef:  headRef,
+		})
+	}
+
+	if !foundDefault {
+		log.Errorf("expected a %q branch, found none", logbook.DefaultBranchName)
+		return nil
+	}
+	return info
 }
 
 func convertHistoryToIndexAndRef(historyLog oplog.Log) (int, string) {
@@ -129,6 +129,9 @@ func (d *Dscache) VerboseString(showEmpty bool) string {
 		if len(r.FsiPath()) != 0 || showEmpty {
 			fmt.Fprintf(&out, "%sfsiPath       = %s\n", indent, r.FsiPath())
 		}
+		for _, bh := range branchHeadsFromEntry(&r) {
+			fmt.Fprintf(&out, "%sbranch        = %s topIndex=%d headRef=%s\n", indent, bh.Name, bh.TopIndex, bh.HeadRef)
+		}
 	}
 	return out.String()
 }
@@ -184,9 +187,19 @@ func (d *Dscache) update(act *logbook.Action) {
 			log.Error(err)
 		}
 	case logbook.ActionDatasetChange:
+		if act.Branch != "" && act.Branch != logbook.DefaultBranchName {
+			if err := d.updateBranchHead(act); err != nil && err != ErrNoDscache {
+				log.Error(err)
+			}
+			return
+		}
 		if err := d.updateMoveCursor(act); err != nil && err != ErrNoDscache {
 			log.Error(err)
 		}
+	case logbook.ActionBranchDelete:
+		if err := d.updateBranchHead(act); err != nil && err != ErrNoDscache {
+			log.Error(err)
+		}
 	}
 }
 
@@ -246,7 +259,7 @@ func (d *Dscache) updateMoveCursor(act *logbook.Action) error {
 		func(r *dscachefb.RefEntryInfo) bool {
 			return string(r.InitID()) == act.InitID
 		},
-		func(refStartMutationFunc func(builder *flatbuffers.Builder)) {
+		func(_ *dscachefb.RefEntryInfo, refStartMutationFunc func(builder *flatbuffers.Builder)) {
 			var metaTitle, commitTitle, commitMessage flatbuffers.UOffsetT
 			if act.Dataset != nil && act.Dataset.Meta != nil {
 				metaTitle = builder.CreateString(act.Dataset.Meta.Title)
@@ -284,6 +297,67 @@ func (d *Dscache) updateMoveCursor(act *logbook.Action) error {
 	return d.save()
 }
 
+// updateBranchHead sets or removes the head of a non-default branch
+func (d *Dscache) updateBranchHead(act *logbook.Action) error {
+	if d.IsEmpty() {
+		return ErrNoDscache
+	}
+	builder := flatbuffers.NewBuilder(0)
+	users := d.copyUserAssociationList(builder)
+	refs := d.copyReferenceListWithReplacement(
+		builder,
+		func(r *dscachefb.RefEntryInfo) bool {
+			return string(r.InitID()) == act.InitID
+		},
+		func(r *dscachefb.RefEntryInfo, refStartMutationFunc func(builder *flatbuffers.Builder)) {
+			heads := make([]branchHead, 0, r.BranchesLength()+1)
+			for _, bh := range branchHeadsFromEntry(r) {
+				if bh.Name != act.Branch {
+					heads = append(heads, bh)
+				}
+			}
+			if act.Type != logbook.ActionBranchDelete {
+				heads = append(heads, branchHead{
+					Name:     act.Branch,
+					TopIndex: act.TopIndex,
+					HeadRef:  act.HeadRef,
+				})
+			}
+			branches := buildBranchHeadList(builder, heads)
+			refStartMutationFunc(builder)
+			dscachefb.RefEntryInfoAddBranches(builder, branches)
+		},
+	)
+	root, serialized := d.finishBuilding(builder, users, refs)
+	d.Root = root
+	d.Buffer = serialized
+	return d.save()
+}
+
+// BranchHead returns the head path of a named branch for a dataset init-id
+func (d *Dscache) BranchHead(initID, branch string) (string, error) {
+	if d.IsEmpty() {
+		return "", ErrNoDscache
+	}
+	for i := 0; i < d.Root.RefsLength(); i++ {
+		r := dscachefb.RefEntryInfo{}
+		d.Root.Refs(&r, i)
+		if string(r.InitID()) != initID {
+			continue
+		}
+		if branch == "" || branch == logbook.DefaultBranchName {
+			return string(r.HeadRef()), nil
+		}
+		for _, bh := range branchHeadsFromEntry(&r) {
+			if bh.Name == branch {
+				return bh.HeadRef, nil
+			}
+		}
+		break
+	}
+	return "", fmt.Errorf("dscache: branch %q not found", branch)
+}
+
 func convertEntryToVersionInfo(r *dscachefb.RefEntryInfo) dsref.VersionInfo {
 	return dsref.VersionInfo{
 		InitID:        string(r.InitID()),
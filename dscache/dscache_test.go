@@ -8,10 +8,17 @@ import (
 	"strings"
 	"testing"
 
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
 	"github.com/qri-io/qfs/localfs"
 	testPeers "github.com/qri-io/qri/config/test"
 	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
 )
 
 // TODO(dlong): Test NewDscache, IsEmpty, Assign, ListRefs, Update
@@ -69,3 +76,83 @@ func TestDscacheAssignSaveAndLoad(t *testing.T) {
 		t.Errorf("expected, 2 refs, got %d refs", loadable.Root.RefsLength())
 	}
 }
+
+func TestDscacheBranchHeads(t *testing.T) {
+	ctx := context.Background()
+	peerInfo := testPeers.GetTestPeerInfo(0)
+
+	tmpdir, err := ioutil.TempDir("", "dscache_branches")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	builder := NewLogbookTempBuilder(t, peerInfo.PrivKey, "test_user", qfs.NewMemFS(), tmpdir)
+	ref := builder.DatasetInit(ctx, t, "branchy")
+	ref = builder.Commit(ctx, t, ref, "initial commit", "QmHashOfVersion1")
+	book := builder.Logbook()
+
+	profiles := profile.NewMemStore()
+	profiles.PutProfile(&profile.Profile{
+		ID:       profile.IDFromPeerID(peerInfo.PeerID),
+		Peername: "test_user",
+	})
+
+	cache, err := BuildDscacheFromLogbookAndProfilesAndDsref(ctx, []reporef.DatasetRef{}, profiles, book, cafs.NewMapstore(), qfs.NewMemFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	book.Observe(cache.update)
+
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initID := dsLog.ID()
+
+	if err := book.WriteBranchInit(ctx, ref, "experiment", ""); err != nil {
+		t.Fatal(err)
+	}
+	expectBranchHead(t, cache, initID, "experiment", "QmHashOfVersion1")
+
+	ds := &dataset.Dataset{
+		Peername:     ref.Username,
+		Name:         ref.Name,
+		Commit:       &dataset.Commit{Timestamp: time.Unix(0, logbook.NewTimestamp()), Title: "experiment"},
+		Path:         "QmHashOfBranchVersion",
+		PreviousPath: ref.Path,
+	}
+	if err := book.WriteBranchVersionSave(ctx, ds, "experiment"); err != nil {
+		t.Fatal(err)
+	}
+	expectBranchHead(t, cache, initID, "experiment", "QmHashOfBranchVersion")
+	expectBranchHead(t, cache, initID, logbook.DefaultBranchName, "QmHashOfVersion1")
+
+	// rebuilding from the logbook should produce the same branch heads
+	rebuilt, err := BuildDscacheFromLogbookAndProfilesAndDsref(ctx, []reporef.DatasetRef{}, profiles, book, cafs.NewMapstore(), qfs.NewMemFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectBranchHead(t, rebuilt, initID, "experiment", "QmHashOfBranchVersion")
+	if str := rebuilt.VerboseString(false); !strings.Contains(str, "branch        = experiment") {
+		t.Errorf("expected verbose string to list branch. got:\n%s", str)
+	}
+
+	if err := book.WriteBranchDelete(ctx, ref, "experiment"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.BranchHead(initID, "experiment"); err == nil {
+		t.Errorf("expected deleted branch to be removed from dscache")
+	}
+}
+
+func expectBranchHead(t *testing.T, cache *Dscache, initID, branch, expect string) {
+	t.Helper()
+	got, err := cache.BranchHead(initID, branch)
+	if err != nil {
+		t.Fatalf("getting %q branch head: %s", branch, err)
+	}
+	if got != expect {
+		t.Errorf("%q branch head mismatch. want: %q got: %q", branch, expect, got)
+	}
+}
@@ -214,8 +214,28 @@ func (rcv *RefEntryInfo) FsiPath() []byte {
 	return nil
 }
 
+func (rcv *RefEntryInfo) Branches(obj *BranchHead, j int) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(42))
+	if o != 0 {
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
+	}
+	return false
+}
+
+func (rcv *RefEntryInfo) BranchesLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(42))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
 func RefEntryInfoStart(builder *flatbuffers.Builder) {
-	builder.StartObject(19)
+	builder.StartObject(20)
 }
 func RefEntryInfoAddInitID(builder *flatbuffers.Builder, initID flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(initID), 0)
@@ -274,6 +294,12 @@ func RefEntryInfoAddHeadRef(builder *flatbuffers.Builder, headRef flatbuffers.UO
 func RefEntryInfoAddFsiPath(builder *flatbuffers.Builder, fsiPath flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(18, flatbuffers.UOffsetT(fsiPath), 0)
 }
+func RefEntryInfoAddBranches(builder *flatbuffers.Builder, branches flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(19, flatbuffers.UOffsetT(branches), 0)
+}
+func RefEntryInfoStartBranchesVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
 func RefEntryInfoEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
@@ -25,7 +25,7 @@ func (d *Dscache) copyUserAssociationList(builder *flatbuffers.Builder) flatbuff
 func (d *Dscache) copyReferenceListWithReplacement(
 	builder *flatbuffers.Builder,
 	findMatchFunc func(*dscachefb.RefEntryInfo) bool,
-	replaceRefFunc func(func(*flatbuffers.Builder))) flatbuffers.UOffsetT {
+	replaceRefFunc func(*dscachefb.RefEntryInfo, func(*flatbuffers.Builder))) flatbuffers.UOffsetT {
 
 	// Construct refs, with all pertinent information for each dataset ref
 	refList := make([]flatbuffers.UOffsetT, 0, d.Root.RefsLength())
@@ -41,7 +41,7 @@ func (d *Dscache) copyReferenceListWithReplacement(
 			startRefBuildFunc := func(_ *flatbuffers.Builder) {
 				d.copyReference(builder, &r)
 			}
-			replaceRefFunc(startRefBuildFunc)
+			replaceRefFunc(&r, startRefBuildFunc)
 			ref := dscachefb.RefEntryInfoEnd(builder)
 			refList = append(refList, ref)
 			continue
@@ -86,6 +86,7 @@ func (d *Dscache) copyReference(builder *flatbuffers.Builder, r *dscachefb.RefEn
 	commitTitle := builder.CreateString(string(r.CommitTitle()))
 	commitMessage := builder.CreateString(string(r.CommitMessage()))
 	fsiPath := builder.CreateString(string(r.FsiPath()))
+	branches := buildBranchHeadList(builder, branchHeadsFromEntry(r))
 	dscachefb.RefEntryInfoStart(builder)
 	dscachefb.RefEntryInfoAddInitID(builder, initID)
 	dscachefb.RefEntryInfoAddProfileID(builder, profileID)
@@ -102,4 +103,19 @@ func (d *Dscache) copyReference(builder *flatbuffers.Builder, r *dscachefb.RefEn
 	dscachefb.RefEntryInfoAddNumErrors(builder, int32(r.NumErrors()))
 	dscachefb.RefEntryInfoAddHeadRef(builder, hashRef)
 	dscachefb.RefEntryInfoAddFsiPath(builder, fsiPath)
+	dscachefb.RefEntryInfoAddBranches(builder, branches)
+}
+
+func branchHeadsFromEntry(r *dscachefb.RefEntryInfo) []branchHead {
+	heads := make([]branchHead, 0, r.BranchesLength())
+	for i := 0; i < r.BranchesLength(); i++ {
+		bh := dscachefb.BranchHead{}
+		r.Branches(&bh, i)
+		heads = append(heads, branchHead{
+			Name:     string(bh.Name()),
+			TopIndex: int(bh.TopIndex()),
+			HeadRef:  string(bh.HeadRef()),
+		})
+	}
+	return heads
 }
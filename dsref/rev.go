@@ -55,6 +55,55 @@ func ParseRev(rev string) (*Rev, error) {
 	return nil, fmt.Errorf("unrecognized revision field: %s", rev)
 }
 
+// ParseRevRange parses a range of dataset history in the form "FROM..TO".
+// Each end of the range is a generation offset from the latest version:
+// "HEAD" is the latest version, "HEAD~N" and "N" are the nth-generational
+// ancestor, and "all" is the first version. FROM must be an older
+// generation than TO. "10..HEAD" spans the last ten versions
+func ParseRevRange(str string) (from, to *Rev, err error) {
+	ends := strings.Split(str, "..")
+	if len(ends) != 2 {
+		return nil, nil, fmt.Errorf("invalid revision range %q. use a range like 10..HEAD", str)
+	}
+	if from, err = parseRangeEnd(ends[0]); err != nil {
+		return nil, nil, err
+	}
+	if to, err = parseRangeEnd(ends[1]); err != nil {
+		return nil, nil, err
+	}
+	if to.Gen == AllGenerations || (from.Gen != AllGenerations && from.Gen <= to.Gen) {
+		return nil, nil, fmt.Errorf("invalid revision range %q. range must start before it ends", str)
+	}
+	return from, to, nil
+}
+
+// ParseRevGen parses a single version relative to the latest version of a
+// history: "HEAD" is the latest version, "HEAD~N" and "N" are the
+// nth-generational ancestor, and "all" is the first version
+func ParseRevGen(str string) (*Rev, error) {
+	rev, err := parseRangeEnd(str)
+	if err != nil {
+		return nil, fmt.Errorf("unrecognized revision: %q", str)
+	}
+	return rev, nil
+}
+
+func parseRangeEnd(str string) (*Rev, error) {
+	switch {
+	case str == "HEAD":
+		return &Rev{Field: "ds", Gen: 0}, nil
+	case str == "all":
+		return &Rev{Field: "ds", Gen: AllGenerations}, nil
+	case strings.HasPrefix(str, "HEAD~"):
+		str = strings.TrimPrefix(str, "HEAD~")
+	}
+	num, err := strconv.Atoi(str)
+	if err != nil || num < 0 {
+		return nil, fmt.Errorf("unrecognized revision range end: %q", str)
+	}
+	return &Rev{Field: "ds", Gen: num}, nil
+}
+
 // NewAllRevisions returns a Rev struct that represents all revisions.
 func NewAllRevisions() Rev {
 	return Rev{Field: "ds", Gen: AllGenerations}
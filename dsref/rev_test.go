@@ -49,3 +49,66 @@ func EnsureRevEqual(a, b *Rev) error {
 	}
 	return nil
 }
+
+func TestParseRevRange(t *testing.T) {
+	cases := []struct {
+		in       string
+		from, to int
+		err      string
+	}{
+		{"10..HEAD", 10, 0, ""},
+		{"HEAD~3..HEAD~1", 3, 1, ""},
+		{"2..1", 2, 1, ""},
+		{"all..HEAD", AllGenerations, 0, ""},
+		{"all..2", AllGenerations, 2, ""},
+
+		{"", 0, 0, `invalid revision range "". use a range like 10..HEAD`},
+		{"10", 0, 0, `invalid revision range "10". use a range like 10..HEAD`},
+		{"HEAD..10", 0, 0, `invalid revision range "HEAD..10". range must start before it ends`},
+		{"1..1", 0, 0, `invalid revision range "1..1". range must start before it ends`},
+		{"HEAD..all", 0, 0, `invalid revision range "HEAD..all". range must start before it ends`},
+		{"ten..HEAD", 0, 0, `unrecognized revision range end: "ten"`},
+		{"-1..HEAD", 0, 0, `unrecognized revision range end: "-1"`},
+	}
+
+	for i, c := range cases {
+		from, to, err := ParseRevRange(c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err != "" {
+			continue
+		}
+		if from.Gen != c.from || to.Gen != c.to {
+			t.Errorf("case %d range mismatch. expected: %d..%d, got: %d..%d", i, c.from, c.to, from.Gen, to.Gen)
+		}
+	}
+}
+
+func TestParseRevGen(t *testing.T) {
+	cases := []struct {
+		in  string
+		gen int
+		err string
+	}{
+		{"HEAD", 0, ""},
+		{"HEAD~1", 1, ""},
+		{"3", 3, ""},
+		{"all", AllGenerations, ""},
+
+		{"HEAD~", 0, `unrecognized revision: "HEAD~"`},
+		{"10..HEAD", 0, `unrecognized revision: "10..HEAD"`},
+	}
+
+	for i, c := range cases {
+		rev, err := ParseRevGen(c.in)
+		if !(err == nil && c.err == "" || err != nil && err.Error() == c.err) {
+			t.Errorf("case %d error mismatch. expected: %s, got: %s", i, c.err, err)
+			continue
+		}
+		if c.err == "" && rev.Gen != c.gen {
+			t.Errorf("case %d generation mismatch. expected: %d, got: %d", i, c.gen, rev.Gen)
+		}
+	}
+}
@@ -12,7 +12,7 @@ import (
 )
 
 // GetBody is an FSI version of base.ReadBody
-func GetBody(dirPath string, format dataset.DataFormat, fcfg dataset.FormatConfig, offset, limit int, all bool) ([]byte, error) {
+func GetBody(dirPath string, format dataset.DataFormat, fcfg dataset.FormatConfig, q *base.BodyQuery, offset, limit int, all bool) ([]byte, error) {
 
 	components, err := component.ListDirectoryComponents(dirPath)
 	if err != nil {
@@ -79,5 +79,5 @@ func GetBody(dirPath string, format dataset.DataFormat, fcfg dataset.FormatConfi
 		structure.Schema = assign.Schema
 	}
 
-	return base.ConvertBodyFile(file, structure, st, limit, offset, all)
+	return base.QueryBodyFile(file, structure, st, q, limit, offset, all)
 }
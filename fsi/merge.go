@@ -0,0 +1,132 @@
+package fsi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/component"
+	"github.com/qri-io/qri/base/merge"
+)
+
+// MergeStateFilename is the name of the file that records a merge waiting for
+// conflicts to be resolved in a linked directory
+const MergeStateFilename = ".qri-merge"
+
+// ErrNoMerge indicates a linked directory has no merge in progress
+var ErrNoMerge = fmt.Errorf("no merge in progress")
+
+// MergeState records a merge with conflicts. The merged components are written
+// to the linked directory with a conflict marker in place of each conflicting
+// value. Saving the directory once all markers are gone completes the merge
+type MergeState struct {
+	// Branch being merged into
+	Branch string `json:"branch"`
+	// Paths of the common ancestor and the merged versions
+	Ancestor string `json:"ancestor"`
+	Ours     string `json:"ours"`
+	Theirs   string `json:"theirs"`
+	// Conflicts that need resolution
+	Conflicts []merge.Conflict `json:"conflicts"`
+}
+
+// WriteMergeState records an in-progress merge in a linked directory
+func WriteMergeState(dir string, state *MergeState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return base.WriteHiddenFile(filepath.Join(dir, MergeStateFilename), string(data))
+}
+
+// ReadMergeState reads the in-progress merge of a linked directory, returning
+// ErrNoMerge if there isn't one
+func ReadMergeState(dir string) (*MergeState, error) {
+	data, err := ioutil.ReadFile(filepath.Join(dir, MergeStateFilename))
+	if os.IsNotExist(err) {
+		return nil, ErrNoMerge
+	} else if err != nil {
+		return nil, err
+	}
+	state := &MergeState{}
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("reading merge state: %s", err)
+	}
+	return state, nil
+}
+
+// RemoveMergeState clears the in-progress merge of a linked directory
+func RemoveMergeState(dir string) error {
+	err := os.Remove(filepath.Join(dir, MergeStateFilename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// ConflictMarkers lists the component files of a linked directory that still
+// contain conflict markers
+func ConflictMarkers(dir string) ([]string, error) {
+	components, err := component.ListDirectoryComponents(dir)
+	if err != nil {
+		return nil, err
+	}
+	fc, ok := components.(*component.FilesysComponent)
+	if !ok {
+		return nil, nil
+	}
+
+	var files []string
+	for _, comp := range fc.Subcomponents {
+		path := comp.Base().SourceFile
+		if path == "" {
+			continue
+		}
+		found, err := fileContains(path, []byte(merge.MarkerPrefix))
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			files = append(files, filepath.Base(path))
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// fileContains searches a file for a byte sequence without reading the whole
+// file into memory
+func fileContains(path string, seq []byte) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	buf := make([]byte, 32*1024)
+	carry := 0
+	for {
+		n, err := f.Read(buf[carry:])
+		if bytes.Contains(buf[:carry+n], seq) {
+			return true, nil
+		}
+		if err == io.EOF {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		// keep the tail of the chunk in case a sequence spans two reads
+		if keep := len(seq) - 1; carry+n > keep {
+			copy(buf, buf[carry+n-keep:carry+n])
+			carry = keep
+		} else {
+			carry += n
+		}
+	}
+}
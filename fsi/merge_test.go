@@ -0,0 +1,101 @@
+package fsi
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/qri/base/merge"
+)
+
+func TestMergeState(t *testing.T) {
+	paths := NewTmpPaths()
+	defer paths.Close()
+
+	if _, err := ReadMergeState(paths.firstDir); err != ErrNoMerge {
+		t.Errorf("expected reading a missing merge state to return ErrNoMerge, got: %v", err)
+	}
+
+	state := &MergeState{
+		Branch:    "main",
+		Ancestor:  "/map/QmAncestor",
+		Ours:      "/map/QmOurs",
+		Theirs:    "/map/QmTheirs",
+		Conflicts: []merge.Conflict{{Path: "/meta/title", Base: "a", Ours: "b", Theirs: "c"}},
+	}
+	if err := WriteMergeState(paths.firstDir, state); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadMergeState(paths.firstDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(state, got); diff != "" {
+		t.Errorf("merge state mismatch (-want +got):\n%s", diff)
+	}
+
+	if err = RemoveMergeState(paths.firstDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadMergeState(paths.firstDir); err != ErrNoMerge {
+		t.Errorf("expected reading a removed merge state to return ErrNoMerge, got: %v", err)
+	}
+	if err = RemoveMergeState(paths.firstDir); err != nil {
+		t.Errorf("removing a missing merge state shouldn't error, got: %s", err)
+	}
+}
+
+func TestConflictMarkers(t *testing.T) {
+	paths := NewTmpPaths()
+	defer paths.Close()
+
+	writeFile := func(name, contents string) {
+		if err := ioutil.WriteFile(filepath.Join(paths.firstDir, name), []byte(contents), os.ModePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("meta.json", `{"title":"resolved"}`)
+	writeFile("body.csv", "a,b\n1,"+merge.MarkerPrefix+": ours 2, base 1, theirs 3 =======\n")
+	writeFile("notes.txt", merge.MarkerPrefix)
+
+	files, err := ConflictMarkers(paths.firstDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"body.csv"}, files); diff != "" {
+		t.Errorf("files mismatch (-want +got):\n%s", diff)
+	}
+
+	writeFile("body.csv", "a,b\n1,2\n")
+	if files, err = ConflictMarkers(paths.firstDir); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 0 {
+		t.Errorf("expected no files with markers, got: %v", files)
+	}
+}
+
+func TestFileContains(t *testing.T) {
+	paths := NewTmpPaths()
+	defer paths.Close()
+
+	// place the sequence across the boundary of the first read
+	path := filepath.Join(paths.firstDir, "big.txt")
+	data := strings.Repeat("x", 32*1024-4) + merge.MarkerPrefix + strings.Repeat("y", 100)
+	if err := ioutil.WriteFile(path, []byte(data), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	found, err := fileContains(path, []byte(merge.MarkerPrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Error("expected to find a sequence that spans reads")
+	}
+	if found, _ = fileContains(path, []byte("z")); found {
+		t.Error("expected not to find a missing sequence")
+	}
+}
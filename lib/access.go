@@ -0,0 +1,252 @@
+package lib
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/repo/profile"
+)
+
+// AccessParams defines parameters for dataset access control methods
+type AccessParams struct {
+	// Reference to the dataset to operate on
+	Ref string
+	// Profile is the peername or profile ID of a collaborator
+	Profile string
+	// Permissions to grant or revoke. Revoking without permissions removes
+	// all access
+	Permissions []string
+}
+
+// Collaborator describes the access a profile has to a dataset
+type Collaborator struct {
+	ProfileID   string   `json:"profileID"`
+	Peername    string   `json:"peername,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// Access lists profiles that have been granted access to a dataset
+func (r *LogRequests) Access(p *AccessParams, res *[]Collaborator) error {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.Access", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	ref, err := r.branchDsref(p.Ref)
+	if err != nil {
+		return err
+	}
+	return r.collaborators(ctx, ref, res)
+}
+
+// GrantAccess gives a profile permissions on a dataset. Only the owner of a
+// dataset can grant access. Access changes are written to the dataset log,
+// and take effect on a remote once logs are pushed
+func (r *LogRequests) GrantAccess(p *AccessParams, res *[]Collaborator) error {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.GrantAccess", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	ref, err := r.branchDsref(p.Ref)
+	if err != nil {
+		return err
+	}
+	pid, err := r.collaboratorID(p.Profile)
+	if err != nil {
+		return err
+	}
+	if err = r.node.Repo.Logbook().WriteACLGrant(ctx, ref, pid, p.Permissions...); err != nil {
+		return err
+	}
+	return r.collaborators(ctx, ref, res)
+}
+
+// RevokeAccess takes permissions on a dataset away from a profile
+func (r *LogRequests) RevokeAccess(p *AccessParams, res *[]Collaborator) error {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.RevokeAccess", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	ref, err := r.branchDsref(p.Ref)
+	if err != nil {
+		return err
+	}
+	pid, err := r.collaboratorID(p.Profile)
+	if err != nil {
+		return err
+	}
+	if err = r.node.Repo.Logbook().WriteACLRevoke(ctx, ref, pid, p.Permissions...); err != nil {
+		return err
+	}
+	return r.collaborators(ctx, ref, res)
+}
+
+// ShareKeyParams defines parameters for sharing the key to a private dataset
+type ShareKeyParams struct {
+	// Reference to the private dataset
+	Ref string
+	// Profile is the peername or profile ID to share the key with
+	Profile string
+	// PubKey is the base64-encoded public key of the profile. When empty the
+	// key is looked up from peers this node has connected to
+	PubKey string
+}
+
+// ShareKey gives a profile the key to read a private dataset. The key is
+// encrypted to the profile's public key & written to the dataset log. Only the
+// owner of a dataset can share its key. Keys can't be unshared, profiles keep
+// access to every version written with a key they've been given. Use RotateKey
+// to keep a profile from reading new versions. res lists the profile IDs
+// holding the key
+func (r *LogRequests) ShareKey(p *ShareKeyParams, res *[]string) error {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.ShareKey", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	ref, err := r.branchDsref(p.Ref)
+	if err != nil {
+		return err
+	}
+	pid, err := r.collaboratorID(p.Profile)
+	if err != nil {
+		return err
+	}
+	pub, err := r.profilePubKey(pid, p.PubKey)
+	if err != nil {
+		return err
+	}
+
+	book := r.node.Repo.Logbook()
+	keyID, key, err := book.DatasetKey(ctx, ref)
+	if errors.Is(err, logbook.ErrNoKeyShare) {
+		return fmt.Errorf("%s is not a private dataset you hold the key to", ref.Alias())
+	} else if err != nil {
+		return err
+	}
+	if err = book.WriteKeyShare(ctx, ref, pid, pub, keyID, key); err != nil {
+		return err
+	}
+	*res, err = book.KeyHolders(ctx, ref)
+	return err
+}
+
+// RotateKeyParams defines parameters for replacing the key to a private
+// dataset
+type RotateKeyParams struct {
+	// Reference to the private dataset
+	Ref string
+	// Revoke lists peernames or profile IDs that aren't given the new key
+	Revoke []string
+}
+
+// RotateKey replaces the key to a private dataset with a new one. New versions
+// are encrypted with the new key, which is shared with every profile holding
+// the current key except revoked profiles. Existing versions aren't
+// re-encrypted, revoked profiles can still read versions written before the
+// rotation. Only the owner of a dataset can rotate its key. res lists the
+// profile IDs holding the new key
+func (r *LogRequests) RotateKey(p *RotateKeyParams, res *[]string) error {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.RotateKey", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	ref, err := r.branchDsref(p.Ref)
+	if err != nil {
+		return err
+	}
+	revoke := make([]string, len(p.Revoke))
+	for i, str := range p.Revoke {
+		if revoke[i], err = r.collaboratorID(str); err != nil {
+			return err
+		}
+	}
+
+	keyID, key, err := dsfs.NewDataKey()
+	if err != nil {
+		return err
+	}
+	book := r.node.Repo.Logbook()
+	if err = book.WriteKeyRotate(ctx, ref, keyID, key, revoke...); err != nil {
+		return err
+	}
+	*res, err = book.KeyHolders(ctx, ref)
+	return err
+}
+
+// profilePubKey gets the public key of a profile, either by decoding a
+// base64-encoded key or from the peerstore of the node. Keys must match the
+// profile ID
+func (r *LogRequests) profilePubKey(pid, b64PubKey string) (crypto.PubKey, error) {
+	var pub crypto.PubKey
+	if b64PubKey != "" {
+		data, err := base64.StdEncoding.DecodeString(b64PubKey)
+		if err != nil {
+			return nil, fmt.Errorf("public key base64 encoding: %s", err)
+		}
+		if pub, err = crypto.UnmarshalPublicKey(data); err != nil {
+			return nil, fmt.Errorf("invalid public key: %s", err)
+		}
+	} else if h := r.node.Host(); h != nil {
+		pub = h.Peerstore().PubKey(peer.ID(profile.IDB58DecodeOrEmpty(pid)))
+	}
+	if pub == nil {
+		return nil, fmt.Errorf("no public key known for %s. connect to the peer or provide their public key", pid)
+	}
+
+	id, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	if id.Pretty() != pid {
+		return nil, fmt.Errorf("public key doesn't belong to %s", pid)
+	}
+	return pub, nil
+}
+
+// collaboratorID resolves a peername or profile ID string to a profile ID
+func (r *LogRequests) collaboratorID(str string) (string, error) {
+	if str == "" {
+		return "", fmt.Errorf("a peername or profile ID is required")
+	}
+	if id, err := r.node.Repo.Profiles().PeernameID(str); err == nil {
+		return id.String(), nil
+	}
+	id, err := profile.IDB58Decode(str)
+	if err != nil {
+		return "", fmt.Errorf("unknown peer '%s'. use a profile ID for peers you haven't connected to", str)
+	}
+	return id.String(), nil
+}
+
+// collaborators lists the access control list of a dataset, sorted by
+// profile ID
+func (r *LogRequests) collaborators(ctx context.Context, ref dsref.Ref, res *[]Collaborator) error {
+	acl, err := r.node.Repo.Logbook().DatasetACL(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	collabs := make([]Collaborator, 0, len(acl))
+	for pid, perms := range acl {
+		c := Collaborator{ProfileID: pid, Permissions: perms}
+		if pro, err := r.node.Repo.Profiles().GetProfile(profile.IDB58DecodeOrEmpty(pid)); err == nil {
+			c.Peername = pro.Peername
+		}
+		collabs = append(collabs, c)
+	}
+	sort.Slice(collabs, func(i, j int) bool { return collabs[i].ProfileID < collabs[j].ProfileID })
+	*res = collabs
+	return nil
+}
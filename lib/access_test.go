@@ -0,0 +1,218 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestSavePrivateDataset(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// use a fresh logbook to keep key shares limited to this test
+	r := tr.Instance.Node().Repo
+	book, err := logbook.NewJournal(r.PrivateKey(), "peer", qfs.NewMemFS(), "/mem/logbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.(*repo.MemRepo).SetLogbook(book)
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	ref := &reporef.DatasetRef{}
+	p := &SaveParams{
+		Ref:      "me/licensed",
+		BodyPath: tr.writeFile(t, "body.json", `[1,2]`),
+		Private:  true,
+	}
+	if err := req.Save(p, ref); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadAll(mustGet(t, r, dsfs.PackageFilepath(r.Store(), ref.Path, dsfs.PackageFileDataset)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dsfs.IsEncrypted(data) {
+		t.Errorf("expected private dataset to be encrypted at rest")
+	}
+
+	res := &GetResult{}
+	if err := req.Get(&GetParams{Path: "me/licensed", Selector: "body", Format: "json", All: true}, res); err != nil {
+		t.Fatal(err)
+	}
+	if string(res.Bytes) != `[1,2]` {
+		t.Errorf("expected to read private body. got: %s", res.Bytes)
+	}
+	// keys are only available to methods of the repo they're shared with
+	if _, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path); !errors.Is(err, dsfs.ErrNoDataKey) {
+		t.Errorf("expected loading outside of the repo methods to fail with ErrNoDataKey. got: %v", err)
+	}
+
+	// new versions of a private dataset stay private
+	p = &SaveParams{
+		Ref:      "me/licensed",
+		BodyPath: tr.writeFile(t, "body.json", `[1,2,3]`),
+	}
+	if err := req.Save(p, ref); err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(mustGet(t, r, ref.Dataset.BodyPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dsfs.IsEncrypted(body) {
+		t.Errorf("expected new version of a private dataset to be encrypted")
+	}
+
+	err = req.SetPublishStatus(&SetPublishStatusParams{Ref: "me/licensed", PublishStatus: true}, &reporef.DatasetRef{})
+	if !errors.Is(err, base.ErrPrivateDataset) {
+		t.Errorf("expected publishing a private dataset to fail with ErrPrivateDataset. got: %v", err)
+	}
+	// private refs marked as published are still left out of published lists
+	published := reporef.DatasetRef{ProfileID: ref.ProfileID, Peername: ref.Peername, Name: ref.Name, Path: ref.Path, Published: true}
+	if err := r.PutRef(published); err != nil {
+		t.Fatal(err)
+	}
+	refs, err := base.ListDatasets(ctx, r, "licensed", 10, 0, false, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 0 {
+		t.Errorf("expected private datasets to be left out of published lists. got: %v", refs)
+	}
+
+	// share the key with another profile
+	logs := NewLogRequests(tr.Instance.Node(), nil)
+	_, pub, err := crypto.GenerateKeyPair(crypto.RSA, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pid, err := peer.IDFromPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubData, err := crypto.MarshalPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sp := &ShareKeyParams{
+		Ref:     "me/licensed",
+		Profile: pid.Pretty(),
+		PubKey:  base64.StdEncoding.EncodeToString(pubData),
+	}
+
+	holders := []string{}
+	if err := logs.ShareKey(&ShareKeyParams{Ref: "me/licensed", Profile: pid.Pretty()}, &holders); err == nil {
+		t.Error("expected sharing a key without a known public key to error")
+	}
+	pro, err := r.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := logs.ShareKey(&ShareKeyParams{Ref: "me/licensed", Profile: pro.ID.String(), PubKey: sp.PubKey}, &holders); err == nil {
+		t.Error("expected sharing with a public key that doesn't match the profile to error")
+	}
+	if err := logs.ShareKey(sp, &holders); err != nil {
+		t.Fatal(err)
+	}
+	if len(holders) != 2 {
+		t.Errorf("expected owner & shared profile to hold the key. got: %v", holders)
+	}
+
+	// rotating the key leaves revoked profiles out, new versions use the new key
+	dsr := reporef.ConvertToDsref(*ref)
+	oldKeyID, _, err := book.DatasetKey(ctx, dsr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevPath := ref.Path
+	if err := logs.RotateKey(&RotateKeyParams{Ref: "me/licensed", Revoke: []string{pid.Pretty()}}, &holders); err != nil {
+		t.Fatal(err)
+	}
+	if len(holders) != 1 || holders[0] != pro.ID.String() {
+		t.Errorf("expected only the owner to hold the rotated key. got: %v", holders)
+	}
+	newKeyID, _, err := book.DatasetKey(ctx, dsr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newKeyID == oldKeyID {
+		t.Errorf("expected rotation to replace the key")
+	}
+	p = &SaveParams{
+		Ref:      "me/licensed",
+		BodyPath: tr.writeFile(t, "body.json", `[1,2,3,4]`),
+	}
+	if err := req.Save(p, ref); err != nil {
+		t.Fatal(err)
+	}
+	body, err = ioutil.ReadAll(mustGet(t, r, ref.Dataset.BodyPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(body, []byte("qri-private\n"+newKeyID+"\n")) {
+		t.Errorf("expected new version to be encrypted with the rotated key")
+	}
+	// versions written before the rotation are still readable
+	if err := req.Get(&GetParams{Path: "me/licensed@" + prevPath, Selector: "body", Format: "json", All: true}, res); err != nil {
+		t.Fatal(err)
+	}
+	if string(res.Bytes) != `[1,2,3]` {
+		t.Errorf("expected to read a version written with the previous key. got: %s", res.Bytes)
+	}
+}
+
+func TestApplyPrivateDataset(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	r := tr.Instance.Node().Repo
+	book, err := logbook.NewJournal(r.PrivateKey(), "peer", qfs.NewMemFS(), "/mem/logbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.(*repo.MemRepo).SetLogbook(book)
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	p := &SaveParams{
+		Ref: "me/licensed_tf",
+		FilePaths: []string{tr.writeFile(t, "transform.star", `
+def transform(ds, ctx):
+  ds.set_body([1,2])
+`)},
+		Private: true,
+	}
+	if err := req.Save(p, &reporef.DatasetRef{}); err != nil {
+		t.Fatal(err)
+	}
+
+	res := &ApplyResult{}
+	if err := req.Apply(&ApplyParams{Ref: "me/licensed_tf"}, res); err != nil {
+		t.Fatal(err)
+	}
+	if !res.BodyMatches {
+		t.Errorf("expected re-running the transform to reproduce the private body")
+	}
+}
+
+func mustGet(t *testing.T, r repo.Repo, path string) qfs.File {
+	f, err := r.Store().Get(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
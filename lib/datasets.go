@@ -3,6 +3,7 @@ package lib
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -10,6 +11,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/ghodss/yaml"
 	"github.com/qri-io/dag"
@@ -24,10 +26,13 @@ import (
 	"github.com/qri-io/qri/dscache/build"
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/fsi"
+	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/repo"
 	"github.com/qri-io/qri/repo/profile"
 	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/startf"
+	"github.com/qri-io/qri/stats"
 )
 
 // DatasetRequests encapsulates business logic for working with Datasets on Qri
@@ -69,7 +74,7 @@ func (r *DatasetRequests) List(p *ListParams, res *[]dsref.VersionInfo) error {
 		p.RPC = true
 		return r.cli.Call("DatasetRequests.List", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 
 	// ensure valid limit value
 	if p.Limit <= 0 {
@@ -186,7 +191,7 @@ func (r *DatasetRequests) ListRawRefs(p *ListParams, text *string) (err error) {
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.ListRawRefs", p, text)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 	if p.UseDscache {
 		c := r.node.Repo.Dscache()
 		if c == nil || c.IsEmpty() {
@@ -213,6 +218,17 @@ type GetParams struct {
 
 	Limit, Offset int
 	All           bool
+
+	// Fields, Where & Sort select fields, filter & sort entries when getting
+	// the body. Where is an expression like `pop > 10 and name ~ "^A"`, sort
+	// fields prefixed with "-" sort descending
+	Fields []string
+	Where  string
+	Sort   []string
+
+	// AsOf reads the version of the dataset that was the latest at a point in
+	// time. zero reads the latest version
+	AsOf time.Time
 }
 
 // GetResult combines data with it's hashed path
@@ -233,13 +249,26 @@ func (r *DatasetRequests) Get(p *GetParams, res *GetResult) (err error) {
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Get", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 
 	ref, err := base.ToDatasetRef(p.Path, r.node.Repo, p.UseFSI)
 	if err != nil {
 		log.Debugf("Get dataset, base.ToDatasetRef %q failed, error: %s", p.Path, err)
 		return err
 	}
+	if !p.AsOf.IsZero() {
+		if p.UseFSI {
+			return fmt.Errorf("cannot read a linked working directory as of a point in time")
+		}
+		if pr, e := repo.ParseDatasetRef(p.Path); e == nil {
+			if err = repo.CheckAsOf(pr, p.AsOf); err != nil {
+				return err
+			}
+		}
+		if err = repo.ResolveAsOf(ctx, r.node.Repo, ref, p.AsOf); err != nil {
+			return err
+		}
+	}
 
 	var ds *dataset.Dataset
 	if p.UseFSI {
@@ -280,14 +309,15 @@ func (r *DatasetRequests) Get(p *GetParams, res *GetResult) (err error) {
 			return err
 		}
 
+		q := &base.BodyQuery{Fields: p.Fields, Where: p.Where, Sort: p.Sort}
 		var bufData []byte
 		if p.UseFSI {
-			if bufData, err = fsi.GetBody(ref.FSIPath, df, p.FormatConfig, p.Offset, p.Limit, p.All); err != nil {
+			if bufData, err = fsi.GetBody(ref.FSIPath, df, p.FormatConfig, q, p.Offset, p.Limit, p.All); err != nil {
 				log.Debugf("Get dataset, fsi.GetBody %q failed, error: %s", ref.FSIPath, err)
 				return err
 			}
 		} else {
-			if bufData, err = base.ReadBody(ds, df, p.FormatConfig, p.Limit, p.Offset, p.All); err != nil {
+			if bufData, err = base.ReadBody(ds, df, p.FormatConfig, q, p.Limit, p.Offset, p.All); err != nil {
 				log.Debugf("Get dataset, base.ReadBody %q failed, error: %s", ds, err)
 				return err
 			}
@@ -390,8 +420,9 @@ type SaveParams struct {
 	// Replace writes the entire given dataset as a new snapshot instead of
 	// applying save params as augmentations to the existing history
 	Replace bool
-	// option to make dataset private. private data is not currently implimented,
-	// see https://github.com/qri-io/qri/issues/291 for updates
+	// encrypt the dataset at rest. private datasets can only be read by
+	// profiles their key has been shared with, and are never published.
+	// new versions of a private dataset are always private
 	Private bool
 	// if true, set saved dataset to published
 	Publish bool
@@ -411,6 +442,15 @@ type SaveParams struct {
 	NewName bool
 	// whether to create a new dscache if none exists
 	UseDscache bool
+	// logbook branch to save to. defaults to the dataset's active branch
+	Branch string
+	// record responses read by the transform download step, making it
+	// possible to replay the transform without network access
+	RecordFixtures bool
+	// caps on the resources a transform can use. zero values are unlimited
+	TransformLimits startf.Limits
+	// replace the body schema with one inferred from body values
+	InferSchema bool
 }
 
 // AbsolutizePaths converts any relative path references to their absolute
@@ -438,10 +478,10 @@ func (r *DatasetRequests) Save(p *SaveParams, res *reporef.DatasetRef) (err erro
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Save", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 
-	if p.Private {
-		return fmt.Errorf("option to make dataset private not yet implemented, refer to https://github.com/qri-io/qri/issues/291 for updates")
+	if p.Private && p.Publish {
+		return fmt.Errorf("private datasets can't be published")
 	}
 
 	// From cmd/, an empty reference becomes "me/", but from api/, it becomes "" (empty string).
@@ -533,15 +573,43 @@ func (r *DatasetRequests) Save(p *SaveParams, res *reporef.DatasetRef) (err erro
 		return
 	}
 
+	branch := p.Branch
+	if branch == "" {
+		branch = r.activeBranch(ctx, ds.Peername, ds.Name)
+	}
+	if err = base.ConvertParquetBody(ctx, r.node.Repo, ds, branch); err != nil {
+		return err
+	}
+
 	// If the dscache doesn't exist yet, it will only be created if the appropriate flag enables it.
 	if p.UseDscache {
 		c := r.node.Repo.Dscache()
 		c.CreateNewEnabled = true
 	}
 
+	onDefaultBranch := branch == "" || branch == logbook.DefaultBranchName
+	if p.Publish && !onDefaultBranch {
+		return fmt.Errorf("can only publish versions saved to the %q branch", logbook.DefaultBranchName)
+	}
+
 	// TODO (b5) - this should be integrated into base.SaveDataset
 	fsiPath := ref.FSIPath
 
+	// saving a working directory with a merge in progress completes the merge
+	mergeParent := ""
+	if fsiPath != "" {
+		if state, err := fsi.ReadMergeState(fsiPath); err == nil && state.Branch == branch {
+			mergeParent = state.Theirs
+			files, err := fsi.ConflictMarkers(fsiPath)
+			if err != nil {
+				return err
+			}
+			if len(files) > 0 {
+				return fmt.Errorf("merge conflicts aren't resolved, remove the conflict markers from %s before saving", strings.Join(files, ", "))
+			}
+		}
+	}
+
 	switches := base.SaveDatasetSwitches{
 		Replace:             p.Replace,
 		DryRun:              p.DryRun,
@@ -550,15 +618,31 @@ func (r *DatasetRequests) Save(p *SaveParams, res *reporef.DatasetRef) (err erro
 		Force:               p.Force,
 		ShouldRender:        p.ShouldRender,
 		NewName:             p.NewName,
+		Branch:              branch,
+		MergeParent:         mergeParent,
+		Private:             p.Private,
+		RecordFixtures:      p.RecordFixtures,
+		TransformLimits:     p.TransformLimits,
+		InferSchema:         p.InferSchema,
 	}
 	ref, err = base.SaveDataset(ctx, r.node.Repo, r.node.LocalStreams, ds, p.Secrets, p.ScriptOutput, switches)
 	if err != nil {
 		log.Debugf("create ds error: %s\n", err.Error())
+		var limitErr *startf.LimitError
+		if errors.As(err, &limitErr) {
+			return NewError(err, fmt.Sprintf("%s. the transform was stopped and nothing was saved", limitErr))
+		}
 		return err
 	}
 
+	if mergeParent != "" && !p.DryRun {
+		if err = fsi.RemoveMergeState(fsiPath); err != nil {
+			return err
+		}
+	}
+
 	// TODO (b5) - this should be integrated into base.SaveDataset
-	if fsiPath != "" {
+	if fsiPath != "" && onDefaultBranch {
 		ref.FSIPath = fsiPath
 		if err = r.node.Repo.PutRef(ref); err != nil {
 			return err
@@ -595,6 +679,78 @@ func (r *DatasetRequests) Save(p *SaveParams, res *reporef.DatasetRef) (err erro
 	return nil
 }
 
+// ApplyParams encapsulates arguments to Apply
+type ApplyParams struct {
+	// dataset reference string of the version to re-run the transform of
+	Ref string
+	// serve transform download requests from the fixtures recorded when the
+	// version was saved instead of the network
+	Replay bool
+	// secrets for transform execution
+	Secrets map[string]string
+	// optional writer to have transform script record standard output to
+	// note: this won't work over RPC, only on local calls
+	ScriptOutput io.Writer
+}
+
+// ApplyResult is the outcome of re-running a transform
+type ApplyResult struct {
+	// Path of the version the transform was re-run for
+	Path string
+	// Data is the dataset the transform produced
+	Data *dataset.Dataset
+	// BodyMatches is true if the transform reproduced the committed body
+	BodyMatches bool
+}
+
+// Apply re-runs the transform of a saved dataset version without saving,
+// comparing the result to the committed body
+func (r *DatasetRequests) Apply(p *ApplyParams, res *ApplyResult) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Apply", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	ref, err := base.ToDatasetRef(p.Ref, r.node.Repo, false)
+	if err != nil {
+		return err
+	}
+
+	next, err := base.ApplyTransform(ctx, r.node.Repo, ref, p.Replay, p.Secrets, p.ScriptOutput)
+	if err != nil {
+		return err
+	}
+
+	res.Path = ref.Path
+	res.Data = next
+	if err = base.CompareBodies(ctx, r.node.Repo, ref.Dataset, next); err != nil {
+		if errors.Is(err, base.ErrBodyMismatch) {
+			return nil
+		}
+		return err
+	}
+	res.BodyMatches = true
+	return nil
+}
+
+// activeBranch returns the active logbook branch for a dataset name, returning
+// the empty string if the dataset or logbook doesn't exist
+func (r *DatasetRequests) activeBranch(ctx context.Context, peername, name string) string {
+	book := r.node.Repo.Logbook()
+	if book == nil || name == "" {
+		return ""
+	}
+	ref := reporef.DatasetRef{Peername: peername, Name: name}
+	if err := repo.CanonicalizeProfile(r.node.Repo, &ref); err != nil {
+		return ""
+	}
+	branch, err := book.ActiveBranch(ctx, reporef.ConvertToDsref(ref))
+	if err != nil {
+		return ""
+	}
+	return branch
+}
+
 // SetPublishStatusParams encapsulates parameters for setting the publication status of a dataset
 type SetPublishStatusParams struct {
 	Ref           string
@@ -636,7 +792,7 @@ func (r *DatasetRequests) Rename(p *RenameParams, res *dsref.VersionInfo) (err e
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Rename", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 
 	if p.Current.IsEmpty() {
 		return fmt.Errorf("current name is required to rename a dataset")
@@ -699,7 +855,7 @@ func (r *DatasetRequests) Remove(p *RemoveParams, res *RemoveResponse) error {
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Remove", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 
 	log.Debugf("Remove dataset ref %q, revisions %v", p.Ref, p.Revision)
 
@@ -872,7 +1028,7 @@ func (r *DatasetRequests) Add(p *AddParams, res *reporef.DatasetRef) (err error)
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Add", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 
 	ref, err := repo.ParseDatasetRef(p.Ref)
 	if err != nil {
@@ -925,7 +1081,7 @@ func (r *DatasetRequests) Validate(p *ValidateDatasetParams, errors *[]jsonschem
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Validate", p, errors)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 
 	// TODO: restore validating data from a URL
 	// if p.URL != "" && ref.IsEmpty() && o.Schema == nil {
@@ -1047,7 +1203,7 @@ func (r *DatasetRequests) Manifest(refstr *string, m *dag.Manifest) (err error)
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Manifest", refstr, m)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 
 	ref, err := repo.ParseDatasetRef(*refstr)
 	if err != nil {
@@ -1071,7 +1227,7 @@ func (r *DatasetRequests) ManifestMissing(a, b *dag.Manifest) (err error) {
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Manifest", a, b)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 
 	var mf *dag.Manifest
 	mf, err = r.node.MissingManifest(ctx, a)
@@ -1092,7 +1248,7 @@ func (r *DatasetRequests) DAGInfo(s *DAGInfoParams, i *dag.Info) (err error) {
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.DAGInfo", s, i)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 
 	ref, err := repo.ParseDatasetRef(s.RefStr)
 	if err != nil {
@@ -1130,7 +1286,7 @@ func (r *DatasetRequests) Stats(p *StatsParams, res *StatsResponse) (err error)
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Stats", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 	if p.Dataset == nil {
 		ref := &reporef.DatasetRef{}
 		ref, err = base.ToDatasetRef(p.Ref, r.node.Repo, false)
@@ -1167,3 +1323,139 @@ func (r *DatasetRequests) Stats(p *StatsParams, res *StatsResponse) (err error)
 	res.StatsBytes, err = ioutil.ReadAll(reader)
 	return err
 }
+
+// InferSchemaParams defines the params for an InferSchema request
+type InferSchemaParams struct {
+	// string representation of a dataset reference
+	Ref string
+	// path to body data, infers a schema for a body that hasn't been saved
+	// instead of a dataset version
+	BodyPath string
+}
+
+// InferSchema proposes a schema for a dataset body from statistics of its
+// values, giving the structure the schema would be saved with. Nothing is
+// saved, the structure can be reviewed & edited before saving
+func (r *DatasetRequests) InferSchema(p *InferSchemaParams, res *dataset.Structure) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.InferSchema", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	ds := &dataset.Dataset{BodyPath: p.BodyPath}
+	if p.BodyPath == "" {
+		ref, err := base.ToDatasetRef(p.Ref, r.node.Repo, false)
+		if err != nil {
+			return err
+		}
+		if ds, err = dsfs.LoadDataset(ctx, r.node.Repo.Store(), ref.Path); err != nil {
+			return fmt.Errorf("loading dataset: %s", err)
+		}
+	}
+	if err = base.OpenDataset(ctx, r.node.Repo.Filesystem(), ds); err != nil {
+		return err
+	}
+
+	sch, err := base.InferSchema(ds)
+	if err != nil {
+		return err
+	}
+	*res = dataset.Structure{
+		Format:       ds.Structure.Format,
+		FormatConfig: ds.Structure.FormatConfig,
+		Schema:       sch,
+	}
+	return nil
+}
+
+// StatsDiffParams defines the params for a StatsDiff request
+type StatsDiffParams struct {
+	// string representation of a dataset reference
+	Ref string
+	// Compare is the version to compare against, either a revision relative to
+	// Ref like "HEAD~1", or a dataset reference
+	Compare string
+	// Thresholds sets how much a column can change before it's flagged,
+	// defaults to stats.DefaultDriftThresholds
+	Thresholds *stats.DriftThresholds
+}
+
+// StatsDiff compares the stats of two versions of a dataset, flagging columns
+// that have changed past threshold
+func (r *DatasetRequests) StatsDiff(p *StatsDiffParams, res *stats.DriftReport) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.StatsDiff", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	if p.Compare == "" {
+		return fmt.Errorf("a version to compare against is required")
+	}
+	ref, err := base.ToDatasetRef(p.Ref, r.node.Repo, false)
+	if err != nil {
+		return err
+	}
+
+	prevPath := ""
+	if rev, err := dsref.ParseRevGen(p.Compare); err == nil {
+		if prevPath, err = base.GenerationPath(ctx, r.node.Repo, ref.Path, rev.Gen); err != nil {
+			return err
+		}
+	} else {
+		cmp, err := base.ToDatasetRef(p.Compare, r.node.Repo, false)
+		if err != nil {
+			return err
+		}
+		prevPath = cmp.Path
+	}
+
+	prev, err := r.statsMaps(ctx, prevPath)
+	if err != nil {
+		return err
+	}
+	next, err := r.statsMaps(ctx, ref.Path)
+	if err != nil {
+		return err
+	}
+
+	th := stats.DefaultDriftThresholds
+	if p.Thresholds != nil {
+		th = *p.Thresholds
+	}
+	*res = *stats.Drift(prev, next, th)
+	return nil
+}
+
+// statsMaps loads the stats of the dataset version at path, decoded as a list
+// of column stats
+func (r *DatasetRequests) statsMaps(ctx context.Context, path string) ([]map[string]interface{}, error) {
+	ds, err := dsfs.LoadDataset(ctx, r.node.Repo.Store(), path)
+	if err != nil {
+		return nil, fmt.Errorf("loading dataset: %s", err)
+	}
+	if err = base.OpenDataset(ctx, r.node.Repo.Filesystem(), ds); err != nil {
+		return nil, err
+	}
+
+	sres := &StatsResponse{}
+	if err = r.Stats(&StatsParams{Dataset: ds}, sres); err != nil {
+		return nil, err
+	}
+	cols := []map[string]interface{}{}
+	if err = json.Unmarshal(sres.StatsBytes, &cols); err != nil {
+		return nil, fmt.Errorf("stats for %s aren't a list of columns: %s", path, err)
+	}
+
+	// name array columns by their schema titles so columns are compared by
+	// name, not position. bodies that aren't tabular have no column titles
+	if ds.Structure != nil {
+		if titles, err := base.ColumnTitles(ds.Structure.Schema); err == nil && len(titles) == len(cols) {
+			for i, col := range cols {
+				if _, ok := col["key"]; !ok && titles[i] != "" {
+					col["key"] = titles[i]
+				}
+			}
+		}
+	}
+	return cols, nil
+}
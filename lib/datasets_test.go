@@ -3,6 +3,7 @@ package lib
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -21,16 +22,19 @@ import (
 	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/dataset/dstest"
 	"github.com/qri-io/jsonschema"
+	"github.com/qri-io/qfs"
 	"github.com/qri-io/qfs/cafs"
 	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/p2p"
 	p2ptest "github.com/qri-io/qri/p2p/test"
 	"github.com/qri-io/qri/repo"
 	reporef "github.com/qri-io/qri/repo/ref"
 	testrepo "github.com/qri-io/qri/repo/test"
+	"github.com/qri-io/qri/stats"
 )
 
 func TestDatasetRequestsSave(t *testing.T) {
@@ -73,8 +77,8 @@ func TestDatasetRequestsSave(t *testing.T) {
 
 	req := NewDatasetRequests(node, nil)
 
-	privateErrMsg := "option to make dataset private not yet implemented, refer to https://github.com/qri-io/qri/issues/291 for updates"
-	if err := req.Save(&SaveParams{Private: true}, nil); err == nil {
+	privateErrMsg := "private datasets can't be published"
+	if err := req.Save(&SaveParams{Private: true, Publish: true}, nil); err == nil {
 		t.Errorf("expected datset to error")
 	} else if err.Error() != privateErrMsg {
 		t.Errorf("private flag error mismatch: expected: '%s', got: '%s'", privateErrMsg, err.Error())
@@ -610,6 +614,81 @@ func TestDatasetRequestsGetP2p(t *testing.T) {
 	wg.Wait()
 }
 
+func TestDatasetRequestsGetAsOf(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	// use a fresh logbook to keep history limited to this test
+	mr := tr.Instance.Node().Repo.(*repo.MemRepo)
+	book, err := logbook.NewJournal(tr.Instance.Node().Repo.PrivateKey(), "peer", qfs.NewMemFS(), "/mem/logbook")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr.SetLogbook(book)
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	save := func(ts time.Time, body string) {
+		dsfs.Timestamp = func() time.Time { return ts }
+		p := &SaveParams{
+			Ref:      "me/as_of",
+			BodyPath: tr.writeFile(t, "body.json", body),
+		}
+		if err := req.Save(p, &reporef.DatasetRef{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), `[1]`)
+	save(time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), `[1,2]`)
+
+	cases := []struct {
+		asOf   time.Time
+		expect string
+	}{
+		{time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), `[1]`},
+		{time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC), `[1]`},
+		{time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), `[1,2]`},
+	}
+	for _, c := range cases {
+		res := &GetResult{}
+		p := &GetParams{Path: "me/as_of", Selector: "body", Format: "json", All: true, AsOf: c.asOf}
+		if err := req.Get(p, res); err != nil {
+			t.Fatalf("as of %s: %s", c.asOf, err)
+		}
+		if string(res.Bytes) != c.expect {
+			t.Errorf("as of %s: expected body %s, got: %s", c.asOf, c.expect, string(res.Bytes))
+		}
+	}
+
+	p := &GetParams{Path: "me/as_of", AsOf: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)}
+	if err := req.Get(p, &GetResult{}); !errors.Is(err, repo.ErrNoVersionAsOf) {
+		t.Errorf("expected getting a dataset before its first version to error. got: %v", err)
+	}
+
+	diff := &DiffResponse{}
+	dp := &DiffParams{LeftPath: "me/as_of", Selector: "body", AsOf: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)}
+	if err := req.Diff(dp, diff); err != nil {
+		t.Fatal(err)
+	}
+	if diff.Stat.Inserts != 1 {
+		t.Errorf("expected diff as of a point in time to compare against the latest version. got stat: %#v", diff.Stat)
+	}
+
+	// an explicit version can't be combined with as-of
+	ref := reporef.DatasetRef{Peername: "me", Name: "as_of"}
+	if err := repo.CanonicalizeDatasetRef(tr.Instance.Node().Repo, &ref); err != nil {
+		t.Fatal(err)
+	}
+	versioned := "me/as_of@" + ref.Path
+	dp = &DiffParams{LeftPath: versioned, Selector: "body", AsOf: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)}
+	if err := req.Diff(dp, &DiffResponse{}); !errors.Is(err, repo.ErrAsOfVersion) {
+		t.Errorf("expected diffing a versioned reference as of a point in time to error. got: %v", err)
+	}
+	p = &GetParams{Path: versioned, AsOf: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)}
+	if err := req.Get(p, &GetResult{}); !errors.Is(err, repo.ErrAsOfVersion) {
+		t.Errorf("expected getting a versioned reference as of a point in time to error. got: %v", err)
+	}
+}
+
 func TestDatasetRequestsRename(t *testing.T) {
 	mr, err := testrepo.NewTestRepo()
 	if err != nil {
@@ -1006,8 +1085,8 @@ func TestDatasetRequestsStats(t *testing.T) {
 		ref         string
 		expected    []byte
 	}{
-		{"csv: me/cities", "me/cities", []byte(`[{"count":5,"maxLength":8,"minLength":7,"type":"string","unique":5},{"count":5,"histogram":{"bins":[35000,4031500.1,8028000.2,12024500.3,16021000.4,20017500.5,24014000.6,28010500.7,32007000.8,36003500.9,40000001],"frequencies":[3,0,1,0,0,0,0,0,0,1]},"max":40000000,"mean":9817000,"median":300000,"min":35000,"type":"numeric"},{"count":5,"histogram":{"bins":[44.4,46.585,48.769999999999996,50.955,53.14,55.325,57.51,59.695,61.879999999999995,64.065,66.25],"frequencies":[2,0,1,0,0,1,0,0,0,1]},"max":65.25,"mean":52.04,"median":50.65,"min":44.4,"type":"numeric"},{"count":5,"falseCount":1,"trueCount":4,"type":"boolean"}]`)},
-		{"json: me/sitemap", "me/sitemap", []byte(`[{"count":10,"histogram":{"bins":[24515,26071.5,27628,29184.5,30741,32297.5,33854,35410.5,36967,38523.5,40080],"frequencies":[4,0,3,1,0,0,1,0,0,1]},"key":"contentLength","max":40079,"mean":28825.8,"median":28059,"min":24515,"type":"numeric"},{"count":10,"frequencies":{"text/html; charset=utf-8":10},"key":"contentSniff","maxLength":24,"minLength":24,"type":"string"},{"count":10,"frequencies":{"text/html; charset=utf-8":10},"key":"contentType","maxLength":24,"minLength":24,"type":"string"},{"count":10,"histogram":{"bins":[74291866,475020463.6,875749061.2,1276477658.8000002,1677206256.4,2077934854,2478663451.6000004,2879392049.2000003,3280120646.8,3680849244.4,4081577842],"frequencies":[2,0,0,0,0,0,0,0,0,8]},"key":"duration","max":4081577841,"mean":3276899953.4,"median":4077230086,"min":74291866,"type":"numeric"},{"count":10,"key":"hash","maxLength":68,"minLength":68,"type":"string","unique":10},{"key":"links","type":"array","values":[{"count":10,"maxLength":58,"minLength":14,"unique":10},{"count":10,"maxLength":115,"minLength":19,"unique":10},{"count":10,"maxLength":68,"minLength":22,"unique":10},{"count":10,"maxLength":115,"minLength":14,"unique":10},{"count":9,"maxLength":70,"minLength":15,"unique":9},{"count":9,"maxLength":115,"minLength":37,"unique":9},{"count":9,"maxLength":52,"minLength":15,"unique":9},{"count":9,"maxLength":75,"minLength":19,"unique":9},{"count":9,"maxLength":66,"minLength":15,"unique":9},{"count":7,"maxLength":75,"minLength":19,"unique":7},{"count":7,"maxLength":66,"minLength":22,"unique":7},{"count":6,"maxLength":43,"minLength":19,"unique":6},{"count":6,"maxLength":77,"minLength":14,"unique":6},{"count":6,"maxLength":77,"minLength":21,"unique":6},{"count":4,"maxLength":43,"minLength":14,"unique":4},{"count":3,"maxLength":32,"minLength":21,"unique":3},{"count":3,"maxLength":42,"minLength":19,"unique":3},{"count":3,"maxLength":66,"minLength":32,"unique":3},{"count":3,"maxLength":46,"minLength":19,"unique":3},{"count":2,"maxLength":66,"minLength":22,"unique":2},{"count":2,"maxLength":32,"minLength":23,"unique":2},{"count":2,"maxLength":33,"minLength":22,"unique":2},{"count":2,"maxLength":32,"minLength":27,"unique":2},{"count":1,"maxLength":33,"minLength":33,"unique":1},{"count":1,"maxLength":27,"minLength":27,"unique":1}]},{"count":1,"key":"redirectTo","maxLength":18,"minLength":18,"type":"string","unique":1},{"count":11,"histogram":{"bins":[200,210.2,220.4,230.6,240.8,251,261.2,271.4,281.6,291.8,302],"frequencies":[10,0,0,0,0,0,0,0,0,1]},"key":"status","max":301,"mean":209.1818181818182,"median":200,"min":200,"type":"numeric"},{"count":11,"key":"timestamp","maxLength":35,"minLength":35,"type":"string","unique":11},{"count":10,"key":"title","maxLength":88,"minLength":53,"type":"string","unique":10},{"count":11,"key":"url","maxLength":78,"minLength":18,"type":"string","unique":11}]`)},
+		{"csv: me/cities", "me/cities", []byte(`[{"count":5,"distinct":5,"emptyRatio":0,"lengthHistogram":{"bins":[7,7.2,7.4,7.6,7.8,8,8.2,8.4,8.6,8.8,9],"frequencies":[4,0,0,0,0,1,0,0,0,0]},"lengthQuantiles":{"p25":7,"p5":7,"p50":7,"p75":7,"p95":8},"maxLength":8,"minLength":7,"nullRatio":0,"type":"string","unique":5},{"count":5,"distinct":5,"histogram":{"bins":[35000,4031500.1,8028000.2,12024500.3,16021000.4,20017500.5,24014000.6,28010500.7,32007000.8,36003500.9,40000001],"frequencies":[3,0,1,0,0,0,0,0,0,1]},"max":40000000,"mean":9817000,"median":300000,"min":35000,"nullRatio":0,"quantiles":{"p25":250000,"p5":35000,"p50":300000,"p75":8500000,"p95":40000000},"type":"numeric"},{"count":5,"distinct":4,"histogram":{"bins":[44.4,46.585,48.769999999999996,50.955,53.14,55.325,57.51,59.695,61.879999999999995,64.065,66.25],"frequencies":[2,0,1,0,0,1,0,0,0,1]},"max":65.25,"mean":52.04,"median":50.65,"min":44.4,"nullRatio":0,"quantiles":{"p25":44.4,"p5":44.4,"p50":50.65,"p75":55.5,"p95":65.25},"type":"numeric"},{"count":5,"falseCount":1,"nullRatio":0,"trueCount":4,"type":"boolean"}]`)},
+		{"json: me/sitemap", "me/sitemap", []byte(`[{"count":10,"distinct":10,"histogram":{"bins":[24515,26071.5,27628,29184.5,30741,32297.5,33854,35410.5,36967,38523.5,40080],"frequencies":[4,0,3,1,0,0,1,0,0,1]},"key":"contentLength","max":40079,"mean":28825.8,"median":28059,"min":24515,"nullRatio":0,"quantiles":{"p25":25028,"p5":24515,"p50":27827,"p75":30258,"p95":40079},"type":"numeric"},{"count":10,"distinct":1,"emptyRatio":0,"frequencies":{"text/html; charset=utf-8":10},"key":"contentSniff","lengthHistogram":{"bins":[24,24.1,24.2,24.3,24.4,24.5,24.6,24.7,24.8,24.9,25],"frequencies":[10,0,0,0,0,0,0,0,0,0]},"lengthQuantiles":{"p25":24,"p5":24,"p50":24,"p75":24,"p95":24},"maxLength":24,"minLength":24,"nullRatio":0,"type":"string"},{"count":10,"distinct":1,"emptyRatio":0,"frequencies":{"text/html; charset=utf-8":10},"key":"contentType","lengthHistogram":{"bins":[24,24.1,24.2,24.3,24.4,24.5,24.6,24.7,24.8,24.9,25],"frequencies":[10,0,0,0,0,0,0,0,0,0]},"lengthQuantiles":{"p25":24,"p5":24,"p50":24,"p75":24,"p95":24},"maxLength":24,"minLength":24,"nullRatio":0,"type":"string"},{"count":10,"distinct":10,"histogram":{"bins":[74291866,475020463.6,875749061.2,1276477658.8000002,1677206256.4,2077934854,2478663451.6000004,2879392049.2000003,3280120646.8,3680849244.4,4081577842],"frequencies":[2,0,0,0,0,0,0,0,0,8]},"key":"duration","max":4081577841,"mean":3276899953.4,"median":4077230086,"min":74291866,"nullRatio":0,"quantiles":{"p25":4055332831,"p5":74291866,"p50":4077173686,"p75":4080164896,"p95":4081577841},"type":"numeric"},{"count":10,"distinct":10,"emptyRatio":0,"key":"hash","lengthHistogram":{"bins":[68,68.1,68.2,68.3,68.4,68.5,68.6,68.7,68.8,68.9,69],"frequencies":[10,0,0,0,0,0,0,0,0,0]},"lengthQuantiles":{"p25":68,"p5":68,"p50":68,"p75":68,"p95":68},"maxLength":68,"minLength":68,"nullRatio":0,"type":"string","unique":10},{"key":"links","type":"array","values":[{"count":10,"distinct":10,"emptyRatio":0,"lengthHistogram":{"bins":[14,18.5,23,27.5,32,36.5,41,45.5,50,54.5,59],"frequencies":[2,3,0,1,1,0,1,0,1,1]},"lengthQuantiles":{"p25":19,"p5":14,"p50":22,"p75":41,"p95":58},"maxLength":58,"minLength":14,"nullRatio":0,"unique":10},{"count":10,"distinct":10,"emptyRatio":0,"lengthHistogram":{"bins":[19,28.7,38.4,48.099999999999994,57.8,67.5,77.19999999999999,86.89999999999999,96.6,106.3,116],"frequencies":[5,1,2,1,0,0,0,0,0,1]},"lengthQuantiles":{"p25":22,"p5":19,"p50":28,"p75":46,"p95":115},"maxLength":115,"minLength":19,"nullRatio":0,"unique":10},{"count":10,"distinct":10,"emptyRatio":0,"lengthHistogram":{"bins":[22,26.7,31.4,36.1,40.8,45.5,50.2,54.9,59.6,64.30000000000001,69],"frequencies":[4,0,0,1,2,0,0,1,0,2]},"lengthQuantiles":{"p25":22,"p5":22,"p50":37,"p75":58,"p95":68},"maxLength":68,"minLength":22,"nullRatio":0,"unique":10},{"count":10,"distinct":10,"emptyRatio":0,"lengthHistogram":{"bins":[14,24.2,34.4,44.599999999999994,54.8,65,75.19999999999999,85.39999999999999,95.6,105.8,116],"frequencies":[4,1,0,2,2,0,0,0,0,1]},"lengthQuantiles":{"p25":20,"p5":14,"p50":32,"p75":60,"p95":115},"maxLength":115,"minLength":14,"nullRatio":0,"unique":10},{"count":9,"distinct":9,"emptyRatio":0,"lengthHistogram":{"bins":[15,20.6,26.2,31.799999999999997,37.4,43,48.599999999999994,54.199999999999996,59.8,65.4,71],"frequencies":[2,2,0,0,0,1,1,1,0,2]},"lengthQuantiles":{"p25":21,"p5":15,"p50":43,"p75":58,"p95":70},"maxLength":70,"minLength":15,"nullRatio":0,"unique":9},{"count":9,"distinct":9,"emptyRatio":0,"lengthHistogram":{"bins":[37,44.9,52.8,60.7,68.6,76.5,84.4,92.30000000000001,100.2,108.10000000000001,116],"frequencies":[2,3,0,1,2,0,0,0,0,1]},"lengthQuantiles":{"p25":48,"p5":37,"p50":50,"p75":73,"p95":115},"maxLength":115,"minLength":37,"nullRatio":0,"unique":9},{"count":9,"distinct":9,"emptyRatio":0,"lengthHistogram":{"bins":[15,18.8,22.6,26.4,30.2,34,37.8,41.599999999999994,45.4,49.199999999999996,53],"frequencies":[1,1,2,0,1,0,0,1,2,1]},"lengthQuantiles":{"p25":23,"p5":15,"p50":32,"p75":47,"p95":52},"maxLength":52,"minLength":15,"nullRatio":0,"unique":9},{"count":9,"distinct":9,"emptyRatio":0,"lengthHistogram":{"bins":[19,24.7,30.4,36.1,41.8,47.5,53.2,58.9,64.6,70.30000000000001,76],"frequencies":[3,0,2,0,0,2,0,0,1,1]},"lengthQuantiles":{"p25":22,"p5":19,"p50":36,"p75":50,"p95":75},"maxLength":75,"minLength":19,"nullRatio":0,"unique":9},{"count":9,"distinct":9,"emptyRatio":0,"lengthHistogram":{"bins":[15,20.2,25.4,30.6,35.8,41,46.2,51.4,56.6,61.800000000000004,67],"frequencies":[2,2,1,1,0,1,1,0,0,1]},"lengthQuantiles":{"p25":22,"p5":15,"p50":29,"p75":44,"p95":66},"maxLength":66,"minLength":15,"nullRatio":0,"unique":9},{"count":7,"distinct":7,"emptyRatio":0,"lengthHistogram":{"bins":[19,24.7,30.4,36.1,41.8,47.5,53.2,58.9,64.6,70.30000000000001,76],"frequencies":[3,0,1,0,2,0,0,0,0,1]},"lengthQuantiles":{"p25":19,"p5":19,"p50":33,"p75":43,"p95":75},"maxLength":75,"minLength":19,"nullRatio":0,"unique":7},{"count":7,"distinct":7,"emptyRatio":0,"lengthHistogram":{"bins":[22,26.5,31,35.5,40,44.5,49,53.5,58,62.5,67],"frequencies":[5,1,0,0,0,0,0,0,0,1]},"lengthQuantiles":{"p25":22,"p5":22,"p50":25,"p75":27,"p95":66},"maxLength":66,"minLength":22,"nullRatio":0,"unique":7},{"count":6,"distinct":6,"emptyRatio":0,"lengthHistogram":{"bins":[19,21.5,24,26.5,29,31.5,34,36.5,39,41.5,44],"frequencies":[2,0,1,0,0,1,0,0,0,2]},"lengthQuantiles":{"p25":20,"p5":19,"p50":26,"p75":43,"p95":43},"maxLength":43,"minLength":19,"nullRatio":0,"unique":6},{"count":6,"distinct":6,"emptyRatio":0,"lengthHistogram":{"bins":[14,20.4,26.8,33.2,39.6,46,52.400000000000006,58.800000000000004,65.2,71.6,78],"frequencies":[1,1,1,0,0,1,1,0,0,1]},"lengthQuantiles":{"p25":22,"p5":14,"p50":33,"p75":57,"p95":77},"maxLength":77,"minLength":14,"nullRatio":0,"unique":6},{"count":6,"distinct":6,"emptyRatio":0,"lengthHistogram":{"bins":[21,26.7,32.4,38.1,43.8,49.5,55.2,60.9,66.6,72.30000000000001,78],"frequencies":[1,1,0,1,2,0,0,0,0,1]},"lengthQuantiles":{"p25":27,"p5":21,"p50":43,"p75":48,"p95":77},"maxLength":77,"minLength":21,"nullRatio":0,"unique":6},{"count":4,"distinct":4,"emptyRatio":0,"lengthHistogram":{"bins":[14,17,20,23,26,29,32,35,38,41,44],"frequencies":[1,0,0,0,1,0,0,1,0,1]},"lengthQuantiles":{"p25":14,"p5":14,"p50":27,"p75":37,"p95":43},"maxLength":43,"minLength":14,"nullRatio":0,"unique":4},{"count":3,"distinct":3,"emptyRatio":0,"lengthHistogram":{"bins":[21,22.2,23.4,24.6,25.8,27,28.2,29.4,30.6,31.799999999999997,33],"frequencies":[1,1,0,0,0,0,0,0,0,1]},"lengthQuantiles":{"p25":21,"p5":21,"p50":23,"p75":32,"p95":32},"maxLength":32,"minLength":21,"nullRatio":0,"unique":3},{"count":3,"distinct":3,"emptyRatio":0,"lengthHistogram":{"bins":[19,21.4,23.8,26.2,28.6,31,33.4,35.8,38.2,40.599999999999994,43],"frequencies":[1,0,0,0,0,0,0,1,0,1]},"lengthQuantiles":{"p25":19,"p5":19,"p50":37,"p75":42,"p95":42},"maxLength":42,"minLength":19,"nullRatio":0,"unique":3},{"count":3,"distinct":3,"emptyRatio":0,"lengthHistogram":{"bins":[32,35.5,39,42.5,46,49.5,53,56.5,60,63.5,67],"frequencies":[1,0,0,0,1,0,0,0,0,1]},"lengthQuantiles":{"p25":32,"p5":32,"p50":46,"p75":66,"p95":66},"maxLength":66,"minLength":32,"nullRatio":0,"unique":3},{"count":3,"distinct":3,"emptyRatio":0,"lengthHistogram":{"bins":[19,21.8,24.6,27.4,30.2,33,35.8,38.599999999999994,41.4,44.2,47],"frequencies":[1,1,0,0,0,0,0,0,0,1]},"lengthQuantiles":{"p25":19,"p5":19,"p50":23,"p75":46,"p95":46},"maxLength":46,"minLength":19,"nullRatio":0,"unique":3},{"count":2,"distinct":2,"emptyRatio":0,"lengthHistogram":{"bins":[22,26.5,31,35.5,40,44.5,49,53.5,58,62.5,67],"frequencies":[1,0,0,0,0,0,0,0,0,1]},"lengthQuantiles":{"p25":22,"p5":22,"p50":22,"p75":66,"p95":66},"maxLength":66,"minLength":22,"nullRatio":0,"unique":2},{"count":2,"distinct":2,"emptyRatio":0,"lengthHistogram":{"bins":[23,24,25,26,27,28,29,30,31,32,33],"frequencies":[1,0,0,0,0,0,0,0,0,1]},"lengthQuantiles":{"p25":23,"p5":23,"p50":23,"p75":32,"p95":32},"maxLength":32,"minLength":23,"nullRatio":0,"unique":2},{"count":2,"distinct":2,"emptyRatio":0,"lengthHistogram":{"bins":[22,23.2,24.4,25.6,26.8,28,29.2,30.4,31.6,32.8,34],"frequencies":[1,0,0,0,0,0,0,0,0,1]},"lengthQuantiles":{"p25":22,"p5":22,"p50":22,"p75":33,"p95":33},"maxLength":33,"minLength":22,"nullRatio":0,"unique":2},{"count":2,"distinct":2,"emptyRatio":0,"lengthHistogram":{"bins":[27,27.6,28.2,28.8,29.4,30,30.6,31.2,31.8,32.4,33],"frequencies":[1,0,0,0,0,0,0,0,1,0]},"lengthQuantiles":{"p25":27,"p5":27,"p50":27,"p75":32,"p95":32},"maxLength":32,"minLength":27,"nullRatio":0,"unique":2},{"count":1,"distinct":1,"emptyRatio":0,"lengthHistogram":{"bins":[33,33.1,33.2,33.3,33.4,33.5,33.6,33.7,33.8,33.9,34],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"lengthQuantiles":{"p25":33,"p5":33,"p50":33,"p75":33,"p95":33},"maxLength":33,"minLength":33,"nullRatio":0,"unique":1},{"count":1,"distinct":1,"emptyRatio":0,"lengthHistogram":{"bins":[27,27.1,27.2,27.3,27.4,27.5,27.6,27.7,27.8,27.9,28],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"lengthQuantiles":{"p25":27,"p5":27,"p50":27,"p75":27,"p95":27},"maxLength":27,"minLength":27,"nullRatio":0,"unique":1}]},{"count":1,"distinct":1,"emptyRatio":0,"key":"redirectTo","lengthHistogram":{"bins":[18,18.1,18.2,18.3,18.4,18.5,18.6,18.7,18.8,18.9,19],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"lengthQuantiles":{"p25":18,"p5":18,"p50":18,"p75":18,"p95":18},"maxLength":18,"minLength":18,"nullRatio":0,"type":"string","unique":1},{"count":11,"distinct":2,"histogram":{"bins":[200,210.2,220.4,230.6,240.8,251,261.2,271.4,281.6,291.8,302],"frequencies":[10,0,0,0,0,0,0,0,0,1]},"key":"status","max":301,"mean":209.1818181818182,"median":200,"min":200,"nullRatio":0,"quantiles":{"p25":200,"p5":200,"p50":200,"p75":200,"p95":301},"type":"numeric"},{"count":11,"distinct":11,"emptyRatio":0,"key":"timestamp","lengthHistogram":{"bins":[35,35.1,35.2,35.3,35.4,35.5,35.6,35.7,35.8,35.9,36],"frequencies":[11,0,0,0,0,0,0,0,0,0]},"lengthQuantiles":{"p25":35,"p5":35,"p50":35,"p75":35,"p95":35},"maxLength":35,"minLength":35,"nullRatio":0,"type":"string","unique":11},{"count":10,"distinct":10,"emptyRatio":0,"key":"title","lengthHistogram":{"bins":[53,56.6,60.2,63.8,67.4,71,74.6,78.2,81.8,85.4,89],"frequencies":[1,3,1,0,1,2,0,1,0,1]},"lengthQuantiles":{"p25":59,"p5":53,"p50":61,"p75":74,"p95":88},"maxLength":88,"minLength":53,"nullRatio":0,"type":"string","unique":10},{"count":11,"distinct":11,"emptyRatio":0,"key":"url","lengthHistogram":{"bins":[18,24.1,30.2,36.3,42.4,48.5,54.599999999999994,60.699999999999996,66.8,72.9,79],"frequencies":[2,0,1,0,1,3,1,1,0,2]},"lengthQuantiles":{"p25":36,"p5":18,"p50":50,"p75":65,"p95":78},"maxLength":78,"minLength":18,"nullRatio":0,"type":"string","unique":11}]`)},
 	}
 	for i, c := range goodCases {
 		res := &StatsResponse{}
@@ -1022,6 +1101,218 @@ func TestDatasetRequestsStats(t *testing.T) {
 	}
 }
 
+func TestDatasetRequestsStatsDiff(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	for i, data := range []string{statsDiffData1, statsDiffData2} {
+		p := &SaveParams{
+			Ref:      "me/stats_diff",
+			BodyPath: tr.writeFile(t, fmt.Sprintf("cities_%d.csv", i), data),
+		}
+		if err := req.Save(p, &reporef.DatasetRef{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res := &stats.DriftReport{}
+	if err := req.StatsDiff(&StatsDiffParams{Ref: "me/stats_diff", Compare: "HEAD~1"}, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Thresholds != stats.DefaultDriftThresholds {
+		t.Errorf("expected default thresholds, got: %v", res.Thresholds)
+	}
+	flags := map[string][]string{}
+	for _, col := range res.Columns {
+		flags[col.Column] = col.Flags
+	}
+	expect := map[string][]string{
+		"city":   nil,
+		"pop":    {stats.DriftMean, stats.DriftPSI},
+		"in_usa": nil,
+		"status": {stats.DriftPSI},
+	}
+	if diff := cmp.Diff(expect, flags); diff != "" {
+		t.Errorf("flags mismatch (-want +got):\n%s", diff)
+	}
+	if res.Flagged != 2 {
+		t.Errorf("expected 2 flagged columns, got: %d", res.Flagged)
+	}
+
+	if err := req.StatsDiff(&StatsDiffParams{Ref: "me/stats_diff", Compare: "me/stats_diff"}, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Flagged != 0 {
+		t.Errorf("expected comparing a version to itself to flag no columns, got: %d", res.Flagged)
+	}
+
+	th := &stats.DriftThresholds{MeanChange: 100, NullRatioChange: 1, DistinctChange: 100, PSI: 100}
+	if err := req.StatsDiff(&StatsDiffParams{Ref: "me/stats_diff", Compare: "1", Thresholds: th}, res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Flagged != 0 {
+		t.Errorf("expected loose thresholds to flag no columns, got: %d", res.Flagged)
+	}
+
+	if err := req.StatsDiff(&StatsDiffParams{Ref: "me/stats_diff", Compare: "HEAD~2"}, res); err == nil {
+		t.Errorf("expected comparing to a version that doesn't exist to error")
+	}
+}
+
+func TestDatasetRequestsInferSchema(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	bodyPath := tr.writeFile(t, "cities.csv", statsDiffData1)
+
+	st := &dataset.Structure{}
+	if err := req.InferSchema(&InferSchemaParams{BodyPath: bodyPath}, st); err != nil {
+		t.Fatal(err)
+	}
+	if st.Format != "csv" {
+		t.Errorf("expected format csv, got: %q", st.Format)
+	}
+	expect := []interface{}{
+		map[string]interface{}{"title": "city", "type": "string"},
+		map[string]interface{}{"title": "pop", "type": "integer"},
+		map[string]interface{}{"title": "in_usa", "type": "boolean"},
+		map[string]interface{}{"title": "status", "type": "string", "enum": []interface{}{"late", "ok"}},
+	}
+	cols, err := tabularColumns(st.Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expect, cols); diff != "" {
+		t.Errorf("inferred columns mismatch (-want +got):\n%s", diff)
+	}
+
+	res := &reporef.DatasetRef{}
+	if err := req.Save(&SaveParams{Ref: "me/inferred", BodyPath: bodyPath, InferSchema: true}, res); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := dsfs.LoadDataset(tr.Ctx, tr.Instance.Repo().Store(), res.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cols, err = tabularColumns(ds.Structure.Schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(expect, cols); diff != "" {
+		t.Errorf("saved columns mismatch (-want +got):\n%s", diff)
+	}
+
+	st = &dataset.Structure{}
+	if err := req.InferSchema(&InferSchemaParams{Ref: "me/inferred"}, st); err != nil {
+		t.Fatal(err)
+	}
+	if cols, _ := tabularColumns(st.Schema); !cmp.Equal(expect, cols) {
+		t.Errorf("expected inferring a schema for a saved version to match")
+	}
+}
+
+func TestDatasetRequestsSaveInferredSchemaEmptyCells(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	bodyPath := tr.writeFile(t, "cities.csv", `city,pop,founded
+toronto,40000000,1793-08-27
+chicago,,
+raleigh,250000,1792-01-01
+`)
+	res := &reporef.DatasetRef{}
+	if err := req.Save(&SaveParams{Ref: "me/empty_cells", BodyPath: bodyPath, InferSchema: true}, res); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := dsfs.LoadDataset(tr.Ctx, tr.Instance.Repo().Store(), res.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Structure.ErrCount != 0 {
+		t.Errorf("expected empty cells of nullable columns to be valid. got %d errors", ds.Structure.ErrCount)
+	}
+
+	errs := []jsonschema.ValError{}
+	if err := req.Validate(&ValidateDatasetParams{Ref: "me/empty_cells"}, &errs); err != nil {
+		t.Fatal(err)
+	}
+	if len(errs) != ds.Structure.ErrCount {
+		t.Errorf("expected validate & save to agree. validate found %d errors, save found %d", len(errs), ds.Structure.ErrCount)
+	}
+}
+
+func TestDatasetRequestsSaveParquetBody(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	res := &reporef.DatasetRef{}
+	if err := req.Save(&SaveParams{Ref: "me/cities", BodyPath: tr.writeFile(t, "cities.csv", statsDiffData1), InferSchema: true}, res); err != nil {
+		t.Fatal(err)
+	}
+	orig, err := dsfs.LoadDataset(tr.Ctx, tr.Instance.Repo().Store(), res.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var exported string
+	exp := NewExportRequests(tr.Instance.Node(), nil)
+	if err := exp.Export(&ExportParams{Ref: "me/cities", TargetDir: tr.Dir, Output: "cities.parquet"}, &exported); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := req.Save(&SaveParams{Ref: "me/from_parquet", BodyPath: filepath.Join(tr.Dir, exported)}, res); err != nil {
+		t.Fatal(err)
+	}
+	ds, err := dsfs.LoadDataset(tr.Ctx, tr.Instance.Repo().Store(), res.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ds.Structure.Format != "json" {
+		t.Errorf("expected parquet body to be saved as json, got: %q", ds.Structure.Format)
+	}
+	if diff := cmp.Diff(orig.Structure.Schema, ds.Structure.Schema); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+
+	body := &GetResult{}
+	if err := req.Get(&GetParams{Path: "me/from_parquet", Selector: "body", Format: "csv", All: true}, body); err != nil {
+		t.Fatal(err)
+	}
+	if string(body.Bytes) != strings.SplitN(statsDiffData1, "\n", 2)[1] {
+		t.Errorf("body mismatch. got:\n%s", body.Bytes)
+	}
+}
+
+// tabularColumns gets the column schemas of a tabular schema
+func tabularColumns(sch map[string]interface{}) ([]interface{}, error) {
+	if row, ok := sch["items"].(map[string]interface{}); ok {
+		if cols, ok := row["items"].([]interface{}); ok {
+			return cols, nil
+		}
+	}
+	return nil, fmt.Errorf("schema isn't tabular: %v", sch)
+}
+
+const statsDiffData1 = `city,pop,in_usa,status
+toronto,400,false,ok
+new york,850,true,ok
+chicago,270,true,ok
+chatham,35,true,ok
+raleigh,25,true,late
+`
+
+const statsDiffData2 = `city,pop,in_usa,status
+toronto,40000,false,ok
+new york,85000,true,late
+chicago,27000,true,late
+chatham,3500,true,late
+raleigh,2500,true,late
+`
+
 // Convert the interface value into an array, or panic if not possible
 func mustBeArray(i interface{}, err error) []interface{} {
 	if err != nil {
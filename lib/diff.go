@@ -3,9 +3,11 @@ package lib
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/qri-io/deepdiff"
 	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/component"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/dsref"
@@ -35,6 +37,11 @@ type DiffParams struct {
 
 	Limit, Offset int
 	All           bool
+
+	// AsOf compares the version of the left reference that was the latest at
+	// a point in time. When RightPath is empty the right side of the diff is the
+	// latest version of the left reference
+	AsOf time.Time
 }
 
 // DiffResponse is the result of a call to diff
@@ -62,7 +69,7 @@ func (r *DatasetRequests) Diff(p *DiffParams, res *DiffResponse) (err error) {
 	if r.cli != nil {
 		return r.cli.Call("DatasetRequests.Diff", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 
 	if p.LeftPath == "" && p.RightPath == "" {
 		return fmt.Errorf("nothing to diff")
@@ -88,6 +95,9 @@ func (r *DatasetRequests) Diff(p *DiffParams, res *DiffResponse) (err error) {
 		dd := deepdiff.New()
 		res.Diff, res.Stat, err = dd.StatDiff(ctx, leftData, rightData)
 		return err
+	} else if dsref.IsRefString(p.LeftPath) && p.RightPath == "" && !p.AsOf.IsZero() && p.WorkingDir == "" {
+		// compare a version in the past to the latest version
+		p.RightPath = p.LeftPath
 	} else if dsref.IsRefString(p.LeftPath) && p.RightPath == "" {
 		// Left parameter with a blank right parameter needs either working directory or as-previous
 		if !p.IsLeftAsPrevious && p.WorkingDir == "" {
@@ -103,6 +113,9 @@ func (r *DatasetRequests) Diff(p *DiffParams, res *DiffResponse) (err error) {
 	if err != nil {
 		return err
 	}
+	if err = repo.CheckAsOf(ref, p.AsOf); err != nil {
+		return err
+	}
 	err = repo.CanonicalizeDatasetRef(r.inst.node.Repo, &ref)
 	if err != nil {
 		if err == repo.ErrNoHistory {
@@ -110,6 +123,11 @@ func (r *DatasetRequests) Diff(p *DiffParams, res *DiffResponse) (err error) {
 		}
 		return err
 	}
+	if !p.AsOf.IsZero() {
+		if err = repo.ResolveAsOf(ctx, r.inst.node.Repo, &ref, p.AsOf); err != nil {
+			return err
+		}
+	}
 	ds, err := dsfs.LoadDataset(ctx, r.inst.node.Repo.Store(), ref.Path)
 	if err != nil {
 		return err
@@ -240,15 +258,61 @@ func (r *DatasetRequests) Diff(p *DiffParams, res *DiffResponse) (err error) {
 	return err
 }
 
+// VersionChanges is an alias for base.VersionChanges, describing the changes
+// a single version made to the version before it
+type VersionChanges = base.VersionChanges
+
+// DiffRangeParams defines parameters for diffing each version in a range of
+// dataset history with DiffRange
+type DiffRangeParams struct {
+	// Reference to a dataset
+	Ref string
+	// Range of history to diff, like "10..HEAD". see dsref.ParseRevRange for
+	// details
+	Range string
+}
+
+// DiffRange builds a changelog for a range of dataset history, diffing the
+// body & schema of each version against the version before it. Changes are
+// ordered newest first
+func (r *DatasetRequests) DiffRange(p *DiffRangeParams, res *[]*VersionChanges) (err error) {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.DiffRange", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	from, to, err := dsref.ParseRevRange(p.Range)
+	if err != nil {
+		return err
+	}
+	ref, err := repo.ParseDatasetRef(p.Ref)
+	if err != nil {
+		return err
+	}
+	if err = repo.CanonicalizeDatasetRef(r.inst.node.Repo, &ref); err != nil {
+		if err == repo.ErrNoHistory {
+			return fmt.Errorf("dataset has no versions, nothing to diff")
+		}
+		return err
+	}
+
+	changes, err := base.DiffRange(ctx, r.inst.node.Repo, ref, from, to)
+	if err != nil {
+		return err
+	}
+	*res = changes
+	return nil
+}
+
 func schemaDiff(ctx context.Context, left, right *component.BodyComponent) ([]*Delta, *DiffStat, error) {
 	dd := deepdiff.New()
 	if left.Format == ".csv" && right.Format == ".csv" {
-		left, err := terribleHackToGetHeaderRow(left.InferredSchema)
+		left, err := base.ColumnTitles(left.InferredSchema)
 		if err != nil {
 			return nil, nil, err
 		}
 
-		right, err := terribleHackToGetHeaderRow(right.InferredSchema)
+		right, err := base.ColumnTitles(right.InferredSchema)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -257,24 +321,3 @@ func schemaDiff(ctx context.Context, left, right *component.BodyComponent) ([]*D
 	}
 	return dd.StatDiff(ctx, left.InferredSchema, right.InferredSchema)
 }
-
-// TODO (b5) - this is terrible. We need better logic error handling for
-// jsonschemas describing CSV data. We're relying too heavily on the schema
-// being well-formed
-func terribleHackToGetHeaderRow(sch map[string]interface{}) ([]string, error) {
-	if itemObj, ok := sch["items"].(map[string]interface{}); ok {
-		if itemArr, ok := itemObj["items"].([]interface{}); ok {
-			titles := make([]string, len(itemArr))
-			for i, f := range itemArr {
-				if field, ok := f.(map[string]interface{}); ok {
-					if title, ok := field["title"].(string); ok {
-						titles[i] = title
-					}
-				}
-			}
-			return titles, nil
-		}
-	}
-	log.Debug("that terrible hack to detect header row & types just failed")
-	return nil, fmt.Errorf("nope")
-}
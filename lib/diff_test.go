@@ -1,6 +1,7 @@
 package lib
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -108,6 +109,37 @@ func TestDatasetRequestsDiff(t *testing.T) {
 	}
 }
 
+func TestDatasetRequestsDiffRange(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	for i, data := range []string{jobsByAutomationData1, jobsByAutomationData2} {
+		p := &SaveParams{
+			Ref:      "me/jobs_range",
+			BodyPath: tr.writeFile(t, fmt.Sprintf("jobs_%d.csv", i), data),
+		}
+		if err := req.Save(p, &reporef.DatasetRef{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	res := []*VersionChanges{}
+	if err := req.DiffRange(&DiffRangeParams{Ref: "me/jobs_range", Range: "10..HEAD"}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 1 {
+		t.Fatalf("expected one version of changes, got: %d", len(res))
+	}
+	if res[0].RowsModified != 1 || res[0].RowsAdded != 0 || res[0].RowsRemoved != 0 {
+		t.Errorf("expected one modified row. got added: %d, removed: %d, modified: %d", res[0].RowsAdded, res[0].RowsRemoved, res[0].RowsModified)
+	}
+
+	if err := req.DiffRange(&DiffRangeParams{Ref: "me/jobs_range", Range: "HEAD..1"}, &res); err == nil {
+		t.Errorf("expected backwards range to error")
+	}
+}
+
 const jobsByAutomationData1 = `
 rank,probability_of_automation,soc_code,job_title
 702,"0.99","41-9041","Telemarketers"
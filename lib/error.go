@@ -16,6 +16,11 @@ func (e Error) Error() string {
 	return e.err.Error()
 }
 
+// Unwrap gives the wrapped error
+func (e Error) Unwrap() error {
+	return e.err
+}
+
 // Message returns the e.msg string
 func (e Error) Message() string {
 	return e.msg
@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/rpc"
@@ -20,7 +21,10 @@ import (
 	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/dsfs/dsutil"
 	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/parquet"
 	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/sqlite"
 )
 
 // ExportRequests encapsulates business logic of export operation
@@ -52,6 +56,19 @@ type ExportParams struct {
 	Output    string
 	Format    string
 	Zipped    bool
+	// AsOf exports the version of each dataset that was the latest at a point
+	// in time. zero exports the latest version
+	AsOf time.Time
+	// Refs lists more datasets to export into the same file as Ref. Only the
+	// sqlite format can hold more than one dataset
+	Refs []string
+	// History also exports every earlier version of each dataset, as one table
+	// per version. Only supported by the sqlite format
+	History bool
+	// All exports every dataset in the repo instead of Ref & Refs. Datasets
+	// that didn't exist as of AsOf are left out. Only supported by the sqlite
+	// format
+	All bool
 }
 
 // Export exports a dataset in the specified format
@@ -66,7 +83,21 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 	if r.cli != nil {
 		return r.cli.Call("ExportRequests.Export", p, fileWritten)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	if p.All {
+		if p.Ref != "" || len(p.Refs) > 0 {
+			return fmt.Errorf("can't export the whole repo and a list of datasets")
+		}
+		refs, err := r.repoRefs(ctx, p.AsOf)
+		if err != nil {
+			return err
+		}
+		if len(refs) == 0 {
+			return fmt.Errorf("no datasets to export")
+		}
+		p.Ref, p.Refs = refs[0], refs[1:]
+	}
 
 	if p.Ref == "" {
 		return repo.ErrEmptyRef
@@ -75,9 +106,17 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 	if err != nil {
 		return fmt.Errorf("'%s' is not a valid dataset reference", p.Ref)
 	}
+	if err = repo.CheckAsOf(ref, p.AsOf); err != nil {
+		return err
+	}
 	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
 		return err
 	}
+	if !p.AsOf.IsZero() {
+		if err = repo.ResolveAsOf(ctx, r.node.Repo, &ref, p.AsOf); err != nil {
+			return err
+		}
+	}
 
 	ds, err := base.ReadDatasetPath(ctx, r.node.Repo, ref.String())
 	if err != nil {
@@ -87,7 +126,9 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 
 	format := p.Format
 	if format == "" {
-		if p.Zipped {
+		if p.All {
+			format = "sqlite"
+		} else if p.Zipped {
 			// Default format, if --zip flag is set, is zip
 			format = "zip"
 		} else {
@@ -98,7 +139,11 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 
 	if p.Output == "" || isDirectory(p.Output) {
 		// If output is blank or a directory, derive filename from repo name and commit timestamp.
-		baseName, err := GenerateFilename(ds, format)
+		named := ds
+		if p.All {
+			named = &dataset.Dataset{Peername: ds.Peername, Name: "repo", Commit: &dataset.Commit{Timestamp: time.Now()}}
+		}
+		baseName, err := GenerateFilename(named, format)
 		if err != nil {
 			return err
 		}
@@ -143,12 +188,20 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 		return fmt.Errorf("already exists: \"%s\"", *fileWritten)
 	}
 
+	if format == "sqlite" && p.Zipped {
+		return fmt.Errorf("sqlite exports can't be zipped")
+	}
+	if format != "sqlite" && (len(p.Refs) > 0 || p.History || p.All) {
+		return fmt.Errorf("exporting more than one dataset or version requires the sqlite format")
+	}
+
 	// Create output writer.
-	var writer io.Writer
-	writer, err = os.Create(outputPath)
+	file, err := os.Create(outputPath)
 	if err != nil {
 		return err
 	}
+	defer file.Close()
+	var writer io.Writer = file
 
 	// If outputting a wrapped zip file, create the zip wrapper.
 	if p.Zipped && format != "zip" {
@@ -239,6 +292,20 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 		}
 		return w.Close()
 
+	case "parquet":
+		w, err := parquet.NewEntryWriter(ds.Structure, writer)
+		if err != nil {
+			return err
+		}
+
+		if err := dsio.Copy(reader, w); err != nil {
+			return err
+		}
+		return w.Close()
+
+	case "sqlite":
+		return r.exportSQLite(ctx, file, p, ref, ds)
+
 	case "zip":
 
 		store := r.node.Repo.Store()
@@ -253,6 +320,241 @@ func (r *ExportRequests) Export(p *ExportParams, fileWritten *string) (err error
 	}
 }
 
+// repoRefs lists references to every dataset in the repo. A non-zero asOf
+// leaves out datasets that had no versions at that time
+func (r *ExportRequests) repoRefs(ctx context.Context, asOf time.Time) ([]string, error) {
+	count, err := r.node.Repo.RefCount()
+	if err != nil {
+		return nil, err
+	}
+	all, err := r.node.Repo.References(0, count)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make([]string, 0, len(all))
+	for _, ref := range all {
+		if ref.Path == "" {
+			continue
+		}
+		if !asOf.IsZero() {
+			if err := repo.ResolveAsOf(ctx, r.node.Repo, &ref, asOf); errors.Is(err, repo.ErrNoVersionAsOf) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+		}
+		refs = append(refs, ref.AliasString())
+	}
+	return refs, nil
+}
+
+// sqliteMetadataTable is the table of sqlite exports that lists the dataset
+// version each table was exported from
+const sqliteMetadataTable = "qri_metadata"
+
+// exportSQLite writes dataset bodies as tables of a sqlite database, adding a
+// row to the metadata table for each exported version
+func (r *ExportRequests) exportSQLite(ctx context.Context, w io.WriterAt, p *ExportParams, ref reporef.DatasetRef, ds *dataset.Dataset) error {
+	db := sqlite.NewWriter(w)
+	metadata, err := r.writeSQLiteDataset(ctx, db, p, ref, ds)
+	if err != nil {
+		return err
+	}
+
+	for _, refStr := range p.Refs {
+		ref, err := repo.ParseDatasetRef(refStr)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a valid dataset reference", refStr)
+		}
+		if err = repo.CheckAsOf(ref, p.AsOf); err != nil {
+			return err
+		}
+		if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+			return err
+		}
+		if !p.AsOf.IsZero() {
+			if err = repo.ResolveAsOf(ctx, r.node.Repo, &ref, p.AsOf); err != nil {
+				return err
+			}
+		}
+		ds, err := base.ReadDatasetPath(ctx, r.node.Repo, ref.String())
+		if err != nil {
+			return err
+		}
+		rows, err := r.writeSQLiteDataset(ctx, db, p, ref, ds)
+		base.CloseDataset(ds)
+		if err != nil {
+			return err
+		}
+		metadata = append(metadata, rows...)
+	}
+
+	t, err := db.CreateTable(sqliteMetadataTable, []sqlite.Column{
+		{Name: "table_name", Type: "TEXT"},
+		{Name: "ref", Type: "TEXT"},
+		{Name: "path", Type: "TEXT"},
+		{Name: "title", Type: "TEXT"},
+		{Name: "description", Type: "TEXT"},
+		{Name: "keywords", Type: "TEXT"},
+		{Name: "license", Type: "TEXT"},
+		{Name: "meta", Type: "TEXT"},
+		{Name: "commit_title", Type: "TEXT"},
+		{Name: "commit_message", Type: "TEXT"},
+		{Name: "commit_timestamp", Type: "TEXT"},
+		{Name: "entries", Type: "INTEGER"},
+	})
+	if err != nil {
+		return err
+	}
+	for _, row := range metadata {
+		if err := t.Insert(row...); err != nil {
+			return err
+		}
+	}
+	return db.Close()
+}
+
+// writeSQLiteDataset writes a dataset as a table, and every earlier version of
+// it when exporting history, returning rows of the metadata table. Versions
+// newer than the exported version, like those after an as-of time, are left
+// out
+func (r *ExportRequests) writeSQLiteDataset(ctx context.Context, db *sqlite.Writer, p *ExportParams, ref reporef.DatasetRef, ds *dataset.Dataset) ([][]interface{}, error) {
+	name := sqliteTableName(db, ds.Name, ds.Peername)
+	row, err := writeSQLiteTable(db, name, ref, ds)
+	if err != nil {
+		return nil, err
+	}
+	metadata := [][]interface{}{row}
+	if !p.History {
+		return metadata, nil
+	}
+
+	history, err := base.DatasetLog(ctx, r.node.Repo, ref, -1, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	// history is listed newest first, drop versions up to the exported one
+	var versions []string
+	seen := map[string]bool{}
+	for _, v := range history {
+		if !seen[v.Path] {
+			seen[v.Path] = true
+			versions = append(versions, v.Path)
+		}
+	}
+	for i, path := range versions {
+		if path == ds.Path {
+			versions = versions[i+1:]
+			break
+		}
+	}
+	for j, path := range versions {
+		vref := ref
+		vref.Path = path
+		vds, err := base.ReadDatasetPath(ctx, r.node.Repo, vref.String())
+		if err != nil {
+			return nil, err
+		}
+		// versions are numbered from the first version, which is listed last
+		vname := sqliteTableName(db, fmt.Sprintf("%s_v%d", name, len(versions)-j))
+		row, err := writeSQLiteTable(db, vname, vref, vds)
+		base.CloseDataset(vds)
+		if err != nil {
+			return nil, err
+		}
+		metadata = append(metadata, row)
+	}
+	return metadata, nil
+}
+
+// writeSQLiteTable writes the body of a dataset version as a table, returning
+// the version's row of the metadata table
+func writeSQLiteTable(db *sqlite.Writer, name string, ref reporef.DatasetRef, ds *dataset.Dataset) ([]interface{}, error) {
+	st := ds.Structure
+	if typ, _ := st.Schema["type"].(string); typ == "array" && !listsColumns(st.Schema) {
+		// pick columns from body values if the schema doesn't list them
+		sch, err := base.InferSchema(ds)
+		if err != nil {
+			return nil, err
+		}
+		st = &dataset.Structure{Format: st.Format, Schema: sch}
+	}
+
+	reader, err := dsio.NewEntryReader(ds.Structure, ds.BodyFile())
+	if err != nil {
+		return nil, err
+	}
+	w, err := sqlite.NewEntryWriter(db, name, st)
+	if err != nil {
+		return nil, err
+	}
+	if err := dsio.Copy(reader, w); err != nil {
+		return nil, fmt.Errorf("exporting %s: %s", ref.AliasString(), err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	row := make([]interface{}, 12)
+	row[0], row[1], row[2] = name, ref.String(), ds.Path
+	if m := ds.Meta; m != nil {
+		row[3], row[4] = m.Title, m.Description
+		if len(m.Keywords) > 0 {
+			data, _ := json.Marshal(m.Keywords)
+			row[5] = string(data)
+		}
+		if m.License != nil {
+			row[6] = m.License.URL
+			if row[6] == "" {
+				row[6] = m.License.Type
+			}
+		}
+		data, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		row[7] = string(data)
+	}
+	if c := ds.Commit; c != nil {
+		row[8], row[9] = c.Title, c.Message
+		if !c.Timestamp.IsZero() {
+			row[10] = c.Timestamp.UTC().Format(time.RFC3339)
+		}
+	}
+	row[11] = int64(ds.Structure.Entries)
+	return row, nil
+}
+
+// listsColumns reports whether a schema describes the columns of tabular rows
+func listsColumns(sch map[string]interface{}) bool {
+	row, _ := sch["items"].(map[string]interface{})
+	items, _ := row["items"].([]interface{})
+	props, _ := row["properties"].(map[string]interface{})
+	return len(items) > 0 || len(props) > 0
+}
+
+// sqliteTableName picks the first candidate table name that isn't used yet,
+// falling back to numbering the first candidate
+func sqliteTableName(db *sqlite.Writer, candidates ...string) string {
+	used := func(name string) bool {
+		return name == "" || sqlite.Reserved(name) || db.HasTable(name) || strings.EqualFold(name, sqliteMetadataTable)
+	}
+	for i, name := range candidates {
+		if i > 0 {
+			name = fmt.Sprintf("%s_%s", name, candidates[0])
+		}
+		if !used(name) {
+			return name
+		}
+	}
+	for i := 2; ; i++ {
+		if name := fmt.Sprintf("%s_%d", candidates[0], i); !used(name) {
+			return name
+		}
+	}
+}
+
 func isDirectory(path string) bool {
 	st, err := os.Stat(path)
 	if err != nil {
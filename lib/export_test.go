@@ -1,19 +1,29 @@
 package lib
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/ghodss/yaml"
+	"github.com/google/go-cmp/cmp"
+	_ "github.com/mattn/go-sqlite3"
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/p2p"
+	"github.com/qri-io/qri/parquet"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
 	testrepo "github.com/qri-io/qri/repo/test"
 )
 
@@ -66,6 +76,9 @@ func TestExport(t *testing.T) {
 		{"export xlsx", ExportParams{Ref: "peer/movies", Format: "xlsx"},
 			"peer-movies_-_0001-01-01-00-00-00.xlsx"},
 
+		{"export parquet", ExportParams{Ref: "peer/movies", Format: "parquet"},
+			"peer-movies_-_0001-01-01-00-00-00.parquet"},
+
 		{"export zip", ExportParams{Ref: "peer/movies", Format: "zip"},
 			"peer-movies_-_0001-01-01-00-00-00.zip"},
 
@@ -137,6 +150,16 @@ func readDataset(path string, ds *dataset.Dataset) error {
 		}
 	case ".xlsx":
 		return fmt.Errorf("SKIP")
+	case ".parquet":
+		// body only, check it's readable
+		r, err := parquet.NewEntryReader(bytes.NewReader(buffer), int64(len(buffer)))
+		if err != nil {
+			return err
+		}
+		if _, err := base.ReadEntries(r); err != nil {
+			return err
+		}
+		return fmt.Errorf("SKIP")
 	case ".zip":
 		// TODO: Instead, unzip the file, and inspect the dataset contents.
 		return fmt.Errorf("SKIP")
@@ -237,3 +260,234 @@ func TestGenerateFilename(t *testing.T) {
 		}
 	}
 }
+
+func TestExportSQLite(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	res := &reporef.DatasetRef{}
+	for i, body := range []string{statsDiffData1, statsDiffData2} {
+		p := &SaveParams{
+			Ref:      "me/db_cities",
+			BodyPath: tr.writeFile(t, fmt.Sprintf("cities_%d.csv", i), body),
+			Title:    fmt.Sprintf("version %d", i+1),
+		}
+		if err := req.Save(p, res); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := req.Save(&SaveParams{Ref: "me/db_counts", BodyPath: tr.writeFile(t, "counts.json", `{"a":1,"b":[2,3]}`)}, res); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := NewExportRequests(tr.Instance.Node(), nil)
+	var written string
+	p := &ExportParams{Ref: "me/db_cities", Refs: []string{"me/db_counts"}, History: true, TargetDir: tr.Dir, Output: "export.sqlite"}
+	if err := exp.Export(p, &written); err != nil {
+		t.Fatal(err)
+	}
+	dbPath := filepath.Join(tr.Dir, written)
+	data, err := ioutil.ReadFile(dbPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("SQLite format 3\x00")) {
+		t.Fatal("expected a sqlite database")
+	}
+
+	bad := []struct {
+		params ExportParams
+		err    string
+	}{
+		{ExportParams{Ref: "me/db_cities", Refs: []string{"me/db_counts"}, Output: "many.json"}, "exporting more than one dataset or version requires the sqlite format"},
+		{ExportParams{Ref: "me/db_cities", History: true, Output: "history.csv"}, "exporting more than one dataset or version requires the sqlite format"},
+		{ExportParams{Ref: "me/db_cities", Format: "sqlite", Zipped: true}, "sqlite exports can't be zipped"},
+	}
+	for _, c := range bad {
+		c.params.TargetDir = tr.Dir
+		if err := exp.Export(&c.params, &written); err == nil || err.Error() != c.err {
+			t.Errorf("expected error %q, got: %v", c.err, err)
+		}
+	}
+
+	got := querySQLite(t, dbPath, "SELECT table_name, title, commit_title, entries FROM qri_metadata ORDER BY table_name")
+	expect := [][]interface{}{
+		{"db_cities", nil, "version 2", int64(5)},
+		{"db_cities_v1", nil, "version 1", int64(5)},
+		{"db_counts", nil, "created dataset", int64(2)},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("metadata mismatch (-want +got):\n%s", diff)
+	}
+	got = querySQLite(t, dbPath, "SELECT count(*), sum(pop) FROM db_cities UNION ALL SELECT count(*), sum(pop) FROM db_cities_v1")
+	if diff := cmp.Diff([][]interface{}{{int64(5), int64(158000)}, {int64(5), int64(1580)}}, got); diff != "" {
+		t.Errorf("cities mismatch (-want +got):\n%s", diff)
+	}
+	got = querySQLite(t, dbPath, "SELECT * FROM db_counts ORDER BY key")
+	if diff := cmp.Diff([][]interface{}{{"a", int64(1)}, {"b", "[2,3]"}}, got); diff != "" {
+		t.Errorf("counts mismatch (-want +got):\n%s", diff)
+	}
+	got = querySQLite(t, dbPath, "SELECT sql FROM sqlite_master WHERE name = 'db_cities'")
+	if diff := cmp.Diff([][]interface{}{{`CREATE TABLE "db_cities" ("city" TEXT, "pop" INTEGER, "in_usa" INTEGER, "status" TEXT)`}}, got); diff != "" {
+		t.Errorf("create statement mismatch (-want +got):\n%s", diff)
+	}
+}
+
+// querySQLite runs a query against a sqlite database file, failing if
+// sqlite's integrity check finds problems with the file
+func querySQLite(t *testing.T, path, query string) (rows [][]interface{}) {
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var check string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&check); err != nil {
+		t.Fatal(err)
+	}
+	if check != "ok" {
+		t.Fatalf("integrity check failed: %s", check)
+	}
+
+	res, err := db.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Close()
+	cols, err := res.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for res.Next() {
+		row := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := res.Scan(ptrs...); err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+	if err := res.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestExportSQLiteAsOf(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	prevTs := dsfs.Timestamp
+	defer func() { dsfs.Timestamp = prevTs }()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	save := func(ref string, ts time.Time) {
+		dsfs.Timestamp = func() time.Time { return ts }
+		p := &SaveParams{Ref: ref, BodyPath: tr.writeFile(t, "body.json", `[1,2]`)}
+		if err := req.Save(p, &reporef.DatasetRef{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("me/as_of_first", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	save("me/as_of_second", time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	exp := NewExportRequests(tr.Instance.Node(), nil)
+	var written string
+	p := &ExportParams{
+		Ref:       "me/as_of_first",
+		Refs:      []string{"me/as_of_second"},
+		AsOf:      time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+		TargetDir: tr.Dir,
+		Output:    "as_of.sqlite",
+	}
+	if err := exp.Export(p, &written); !errors.Is(err, repo.ErrNoVersionAsOf) {
+		t.Errorf("expected exporting a dataset created after as-of to error. got: %v", err)
+	}
+
+	p.AsOf = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	p.Output = "as_of_latest.sqlite"
+	if err := exp.Export(p, &written); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExportSQLiteHistoryAsOf(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	prevTs := dsfs.Timestamp
+	defer func() { dsfs.Timestamp = prevTs }()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	for i, body := range []string{`[1]`, `[1,2]`, `[1,2,3]`} {
+		ts := time.Date(2020+i, 1, 1, 0, 0, 0, 0, time.UTC)
+		dsfs.Timestamp = func() time.Time { return ts }
+		p := &SaveParams{Ref: "me/history_as_of", BodyPath: tr.writeFile(t, "body.json", body), Title: fmt.Sprintf("version %d", i+1)}
+		if err := req.Save(p, &reporef.DatasetRef{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dsfs.Timestamp = func() time.Time { return time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC) }
+	if err := req.Save(&SaveParams{Ref: "me/history_later", BodyPath: tr.writeFile(t, "body.json", `[1]`)}, &reporef.DatasetRef{}); err != nil {
+		t.Fatal(err)
+	}
+
+	exp := NewExportRequests(tr.Instance.Node(), nil)
+	var written string
+	p := &ExportParams{
+		Ref:       "me/history_as_of",
+		History:   true,
+		AsOf:      time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC),
+		TargetDir: tr.Dir,
+		Output:    "history_as_of.sqlite",
+	}
+	if err := exp.Export(p, &written); err != nil {
+		t.Fatal(err)
+	}
+	got := querySQLite(t, filepath.Join(tr.Dir, written), "SELECT table_name, commit_title, entries FROM qri_metadata ORDER BY table_name")
+	expect := [][]interface{}{
+		{"history_as_of", "version 2", int64(2)},
+		{"history_as_of_v1", "version 1", int64(1)},
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("versions newer than as-of should be left out (-want +got):\n%s", diff)
+	}
+
+	tableNames := func(path string) map[string]bool {
+		names := map[string]bool{}
+		for _, row := range querySQLite(t, path, "SELECT table_name FROM qri_metadata") {
+			names[row[0].(string)] = true
+		}
+		return names
+	}
+
+	// whole-repo exports leave out datasets created after as-of
+	p = &ExportParams{All: true, AsOf: time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC), TargetDir: tr.Dir, Output: "repo_as_of.sqlite"}
+	if err := exp.Export(p, &written); err != nil {
+		t.Fatal(err)
+	}
+	names := tableNames(filepath.Join(tr.Dir, written))
+	if !names["history_as_of"] || names["history_later"] {
+		t.Errorf("expected repo export as of 2021-06-01 to include history_as_of & not history_later. got: %v", names)
+	}
+
+	p = &ExportParams{All: true, TargetDir: tr.Dir}
+	if err := exp.Export(p, &written); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(written, "peer-repo_-_") || filepath.Ext(written) != ".sqlite" {
+		t.Errorf("expected a repo sqlite file name. got: %s", written)
+	}
+	names = tableNames(filepath.Join(tr.Dir, written))
+	if !names["history_as_of"] || !names["history_later"] || !names["cities"] {
+		t.Errorf("expected repo export to include every dataset. got: %v", names)
+	}
+
+	if err := exp.Export(&ExportParams{All: true, Ref: "me/history_later", TargetDir: tr.Dir}, &written); err == nil {
+		t.Error("expected exporting the whole repo and a dataset to error")
+	}
+}
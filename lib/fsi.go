@@ -11,6 +11,7 @@ import (
 	"github.com/qri-io/qri/base/component"
 	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/fsi"
+	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/repo"
 	reporef "github.com/qri-io/qri/repo/ref"
 )
@@ -81,7 +82,7 @@ func (m *FSIMethods) Status(dir *string, res *[]StatusItem) (err error) {
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.Status", dir, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), m.inst.repo)
 
 	*res, err = m.inst.fsi.Status(ctx, *dir)
 	return err
@@ -94,7 +95,7 @@ func (m *FSIMethods) StatusForAlias(alias *string, res *[]StatusItem) (err error
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.AliasStatus", alias, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), m.inst.repo)
 
 	dir, err := m.inst.fsi.AliasToLinkedDir(*alias)
 	if err != nil {
@@ -110,7 +111,7 @@ func (m *FSIMethods) StatusAtVersion(ref *string, res *[]StatusItem) (err error)
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.StoredStatus", ref, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), m.inst.repo)
 
 	*res, err = m.inst.fsi.StatusAtVersion(ctx, *ref)
 	return err
@@ -120,6 +121,9 @@ func (m *FSIMethods) StatusAtVersion(ref *string, res *[]StatusItem) (err error)
 type CheckoutParams struct {
 	Dir string
 	Ref string
+	// Branch to check out & switch to. A branch may also be given as an
+	// "@branch" suffix on Ref
+	Branch string
 }
 
 // Checkout method writes a dataset to a directory as individual files.
@@ -127,7 +131,7 @@ func (m *FSIMethods) Checkout(p *CheckoutParams, out *string) (err error) {
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.Checkout", p, out)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), m.inst.repo)
 
 	log.Debugf("Checkout started, stat'ing %q", p.Dir)
 
@@ -144,7 +148,11 @@ func (m *FSIMethods) Checkout(p *CheckoutParams, out *string) (err error) {
 	if p.Ref == "" {
 		return repo.ErrEmptyRef
 	}
-	*ref, err = repo.ParseDatasetRef(p.Ref)
+	refStr, branch := splitBranchRef(p.Ref)
+	if p.Branch != "" {
+		branch = p.Branch
+	}
+	*ref, err = repo.ParseDatasetRef(refStr)
 	if err != nil {
 		return fmt.Errorf("'%s' is not a valid dataset reference", p.Ref)
 	}
@@ -152,6 +160,20 @@ func (m *FSIMethods) Checkout(p *CheckoutParams, out *string) (err error) {
 		return
 	}
 
+	if branch != "" {
+		book := m.inst.repo.Logbook()
+		if book == nil {
+			return logbook.ErrNoLogbook
+		}
+		versions, err := book.BranchVersions(ctx, reporef.ConvertToDsref(*ref), branch, 0, 1)
+		if err != nil {
+			return fmt.Errorf("branch '%s' not found", branch)
+		}
+		if len(versions) > 0 {
+			ref.Path = versions[0].Path
+		}
+	}
+
 	log.Debugf("Checkout for ref %q", ref)
 
 	// Load dataset that is being checked out.
@@ -176,11 +198,18 @@ func (m *FSIMethods) Checkout(p *CheckoutParams, out *string) (err error) {
 	log.Debugf("Checkout made directory %q", p.Dir)
 
 	// Create the link file, containing the dataset reference.
-	if _, _, err = m.inst.fsi.CreateLink(p.Dir, p.Ref); err != nil {
+	if _, _, err = m.inst.fsi.CreateLink(p.Dir, refStr); err != nil {
 		log.Debugf("Checkout, fsi.CreateLink failed, error: %s", ref)
 		return err
 	}
-	log.Debugf("Checkout created link for %q <-> %q", p.Dir, p.Ref)
+	log.Debugf("Checkout created link for %q <-> %q", p.Dir, refStr)
+
+	if branch != "" {
+		if err = m.inst.repo.Logbook().WriteBranchSwitch(ctx, reporef.ConvertToDsref(*ref), branch); err != nil {
+			return err
+		}
+		log.Debugf("Checkout switched to branch %q", branch)
+	}
 
 	// Write components of the dataset to the working directory.
 	err = fsi.WriteComponents(ds, p.Dir, m.inst.node.Repo.Filesystem())
@@ -204,7 +233,7 @@ func (m *FSIMethods) Write(p *FSIWriteParams, res *[]StatusItem) (err error) {
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.Write", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), m.inst.repo)
 
 	if p.Ref == "" {
 		return repo.ErrEmptyRef
@@ -247,7 +276,7 @@ func (m *FSIMethods) Restore(p *RestoreParams, out *string) (err error) {
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("FSIMethods.Restore", p, out)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), m.inst.repo)
 
 	if p.Ref == "" {
 		return repo.ErrEmptyRef
@@ -306,6 +335,11 @@ func (m *FSIMethods) Restore(p *RestoreParams, out *string) (err error) {
 			}
 		}
 	}
+
+	// restoring all components abandons any merge in progress
+	if p.Component == "" {
+		return fsi.RemoveMergeState(p.Dir)
+	}
 	return nil
 }
 
@@ -352,6 +386,12 @@ type FSIBodyParams struct {
 
 	Offset, Limit int
 	All           bool
+
+	// Fields, Where & Sort select fields, filter & sort entries, the same way
+	// they do for GetParams
+	Fields []string
+	Where  string
+	Sort   []string
 }
 
 // FSIDatasetBody grabs the body of a dataset
@@ -374,7 +414,8 @@ func (m *FSIMethods) FSIDatasetBody(p *FSIBodyParams, res *[]byte) error {
 		return err
 	}
 
-	*res, err = fsi.GetBody(ref.FSIPath, df, p.FormatConfig, p.Offset, p.Limit, p.All)
+	q := &base.BodyQuery{Fields: p.Fields, Where: p.Where, Sort: p.Sort}
+	*res, err = fsi.GetBody(ref.FSIPath, df, p.FormatConfig, q, p.Offset, p.Limit, p.All)
 	return err
 }
 
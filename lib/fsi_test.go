@@ -137,3 +137,31 @@ func TestFSIMethodsWrite(t *testing.T) {
 		})
 	}
 }
+
+func TestFSIMethodsDatasetBody(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	methods := NewFSIMethods(tr.Instance)
+	var out string
+	if err := methods.Checkout(&CheckoutParams{Dir: filepath.Join(tr.Dir, "cities"), Ref: "me/cities"}, &out); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &FSIBodyParams{
+		Path:   "me/cities",
+		Format: "json",
+		All:    true,
+		Fields: []string{"city", "pop"},
+		Where:  "pop > 100000",
+		Sort:   []string{"pop"},
+	}
+	res := []byte{}
+	if err := methods.FSIDatasetBody(p, &res); err != nil {
+		t.Fatal(err)
+	}
+	expect := `[["raleigh",250000],["chicago",300000],["new york",8500000],["toronto",40000000]]`
+	if diff := cmp.Diff(expect, string(res)); diff != "" {
+		t.Errorf("body mismatch (-want +got):\n%s", diff)
+	}
+}
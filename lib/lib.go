@@ -19,6 +19,7 @@ import (
 	"github.com/qri-io/qfs"
 	"github.com/qri-io/qfs/cafs"
 	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/config/migrate"
 	"github.com/qri-io/qri/dscache"
@@ -32,7 +33,9 @@ import (
 	"github.com/qri-io/qri/repo/buildrepo"
 	fsrepo "github.com/qri-io/qri/repo/fs"
 	"github.com/qri-io/qri/repo/profile"
+	"github.com/qri-io/qri/search"
 	"github.com/qri-io/qri/stats"
+	"github.com/qri-io/qri/token"
 	"github.com/qri-io/qri/update"
 	"github.com/qri-io/qri/update/cron"
 	"github.com/qri-io/qri/watchfs"
@@ -53,6 +56,10 @@ func init() {
 	// over net/rpc calls.
 	gob.Register([]interface{}{})
 	gob.Register(map[string]interface{}{})
+	// update jobs carry options of the job type when they're sent over RPC
+	gob.Register(&cron.DatasetOptions{})
+	gob.Register(&cron.ShellScriptOptions{})
+	gob.Register(&cron.TransformOptions{})
 }
 
 // Receivers returns a slice of CoreRequests that defines the full local
@@ -74,6 +81,8 @@ func Receivers(inst *Instance) []Methods {
 		NewRenderRequests(r, nil),
 		NewUpdateMethods(inst),
 		NewFSIMethods(inst),
+		NewTokenMethods(inst),
+		NewSQLMethods(inst),
 	}
 }
 
@@ -323,7 +332,7 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 		log.Debugf("--log-all set: turning on logging for all activity")
 	}
 
-	if inst.cron, err = newCron(cfg, inst.repoPath); err != nil {
+	if inst.cron, err = newCron(cfg, inst.repoPath, inst.runTransformJob); err != nil {
 		log.Error("initializing cron:", err.Error())
 		return nil, fmt.Errorf("newCron: %s", err)
 	}
@@ -398,6 +407,9 @@ func NewInstance(ctx context.Context, repoPath string, opts ...Option) (qri *Ins
 		_ = base.SetFileHidden(inst.repoPath)
 
 		inst.fsi = fsi.NewFSI(inst.repo, inst.bus)
+		inst.searchIndex = newSearchIndex(inst.repoPath)
+		search.Observe(inst.repo, inst.searchIndex)
+		inst.tokens = newTokenStore(inst.repoPath)
 	}
 
 	if inst.node == nil {
@@ -507,6 +519,24 @@ func newRepo(path string, cfg *config.Config, store cafs.Filestore, fs qfs.Files
 	}
 }
 
+// newSearchIndex loads the local search index stored in the repo directory.
+// Repos without a path get an in-memory index
+func newSearchIndex(repoPath string) *search.Index {
+	if repoPath == "" {
+		return search.NewIndex("")
+	}
+	return search.NewIndex(filepath.Join(repoPath, "search_index.json"))
+}
+
+// newTokenStore loads the record of api tokens stored in the repo directory.
+// Repos without a path get an in-memory store
+func newTokenStore(repoPath string) *token.Store {
+	if repoPath == "" {
+		return token.NewStore("")
+	}
+	return token.NewStore(filepath.Join(repoPath, "tokens.json"))
+}
+
 func newStats(repoPath string, cfg *config.Config) *stats.Stats {
 	// The stats cache default location is repoPath/stats
 	// can be overridden in the config: cfg.Stats.Path
@@ -517,9 +547,10 @@ func newStats(repoPath string, cfg *config.Config) *stats.Stats {
 	if cfg.Stats.Cache.Path != "" {
 		path = cfg.Stats.Cache.Path
 	}
+	opt := stats.OptHistogramBins(cfg.Stats.HistogramBins)
 	switch cfg.Stats.Cache.Type {
 	case "fs":
-		return stats.New(stats.NewOSCache(path, cfg.Stats.Cache.MaxSize))
+		return stats.New(stats.NewOSCache(path, cfg.Stats.Cache.MaxSize), opt)
 	// TODO (ramfox): return a mem and/or postgres version of the stats.Stats
 	// once those are implemented
 	// case "mem":
@@ -527,11 +558,11 @@ func newStats(repoPath string, cfg *config.Config) *stats.Stats {
 	// case "postgres":
 	// 	return stats.New(stats.NewSqlCache(path, cfg.Stats.Cache.MaxSize))
 	default:
-		return stats.New(nil)
+		return stats.New(nil, opt)
 	}
 }
 
-func newCron(cfg *config.Config, repoPath string) (cron.Scheduler, error) {
+func newCron(cfg *config.Config, repoPath string, runTransform cron.RunJobFunc) (cron.Scheduler, error) {
 	updateCfg := cfg.Update
 	if updateCfg == nil {
 		updateCfg = config.DefaultUpdate()
@@ -559,7 +590,8 @@ func newCron(cfg *config.Config, repoPath string) (cron.Scheduler, error) {
 		return nil, fmt.Errorf("unknown cron type: %s", updateCfg.Type)
 	}
 
-	svc := cron.NewCron(jobStore, logStore, update.Factory)
+	svc := cron.NewCron(jobStore, logStore, update.NewFactory(update.TransformLimits(updateCfg), runTransform))
+	svc.SetFailurePolicy(update.FailurePolicy(updateCfg))
 	return svc, nil
 }
 
@@ -589,11 +621,27 @@ func NewInstanceFromConfigAndNode(cfg *config.Config, node *p2p.QriNode) *Instan
 		inst.qfs = node.Repo.Filesystem()
 		inst.bus = event.NewBus(ctx)
 		inst.fsi = fsi.NewFSI(inst.repo, inst.bus)
+		inst.searchIndex = search.NewIndex("")
+		search.Observe(inst.repo, inst.searchIndex)
+		inst.tokens = token.NewStore("")
 	}
 
 	return inst
 }
 
+// withKeyring makes data keys shared with the repo profile available for
+// reading private datasets within a context. Keys are scoped to the repo, a
+// context for one repo can't decrypt private datasets shared with another
+func withKeyring(ctx context.Context, r repo.Repo) context.Context {
+	if r == nil {
+		return ctx
+	}
+	if book := r.Logbook(); book != nil {
+		return dsfs.WithKeyring(ctx, book)
+	}
+	return ctx
+}
+
 // Instance bundles the foundational values qri relies on, including a qri
 // configuration, p2p node, and base context.
 // An instance wraps required state for for "Method" constructors, which
@@ -620,6 +668,8 @@ type Instance struct {
 	stats        *stats.Stats
 	logbook      *logbook.Book
 	dscache      *dscache.Dscache
+	searchIndex  *search.Index
+	tokens       *token.Store
 	bus          event.Bus
 
 	Watcher *watchfs.FilesysWatcher
@@ -710,6 +760,23 @@ func (inst *Instance) RepoPath() string {
 	return inst.repoPath
 }
 
+// Authenticate checks a bearer token was issued by this instance, and hasn't
+// expired or been revoked
+func (inst *Instance) Authenticate(tok string) (*token.Claims, error) {
+	r := inst.Repo()
+	if r == nil || r.PrivateKey() == nil || inst.tokens == nil {
+		return nil, fmt.Errorf("instance can't check tokens without a repo")
+	}
+	claims, err := token.Parse(tok, r.PrivateKey().GetPublic())
+	if err != nil {
+		return nil, err
+	}
+	if err = inst.tokens.Check(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
 // RPC accesses the instance RPC client if one exists
 func (inst *Instance) RPC() *rpc.Client {
 	if inst == nil {
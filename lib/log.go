@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/rpc"
+	"strings"
 
 	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/dsref"
@@ -41,6 +42,8 @@ type LogParams struct {
 	ListParams
 	// Reference to data to fetch history for
 	Ref string
+	// Branch to show history for, defaults to the default branch
+	Branch string
 }
 
 // Log returns the history of changes for a given dataset
@@ -48,7 +51,7 @@ func (r *LogRequests) Log(params *LogParams, res *[]dsref.VersionInfo) (err erro
 	if r.cli != nil {
 		return r.cli.Call("LogRequests.Log", params, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 
 	if params.Ref == "" {
 		return repo.ErrEmptyRef
@@ -73,10 +76,166 @@ func (r *LogRequests) Log(params *LogParams, res *[]dsref.VersionInfo) (err erro
 		params.Offset = 0
 	}
 
+	if params.Branch != "" {
+		*res, err = base.DatasetBranchLog(ctx, r.node.Repo, ref, params.Branch, params.Limit, params.Offset)
+		return
+	}
 	*res, err = base.DatasetLog(ctx, r.node.Repo, ref, params.Limit, params.Offset, true)
 	return
 }
 
+// BranchParams defines parameters for branch methods
+type BranchParams struct {
+	// Reference to the dataset to operate on
+	Ref string
+	// Name of the branch
+	Branch string
+	// Name of the branch to create a new branch from, defaults to the default
+	// branch
+	From string
+}
+
+// BranchInfo describes a branch of a dataset
+type BranchInfo struct {
+	Name   string `json:"name"`
+	Active bool   `json:"active"`
+	// Path of the newest version on the branch
+	Path string `json:"path,omitempty"`
+}
+
+// Branches lists the branches of a dataset
+func (r *LogRequests) Branches(p *BranchParams, res *[]BranchInfo) error {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.Branches", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	ref, err := r.branchDsref(p.Ref)
+	if err != nil {
+		return err
+	}
+
+	book := r.node.Repo.Logbook()
+	names, err := book.Branches(ctx, ref)
+	if err != nil {
+		return err
+	}
+	active, err := book.ActiveBranch(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	branches := make([]BranchInfo, len(names))
+	for i, name := range names {
+		branches[i] = BranchInfo{Name: name, Active: name == active}
+		if versions, err := book.BranchVersions(ctx, ref, name, 0, 1); err == nil && len(versions) > 0 {
+			branches[i].Path = versions[0].Path
+		}
+	}
+	*res = branches
+	return nil
+}
+
+// CreateBranch starts a new branch of a dataset's history. New branches start
+// from the newest version of the From branch
+func (r *LogRequests) CreateBranch(p *BranchParams, res *BranchInfo) error {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.CreateBranch", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	ref, err := r.branchDsref(p.Ref)
+	if err != nil {
+		return err
+	}
+	book := r.node.Repo.Logbook()
+	if err = book.WriteBranchInit(ctx, ref, p.Branch, p.From); err != nil {
+		return err
+	}
+
+	*res = BranchInfo{Name: p.Branch}
+	if versions, err := book.BranchVersions(ctx, ref, p.Branch, 0, 1); err == nil && len(versions) > 0 {
+		res.Path = versions[0].Path
+	}
+	return nil
+}
+
+// SwitchBranch makes a branch the active branch of a dataset. Saves that don't
+// specify a branch are written to the active branch
+func (r *LogRequests) SwitchBranch(p *BranchParams, res *BranchInfo) error {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.SwitchBranch", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	ref, err := r.branchDsref(p.Ref)
+	if err != nil {
+		return err
+	}
+	book := r.node.Repo.Logbook()
+	if err = book.WriteBranchSwitch(ctx, ref, p.Branch); err != nil {
+		return err
+	}
+
+	*res = BranchInfo{Name: p.Branch, Active: true}
+	if versions, err := book.BranchVersions(ctx, ref, p.Branch, 0, 1); err == nil && len(versions) > 0 {
+		res.Path = versions[0].Path
+	}
+	return nil
+}
+
+// DeleteBranch removes a branch from a dataset. The default branch cannot be
+// deleted
+func (r *LogRequests) DeleteBranch(p *BranchParams, res *bool) error {
+	if r.cli != nil {
+		return r.cli.Call("LogRequests.DeleteBranch", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	ref, err := r.branchDsref(p.Ref)
+	if err != nil {
+		return err
+	}
+	if err = r.node.Repo.Logbook().WriteBranchDelete(ctx, ref, p.Branch); err != nil {
+		return err
+	}
+	*res = true
+	return nil
+}
+
+// splitBranchRef separates a trailing "@branch" suffix from a reference
+// string. Suffixes that are paths or profileIDs are not branch names
+func splitBranchRef(ref string) (string, string) {
+	at := strings.LastIndex(ref, "@")
+	if at == -1 {
+		return ref, ""
+	}
+	suffix := ref[at+1:]
+	if strings.HasPrefix(suffix, "Qm") || !dsref.IsValidName(suffix) {
+		return ref, ""
+	}
+	return ref[:at], suffix
+}
+
+// branchDsref resolves a reference string to a dataset reference suitable for
+// logbook branch operations
+func (r *LogRequests) branchDsref(refstr string) (dsref.Ref, error) {
+	if refstr == "" {
+		return dsref.Ref{}, repo.ErrEmptyRef
+	}
+	ref, err := repo.ParseDatasetRef(refstr)
+	if err != nil {
+		return dsref.Ref{}, fmt.Errorf("'%s' is not a valid dataset reference", refstr)
+	}
+	if err = repo.CanonicalizeProfile(r.node.Repo, &ref); err != nil {
+		return dsref.Ref{}, err
+	}
+	if r.node.Repo.Logbook() == nil {
+		return dsref.Ref{}, logbook.ErrNoLogbook
+	}
+	return reporef.ConvertToDsref(ref), nil
+}
+
 // RefListParams encapsulates parameters for requests to a single reference
 // that will produce a paginated result
 type RefListParams struct {
@@ -94,7 +253,7 @@ func (r *LogRequests) Logbook(p *RefListParams, res *[]LogEntry) error {
 	if r.cli != nil {
 		return r.cli.Call("LogRequests.Logbook", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 
 	ref, err := repo.ParseDatasetRef(p.Ref)
 	if err != nil {
@@ -122,7 +281,7 @@ func (r *LogRequests) PlainLogs(p *PlainLogsParams, res *PlainLogs) (err error)
 	if r.cli != nil {
 		return r.cli.Call("LogRequests.PlainLogs", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.node.Repo)
 	*res, err = r.node.Repo.Logbook().PlainLogs(ctx)
 	return err
 }
@@ -126,3 +126,21 @@ func TestHistoryRequestsLogEntries(t *testing.T) {
 		t.Errorf("result mismatch (-want +got):\n%s", diff)
 	}
 }
+
+func TestSplitBranchRef(t *testing.T) {
+	cases := []struct {
+		in, ref, branch string
+	}{
+		{"me/ds", "me/ds", ""},
+		{"me/ds@cleanup", "me/ds", "cleanup"},
+		{"me/ds@/ipfs/QmFoo", "me/ds@/ipfs/QmFoo", ""},
+		{"me/ds@QmProfileID", "me/ds@QmProfileID", ""},
+		{"me/ds@QmProfileID/ipfs/QmFoo", "me/ds@QmProfileID/ipfs/QmFoo", ""},
+	}
+	for _, c := range cases {
+		ref, branch := splitBranchRef(c.in)
+		if ref != c.ref || branch != c.branch {
+			t.Errorf("splitBranchRef(%q) = (%q, %q), expected (%q, %q)", c.in, ref, branch, c.ref, c.branch)
+		}
+	}
+}
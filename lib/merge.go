@@ -0,0 +1,251 @@
+package lib
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qri/base"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/base/merge"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/fsi"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/repo"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// MergeConflict is an alias for merge.Conflict, abstracting the merge
+// implementation away from packages that depend on lib
+type MergeConflict = merge.Conflict
+
+// MergeParams defines parameters for merging two histories of a dataset
+type MergeParams struct {
+	// Ref is the dataset to merge into. An "@branch" suffix selects the branch
+	// to merge into, defaults to the dataset's active branch
+	Ref string
+	// Theirs is the version to merge, either the name of another branch of the
+	// dataset, or a dataset reference with a path
+	Theirs string
+	// Commit title & message of the merge version. A title is generated if
+	// none is given
+	Title   string
+	Message string
+	// DryRun merges without saving
+	DryRun bool
+}
+
+// MergeResponse is the result of a merge
+type MergeResponse struct {
+	// Paths of the common ancestor and the two merged versions
+	Ancestor string `json:"ancestor,omitempty"`
+	Ours     string `json:"ours"`
+	Theirs   string `json:"theirs"`
+	// UpToDate is true when Theirs is already part of our history, and there
+	// is nothing to merge
+	UpToDate bool `json:"upToDate,omitempty"`
+	// Ref is the saved merge version
+	Ref *reporef.DatasetRef `json:"ref,omitempty"`
+	// Conflicts lists changes that need manual resolution. Merges with conflicts
+	// aren't saved
+	Conflicts []MergeConflict `json:"conflicts,omitempty"`
+	// WorkingDir is the linked directory conflicts were written to
+	WorkingDir string `json:"workingDir,omitempty"`
+	// Summaries of the changes on each side since the common ancestor
+	OursStat   *DiffStat `json:"oursStat,omitempty"`
+	TheirsStat *DiffStat `json:"theirsStat,omitempty"`
+}
+
+// Merge three-way merges two histories of a dataset. Changes are found by
+// comparing each side against the nearest common ancestor. When both sides
+// change the same value differently the merge has conflicts and isn't saved.
+// If the dataset is linked to a working directory, the merged components are
+// written to the directory for resolution and saving the directory completes
+// the merge
+func (r *DatasetRequests) Merge(p *MergeParams, res *MergeResponse) error {
+	if r.cli != nil {
+		return r.cli.Call("DatasetRequests.Merge", p, res)
+	}
+	ctx := withKeyring(context.TODO(), r.node.Repo)
+
+	if p.Ref == "" {
+		return repo.ErrEmptyRef
+	}
+	if p.Theirs == "" {
+		return fmt.Errorf("a version to merge is required")
+	}
+	book := r.node.Repo.Logbook()
+	if book == nil {
+		return logbook.ErrNoLogbook
+	}
+
+	refStr, branch := splitBranchRef(p.Ref)
+	ref, err := repo.ParseDatasetRef(refStr)
+	if err != nil {
+		return fmt.Errorf("'%s' is not a valid dataset reference", p.Ref)
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil {
+		return err
+	}
+	dsr := reporef.ConvertToDsref(ref)
+
+	active, err := book.ActiveBranch(ctx, dsr)
+	if err != nil {
+		return err
+	}
+	if branch == "" {
+		branch = active
+	}
+
+	ours, err := branchHead(ctx, book, dsr, branch)
+	if err != nil {
+		return err
+	}
+	theirs, theirsName, err := r.resolveMergeSource(ctx, dsr, p.Theirs)
+	if err != nil {
+		return err
+	}
+	res.Ours, res.Theirs = ours, theirs
+
+	mergeParents, err := book.MergeParents(ctx, dsr)
+	if err != nil {
+		return err
+	}
+	if res.Ancestor, err = base.CommonAncestor(ctx, r.node.Repo.Store(), ours, theirs, mergeParents); err != nil {
+		return err
+	}
+	if res.Ancestor == theirs {
+		res.UpToDate = true
+		return nil
+	}
+
+	// a linked working directory follows the active branch
+	workingDir := ""
+	if ref.FSIPath != "" && branch == active {
+		workingDir = ref.FSIPath
+		if _, err := fsi.ReadMergeState(workingDir); err != fsi.ErrNoMerge {
+			return fmt.Errorf("%s has a merge in progress, save or restore the working directory before merging", workingDir)
+		}
+		// TODO: fsi status compares against the default branch, skip the check
+		// for other branches until status is branch-aware
+		if branch == logbook.DefaultBranchName && r.inst != nil {
+			if err = r.inst.fsi.IsWorkingDirectoryClean(ctx, workingDir); err != nil {
+				if err == fsi.ErrWorkingDirectoryDirty {
+					return fmt.Errorf("working directory has unsaved changes, save or restore them before merging")
+				}
+				return err
+			}
+		}
+	}
+
+	merged, err := base.MergeDatasets(ctx, r.node.Repo, res.Ancestor, ours, theirs)
+	if err != nil {
+		return err
+	}
+	merged.Dataset.Peername = ref.Peername
+	merged.Dataset.Name = ref.Name
+	res.Conflicts = merged.Conflicts
+	res.OursStat = merged.OursStat
+	res.TheirsStat = merged.TheirsStat
+
+	if len(res.Conflicts) > 0 {
+		if workingDir == "" || p.DryRun {
+			return nil
+		}
+		ds := merged.Dataset
+		if err = base.OpenDataset(ctx, r.node.Repo.Filesystem(), ds); err != nil {
+			return err
+		}
+		if ds.BodyPath == "" && ds.BodyFile() != nil {
+			// a marked body only exists in memory, inline it so it's written to
+			// the working directory
+			rdr, err := dsio.NewEntryReader(ds.Structure, ds.BodyFile())
+			if err != nil {
+				return err
+			}
+			if ds.Body, err = base.ReadEntries(rdr); err != nil {
+				return err
+			}
+		}
+		if err = fsi.WriteComponents(ds, workingDir, r.node.Repo.Filesystem()); err != nil {
+			return err
+		}
+		state := &fsi.MergeState{
+			Branch:    branch,
+			Ancestor:  res.Ancestor,
+			Ours:      ours,
+			Theirs:    theirs,
+			Conflicts: res.Conflicts,
+		}
+		if err = fsi.WriteMergeState(workingDir, state); err != nil {
+			return err
+		}
+		res.WorkingDir = workingDir
+		return nil
+	}
+
+	title := p.Title
+	if title == "" {
+		title = fmt.Sprintf("merge %s into %s", theirsName, branch)
+	}
+	saved, err := base.SaveMerge(ctx, r.node.Repo, r.node.LocalStreams, merged, branch, title, p.Message, p.DryRun)
+	if err != nil {
+		return err
+	}
+	res.Ref = &saved
+
+	if workingDir != "" && !p.DryRun {
+		if branch == logbook.DefaultBranchName {
+			saved.FSIPath = workingDir
+			if err = r.node.Repo.PutRef(saved); err != nil {
+				return err
+			}
+		}
+		ds, err := dsfs.LoadDataset(ctx, r.node.Repo.Store(), saved.Path)
+		if err != nil {
+			return err
+		}
+		if err = base.OpenDataset(ctx, r.node.Repo.Filesystem(), ds); err != nil {
+			return err
+		}
+		if err = fsi.WriteComponents(ds, workingDir, r.node.Repo.Filesystem()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolveMergeSource finds the path of the version to merge. plain names refer
+// to branches of the dataset being merged into, anything else is a dataset
+// reference
+func (r *DatasetRequests) resolveMergeSource(ctx context.Context, dsr dsref.Ref, theirs string) (path, name string, err error) {
+	if !strings.Contains(theirs, "/") && dsref.IsValidName(theirs) {
+		path, err = branchHead(ctx, r.node.Repo.Logbook(), dsr, theirs)
+		return path, theirs, err
+	}
+
+	ref, err := repo.ParseDatasetRef(theirs)
+	if err != nil {
+		return "", "", fmt.Errorf("'%s' is not a valid dataset reference", theirs)
+	}
+	if err = repo.CanonicalizeDatasetRef(r.node.Repo, &ref); err != nil && err != repo.ErrNotFound {
+		return "", "", err
+	}
+	if ref.Path == "" {
+		return "", "", fmt.Errorf("reference '%s' has no version to merge", theirs)
+	}
+	return ref.Path, theirs, nil
+}
+
+// branchHead returns the path of the newest version on a logbook branch
+func branchHead(ctx context.Context, book *logbook.Book, ref dsref.Ref, branch string) (string, error) {
+	versions, err := book.BranchVersions(ctx, ref, branch, 0, 1)
+	if err != nil {
+		return "", fmt.Errorf("branch '%s' not found", branch)
+	}
+	if len(versions) == 0 {
+		return "", fmt.Errorf("branch '%s' has no versions", branch)
+	}
+	return versions[0].Path, nil
+}
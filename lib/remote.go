@@ -61,7 +61,7 @@ func (r *RemoteMethods) Fetch(p *FetchParams, res *[]dsref.VersionInfo) error {
 	}
 
 	// TODO (b5) - need contexts yo
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.inst.repo)
 	logs, err := r.inst.RemoteClient().FetchLogs(ctx, reporef.ConvertToDsref(ref), addr)
 	if err != nil {
 		return err
@@ -128,7 +128,11 @@ func (r *RemoteMethods) Publish(p *PublicationParams, res *dsref.Ref) error {
 	}
 
 	// TODO (b5) - need contexts yo
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.inst.repo)
+
+	if base.IsPrivate(ctx, r.inst.Repo(), ref) {
+		return fmt.Errorf("%w: private datasets can't be published", base.ErrPrivateDataset)
+	}
 
 	// TODO (b5) - we're early in log syncronization days. This is going to fail a bunch
 	// while we work to upgrade the stack. Long term we may want to consider a mechanism
@@ -176,7 +180,7 @@ func (r *RemoteMethods) Unpublish(p *PublicationParams, res *dsref.Ref) error {
 	}
 
 	// TODO (b5) - need contexts yo
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.inst.repo)
 
 	// TODO (b5) - we're early in log syncronization days. This is going to fail a bunch
 	// while we work to upgrade the stack. Long term we may want to consider a mechanism
@@ -211,7 +215,7 @@ func (r *RemoteMethods) PullDataset(p *PublicationParams, res *bool) error {
 	}
 
 	// TODO (b5) - need contexts yo
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.inst.repo)
 
 	err = r.inst.RemoteClient().PullDataset(ctx, &ref, p.RemoteName)
 	return err
@@ -223,7 +227,7 @@ func (r *RemoteMethods) Feeds(remoteName *string, res *map[string][]dsref.Versio
 	if r.inst.rpc != nil {
 		return r.inst.rpc.Call("RemoteMethods.Feeds", remoteName, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.inst.repo)
 
 	addr, err := remote.Address(r.inst.Config(), *remoteName)
 	if err != nil {
@@ -250,7 +254,7 @@ func (r *RemoteMethods) Preview(p *PreviewParams, res *dataset.Dataset) error {
 	if r.inst.rpc != nil {
 		return r.inst.rpc.Call("RemoteMethods.Preview", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.inst.repo)
 
 	ref, err := repo.ParseDatasetRef(p.Ref)
 	if err != nil {
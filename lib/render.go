@@ -65,7 +65,7 @@ func (r *RenderRequests) RenderViz(p *RenderParams, res *[]byte) (err error) {
 	if r.cli != nil {
 		return r.cli.Call("RenderRequests.RenderViz", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.repo)
 
 	if err = p.Validate(); err != nil {
 		return err
@@ -95,7 +95,7 @@ func (r *RenderRequests) RenderReadme(p *RenderParams, res *string) (err error)
 	if r.cli != nil {
 		return r.cli.Call("RenderRequests.RenderReadme", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), r.repo)
 
 	if err = p.Validate(); err != nil {
 		return err
@@ -132,6 +132,11 @@ func (r *RenderRequests) RenderReadme(p *RenderParams, res *string) (err error)
 	if err = ds.Readme.OpenScriptFile(ctx, r.repo.Filesystem()); err != nil {
 		return err
 	}
+	script, err := dsfs.DecryptFile(ctx, ds.Readme.ScriptFile())
+	if err != nil {
+		return err
+	}
+	ds.Readme.SetScriptFile(script)
 	if ds.Readme.ScriptFile() == nil {
 		return fmt.Errorf("no readme to render")
 	}
@@ -1,10 +1,12 @@
 package lib
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/qri-io/qri/registry/regclient"
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/search"
 )
 
 // SearchMethods encapsulates business logic for the qri search command
@@ -26,6 +28,8 @@ type SearchParams struct {
 	QueryString string `json:"q"`
 	Limit       int    `json:"limit,omitempty"`
 	Offset      int    `json:"offset,omitempty"`
+	// Local searches datasets in the local repo instead of the registry
+	Local bool `json:"local,omitempty"`
 }
 
 // SearchResult struct
@@ -33,6 +37,8 @@ type SearchResult struct {
 	Type, ID string
 	URL      string
 	Value    interface{}
+	// Score ranks local search results, higher scores are better matches
+	Score float64 `json:",omitempty"`
 }
 
 // Search queries for items on qri related to given parameters
@@ -43,6 +49,9 @@ func (m *SearchMethods) Search(p *SearchParams, results *[]SearchResult) error {
 	if p == nil {
 		return fmt.Errorf("error: search params cannot be nil")
 	}
+	if p.Local {
+		return m.searchLocal(p, results)
+	}
 
 	reg := m.inst.registry
 	if reg == nil {
@@ -73,3 +82,31 @@ func (m *SearchMethods) Search(p *SearchParams, results *[]SearchResult) error {
 	*results = searchResults
 	return nil
 }
+
+// searchLocal queries the local search index, building it from the repo on
+// first use
+func (m *SearchMethods) searchLocal(p *SearchParams, results *[]SearchResult) error {
+	ctx := context.TODO()
+	idx := m.inst.searchIndex
+	if idx == nil {
+		return fmt.Errorf("local search index is not available")
+	}
+	if !idx.Built() {
+		if err := search.Reindex(ctx, m.inst.repo, idx); err != nil {
+			return err
+		}
+	}
+
+	hits := search.Page(idx.Query(p.QueryString), p.Limit, p.Offset)
+	searchResults := make([]SearchResult, len(hits))
+	for i, hit := range hits {
+		searchResults[i] = SearchResult{
+			Type:  "dataset",
+			ID:    hit.Dataset.Path,
+			Value: hit.Dataset,
+			Score: hit.Score,
+		}
+	}
+	*results = searchResults
+	return nil
+}
@@ -5,9 +5,12 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/qri-io/dataset"
 	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/p2p"
 	"github.com/qri-io/qri/registry/regclient"
+	reporef "github.com/qri-io/qri/repo/ref"
 	testrepo "github.com/qri-io/qri/repo/test"
 )
 
@@ -31,7 +34,7 @@ func TestSearch(t *testing.T) {
 
 	m := NewSearchMethods(inst)
 
-	p := &SearchParams{"nuun", 0, 100}
+	p := &SearchParams{"nuun", 0, 100, false}
 	got := &[]SearchResult{}
 	if err = m.Search(p, got); err != nil {
 		t.Error(err)
@@ -42,6 +45,73 @@ func TestSearch(t *testing.T) {
 	}
 }
 
+func TestSearchLocal(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	m := NewSearchMethods(tr.Instance)
+	names := func(q string) []string {
+		results := []SearchResult{}
+		if err := m.Search(&SearchParams{QueryString: q, Local: true}, &results); err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, r := range results {
+			names = append(names, r.Value.(*dataset.Dataset).Name)
+		}
+		return names
+	}
+
+	// the index is built from the test repo on first search
+	if got := names("movie"); len(got) != 1 || got[0] != "movies" {
+		t.Errorf("expected movie to match movies. got: %v", got)
+	}
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	res := &reporef.DatasetRef{}
+	p := &SaveParams{
+		Ref: "me/rain_gauges",
+		Dataset: &dataset.Dataset{
+			Meta:   &dataset.Meta{Title: "Rainfall Gauges", Keywords: []string{"weather"}},
+			Readme: &dataset.Readme{ScriptBytes: []byte("# gauges\nmillimetres of precipitation")},
+		},
+		BodyPath: tr.writeFile(t, "body.json", `[[1,2]]`),
+	}
+	if err := req.Save(p, res); err != nil {
+		t.Fatal(err)
+	}
+	if got := names("precipitation"); len(got) != 1 || got[0] != "rain_gauges" {
+		t.Errorf("expected saved dataset readme to be indexed. got: %v", got)
+	}
+
+	info := &dsref.VersionInfo{}
+	rp := &RenameParams{
+		Current: dsref.Ref{Username: "me", Name: "rain_gauges"},
+		Next:    dsref.Ref{Username: "me", Name: "drizzle"},
+	}
+	if err := req.Rename(rp, info); err != nil {
+		t.Fatal(err)
+	}
+	if got := names("drizzle weather"); len(got) != 1 || got[0] != "drizzle" {
+		t.Errorf("expected renamed dataset to be found by its new name. got: %v", got)
+	}
+
+	if err := req.Remove(&RemoveParams{Ref: "me/drizzle", Revision: dsref.Rev{Field: "ds", Gen: -1}}, &RemoveResponse{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := names("weather"); len(got) != 0 {
+		t.Errorf("expected removed dataset to leave the index. got: %v", got)
+	}
+
+	results := []SearchResult{}
+	if err := m.Search(&SearchParams{QueryString: "movies cities", Local: true, Limit: 1, Offset: 1}, &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 {
+		t.Errorf("expected a page of 1 result. got: %d", len(results))
+	}
+}
+
 var mockResponse = []byte(`{"data":[
   {
     "Type": "dataset",
@@ -0,0 +1,157 @@
+package lib
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/sql"
+)
+
+// SQLMethods runs SQL queries against dataset bodies
+type SQLMethods struct {
+	inst *Instance
+}
+
+// NewSQLMethods creates SQLMethods from a qri Instance
+func NewSQLMethods(inst *Instance) *SQLMethods {
+	return &SQLMethods{inst: inst}
+}
+
+// CoreRequestsName implements the Methods interface
+func (m SQLMethods) CoreRequestsName() string { return "sql" }
+
+// SQLParams defines parameters for the Exec method
+type SQLParams struct {
+	// Query is a SELECT statement. Datasets are named by reference in FROM &
+	// JOIN clauses, like me/dataset or me/dataset@/ipfs/Qm... for a version
+	Query string
+	// Format of the results: json, csv, cbor or xlsx. Defaults to json
+	Format string
+	// FormatConfig configures the results format. csv results have a header
+	// row unless headerRow is false
+	FormatConfig map[string]interface{}
+	// optional writer to stream results to as rows are produced. when set res
+	// is left empty
+	// note: this won't work over RPC, only on local calls
+	Output io.Writer
+}
+
+// Exec runs a query, writing result rows to res. Rows are arrays of values,
+// in the order of the selected columns
+func (m *SQLMethods) Exec(p *SQLParams, res *[]byte) error {
+	if m.inst.rpc != nil {
+		if p.Output == nil {
+			return m.inst.rpc.Call("SQLMethods.Exec", p, res)
+		}
+		rp := *p
+		rp.Output = nil
+		if err := m.inst.rpc.Call("SQLMethods.Exec", &rp, res); err != nil {
+			return err
+		}
+		_, err := p.Output.Write(*res)
+		*res = nil
+		return err
+	}
+	ctx := withKeyring(context.TODO(), m.inst.repo)
+
+	if p.Query == "" {
+		return fmt.Errorf("query is required")
+	}
+	format := p.Format
+	if format == "" {
+		format = dataset.JSONDataFormat.String()
+	}
+	df, err := dataset.ParseDataFormatString(format)
+	if err != nil {
+		return err
+	}
+
+	rows, err := sql.Query(ctx, p.Query, m.openBody)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	st := &dataset.Structure{
+		Format:       df.String(),
+		FormatConfig: p.FormatConfig,
+		Schema:       rows.Structure().Schema,
+	}
+	if df == dataset.CSVDataFormat {
+		st.FormatConfig = map[string]interface{}{"headerRow": true}
+		for k, v := range p.FormatConfig {
+			st.FormatConfig[k] = v
+		}
+	}
+
+	out := p.Output
+	buf := &bytes.Buffer{}
+	if out == nil {
+		out = buf
+	}
+	w, err := dsio.NewEntryWriter(st, out)
+	if err != nil {
+		return err
+	}
+	if err := dsio.Copy(rows, w); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if p.Output == nil {
+		*res = buf.Bytes()
+	}
+	return nil
+}
+
+// openBody opens the body of a dataset for querying. only the body file is
+// loaded, other components are left unopened
+func (m *SQLMethods) openBody(ctx context.Context, refstr string) (dsio.EntryReader, error) {
+	r := m.inst.Repo()
+	ref, err := repo.ParseDatasetRef(refstr)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid dataset reference", refstr)
+	}
+	if err := repo.CanonicalizeDatasetRef(r, &ref); err != nil {
+		return nil, fmt.Errorf("loading %s: %s", refstr, err)
+	}
+	ds, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %s", refstr, err)
+	}
+	if ds.Structure == nil || ds.BodyPath == "" {
+		return nil, fmt.Errorf("dataset %s has no body", refstr)
+	}
+	file, err := dsfs.LoadBody(ctx, r.Store(), ds)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s body: %s", refstr, err)
+	}
+	rdr, err := dsio.NewEntryReader(ds.Structure, file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return bodyReader{EntryReader: rdr, file: file}, nil
+}
+
+// bodyReader closes the body file of a dataset along with its reader
+type bodyReader struct {
+	dsio.EntryReader
+	file qfs.File
+}
+
+func (r bodyReader) Close() error {
+	err := r.EntryReader.Close()
+	if e := r.file.Close(); e != nil && err == nil {
+		err = e
+	}
+	return err
+}
@@ -0,0 +1,92 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestSQLExec(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	req := NewDatasetRequestsInstance(tr.Instance)
+	var versions []string
+	for i, body := range []string{statsDiffData1, statsDiffData2} {
+		res := &reporef.DatasetRef{}
+		p := &SaveParams{
+			Ref:      "me/sql_cities",
+			BodyPath: tr.writeFile(t, fmt.Sprintf("cities_%d.csv", i), body),
+		}
+		if err := req.Save(p, res); err != nil {
+			t.Fatal(err)
+		}
+		versions = append(versions, res.Path)
+	}
+	states := `[{"city":"new york","state":"NY"},{"city":"chicago","state":"IL"},{"city":"raleigh","state":"NC"}]`
+	if err := req.Save(&SaveParams{Ref: "me/sql_states", BodyPath: tr.writeFile(t, "states.json", states)}, &reporef.DatasetRef{}); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewSQLMethods(tr.Instance)
+	cases := []struct {
+		params SQLParams
+		expect string
+	}{
+		{SQLParams{Query: "SELECT city, pop FROM me/sql_cities WHERE pop > 30000 ORDER BY pop DESC"},
+			`[["new york",85000],["toronto",40000]]`},
+		{SQLParams{Query: "SELECT status, COUNT(*) AS n, SUM(pop) FROM me/sql_cities GROUP BY status ORDER BY n", Format: "csv"},
+			"status,n,SUM(pop)\nok,1,40000\nlate,4,118000\n"},
+		{SQLParams{Query: "SELECT state, c.pop - old.pop AS growth FROM me/sql_cities c JOIN me/sql_cities@" + versions[0] + " old ON c.city = old.city JOIN me/sql_states s ON s.city = c.city ORDER BY state LIMIT 2"},
+			`[["IL",26730],["NC",2475]]`},
+		{SQLParams{Query: "SELECT city FROM me/sql_cities LIMIT 1", Format: "csv", FormatConfig: map[string]interface{}{"headerRow": false}},
+			"toronto\n"},
+	}
+	for _, c := range cases {
+		var res []byte
+		if err := m.Exec(&c.params, &res); err != nil {
+			t.Errorf("%q: unexpected error: %s", c.params.Query, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, string(res)); diff != "" {
+			t.Errorf("%q: result mismatch (-want +got):\n%s", c.params.Query, diff)
+		}
+	}
+
+	// results stream to Output when it's set
+	buf := &bytes.Buffer{}
+	var res []byte
+	if err := m.Exec(&SQLParams{Query: "SELECT city FROM me/sql_cities LIMIT 2", Format: "csv", Output: buf}, &res); err != nil {
+		t.Fatal(err)
+	}
+	if len(res) != 0 {
+		t.Errorf("expected empty result when streaming to Output, got %q", res)
+	}
+	if diff := cmp.Diff("city\ntoronto\nnew york\n", buf.String()); diff != "" {
+		t.Errorf("streamed result mismatch (-want +got):\n%s", diff)
+	}
+
+	bad := []struct {
+		params SQLParams
+		err    string
+	}{
+		{SQLParams{}, "query is required"},
+		{SQLParams{Query: "SELECT * FROM me/sql_cities", Format: "nope"}, "invalid data format: `nope`"},
+		{SQLParams{Query: "SELECT * FROM me/not_a_dataset"}, "loading me/not_a_dataset: repo: not found"},
+		{SQLParams{Query: "DELETE FROM me/sql_cities"}, `syntax error at position 1: expected SELECT, got "DELETE"`},
+	}
+	for _, c := range bad {
+		var res []byte
+		err := m.Exec(&c.params, &res)
+		if err == nil {
+			t.Errorf("%q: expected error", c.params.Query)
+			continue
+		}
+		if err.Error() != c.err {
+			t.Errorf("%q: error mismatch. expected: %q, got: %q", c.params.Query, c.err, err.Error())
+		}
+	}
+}
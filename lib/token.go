@@ -0,0 +1,104 @@
+package lib
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/qri-io/qri/identity"
+	"github.com/qri-io/qri/token"
+)
+
+// TokenMethods issues, lists & revokes bearer tokens for the HTTP API
+type TokenMethods struct {
+	inst *Instance
+}
+
+// NewTokenMethods creates TokenMethods from a qri Instance
+func NewTokenMethods(inst *Instance) *TokenMethods {
+	return &TokenMethods{inst: inst}
+}
+
+// CoreRequestsName implements the Methods interface
+func (m TokenMethods) CoreRequestsName() string { return "token" }
+
+// IssueTokenParams defines parameters for issuing a token
+type IssueTokenParams struct {
+	// Subject describes who or what the token is for
+	Subject string
+	// Scopes the token grants: read, write, publish, or admin
+	Scopes []string
+	// TTL is how long the token is valid for, zero never expires
+	TTL time.Duration
+}
+
+// IssuedToken is a newly issued token & its claims
+type IssuedToken struct {
+	Token  string
+	Claims token.Claims
+}
+
+// Issue creates a token signed by the instance private key
+func (m *TokenMethods) Issue(p *IssueTokenParams, res *IssuedToken) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("TokenMethods.Issue", p, res)
+	}
+	if len(p.Scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	scopes, err := token.ParseScopes(p.Scopes)
+	if err != nil {
+		return err
+	}
+	if p.TTL < 0 {
+		return fmt.Errorf("token lifetime can't be negative")
+	}
+
+	pk := m.inst.Repo().PrivateKey()
+	issuer, err := identity.KeyIDFromPriv(pk)
+	if err != nil {
+		return err
+	}
+	claims, err := token.NewClaims(issuer, p.Subject, scopes, p.TTL)
+	if err != nil {
+		return err
+	}
+	tok, err := token.Sign(pk, claims)
+	if err != nil {
+		return err
+	}
+	if err = m.inst.tokens.Put(claims); err != nil {
+		return err
+	}
+
+	*res = IssuedToken{Token: tok, Claims: *claims}
+	return nil
+}
+
+// List gives all tokens issued by the instance, including expired & revoked
+// tokens
+func (m *TokenMethods) List(in *bool, res *[]token.Record) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("TokenMethods.List", in, res)
+	}
+	recs, err := m.inst.tokens.List()
+	if err != nil {
+		return err
+	}
+	*res = recs
+	return nil
+}
+
+// Revoke stops the token with the given ID from being accepted
+func (m *TokenMethods) Revoke(id *string, res *bool) error {
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("TokenMethods.Revoke", id, res)
+	}
+	if err := m.inst.tokens.Revoke(*id); err != nil {
+		if err == token.ErrNotFound {
+			return NewError(err, fmt.Sprintf("no token with ID %q", *id))
+		}
+		return err
+	}
+	*res = true
+	return nil
+}
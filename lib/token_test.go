@@ -0,0 +1,65 @@
+package lib
+
+import (
+	"testing"
+	"time"
+
+	"github.com/qri-io/qri/token"
+)
+
+func TestTokenMethods(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	m := NewTokenMethods(tr.Instance)
+
+	bad := []IssueTokenParams{
+		{},
+		{Scopes: []string{"everything"}},
+		{Scopes: []string{"read"}, TTL: -time.Hour},
+	}
+	for i, p := range bad {
+		if err := m.Issue(&p, &IssuedToken{}); err == nil {
+			t.Errorf("case %d: expected error issuing token", i)
+		}
+	}
+
+	issued := &IssuedToken{}
+	p := &IssueTokenParams{Subject: "dashboard", Scopes: []string{"read"}, TTL: time.Hour}
+	if err := m.Issue(p, issued); err != nil {
+		t.Fatal(err)
+	}
+	if issued.Claims.ExpiresAt == 0 {
+		t.Error("expected token to have an expiry")
+	}
+
+	claims, err := tr.Instance.Authenticate(issued.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !claims.Allows(token.ScopeRead) || claims.Allows(token.ScopeWrite) {
+		t.Errorf("unexpected scopes: %v", claims.Scopes)
+	}
+
+	list := true
+	recs := []token.Record{}
+	if err := m.List(&list, &recs); err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 1 || recs[0].ID != issued.Claims.ID || recs[0].Subject != "dashboard" {
+		t.Errorf("unexpected token records: %v", recs)
+	}
+
+	ok := false
+	id := issued.Claims.ID
+	if err := m.Revoke(&id, &ok); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.Instance.Authenticate(issued.Token); err != token.ErrRevoked {
+		t.Errorf("expected revoked token to fail authentication, got: %v", err)
+	}
+	missing := "missing"
+	if err := m.Revoke(&missing, &ok); err == nil {
+		t.Error("expected revoking an unknown token to error")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"io/ioutil"
 	"time"
 
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/ioes"
 	"github.com/qri-io/qfs"
 	"github.com/qri-io/qri/base"
 	"github.com/qri-io/qri/config"
@@ -46,6 +48,13 @@ type ScheduleParams struct {
 
 	// SaveParams only applies to dataset saves
 	SaveParams *SaveParams
+	// Upstream names jobs that trigger this job when they run successfully.
+	// Jobs with upstream jobs don't require a periodicity
+	Upstream []string
+	// Transform schedules running the dataset's transform in-process instead
+	// of calling "qri save". Only title, message, secrets, publish & force
+	// SaveParams apply to transform updates
+	Transform bool
 }
 
 // Schedule creates a job and adds it to the scheduler
@@ -62,6 +71,14 @@ func (m *UpdateMethods) Schedule(in *ScheduleParams, out *cron.Job) (err error)
 		}
 	}
 
+	for i, name := range in.Upstream {
+		if update.PossibleShellScript(name) {
+			if err = qfs.AbsPath(&in.Upstream[i]); err != nil {
+				return
+			}
+		}
+	}
+
 	if err = in.SaveParams.AbsolutizePaths(); err != nil {
 		return err
 	}
@@ -88,8 +105,13 @@ func (m *UpdateMethods) Schedule(in *ScheduleParams, out *cron.Job) (err error)
 }
 
 func (m *UpdateMethods) jobFromScheduleParams(ctx context.Context, p *ScheduleParams) (job *cron.Job, err error) {
+	upstream, err := canonicalUpstream(m.inst.Repo(), p.Upstream)
+	if err != nil {
+		return nil, err
+	}
+
 	if update.PossibleShellScript(p.Name) {
-		return update.ShellScriptToJob(p.Name, p.Periodicity, nil)
+		return update.ShellScriptToJob(p.Name, p.Periodicity, upstream, nil)
 	}
 
 	var ref reporef.DatasetRef
@@ -117,6 +139,20 @@ func (m *UpdateMethods) jobFromScheduleParams(ctx context.Context, p *SchedulePa
 		return
 	}
 
+	if p.Transform {
+		var o *cron.TransformOptions
+		if p.SaveParams != nil {
+			o = &cron.TransformOptions{
+				Title:   p.SaveParams.Title,
+				Message: p.SaveParams.Message,
+				Publish: p.SaveParams.Publish,
+				Force:   p.SaveParams.Force,
+				Secrets: p.SaveParams.Secrets,
+			}
+		}
+		return update.TransformToJob(ref.Dataset, p.Periodicity, upstream, o)
+	}
+
 	var o *cron.DatasetOptions
 	if p.SaveParams != nil {
 		o = &cron.DatasetOptions{
@@ -136,7 +172,56 @@ func (m *UpdateMethods) jobFromScheduleParams(ctx context.Context, p *SchedulePa
 		}
 	}
 
-	return update.DatasetToJob(ref.Dataset, p.Periodicity, o)
+	return update.DatasetToJob(ref.Dataset, p.Periodicity, upstream, o)
+}
+
+// canonicalUpstream names upstream datasets the way dataset jobs are named,
+// resolving references like "me/dataset" to "peername/dataset". Shell script
+// names are kept as-is
+func canonicalUpstream(r repo.Repo, upstream []string) ([]string, error) {
+	if len(upstream) == 0 {
+		return upstream, nil
+	}
+	names := make([]string, len(upstream))
+	for i, name := range upstream {
+		if update.PossibleShellScript(name) {
+			names[i] = name
+			continue
+		}
+		ref, err := repo.ParseDatasetRef(name)
+		if err != nil {
+			return nil, fmt.Errorf("upstream %q: %w", name, err)
+		}
+		if err = repo.CanonicalizeDatasetRef(r, &ref); err != nil {
+			return nil, fmt.Errorf("upstream %q: %w", name, err)
+		}
+		names[i] = ref.AliasString()
+	}
+	return names, nil
+}
+
+// DependencyGraph aliases cron.DependencyGraph, describing the order
+// scheduled jobs trigger each other in
+type DependencyGraph = cron.DependencyGraph
+
+// Dependencies shows how scheduled jobs depend on each other
+func (m *UpdateMethods) Dependencies(in *bool, res *DependencyGraph) error {
+	// this context is scoped to the scheduling request. currently not cancellable
+	// because our lib methods don't accept a context themselves
+	// TODO (b5): refactor RPC communication to use context
+	var ctx = context.Background()
+
+	jobs, err := m.inst.cron.ListJobs(ctx, 0, -1)
+	if err != nil {
+		return err
+	}
+	graph, err := cron.NewDependencyGraph(jobs)
+	if err != nil {
+		return err
+	}
+
+	*res = *graph
+	return nil
 }
 
 // Unschedule removes a job from the scheduler by name
@@ -149,6 +234,77 @@ func (m *UpdateMethods) Unschedule(name *string, unscheduled *bool) error {
 	return m.inst.cron.Unschedule(ctx, *name)
 }
 
+// Pause stops a scheduled job from running until it's resumed. Paused jobs
+// don't run on schedule or when triggered by upstream jobs, but can still be
+// run manually
+func (m *UpdateMethods) Pause(name *string, paused *bool) (err error) {
+	if update.PossibleShellScript(*name) {
+		if err = qfs.AbsPath(name); err != nil {
+			return err
+		}
+	}
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("UpdateMethods.Pause", name, paused)
+	}
+	// this context is scoped to the scheduling request. currently not cancellable
+	// because our lib methods don't accept a context themselves
+	// TODO (b5): refactor RPC communication to use context
+	var ctx = context.Background()
+
+	if err = m.inst.cron.Pause(ctx, *name); err != nil {
+		return err
+	}
+	*paused = true
+	return nil
+}
+
+// Resume restarts a paused job, clearing any record of consecutive failures
+func (m *UpdateMethods) Resume(name *string, resumed *bool) (err error) {
+	if update.PossibleShellScript(*name) {
+		if err = qfs.AbsPath(name); err != nil {
+			return err
+		}
+	}
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("UpdateMethods.Resume", name, resumed)
+	}
+	// this context is scoped to the scheduling request. currently not cancellable
+	// because our lib methods don't accept a context themselves
+	// TODO (b5): refactor RPC communication to use context
+	var ctx = context.Background()
+
+	if err = m.inst.cron.Resume(ctx, *name); err != nil {
+		return err
+	}
+	*resumed = true
+	return nil
+}
+
+// RunScheduled runs a scheduled job immediately through the scheduler, which
+// numbers & logs the run and applies the failure policy the same way it would
+// for a scheduled run. res is the logged run, a failed run sets res.RunError
+func (m *UpdateMethods) RunScheduled(name *string, res *Job) (err error) {
+	if update.PossibleShellScript(*name) {
+		if err = qfs.AbsPath(name); err != nil {
+			return err
+		}
+	}
+	if m.inst.rpc != nil {
+		return m.inst.rpc.Call("UpdateMethods.RunScheduled", name, res)
+	}
+	// this context is scoped to the run request. currently not cancellable
+	// because our lib methods don't accept a context themselves
+	// TODO (b5): refactor RPC communication to use context
+	var ctx = context.Background()
+
+	run, err := m.inst.cron.Run(ctx, *name)
+	if err != nil {
+		return err
+	}
+	*res = *run
+	return nil
+}
+
 // List gets scheduled jobs
 func (m *UpdateMethods) List(p *ListParams, jobs *[]*Job) error {
 	// this context is scoped to the scheduling request. currently not cancellable
@@ -167,6 +323,11 @@ func (m *UpdateMethods) List(p *ListParams, jobs *[]*Job) error {
 
 // Job gets a job by name
 func (m *UpdateMethods) Job(name *string, job *Job) (err error) {
+	if update.PossibleShellScript(*name) {
+		if err = qfs.AbsPath(name); err != nil {
+			return err
+		}
+	}
 	// this context is scoped to the scheduling request. currently not cancellable
 	// because our lib methods don't accept a context themselves
 	// TODO (b5): refactor RPC communication to use context
@@ -263,8 +424,12 @@ func (m *UpdateMethods) ServiceStart(p *UpdateServiceStartParams, started *bool)
 		log.Info("starting update service")
 	}
 
+	if m.inst == nil {
+		return fmt.Errorf("starting the update service requires an instance")
+	}
+
 	*started = true
-	return update.Start(p.Ctx, p.RepoPath, p.UpdateCfg, p.Daemonize)
+	return update.Start(p.Ctx, p.RepoPath, p.UpdateCfg, p.Daemonize, m.inst.runTransformJob)
 }
 
 // ServiceStop halts the scheduler
@@ -288,7 +453,7 @@ func (m *UpdateMethods) Run(p *Job, res *reporef.DatasetRef) (err error) {
 			return
 		}
 		p.Type = cron.JTShellScript
-	} else {
+	} else if p.Type != cron.JTTransform {
 		p.Type = cron.JTDataset
 	}
 
@@ -299,7 +464,7 @@ func (m *UpdateMethods) Run(p *Job, res *reporef.DatasetRef) (err error) {
 	if m.inst.rpc != nil {
 		return m.inst.rpc.Call("UpdateMethods.Run", p, res)
 	}
-	ctx := context.TODO()
+	ctx := withKeyring(context.TODO(), m.inst.repo)
 
 	switch p.Type {
 	case cron.JTDataset:
@@ -328,6 +493,9 @@ func (m *UpdateMethods) Run(p *Job, res *reporef.DatasetRef) (err error) {
 		*res = reporef.DatasetRef{}
 		err = m.runDatasetUpdate(ctx, params, res)
 
+	case cron.JTTransform:
+		*res = reporef.DatasetRef{}
+		err = m.runTransform(ctx, m.inst.streams, p, res)
 	case cron.JTShellScript:
 		err = update.JobToCmd(m.inst.streams, p).Run()
 	case cron.JobType(""):
@@ -372,6 +540,10 @@ func (m *UpdateMethods) runDatasetUpdate(ctx context.Context, p *SaveParams, res
 		return err
 	}
 
+	if cfg := m.inst.cfg; cfg != nil {
+		p.TransformLimits = update.TransformLimits(cfg.Update)
+	}
+
 	if !base.InLocalNamespace(m.inst.Repo(), &ref) {
 		// TODO (b5) - add remoteclient.Update method
 		return fmt.Errorf("remote updating is currently disabled")
@@ -386,3 +558,44 @@ func (m *UpdateMethods) runDatasetUpdate(ctx context.Context, p *SaveParams, res
 	dsr := NewDatasetRequestsInstance(m.inst)
 	return dsr.Save(p, res)
 }
+
+// runTransform executes the transform of the dataset named by a job
+// in-process, saving the result as a new version
+func (m *UpdateMethods) runTransform(ctx context.Context, streams ioes.IOStreams, job *Job, res *reporef.DatasetRef) error {
+	p := &SaveParams{
+		Ref:          job.Name,
+		Recall:       "tf",
+		ScriptOutput: streams.ErrOut,
+		ShouldRender: true,
+	}
+	if o, ok := job.Options.(*cron.TransformOptions); ok {
+		p.Title = o.Title
+		p.Message = o.Message
+		p.Publish = o.Publish
+		p.Force = o.Force
+		p.Secrets = o.Secrets
+		if len(o.Config) > 0 {
+			cfg := map[string]interface{}{}
+			for key, val := range o.Config {
+				cfg[key] = val
+			}
+			p.Dataset = &dataset.Dataset{Transform: &dataset.Transform{Config: cfg}}
+		}
+	}
+	return m.runDatasetUpdate(ctx, p, res)
+}
+
+// runTransformJob is a cron.RunJobFunc that runs transform jobs against the
+// instance repo. Instances that connect to another process over RPC run the
+// job in that process, so only one process ever writes to the repo
+func (inst *Instance) runTransformJob(ctx context.Context, streams ioes.IOStreams, job *cron.Job) error {
+	res := &reporef.DatasetRef{}
+	if inst.rpc != nil {
+		return NewUpdateMethods(inst).Run(job, res)
+	}
+	ctx = withKeyring(ctx, inst.repo)
+	if err := NewUpdateMethods(inst).runTransform(ctx, streams, job, res); err != nil {
+		return err
+	}
+	return inst.Repo().Logbook().WriteCronJobRan(ctx, job.RunNumber, reporef.ConvertToDsref(*res))
+}
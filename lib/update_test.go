@@ -2,7 +2,10 @@ package lib
 
 import (
 	"context"
+	"errors"
 	"io/ioutil"
+	"net"
+	"net/rpc"
 	"os"
 	"testing"
 	"time"
@@ -11,6 +14,8 @@ import (
 	"github.com/qri-io/ioes"
 	"github.com/qri-io/qri/config"
 	reporef "github.com/qri-io/qri/repo/ref"
+	"github.com/qri-io/qri/startf"
+	"github.com/qri-io/qri/update"
 	"github.com/qri-io/qri/update/cron"
 )
 
@@ -167,3 +172,175 @@ func TestDatasetMethodsRun(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestUpdateMethodsRunTransform(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+	inst := tr.Instance
+	m := NewUpdateMethods(inst)
+
+	ref := addNowTransformDataset(t, inst.Node())
+	job := &Job{
+		Name:    ref.AliasString(),
+		Type:    cron.JTTransform,
+		Options: &cron.TransformOptions{Title: "transform update"},
+	}
+	res := &reporef.DatasetRef{}
+	if err := m.Run(job, res); err != nil {
+		t.Fatal(err)
+	}
+	if job.Type != cron.JTTransform {
+		t.Errorf("expected job type to remain %q. got: %q", cron.JTTransform, job.Type)
+	}
+	if res.Dataset == nil || res.Dataset.Commit.Title != "transform update" {
+		t.Errorf("expected saved version to use the job commit title. got: %v", res.Dataset)
+	}
+
+	if err := inst.runTransformJob(tr.Ctx, ioes.NewDiscardIOStreams(), job); err != nil {
+		t.Error(err)
+	}
+
+	// each run appends an entry to the body, the next run would produce 4
+	inst.cfg.Update.TransformMaxBodyEntries = 3
+	err := m.Run(job, res)
+	limitErr := &startf.LimitError{}
+	if !errors.As(err, &limitErr) || limitErr.Kind != startf.LimitBodyEntries {
+		t.Errorf("expected body entries limit error. got: %v", err)
+	}
+}
+
+func TestRunTransformJobOverRPC(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+	inst := tr.Instance
+
+	srv := rpc.NewServer()
+	if err := srv.Register(NewUpdateMethods(inst)); err != nil {
+		t.Fatal(err)
+	}
+	srvConn, cliConn := net.Pipe()
+	go srv.ServeConn(srvConn)
+	client := &Instance{rpc: rpc.NewClient(cliConn)}
+	defer client.rpc.Close()
+
+	ref := addNowTransformDataset(t, inst.Node())
+	book := inst.Repo().Logbook()
+	before, err := book.Versions(tr.Ctx, reporef.ConvertToDsref(ref), 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job := &Job{Name: ref.AliasString(), Type: cron.JTTransform, RunNumber: 1}
+	if err := client.runTransformJob(tr.Ctx, ioes.NewDiscardIOStreams(), job); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := book.Versions(tr.Ctx, reporef.ConvertToDsref(ref), 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(after) != len(before)+1 {
+		t.Errorf("expected the job to save a version in the instance serving RPC. got %d versions, expected %d", len(after), len(before)+1)
+	}
+}
+
+func TestUpdateMethodsPause(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+	inst := tr.Instance
+	inst.cron = cron.NewCron(&cron.MemJobStore{}, &cron.MemJobStore{}, update.NewFactory(startf.Limits{}, inst.runTransformJob))
+	m := NewUpdateMethods(inst)
+
+	res := &Job{}
+	if err := m.Schedule(&ScheduleParams{Name: "testdata/hello.sh", Periodicity: "R/P1D"}, res); err != nil {
+		t.Fatal(err)
+	}
+
+	name := "testdata/hello.sh"
+	var ok bool
+	if err := m.Pause(&name, &ok); err != nil {
+		t.Fatal(err)
+	}
+	job := &Job{}
+	if err := m.Job(&res.Name, job); err != nil {
+		t.Fatal(err)
+	}
+	if !job.Paused {
+		t.Error("expected job to be paused")
+	}
+
+	// paused jobs can still be run manually, through the scheduler
+	name = "testdata/hello.sh"
+	run := &Job{}
+	if err := m.RunScheduled(&name, run); err != nil {
+		t.Fatal(err)
+	}
+	if run.RunNumber != 1 || run.RunError != "" {
+		t.Errorf("expected a successful first run. got run number: %d, error: %q", run.RunNumber, run.RunError)
+	}
+	logs := []*Job{}
+	if err := m.Logs(&ListParams{Limit: -1}, &logs); err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 {
+		t.Errorf("expected manual run to be logged. got %d logs", len(logs))
+	}
+
+	name = "testdata/hello.sh"
+	if err := m.Resume(&name, &ok); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Job(&res.Name, job); err != nil {
+		t.Fatal(err)
+	}
+	if job.Paused {
+		t.Error("expected job to be resumed")
+	}
+
+	name = "me/not_scheduled"
+	if err := m.Pause(&name, &ok); err == nil {
+		t.Error("expected pausing an unscheduled update to error")
+	}
+}
+
+func TestScheduleUpstreamMeRef(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+	inst := tr.Instance
+	inst.cron = cron.NewCron(&cron.MemJobStore{}, &cron.MemJobStore{}, update.NewFactory(startf.Limits{}, inst.runTransformJob))
+	m := NewUpdateMethods(inst)
+
+	ref := addNowTransformDataset(t, inst.Node())
+	up := &Job{}
+	if err := m.Schedule(&ScheduleParams{Name: ref.AliasString(), Periodicity: "R/P1D", Transform: true}, up); err != nil {
+		t.Fatal(err)
+	}
+
+	down := &Job{}
+	if err := m.Schedule(&ScheduleParams{Name: "testdata/hello.sh", Upstream: []string{"me/" + ref.Name}}, down); err != nil {
+		t.Fatal(err)
+	}
+	if len(down.Upstream) != 1 || down.Upstream[0] != up.Name {
+		t.Errorf("expected upstream to be named like the upstream job %q. got: %v", up.Name, down.Upstream)
+	}
+
+	run := &Job{}
+	if err := m.RunScheduled(&up.Name, run); err != nil {
+		t.Fatal(err)
+	}
+	if run.RunError != "" {
+		t.Fatalf("upstream run failed: %s", run.RunError)
+	}
+	got := &Job{}
+	if err := m.Job(&down.Name, got); err != nil {
+		t.Fatal(err)
+	}
+	if got.RunNumber != 1 {
+		t.Errorf("expected a successful upstream run to trigger the downstream job. got run number: %d", got.RunNumber)
+	}
+
+	bad := &ScheduleParams{Name: "testdata/hello.sh", Upstream: []string{"me/not_a_dataset"}}
+	if err := m.Schedule(bad, &Job{}); err == nil {
+		t.Error("expected scheduling with an unknown upstream dataset to error")
+	}
+}
@@ -0,0 +1,284 @@
+package logbook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook/oplog"
+)
+
+const (
+	// PermissionRead allows pulling a dataset. Datasets that haven't granted
+	// read access to anyone can be read by everyone
+	PermissionRead = "read"
+	// PermissionWrite allows pushing new versions of a dataset, both to its
+	// logs & to remotes
+	PermissionWrite = "write"
+	// PermissionPublish allows removing versions of a dataset from a remote
+	PermissionPublish = "publish"
+)
+
+// Permissions lists all permissions that can be granted on a dataset
+var Permissions = []string{PermissionRead, PermissionWrite, PermissionPublish}
+
+// ErrAccessDenied indicates an author doesn't have permission to perform an
+// action on a dataset
+var ErrAccessDenied = fmt.Errorf("logbook: access denied")
+
+// ACL maps the profile IDs of dataset collaborators to the permissions they've
+// been granted. The owner of a dataset isn't listed, owners have all
+// permissions
+type ACL map[string][]string
+
+// Can returns true if a profile has been granted a permission
+func (acl ACL) Can(profileID, permission string) bool {
+	for _, p := range acl[profileID] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Restricts returns true if access to a permission is limited to the owner
+// and granted profiles. Read access is open to everyone until it's granted to
+// someone, all other permissions are always restricted
+func (acl ACL) Restricts(permission string) bool {
+	if permission != PermissionRead {
+		return true
+	}
+	for pid := range acl {
+		if acl.Can(pid, PermissionRead) {
+			return true
+		}
+	}
+	return false
+}
+
+// DatasetOwner returns the profile ID of the author that owns a dataset log.
+// Datasets are owned by the author whose namespace they're created in
+func DatasetOwner(dsLog *oplog.Log) string {
+	root := dsLog
+	for root.Parent() != nil {
+		root = root.Parent()
+	}
+	if root.Model() != AuthorModel {
+		return ""
+	}
+	return root.Author()
+}
+
+// DatasetACL plays forward the access control operations in a dataset log.
+// Only operations written by the owner of the dataset are considered
+func DatasetACL(dsLog *oplog.Log) ACL {
+	owner := DatasetOwner(dsLog)
+	acl := ACL{}
+	for _, op := range dsLog.Ops {
+		if op.Model != ACLModel || op.AuthorID != owner {
+			continue
+		}
+		switch op.Type {
+		case oplog.OpTypeAmend:
+			acl[op.Ref] = op.Relations
+		case oplog.OpTypeRemove:
+			delete(acl, op.Ref)
+		}
+	}
+	return acl
+}
+
+// DatasetACL gets the access control list of a dataset
+func (book Book) DatasetACL(ctx context.Context, ref dsref.Ref) (ACL, error) {
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return DatasetACL(dsLog), nil
+}
+
+// HasPermission returns true if a profile can perform actions that require
+// a permission on a dataset. Dataset owners have all permissions
+func (book Book) HasPermission(ctx context.Context, ref dsref.Ref, profileID, permission string) (bool, error) {
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return false, err
+	}
+	if profileID != "" && profileID == DatasetOwner(dsLog) {
+		return true, nil
+	}
+	acl := DatasetACL(dsLog)
+	return !acl.Restricts(permission) || acl.Can(profileID, permission), nil
+}
+
+// WriteACLGrant gives a profile permissions on a dataset. Only the owner of a
+// dataset can change who has access to it
+func (book *Book) WriteACLGrant(ctx context.Context, ref dsref.Ref, profileID string, permissions ...string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteACLGrant: %s, profileID: %s, permissions: %v", ref, profileID, permissions)
+	if len(permissions) == 0 {
+		return fmt.Errorf("logbook: at least one permission is required")
+	}
+
+	dsLog, owner, err := book.ownedDatasetLog(ctx, ref, profileID, permissions)
+	if err != nil {
+		return err
+	}
+
+	granted := DatasetACL(dsLog)[profileID]
+	updated := filterPermissions(func(p string) bool {
+		return containsString(granted, p) || containsString(permissions, p)
+	})
+	return book.writeACL(ctx, dsLog, owner, profileID, updated)
+}
+
+// WriteACLRevoke takes permissions on a dataset away from a profile. Revoking
+// without listing any permissions removes all access
+func (book *Book) WriteACLRevoke(ctx context.Context, ref dsref.Ref, profileID string, permissions ...string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteACLRevoke: %s, profileID: %s, permissions: %v", ref, profileID, permissions)
+
+	dsLog, owner, err := book.ownedDatasetLog(ctx, ref, profileID, permissions)
+	if err != nil {
+		return err
+	}
+
+	granted := DatasetACL(dsLog)[profileID]
+	if len(granted) == 0 {
+		return fmt.Errorf("logbook: %s has no access to revoke", profileID)
+	}
+	updated := filterPermissions(func(p string) bool {
+		return containsString(granted, p) && len(permissions) > 0 && !containsString(permissions, p)
+	})
+	return book.writeACL(ctx, dsLog, owner, profileID, updated)
+}
+
+// ownedDatasetLog fetches the log of a dataset owned by the book author,
+// validating arguments to an access control change
+func (book *Book) ownedDatasetLog(ctx context.Context, ref dsref.Ref, profileID string, permissions []string) (*oplog.Log, string, error) {
+	if profileID == "" {
+		return nil, "", fmt.Errorf("logbook: profileID is required")
+	}
+	for _, p := range permissions {
+		if !containsString(Permissions, p) {
+			return nil, "", fmt.Errorf("logbook: invalid permission '%s'. permissions must be one of %v", p, Permissions)
+		}
+	}
+
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return nil, "", err
+	}
+	owner := DatasetOwner(dsLog)
+	keyID, err := book.ActivePeerID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if owner != keyID {
+		return nil, "", fmt.Errorf("%w: only the owner of a dataset can change access", ErrAccessDenied)
+	}
+	if profileID == owner {
+		return nil, "", fmt.Errorf("logbook: cannot change the access of a dataset owner")
+	}
+	return dsLog, owner, nil
+}
+
+// writeACL records the full set of permissions a profile has on a dataset.
+// An empty set removes all access
+func (book *Book) writeACL(ctx context.Context, dsLog *oplog.Log, owner, profileID string, permissions []string) error {
+	op := oplog.Op{
+		Type:      oplog.OpTypeAmend,
+		Model:     ACLModel,
+		Ref:       profileID,
+		Relations: permissions,
+		AuthorID:  owner,
+		Timestamp: NewTimestamp(),
+	}
+	if len(permissions) == 0 {
+		op.Type = oplog.OpTypeRemove
+		op.Relations = nil
+	}
+	dsLog.Append(op)
+	return book.save(ctx)
+}
+
+// CheckPushAccess confirms a log pushed by an author can be merged into the
+// book. Authors can push anything to their own namespace. Collaborators with
+// write access to a dataset can add versions & branches to it, but can't
+// change the dataset itself or rewrite existing history
+func (book Book) CheckPushAccess(ctx context.Context, senderID string, lg *oplog.Log) error {
+	existing, err := book.store.Log(ctx, lg.ID())
+	if err == oplog.ErrNotFound {
+		if senderID != lg.Author() {
+			return fmt.Errorf("%w: authors can only create logs they own", ErrAccessDenied)
+		}
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if senderID == existing.Author() {
+		return nil
+	}
+	if !isAppendOnly(existing, lg) || len(lg.Ops) > len(existing.Ops) {
+		return fmt.Errorf("%w: only %s can change their profile", ErrAccessDenied, existing.Name())
+	}
+
+	for _, dsLog := range lg.Logs {
+		prev, err := existing.Log(dsLog.ID())
+		if err != nil {
+			return fmt.Errorf("%w: only %s can create datasets in their namespace", ErrAccessDenied, existing.Name())
+		}
+		if !DatasetACL(prev).Can(senderID, PermissionWrite) {
+			return fmt.Errorf("%w: no write access to %s/%s", ErrAccessDenied, existing.Name(), prev.Name())
+		}
+		if !isAppendOnly(prev, dsLog) || len(dsLog.Ops) > len(prev.Ops) {
+			return fmt.Errorf("%w: only the owner can rename, delete, switch branches, or change access to %s/%s", ErrAccessDenied, existing.Name(), prev.Name())
+		}
+		for _, branch := range dsLog.Logs {
+			if prevBranch, err := prev.Log(branch.ID()); err == nil && !isAppendOnly(prevBranch, branch) {
+				return fmt.Errorf("%w: branch %s of %s/%s has history that doesn't match", ErrAccessDenied, branch.Name(), existing.Name(), prev.Name())
+			}
+		}
+	}
+	return nil
+}
+
+// isAppendOnly returns true if the operations of next begin with the
+// operations of prev, or the other way around
+func isAppendOnly(prev, next *oplog.Log) bool {
+	n := len(prev.Ops)
+	if len(next.Ops) < n {
+		n = len(next.Ops)
+	}
+	for i := 0; i < n; i++ {
+		if !prev.Ops[i].Equal(next.Ops[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterPermissions returns permissions that pass a filter in canonical order
+func filterPermissions(keep func(p string) bool) []string {
+	perms := []string{}
+	for _, p := range Permissions {
+		if keep(p) {
+			perms = append(perms, p)
+		}
+	}
+	return perms
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package logbook
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/identity"
+)
+
+func TestACL(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	tr.WriteWorldBankExample(t)
+	ref := tr.WorldBankRef()
+
+	collabPk := testPrivKey2(t)
+	collabID, err := identity.KeyIDFromPriv(collabPk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerID, err := tr.Book.ActivePeerID(tr.Ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := tr.Book.HasPermission(tr.Ctx, ref, ownerID, PermissionPublish); err != nil || !ok {
+		t.Errorf("expected owner to have publish permission. got: %t, %v", ok, err)
+	}
+	if ok, _ := tr.Book.HasPermission(tr.Ctx, ref, collabID, PermissionRead); !ok {
+		t.Errorf("expected datasets without read grants to be readable")
+	}
+	if ok, _ := tr.Book.HasPermission(tr.Ctx, ref, collabID, PermissionWrite); ok {
+		t.Errorf("expected collaborator to not have write access before granting")
+	}
+
+	if err := tr.Book.WriteACLGrant(tr.Ctx, ref, collabID, "fly"); err == nil {
+		t.Error("expected granting an invalid permission to error")
+	}
+	if err := tr.Book.WriteACLGrant(tr.Ctx, ref, ownerID, PermissionWrite); err == nil {
+		t.Error("expected changing owner access to error")
+	}
+	if err := tr.Book.WriteACLGrant(tr.Ctx, ref, collabID, PermissionWrite, PermissionRead); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Book.WriteACLGrant(tr.Ctx, ref, collabID, PermissionPublish); err != nil {
+		t.Fatal(err)
+	}
+
+	acl, err := tr.Book.DatasetACL(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := ACL{collabID: {PermissionRead, PermissionWrite, PermissionPublish}}
+	if diff := cmp.Diff(expect, acl); diff != "" {
+		t.Errorf("ACL mismatch (-want +got):\n%s", diff)
+	}
+	if ok, _ := tr.Book.HasPermission(tr.Ctx, ref, "someone_else", PermissionRead); ok {
+		t.Errorf("expected granting read access to restrict reads")
+	}
+
+	if err := tr.Book.WriteACLRevoke(tr.Ctx, ref, collabID, PermissionRead); err != nil {
+		t.Fatal(err)
+	}
+	acl, _ = tr.Book.DatasetACL(tr.Ctx, ref)
+	expect = ACL{collabID: {PermissionWrite, PermissionPublish}}
+	if diff := cmp.Diff(expect, acl); diff != "" {
+		t.Errorf("ACL mismatch after revoking read (-want +got):\n%s", diff)
+	}
+
+	// sync the log to the collaborator
+	collab, err := NewJournal(collabPk, "collaborator", qfs.NewMemFS(), "/mem/collab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerLog, err := tr.Book.UserDatasetRef(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ownerLog.Sign(tr.Book.pk); err != nil {
+		t.Fatal(err)
+	}
+	if err := collab.MergeLog(tr.Ctx, tr.Book.Author(), ownerLog.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := collab.HasPermission(tr.Ctx, ref, collabID, PermissionWrite); !ok {
+		t.Errorf("expected synced log to grant collaborator write access")
+	}
+	if err := collab.WriteACLGrant(tr.Ctx, ref, collabID, PermissionRead); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("expected collaborator granting access to be denied. got: %v", err)
+	}
+
+	// collaborator saves a new version of the owner's dataset
+	if err := collab.WriteVersionSave(tr.Ctx, &dataset.Dataset{
+		Peername: tr.Username,
+		Name:     ref.Name,
+		Commit: &dataset.Commit{
+			Timestamp: time.Date(2000, time.January, 4, 0, 0, 0, 0, time.UTC),
+			Title:     "collaborator commit",
+		},
+		Path:         "QmHashOfVersion4",
+		PreviousPath: "QmHashOfVersion3",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	pushed, err := collab.UserDatasetRef(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Book.CheckPushAccess(tr.Ctx, collabID, pushed); err != nil {
+		t.Errorf("expected collaborator with write access to push. got: %s", err)
+	}
+	if err := tr.Book.CheckPushAccess(tr.Ctx, "someone_else", pushed); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("expected push without write access to be denied. got: %v", err)
+	}
+
+	// collaborators can't change the dataset log
+	if err := collab.WriteDatasetRename(tr.Ctx, ref, "renamed"); err != nil {
+		t.Fatal(err)
+	}
+	renamed := tr.WorldBankRef()
+	renamed.Name = "renamed"
+	if pushed, err = collab.UserDatasetRef(tr.Ctx, renamed); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Book.CheckPushAccess(tr.Ctx, collabID, pushed); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("expected collaborator rename to be denied. got: %v", err)
+	}
+
+	if err := tr.Book.WriteACLRevoke(tr.Ctx, ref, collabID); err != nil {
+		t.Fatal(err)
+	}
+	if acl, _ = tr.Book.DatasetACL(tr.Ctx, ref); len(acl) != 0 {
+		t.Errorf("expected revoking all access to empty the ACL. got: %v", acl)
+	}
+	if err := tr.Book.WriteACLRevoke(tr.Ctx, ref, collabID); err == nil {
+		t.Error("expected revoking from a profile without access to error")
+	}
+}
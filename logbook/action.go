@@ -12,12 +12,19 @@ const (
 	ActionDatasetNameInit ActionType = iota
 	// ActionDatasetChange is an action for when a dataset changes
 	ActionDatasetChange
+	// ActionBranchDelete is an action for when a dataset branch is removed
+	ActionBranchDelete
+	// ActionDatasetRename is an action for when a dataset is renamed
+	ActionDatasetRename
+	// ActionDatasetDelete is an action for when a dataset is removed
+	ActionDatasetDelete
 )
 
 // Action represents the result of an action that logbook just completed
 type Action struct {
 	Type       ActionType
 	InitID     string
+	Branch     string
 	TopIndex   int
 	ProfileID  string
 	Username   string
@@ -0,0 +1,366 @@
+package logbook
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"sync"
+
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook/oplog"
+)
+
+// ErrNoKeyShare indicates a profile hasn't been given the key to a private
+// dataset
+var ErrNoKeyShare = fmt.Errorf("logbook: no key shared")
+
+// KeyShare is the data key of a private dataset encrypted to the public key of
+// a single profile
+type KeyShare struct {
+	KeyID      string
+	Ciphertext []byte
+	// PubKey is the marshaled public key the share is encrypted to. Rotating
+	// a dataset key shares the replacement key with this public key
+	PubKey []byte
+}
+
+// DatasetKeyShares plays forward the key sharing operations in a dataset log,
+// mapping profile IDs to the current keys they've been given. Revoked
+// profiles are left out. Only operations written by the owner of the dataset
+// are considered
+func DatasetKeyShares(dsLog *oplog.Log) map[string]KeyShare {
+	shares := map[string]KeyShare{}
+	eachKeyOp(dsLog, func(op oplog.Op, share KeyShare) {
+		if op.Type == oplog.OpTypeRemove {
+			delete(shares, op.Ref)
+			return
+		}
+		shares[op.Ref] = share
+	})
+	return shares
+}
+
+// profileKeyShares lists every key ever shared with a profile, including keys
+// that have since been rotated out. Versions written before a rotation are
+// encrypted with an earlier key
+func profileKeyShares(dsLog *oplog.Log, profileID string) (shares []KeyShare) {
+	eachKeyOp(dsLog, func(op oplog.Op, share KeyShare) {
+		if op.Type != oplog.OpTypeRemove && op.Ref == profileID {
+			shares = append(shares, share)
+		}
+	})
+	return shares
+}
+
+// eachKeyOp calls fn with every key operation written by the owner of a
+// dataset, in order, decoding shares
+func eachKeyOp(dsLog *oplog.Log, fn func(op oplog.Op, share KeyShare)) {
+	owner := DatasetOwner(dsLog)
+	for _, op := range dsLog.Ops {
+		if op.Model != KeyModel || op.AuthorID != owner {
+			continue
+		}
+		if op.Type == oplog.OpTypeRemove {
+			fn(op, KeyShare{})
+			continue
+		}
+		ciphertext, err := base64.StdEncoding.DecodeString(op.Note)
+		if err != nil {
+			log.Debugf("decoding key share for %s: %s", op.Ref, err)
+			continue
+		}
+		share := KeyShare{KeyID: op.Name, Ciphertext: ciphertext}
+		if len(op.Relations) > 0 {
+			share.PubKey, _ = base64.StdEncoding.DecodeString(op.Relations[0])
+		}
+		fn(op, share)
+	}
+}
+
+// keyCache holds decrypted data keys by key ID, sparing reads of private
+// datasets a scan of the logbook & an RSA decryption. Key IDs are derived from
+// keys, so cached keys never go stale
+type keyCache struct {
+	lk   sync.Mutex
+	keys map[string][]byte
+}
+
+func newKeyCache() *keyCache {
+	return &keyCache{keys: map[string][]byte{}}
+}
+
+func (c *keyCache) get(keyID string) ([]byte, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	key, ok := c.keys[keyID]
+	return key, ok
+}
+
+func (c *keyCache) put(keyID string, key []byte) {
+	if c == nil {
+		return
+	}
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	c.keys[keyID] = key
+}
+
+// KeyHolders lists the profile IDs the key to a dataset has been shared with,
+// sorted
+func (book Book) KeyHolders(ctx context.Context, ref dsref.Ref) ([]string, error) {
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	holders := []string{}
+	for pid := range DatasetKeyShares(dsLog) {
+		holders = append(holders, pid)
+	}
+	sort.Strings(holders)
+	return holders, nil
+}
+
+// IsPrivate returns true if a dataset is encrypted. Private datasets have
+// shared their key with at least one profile
+func (book Book) IsPrivate(ctx context.Context, ref dsref.Ref) (bool, error) {
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return false, err
+	}
+	return len(DatasetKeyShares(dsLog)) > 0, nil
+}
+
+// DatasetKey decrypts the data key of a private dataset that's been shared
+// with the book author
+func (book Book) DatasetKey(ctx context.Context, ref dsref.Ref) (keyID string, key []byte, err error) {
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return "", nil, err
+	}
+	pid, err := book.profileID(ctx)
+	if err != nil {
+		return "", nil, err
+	}
+	share, ok := DatasetKeyShares(dsLog)[pid]
+	if !ok {
+		return "", nil, ErrNoKeyShare
+	}
+	key, err = book.decryptKeyShare(share)
+	return share.KeyID, key, err
+}
+
+// DataKey finds a data key shared with the book author in any dataset log,
+// satisfying the dsfs.Keyring interface. Keys are cached once decrypted
+func (book Book) DataKey(keyID string) ([]byte, error) {
+	if key, ok := book.keys.get(keyID); ok {
+		return key, nil
+	}
+
+	ctx := context.Background()
+	pid, err := book.profileID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	authors, err := book.store.Logs(ctx, 0, -1)
+	if err != nil {
+		return nil, err
+	}
+	for _, author := range authors {
+		if err := book.store.Descendants(ctx, author); err != nil {
+			return nil, err
+		}
+		for _, dsLog := range author.Logs {
+			for _, share := range profileKeyShares(dsLog, pid) {
+				if share.KeyID == keyID {
+					return book.decryptKeyShare(share)
+				}
+			}
+		}
+	}
+	return nil, ErrNoKeyShare
+}
+
+// WriteKeyShare gives a profile the data key of a private dataset, encrypted
+// to the profile's public key. Only the owner of a dataset can share its key.
+// Owners share keys with themselves to keep them
+func (book *Book) WriteKeyShare(ctx context.Context, ref dsref.Ref, profileID string, pub crypto.PubKey, keyID string, key []byte) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteKeyShare: %s, profileID: %s, keyID: %s", ref, profileID, keyID)
+	if profileID == "" {
+		return fmt.Errorf("logbook: profileID is required")
+	}
+
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return err
+	}
+	owner := DatasetOwner(dsLog)
+	if pid, err := book.profileID(ctx); err != nil || owner != pid {
+		return fmt.Errorf("%w: only the owner of a dataset can share its key", ErrAccessDenied)
+	}
+
+	op, err := keyShareOp(owner, profileID, pub, keyID, key)
+	if err != nil {
+		return err
+	}
+	dsLog.Append(op)
+	return book.save(ctx)
+}
+
+// WriteKeyRotate replaces the data key of a private dataset. The new key is
+// shared with every profile holding the current key except revoked profiles,
+// encrypted to the public key recorded with their share. New versions are
+// encrypted with the new key. Versions written before the rotation aren't
+// re-encrypted, revoked profiles can still read the versions they could read
+// before. Only the owner of a dataset can rotate its key
+func (book *Book) WriteKeyRotate(ctx context.Context, ref dsref.Ref, keyID string, key []byte, revoke ...string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteKeyRotate: %s, keyID: %s, revoke: %v", ref, keyID, revoke)
+
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return err
+	}
+	owner := DatasetOwner(dsLog)
+	if pid, err := book.profileID(ctx); err != nil || owner != pid {
+		return fmt.Errorf("%w: only the owner of a dataset can rotate its key", ErrAccessDenied)
+	}
+
+	shares := DatasetKeyShares(dsLog)
+	if _, ok := shares[owner]; !ok {
+		return fmt.Errorf("%w: %s is not a private dataset", ErrNoKeyShare, ref.Alias())
+	}
+	ops := []oplog.Op{}
+	for _, pid := range revoke {
+		if pid == owner {
+			return fmt.Errorf("logbook: the owner of a dataset can't be revoked from its key")
+		}
+		if _, ok := shares[pid]; !ok {
+			return fmt.Errorf("logbook: %s doesn't hold the key to %s", pid, ref.Alias())
+		}
+		delete(shares, pid)
+		ops = append(ops, oplog.Op{
+			Type:      oplog.OpTypeRemove,
+			Model:     KeyModel,
+			Ref:       pid,
+			AuthorID:  owner,
+			Timestamp: NewTimestamp(),
+		})
+	}
+
+	holders := make([]string, 0, len(shares))
+	for pid := range shares {
+		holders = append(holders, pid)
+	}
+	sort.Strings(holders)
+	for _, pid := range holders {
+		share := shares[pid]
+		if share.PubKey == nil {
+			return fmt.Errorf("logbook: no public key is recorded for %s, share the key with them again before rotating", pid)
+		}
+		pub, err := crypto.UnmarshalPublicKey(share.PubKey)
+		if err != nil {
+			return fmt.Errorf("logbook: public key of %s: %s", pid, err)
+		}
+		op, err := keyShareOp(owner, pid, pub, keyID, key)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, op)
+	}
+
+	for _, op := range ops {
+		dsLog.Append(op)
+	}
+	book.keys.put(keyID, key)
+	return book.save(ctx)
+}
+
+// keyShareOp creates an operation sharing a data key with a profile. The
+// public key is recorded alongside the encrypted key for key rotation
+func keyShareOp(owner, profileID string, pub crypto.PubKey, keyID string, key []byte) (oplog.Op, error) {
+	ciphertext, err := encryptKeyShare(pub, key)
+	if err != nil {
+		return oplog.Op{}, err
+	}
+	pubData, err := crypto.MarshalPublicKey(pub)
+	if err != nil {
+		return oplog.Op{}, err
+	}
+	return oplog.Op{
+		Type:      oplog.OpTypeAmend,
+		Model:     KeyModel,
+		Ref:       profileID,
+		Name:      keyID,
+		Relations: []string{base64.StdEncoding.EncodeToString(pubData)},
+		AuthorID:  owner,
+		Timestamp: NewTimestamp(),
+		Note:      base64.StdEncoding.EncodeToString(ciphertext),
+	}, nil
+}
+
+// profileID gets the profile ID of the book author
+func (book Book) profileID(ctx context.Context) (string, error) {
+	lg, err := book.store.Log(ctx, book.authorID)
+	if err != nil {
+		return "", err
+	}
+	return lg.Author(), nil
+}
+
+func encryptKeyShare(pub crypto.PubKey, key []byte) ([]byte, error) {
+	if pub == nil {
+		return nil, fmt.Errorf("logbook: public key is required to share a key")
+	}
+	raw, err := pub.Raw()
+	if err != nil {
+		return nil, err
+	}
+	k, err := x509.ParsePKIXPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("logbook: key sharing requires an RSA public key")
+	}
+	rsaPub, ok := k.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("logbook: key sharing requires an RSA public key")
+	}
+	return rsa.EncryptOAEP(sha256.New(), rand.Reader, rsaPub, key, nil)
+}
+
+// decryptKeyShare decrypts a key shared with the book author, caching the
+// result by key ID
+func (book Book) decryptKeyShare(share KeyShare) ([]byte, error) {
+	if key, ok := book.keys.get(share.KeyID); ok {
+		return key, nil
+	}
+	if book.pk == nil {
+		return nil, ErrNoKeyShare
+	}
+	raw, err := book.pk.Raw()
+	if err != nil {
+		return nil, err
+	}
+	sk, err := x509.ParsePKCS1PrivateKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("logbook: key sharing requires an RSA private key")
+	}
+	key, err := rsa.DecryptOAEP(sha256.New(), rand.Reader, sk, share.Ciphertext, nil)
+	if err != nil {
+		return nil, err
+	}
+	book.keys.put(share.KeyID, key)
+	return key, nil
+}
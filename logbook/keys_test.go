@@ -0,0 +1,201 @@
+package logbook
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/identity"
+)
+
+func TestKeyShares(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	tr.WriteWorldBankExample(t)
+	ref := tr.WorldBankRef()
+
+	collabPk := testPrivKey2(t)
+	collabID, err := identity.KeyIDFromPriv(collabPk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerID, err := tr.Book.ActivePeerID(tr.Ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if private, err := tr.Book.IsPrivate(tr.Ctx, ref); err != nil || private {
+		t.Errorf("expected dataset without key shares to be public. got: %t, %v", private, err)
+	}
+	if _, _, err := tr.Book.DatasetKey(tr.Ctx, ref); !errors.Is(err, ErrNoKeyShare) {
+		t.Errorf("expected getting the key of a public dataset to fail with ErrNoKeyShare. got: %v", err)
+	}
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	if err := tr.Book.WriteKeyShare(tr.Ctx, ref, ownerID, nil, "key_id", key); err == nil {
+		t.Error("expected sharing without a public key to error")
+	}
+	if err := tr.Book.WriteKeyShare(tr.Ctx, ref, ownerID, tr.Book.pk.GetPublic(), "key_id", key); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Book.WriteKeyShare(tr.Ctx, ref, collabID, collabPk.GetPublic(), "key_id", key); err != nil {
+		t.Fatal(err)
+	}
+
+	if private, _ := tr.Book.IsPrivate(tr.Ctx, ref); !private {
+		t.Errorf("expected dataset with key shares to be private")
+	}
+	keyID, got, err := tr.Book.DatasetKey(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyID != "key_id" || !bytes.Equal(key, got) {
+		t.Errorf("key mismatch. expected: key_id %q, got: %s %q", key, keyID, got)
+	}
+	if got, err = tr.Book.DataKey("key_id"); err != nil || !bytes.Equal(key, got) {
+		t.Errorf("expected book to be a keyring for shared keys. got: %q, %v", got, err)
+	}
+	if _, err = tr.Book.DataKey("unknown"); !errors.Is(err, ErrNoKeyShare) {
+		t.Errorf("expected unknown key ID to fail with ErrNoKeyShare. got: %v", err)
+	}
+
+	holders, err := tr.Book.KeyHolders(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{collabID, ownerID}
+	if ownerID < collabID {
+		expect = []string{ownerID, collabID}
+	}
+	if diff := cmp.Diff(expect, holders); diff != "" {
+		t.Errorf("key holders mismatch (-want +got):\n%s", diff)
+	}
+
+	// sync the log to the collaborator, who can decrypt their share
+	collab, err := NewJournal(collabPk, "collaborator", qfs.NewMemFS(), "/mem/collab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerLog, err := tr.Book.UserDatasetRef(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ownerLog.Sign(tr.Book.pk); err != nil {
+		t.Fatal(err)
+	}
+	if err := collab.MergeLog(tr.Ctx, tr.Book.Author(), ownerLog.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+	if got, err = collab.DataKey("key_id"); err != nil || !bytes.Equal(key, got) {
+		t.Errorf("expected collaborator to decrypt a shared key. got: %q, %v", got, err)
+	}
+	if err := collab.WriteKeyShare(tr.Ctx, ref, collabID, collabPk.GetPublic(), "key_id", key); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("expected collaborator sharing a key to be denied. got: %v", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	tr.WriteWorldBankExample(t)
+	ref := tr.WorldBankRef()
+
+	collabPk := testPrivKey2(t)
+	collabID, err := identity.KeyIDFromPriv(collabPk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerID, err := tr.Book.ActivePeerID(tr.Ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	if err := tr.Book.WriteKeyRotate(tr.Ctx, ref, "key_2", key); !errors.Is(err, ErrNoKeyShare) {
+		t.Errorf("expected rotating the key of a public dataset to fail with ErrNoKeyShare. got: %v", err)
+	}
+	if err := tr.Book.WriteKeyShare(tr.Ctx, ref, ownerID, tr.Book.pk.GetPublic(), "key_1", key); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.Book.WriteKeyShare(tr.Ctx, ref, collabID, collabPk.GetPublic(), "key_1", key); err != nil {
+		t.Fatal(err)
+	}
+
+	// decrypted keys are cached, a book without a private key can still read
+	// keys it has already decrypted
+	if _, err := tr.Book.DataKey("key_1"); err != nil {
+		t.Fatal(err)
+	}
+	cached := *tr.Book
+	cached.pk = nil
+	if got, err := cached.DataKey("key_1"); err != nil || !bytes.Equal(key, got) {
+		t.Errorf("expected cached key. got: %q, %v", got, err)
+	}
+
+	bad := []struct {
+		revoke []string
+		err    string
+	}{
+		{[]string{ownerID}, "logbook: the owner of a dataset can't be revoked from its key"},
+		{[]string{"QmNotAHolder"}, "logbook: QmNotAHolder doesn't hold the key to " + ref.Alias()},
+	}
+	for _, c := range bad {
+		err := tr.Book.WriteKeyRotate(tr.Ctx, ref, "key_2", []byte("fedcba9876543210fedcba9876543210"), c.revoke...)
+		if err == nil || err.Error() != c.err {
+			t.Errorf("revoking %v: error mismatch. expected: %q, got: %v", c.revoke, c.err, err)
+		}
+	}
+
+	// rotating without revoking shares the new key with every holder
+	key2 := []byte("fedcba9876543210fedcba9876543210")
+	if err := tr.Book.WriteKeyRotate(tr.Ctx, ref, "key_2", key2); err != nil {
+		t.Fatal(err)
+	}
+	if keyID, got, err := tr.Book.DatasetKey(tr.Ctx, ref); err != nil || keyID != "key_2" || !bytes.Equal(key2, got) {
+		t.Errorf("expected rotated key. got: %s %q, %v", keyID, got, err)
+	}
+
+	// revoking leaves a profile out of the next key
+	key3 := []byte("abcdefabcdefabcdefabcdefabcdefab")
+	if err := tr.Book.WriteKeyRotate(tr.Ctx, ref, "key_3", key3, collabID); err != nil {
+		t.Fatal(err)
+	}
+	holders, err := tr.Book.KeyHolders(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{ownerID}, holders); diff != "" {
+		t.Errorf("key holders mismatch (-want +got):\n%s", diff)
+	}
+
+	collab, err := NewJournal(collabPk, "collaborator", qfs.NewMemFS(), "/mem/collab")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerLog, err := tr.Book.UserDatasetRef(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ownerLog.Sign(tr.Book.pk); err != nil {
+		t.Fatal(err)
+	}
+	if err := collab.MergeLog(tr.Ctx, tr.Book.Author(), ownerLog.DeepCopy()); err != nil {
+		t.Fatal(err)
+	}
+	// revoked profiles keep keys to versions written before they were revoked
+	for keyID, expect := range map[string][]byte{"key_1": key, "key_2": key2} {
+		if got, err := collab.DataKey(keyID); err != nil || !bytes.Equal(expect, got) {
+			t.Errorf("expected revoked collaborator to keep %s. got: %q, %v", keyID, got, err)
+		}
+	}
+	if _, err := collab.DataKey("key_3"); !errors.Is(err, ErrNoKeyShare) {
+		t.Errorf("expected revoked collaborator not to get the new key. got: %v", err)
+	}
+	if err := collab.WriteKeyRotate(tr.Ctx, ref, "key_4", key, ownerID); !errors.Is(err, ErrAccessDenied) {
+		t.Errorf("expected collaborator rotating a key to be denied. got: %v", err)
+	}
+}
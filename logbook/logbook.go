@@ -56,11 +56,13 @@ const (
 	ACLModel
 	// CronJobModel is the enum for a cron-job model
 	CronJobModel
+	// KeyModel is the enum for a key-share model
+	KeyModel
 )
 
 // DefaultBranchName is the default name all branch-level logbook data is read
-// from and written to. we currently don't present branches as a user-facing
-// feature in qri, but logbook supports them
+// from and written to. The default branch holds the published history of a
+// dataset, and can't be deleted
 const DefaultBranchName = "main"
 
 // ModelString gets a unique string descriptor for an integral model identifier
@@ -80,6 +82,8 @@ func ModelString(m uint32) string {
 		return "acl"
 	case CronJobModel:
 		return "cronJob"
+	case KeyModel:
+		return "key"
 	default:
 		return ""
 	}
@@ -96,12 +100,14 @@ type Book struct {
 	fsLocation string
 	fs         qfs.Filesystem
 
-	listener func(*Action)
+	listeners []func(*Action)
+	// keys caches decrypted data keys of private datasets
+	keys *keyCache
 }
 
 // NewBook creates a book with a user-provided logstore
 func NewBook(pk crypto.PrivKey, store oplog.Logstore) *Book {
-	return &Book{pk: pk, store: store}
+	return &Book{pk: pk, store: store, keys: newKeyCache()}
 }
 
 // NewJournal initializes a logbook owned by a single author, reading any
@@ -125,6 +131,7 @@ func NewJournal(pk crypto.PrivKey, username string, fs qfs.Filesystem, location
 		pk:         pk,
 		authorName: username,
 		fsLocation: location,
+		keys:       newKeyCache(),
 	}
 
 	if err := book.load(ctx); err != nil {
@@ -201,6 +208,11 @@ func (book *Book) AuthorPubKey() crypto.PubKey {
 	return book.pk.GetPublic()
 }
 
+// SignBytes signs data with the private key of this book's author
+func (book *Book) SignBytes(data []byte) ([]byte, error) {
+	return book.pk.Sign(data)
+}
+
 // RenameAuthor marks a change in author name
 func (book *Book) RenameAuthor() error {
 	return fmt.Errorf("not finished")
@@ -314,15 +326,13 @@ func (book Book) initName(ctx context.Context, profileID, username, name string)
 	nameLog := book.authorLog(ctx)
 	nameLog.AddChild(dsLog)
 
-	if book.listener != nil {
-		book.listener(&Action{
-			Type:       ActionDatasetNameInit,
-			InitID:     dsLog.ID(),
-			Username:   username,
-			ProfileID:  profileID,
-			PrettyName: name,
-		})
-	}
+	book.notify(&Action{
+		Type:       ActionDatasetNameInit,
+		InitID:     dsLog.ID(),
+		Username:   username,
+		ProfileID:  profileID,
+		PrettyName: name,
+	})
 
 	return branch
 }
@@ -355,7 +365,16 @@ func (book *Book) WriteDatasetRename(ctx context.Context, ref dsref.Ref, newName
 		Name:      newName,
 		Timestamp: NewTimestamp(),
 	})
-	return book.save(ctx)
+	if err := book.save(ctx); err != nil {
+		return err
+	}
+
+	book.notify(&Action{
+		Type:       ActionDatasetRename,
+		InitID:     l.ID(),
+		PrettyName: newName,
+	})
+	return nil
 }
 
 // WriteDatasetDelete closes a dataset, marking it as deleted
@@ -376,7 +395,15 @@ func (book *Book) WriteDatasetDelete(ctx context.Context, ref dsref.Ref) error {
 		Timestamp: NewTimestamp(),
 	})
 
-	return book.save(ctx)
+	if err := book.save(ctx); err != nil {
+		return err
+	}
+
+	book.notify(&Action{
+		Type:   ActionDatasetDelete,
+		InitID: l.ID(),
+	})
+	return nil
 }
 
 // WriteVersionSave adds an operation to a log marking the creation of a
@@ -389,15 +416,43 @@ func (book *Book) WriteDatasetDelete(ctx context.Context, ref dsref.Ref) error {
 // world were references are only used in the porcelain of qri, and stable ids
 // like initID would only be used in the plumbling.
 func (book *Book) WriteVersionSave(ctx context.Context, ds *dataset.Dataset) error {
+	return book.WriteBranchVersionSave(ctx, ds, DefaultBranchName)
+}
+
+// WriteBranchVersionSave adds an operation to a named branch log marking the
+// creation of a dataset version. Saving to the default branch will initialize
+// a dataset log if none exists, all other branches must be created with
+// WriteBranchInit before versions can be saved to them
+func (book *Book) WriteBranchVersionSave(ctx context.Context, ds *dataset.Dataset, branch string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteBranchVersionSave: %s, branch: %s", ds.Path, branch)
+	return book.writeBranchVersion(ctx, ds, branch, nil)
+}
+
+// WriteBranchVersionMerge adds an operation to a named branch log marking the
+// creation of a merge version. Merge versions have two parents: the version
+// at ds.PreviousPath, and the merged version at mergeParent
+func (book *Book) WriteBranchVersionMerge(ctx context.Context, ds *dataset.Dataset, branch, mergeParent string) error {
 	if book == nil {
 		return ErrNoLogbook
 	}
+	if mergeParent == "" {
+		return fmt.Errorf("merge parent is required")
+	}
+	log.Debugf("WriteBranchVersionMerge: %s, branch: %s, merge parent: %s", ds.Path, branch, mergeParent)
+	return book.writeBranchVersion(ctx, ds, branch, []string{mergeParent})
+}
 
+func (book *Book) writeBranchVersion(ctx context.Context, ds *dataset.Dataset, branch string, parents []string) error {
+	if branch == "" {
+		branch = DefaultBranchName
+	}
 	ref := refFromDataset(ds)
-	log.Debugf("WriteVersionSave: %s", ref)
-	branchLog, err := book.BranchRef(ctx, ref)
+	branchLog, err := book.NamedBranchRef(ctx, ref, branch)
 	if err != nil {
-		if err == oplog.ErrNotFound {
+		if err == oplog.ErrNotFound && branch == DefaultBranchName {
 			branchLog = book.initName(ctx, ds.ProfileID, ref.Username, ref.Name)
 			err = nil
 		} else {
@@ -409,7 +464,7 @@ func (book *Book) WriteVersionSave(ctx context.Context, ds *dataset.Dataset) err
 		return err
 	}
 
-	book.appendVersionSave(branchLog, ds)
+	book.appendVersionSave(branchLog, ds, parents)
 	// TODO(dlong): Think about how to handle a failure exactly here, what needs to be rolled back?
 	err = book.save(ctx)
 	if err != nil {
@@ -418,24 +473,26 @@ func (book *Book) WriteVersionSave(ctx context.Context, ds *dataset.Dataset) err
 	// Index of the branch's top is one less than the length
 	topIndex := len(branchLog.Ops) - 1
 
-	if book.listener != nil {
-		book.listener(&Action{
-			Type:     ActionDatasetChange,
-			InitID:   datasetLog.ID(),
-			TopIndex: topIndex,
-			HeadRef:  ds.Path,
-			Dataset:  ds,
-		})
-	}
+	book.notify(&Action{
+		Type:     ActionDatasetChange,
+		InitID:   datasetLog.ID(),
+		Branch:   branch,
+		TopIndex: topIndex,
+		HeadRef:  ds.Path,
+		Dataset:  ds,
+	})
 	return nil
 }
 
-func (book *Book) appendVersionSave(l *oplog.Log, ds *dataset.Dataset) {
+// appendVersionSave adds a commit op to a log. Any merge parents of the
+// version are stored as relations of the op
+func (book *Book) appendVersionSave(l *oplog.Log, ds *dataset.Dataset, mergeParents []string) {
 	op := oplog.Op{
-		Type:  oplog.OpTypeInit,
-		Model: CommitModel,
-		Ref:   ds.Path,
-		Prev:  ds.PreviousPath,
+		Type:      oplog.OpTypeInit,
+		Model:     CommitModel,
+		Ref:       ds.Path,
+		Prev:      ds.PreviousPath,
+		Relations: mergeParents,
 
 		Timestamp: ds.Commit.Timestamp.UnixNano(),
 		Note:      ds.Commit.Title,
@@ -568,9 +625,17 @@ func (book *Book) WriteCronJobRan(ctx context.Context, number int64, ref dsref.R
 	return book.save(ctx)
 }
 
-// Observe saves a function which listens for changes
+// Observe adds a function which listens for changes. Listeners are called in
+// the order they're added
 func (book *Book) Observe(listener func(*Action)) {
-	book.listener = listener
+	book.listeners = append(book.listeners, listener)
+}
+
+// notify calls all listeners with an action
+func (book *Book) notify(act *Action) {
+	for _, listener := range book.listeners {
+		listener(act)
+	}
 }
 
 // ListAllLogs lists all of the logs in the logbook
@@ -640,20 +705,209 @@ func (book Book) DatasetRef(ctx context.Context, ref dsref.Ref) (*oplog.Log, err
 	return book.store.HeadRef(ctx, ref.Username, ref.Name)
 }
 
-// BranchRef gets a branch log for a dataset reference. Branch logs describe
-// a line of commits
-//
-// currently all logs are hardcoded to only accept one branch name. This
-// function always returns
+// BranchRef gets the default branch log for a dataset reference. Branch logs
+// describe a line of commits
 func (book Book) BranchRef(ctx context.Context, ref dsref.Ref) (*oplog.Log, error) {
+	return book.NamedBranchRef(ctx, ref, DefaultBranchName)
+}
+
+// NamedBranchRef gets a branch log by name for a dataset reference
+func (book Book) NamedBranchRef(ctx context.Context, ref dsref.Ref, branch string) (*oplog.Log, error) {
 	if ref.Username == "" {
 		return nil, fmt.Errorf("logbook: ref.Username is required")
 	}
 	if ref.Name == "" {
 		return nil, fmt.Errorf("logbook: ref.Name is required")
 	}
+	if branch == "" {
+		branch = DefaultBranchName
+	}
+
+	return book.store.HeadRef(ctx, ref.Username, ref.Name, branch)
+}
+
+// Branches lists the names of all branches of a dataset, the default branch is
+// always listed first
+func (book Book) Branches(ctx context.Context, ref dsref.Ref) ([]string, error) {
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for _, l := range dsLog.Logs {
+		if l.Model() != BranchModel || l.Removed() {
+			continue
+		}
+		if l.Name() == DefaultBranchName {
+			names = append([]string{l.Name()}, names...)
+			continue
+		}
+		names = append(names, l.Name())
+	}
+	return names, nil
+}
+
+// ActiveBranch returns the name of the branch a dataset is currently switched
+// to. Saves that don't specify a branch are written to the active branch.
+// Datasets that have never switched branches are on the default branch
+func (book Book) ActiveBranch(ctx context.Context, ref dsref.Ref) (string, error) {
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+
+	active := DefaultBranchName
+	for _, op := range dsLog.Ops {
+		if op.Model == BranchModel && op.Type == oplog.OpTypeAmend {
+			active = op.Name
+		}
+	}
 
-	return book.store.HeadRef(ctx, ref.Username, ref.Name, DefaultBranchName)
+	// a branch that has since been deleted falls back to the default
+	if _, err := dsLog.HeadRef(active); err != nil {
+		return DefaultBranchName, nil
+	}
+	return active, nil
+}
+
+// WriteBranchInit creates a new branch of a dataset. The new branch starts as
+// a copy of the commit history of the "from" branch, defaulting to the default
+// branch if from is the empty string
+func (book *Book) WriteBranchInit(ctx context.Context, ref dsref.Ref, branch, from string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteBranchInit: %s, branch: %s, from: %s", ref, branch, from)
+
+	if !dsref.IsValidName(branch) {
+		return fmt.Errorf("logbook: invalid branch name '%s'. branch names must start with a letter, and only contain letters, numbers, and underscore", branch)
+	}
+	if from == "" {
+		from = DefaultBranchName
+	}
+
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if _, err := dsLog.HeadRef(branch); err == nil {
+		return fmt.Errorf("logbook: branch '%s' already exists", branch)
+	}
+	src, err := dsLog.HeadRef(from)
+	if err != nil {
+		return fmt.Errorf("logbook: branch '%s' not found", from)
+	}
+
+	branchLog := oplog.InitLog(oplog.Op{
+		Type:      oplog.OpTypeInit,
+		Model:     BranchModel,
+		AuthorID:  book.AuthorID(),
+		Name:      branch,
+		Relations: []string{src.ID()},
+		Timestamp: NewTimestamp(),
+	})
+	for _, op := range src.Ops {
+		if op.Model == CommitModel {
+			branchLog.Append(op)
+		}
+	}
+	dsLog.AddChild(branchLog)
+
+	if err := book.save(ctx); err != nil {
+		return err
+	}
+
+	if len(book.listeners) > 0 {
+		vs := Versions(branchLog, ref, 0, 1)
+		act := &Action{
+			Type:     ActionDatasetChange,
+			InitID:   dsLog.ID(),
+			Branch:   branch,
+			TopIndex: len(branchLog.Ops) - 1,
+		}
+		if len(vs) > 0 {
+			act.HeadRef = vs[0].Path
+		}
+		book.notify(act)
+	}
+	return nil
+}
+
+// WriteBranchSwitch marks a branch as the active branch of a dataset
+func (book *Book) WriteBranchSwitch(ctx context.Context, ref dsref.Ref, branch string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteBranchSwitch: %s, branch: %s", ref, branch)
+
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if _, err := dsLog.HeadRef(branch); err != nil {
+		return fmt.Errorf("logbook: branch '%s' not found", branch)
+	}
+
+	dsLog.Append(oplog.Op{
+		Type:      oplog.OpTypeAmend,
+		Model:     BranchModel,
+		Name:      branch,
+		Timestamp: NewTimestamp(),
+	})
+	return book.save(ctx)
+}
+
+// WriteBranchDelete closes a branch, marking it as deleted. The default branch
+// cannot be deleted. Deleting the active branch switches the dataset back to
+// the default branch
+func (book *Book) WriteBranchDelete(ctx context.Context, ref dsref.Ref, branch string) error {
+	if book == nil {
+		return ErrNoLogbook
+	}
+	log.Debugf("WriteBranchDelete: %s, branch: %s", ref, branch)
+
+	if branch == DefaultBranchName {
+		return fmt.Errorf("logbook: cannot delete the default branch")
+	}
+
+	dsLog, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return err
+	}
+	active, err := book.ActiveBranch(ctx, ref)
+	if err != nil {
+		return err
+	}
+	branchLog, err := dsLog.HeadRef(branch)
+	if err != nil {
+		return fmt.Errorf("logbook: branch '%s' not found", branch)
+	}
+
+	branchLog.Append(oplog.Op{
+		Type:      oplog.OpTypeRemove,
+		Model:     BranchModel,
+		Timestamp: NewTimestamp(),
+	})
+	if active == branch {
+		dsLog.Append(oplog.Op{
+			Type:      oplog.OpTypeAmend,
+			Model:     BranchModel,
+			Name:      DefaultBranchName,
+			Timestamp: NewTimestamp(),
+		})
+	}
+
+	if err := book.save(ctx); err != nil {
+		return err
+	}
+
+	book.notify(&Action{
+		Type:   ActionBranchDelete,
+		InitID: dsLog.ID(),
+		Branch: branch,
+	})
+	return nil
 }
 
 // LogBytes signs a log with this book's private key and writes to a flatbuffer
@@ -777,7 +1031,7 @@ func (book *Book) ConstructDatasetLog(ctx context.Context, ref dsref.Ref, histor
 
 	branchLog = book.initName(ctx, ref.ProfileID, ref.Username, ref.Name)
 	for _, ds := range history {
-		book.appendVersionSave(branchLog, ds)
+		book.appendVersionSave(branchLog, ds, nil)
 	}
 
 	return book.save(ctx)
@@ -798,7 +1052,12 @@ func infoFromOp(ref dsref.Ref, op oplog.Op) dsref.VersionInfo {
 // Versions plays a set of operations for a given log, producing a State struct
 // that describes the current state of a dataset
 func (book Book) Versions(ctx context.Context, ref dsref.Ref, offset, limit int) ([]dsref.VersionInfo, error) {
-	l, err := book.BranchRef(ctx, ref)
+	return book.BranchVersions(ctx, ref, DefaultBranchName, offset, limit)
+}
+
+// BranchVersions produces the commit history of a named branch
+func (book Book) BranchVersions(ctx context.Context, ref dsref.Ref, branch string, offset, limit int) ([]dsref.VersionInfo, error) {
+	l, err := book.NamedBranchRef(ctx, ref, branch)
 	if err != nil {
 		return nil, err
 	}
@@ -806,6 +1065,25 @@ func (book Book) Versions(ctx context.Context, ref dsref.Ref, offset, limit int)
 	return Versions(l, ref, offset, limit), nil
 }
 
+// MergeParents maps the path of each merge version in a dataset's history to
+// the paths of the versions merged into it, across all branches of the dataset
+func (book Book) MergeParents(ctx context.Context, ref dsref.Ref) (map[string][]string, error) {
+	l, err := book.DatasetRef(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	parents := map[string][]string{}
+	for _, branchLog := range l.Logs {
+		for _, op := range branchLog.Ops {
+			if op.Model == CommitModel && op.Type == oplog.OpTypeInit && len(op.Relations) > 0 {
+				parents[op.Ref] = op.Relations
+			}
+		}
+	}
+	return parents, nil
+}
+
 // Versions interprets a dataset oplog into a commit history
 func Versions(l *oplog.Log, ref dsref.Ref, offset, limit int) []dsref.VersionInfo {
 	refs := []dsref.VersionInfo{}
@@ -229,6 +229,15 @@ func TestNilCallable(t *testing.T) {
 	if err = book.WriteVersionSave(ctx, nil); err != ErrNoLogbook {
 		t.Errorf("expected '%s', got: %v", ErrNoLogbook, err)
 	}
+	if err = book.WriteBranchInit(ctx, dsref.Ref{}, "", ""); err != ErrNoLogbook {
+		t.Errorf("expected '%s', got: %v", ErrNoLogbook, err)
+	}
+	if err = book.WriteBranchSwitch(ctx, dsref.Ref{}, ""); err != ErrNoLogbook {
+		t.Errorf("expected '%s', got: %v", ErrNoLogbook, err)
+	}
+	if err = book.WriteBranchDelete(ctx, dsref.Ref{}, ""); err != ErrNoLogbook {
+		t.Errorf("expected '%s', got: %v", ErrNoLogbook, err)
+	}
 }
 
 func TestBookLogEntries(t *testing.T) {
@@ -717,6 +726,108 @@ func TestVersions(t *testing.T) {
 	}
 }
 
+func TestBranches(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	tr.WriteWorldBankExample(t)
+	book := tr.Book
+	ref := tr.WorldBankRef()
+
+	if err := book.WriteBranchInit(tr.Ctx, ref, "main", ""); err == nil {
+		t.Error("expected creating a branch that already exists to fail")
+	}
+	if err := book.WriteBranchInit(tr.Ctx, ref, "cleanup", "nope"); err == nil {
+		t.Error("expected creating a branch from a missing branch to fail")
+	}
+	if err := book.WriteBranchInit(tr.Ctx, ref, "bad name", ""); err == nil {
+		t.Error("expected creating a branch with an invalid name to fail")
+	}
+	if err := book.WriteBranchInit(tr.Ctx, ref, "cleanup", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	branches, err := book.Branches(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"main", "cleanup"}, branches); diff != "" {
+		t.Errorf("branches mismatch (-want +got):\n%s", diff)
+	}
+
+	// new branches start with the history of the branch they're created from
+	mainVersions, err := book.Versions(tr.Ctx, ref, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	branchVersions, err := book.BranchVersions(tr.Ctx, ref, "cleanup", 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(mainVersions, branchVersions); diff != "" {
+		t.Errorf("forked branch history mismatch (-want +got):\n%s", diff)
+	}
+
+	ds := &dataset.Dataset{
+		Peername: tr.Username,
+		Name:     ref.Name,
+		Commit: &dataset.Commit{
+			Timestamp: time.Date(2000, time.January, 4, 0, 0, 0, 0, time.UTC),
+			Title:     "cleanup pass",
+		},
+		Path:         "QmHashOfBranchVersion",
+		PreviousPath: mainVersions[0].Path,
+	}
+	if err := book.WriteBranchVersionSave(tr.Ctx, ds, "cleanup"); err != nil {
+		t.Fatal(err)
+	}
+	if err := book.WriteBranchVersionSave(tr.Ctx, ds, "missing"); err == nil {
+		t.Error("expected saving to a missing branch to fail")
+	}
+
+	// saving to a branch doesn't move the default branch
+	if got, err := book.Versions(tr.Ctx, ref, 0, -1); err != nil {
+		t.Fatal(err)
+	} else if diff := cmp.Diff(mainVersions, got); diff != "" {
+		t.Errorf("default branch history changed (-want +got):\n%s", diff)
+	}
+	if got, err := book.BranchVersions(tr.Ctx, ref, "cleanup", 0, 1); err != nil {
+		t.Fatal(err)
+	} else if got[0].Path != "QmHashOfBranchVersion" {
+		t.Errorf("expected branch head to be the branch save. got: %s", got[0].Path)
+	}
+
+	active, err := book.ActiveBranch(tr.Ctx, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active != DefaultBranchName {
+		t.Errorf("expected active branch to default to %q. got: %q", DefaultBranchName, active)
+	}
+	if err := book.WriteBranchSwitch(tr.Ctx, ref, "missing"); err == nil {
+		t.Error("expected switching to a missing branch to fail")
+	}
+	if err := book.WriteBranchSwitch(tr.Ctx, ref, "cleanup"); err != nil {
+		t.Fatal(err)
+	}
+	if active, _ = book.ActiveBranch(tr.Ctx, ref); active != "cleanup" {
+		t.Errorf("expected active branch to be %q. got: %q", "cleanup", active)
+	}
+
+	if err := book.WriteBranchDelete(tr.Ctx, ref, DefaultBranchName); err == nil {
+		t.Error("expected deleting the default branch to fail")
+	}
+	if err := book.WriteBranchDelete(tr.Ctx, ref, "cleanup"); err != nil {
+		t.Fatal(err)
+	}
+	if branches, _ = book.Branches(tr.Ctx, ref); len(branches) != 1 {
+		t.Errorf("expected one branch after delete. got: %v", branches)
+	}
+	if active, _ = book.ActiveBranch(tr.Ctx, ref); active != DefaultBranchName {
+		t.Errorf("expected deleting the active branch to switch to %q. got: %q", DefaultBranchName, active)
+	}
+}
+
 func TestConstructDatasetLog(t *testing.T) {
 	tr, cleanup := newTestRunner(t)
 	defer cleanup()
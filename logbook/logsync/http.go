@@ -1,12 +1,17 @@
 package logsync
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"time"
 
 	crypto "github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/qri-io/qri/dsref"
@@ -15,9 +20,23 @@ import (
 	reporef "github.com/qri-io/qri/repo/ref"
 )
 
+var (
+	// ErrUnsignedRequest indicates an HTTP logsync request isn't signed by the
+	// key it claims to be sent by
+	ErrUnsignedRequest = errors.New("logsync: request isn't signed by its sender")
+
+	// nowFunc is an ps function for getting timestamps
+	nowFunc = time.Now
+	// requestTTL is how far the timestamp of a signed request can be from the
+	// time it's received. keeps captured requests from being replayed later
+	requestTTL = time.Minute * 10
+)
+
 // httpClient is the request side of doing dsync over HTTP
 type httpClient struct {
 	URL string
+	// sign signs requests with the private key of the author making them
+	sign func(data []byte) ([]byte, error)
 }
 
 // compile time assertion that httpClient is a remote
@@ -26,7 +45,14 @@ var _ remote = (*httpClient)(nil)
 
 // Put
 func (c *httpClient) put(ctx context.Context, author identity.Author, r io.Reader) error {
-	req, err := http.NewRequest("PUT", c.URL, r)
+	var body []byte
+	if r != nil {
+		var err error
+		if body, err = ioutil.ReadAll(r); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest("PUT", c.URL, bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
@@ -35,6 +61,9 @@ func (c *httpClient) put(ctx context.Context, author identity.Author, r io.Reade
 	if err := addAuthorHTTPHeaders(req.Header, author); err != nil {
 		return err
 	}
+	if err := c.signRequest(req, "", body); err != nil {
+		return err
+	}
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -60,6 +89,9 @@ func (c *httpClient) get(ctx context.Context, author identity.Author, ref dsref.
 	if err := addAuthorHTTPHeaders(req.Header, author); err != nil {
 		return nil, nil, err
 	}
+	if err := c.signRequest(req, ref.String(), nil); err != nil {
+		return nil, nil, err
+	}
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, nil, err
@@ -89,6 +121,9 @@ func (c *httpClient) del(ctx context.Context, author identity.Author, ref dsref.
 	if err := addAuthorHTTPHeaders(req.Header, author); err != nil {
 		return err
 	}
+	if err := c.signRequest(req, ref.String(), nil); err != nil {
+		return err
+	}
 
 	res, err := http.DefaultClient.Do(req)
 	if err != nil {
@@ -102,6 +137,49 @@ func (c *httpClient) del(ctx context.Context, author identity.Author, ref dsref.
 	return err
 }
 
+// signRequest adds a timestamp & a signature of the request to its headers,
+// proving the request was made by the holder of the sender's private key
+func (c *httpClient) signRequest(req *http.Request, ref string, body []byte) error {
+	if c.sign == nil {
+		return fmt.Errorf("logsync: http client can't sign requests")
+	}
+	timestamp := fmt.Sprintf("%d", nowFunc().In(time.UTC).Unix())
+	sig, err := c.sign([]byte(requestSigningString(timestamp, req.Method, ref, body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Timestamp", timestamp)
+	req.Header.Set("Signature", base64.StdEncoding.EncodeToString(sig))
+	return nil
+}
+
+// verifyRequest confirms a request was signed by sender within requestTTL
+func verifyRequest(r *http.Request, sender identity.Author, ref string, body []byte) error {
+	ts, err := strconv.ParseInt(r.Header.Get("Timestamp"), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", ErrUnsignedRequest)
+	}
+	if age := nowFunc().Sub(time.Unix(ts, 0)); age > requestTTL || age < -requestTTL {
+		return fmt.Errorf("%w: request has expired", ErrUnsignedRequest)
+	}
+	sig, err := base64.StdEncoding.DecodeString(r.Header.Get("Signature"))
+	if err != nil || len(sig) == 0 {
+		return ErrUnsignedRequest
+	}
+	rss := requestSigningString(r.Header.Get("Timestamp"), r.Method, ref, body)
+	if ok, err := sender.AuthorPubKey().Verify([]byte(rss), sig); err != nil || !ok {
+		return ErrUnsignedRequest
+	}
+	return nil
+}
+
+// requestSigningString is the string signed for a request. it covers the
+// request method, the dataset reference & a hash of the body
+func requestSigningString(timestamp, method, ref string, body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf("%s.%s.%s.%s", timestamp, method, ref, base64.StdEncoding.EncodeToString(sum[:]))
+}
+
 func addAuthorHTTPHeaders(h http.Header, author identity.Author) error {
 	h.Set("ID", author.AuthorID())
 
@@ -128,7 +206,8 @@ func senderFromHTTPHeaders(h http.Header) (identity.Author, error) {
 }
 
 // HTTPHandler exposes a Dsync remote over HTTP by exposing a HTTP handler
-// that interlocks with methods exposed by httpClient
+// that interlocks with methods exposed by httpClient. Requests must be signed
+// by the private key of the sender they name
 func HTTPHandler(lsync *Logsync) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		sender, err := senderFromHTTPHeaders(r.Header)
@@ -140,12 +219,23 @@ func HTTPHandler(lsync *Logsync) http.HandlerFunc {
 
 		switch r.Method {
 		case "PUT":
-			if err := lsync.put(r.Context(), sender, r.Body); err != nil {
+			body, err := ioutil.ReadAll(r.Body)
+			r.Body.Close()
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			if err := verifyRequest(r, sender, "", body); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(err.Error()))
+				return
+			}
+			if err := lsync.put(r.Context(), sender, bytes.NewReader(body)); err != nil {
 				w.WriteHeader(http.StatusBadRequest)
 				w.Write([]byte(err.Error()))
 				return
 			}
-			r.Body.Close()
 
 			addAuthorHTTPHeaders(w.Header(), lsync.Author())
 		case "GET":
@@ -155,6 +245,11 @@ func HTTPHandler(lsync *Logsync) http.HandlerFunc {
 				w.Write([]byte(err.Error()))
 				return
 			}
+			if err := verifyRequest(r, sender, r.FormValue("ref"), nil); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(err.Error()))
+				return
+			}
 
 			receiver, r, err := lsync.get(r.Context(), sender, reporef.ConvertToDsref(ref))
 			if err != nil {
@@ -172,6 +267,11 @@ func HTTPHandler(lsync *Logsync) http.HandlerFunc {
 				w.Write([]byte(err.Error()))
 				return
 			}
+			if err := verifyRequest(r, sender, r.FormValue("ref"), nil); err != nil {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(err.Error()))
+				return
+			}
 
 			if err = lsync.del(r.Context(), sender, reporef.ConvertToDsref(ref)); err != nil {
 				w.WriteHeader(http.StatusBadRequest)
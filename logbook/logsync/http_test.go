@@ -1,10 +1,12 @@
 package logsync
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	cmp "github.com/google/go-cmp/cmp"
 	"github.com/qri-io/qri/dsref"
@@ -150,9 +152,10 @@ func TestHTTPHandlerErrors(t *testing.T) {
 		expectStatus     int
 	}{
 		{"no author fields", "GET", "", http.StatusBadRequest},
-		{"no author fields", "GET", "?ref=foo/bar", http.StatusBadRequest},
-		{"no author fields", "PUT", "", http.StatusBadRequest},
+		{"unsigned request", "GET", "?ref=foo/bar", http.StatusUnauthorized},
+		{"unsigned request", "PUT", "", http.StatusUnauthorized},
 		{"no author fields", "DELETE", "", http.StatusBadRequest},
+		{"unsigned request", "DELETE", "?ref=foo/bar", http.StatusUnauthorized},
 	}
 
 	for _, c := range cases {
@@ -168,3 +171,60 @@ func TestHTTPHandlerErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestHTTPHandlerAuthentication(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	a, b := tr.DefaultLogsyncs()
+	server := httptest.NewServer(HTTPHandler(a))
+	defer server.Close()
+
+	worldBankRef, err := writeWorldBankLogs(tr.Ctx, tr.B)
+	if err != nil {
+		t.Fatal(err)
+	}
+	push, err := b.NewPush(worldBankRef, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := push.Do(tr.Ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// a client claiming B's key, signing with A's key
+	forged := &httpClient{URL: server.URL, sign: tr.A.SignBytes}
+	if err := forged.del(tr.Ctx, tr.B.Author(), worldBankRef); err == nil {
+		t.Error("expected removing with a forged signature to fail")
+	}
+	if _, err := tr.A.Versions(tr.Ctx, worldBankRef, 0, 100); err != nil {
+		t.Errorf("expected forged removal to leave the log in place. got: %s", err)
+	}
+
+	req, err := http.NewRequest("DELETE", fmt.Sprintf("%s?ref=%s", server.URL, worldBankRef), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := addAuthorHTTPHeaders(req.Header, tr.B.Author()); err != nil {
+		t.Fatal(err)
+	}
+	timestamp := fmt.Sprintf("%d", time.Now().Add(-time.Hour).Unix())
+	sig, err := tr.B.SignBytes([]byte(requestSigningString(timestamp, "DELETE", worldBankRef.String(), nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Timestamp", timestamp)
+	req.Header.Set("Signature", base64.StdEncoding.EncodeToString(sig))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected an expired signature to be unauthorized. got status: %d", res.StatusCode)
+	}
+
+	signed := &httpClient{URL: server.URL, sign: tr.B.SignBytes}
+	if err := signed.del(tr.Ctx, tr.B.Author(), worldBankRef); err != nil {
+		t.Errorf("expected signed removal to succeed. got: %s", err)
+	}
+}
@@ -152,7 +152,7 @@ func (lsync *Logsync) DoRemove(ctx context.Context, ref dsref.Ref, remoteAddr st
 
 func (lsync *Logsync) remoteClient(ctx context.Context, remoteAddr string) (rem remote, err error) {
 	if strings.HasPrefix(remoteAddr, "http") {
-		return &httpClient{URL: remoteAddr}, nil
+		return &httpClient{URL: remoteAddr, sign: lsync.book.SignBytes}, nil
 	}
 
 	// if we're given a logbook authorId, convert it to the active public key ID
@@ -0,0 +1,372 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// errShortData indicates encoded data ended before all values were read
+var errShortData = fmt.Errorf("parquet data is truncated")
+
+// maxPrealloc caps slice capacities taken from value counts in a file, which
+// may be corrupt
+const maxPrealloc = 1 << 16
+
+// capacity gives the capacity to allocate for n values
+func capacity(n int) int {
+	if n > maxPrealloc {
+		return maxPrealloc
+	}
+	return n
+}
+
+// encodeLevels encodes definition levels of a flat optional column (bit
+// width 1) with the RLE/bit-packing hybrid encoding, using only RLE runs
+func encodeLevels(defined []bool) []byte {
+	buf := &bytes.Buffer{}
+	var hdr [binary.MaxVarintLen64]byte
+	for i := 0; i < len(defined); {
+		j := i + 1
+		for j < len(defined) && defined[j] == defined[i] {
+			j++
+		}
+		n := binary.PutUvarint(hdr[:], uint64(j-i)<<1)
+		buf.Write(hdr[:n])
+		if defined[i] {
+			buf.WriteByte(1)
+		} else {
+			buf.WriteByte(0)
+		}
+		i = j
+	}
+	return buf.Bytes()
+}
+
+// decodeHybrid decodes n values of bitWidth bits encoded with the
+// RLE/bit-packing hybrid encoding
+func decodeHybrid(data []byte, bitWidth, n int) ([]int64, error) {
+	if bitWidth < 0 || bitWidth > 32 {
+		return nil, fmt.Errorf("invalid bit width: %d", bitWidth)
+	}
+	vals := make([]int64, 0, capacity(n))
+	byteWidth := (bitWidth + 7) / 8
+	for len(vals) < n {
+		hdr, m := binary.Uvarint(data)
+		if m <= 0 {
+			return nil, errShortData
+		}
+		data = data[m:]
+
+		if hdr&1 == 0 {
+			// rle run: a count followed by one value
+			count := int(hdr >> 1)
+			if len(data) < byteWidth {
+				return nil, errShortData
+			}
+			var v int64
+			for i := 0; i < byteWidth; i++ {
+				v |= int64(data[i]) << (8 * uint(i))
+			}
+			data = data[byteWidth:]
+			for i := 0; i < count && len(vals) < n; i++ {
+				vals = append(vals, v)
+			}
+			continue
+		}
+
+		// bit-packed run: groups of 8 values
+		groups := int(hdr >> 1)
+		size := groups * bitWidth
+		if size > len(data) {
+			// the last run may be padded past the end of the data
+			size = len(data)
+		}
+		unpacked := unpack(data[:size], bitWidth, groups*8)
+		data = data[size:]
+		for _, v := range unpacked {
+			if len(vals) == n {
+				break
+			}
+			vals = append(vals, v)
+		}
+	}
+	return vals, nil
+}
+
+// unpack reads up to n little-endian bit-packed values of bitWidth bits
+func unpack(data []byte, bitWidth, n int) []int64 {
+	vals := make([]int64, 0, capacity(n))
+	if bitWidth > 56 {
+		// too wide to accumulate a byte at a time, read bit by bit
+		for i := 0; i < n && (i+1)*bitWidth <= len(data)*8; i++ {
+			var v uint64
+			for b := 0; b < bitWidth; b++ {
+				p := i*bitWidth + b
+				v |= uint64(data[p/8]>>uint(p%8)&1) << uint(b)
+			}
+			vals = append(vals, int64(v))
+		}
+		return vals
+	}
+	if bitWidth == 0 {
+		for i := 0; i < n; i++ {
+			vals = append(vals, 0)
+		}
+		return vals
+	}
+	var acc uint64
+	var accBits uint
+	for _, b := range data {
+		acc |= uint64(b) << accBits
+		accBits += 8
+		for accBits >= uint(bitWidth) && len(vals) < n {
+			vals = append(vals, int64(acc&(1<<uint(bitWidth)-1)))
+			acc >>= uint(bitWidth)
+			accBits -= uint(bitWidth)
+		}
+	}
+	return vals
+}
+
+// encodePlain encodes values of a physical type with the plain encoding.
+// values must already be converted to the go type of the physical type
+func encodePlain(typ physicalType, vals []interface{}) []byte {
+	buf := &bytes.Buffer{}
+	var b [8]byte
+	switch typ {
+	case typeBoolean:
+		packed := make([]byte, (len(vals)+7)/8)
+		for i, v := range vals {
+			if v.(bool) {
+				packed[i/8] |= 1 << uint(i%8)
+			}
+		}
+		buf.Write(packed)
+	case typeInt32:
+		for _, v := range vals {
+			binary.LittleEndian.PutUint32(b[:4], uint32(v.(int32)))
+			buf.Write(b[:4])
+		}
+	case typeInt64:
+		for _, v := range vals {
+			binary.LittleEndian.PutUint64(b[:], uint64(v.(int64)))
+			buf.Write(b[:])
+		}
+	case typeDouble:
+		for _, v := range vals {
+			binary.LittleEndian.PutUint64(b[:], math.Float64bits(v.(float64)))
+			buf.Write(b[:])
+		}
+	case typeByteArray:
+		for _, v := range vals {
+			data := v.([]byte)
+			binary.LittleEndian.PutUint32(b[:4], uint32(len(data)))
+			buf.Write(b[:4])
+			buf.Write(data)
+		}
+	}
+	return buf.Bytes()
+}
+
+// decodePlain decodes n plain-encoded values. integers are returned as int64,
+// floats as float64, and binary values as []byte
+func decodePlain(typ physicalType, length int32, data []byte, n int) ([]interface{}, error) {
+	vals := make([]interface{}, 0, capacity(n))
+	switch typ {
+	case typeBoolean:
+		if len(data) < (n+7)/8 {
+			return nil, errShortData
+		}
+		for i := 0; i < n; i++ {
+			vals = append(vals, data[i/8]&(1<<uint(i%8)) != 0)
+		}
+		return vals, nil
+	case typeByteArray:
+		for i := 0; i < n; i++ {
+			if len(data) < 4 {
+				return nil, errShortData
+			}
+			l := binary.LittleEndian.Uint32(data)
+			data = data[4:]
+			if uint64(l) > uint64(len(data)) {
+				return nil, errShortData
+			}
+			vals = append(vals, data[:l])
+			data = data[l:]
+		}
+		return vals, nil
+	}
+
+	size := fixedSize(typ, length)
+	if size <= 0 {
+		return nil, fmt.Errorf("invalid fixed length: %d", length)
+	}
+	if len(data) < n*size {
+		return nil, errShortData
+	}
+	for i := 0; i < n; i++ {
+		vals = append(vals, fixedValue(typ, data[i*size:(i+1)*size]))
+	}
+	return vals, nil
+}
+
+// fixedSize is the width in bytes of fixed-width physical types
+func fixedSize(typ physicalType, length int32) int {
+	switch typ {
+	case typeInt32, typeFloat:
+		return 4
+	case typeInt64, typeDouble:
+		return 8
+	case typeInt96:
+		return 12
+	case typeFixedLenByteArray:
+		return int(length)
+	}
+	return 0
+}
+
+func fixedValue(typ physicalType, b []byte) interface{} {
+	switch typ {
+	case typeInt32:
+		return int64(int32(binary.LittleEndian.Uint32(b)))
+	case typeInt64:
+		return int64(binary.LittleEndian.Uint64(b))
+	case typeFloat:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(b)))
+	case typeDouble:
+		return math.Float64frombits(binary.LittleEndian.Uint64(b))
+	}
+	return b
+}
+
+// decodeByteStreamSplit decodes n values whose bytes are split into one
+// stream per byte position
+func decodeByteStreamSplit(typ physicalType, length int32, data []byte, n int) ([]interface{}, error) {
+	size := fixedSize(typ, length)
+	if size <= 0 || typ == typeInt96 {
+		return nil, fmt.Errorf("byte stream split encoding isn't supported for %s", typ)
+	}
+	if len(data) < n*size {
+		return nil, errShortData
+	}
+	vals := make([]interface{}, n)
+	b := make([]byte, size)
+	for i := 0; i < n; i++ {
+		for j := 0; j < size; j++ {
+			b[j] = data[j*n+i]
+		}
+		vals[i] = fixedValue(typ, append([]byte(nil), b...))
+	}
+	return vals, nil
+}
+
+// decodeDeltaBinaryPacked decodes n integers, returning the remaining data
+func decodeDeltaBinaryPacked(data []byte, n int) ([]int64, []byte, error) {
+	blockSize, m := binary.Uvarint(data)
+	if m <= 0 {
+		return nil, nil, errShortData
+	}
+	data = data[m:]
+	miniblocks, m := binary.Uvarint(data)
+	if m <= 0 {
+		return nil, nil, errShortData
+	}
+	data = data[m:]
+	total, m := binary.Uvarint(data)
+	if m <= 0 {
+		return nil, nil, errShortData
+	}
+	data = data[m:]
+	first, m := binary.Varint(data)
+	if m <= 0 {
+		return nil, nil, errShortData
+	}
+	data = data[m:]
+	if miniblocks == 0 || blockSize == 0 || blockSize%(miniblocks*8) != 0 || blockSize > 1<<20 {
+		return nil, nil, fmt.Errorf("invalid delta encoding block size")
+	}
+	perMiniblock := int(blockSize / miniblocks)
+	if int(total) < n {
+		return nil, nil, errShortData
+	}
+
+	vals := make([]int64, 0, capacity(n))
+	if total > 0 {
+		vals = append(vals, first)
+	}
+	prev := first
+	for len(vals) < int(total) {
+		minDelta, m := binary.Varint(data)
+		if m <= 0 {
+			return nil, nil, errShortData
+		}
+		data = data[m:]
+		if len(data) < int(miniblocks) {
+			return nil, nil, errShortData
+		}
+		widths := data[:miniblocks]
+		data = data[miniblocks:]
+		for _, w := range widths {
+			if len(vals) >= int(total) {
+				break
+			}
+			size := perMiniblock * int(w) / 8
+			if len(data) < size {
+				return nil, nil, errShortData
+			}
+			for _, d := range unpack(data[:size], int(w), perMiniblock) {
+				if len(vals) >= int(total) {
+					break
+				}
+				prev += minDelta + d
+				vals = append(vals, prev)
+			}
+			data = data[size:]
+		}
+	}
+	return vals[:n], data, nil
+}
+
+// decodeDeltaLengthByteArray decodes n binary values prefixed by their
+// delta-encoded lengths
+func decodeDeltaLengthByteArray(data []byte, n int) ([]interface{}, error) {
+	lengths, data, err := decodeDeltaBinaryPacked(data, n)
+	if err != nil {
+		return nil, err
+	}
+	vals := make([]interface{}, n)
+	for i, l := range lengths {
+		if l < 0 || l > int64(len(data)) {
+			return nil, errShortData
+		}
+		vals[i] = data[:l]
+		data = data[l:]
+	}
+	return vals, nil
+}
+
+// decodeDeltaByteArray decodes n binary values stored as a shared prefix
+// length with the previous value & a suffix
+func decodeDeltaByteArray(data []byte, n int) ([]interface{}, error) {
+	prefixes, data, err := decodeDeltaBinaryPacked(data, n)
+	if err != nil {
+		return nil, err
+	}
+	suffixes, err := decodeDeltaLengthByteArray(data, n)
+	if err != nil {
+		return nil, err
+	}
+	vals := make([]interface{}, n)
+	var prev []byte
+	for i, p := range prefixes {
+		if p < 0 || p > int64(len(prev)) {
+			return nil, fmt.Errorf("invalid delta byte array prefix length")
+		}
+		v := append(append([]byte{}, prev[:p]...), suffixes[i].([]byte)...)
+		vals[i] = v
+		prev = v
+	}
+	return vals, nil
+}
@@ -0,0 +1,519 @@
+package parquet
+
+import (
+	"fmt"
+)
+
+// physicalType is how values are stored on disk
+type physicalType int32
+
+const (
+	typeBoolean physicalType = iota
+	typeInt32
+	typeInt64
+	typeInt96
+	typeFloat
+	typeDouble
+	typeByteArray
+	typeFixedLenByteArray
+)
+
+// String implements the fmt.Stringer interface
+func (t physicalType) String() string {
+	switch t {
+	case typeBoolean:
+		return "BOOLEAN"
+	case typeInt32:
+		return "INT32"
+	case typeInt64:
+		return "INT64"
+	case typeInt96:
+		return "INT96"
+	case typeFloat:
+		return "FLOAT"
+	case typeDouble:
+		return "DOUBLE"
+	case typeByteArray:
+		return "BYTE_ARRAY"
+	case typeFixedLenByteArray:
+		return "FIXED_LEN_BYTE_ARRAY"
+	}
+	return fmt.Sprintf("type(%d)", int32(t))
+}
+
+// field repetition types
+const (
+	repRequired int64 = 0
+	repOptional int64 = 1
+	repRepeated int64 = 2
+)
+
+// converted types, the predecessor of logical types
+const (
+	convUTF8            int64 = 0
+	convEnum            int64 = 4
+	convDecimal         int64 = 5
+	convDate            int64 = 6
+	convTimeMillis      int64 = 7
+	convTimeMicros      int64 = 8
+	convTimestampMillis int64 = 9
+	convTimestampMicros int64 = 10
+	convJSON            int64 = 19
+)
+
+// logical type union field ids
+const (
+	logicalString    int16 = 1
+	logicalEnum      int16 = 4
+	logicalDecimal   int16 = 5
+	logicalDate      int16 = 6
+	logicalTime      int16 = 7
+	logicalTimestamp int16 = 8
+	logicalJSON      int16 = 12
+	logicalUUID      int16 = 14
+)
+
+// time unit union field ids
+const (
+	unitMillis int16 = 1
+	unitMicros int16 = 2
+	unitNanos  int16 = 3
+)
+
+// encodings
+const (
+	encPlain                int64 = 0
+	encPlainDictionary      int64 = 2
+	encRLE                  int64 = 3
+	encBitPacked            int64 = 4
+	encDeltaBinaryPacked    int64 = 5
+	encDeltaLengthByteArray int64 = 6
+	encDeltaByteArray       int64 = 7
+	encRLEDictionary        int64 = 8
+	encByteStreamSplit      int64 = 9
+)
+
+// compression codecs
+const (
+	codecUncompressed int64 = 0
+	codecSnappy       int64 = 1
+	codecGzip         int64 = 2
+)
+
+// page types
+const (
+	pageData       int64 = 0
+	pageIndex      int64 = 1
+	pageDictionary int64 = 2
+	pageDataV2     int64 = 3
+)
+
+// kind is how a column's values are presented as go values
+type kind int
+
+const (
+	// kindPlain values are presented as their physical type: bool, int64,
+	// float64, or base64-encoded strings for binary
+	kindPlain kind = iota
+	kindString
+	kindJSON
+	kindDate
+	kindTime
+	kindTimestamp
+	kindDecimal
+	kindUUID
+)
+
+// column describes a leaf column of a flat parquet schema
+type column struct {
+	name     string
+	typ      physicalType
+	length   int32
+	optional bool
+	kind     kind
+	unit     int16
+	scale    int32
+}
+
+// encode writes a column as a thrift SchemaElement
+func (c *column) encode(e *encoder) {
+	e.elemBegin()
+	e.i32(1, int32(c.typ))
+	rep := repRequired
+	if c.optional {
+		rep = repOptional
+	}
+	e.i32(3, int32(rep))
+	e.binary(4, []byte(c.name))
+	switch c.kind {
+	case kindString:
+		e.i32(6, int32(convUTF8))
+		e.structBegin(10)
+		e.structBegin(logicalString)
+		e.structEnd()
+		e.structEnd()
+	case kindJSON:
+		e.i32(6, int32(convJSON))
+		e.structBegin(10)
+		e.structBegin(logicalJSON)
+		e.structEnd()
+		e.structEnd()
+	case kindDate:
+		e.i32(6, int32(convDate))
+		e.structBegin(10)
+		e.structBegin(logicalDate)
+		e.structEnd()
+		e.structEnd()
+	case kindTimestamp:
+		e.i32(6, int32(convTimestampMicros))
+		e.structBegin(10)
+		e.structBegin(logicalTimestamp)
+		e.bool(1, true)
+		e.structBegin(2)
+		e.structBegin(unitMicros)
+		e.structEnd()
+		e.structEnd()
+		e.structEnd()
+		e.structEnd()
+	}
+	e.structEnd()
+}
+
+// decodeColumn reads a column from a thrift SchemaElement
+func decodeColumn(s tstruct) (*column, error) {
+	c := &column{name: s.str(4)}
+	typ, ok := s.int(1)
+	if !ok {
+		return nil, fmt.Errorf("column %q: nested columns aren't supported", c.name)
+	}
+	c.typ = physicalType(typ)
+	if l, ok := s.int(2); ok {
+		c.length = int32(l)
+	}
+	rep, _ := s.int(3)
+	switch rep {
+	case repOptional:
+		c.optional = true
+	case repRepeated:
+		return nil, fmt.Errorf("column %q: repeated columns aren't supported", c.name)
+	}
+
+	if lt := s.strct(10); len(lt) > 0 {
+		switch {
+		case lt[logicalString] != nil, lt[logicalEnum] != nil:
+			c.kind = kindString
+		case lt[logicalJSON] != nil:
+			c.kind = kindJSON
+		case lt[logicalDate] != nil:
+			c.kind = kindDate
+		case lt[logicalUUID] != nil:
+			c.kind = kindUUID
+		case lt[logicalDecimal] != nil:
+			c.kind = kindDecimal
+			scale, _ := lt.strct(logicalDecimal).int(1)
+			c.scale = int32(scale)
+		case lt[logicalTime] != nil:
+			c.kind = kindTime
+			c.unit = timeUnit(lt.strct(logicalTime).strct(2))
+		case lt[logicalTimestamp] != nil:
+			c.kind = kindTimestamp
+			c.unit = timeUnit(lt.strct(logicalTimestamp).strct(2))
+		}
+	} else if conv, ok := s.int(6); ok {
+		switch conv {
+		case convUTF8, convEnum:
+			c.kind = kindString
+		case convJSON:
+			c.kind = kindJSON
+		case convDate:
+			c.kind = kindDate
+		case convDecimal:
+			c.kind = kindDecimal
+			scale, _ := s.int(7)
+			c.scale = int32(scale)
+		case convTimeMillis:
+			c.kind, c.unit = kindTime, unitMillis
+		case convTimeMicros:
+			c.kind, c.unit = kindTime, unitMicros
+		case convTimestampMillis:
+			c.kind, c.unit = kindTimestamp, unitMillis
+		case convTimestampMicros:
+			c.kind, c.unit = kindTimestamp, unitMicros
+		}
+	}
+	if c.typ == typeInt96 {
+		c.kind = kindTimestamp
+	}
+	return c, nil
+}
+
+func timeUnit(s tstruct) int16 {
+	for _, u := range []int16{unitMillis, unitMicros, unitNanos} {
+		if s[u] != nil {
+			return u
+		}
+	}
+	return unitMillis
+}
+
+// columnChunk is the location & size of a column's values in a row group
+type columnChunk struct {
+	typ              physicalType
+	codec            int64
+	numValues        int64
+	uncompressedSize int64
+	compressedSize   int64
+	dataPageOffset   int64
+	dictPageOffset   int64
+	name             string
+}
+
+// offset is where the first page of the chunk starts
+func (c *columnChunk) offset() int64 {
+	if c.dictPageOffset > 0 && c.dictPageOffset < c.dataPageOffset {
+		return c.dictPageOffset
+	}
+	return c.dataPageOffset
+}
+
+func (c *columnChunk) encode(e *encoder) {
+	e.elemBegin()
+	e.i64(2, c.dataPageOffset)
+	e.structBegin(3)
+	e.i32(1, int32(c.typ))
+	e.listBegin(2, tI32, 2)
+	e.varint(encPlain)
+	e.varint(encRLE)
+	e.listBegin(3, tBinary, 1)
+	e.binaryValue([]byte(c.name))
+	e.i32(4, int32(c.codec))
+	e.i64(5, c.numValues)
+	e.i64(6, c.uncompressedSize)
+	e.i64(7, c.compressedSize)
+	e.i64(9, c.dataPageOffset)
+	e.structEnd()
+	e.structEnd()
+}
+
+func decodeColumnChunk(s tstruct) (*columnChunk, error) {
+	md := s.strct(3)
+	if md == nil {
+		return nil, fmt.Errorf("column chunk is missing metadata")
+	}
+	if path := s.str(1); path != "" {
+		return nil, fmt.Errorf("column chunks in external files aren't supported")
+	}
+	c := &columnChunk{}
+	typ, _ := md.int(1)
+	c.typ = physicalType(typ)
+	c.codec, _ = md.int(4)
+	c.numValues, _ = md.int(5)
+	c.uncompressedSize, _ = md.int(6)
+	c.compressedSize, _ = md.int(7)
+	c.dataPageOffset, _ = md.int(9)
+	c.dictPageOffset, _ = md.int(11)
+	if c.compressedSize < 0 || c.dataPageOffset < 0 || c.dictPageOffset < 0 {
+		return nil, fmt.Errorf("invalid column chunk metadata")
+	}
+	return c, nil
+}
+
+// rowGroup is a horizontal slice of rows, stored as one chunk per column
+type rowGroup struct {
+	numRows int64
+	chunks  []*columnChunk
+}
+
+func (g *rowGroup) encode(e *encoder) {
+	e.elemBegin()
+	e.listBegin(1, tStruct, len(g.chunks))
+	var size, compressed int64
+	for _, c := range g.chunks {
+		c.encode(e)
+		size += c.uncompressedSize
+		compressed += c.compressedSize
+	}
+	e.i64(2, size)
+	e.i64(3, g.numRows)
+	if len(g.chunks) > 0 {
+		e.i64(5, g.chunks[0].offset())
+	}
+	e.i64(6, compressed)
+	e.structEnd()
+}
+
+// fileMetaData is the footer of a parquet file
+type fileMetaData struct {
+	numRows   int64
+	columns   []*column
+	rowGroups []*rowGroup
+	keyValues [][2]string
+	createdBy string
+}
+
+func (m *fileMetaData) encode() []byte {
+	e := newEncoder()
+	e.i32(1, 1)
+	e.listBegin(2, tStruct, len(m.columns)+1)
+	e.elemBegin()
+	e.binary(4, []byte("schema"))
+	e.i32(5, int32(len(m.columns)))
+	e.structEnd()
+	for _, c := range m.columns {
+		c.encode(e)
+	}
+	e.i64(3, m.numRows)
+	e.listBegin(4, tStruct, len(m.rowGroups))
+	for _, g := range m.rowGroups {
+		g.encode(e)
+	}
+	if len(m.keyValues) > 0 {
+		e.listBegin(5, tStruct, len(m.keyValues))
+		for _, kv := range m.keyValues {
+			e.elemBegin()
+			e.binary(1, []byte(kv[0]))
+			e.binary(2, []byte(kv[1]))
+			e.structEnd()
+		}
+	}
+	if m.createdBy != "" {
+		e.binary(6, []byte(m.createdBy))
+	}
+	e.buf.WriteByte(tStop)
+	return e.Bytes()
+}
+
+func decodeFileMetaData(s tstruct) (*fileMetaData, error) {
+	m := &fileMetaData{createdBy: s.str(6)}
+	m.numRows, _ = s.int(3)
+
+	elems := s.list(2)
+	if len(elems) == 0 {
+		return nil, fmt.Errorf("missing schema")
+	}
+	root, _ := elems[0].(tstruct)
+	if n, _ := root.int(5); int(n) != len(elems)-1 {
+		return nil, fmt.Errorf("nested columns aren't supported")
+	}
+	for _, el := range elems[1:] {
+		es, _ := el.(tstruct)
+		if n, _ := es.int(5); n > 0 {
+			return nil, fmt.Errorf("column %q: nested columns aren't supported", es.str(4))
+		}
+		c, err := decodeColumn(es)
+		if err != nil {
+			return nil, err
+		}
+		m.columns = append(m.columns, c)
+	}
+
+	for _, rg := range s.list(4) {
+		rgs, _ := rg.(tstruct)
+		g := &rowGroup{}
+		g.numRows, _ = rgs.int(3)
+		if g.numRows < 0 {
+			return nil, fmt.Errorf("invalid row group size: %d", g.numRows)
+		}
+		chunks := rgs.list(1)
+		if len(chunks) != len(m.columns) {
+			return nil, fmt.Errorf("row group has %d columns, expected %d", len(chunks), len(m.columns))
+		}
+		for _, ch := range chunks {
+			chs, _ := ch.(tstruct)
+			c, err := decodeColumnChunk(chs)
+			if err != nil {
+				return nil, err
+			}
+			g.chunks = append(g.chunks, c)
+		}
+		m.rowGroups = append(m.rowGroups, g)
+	}
+
+	for _, kv := range s.list(5) {
+		kvs, _ := kv.(tstruct)
+		m.keyValues = append(m.keyValues, [2]string{kvs.str(1), kvs.str(2)})
+	}
+	return m, nil
+}
+
+// keyValue gets a value from file metadata
+func (m *fileMetaData) keyValue(key string) (string, bool) {
+	for _, kv := range m.keyValues {
+		if kv[0] == key {
+			return kv[1], true
+		}
+	}
+	return "", false
+}
+
+// pageHeader precedes every page in a column chunk
+type pageHeader struct {
+	typ              int64
+	uncompressedSize int32
+	compressedSize   int32
+	numValues        int32
+	encoding         int64
+	// data page v2 fields
+	numNulls     int32
+	defLevelsLen int32
+	repLevelsLen int32
+	compressed   bool
+}
+
+func (h *pageHeader) encode() []byte {
+	e := newEncoder()
+	e.i32(1, int32(h.typ))
+	e.i32(2, h.uncompressedSize)
+	e.i32(3, h.compressedSize)
+	e.structBegin(5)
+	e.i32(1, h.numValues)
+	e.i32(2, int32(h.encoding))
+	e.i32(3, int32(encRLE))
+	e.i32(4, int32(encRLE))
+	e.structEnd()
+	e.buf.WriteByte(tStop)
+	return e.Bytes()
+}
+
+func decodePageHeader(s tstruct) (*pageHeader, error) {
+	h := &pageHeader{}
+	h.typ, _ = s.int(1)
+	usize, _ := s.int(2)
+	csize, _ := s.int(3)
+	if usize < 0 || csize < 0 {
+		return nil, fmt.Errorf("invalid page size")
+	}
+	h.uncompressedSize, h.compressedSize = int32(usize), int32(csize)
+
+	var n int64
+	switch h.typ {
+	case pageData:
+		dh := s.strct(5)
+		n, _ = dh.int(1)
+		h.encoding, _ = dh.int(2)
+	case pageDictionary:
+		dh := s.strct(7)
+		n, _ = dh.int(1)
+		h.encoding, _ = dh.int(2)
+	case pageDataV2:
+		dh := s.strct(8)
+		n, _ = dh.int(1)
+		nulls, _ := dh.int(2)
+		h.encoding, _ = dh.int(4)
+		defLen, _ := dh.int(5)
+		repLen, _ := dh.int(6)
+		h.numNulls, h.defLevelsLen, h.repLevelsLen = int32(nulls), int32(defLen), int32(repLen)
+		h.compressed = true
+		if v, ok := dh[7].(bool); ok {
+			h.compressed = v
+		}
+		if defLen < 0 || repLen < 0 || defLen+repLen > csize {
+			return nil, fmt.Errorf("invalid page level lengths")
+		}
+	}
+	if n < 0 {
+		return nil, fmt.Errorf("invalid page value count")
+	}
+	h.numValues = int32(n)
+	return h, nil
+}
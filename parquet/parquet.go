@@ -0,0 +1,74 @@
+// Package parquet reads & writes dataset bodies as Apache Parquet files.
+// Parquet is a columnar format, so only tabular bodies are supported: arrays
+// of rows, where every row is an array or an object. Nested & repeated parquet
+// columns aren't supported, nested values are stored as JSON text.
+//
+// jsonschema types map to parquet types as follows:
+//
+//	integer                     INT64
+//	number                      DOUBLE
+//	boolean                     BOOLEAN
+//	string                      BYTE_ARRAY (STRING)
+//	string, format: date        INT32 (DATE)
+//	string, format: date-time   INT64 (TIMESTAMP, microseconds, UTC)
+//	anything else               BYTE_ARRAY (JSON)
+//
+// Written files keep the dataset's schema in file metadata, so a body that's
+// exported & imported again gets its original schema back
+package parquet
+
+import (
+	"fmt"
+)
+
+var (
+	// magic starts & ends every parquet file
+	magic = []byte("PAR1")
+	// ErrNotParquet indicates data isn't a parquet file
+	ErrNotParquet = fmt.Errorf("not a parquet file")
+)
+
+// SchemaMetadataKey is the file metadata key parquet files written by qri
+// store the dataset's jsonschema under
+const SchemaMetadataKey = "qri.schema"
+
+// Options configures writing parquet files
+type Options struct {
+	// RowGroupSize is the number of rows buffered in memory & written together
+	// as a row group
+	RowGroupSize int
+	// Compress compresses pages with snappy compression
+	Compress bool
+}
+
+// DefaultRowGroupSize is the number of rows in a row group if not set by
+// options
+var DefaultRowGroupSize = 10000
+
+// OptRowGroupSize sets the number of rows in a row group
+func OptRowGroupSize(n int) func(*Options) {
+	return func(o *Options) {
+		o.RowGroupSize = n
+	}
+}
+
+// OptUncompressed disables compression of written pages
+func OptUncompressed() func(*Options) {
+	return func(o *Options) {
+		o.Compress = false
+	}
+}
+
+func newOptions(opts []func(*Options)) *Options {
+	o := &Options{
+		RowGroupSize: DefaultRowGroupSize,
+		Compress:     true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.RowGroupSize <= 0 {
+		o.RowGroupSize = DefaultRowGroupSize
+	}
+	return o
+}
@@ -0,0 +1,436 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func writeRows(t *testing.T, st *dataset.Structure, rows []interface{}, opts ...func(*Options)) []byte {
+	buf := &bytes.Buffer{}
+	w, err := NewEntryWriter(st, buf, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range rows {
+		if err := w.WriteEntry(dsio.Entry{Index: i, Value: row}); err != nil {
+			t.Fatalf("writing row %d: %s", i, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func readRows(t *testing.T, data []byte) (*dataset.Structure, []interface{}) {
+	r, err := NewEntryReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []interface{}
+	err = dsio.EachEntry(r, func(_ int, ent dsio.Entry, err error) error {
+		if err != nil {
+			return err
+		}
+		rows = append(rows, ent.Value)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(rows)) != r.NumRows() {
+		t.Errorf("read %d rows, file has %d", len(rows), r.NumRows())
+	}
+	return r.Structure(), rows
+}
+
+func TestArrayRowsRoundtrip(t *testing.T) {
+	sch := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "id", "type": "integer"},
+				map[string]interface{}{"title": "score", "type": []interface{}{"number", "null"}},
+				map[string]interface{}{"title": "ok", "type": "boolean"},
+				map[string]interface{}{"title": "name", "type": "string"},
+				map[string]interface{}{"title": "day", "type": "string", "format": "date"},
+				map[string]interface{}{"title": "ts", "type": "string", "format": "date-time"},
+				map[string]interface{}{"title": "extra"},
+			},
+		},
+	}
+	rows := []interface{}{
+		[]interface{}{int64(1), 1.5, true, "alice", "2019-01-01", "2019-01-01T10:00:00Z", map[string]interface{}{"a": int64(1)}},
+		[]interface{}{int64(2), nil, false, "bob", "2019-01-02", "2019-01-02T10:00:00.5Z", nil},
+		[]interface{}{int64(3), 3.25, true, "", nil, nil, []interface{}{"x"}},
+		[]interface{}{int64(4), float64(-4), nil, "dave", "1969-12-31", "1969-12-31T23:59:59.25Z", "text"},
+		[]interface{}{int64(5)},
+	}
+	expect := []interface{}{
+		rows[0], rows[1], rows[2], rows[3],
+		[]interface{}{int64(5), nil, nil, nil, nil, nil, nil},
+	}
+
+	for _, opts := range [][]func(*Options){
+		{OptRowGroupSize(2)},
+		{OptUncompressed()},
+	} {
+		data := writeRows(t, &dataset.Structure{Format: "json", Schema: sch}, rows, opts...)
+		st, got := readRows(t, data)
+		if diff := cmp.Diff(expect, got); diff != "" {
+			t.Errorf("rows mismatch (-want +got):\n%s", diff)
+		}
+		if diff := cmp.Diff(sch, st.Schema); diff != "" {
+			t.Errorf("schema mismatch (-want +got):\n%s", diff)
+		}
+		if st.Format != "json" {
+			t.Errorf("expected json format, got %q", st.Format)
+		}
+	}
+}
+
+func TestObjectRowsInferred(t *testing.T) {
+	rows := []interface{}{
+		map[string]interface{}{"city": "toronto", "pop": int64(40000000), "in_usa": false},
+		map[string]interface{}{"city": "new york", "pop": int64(8500000), "in_usa": true},
+		map[string]interface{}{"city": "chatham", "in_usa": false},
+	}
+	data := writeRows(t, &dataset.Structure{Format: "json"}, rows)
+	st, got := readRows(t, data)
+	if diff := cmp.Diff(rows, got); diff != "" {
+		t.Errorf("rows mismatch (-want +got):\n%s", diff)
+	}
+	cols, objectRows, ok := schemaColumns(st.Schema)
+	if !ok || !objectRows {
+		t.Fatalf("expected inferred schema of object rows, got: %v", st.Schema)
+	}
+	expect := map[string]physicalType{"city": typeByteArray, "in_usa": typeBoolean, "pop": typeInt64}
+	for _, c := range cols {
+		if expect[c.name] != c.typ {
+			t.Errorf("column %q: expected type %s, got %s", c.name, expect[c.name], c.typ)
+		}
+	}
+}
+
+func TestEmptyBody(t *testing.T) {
+	data := writeRows(t, nil, nil)
+	_, got := readRows(t, data)
+	if len(got) != 0 {
+		t.Errorf("expected no rows, got %d", len(got))
+	}
+}
+
+func TestWriterErrors(t *testing.T) {
+	if _, err := NewEntryWriter(&dataset.Structure{Schema: dataset.BaseSchemaObject}, ioutil.Discard); err == nil {
+		t.Error("expected error writing an object body")
+	}
+
+	arraySchema := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": []interface{}{map[string]interface{}{"title": "n", "type": "integer"}},
+		},
+	}
+	cases := []struct {
+		description string
+		schema      map[string]interface{}
+		rows        []interface{}
+		err         string
+	}{
+		{"scalar rows", nil, []interface{}{int64(1)}, "parquet files can only be written from bodies of arrays or objects"},
+		{"too many values", arraySchema, []interface{}{[]interface{}{int64(1), int64(2)}}, "row 0: has 2 values, schema lists 1 columns"},
+		{"object row in array body", arraySchema, []interface{}{map[string]interface{}{"n": int64(1)}}, "row 0: expected an array"},
+		{"wrong value type", arraySchema, []interface{}{[]interface{}{"one"}}, `row 0, column "n": can't write "one" as INT64`},
+		{"unknown key", nil, []interface{}{
+			map[string]interface{}{"a": int64(1)},
+			map[string]interface{}{"a": int64(2), "b": int64(3)},
+		}, `row 1: key "b" isn't a column in the schema`},
+	}
+
+	for _, c := range cases {
+		// one row per group, so columns are inferred from the first row only
+		w, err := NewEntryWriter(&dataset.Structure{Schema: c.schema}, ioutil.Discard, OptRowGroupSize(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, row := range c.rows {
+			if err = w.WriteEntry(dsio.Entry{Index: i, Value: row}); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			err = w.Close()
+		}
+		if err == nil || err.Error() != c.err {
+			t.Errorf("case %q: expected error %q, got: %v", c.description, c.err, err)
+		}
+	}
+}
+
+func TestNotParquet(t *testing.T) {
+	data := []byte(`[["a","b"],["c","d"]]`)
+	if _, err := NewEntryReader(bytes.NewReader(data), int64(len(data))); err != ErrNotParquet {
+		t.Errorf("expected ErrNotParquet, got: %v", err)
+	}
+}
+
+// testdata files are written by another parquet implementation, using
+// dictionary & delta encodings, decimals and legacy INT96 timestamps
+func TestReadForeignFiles(t *testing.T) {
+	names := []interface{}{"alpha", "beta", "gamma"}
+	expect := make([]interface{}, 100)
+	for i := range expect {
+		var name, score interface{}
+		if i%5 != 0 {
+			name = names[i%3]
+		}
+		if i%4 != 0 {
+			score = float64(i) / 4
+		}
+		expect[i] = []interface{}{
+			int64(i),
+			name,
+			score,
+			int64(i*7 - 300),
+			fmt.Sprintf("tag-%03d", i/3),
+			fmt.Sprintf("note %d", i*i),
+			i%3 == 0,
+			float64(i*125-1000) / 100,
+			fmt.Sprintf("2020-%s", dayOfYear(i)),
+			fmt.Sprintf("2020-01-01T%02d:%02d:00Z", i/60, i%60),
+			fmt.Sprintf("2020-01-%02dT%02d:00:00Z", 1+i/24, i%24),
+		}
+	}
+
+	for _, file := range []string{"testdata/snappy.parquet", "testdata/gzip.parquet"} {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		st, got := readRows(t, data)
+		if diff := cmp.Diff(expect, got); diff != "" {
+			t.Errorf("%s: rows mismatch (-want +got):\n%s", file, diff)
+		}
+		cols, _, _ := schemaColumns(st.Schema)
+		if len(cols) != 11 || cols[1].name != "name" {
+			t.Errorf("%s: unexpected schema: %v", file, st.Schema)
+		}
+	}
+}
+
+// dayOfYear formats the date i days after the first of january 2020 as MM-DD
+func dayOfYear(i int) string {
+	months := []int{31, 29, 31, 30}
+	m := 0
+	for i >= months[m] {
+		i -= months[m]
+		m++
+	}
+	return fmt.Sprintf("%02d-%02d", m+1, i+1)
+}
+
+// reference files come from the apache parquet-testing repository, written by
+// impala, parquet-mr & pyarrow
+func TestReadReferenceFiles(t *testing.T) {
+	b64 := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+	alltypes := func(id int64, day string) []interface{} {
+		return []interface{}{
+			[]interface{}{id, true, int64(0), int64(0), int64(0), int64(0), float64(0), float64(0), b64(day + "/01/09"), b64("0"), fmt.Sprintf("2009-%s-01T00:00:00Z", day)},
+			[]interface{}{id + 1, false, int64(1), int64(1), int64(1), int64(10), float64(float32(1.1)), 10.1, b64(day + "/01/09"), b64("1"), fmt.Sprintf("2009-%s-01T00:01:00Z", day)},
+		}
+	}
+	partkeys := make([]interface{}, 39)
+	for i := range partkeys {
+		partkeys[i] = []interface{}{int64(1552)}
+	}
+	decimals := make([]interface{}, 24)
+	for i := range decimals {
+		decimals[i] = []interface{}{float64(i + 1)}
+	}
+
+	cases := []struct {
+		file   string
+		expect []interface{}
+	}{
+		// impala, dictionary encoded pages
+		{"alltypes_dictionary.parquet", alltypes(0, "01")},
+		// impala, snappy compressed plain pages
+		{"alltypes_plain.snappy.parquet", alltypes(6, "04")},
+		// dremio, dictionary page at column chunk offset zero
+		{"dict-page-offset-zero.parquet", partkeys},
+		// spark, FIXED_LEN_BYTE_ARRAY decimals
+		{"fixed_length_decimal.parquet", decimals},
+	}
+	for _, c := range cases {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", c.file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, got := readRows(t, data)
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("%s: rows mismatch (-want +got):\n%s", c.file, diff)
+		}
+	}
+}
+
+func TestNestedFilesRejected(t *testing.T) {
+	files := []string{
+		// spark, list<list<list<string>>>
+		"nested_lists.snappy.parquet",
+		// spark, map<string, map<int, bool>>
+		"nested_maps.snappy.parquet",
+		// parquet-mr, lists, maps & structs
+		"nullable.impala.parquet",
+		// pyarrow, list<int64> & list<string>
+		"list_columns.parquet",
+	}
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = NewEntryReader(bytes.NewReader(data), int64(len(data)))
+		if err == nil || !strings.Contains(err.Error(), "nested columns aren't supported") {
+			t.Errorf("%s: expected nested columns error, got: %v", file, err)
+		}
+	}
+}
+
+// TestReadCorruptFiles reads damaged copies of every test file. Reads may
+// fail, but must not panic or read past the data
+func TestReadCorruptFiles(t *testing.T) {
+	files, err := filepath.Glob("testdata/*.parquet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for _, file := range files {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 1000; i++ {
+			corrupt := append([]byte(nil), data...)
+			switch i % 3 {
+			case 0:
+				// flip bytes
+				for j := 0; j < 1+rnd.Intn(8); j++ {
+					corrupt[rnd.Intn(len(corrupt))] ^= byte(1 + rnd.Intn(255))
+				}
+			case 1:
+				// overwrite a run of bytes in the footer, where offsets & sizes live
+				n := 1 + rnd.Intn(16)
+				start := len(corrupt) - 8 - rnd.Intn(len(corrupt)/4)
+				if start < 0 {
+					start = 0
+				}
+				for j := start; j < start+n && j < len(corrupt)-8; j++ {
+					corrupt[j] = byte(rnd.Intn(256))
+				}
+			case 2:
+				// truncate, keeping the trailing magic so the file still opens
+				cut := rnd.Intn(len(corrupt) - 8)
+				corrupt = append(corrupt[:cut], corrupt[len(corrupt)-8:]...)
+			}
+			readCorrupt(t, fmt.Sprintf("%s, case %d", file, i), corrupt)
+		}
+	}
+}
+
+func readCorrupt(t *testing.T, name string, data []byte) {
+	defer func() {
+		if p := recover(); p != nil {
+			t.Fatalf("%s: panic: %v", name, p)
+		}
+	}()
+	r, err := NewEntryReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return
+	}
+	dsio.EachEntry(r, func(_ int, _ dsio.Entry, err error) error { return err })
+}
+
+func TestDecodeHybrid(t *testing.T) {
+	cases := []struct {
+		data     []byte
+		bitWidth int
+		n        int
+		expect   []int64
+	}{
+		// rle run of 5 threes
+		{[]byte{10, 3}, 2, 5, []int64{3, 3, 3, 3, 3}},
+		// bit-packed run of 0..7 with width 3, from the parquet spec
+		{[]byte{3, 0x88, 0xc6, 0xfa}, 3, 8, []int64{0, 1, 2, 3, 4, 5, 6, 7}},
+		// bit-packed run padded past n, followed by an rle run
+		{[]byte{3, 0x05, 4, 1}, 1, 5, []int64{1, 0, 1, 0, 0}},
+		{[]byte{3, 0x05, 4, 1}, 1, 10, []int64{1, 0, 1, 0, 0, 0, 0, 0, 1, 1}},
+	}
+	for i, c := range cases {
+		got, err := decodeHybrid(c.data, c.bitWidth, c.n)
+		if err != nil {
+			t.Errorf("case %d: unexpected error: %s", i, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("case %d: mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+
+	if _, err := decodeHybrid([]byte{10}, 2, 5); err != errShortData {
+		t.Errorf("expected errShortData, got: %v", err)
+	}
+}
+
+func TestLevelsRoundtrip(t *testing.T) {
+	defined := []bool{true, true, false, true, false, false, false, true}
+	got, err := decodeHybrid(encodeLevels(defined), 1, len(defined))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, d := range defined {
+		if (got[i] == 1) != d {
+			t.Errorf("level %d: expected %t, got %d", i, d, got[i])
+		}
+	}
+}
+
+func TestReadFileWrittenToDisk(t *testing.T) {
+	f, err := ioutil.TempFile("", "parquet_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	rows := []interface{}{[]interface{}{"a", int64(1)}, []interface{}{"b", int64(2)}}
+	if _, err := f.Write(writeRows(t, nil, rows)); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewEntryReader(f, fi.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ent, err := r.ReadEntry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(rows[0], ent.Value); diff != "" {
+		t.Errorf("row mismatch (-want +got):\n%s", diff)
+	}
+	f.Close()
+}
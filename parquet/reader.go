@@ -0,0 +1,384 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/golang/snappy"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// maxFooterSize limits the size of file metadata that will be read
+const maxFooterSize = 64 << 20
+
+// EntryReader reads the rows of a parquet file as dataset body entries. Row
+// groups are decoded one at a time. Rows are read as arrays, unless the file
+// was written from a body of objects by an EntryWriter
+type EntryReader struct {
+	r          io.ReaderAt
+	md         *fileMetaData
+	st         *dataset.Structure
+	objectRows bool
+
+	group int
+	cols  [][]interface{}
+	row   int
+	index int
+}
+
+var _ dsio.EntryReader = (*EntryReader)(nil)
+
+// NewEntryReader reads a parquet file of size bytes
+func NewEntryReader(r io.ReaderAt, size int64) (*EntryReader, error) {
+	if size < int64(len(magic)*2+4) {
+		return nil, ErrNotParquet
+	}
+	tail := make([]byte, 8)
+	if _, err := r.ReadAt(tail, size-8); err != nil {
+		return nil, err
+	}
+	head := make([]byte, 4)
+	if _, err := r.ReadAt(head, 0); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(tail[4:], magic) || !bytes.Equal(head, magic) {
+		return nil, ErrNotParquet
+	}
+	footerSize := int64(binary.LittleEndian.Uint32(tail[:4]))
+	if footerSize > size-12 || footerSize > maxFooterSize {
+		return nil, fmt.Errorf("invalid parquet footer size: %d", footerSize)
+	}
+	footer := make([]byte, footerSize)
+	if _, err := r.ReadAt(footer, size-8-footerSize); err != nil {
+		return nil, err
+	}
+	s, err := newDecoder(bytes.NewReader(footer)).readStruct()
+	if err != nil {
+		return nil, fmt.Errorf("reading parquet metadata: %s", err)
+	}
+	md, err := decodeFileMetaData(s)
+	if err != nil {
+		return nil, fmt.Errorf("reading parquet metadata: %s", err)
+	}
+
+	pr := &EntryReader{r: r, md: md}
+	pr.st, pr.objectRows = md.structure()
+	return pr, nil
+}
+
+// structure gives the structure of a file's body, preferring a schema written
+// by qri
+func (m *fileMetaData) structure() (st *dataset.Structure, objectRows bool) {
+	st = &dataset.Structure{Format: "json"}
+	if data, ok := m.keyValue(SchemaMetadataKey); ok {
+		sch := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(data), &sch); err == nil {
+			if cols, objRows, ok := schemaColumns(sch); ok && sameNames(cols, m.columns) {
+				st.Schema = sch
+				return st, objRows
+			}
+		}
+	}
+
+	items := make([]interface{}, len(m.columns))
+	for i, c := range m.columns {
+		items[i] = c.jsonschema()
+	}
+	st.Schema = map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		},
+	}
+	return st, false
+}
+
+func sameNames(a, b []*column) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].name != b[i].name {
+			return false
+		}
+	}
+	return true
+}
+
+// Structure gives the structure of the body being read. The structure format
+// is json, the format parquet bodies are converted to when saved
+func (r *EntryReader) Structure() *dataset.Structure {
+	return r.st
+}
+
+// NumRows gives the number of rows in the file
+func (r *EntryReader) NumRows() int64 {
+	return r.md.numRows
+}
+
+// ReadEntry reads one row
+func (r *EntryReader) ReadEntry() (dsio.Entry, error) {
+	for r.cols == nil || r.row >= len(r.cols[0]) {
+		if r.group >= len(r.md.rowGroups) || len(r.md.columns) == 0 {
+			return dsio.Entry{}, io.EOF
+		}
+		if err := r.readGroup(r.md.rowGroups[r.group]); err != nil {
+			return dsio.Entry{}, err
+		}
+		r.group++
+	}
+
+	ent := dsio.Entry{Index: r.index}
+	if r.objectRows {
+		row := map[string]interface{}{}
+		for i, c := range r.md.columns {
+			if v := r.cols[i][r.row]; v != nil {
+				row[c.name] = v
+			}
+		}
+		ent.Value = row
+	} else {
+		row := make([]interface{}, len(r.cols))
+		for i := range r.cols {
+			row[i] = r.cols[i][r.row]
+		}
+		ent.Value = row
+	}
+	r.row++
+	r.index++
+	return ent, nil
+}
+
+// Close finalizes the reader
+func (r *EntryReader) Close() error {
+	r.cols = nil
+	return nil
+}
+
+// readGroup decodes every column of a row group
+func (r *EntryReader) readGroup(g *rowGroup) error {
+	cols := make([][]interface{}, len(r.md.columns))
+	for i, col := range r.md.columns {
+		vals, err := r.readChunk(col, g.chunks[i], int(g.numRows))
+		if err != nil {
+			return fmt.Errorf("column %q: %s", col.name, err)
+		}
+		cols[i] = vals
+	}
+	r.cols, r.row = cols, 0
+	return nil
+}
+
+// readChunk decodes the pages of a column chunk
+func (r *EntryReader) readChunk(col *column, ch *columnChunk, numRows int) ([]interface{}, error) {
+	if ch.compressedSize > maxFooterSize*16 {
+		return nil, fmt.Errorf("column chunk too large: %d bytes", ch.compressedSize)
+	}
+	data := make([]byte, ch.compressedSize)
+	if _, err := r.r.ReadAt(data, ch.offset()); err != nil {
+		return nil, err
+	}
+	buf := bytes.NewReader(data)
+
+	var dict []interface{}
+	vals := make([]interface{}, 0, capacity(numRows))
+	for len(vals) < numRows {
+		s, err := newDecoder(buf).readStruct()
+		if err != nil {
+			return nil, fmt.Errorf("reading page header: %s", err)
+		}
+		h, err := decodePageHeader(s)
+		if err != nil {
+			return nil, err
+		}
+		if int64(h.compressedSize) > int64(buf.Len()) {
+			return nil, errShortData
+		}
+		page := make([]byte, h.compressedSize)
+		if _, err := io.ReadFull(buf, page); err != nil {
+			return nil, err
+		}
+
+		switch h.typ {
+		case pageDictionary:
+			if page, err = decompress(ch.codec, page, h.uncompressedSize); err != nil {
+				return nil, err
+			}
+			if dict, err = decodePlain(col.typ, col.length, page, int(h.numValues)); err != nil {
+				return nil, err
+			}
+		case pageData:
+			if page, err = decompress(ch.codec, page, h.uncompressedSize); err != nil {
+				return nil, err
+			}
+			if vals, err = readDataPage(col, h, page, dict, vals); err != nil {
+				return nil, err
+			}
+		case pageDataV2:
+			levels := page[:h.defLevelsLen+h.repLevelsLen]
+			body := page[len(levels):]
+			if h.compressed {
+				if body, err = decompress(ch.codec, body, h.uncompressedSize-int32(len(levels))); err != nil {
+					return nil, err
+				}
+			}
+			if vals, err = readDataPageV2(col, h, levels[h.repLevelsLen:], body, dict, vals); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if len(vals) != numRows {
+		return nil, fmt.Errorf("expected %d values, read %d", numRows, len(vals))
+	}
+	return vals, nil
+}
+
+// readDataPage decodes a data page, appending values to vals
+func readDataPage(col *column, h *pageHeader, page []byte, dict, vals []interface{}) ([]interface{}, error) {
+	n := int(h.numValues)
+	var levels []int64
+	if col.optional {
+		if len(page) < 4 {
+			return nil, errShortData
+		}
+		l := binary.LittleEndian.Uint32(page)
+		if uint64(l) > uint64(len(page)-4) {
+			return nil, errShortData
+		}
+		var err error
+		if levels, err = decodeHybrid(page[4:4+l], 1, n); err != nil {
+			return nil, err
+		}
+		page = page[4+l:]
+	}
+	return appendValues(col, h.encoding, levels, page, n, dict, vals)
+}
+
+// readDataPageV2 decodes a version 2 data page, appending values to vals
+func readDataPageV2(col *column, h *pageHeader, defLevels, page []byte, dict, vals []interface{}) ([]interface{}, error) {
+	n := int(h.numValues)
+	var levels []int64
+	if col.optional {
+		var err error
+		if levels, err = decodeHybrid(defLevels, 1, n); err != nil {
+			return nil, err
+		}
+	}
+	return appendValues(col, h.encoding, levels, page, n, dict, vals)
+}
+
+// appendValues decodes the values of a page & appends them to vals, using
+// definition levels to place nulls
+func appendValues(col *column, encoding int64, levels []int64, data []byte, n int, dict, vals []interface{}) ([]interface{}, error) {
+	defined := n
+	if levels != nil {
+		defined = 0
+		for _, l := range levels {
+			if l == 1 {
+				defined++
+			}
+		}
+	}
+
+	var raw []interface{}
+	var err error
+	switch encoding {
+	case encPlain:
+		raw, err = decodePlain(col.typ, col.length, data, defined)
+	case encPlainDictionary, encRLEDictionary:
+		if dict == nil {
+			return nil, fmt.Errorf("dictionary encoded page without a dictionary")
+		}
+		if len(data) < 1 {
+			return nil, errShortData
+		}
+		var idx []int64
+		if idx, err = decodeHybrid(data[1:], int(data[0]), defined); err != nil {
+			return nil, err
+		}
+		raw = make([]interface{}, len(idx))
+		for i, x := range idx {
+			if x < 0 || x >= int64(len(dict)) {
+				return nil, fmt.Errorf("dictionary index out of range")
+			}
+			raw[i] = dict[x]
+		}
+	case encRLE:
+		if col.typ != typeBoolean {
+			return nil, fmt.Errorf("RLE encoding is only supported for booleans")
+		}
+		if len(data) < 4 {
+			return nil, errShortData
+		}
+		var bs []int64
+		if bs, err = decodeHybrid(data[4:], 1, defined); err != nil {
+			return nil, err
+		}
+		raw = make([]interface{}, len(bs))
+		for i, b := range bs {
+			raw[i] = b == 1
+		}
+	case encDeltaBinaryPacked:
+		var ints []int64
+		if ints, _, err = decodeDeltaBinaryPacked(data, defined); err != nil {
+			return nil, err
+		}
+		raw = make([]interface{}, len(ints))
+		for i, x := range ints {
+			raw[i] = x
+		}
+	case encDeltaLengthByteArray:
+		raw, err = decodeDeltaLengthByteArray(data, defined)
+	case encDeltaByteArray:
+		raw, err = decodeDeltaByteArray(data, defined)
+	case encByteStreamSplit:
+		raw, err = decodeByteStreamSplit(col.typ, col.length, data, defined)
+	default:
+		return nil, fmt.Errorf("unsupported encoding: %d", encoding)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	next := 0
+	for i := 0; i < n; i++ {
+		if levels != nil && levels[i] != 1 {
+			vals = append(vals, nil)
+			continue
+		}
+		v, err := col.value(raw[next])
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, v)
+		next++
+	}
+	return vals, nil
+}
+
+// decompress decodes page data compressed with a codec
+func decompress(codec int64, data []byte, size int32) ([]byte, error) {
+	switch codec {
+	case codecUncompressed:
+		return data, nil
+	case codecSnappy:
+		if n, err := snappy.DecodedLen(data); err != nil || n != int(size) {
+			return nil, fmt.Errorf("invalid snappy page size")
+		}
+		return snappy.Decode(make([]byte, 0, size), data)
+	case codecGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(io.LimitReader(r, int64(size)))
+	}
+	return nil, fmt.Errorf("unsupported compression codec: %d", codec)
+}
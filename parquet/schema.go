@@ -0,0 +1,341 @@
+package parquet
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// julianUnixEpoch is the julian day number of 1970-01-01, used to decode
+// legacy INT96 timestamps
+const julianUnixEpoch = 2440588
+
+// schemaColumns maps the row schema of a tabular jsonschema to parquet
+// columns. Array rows map each item to a column in order, object rows map
+// properties to columns sorted by name. ok is false if the schema doesn't
+// list columns
+func schemaColumns(sch map[string]interface{}) (cols []*column, objectRows, ok bool) {
+	if typ, _ := sch["type"].(string); typ != "array" {
+		return nil, false, false
+	}
+	row, _ := sch["items"].(map[string]interface{})
+	switch row["type"] {
+	case "array":
+		items, _ := row["items"].([]interface{})
+		if len(items) == 0 {
+			return nil, false, false
+		}
+		for i, it := range items {
+			col, _ := it.(map[string]interface{})
+			name, _ := col["title"].(string)
+			if name == "" {
+				name = fmt.Sprintf("field_%d", i+1)
+			}
+			cols = append(cols, schemaColumn(name, col))
+		}
+		return cols, false, true
+	case "object":
+		props, _ := row["properties"].(map[string]interface{})
+		if len(props) == 0 {
+			return nil, true, false
+		}
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			col, _ := props[name].(map[string]interface{})
+			cols = append(cols, schemaColumn(name, col))
+		}
+		return cols, true, true
+	}
+	return nil, false, false
+}
+
+// schemaColumn picks the parquet type of a column from its jsonschema.
+// Columns are always optional, values that are missing or null are written as
+// nulls
+func schemaColumn(name string, sch map[string]interface{}) *column {
+	c := &column{name: name, optional: true, typ: typeByteArray, kind: kindJSON}
+
+	var types []string
+	switch t := sch["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				types = append(types, s)
+			}
+		}
+	}
+	if len(types) != 1 {
+		return c
+	}
+
+	switch types[0] {
+	case "integer":
+		c.typ, c.kind = typeInt64, kindPlain
+	case "number":
+		c.typ, c.kind = typeDouble, kindPlain
+	case "boolean":
+		c.typ, c.kind = typeBoolean, kindPlain
+	case "string":
+		switch sch["format"] {
+		case "date":
+			c.typ, c.kind = typeInt32, kindDate
+		case "date-time":
+			c.typ, c.kind, c.unit = typeInt64, kindTimestamp, unitMicros
+		default:
+			c.kind = kindString
+		}
+	}
+	return c
+}
+
+// jsonschema gives the schema of values read from a column
+func (c *column) jsonschema() map[string]interface{} {
+	sch := map[string]interface{}{"title": c.name}
+	typ := ""
+	switch c.kind {
+	case kindPlain:
+		switch c.typ {
+		case typeBoolean:
+			typ = "boolean"
+		case typeInt32, typeInt64:
+			typ = "integer"
+		case typeFloat, typeDouble:
+			typ = "number"
+		default:
+			typ = "string"
+			sch["contentEncoding"] = "base64"
+		}
+	case kindString, kindUUID:
+		typ = "string"
+	case kindDate:
+		typ = "string"
+		sch["format"] = "date"
+	case kindTime:
+		typ = "string"
+		sch["format"] = "time"
+	case kindTimestamp:
+		typ = "string"
+		sch["format"] = "date-time"
+	case kindDecimal:
+		typ = "number"
+	case kindJSON:
+		return sch
+	}
+	if c.optional {
+		sch["type"] = []interface{}{typ, "null"}
+	} else {
+		sch["type"] = typ
+	}
+	return sch
+}
+
+// value converts a decoded physical value to the go value of the column
+func (c *column) value(v interface{}) (interface{}, error) {
+	switch c.kind {
+	case kindString:
+		if b, ok := v.([]byte); ok {
+			return string(b), nil
+		}
+	case kindJSON:
+		if b, ok := v.([]byte); ok {
+			var val interface{}
+			if err := json.Unmarshal(b, &val); err != nil {
+				return nil, fmt.Errorf("column %q: invalid JSON value: %s", c.name, err)
+			}
+			return jsonNumbers(val), nil
+		}
+	case kindDate:
+		if days, ok := v.(int64); ok {
+			return time.Unix(days*86400, 0).UTC().Format("2006-01-02"), nil
+		}
+	case kindTime:
+		if n, ok := v.(int64); ok {
+			return unixTime(n, c.unit).UTC().Format("15:04:05.999999999"), nil
+		}
+	case kindTimestamp:
+		switch n := v.(type) {
+		case int64:
+			return unixTime(n, c.unit).UTC().Format(time.RFC3339Nano), nil
+		case []byte:
+			if len(n) == 12 {
+				nanos := int64(binary.LittleEndian.Uint64(n[:8]))
+				days := int64(binary.LittleEndian.Uint32(n[8:])) - julianUnixEpoch
+				return time.Unix(days*86400, nanos).UTC().Format(time.RFC3339Nano), nil
+			}
+		}
+	case kindDecimal:
+		return decimal(v, c.scale), nil
+	case kindUUID:
+		if b, ok := v.([]byte); ok && len(b) == 16 {
+			return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+		}
+	}
+	if b, ok := v.([]byte); ok {
+		return base64.StdEncoding.EncodeToString(b), nil
+	}
+	return v, nil
+}
+
+// unixTime converts a count of time units since the unix epoch to a time
+func unixTime(n int64, unit int16) time.Time {
+	switch unit {
+	case unitMicros:
+		return time.Unix(n/1e6, n%1e6*1e3)
+	case unitNanos:
+		return time.Unix(0, n)
+	}
+	return time.Unix(n/1e3, n%1e3*1e6)
+}
+
+// decimal converts an unscaled decimal value to a float
+func decimal(v interface{}, scale int32) interface{} {
+	var unscaled *big.Int
+	switch n := v.(type) {
+	case int64:
+		unscaled = big.NewInt(n)
+	case []byte:
+		// big-endian two's complement
+		unscaled = new(big.Int).SetBytes(n)
+		if len(n) > 0 && n[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(n)*8)))
+		}
+	default:
+		return v
+	}
+	f, _ := new(big.Float).SetInt(unscaled).Float64()
+	return f / math.Pow10(int(scale))
+}
+
+// jsonNumbers converts whole numbers decoded from JSON to int64, matching the
+// values produced by dataset readers
+func jsonNumbers(v interface{}) interface{} {
+	switch x := v.(type) {
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1<<53 {
+			return int64(x)
+		}
+	case []interface{}:
+		for i := range x {
+			x[i] = jsonNumbers(x[i])
+		}
+	case map[string]interface{}:
+		for k := range x {
+			x[k] = jsonNumbers(x[k])
+		}
+	}
+	return v
+}
+
+// physical converts a go value to the physical value written for the column.
+// ok is false for null values
+func (c *column) physical(v interface{}) (val interface{}, ok bool, err error) {
+	if v == nil {
+		return nil, false, nil
+	}
+	if s, isStr := v.(string); isStr && s == "" && c.kind != kindString && c.kind != kindJSON {
+		// empty cells of tabular formats are nulls
+		return nil, false, nil
+	}
+
+	switch c.kind {
+	case kindString:
+		switch x := v.(type) {
+		case string:
+			return []byte(x), true, nil
+		case map[string]interface{}, []interface{}:
+			data, err := json.Marshal(x)
+			return data, err == nil, err
+		}
+		return []byte(fmt.Sprint(v)), true, nil
+	case kindJSON:
+		data, err := json.Marshal(v)
+		return data, err == nil, err
+	case kindDate:
+		t, err := parseTime(v)
+		if err != nil {
+			return nil, false, err
+		}
+		days := int32(math.Floor(float64(t.Unix()) / 86400))
+		return days, true, nil
+	case kindTimestamp:
+		t, err := parseTime(v)
+		if err != nil {
+			return nil, false, err
+		}
+		return t.Unix()*1e6 + int64(t.Nanosecond()/1e3), true, nil
+	}
+
+	switch c.typ {
+	case typeInt64:
+		switch x := v.(type) {
+		case int:
+			return int64(x), true, nil
+		case int32:
+			return int64(x), true, nil
+		case int64:
+			return x, true, nil
+		case float64:
+			if x == math.Trunc(x) {
+				return int64(x), true, nil
+			}
+		case string:
+			if i, err := strconv.ParseInt(x, 10, 64); err == nil {
+				return i, true, nil
+			}
+		}
+	case typeDouble:
+		switch x := v.(type) {
+		case int:
+			return float64(x), true, nil
+		case int32:
+			return float64(x), true, nil
+		case int64:
+			return float64(x), true, nil
+		case float32:
+			return float64(x), true, nil
+		case float64:
+			return x, true, nil
+		case string:
+			if f, err := strconv.ParseFloat(x, 64); err == nil {
+				return f, true, nil
+			}
+		}
+	case typeBoolean:
+		switch x := v.(type) {
+		case bool:
+			return x, true, nil
+		case string:
+			if b, err := strconv.ParseBool(x); err == nil {
+				return b, true, nil
+			}
+		}
+	}
+	return nil, false, fmt.Errorf("can't write %#v as %s", v, c.typ)
+}
+
+// parseTime reads dates & date-times
+func parseTime(v interface{}) (time.Time, error) {
+	switch x := v.(type) {
+	case time.Time:
+		return x, nil
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+			if t, err := time.Parse(layout, x); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("can't write %#v as a date", v)
+}
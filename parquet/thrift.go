@@ -0,0 +1,323 @@
+package parquet
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// parquet metadata is serialized with the thrift compact protocol. This file
+// implements the subset of the protocol parquet needs: structs, lists,
+// integers, doubles, booleans & binary
+
+// thrift compact protocol type ids
+const (
+	tStop      byte = 0
+	tBoolTrue  byte = 1
+	tBoolFalse byte = 2
+	tByte      byte = 3
+	tI16       byte = 4
+	tI32       byte = 5
+	tI64       byte = 6
+	tDouble    byte = 7
+	tBinary    byte = 8
+	tList      byte = 9
+	tSet       byte = 10
+	tMap       byte = 11
+	tStruct    byte = 12
+)
+
+const (
+	// maxTDepth limits struct nesting when decoding
+	maxTDepth = 64
+	// maxTElement limits the size of decoded binary values & lists
+	maxTElement = 1 << 24
+)
+
+// encoder writes thrift compact protocol structs. Fields must be written in
+// increasing id order within a struct
+type encoder struct {
+	buf  bytes.Buffer
+	last []int16
+}
+
+func newEncoder() *encoder {
+	return &encoder{last: []int16{0}}
+}
+
+func (e *encoder) Bytes() []byte { return e.buf.Bytes() }
+
+func (e *encoder) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	e.buf.Write(b[:n])
+}
+
+func (e *encoder) varint(v int64) {
+	e.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (e *encoder) fieldHeader(id int16, typ byte) {
+	last := e.last[len(e.last)-1]
+	if delta := id - last; delta > 0 && delta <= 15 {
+		e.buf.WriteByte(byte(delta<<4) | typ)
+	} else {
+		e.buf.WriteByte(typ)
+		e.varint(int64(id))
+	}
+	e.last[len(e.last)-1] = id
+}
+
+func (e *encoder) bool(id int16, v bool) {
+	if v {
+		e.fieldHeader(id, tBoolTrue)
+	} else {
+		e.fieldHeader(id, tBoolFalse)
+	}
+}
+
+func (e *encoder) i32(id int16, v int32) {
+	e.fieldHeader(id, tI32)
+	e.varint(int64(v))
+}
+
+func (e *encoder) i64(id int16, v int64) {
+	e.fieldHeader(id, tI64)
+	e.varint(v)
+}
+
+func (e *encoder) binary(id int16, v []byte) {
+	e.fieldHeader(id, tBinary)
+	e.binaryValue(v)
+}
+
+func (e *encoder) binaryValue(v []byte) {
+	e.uvarint(uint64(len(v)))
+	e.buf.Write(v)
+}
+
+// structBegin starts a struct field, which must be ended with structEnd
+func (e *encoder) structBegin(id int16) {
+	e.fieldHeader(id, tStruct)
+	e.elemBegin()
+}
+
+// elemBegin starts a struct that is a list element
+func (e *encoder) elemBegin() {
+	e.last = append(e.last, 0)
+}
+
+func (e *encoder) structEnd() {
+	e.buf.WriteByte(tStop)
+	e.last = e.last[:len(e.last)-1]
+}
+
+// listBegin writes a list field header for n elements of elemType. elements
+// follow as values: elemBegin/structEnd for structs, varint for integers,
+// binaryValue for binary
+func (e *encoder) listBegin(id int16, elemType byte, n int) {
+	e.fieldHeader(id, tList)
+	if n < 15 {
+		e.buf.WriteByte(byte(n<<4) | elemType)
+	} else {
+		e.buf.WriteByte(0xf0 | elemType)
+		e.uvarint(uint64(n))
+	}
+}
+
+// tstruct is a decoded thrift struct, mapping field ids to values. values are
+// bool, int64, float64, []byte, []interface{} or tstruct
+type tstruct map[int16]interface{}
+
+func (s tstruct) int(id int16) (int64, bool) {
+	v, ok := s[id].(int64)
+	return v, ok
+}
+
+func (s tstruct) bool(id int16) bool {
+	v, _ := s[id].(bool)
+	return v
+}
+
+func (s tstruct) str(id int16) string {
+	v, _ := s[id].([]byte)
+	return string(v)
+}
+
+func (s tstruct) strct(id int16) tstruct {
+	v, _ := s[id].(tstruct)
+	return v
+}
+
+func (s tstruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+// decoder reads thrift compact protocol structs
+type decoder struct {
+	r     io.ByteReader
+	depth int
+}
+
+func newDecoder(r io.Reader) *decoder {
+	if br, ok := r.(io.ByteReader); ok {
+		return &decoder{r: br}
+	}
+	return &decoder{r: bufio.NewReader(r)}
+}
+
+func (d *decoder) readStruct() (tstruct, error) {
+	d.depth++
+	defer func() { d.depth-- }()
+	if d.depth > maxTDepth {
+		return nil, fmt.Errorf("thrift struct nested too deeply")
+	}
+
+	s := tstruct{}
+	var last int16
+	for {
+		b, err := d.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		typ := b & 0x0f
+		if typ == tStop {
+			return s, nil
+		}
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			v, err := d.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		last = id
+
+		var val interface{}
+		switch typ {
+		case tBoolTrue:
+			val = true
+		case tBoolFalse:
+			val = false
+		default:
+			if val, err = d.readValue(typ); err != nil {
+				return nil, err
+			}
+		}
+		s[id] = val
+	}
+}
+
+func (d *decoder) readValue(typ byte) (interface{}, error) {
+	switch typ {
+	case tByte:
+		b, err := d.r.ReadByte()
+		return int64(int8(b)), err
+	case tI16, tI32, tI64:
+		return d.varint()
+	case tDouble:
+		var b [8]byte
+		for i := range b {
+			c, err := d.r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			b[i] = c
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b[:])), nil
+	case tBinary:
+		n, err := binary.ReadUvarint(d.r)
+		if err != nil {
+			return nil, err
+		}
+		if n > maxTElement {
+			return nil, fmt.Errorf("thrift binary too long: %d bytes", n)
+		}
+		b := make([]byte, n)
+		for i := range b {
+			if b[i], err = d.r.ReadByte(); err != nil {
+				return nil, err
+			}
+		}
+		return b, nil
+	case tList, tSet:
+		return d.readList()
+	case tMap:
+		return nil, d.skipMap()
+	case tStruct:
+		return d.readStruct()
+	}
+	return nil, fmt.Errorf("unknown thrift type: %d", typ)
+}
+
+func (d *decoder) readList() ([]interface{}, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	n := uint64(b >> 4)
+	typ := b & 0x0f
+	if n == 15 {
+		if n, err = binary.ReadUvarint(d.r); err != nil {
+			return nil, err
+		}
+	}
+	if n > maxTElement {
+		return nil, fmt.Errorf("thrift list too long: %d elements", n)
+	}
+	list := make([]interface{}, n)
+	for i := range list {
+		if typ == tBoolTrue || typ == tBoolFalse {
+			c, err := d.r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			list[i] = c == tBoolTrue
+			continue
+		}
+		if list[i], err = d.readValue(typ); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// skipMap reads past a map. parquet metadata doesn't use maps, but they're
+// valid in fields added by future versions of the format
+func (d *decoder) skipMap() error {
+	n, err := binary.ReadUvarint(d.r)
+	if err != nil || n == 0 {
+		return err
+	}
+	types, err := d.r.ReadByte()
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < n; i++ {
+		for _, typ := range []byte{types >> 4, types & 0x0f} {
+			if typ == tBoolTrue || typ == tBoolFalse {
+				if _, err := d.r.ReadByte(); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := d.readValue(typ); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *decoder) varint() (int64, error) {
+	u, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return 0, err
+	}
+	return int64(u>>1) ^ -int64(u&1), nil
+}
@@ -0,0 +1,305 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/golang/snappy"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qri/stats"
+	"github.com/qri-io/qri/version"
+)
+
+// EntryWriter writes dataset body entries as a parquet file. Rows are buffered
+// in memory & written a row group at a time. If the structure schema doesn't
+// list columns, columns are inferred from the rows of the first row group
+type EntryWriter struct {
+	st   *dataset.Structure
+	w    *countWriter
+	opts *Options
+
+	cols       []*column
+	objectRows bool
+	schema     map[string]interface{}
+
+	buf    []dsio.Entry
+	groups []*rowGroup
+	rows   int64
+	err    error
+}
+
+var _ dsio.EntryWriter = (*EntryWriter)(nil)
+
+// NewEntryWriter creates a parquet EntryWriter. Only array bodies can be
+// written
+func NewEntryWriter(st *dataset.Structure, w io.Writer, opts ...func(*Options)) (*EntryWriter, error) {
+	if st == nil {
+		st = &dataset.Structure{}
+	}
+	if typ, _ := st.Schema["type"].(string); st.Schema != nil && typ != "array" {
+		return nil, fmt.Errorf("parquet files can only be written from array bodies")
+	}
+	pw := &EntryWriter{
+		st:     st,
+		w:      &countWriter{w: w},
+		opts:   newOptions(opts),
+		schema: st.Schema,
+	}
+	pw.cols, pw.objectRows, _ = schemaColumns(st.Schema)
+	return pw, nil
+}
+
+// Structure gives the structure being written
+func (w *EntryWriter) Structure() *dataset.Structure {
+	return w.st
+}
+
+// WriteEntry buffers a row, writing a row group once enough rows are buffered
+func (w *EntryWriter) WriteEntry(ent dsio.Entry) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.buf = append(w.buf, ent)
+	if len(w.buf) >= w.opts.RowGroupSize {
+		w.err = w.flush()
+	}
+	return w.err
+}
+
+// Close writes any buffered rows & the file footer. Close doesn't close the
+// underlying writer
+func (w *EntryWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) > 0 || w.w.n == 0 {
+		if w.err = w.flush(); w.err != nil {
+			return w.err
+		}
+	}
+
+	md := &fileMetaData{
+		numRows:   w.rows,
+		columns:   w.cols,
+		rowGroups: w.groups,
+		createdBy: fmt.Sprintf("qri version %s", version.String),
+	}
+	if w.schema != nil {
+		data, err := json.Marshal(w.schema)
+		if err != nil {
+			return err
+		}
+		md.keyValues = [][2]string{{SchemaMetadataKey, string(data)}}
+	}
+
+	footer := md.encode()
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(footer)))
+	for _, b := range [][]byte{footer, size[:], magic} {
+		if _, err := w.w.Write(b); err != nil {
+			w.err = err
+			return err
+		}
+	}
+	w.err = fmt.Errorf("writer is closed")
+	return nil
+}
+
+// flush writes buffered rows as a row group
+func (w *EntryWriter) flush() error {
+	if w.w.n == 0 {
+		if _, err := w.w.Write(magic); err != nil {
+			return err
+		}
+	}
+	if w.cols == nil && len(w.buf) > 0 {
+		if err := w.inferColumns(); err != nil {
+			return err
+		}
+	}
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	rows, err := w.cells()
+	if err != nil {
+		return err
+	}
+	g := &rowGroup{numRows: int64(len(rows))}
+	for i, col := range w.cols {
+		chunk, err := w.writeChunk(col, i, rows)
+		if err != nil {
+			return err
+		}
+		g.chunks = append(g.chunks, chunk)
+	}
+	w.groups = append(w.groups, g)
+	w.rows += g.numRows
+	w.buf = w.buf[:0]
+	return nil
+}
+
+// inferColumns picks columns from the buffered rows when the schema doesn't
+// list them
+func (w *EntryWriter) inferColumns() error {
+	if _, ok := w.buf[0].Value.(map[string]interface{}); ok {
+		w.objectRows = true
+	} else if _, ok := w.buf[0].Value.([]interface{}); !ok {
+		return fmt.Errorf("parquet files can only be written from bodies of arrays or objects")
+	}
+
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	acc := stats.NewAccumulator(&sliceReader{st: st, ents: w.buf})
+	if err := dsio.EachEntry(acc, func(int, dsio.Entry, error) error { return nil }); err != nil {
+		return err
+	}
+	if err := acc.Close(); err != nil {
+		return err
+	}
+	w.schema = acc.Schema()
+	cols, objectRows, ok := schemaColumns(w.schema)
+	if !ok || objectRows != w.objectRows {
+		return fmt.Errorf("parquet files require rows with the same shape. add a schema that lists columns")
+	}
+	w.cols = cols
+	return nil
+}
+
+// cells splits buffered rows into one value per column
+func (w *EntryWriter) cells() ([][]interface{}, error) {
+	rows := make([][]interface{}, len(w.buf))
+	for i, ent := range w.buf {
+		row := make([]interface{}, len(w.cols))
+		switch v := ent.Value.(type) {
+		case []interface{}:
+			if w.objectRows {
+				return nil, fmt.Errorf("row %d: expected an object", w.rows+int64(i))
+			}
+			if len(v) > len(w.cols) {
+				return nil, fmt.Errorf("row %d: has %d values, schema lists %d columns", w.rows+int64(i), len(v), len(w.cols))
+			}
+			copy(row, v)
+		case map[string]interface{}:
+			if !w.objectRows {
+				return nil, fmt.Errorf("row %d: expected an array", w.rows+int64(i))
+			}
+			found := 0
+			for j, col := range w.cols {
+				if val, ok := v[col.name]; ok {
+					row[j] = val
+					found++
+				}
+			}
+			if found != len(v) {
+				for key := range v {
+					if w.column(key) < 0 {
+						return nil, fmt.Errorf("row %d: key %q isn't a column in the schema", w.rows+int64(i), key)
+					}
+				}
+			}
+		default:
+			return nil, fmt.Errorf("row %d: expected an array or object", w.rows+int64(i))
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+func (w *EntryWriter) column(name string) int {
+	for i, c := range w.cols {
+		if c.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// writeChunk writes one column of a row group as a single data page
+func (w *EntryWriter) writeChunk(col *column, i int, rows [][]interface{}) (*columnChunk, error) {
+	defined := make([]bool, len(rows))
+	vals := make([]interface{}, 0, len(rows))
+	for r, row := range rows {
+		v, ok, err := col.physical(row[i])
+		if err != nil {
+			return nil, fmt.Errorf("row %d, column %q: %s", w.rows+int64(r), col.name, err)
+		}
+		if ok {
+			vals = append(vals, v)
+			defined[r] = true
+		} else if !col.optional {
+			return nil, fmt.Errorf("row %d, column %q: value is required", w.rows+int64(r), col.name)
+		}
+	}
+
+	var page []byte
+	if col.optional {
+		levels := encodeLevels(defined)
+		page = make([]byte, 4, 4+len(levels))
+		binary.LittleEndian.PutUint32(page, uint32(len(levels)))
+		page = append(page, levels...)
+	}
+	page = append(page, encodePlain(col.typ, vals)...)
+
+	codec, body := codecUncompressed, page
+	if w.opts.Compress {
+		codec, body = codecSnappy, snappy.Encode(nil, page)
+	}
+	header := (&pageHeader{
+		typ:              pageData,
+		uncompressedSize: int32(len(page)),
+		compressedSize:   int32(len(body)),
+		numValues:        int32(len(rows)),
+		encoding:         encPlain,
+	}).encode()
+
+	chunk := &columnChunk{
+		name:             col.name,
+		typ:              col.typ,
+		codec:            codec,
+		numValues:        int64(len(rows)),
+		uncompressedSize: int64(len(header) + len(page)),
+		compressedSize:   int64(len(header) + len(body)),
+		dataPageOffset:   w.w.n,
+	}
+	for _, b := range [][]byte{header, body} {
+		if _, err := w.w.Write(b); err != nil {
+			return nil, err
+		}
+	}
+	return chunk, nil
+}
+
+// countWriter tracks the number of bytes written, which parquet metadata uses
+// as offsets
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// sliceReader reads entries from a slice
+type sliceReader struct {
+	st   *dataset.Structure
+	ents []dsio.Entry
+	i    int
+}
+
+func (r *sliceReader) Structure() *dataset.Structure { return r.st }
+
+func (r *sliceReader) ReadEntry() (dsio.Entry, error) {
+	if r.i >= len(r.ents) {
+		return dsio.Entry{}, io.EOF
+	}
+	r.i++
+	return r.ents[r.i-1], nil
+}
+
+func (r *sliceReader) Close() error { return nil }
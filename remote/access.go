@@ -0,0 +1,138 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/identity"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/logbook/logsync"
+	"github.com/qri-io/qri/logbook/oplog"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// Remotes enforce the access control lists kept in dataset logs. Dataset
+// owners can do anything with their datasets. Other profiles need to be
+// granted permissions by the owner:
+//   * read: pull logs & versions. datasets that haven't granted read access
+//     to anyone are readable by everyone
+//   * write: push logs & the new versions they add
+//   * publish: remove versions
+//
+// Access checks run before any user-supplied hooks
+
+// logPushAccessCheck wraps a log push hook, rejecting logs that change
+// datasets the sender doesn't have write access to
+func (r *Remote) logPushAccessCheck(h Hook) logsync.Hook {
+	hook := r.logHook(h)
+	return func(ctx context.Context, author identity.Author, ref dsref.Ref, l *oplog.Log) error {
+		if l != nil {
+			kid, err := identity.KeyIDFromPub(author.AuthorPubKey())
+			if err != nil {
+				return err
+			}
+			if err := r.node.Repo.Logbook().CheckPushAccess(ctx, kid, l); err != nil {
+				log.Debugf("rejecting log push from %s: %s", kid, err)
+				return err
+			}
+		}
+		return hook(ctx, author, ref, l)
+	}
+}
+
+// logPullAccessCheck wraps a log pull hook, rejecting requests for logs the
+// requester doesn't have read access to
+func (r *Remote) logPullAccessCheck(h Hook) logsync.Hook {
+	hook := r.logHook(h)
+	return func(ctx context.Context, author identity.Author, ref dsref.Ref, l *oplog.Log) error {
+		kid, err := identity.KeyIDFromPub(author.AuthorPubKey())
+		if err != nil {
+			return err
+		}
+		if err := r.checkDatasetAccess(ctx, kid, ref, logbook.PermissionRead); err != nil {
+			return err
+		}
+		return hook(ctx, author, ref, l)
+	}
+}
+
+// checkDatasetPush confirms a profile can push a dataset version. Owners can
+// always push. Collaborators need write access, and can only push versions
+// that are already in the dataset log. This ties dataset pushes to log pushes,
+// which are signed by the pushing author. pid must be authenticated
+func (r *Remote) checkDatasetPush(ctx context.Context, pid profile.ID, ref reporef.DatasetRef) error {
+	owner, err := r.datasetOwner(ctx, pid, ref)
+	if err != nil || owner {
+		return err
+	}
+	if err := r.checkDatasetAccess(ctx, pid.String(), reporef.ConvertToDsref(ref), logbook.PermissionWrite); err != nil {
+		return err
+	}
+
+	versions, err := r.node.Repo.Logbook().Versions(ctx, reporef.ConvertToDsref(ref), 0, -1)
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if v.Path == ref.Path {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: version %s isn't in the log of %s, push logs before pushing versions", logbook.ErrAccessDenied, ref.Path, ref.AliasString())
+}
+
+// checkDatasetRemove confirms a profile can remove a dataset from this remote.
+// pid must be authenticated
+func (r *Remote) checkDatasetRemove(ctx context.Context, pid profile.ID, ref reporef.DatasetRef) error {
+	owner, err := r.datasetOwner(ctx, pid, ref)
+	if err != nil || owner {
+		return err
+	}
+	return r.checkDatasetAccess(ctx, pid.String(), reporef.ConvertToDsref(ref), logbook.PermissionPublish)
+}
+
+// datasetOwner returns true if pid owns a dataset. Ownership comes from the
+// dataset log when this remote has one, the profile ID a reference claims
+// can't override it. Datasets without logs belong to the profile ID in their
+// reference, and can't be pushed by anyone else
+func (r *Remote) datasetOwner(ctx context.Context, pid profile.ID, ref reporef.DatasetRef) (bool, error) {
+	book := r.node.Repo.Logbook()
+	if book != nil && ref.Peername != "" && ref.Name != "" {
+		dsLog, err := book.DatasetRef(ctx, dsref.Ref{Username: ref.Peername, Name: ref.Name})
+		if err == nil {
+			return logbook.DatasetOwner(dsLog) == pid.String(), nil
+		} else if err != oplog.ErrNotFound {
+			return false, err
+		}
+	}
+	if ref.ProfileID != "" && ref.ProfileID != pid {
+		return false, fmt.Errorf("%w: %s can't change datasets owned by %s", logbook.ErrAccessDenied, pid, ref.ProfileID)
+	}
+	return true, nil
+}
+
+// checkDatasetAccess confirms a profile has a permission on a dataset.
+// Datasets this remote doesn't have logs for are owned by the profile that
+// pushed them, and only the owner can change them
+func (r *Remote) checkDatasetAccess(ctx context.Context, profileID string, ref dsref.Ref, permission string) error {
+	book := r.node.Repo.Logbook()
+	if book == nil || ref.Username == "" || ref.Name == "" {
+		return nil
+	}
+
+	ok, err := book.HasPermission(ctx, dsref.Ref{Username: ref.Username, Name: ref.Name}, profileID, permission)
+	if err == oplog.ErrNotFound {
+		if permission == logbook.PermissionRead {
+			return nil
+		}
+		return fmt.Errorf("%w: no logs for %s, the owner must push logs first", logbook.ErrAccessDenied, ref.Alias())
+	} else if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("%w: %s doesn't have %s access to %s", logbook.ErrAccessDenied, profileID, permission, ref.Alias())
+	}
+	return nil
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/qri-io/qri/config"
 	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/identity"
+	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/logbook/logsync"
 	"github.com/qri-io/qri/logbook/oplog"
 	"github.com/qri-io/qri/p2p"
@@ -171,9 +172,9 @@ func NewRemote(node *p2p.QriNode, cfg *config.Remote, opts ...func(o *Options))
 	if book := node.Repo.Logbook(); book != nil {
 		r.logsync = logsync.New(book, func(lso *logsync.Options) {
 			lso.PushPreCheck = r.logHook(o.LogPushPreCheck)
-			lso.PushFinalCheck = r.logHook(o.LogPushFinalCheck)
+			lso.PushFinalCheck = r.logPushAccessCheck(o.LogPushFinalCheck)
 			lso.Pushed = r.logHook(o.LogPushed)
-			lso.PullPreCheck = r.logHook(o.LogPullPreCheck)
+			lso.PullPreCheck = r.logPullAccessCheck(o.LogPullPreCheck)
 			lso.Pulled = r.logHook(o.LogPulled)
 			lso.RemovePreCheck = r.logHook(o.LogRemovePreCheck)
 			lso.Removed = r.logHook(o.LogRemoved)
@@ -226,12 +227,16 @@ func (r *Remote) ResolveHeadRef(ctx context.Context, peername, name string) (*re
 // the dataset ref from the refstore and add the (n + 1)th to the refstore
 // gen = -1 should indicate that we remove all the dataset versions
 func (r *Remote) RemoveDataset(ctx context.Context, params map[string]string) error {
-	pid, ref, err := r.pidAndRefFromMeta(params)
+	pid, ref, err := r.authenticatedPidAndRef(params)
 	if err != nil {
 		return err
 	}
 	log.Debugf("remove dataset %s", ref)
 
+	if err = r.checkDatasetRemove(ctx, pid, ref); err != nil {
+		return err
+	}
+
 	// run pre check hook
 	if r.datasetRemovePreCheck != nil {
 		if err = r.datasetRemovePreCheck(ctx, pid, ref); err != nil {
@@ -287,11 +292,15 @@ func (r *Remote) dsPushPreCheck(ctx context.Context, info dag.Info, meta map[str
 		}
 	}
 
+	pid, ref, err := r.authenticatedPidAndRef(meta)
+	if err != nil {
+		return err
+	}
+	if err := r.checkDatasetPush(ctx, pid, ref); err != nil {
+		return err
+	}
+
 	if r.datasetPushPreCheck != nil {
-		pid, ref, err := r.pidAndRefFromMeta(meta)
-		if err != nil {
-			return err
-		}
 		if err := r.datasetPushPreCheck(ctx, pid, ref); err != nil {
 			return err
 		}
@@ -344,11 +353,15 @@ func (r *Remote) dsPushComplete(ctx context.Context, info dag.Info, meta map[str
 }
 
 func (r *Remote) dsRemovePreCheck(ctx context.Context, info dag.Info, meta map[string]string) error {
-	pid, ref, err := r.pidAndRefFromMeta(meta)
+	pid, ref, err := r.authenticatedPidAndRef(meta)
 	if err != nil {
 		return err
 	}
 
+	if err = r.checkDatasetRemove(ctx, pid, ref); err != nil {
+		return err
+	}
+
 	if r.datasetRemovePreCheck != nil {
 		if err = r.datasetRemovePreCheck(ctx, pid, ref); err != nil {
 			return err
@@ -358,12 +371,16 @@ func (r *Remote) dsRemovePreCheck(ctx context.Context, info dag.Info, meta map[s
 }
 
 func (r *Remote) dsGetDagInfo(ctx context.Context, into dag.Info, meta map[string]string) error {
-	pid, ref, err := r.pidAndRefFromMeta(meta)
+	pid, ref, err := r.authenticatedPidAndRef(meta)
 	if err != nil {
 		log.Errorf("ref from meta: %s", err.Error())
 		return err
 	}
 
+	if err = r.checkDatasetAccess(ctx, pid.String(), reporef.ConvertToDsref(ref), logbook.PermissionRead); err != nil {
+		return err
+	}
+
 	if r.datasetPulled != nil {
 		if err = r.datasetPulled(ctx, pid, ref); err != nil {
 			log.Errorf("dataset pulled hook: %s", err.Error())
@@ -373,6 +390,19 @@ func (r *Remote) dsGetDagInfo(ctx context.Context, into dag.Info, meta map[strin
 	return nil
 }
 
+// authenticatedPidAndRef reads the sender & dataset reference from signed
+// dsync metadata, rejecting metadata that isn't signed by the profile it names
+func (r *Remote) authenticatedPidAndRef(meta map[string]string) (profile.ID, reporef.DatasetRef, error) {
+	if _, err := authenticateParams(meta); err != nil {
+		return "", reporef.DatasetRef{}, err
+	}
+	return r.pidAndRefFromMeta(meta)
+}
+
+// pidAndRefFromMeta reads the sender & dataset reference from dsync metadata
+// without checking them. Only use it for metadata that's already been
+// authenticated, like the metadata of a receive session that passed
+// dsPushPreCheck
 func (r *Remote) pidAndRefFromMeta(meta map[string]string) (profile.ID, reporef.DatasetRef, error) {
 	ref := reporef.DatasetRef{
 		Peername: meta["peername"],
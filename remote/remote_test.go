@@ -2,6 +2,7 @@ package remote
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,6 +10,8 @@ import (
 
 	"github.com/google/go-cmp/cmp"
 	core "github.com/ipfs/go-ipfs/core"
+	crypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/qri-io/dag"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/ioes"
 	"github.com/qri-io/qfs"
@@ -17,6 +20,7 @@ import (
 	"github.com/qri-io/qri/config"
 	cfgtest "github.com/qri-io/qri/config/test"
 	"github.com/qri-io/qri/dsref"
+	"github.com/qri-io/qri/logbook"
 	"github.com/qri-io/qri/p2p"
 	p2ptest "github.com/qri-io/qri/p2p/test"
 	"github.com/qri-io/qri/repo"
@@ -235,6 +239,167 @@ func TestFeeds(t *testing.T) {
 
 }
 
+func TestCollaboratorPush(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	rem := tr.NodeARemote(t)
+	server := tr.RemoteTestServer(rem)
+	defer server.Close()
+
+	worldBankRef := writeWorldBankPopulation(tr.Ctx, t, tr.NodeA.Repo)
+	dsr := reporef.ConvertToDsref(worldBankRef)
+
+	collab, err := tr.NodeB.Repo.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerBook := tr.NodeA.Repo.Logbook()
+	if err := ownerBook.WriteACLGrant(tr.Ctx, dsr, collab.ID.String(), logbook.PermissionWrite); err != nil {
+		t.Fatal(err)
+	}
+
+	// collaborator clones the dataset
+	cli := tr.NodeBClient(t)
+	if err := cli.CloneLogs(tr.Ctx, dsr, server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := cli.PullDataset(tr.Ctx, &worldBankRef, server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.NodeB.Repo.PutRef(worldBankRef); err != nil {
+		t.Fatal(err)
+	}
+
+	save := func(title string) reporef.DatasetRef {
+		ds := &dataset.Dataset{
+			Peername: worldBankRef.Peername,
+			Name:     worldBankRef.Name,
+			Meta:     &dataset.Meta{Title: title},
+		}
+		ref, err := base.SaveDataset(tr.Ctx, tr.NodeB.Repo, ioes.NewDiscardIOStreams(), ds, nil, nil, base.SaveDatasetSwitches{Pin: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ref
+	}
+
+	saved := save("World Bank Population, Edited")
+	if saved.Peername != worldBankRef.Peername || saved.ProfileID != worldBankRef.ProfileID {
+		t.Errorf("expected collaborator version to belong to the owner. got: %s", saved)
+	}
+
+	if err := cli.PushLogs(tr.Ctx, reporef.ConvertToDsref(saved), server.URL); err != nil {
+		t.Fatalf("pushing logs with write access: %s", err)
+	}
+	if err := cli.PushDataset(tr.Ctx, saved, server.URL); err != nil {
+		t.Fatalf("pushing dataset with write access: %s", err)
+	}
+	if err := cli.RemoveDataset(tr.Ctx, saved, server.URL); err == nil {
+		t.Error("expected removing a dataset without publish access to fail")
+	}
+
+	versions, err := ownerBook.Versions(tr.Ctx, dsr, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 || versions[0].Path != saved.Path {
+		t.Errorf("expected owner log head to be the pushed version %q. got: %v", saved.Path, versions)
+	}
+	head := &reporef.DatasetRef{Peername: worldBankRef.Peername, Name: worldBankRef.Name}
+	if err := repo.CanonicalizeDatasetRef(tr.NodeA.Repo, head); err != nil {
+		t.Fatal(err)
+	}
+	if head.Path != saved.Path {
+		t.Errorf("expected owner ref to move to the pushed version %q. got: %q", saved.Path, head.Path)
+	}
+
+	// revoked collaborators can't push
+	if err := ownerBook.WriteACLRevoke(tr.Ctx, dsr, collab.ID.String(), logbook.PermissionWrite); err != nil {
+		t.Fatal(err)
+	}
+	saved = save("World Bank Population, Edited Again")
+	if err := cli.PushLogs(tr.Ctx, reporef.ConvertToDsref(saved), server.URL); err == nil {
+		t.Error("expected pushing logs without write access to fail")
+	}
+	if err := cli.PushDataset(tr.Ctx, saved, server.URL); err == nil {
+		t.Error("expected pushing a version missing from the owner's log to fail")
+	}
+}
+
+func TestDatasetPushAuthentication(t *testing.T) {
+	tr, cleanup := newTestRunner(t)
+	defer cleanup()
+
+	rem := tr.NodeARemote(t)
+	worldBankRef := writeWorldBankPopulation(tr.Ctx, t, tr.NodeA.Repo)
+	ownerKey := tr.NodeA.Repo.PrivateKey()
+	otherKey := tr.NodeB.Repo.PrivateKey()
+
+	mustSigParams := func(pk crypto.PrivKey, ref reporef.DatasetRef) map[string]string {
+		params, err := sigParams(pk, ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return params
+	}
+
+	if err := rem.dsPushPreCheck(tr.Ctx, dag.Info{}, mustSigParams(ownerKey, worldBankRef)); err != nil {
+		t.Errorf("expected owner push to pass. got: %s", err)
+	}
+
+	unsigned := mustSigParams(ownerKey, worldBankRef)
+	delete(unsigned, "signature")
+	forged := mustSigParams(otherKey, worldBankRef)
+	forged["pid"] = unsigned["pid"]
+	swappedKey := mustSigParams(otherKey, worldBankRef)
+	swappedKey["pid"] = unsigned["pid"]
+	swappedKey["pubkey"] = unsigned["pubkey"]
+	downgraded := mustSigParams(ownerKey, worldBankRef)
+	delete(downgraded, "sigVersion")
+	delete(downgraded, "refSignature")
+	renamed := mustSigParams(ownerKey, worldBankRef)
+	renamed["name"] = "other_dataset"
+	prevNow := nowFunc
+	nowFunc = func() time.Time { return time.Now().Add(-time.Hour) }
+	expired := mustSigParams(ownerKey, worldBankRef)
+	nowFunc = prevNow
+
+	unauthenticated := map[string]map[string]string{
+		"no metadata":       {},
+		"unsigned":          unsigned,
+		"claims owner pid":  forged,
+		"claims owner key":  swappedKey,
+		"changed reference": renamed,
+		"legacy signature":  downgraded,
+		"expired":           expired,
+	}
+	for description, meta := range unauthenticated {
+		if err := rem.dsPushPreCheck(tr.Ctx, dag.Info{}, meta); !errors.Is(err, ErrUnauthenticated) {
+			t.Errorf("case %q: expected unauthenticated error. got: %v", description, err)
+		}
+	}
+
+	other, err := tr.NodeB.Repo.Profile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	claimed := worldBankRef
+	claimed.ProfileID = other.ID
+	denied := map[string]map[string]string{
+		"not a collaborator": mustSigParams(otherKey, worldBankRef),
+		"claims to be owner": mustSigParams(otherKey, claimed),
+	}
+	for description, meta := range denied {
+		if err := rem.dsPushPreCheck(tr.Ctx, dag.Info{}, meta); !errors.Is(err, logbook.ErrAccessDenied) {
+			t.Errorf("case %q: expected access denied error. got: %v", description, err)
+		}
+	}
+	if err := rem.RemoveDataset(tr.Ctx, mustSigParams(otherKey, claimed)); !errors.Is(err, logbook.ErrAccessDenied) {
+		t.Errorf("expected remove by a profile claiming to be the owner to be denied. got: %v", err)
+	}
+}
+
 type testRunner struct {
 	Ctx          context.Context
 	NodeA, NodeB *p2p.QriNode
@@ -2,46 +2,73 @@ package remote
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	crypto "github.com/libp2p/go-libp2p-core/crypto"
 	"github.com/multiformats/go-multihash"
+	"github.com/qri-io/qri/repo/profile"
 	reporef "github.com/qri-io/qri/repo/ref"
 )
 
+// ErrUnauthenticated indicates a request couldn't be tied to the profile that
+// sent it
+var ErrUnauthenticated = errors.New("unauthenticated")
+
 var (
 	// nowFunc is an ps function for getting timestamps
 	nowFunc = time.Now
+	// sigParamsTTL is how far the timestamp of signed params can be from the
+	// time they're received. keeps captured params from being replayed later
+	sigParamsTTL = time.Minute * 10
 )
 
+// sigVersionRef marks signed params that sign the full dataset reference
+// in addition to the legacy path signature
+const sigVersionRef = "2"
+
 func sigParams(pk crypto.PrivKey, ref reporef.DatasetRef) (map[string]string, error) {
 	pid, err := calcProfileID(pk)
 	if err != nil {
 		return nil, err
 	}
 
-	now := fmt.Sprintf("%d", nowFunc().In(time.UTC).Unix())
-	rss := requestSigningString(now, pid, ref.Path)
-	b64Sig, err := signString(pk, rss)
+	pubkeybytes, err := pk.GetPublic().Bytes()
 	if err != nil {
 		return nil, err
 	}
 
-	return map[string]string{
+	params := map[string]string{
 		"peername":  ref.Peername,
 		"name":      ref.Name,
 		"profileID": ref.ProfileID.String(),
 		"path":      ref.Path,
 
-		"pid":       pid,
-		"timestamp": now,
-		"signature": b64Sig,
-	}, nil
+		"pid":        pid,
+		"pubkey":     base64.StdEncoding.EncodeToString(pubkeybytes),
+		"timestamp":  fmt.Sprintf("%d", nowFunc().In(time.UTC).Unix()),
+		"sigVersion": sigVersionRef,
+	}
+
+	// "signature" keeps the legacy format so remotes that predate signature
+	// versions can still verify it. "refSignature" signs the full reference
+	rss := requestSigningString(params["timestamp"], pid, params["path"])
+	if params["signature"], err = signString(pk, rss); err != nil {
+		return nil, err
+	}
+	rss = requestSigningString(params["timestamp"], pid, paramsRefString(params))
+	if params["refSignature"], err = signString(pk, rss); err != nil {
+		return nil, err
+	}
+	return params, nil
 }
 
 // VerifySigParams takes a public key and a map[string]string params and verifies
-// the the signature is correct
+// the the signature is correct. Params without a "sigVersion" are legacy params
+// that only sign the dataset path. Versioned params must also carry a valid
+// signature of the full dataset reference
 // TODO (ramfox): should be refactored to be private once remotes have their
 // own keystore and can make the replation between a pid and a public key
 // on their own
@@ -63,22 +90,91 @@ func VerifySigParams(pubkey crypto.PubKey, params map[string]string) (bool, erro
 		return false, fmt.Errorf("params need key 'signature'")
 	}
 
-	sigBytes, err := base64.StdEncoding.DecodeString(signature)
+	if ok, err := verifyString(pubkey, requestSigningString(timestamp, pid, path), signature); err != nil || !ok {
+		return ok, err
+	}
+
+	switch params["sigVersion"] {
+	case "":
+		return true, nil
+	case sigVersionRef:
+		refSignature, ok := params["refSignature"]
+		if !ok {
+			return false, fmt.Errorf("params need key 'refSignature'")
+		}
+		return verifyString(pubkey, requestSigningString(timestamp, pid, paramsRefString(params)), refSignature)
+	default:
+		return false, fmt.Errorf("unsupported signature version '%s'", params["sigVersion"])
+	}
+}
+
+// authenticateParams confirms signed params were sent by the profile they
+// name. params must carry the public key of the sender, which must hash to
+// the claimed profile ID & verify the signature, and a timestamp within
+// sigParamsTTL of now. Params that are missing any of these are rejected.
+// Legacy params don't carry a public key or sign the dataset reference, so
+// they can't be authenticated
+func authenticateParams(params map[string]string) (profile.ID, error) {
+	if params["sigVersion"] == "" {
+		return "", fmt.Errorf("%w: legacy signed params don't identify the sender, the client must be upgraded", ErrUnauthenticated)
+	}
+	pid, err := profile.IDB58Decode(params["pid"])
 	if err != nil {
-		return false, err
+		return "", fmt.Errorf("%w: invalid profile ID in signed params", ErrUnauthenticated)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(params["pubkey"])
+	if err != nil || len(data) == 0 {
+		return "", fmt.Errorf("%w: params need a public key", ErrUnauthenticated)
 	}
-	str := base64.StdEncoding.EncodeToString(sigBytes)
-	if str != signature {
-		return false, fmt.Errorf("signature was '%s', after decode then encode it was '%s", signature, str)
+	pub, err := crypto.UnmarshalPublicKey(data)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid public key: %s", ErrUnauthenticated, err)
 	}
-	rss := requestSigningString(timestamp, pid, path)
-	return pubkey.Verify([]byte(rss), sigBytes)
+	mh, err := multihash.Sum(data, multihash.SHA2_256, 32)
+	if err != nil {
+		return "", err
+	}
+	if mh.B58String() != params["pid"] {
+		return "", fmt.Errorf("%w: public key doesn't match profile ID %s", ErrUnauthenticated, params["pid"])
+	}
+
+	ts, err := strconv.ParseInt(params["timestamp"], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid timestamp", ErrUnauthenticated)
+	}
+	if age := nowFunc().Sub(time.Unix(ts, 0)); age > sigParamsTTL || age < -sigParamsTTL {
+		return "", fmt.Errorf("%w: signed params have expired", ErrUnauthenticated)
+	}
+
+	if ok, err := VerifySigParams(pub, params); err != nil || !ok {
+		return "", fmt.Errorf("%w: invalid signature", ErrUnauthenticated)
+	}
+	return pid, nil
+}
+
+// paramsRefString is the dataset reference signed params identify. signing
+// the full reference keeps a signature from being reused for another dataset
+func paramsRefString(params map[string]string) string {
+	return fmt.Sprintf("%s/%s@%s%s", params["peername"], params["name"], params["profileID"], params["path"])
 }
 
 func requestSigningString(timestamp, peerID, cidStr string) string {
 	return fmt.Sprintf("%s.%s.%s", timestamp, peerID, cidStr)
 }
 
+// verifyString checks a base64-encoded signature of str
+func verifyString(pubkey crypto.PubKey, str, b64Sig string) (bool, error) {
+	sigBytes, err := base64.StdEncoding.DecodeString(b64Sig)
+	if err != nil {
+		return false, err
+	}
+	if enc := base64.StdEncoding.EncodeToString(sigBytes); enc != b64Sig {
+		return false, fmt.Errorf("signature was '%s', after decode then encode it was '%s", b64Sig, enc)
+	}
+	return pubkey.Verify([]byte(str), sigBytes)
+}
+
 func signString(privKey crypto.PrivKey, str string) (b64Sig string, err error) {
 	sigbytes, err := privKey.Sign([]byte(str))
 	if err != nil {
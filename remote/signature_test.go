@@ -1,6 +1,8 @@
 package remote
 
 import (
+	"encoding/base64"
+	"errors"
 	"testing"
 
 	"github.com/qri-io/qri/config/test"
@@ -51,3 +53,76 @@ func TestVerifySigParams(t *testing.T) {
 		t.Errorf("case 'should not verify', expected verification to be false, but was true")
 	}
 }
+
+func TestVerifySigParamsVersions(t *testing.T) {
+	peerInfo0 := test.GetTestPeerInfo(0)
+	pid, err := calcProfileID(peerInfo0.PrivKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := reporef.DatasetRef{Path: "foo", Peername: "bar", Name: "baz"}
+
+	// legacy params only sign the path
+	legacySig, err := signString(peerInfo0.PrivKey, requestSigningString("1", pid, ref.Path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := map[string]string{
+		"peername":  ref.Peername,
+		"name":      ref.Name,
+		"path":      ref.Path,
+		"pid":       pid,
+		"timestamp": "1",
+		"signature": legacySig,
+	}
+	if ok, err := VerifySigParams(peerInfo0.PubKey, legacy); err != nil || !ok {
+		t.Errorf("expected legacy params to verify. got: %t, %v", ok, err)
+	}
+
+	versioned, err := sigParams(peerInfo0.PrivKey, ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifySigParams(peerInfo0.PubKey, versioned); err != nil || !ok {
+		t.Errorf("expected versioned params to verify. got: %t, %v", ok, err)
+	}
+
+	// remotes that predate signature versions only check the path signature
+	sigBytes, err := base64.StdEncoding.DecodeString(versioned["signature"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rss := requestSigningString(versioned["timestamp"], pid, versioned["path"])
+	if ok, err := peerInfo0.PubKey.Verify([]byte(rss), sigBytes); err != nil || !ok {
+		t.Errorf("expected versioned params to verify with the legacy format. got: %t, %v", ok, err)
+	}
+
+	renamed := copyParams(versioned)
+	renamed["name"] = "other_dataset"
+	missingRefSig := copyParams(versioned)
+	delete(missingRefSig, "refSignature")
+	unknownVersion := copyParams(versioned)
+	unknownVersion["sigVersion"] = "3"
+
+	for description, params := range map[string]map[string]string{
+		"changed reference":     renamed,
+		"missing ref signature": missingRefSig,
+		"unknown version":       unknownVersion,
+	} {
+		if ok, _ := VerifySigParams(peerInfo0.PubKey, params); ok {
+			t.Errorf("case %q: expected params not to verify", description)
+		}
+	}
+
+	if _, err := authenticateParams(legacy); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("expected legacy params to be unauthenticated. got: %v", err)
+	}
+}
+
+func copyParams(params map[string]string) map[string]string {
+	cp := make(map[string]string, len(params))
+	for k, v := range params {
+		cp[k] = v
+	}
+	return cp
+}
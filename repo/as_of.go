@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/qri-io/qri/logbook"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// asOfLayouts are the timestamp formats ParseAsOf accepts, in order of
+// precedence
+var asOfLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// ParseAsOf parses a timestamp for reading a dataset as of a point in time.
+// Timestamps are RFC3339 strings that can omit seconds, or the entire time of
+// day. Timestamps without a time zone are in UTC
+func ParseAsOf(str string) (time.Time, error) {
+	for _, layout := range asOfLayouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid timestamp %q. use a format like 2006-01-02T15:04:05Z", str)
+}
+
+// CheckAsOf returns an error wrapping ErrAsOfVersion if a reference that
+// hasn't been canonicalized yet names a specific version while an as-of time
+// is set. Resolving as-of would otherwise silently replace that version
+func CheckAsOf(ref reporef.DatasetRef, asOf time.Time) error {
+	if !asOf.IsZero() && ref.Path != "" {
+		return fmt.Errorf("%w: %s", ErrAsOfVersion, ref.AliasString()+"@"+ref.Path)
+	}
+	return nil
+}
+
+// ResolveAsOf sets ref.Path to the version of a dataset that was the latest
+// version at a given time, walking the dataset's commit history in the
+// logbook. ResolveAsOf expects a canonicalized reference, and returns an error
+// wrapping ErrNoVersionAsOf if the dataset didn't exist at the given time
+func ResolveAsOf(ctx context.Context, r Repo, ref *reporef.DatasetRef, asOf time.Time) error {
+	book := r.Logbook()
+	if book == nil {
+		return logbook.ErrNoLogbook
+	}
+
+	versions, err := book.Versions(ctx, reporef.ConvertToDsref(*ref), 0, -1)
+	if err != nil {
+		return err
+	}
+
+	// versions are ordered newest first
+	for _, v := range versions {
+		if !v.CommitTime.After(asOf) {
+			ref.Path = v.Path
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s has no versions as of %s", ErrNoVersionAsOf, ref.AliasString(), asOf.Format(time.RFC3339))
+}
@@ -0,0 +1,109 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qfs/cafs"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+func TestParseAsOf(t *testing.T) {
+	cases := []struct {
+		in     string
+		expect time.Time
+	}{
+		{"2026-01-01T00:00:00Z", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"2026-01-01T00:00Z", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"2026-01-01T12:30", time.Date(2026, 1, 1, 12, 30, 0, 0, time.UTC)},
+		{"2026-01-01", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		got, err := ParseAsOf(c.in)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", c.in, err)
+			continue
+		}
+		if !got.Equal(c.expect) {
+			t.Errorf("%q: expected %s, got %s", c.in, c.expect, got)
+		}
+	}
+
+	if _, err := ParseAsOf("last tuesday"); err == nil {
+		t.Error("expected invalid timestamp to error")
+	}
+}
+
+func TestResolveAsOf(t *testing.T) {
+	ctx := context.Background()
+	lucille := &profile.Profile{ID: profile.IDRawByteString("a"), Peername: "lucille", PrivKey: privKey}
+	memRepo, err := NewMemRepo(lucille, cafs.NewMapstore(), qfs.NewMemFS(), profile.NewMemStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prev := ""
+	for i, path := range []string{"/map/QmV1", "/map/QmV2", "/map/QmV3"} {
+		ds := &dataset.Dataset{
+			Peername: "lucille",
+			Name:     "foo",
+			Path:     path,
+			Commit: &dataset.Commit{
+				Timestamp: time.Date(2026, 1, 1+i*10, 0, 0, 0, 0, time.UTC),
+				Title:     path,
+			},
+			PreviousPath: prev,
+		}
+		if err := memRepo.Logbook().WriteVersionSave(ctx, ds); err != nil {
+			t.Fatal(err)
+		}
+		prev = path
+	}
+
+	cases := []struct {
+		asOf   time.Time
+		expect string
+	}{
+		{time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), "/map/QmV1"},
+		{time.Date(2026, 1, 15, 0, 0, 0, 0, time.UTC), "/map/QmV2"},
+		{time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), "/map/QmV3"},
+	}
+	for _, c := range cases {
+		ref := &reporef.DatasetRef{Peername: "lucille", ProfileID: lucille.ID, Name: "foo", Path: "/map/QmV3"}
+		if err := ResolveAsOf(ctx, memRepo, ref, c.asOf); err != nil {
+			t.Errorf("%s: unexpected error: %s", c.asOf, err)
+			continue
+		}
+		if ref.Path != c.expect {
+			t.Errorf("%s: expected path %s, got %s", c.asOf, c.expect, ref.Path)
+		}
+	}
+
+	ref := &reporef.DatasetRef{Peername: "lucille", ProfileID: lucille.ID, Name: "foo"}
+	if err := ResolveAsOf(ctx, memRepo, ref, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)); !errors.Is(err, ErrNoVersionAsOf) {
+		t.Errorf("expected resolving before the first version to return ErrNoVersionAsOf. got: %v", err)
+	}
+}
+
+func TestCheckAsOf(t *testing.T) {
+	asOf := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if err := CheckAsOf(reporef.DatasetRef{Peername: "lucille", Name: "foo"}, asOf); err != nil {
+		t.Errorf("unexpected error checking a reference without a version: %s", err)
+	}
+	if err := CheckAsOf(reporef.DatasetRef{Peername: "lucille", Name: "foo", Path: "/map/QmV1"}, time.Time{}); err != nil {
+		t.Errorf("unexpected error checking a versioned reference without as-of: %s", err)
+	}
+	err := CheckAsOf(reporef.DatasetRef{Peername: "lucille", Name: "foo", Path: "/map/QmV1"}, asOf)
+	if !errors.Is(err, ErrAsOfVersion) {
+		t.Fatalf("expected a versioned reference with as-of to return ErrAsOfVersion. got: %v", err)
+	}
+	expect := "repo: can't read a specific version as of a point in time: lucille/foo@/map/QmV1"
+	if err.Error() != expect {
+		t.Errorf("error mismatch. expected: %q, got: %q", expect, err.Error())
+	}
+}
@@ -44,6 +44,11 @@ var (
 	ErrNoRegistry = fmt.Errorf("no configured registry")
 	// ErrEmptyRef indicates that the given reference is empty
 	ErrEmptyRef = fmt.Errorf("repo: empty dataset reference")
+	// ErrNoVersionAsOf indicates a dataset had no versions at a requested time
+	ErrNoVersionAsOf = fmt.Errorf("repo: no version as of requested time")
+	// ErrAsOfVersion indicates a reference names a specific version, which
+	// can't be combined with reading as of a point in time
+	ErrAsOfVersion = fmt.Errorf("repo: can't read a specific version as of a point in time")
 )
 
 // Repo is the interface for working with a qri repository qri repos are stored
@@ -0,0 +1,473 @@
+// Package search maintains a local full-text index of datasets, making
+// datasets findable by content without a registry
+package search
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	golog "github.com/ipfs/go-log"
+	"github.com/qri-io/dataset"
+)
+
+var log = golog.Logger("search")
+
+// field weights favour names & titles over longer-form text
+const (
+	weightName        = 3
+	weightTitle       = 3
+	weightKeyword     = 2
+	weightTheme       = 2
+	weightField       = 2
+	weightDescription = 1
+	weightReadme      = 1
+)
+
+// prefixWeight scales the score of terms that only match a query term by
+// prefix
+const prefixWeight = 0.5
+
+// minPrefixLen is the shortest query term that will match terms by prefix
+const minPrefixLen = 3
+
+// minCompact is the number of changes the index log holds before it can be
+// compacted into the index file. Logs are compacted once they hold more
+// changes than the index has documents
+const minCompact = 64
+
+// Doc is a single indexed dataset
+type Doc struct {
+	// InitID is the stable identifier of the dataset
+	InitID string `json:"initID"`
+	// Dataset is a summary of the indexed version, without body or scripts
+	Dataset *dataset.Dataset `json:"dataset"`
+	// Terms maps tokens to their weighted frequency within the document
+	Terms map[string]float64 `json:"terms"`
+}
+
+// Hit is a document that matches a query
+type Hit struct {
+	InitID  string
+	Score   float64
+	Dataset *dataset.Dataset
+}
+
+// Index is an inverted index of dataset documents, keyed by init ID.
+// Indexes are safe for concurrent use
+type Index struct {
+	filename string
+
+	lk       sync.Mutex
+	built    bool
+	docs     map[string]*Doc
+	postings map[string]map[string]float64
+	// terms is the sorted vocabulary of postings, for matching by prefix
+	terms []string
+	// logged is the number of changes in the log since the index file was
+	// last written
+	logged int
+}
+
+// NewIndex creates an index that persists to filename, loading any existing
+// index stored there. Changes are appended to a log alongside filename, which
+// is periodically compacted into the index file. An empty filename creates an
+// in-memory index
+func NewIndex(filename string) *Index {
+	idx := &Index{
+		filename: filename,
+		docs:     map[string]*Doc{},
+		postings: map[string]map[string]float64{},
+	}
+	if filename == "" {
+		return idx
+	}
+	if err := idx.load(); err != nil && !os.IsNotExist(err) {
+		log.Errorf("loading search index: %s", err)
+	}
+	return idx
+}
+
+// Built returns true if the index has been populated, either by loading an
+// existing index or by a call to SetBuilt
+func (idx *Index) Built() bool {
+	idx.lk.Lock()
+	defer idx.lk.Unlock()
+	return idx.built
+}
+
+// SetBuilt marks the index as populated
+func (idx *Index) SetBuilt() error {
+	idx.lk.Lock()
+	defer idx.lk.Unlock()
+	idx.built = true
+	return idx.snapshot()
+}
+
+// Len returns the number of indexed documents
+func (idx *Index) Len() int {
+	idx.lk.Lock()
+	defer idx.lk.Unlock()
+	return len(idx.docs)
+}
+
+// Add indexes a dataset, replacing any document with the same init ID
+func (idx *Index) Add(initID string, ds *dataset.Dataset, readme string) error {
+	doc := &Doc{
+		InitID:  initID,
+		Dataset: summarize(ds),
+		Terms:   docTerms(ds, readme),
+	}
+
+	idx.lk.Lock()
+	defer idx.lk.Unlock()
+	idx.remove(initID)
+	idx.docs[initID] = doc
+	idx.post(doc)
+	return idx.record(logEntry{Doc: doc})
+}
+
+// Rename changes the name of an indexed dataset. Renaming a dataset that
+// isn't in the index is a no-op
+func (idx *Index) Rename(initID, name string) error {
+	idx.lk.Lock()
+	defer idx.lk.Unlock()
+	doc, ok := idx.docs[initID]
+	if !ok {
+		return nil
+	}
+
+	idx.remove(initID)
+	for _, t := range tokenize(doc.Dataset.Name) {
+		doc.Terms[t] -= weightName
+		if doc.Terms[t] <= 0 {
+			delete(doc.Terms, t)
+		}
+	}
+	doc.Dataset.Name = name
+	addTerms(doc.Terms, name, weightName)
+	idx.docs[initID] = doc
+	idx.post(doc)
+	return idx.record(logEntry{Doc: doc})
+}
+
+// Remove drops a dataset from the index
+func (idx *Index) Remove(initID string) error {
+	idx.lk.Lock()
+	defer idx.lk.Unlock()
+	if _, ok := idx.docs[initID]; !ok {
+		return nil
+	}
+	idx.remove(initID)
+	return idx.record(logEntry{Remove: initID})
+}
+
+// Query ranks documents that match any term in q by tf-idf. Terms that only
+// match by prefix score less than exact matches. An empty query matches
+// nothing
+func (idx *Index) Query(q string) []Hit {
+	idx.lk.Lock()
+	defer idx.lk.Unlock()
+
+	n := float64(len(idx.docs))
+	scores := map[string]float64{}
+	score := func(posting map[string]float64, weight float64) {
+		idf := math.Log(1 + n/float64(len(posting)))
+		for id, tf := range posting {
+			scores[id] += weight * tf * idf
+		}
+	}
+	for _, qt := range tokenize(q) {
+		if posting, ok := idx.postings[qt]; ok {
+			score(posting, 1)
+		}
+		if len(qt) < minPrefixLen {
+			continue
+		}
+		// terms sharing a prefix are adjacent in the sorted vocabulary
+		for i := sort.SearchStrings(idx.terms, qt); i < len(idx.terms) && strings.HasPrefix(idx.terms[i], qt); i++ {
+			if idx.terms[i] != qt {
+				score(idx.postings[idx.terms[i]], prefixWeight)
+			}
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{InitID: id, Score: score, Dataset: idx.docs[id].Dataset})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		a, b := hits[i].Dataset, hits[j].Dataset
+		if a.Peername != b.Peername {
+			return a.Peername < b.Peername
+		}
+		return a.Name < b.Name
+	})
+	return hits
+}
+
+// remove drops a document and its postings. callers must hold the lock
+func (idx *Index) remove(initID string) {
+	doc, ok := idx.docs[initID]
+	if !ok {
+		return
+	}
+	for t := range doc.Terms {
+		delete(idx.postings[t], initID)
+		if len(idx.postings[t]) == 0 {
+			delete(idx.postings, t)
+			i := sort.SearchStrings(idx.terms, t)
+			idx.terms = append(idx.terms[:i], idx.terms[i+1:]...)
+		}
+	}
+	delete(idx.docs, initID)
+}
+
+// post adds document terms to postings. callers must hold the lock
+func (idx *Index) post(doc *Doc) {
+	for t, tf := range doc.Terms {
+		if idx.postings[t] == nil {
+			idx.postings[t] = map[string]float64{}
+			i := sort.SearchStrings(idx.terms, t)
+			idx.terms = append(idx.terms, "")
+			copy(idx.terms[i+1:], idx.terms[i:])
+			idx.terms[i] = t
+		}
+		idx.postings[t][doc.InitID] = tf
+	}
+}
+
+// indexFile is the persisted form of an index. Postings are derived from
+// documents on load
+type indexFile struct {
+	Docs []*Doc `json:"docs"`
+}
+
+// logEntry is a change to the index, stored one per line in the index log.
+// entries either add a document, replacing any with the same init ID, or
+// remove one
+type logEntry struct {
+	Doc    *Doc   `json:"doc,omitempty"`
+	Remove string `json:"remove,omitempty"`
+}
+
+func (idx *Index) logFilename() string {
+	return idx.filename + ".log"
+}
+
+func (idx *Index) load() error {
+	data, err := ioutil.ReadFile(idx.filename)
+	if err != nil {
+		return err
+	}
+	f := indexFile{}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	for _, doc := range f.Docs {
+		if doc.Dataset == nil || doc.Terms == nil {
+			continue
+		}
+		idx.docs[doc.InitID] = doc
+		idx.post(doc)
+	}
+	idx.built = true
+	return idx.replay()
+}
+
+// replay applies changes from the index log. a partially written last entry
+// is dropped
+func (idx *Index) replay() error {
+	lf, err := os.Open(idx.logFilename())
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer lf.Close()
+
+	sc := bufio.NewScanner(lf)
+	sc.Buffer(nil, 64<<20)
+	for sc.Scan() {
+		ent := logEntry{}
+		if err := json.Unmarshal(sc.Bytes(), &ent); err != nil {
+			log.Debugf("dropping search index log entry: %s", err)
+			break
+		}
+		if ent.Remove != "" {
+			idx.remove(ent.Remove)
+		} else if ent.Doc != nil && ent.Doc.Dataset != nil && ent.Doc.Terms != nil {
+			idx.remove(ent.Doc.InitID)
+			idx.docs[ent.Doc.InitID] = ent.Doc
+			idx.post(ent.Doc)
+		}
+		idx.logged++
+	}
+	return sc.Err()
+}
+
+// record persists a change by appending it to the index log, compacting the
+// log into the index file once it holds more changes than the index has
+// documents. callers must hold the lock
+func (idx *Index) record(ent logEntry) error {
+	if idx.filename == "" || !idx.built {
+		return nil
+	}
+	if idx.logged >= minCompact && idx.logged >= len(idx.docs) {
+		return idx.snapshot()
+	}
+
+	data, err := json.Marshal(ent)
+	if err != nil {
+		return err
+	}
+	lf, err := os.OpenFile(idx.logFilename(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = lf.Write(append(data, '\n')); err != nil {
+		lf.Close()
+		return err
+	}
+	idx.logged++
+	return lf.Close()
+}
+
+// snapshot writes the entire index to disk & clears the log. callers must
+// hold the lock
+func (idx *Index) snapshot() error {
+	if idx.filename == "" || !idx.built {
+		return nil
+	}
+	f := indexFile{Docs: make([]*Doc, 0, len(idx.docs))}
+	for _, doc := range idx.docs {
+		f.Docs = append(f.Docs, doc)
+	}
+	sort.Slice(f.Docs, func(i, j int) bool { return f.Docs[i].InitID < f.Docs[j].InitID })
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	// write to a temp file & move it into place so a failed write doesn't
+	// clobber the existing index
+	tmp := idx.filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, idx.filename); err != nil {
+		return err
+	}
+	if err := os.Remove(idx.logFilename()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	idx.logged = 0
+	return nil
+}
+
+// summarize copies the parts of a dataset search results show
+func summarize(ds *dataset.Dataset) *dataset.Dataset {
+	sum := &dataset.Dataset{
+		ProfileID: ds.ProfileID,
+		Peername:  ds.Peername,
+		Name:      ds.Name,
+		Path:      ds.Path,
+		Meta:      ds.Meta,
+		Commit:    ds.Commit,
+	}
+	if ds.Structure != nil {
+		sum.Structure = &dataset.Structure{
+			Format:   ds.Structure.Format,
+			Length:   ds.Structure.Length,
+			Entries:  ds.Structure.Entries,
+			ErrCount: ds.Structure.ErrCount,
+		}
+	}
+	return sum
+}
+
+// docTerms collects weighted terms from the searchable parts of a dataset
+func docTerms(ds *dataset.Dataset, readme string) map[string]float64 {
+	terms := map[string]float64{}
+	addTerms(terms, ds.Name, weightName)
+	if md := ds.Meta; md != nil {
+		addTerms(terms, md.Title, weightTitle)
+		addTerms(terms, md.Description, weightDescription)
+		for _, kw := range md.Keywords {
+			addTerms(terms, kw, weightKeyword)
+		}
+		for _, th := range md.Theme {
+			addTerms(terms, th, weightTheme)
+		}
+	}
+	if ds.Structure != nil {
+		for _, f := range schemaFields(ds.Structure.Schema) {
+			addTerms(terms, f, weightField)
+		}
+	}
+	addTerms(terms, readme, weightReadme)
+	return terms
+}
+
+func addTerms(terms map[string]float64, text string, weight float64) {
+	for _, t := range tokenize(text) {
+		terms[t] += weight
+	}
+}
+
+// schemaFields lists property names and column titles from a jsonschema
+func schemaFields(sch interface{}) (fields []string) {
+	switch v := sch.(type) {
+	case map[string]interface{}:
+		for key, val := range v {
+			switch key {
+			case "title":
+				if s, ok := val.(string); ok {
+					fields = append(fields, s)
+				}
+			case "properties":
+				if props, ok := val.(map[string]interface{}); ok {
+					for name := range props {
+						fields = append(fields, name)
+					}
+				}
+			}
+			fields = append(fields, schemaFields(val)...)
+		}
+	case []interface{}:
+		for _, val := range v {
+			fields = append(fields, schemaFields(val)...)
+		}
+	}
+	return fields
+}
+
+// tokenize splits text into lowercase words, dropping stopwords. Underscores
+// and dashes separate words so identifiers like "city_name" match "city"
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	tokens := words[:0]
+	for _, w := range words {
+		if !stopwords[w] {
+			tokens = append(tokens, w)
+		}
+	}
+	return tokens
+}
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "in": true, "is": true,
+	"it": true, "of": true, "on": true, "or": true, "that": true, "the": true,
+	"this": true, "to": true, "was": true, "with": true,
+}
@@ -0,0 +1,231 @@
+package search
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/repo"
+)
+
+func TestIndexQuery(t *testing.T) {
+	idx := NewIndex("")
+	addTestDocs(t, idx)
+
+	cases := []struct {
+		q      string
+		expect []string
+	}{
+		{"", []string{}},
+		{"the", []string{}},
+		{"rainfall", []string{"precip", "weather_stations"}},
+		{"Station", []string{"weather_stations"}},
+		{"city population", []string{"city_pop", "weather_stations"}},
+		{"popul", []string{"city_pop"}},
+		{"po", []string{}},
+		{"health", []string{"city_pop"}},
+		{"qri", []string{"precip"}},
+	}
+
+	for _, c := range cases {
+		got := hitNames(idx.Query(c.q))
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("query %q mismatch (-want +got):\n%s", c.q, diff)
+		}
+	}
+}
+
+func TestIndexUpdates(t *testing.T) {
+	idx := NewIndex("")
+	addTestDocs(t, idx)
+
+	if err := idx.Rename("id_weather_stations", "gauges"); err != nil {
+		t.Fatal(err)
+	}
+	if got := hitNames(idx.Query("weather")); len(got) != 0 {
+		t.Errorf("expected renamed dataset to stop matching its old name. got: %v", got)
+	}
+	if diff := cmp.Diff([]string{"gauges"}, hitNames(idx.Query("gauges"))); diff != "" {
+		t.Errorf("rename mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"gauges"}, hitNames(idx.Query("gaug weath"))); diff != "" {
+		t.Errorf("rename prefix mismatch (-want +got):\n%s", diff)
+	}
+
+	if err := idx.Remove("id_city_pop"); err != nil {
+		t.Fatal(err)
+	}
+	if got := hitNames(idx.Query("population")); len(got) != 0 {
+		t.Errorf("expected removed dataset to be dropped from results. got: %v", got)
+	}
+	if idx.Len() != 2 {
+		t.Errorf("expected 2 documents. got: %d", idx.Len())
+	}
+
+	refs, err := idx.Search(repo.SearchParams{Q: "rainfall", Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 || refs[0].Name != "gauges" {
+		t.Errorf("expected second page to hold gauges. got: %v", refs)
+	}
+}
+
+func TestIndexPersistence(t *testing.T) {
+	dir, err := ioutil.TempDir("", "search_index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "search_index.json")
+
+	idx := NewIndex(filename)
+	if idx.Built() {
+		t.Errorf("expected index without a file to need building")
+	}
+	addTestDocs(t, idx)
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		t.Errorf("expected unbuilt index not to be written")
+	}
+	if err := idx.SetBuilt(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewIndex(filename)
+	if !loaded.Built() {
+		t.Errorf("expected loaded index to be built")
+	}
+	expect, got := idx.Query("city rainfall"), loaded.Query("city rainfall")
+	if diff := cmp.Diff(hitScores(expect), hitScores(got)); diff != "" {
+		t.Errorf("loaded index mismatch (-want +got):\n%s", diff)
+	}
+
+	// changes are appended to the log, leaving the index file alone
+	before, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Rename("id_precip", "rain_gauges"); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Remove("id_city_pop"); err != nil {
+		t.Fatal(err)
+	}
+	if err := idx.Add("id_city_pop", &dataset.Dataset{Peername: "peer", Name: "city_area"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if after, err := ioutil.ReadFile(filename); err != nil {
+		t.Fatal(err)
+	} else if string(before) != string(after) {
+		t.Errorf("expected changes not to rewrite the index file")
+	}
+
+	loaded = NewIndex(filename)
+	expect, got = idx.Query("city rainfall gauges popul"), loaded.Query("city rainfall gauges popul")
+	if diff := cmp.Diff(hitScores(expect), hitScores(got)); diff != "" {
+		t.Errorf("replayed index mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestIndexCompaction(t *testing.T) {
+	dir, err := ioutil.TempDir("", "search_index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "search_index.json")
+
+	idx := NewIndex(filename)
+	addTestDocs(t, idx)
+	if err := idx.SetBuilt(); err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"gauges", "rain", "precipitation"}
+	for i := 0; i < minCompact*3; i++ {
+		if err := idx.Rename("id_precip", names[i%len(names)]); err != nil {
+			t.Fatal(err)
+		}
+		if idx.logged > minCompact {
+			t.Fatalf("expected log to be compacted after %d changes, has %d", minCompact, idx.logged)
+		}
+	}
+
+	loaded := NewIndex(filename)
+	if loaded.logged != idx.logged {
+		t.Errorf("expected loaded index to replay %d log entries, got %d", idx.logged, loaded.logged)
+	}
+	expect, got := idx.Query("rainfall precip"), loaded.Query("rainfall precip")
+	if diff := cmp.Diff(hitScores(expect), hitScores(got)); diff != "" {
+		t.Errorf("compacted index mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(loaded.terms, idx.terms); diff != "" {
+		t.Errorf("vocabulary mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func addTestDocs(t *testing.T, idx *Index) {
+	docs := []struct {
+		ds     *dataset.Dataset
+		readme string
+	}{
+		{&dataset.Dataset{
+			Peername: "peer",
+			Name:     "city_pop",
+			Meta: &dataset.Meta{
+				Title:       "City Population",
+				Description: "population of cities over time",
+				Theme:       []string{"health"},
+			},
+		}, ""},
+		{&dataset.Dataset{
+			Peername: "peer",
+			Name:     "precip",
+			Meta: &dataset.Meta{
+				Title:    "Precipitation",
+				Keywords: []string{"rainfall"},
+			},
+		}, "# precip\nrainfall totals collected with qri"},
+		{&dataset.Dataset{
+			Peername: "peer",
+			Name:     "weather_stations",
+			Structure: &dataset.Structure{
+				Format: "csv",
+				Schema: map[string]interface{}{
+					"type": "array",
+					"items": map[string]interface{}{
+						"type": "array",
+						"items": []interface{}{
+							map[string]interface{}{"title": "station_id", "type": "string"},
+							map[string]interface{}{"title": "city", "type": "string"},
+							map[string]interface{}{"title": "rainfall_mm", "type": "number"},
+						},
+					},
+				},
+			},
+		}, ""},
+	}
+	for _, d := range docs {
+		if err := idx.Add("id_"+d.ds.Name, d.ds, d.readme); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func hitNames(hits []Hit) []string {
+	names := make([]string, len(hits))
+	for i, h := range hits {
+		names[i] = h.Dataset.Name
+	}
+	return names
+}
+
+func hitScores(hits []Hit) map[string]float64 {
+	scores := map[string]float64{}
+	for _, h := range hits {
+		scores[h.Dataset.Name] = h.Score
+	}
+	return scores
+}
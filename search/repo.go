@@ -0,0 +1,145 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/qri/logbook"
+	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/qri/repo/profile"
+	reporef "github.com/qri-io/qri/repo/ref"
+)
+
+// IndexDataset adds a dataset version to the index, including its readme
+// text. Private datasets are kept out of the index so their contents never
+// touch disk unencrypted
+func IndexDataset(ctx context.Context, r repo.Repo, idx *Index, initID string, ds *dataset.Dataset) error {
+	store := r.Store()
+	if ds.Path != "" {
+		f, err := store.Get(ctx, dsfs.PackageFilepath(store, ds.Path, dsfs.PackageFileDataset))
+		if err == nil {
+			data, err := ioutil.ReadAll(f)
+			if err == nil && dsfs.IsEncrypted(data) {
+				return idx.Remove(initID)
+			}
+		}
+	}
+
+	readme := ""
+	if ds.Path != "" {
+		if f, err := dsfs.LoadReadmeScript(ctx, store, ds.Path); err == nil {
+			if data, err := ioutil.ReadAll(f); err == nil {
+				readme = string(data)
+			}
+		}
+	}
+	return idx.Add(initID, ds, readme)
+}
+
+// Reindex builds the index from every dataset reference in a repo
+func Reindex(ctx context.Context, r repo.Repo, idx *Index) error {
+	count, err := r.RefCount()
+	if err != nil {
+		return err
+	}
+	refs, err := r.References(0, count)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range refs {
+		if ref.Path == "" {
+			continue
+		}
+		ds, err := dsfs.LoadDataset(ctx, r.Store(), ref.Path)
+		if errors.Is(err, dsfs.ErrNoDataKey) {
+			continue
+		} else if err != nil {
+			log.Debugf("loading %s for search index: %s", ref.AliasString(), err)
+			continue
+		}
+		ds.ProfileID = ref.ProfileID.String()
+		ds.Peername = ref.Peername
+		ds.Name = ref.Name
+		ds.Path = ref.Path
+
+		if err := IndexDataset(ctx, r, idx, InitID(ctx, r, ref), ds); err != nil {
+			return err
+		}
+	}
+	return idx.SetBuilt()
+}
+
+// InitID gets the key a dataset is indexed by. Datasets without a log are
+// keyed by alias
+func InitID(ctx context.Context, r repo.Repo, ref reporef.DatasetRef) string {
+	if book := r.Logbook(); book != nil {
+		if dsLog, err := book.DatasetRef(ctx, reporef.ConvertToDsref(ref)); err == nil {
+			return dsLog.ID()
+		}
+	}
+	return ref.AliasString()
+}
+
+// Observe keeps the index up to date with changes to the repo logbook
+func Observe(r repo.Repo, idx *Index) {
+	book := r.Logbook()
+	if book == nil {
+		return
+	}
+	book.Observe(func(act *logbook.Action) {
+		var err error
+		switch act.Type {
+		case logbook.ActionDatasetChange:
+			if act.Dataset == nil || (act.Branch != "" && act.Branch != logbook.DefaultBranchName) {
+				return
+			}
+			err = IndexDataset(context.Background(), r, idx, act.InitID, act.Dataset)
+		case logbook.ActionDatasetRename:
+			err = idx.Rename(act.InitID, act.PrettyName)
+		case logbook.ActionDatasetDelete:
+			err = idx.Remove(act.InitID)
+		}
+		if err != nil {
+			log.Errorf("updating search index: %s", err)
+		}
+	})
+}
+
+// Search queries the index, implementing the repo.Searchable interface.
+// A limit less than one returns all matches
+func (idx *Index) Search(p repo.SearchParams) ([]reporef.DatasetRef, error) {
+	hits := Page(idx.Query(p.Q), p.Limit, p.Offset)
+	refs := make([]reporef.DatasetRef, len(hits))
+	for i, hit := range hits {
+		refs[i] = reporef.DatasetRef{
+			ProfileID: profile.IDB58DecodeOrEmpty(hit.Dataset.ProfileID),
+			Peername:  hit.Dataset.Peername,
+			Name:      hit.Dataset.Name,
+			Path:      hit.Dataset.Path,
+			Dataset:   hit.Dataset,
+		}
+	}
+	return refs, nil
+}
+
+// Page slices a page of hits. A limit less than one returns all hits
+// after offset
+func Page(hits []Hit, limit, offset int) []Hit {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(hits) {
+		return []Hit{}
+	}
+	hits = hits[offset:]
+	if limit > 0 && limit < len(hits) {
+		hits = hits[:limit]
+	}
+	return hits
+}
+
+var _ repo.Searchable = (*Index)(nil)
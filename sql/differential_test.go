@@ -0,0 +1,313 @@
+package sql
+
+import (
+	"context"
+	dbsql "database/sql"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+
+	// sqlite is the reference engine query results are checked against
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// diffTable is a randomly generated table, loaded into both engines
+type diffTable struct {
+	name string
+	cols []string
+	typs []string
+	rows [][]interface{}
+}
+
+// diffTables generates tables t1 & t2 with a small domain of values so joins
+// & groups have plenty of matches, and plenty of NULLs
+func diffTables(rnd *rand.Rand) []*diffTable {
+	maybe := func(v interface{}) interface{} {
+		if rnd.Intn(5) == 0 {
+			return nil
+		}
+		return v
+	}
+	strs := []string{"a", "b", "B", "ab", "ba", ""}
+	nums := []float64{-1.5, 0, 0.25, 2, 3.75}
+
+	t1 := &diffTable{name: "t1", cols: []string{"id", "k", "v", "s"}, typs: []string{"integer", "integer", "number", "string"}}
+	for i, n := 0, rnd.Intn(12); i < n; i++ {
+		t1.rows = append(t1.rows, []interface{}{i + 1, maybe(rnd.Intn(4)), maybe(nums[rnd.Intn(len(nums))]), maybe(strs[rnd.Intn(len(strs))])})
+	}
+	t2 := &diffTable{name: "t2", cols: []string{"k", "w", "s"}, typs: []string{"integer", "integer", "string"}}
+	for i, n := 0, rnd.Intn(8); i < n; i++ {
+		t2.rows = append(t2.rows, []interface{}{maybe(rnd.Intn(5)), maybe(rnd.Intn(7) - 3), maybe(strs[rnd.Intn(len(strs))])})
+	}
+	return []*diffTable{t1, t2}
+}
+
+// opener serves tables as me/<name> JSON datasets
+func diffOpener(tables []*diffTable) Opener {
+	return func(ctx context.Context, ref string) (dsio.EntryReader, error) {
+		for _, t := range tables {
+			if ref != "me/"+t.name {
+				continue
+			}
+			items := make([]interface{}, len(t.cols))
+			for i, c := range t.cols {
+				items[i] = map[string]interface{}{"title": c, "type": []interface{}{t.typs[i], "null"}}
+			}
+			st := &dataset.Structure{
+				Format: "json",
+				Schema: map[string]interface{}{
+					"type":  "array",
+					"items": map[string]interface{}{"type": "array", "items": items},
+				},
+			}
+			data, err := json.Marshal(t.rows)
+			if err != nil {
+				return nil, err
+			}
+			if t.rows == nil {
+				data = []byte("[]")
+			}
+			return dsio.NewJSONReader(st, strings.NewReader(string(data)))
+		}
+		return nil, fmt.Errorf("dataset %q not found", ref)
+	}
+}
+
+// loadSQLite creates tables in a fresh in-memory sqlite database
+func loadSQLite(t *testing.T, tables []*diffTable) *dbsql.DB {
+	t.Helper()
+	db, err := dbsql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// in-memory databases are per-connection
+	db.SetMaxOpenConns(1)
+	for _, tbl := range tables {
+		defs := make([]string, len(tbl.cols))
+		marks := make([]string, len(tbl.cols))
+		for i, c := range tbl.cols {
+			typ := map[string]string{"integer": "INTEGER", "number": "REAL", "string": "TEXT"}[tbl.typs[i]]
+			defs[i] = c + " " + typ
+			marks[i] = "?"
+		}
+		if _, err := db.Exec(fmt.Sprintf("CREATE TABLE %s (%s)", tbl.name, strings.Join(defs, ", "))); err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range tbl.rows {
+			if _, err := db.Exec(fmt.Sprintf("INSERT INTO %s VALUES (%s)", tbl.name, strings.Join(marks, ", ")), row...); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	return db
+}
+
+// diffValue formats a result value so both engines agree on representation:
+// numbers as floats, booleans as 0 or 1
+func diffValue(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if x {
+			return "1"
+		}
+		return "0"
+	case int:
+		return diffValue(float64(x))
+	case int64:
+		return diffValue(float64(x))
+	case float64:
+		return strconv.FormatFloat(x, 'g', 10, 64)
+	case []byte:
+		return strconv.Quote(string(x))
+	case string:
+		return strconv.Quote(x)
+	}
+	return fmt.Sprintf("%T(%v)", v, v)
+}
+
+// diffRows formats result rows. unless ordered, rows are sorted so results
+// are compared as multisets
+func diffRows(rows [][]interface{}, ordered bool) []string {
+	res := make([]string, len(rows))
+	for i, row := range rows {
+		vals := make([]string, len(row))
+		for j, v := range row {
+			vals[j] = diffValue(v)
+		}
+		res[i] = strings.Join(vals, ", ")
+	}
+	if !ordered {
+		sort.Strings(res)
+	}
+	return res
+}
+
+func queryQri(tables []*diffTable, q string) ([][]interface{}, error) {
+	rows, err := Query(context.Background(), q, diffOpener(tables))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var res [][]interface{}
+	for {
+		ent, err := rows.ReadEntry()
+		if err != nil {
+			if isEOF(err) {
+				return res, nil
+			}
+			return nil, err
+		}
+		res = append(res, ent.Value.([]interface{}))
+	}
+}
+
+func querySQLite(db *dbsql.DB, q string) ([][]interface{}, error) {
+	rows, err := db.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var res [][]interface{}
+	for rows.Next() {
+		row := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		res = append(res, row)
+	}
+	return res, rows.Err()
+}
+
+// diffQueries are checked against sqlite. {t1} & {t2} are replaced with each
+// engine's table names. queries that ORDER BY a unique key are compared in
+// order, the rest as multisets
+var diffQueries = []struct {
+	q       string
+	ordered bool
+}{
+	// NULL semantics
+	{q: "SELECT id, k FROM {t1} a WHERE k = NULL"},
+	{q: "SELECT id FROM {t1} a WHERE k IS NULL"},
+	{q: "SELECT id FROM {t1} a WHERE k IS NOT NULL AND v IS NULL"},
+	{q: "SELECT id FROM {t1} a WHERE NOT (k = 1)"},
+	{q: "SELECT id FROM {t1} a WHERE k <> 1 OR v > 0"},
+	{q: "SELECT id FROM {t1} a WHERE NOT (k = 1 AND v > 0)"},
+	{q: "SELECT id FROM {t1} a WHERE k IN (1, 2)"},
+	{q: "SELECT id FROM {t1} a WHERE k NOT IN (1, 2)"},
+	{q: "SELECT id FROM {t1} a WHERE k BETWEEN 1 AND 2"},
+	{q: "SELECT id FROM {t1} a WHERE v NOT BETWEEN 0 AND 2"},
+	{q: "SELECT id, k + v, k * 2 - v FROM {t1} a"},
+	{q: "SELECT id, k = 1, k < v, v IS NULL FROM {t1} a"},
+	{q: "SELECT id, COALESCE(k, v, -1) FROM {t1} a"},
+	{q: "SELECT id, s FROM {t1} a WHERE s LIKE 'a%'"},
+	{q: "SELECT id, s FROM {t1} a WHERE s NOT LIKE '%b'"},
+
+	// aggregates & GROUP BY
+	{q: "SELECT COUNT(*), COUNT(k), COUNT(DISTINCT k), SUM(k), MIN(v), MAX(v) FROM {t1} a"},
+	{q: "SELECT AVG(v), SUM(v) FROM {t1} a WHERE k = 99"},
+	{q: "SELECT k, COUNT(*), SUM(v), MIN(s), MAX(s) FROM {t1} a GROUP BY k"},
+	{q: "SELECT k, s, COUNT(*) FROM {t1} a GROUP BY k, s"},
+	{q: "SELECT k, COUNT(v) AS n FROM {t1} a GROUP BY k HAVING COUNT(v) > 1"},
+	{q: "SELECT k, SUM(v) FROM {t1} a GROUP BY k HAVING SUM(v) IS NULL"},
+	{q: "SELECT k, AVG(v) FROM {t1} a WHERE v > 0 GROUP BY k"},
+	{q: "SELECT k + 1, COUNT(*) FROM {t1} a GROUP BY k + 1"},
+	{q: "SELECT DISTINCT k FROM {t1} a"},
+	{q: "SELECT DISTINCT k, s FROM {t1} a"},
+
+	// joins
+	{q: "SELECT a.id, b.w FROM {t1} a JOIN {t2} b ON a.k = b.k"},
+	{q: "SELECT a.id, b.w FROM {t1} a JOIN {t2} b ON a.k = b.k AND a.s = b.s"},
+	{q: "SELECT a.id, b.w FROM {t1} a JOIN {t2} b ON a.k < b.k"},
+	{q: "SELECT a.id, b.k, b.w FROM {t1} a LEFT JOIN {t2} b ON a.k = b.k"},
+	{q: "SELECT a.id, b.w FROM {t1} a LEFT JOIN {t2} b ON a.k = b.k WHERE b.k IS NULL"},
+	{q: "SELECT a.id, b.w FROM {t1} a LEFT JOIN {t2} b ON a.k = b.k AND b.w > 0"},
+	{q: "SELECT a.id, b.k FROM {t1} a CROSS JOIN {t2} b"},
+	{q: "SELECT a.id, b.k, c.id FROM {t1} a JOIN {t2} b ON a.k = b.k LEFT JOIN {t1} c ON c.k = b.w"},
+	{q: "SELECT b.k, COUNT(a.id), SUM(a.v) FROM {t2} b LEFT JOIN {t1} a ON a.k = b.k GROUP BY b.k"},
+	{q: "SELECT a.s, COUNT(*) FROM {t1} a JOIN {t2} b ON a.s = b.s GROUP BY a.s HAVING COUNT(*) > 1"},
+
+	// ordering by a unique key
+	{q: "SELECT id, k FROM {t1} a ORDER BY id DESC", ordered: true},
+	{q: "SELECT id, k FROM {t1} a ORDER BY id LIMIT 3 OFFSET 1", ordered: true},
+	{q: "SELECT id, k FROM {t1} a ORDER BY k, id", ordered: true},
+	{q: "SELECT id, v FROM {t1} a ORDER BY v DESC, id DESC", ordered: true},
+}
+
+// randPredicate builds a random boolean expression over t1's columns
+func randPredicate(rnd *rand.Rand, depth int) string {
+	if depth > 0 {
+		switch rnd.Intn(4) {
+		case 0:
+			return fmt.Sprintf("(%s AND %s)", randPredicate(rnd, depth-1), randPredicate(rnd, depth-1))
+		case 1:
+			return fmt.Sprintf("(%s OR %s)", randPredicate(rnd, depth-1), randPredicate(rnd, depth-1))
+		case 2:
+			return fmt.Sprintf("NOT %s", randPredicate(rnd, depth-1))
+		}
+	}
+	operands := []string{"k", "v", "id", "0", "1", "2.5", "NULL"}
+	ops := []string{"=", "<>", "<", "<=", ">", ">="}
+	switch rnd.Intn(5) {
+	case 0:
+		return fmt.Sprintf("%s IS NULL", operands[rnd.Intn(3)])
+	case 1:
+		return fmt.Sprintf("%s IN (0, 1, NULL)", operands[rnd.Intn(3)])
+	}
+	return fmt.Sprintf("%s %s %s", operands[rnd.Intn(len(operands))], ops[rnd.Intn(len(ops))], operands[rnd.Intn(len(operands))])
+}
+
+// TestDifferentialSQLite checks query results against sqlite over randomly
+// generated tables
+func TestDifferentialSQLite(t *testing.T) {
+	qri := strings.NewReplacer("{t1}", "me/t1", "{t2}", "me/t2")
+	lite := strings.NewReplacer("{t1}", "t1", "{t2}", "t2")
+
+	for seed := int64(1); seed <= 30; seed++ {
+		rnd := rand.New(rand.NewSource(seed))
+		tables := diffTables(rnd)
+		db := loadSQLite(t, tables)
+
+		queries := diffQueries
+		for i := 0; i < 20; i++ {
+			q := fmt.Sprintf("SELECT id, k, v FROM {t1} a WHERE %s", randPredicate(rnd, 3))
+			queries = append(queries, struct {
+				q       string
+				ordered bool
+			}{q: q})
+		}
+
+		for _, c := range queries {
+			expect, err := querySQLite(db, lite.Replace(c.q))
+			if err != nil {
+				t.Fatalf("seed %d: sqlite %q: %s", seed, c.q, err)
+			}
+			got, err := queryQri(tables, qri.Replace(c.q))
+			if err != nil {
+				t.Errorf("seed %d: %q: unexpected error: %s", seed, c.q, err)
+				continue
+			}
+			want, have := diffRows(expect, c.ordered), diffRows(got, c.ordered)
+			if strings.Join(want, "\n") != strings.Join(have, "\n") {
+				t.Errorf("seed %d: %q: result mismatch.\nsqlite:\n%s\ngot:\n%s", seed, c.q, strings.Join(want, "\n"), strings.Join(have, "\n"))
+			}
+		}
+		db.Close()
+	}
+}
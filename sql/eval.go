@@ -0,0 +1,764 @@
+package sql
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// errIntegerOverflow is returned by integer arithmetic with results that don't
+// fit in an int64
+var errIntegerOverflow = fmt.Errorf("integer overflow")
+
+// expr is a node of an expression tree
+type expr interface {
+	// children lists the expressions an expression is built from
+	children() []expr
+}
+
+type literal struct {
+	v interface{}
+}
+
+// column refers to a value of the current row. index is set when the
+// expression is bound to a scope
+type column struct {
+	table, name string
+	index       int
+}
+
+type binary struct {
+	op   string
+	l, r expr
+}
+
+type unary struct {
+	op string
+	x  expr
+}
+
+type isNull struct {
+	x   expr
+	not bool
+}
+
+type in struct {
+	x    expr
+	list []expr
+	not  bool
+}
+
+// match tests values against LIKE patterns or regular expressions (the ~
+// operator). patterns that are literals are compiled once, other patterns are
+// compiled when they change
+type match struct {
+	x, pattern expr
+	like, not  bool
+	re         *regexp.Regexp
+	cached     *regexp.Regexp
+	last       string
+}
+
+// call is a function call. aggregate calls read their result from the row,
+// at index agg
+type call struct {
+	name     string
+	args     []expr
+	star     bool
+	distinct bool
+	agg      int
+}
+
+func (x *literal) children() []expr { return nil }
+func (x *column) children() []expr  { return nil }
+func (x *binary) children() []expr  { return []expr{x.l, x.r} }
+func (x *unary) children() []expr   { return []expr{x.x} }
+func (x *isNull) children() []expr  { return []expr{x.x} }
+func (x *in) children() []expr      { return append([]expr{x.x}, x.list...) }
+func (x *match) children() []expr   { return []expr{x.x, x.pattern} }
+func (x *call) children() []expr    { return x.args }
+
+func newMatch(x, pattern expr, like, not bool) (expr, error) {
+	m := &match{x: x, pattern: pattern, like: like, not: not}
+	if lit, ok := pattern.(*literal); ok {
+		s, ok := lit.v.(string)
+		if !ok {
+			return nil, fmt.Errorf("pattern must be a string")
+		}
+		var err error
+		if m.re, err = m.compile(s); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// compile converts a pattern to a regular expression. LIKE patterns match the
+// whole value, ignoring case, with % matching any text & _ any character
+func (m *match) compile(pattern string) (*regexp.Regexp, error) {
+	if !m.like {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %s", pattern, err)
+		}
+		return re, nil
+	}
+	var b strings.Builder
+	b.WriteString("(?is)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// walk calls fn for x & every expression below it
+func walk(x expr, fn func(expr) error) error {
+	if x == nil {
+		return nil
+	}
+	if err := fn(x); err != nil {
+		return err
+	}
+	for _, c := range x.children() {
+		if err := walk(c, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hasAggregate(x expr) bool {
+	found := false
+	walk(x, func(x expr) error {
+		if c, ok := x.(*call); ok && functions[c.name].aggregate {
+			found = true
+		}
+		return nil
+	})
+	return found
+}
+
+// eval computes the value of an expression for a row. Values follow SQL's
+// three-valued logic: comparisons & arithmetic involving null give null.
+// arithmetic on values that aren't numbers also gives null
+func eval(x expr, row []interface{}) (interface{}, error) {
+	switch x := x.(type) {
+	case *literal:
+		return x.v, nil
+	case *column:
+		return row[x.index], nil
+	case *unary:
+		v, err := eval(x.x, row)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		if x.op == "NOT" {
+			b, ok := truth(v)
+			if !ok {
+				return nil, nil
+			}
+			return !b, nil
+		}
+		switch n := number(v).(type) {
+		case int64:
+			if n == math.MinInt64 {
+				return nil, errIntegerOverflow
+			}
+			return -n, nil
+		case float64:
+			return -n, nil
+		}
+		return nil, nil
+	case *binary:
+		return evalBinary(x, row)
+	case *isNull:
+		v, err := eval(x.x, row)
+		if err != nil {
+			return nil, err
+		}
+		return (v == nil) != x.not, nil
+	case *in:
+		v, err := eval(x.x, row)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		sawNull := false
+		for _, item := range x.list {
+			w, err := eval(item, row)
+			if err != nil {
+				return nil, err
+			}
+			if w == nil {
+				sawNull = true
+			} else if compare(v, w) == 0 {
+				return !x.not, nil
+			}
+		}
+		if sawNull {
+			return nil, nil
+		}
+		return x.not, nil
+	case *match:
+		v, err := eval(x.x, row)
+		if err != nil || v == nil {
+			return nil, err
+		}
+		re := x.re
+		if re == nil {
+			p, err := eval(x.pattern, row)
+			if err != nil || p == nil {
+				return nil, err
+			}
+			if s := text(p); s != x.last || x.cached == nil {
+				if x.cached, err = x.compile(s); err != nil {
+					return nil, err
+				}
+				x.last = s
+			}
+			re = x.cached
+		}
+		return re.MatchString(text(v)) != x.not, nil
+	case *call:
+		if x.agg >= 0 {
+			return row[x.agg], nil
+		}
+		args := make([]interface{}, len(x.args))
+		for i, a := range x.args {
+			v, err := eval(a, row)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = v
+		}
+		return functions[x.name].scalar(args)
+	}
+	return nil, fmt.Errorf("unknown expression %T", x)
+}
+
+func evalBinary(x *binary, row []interface{}) (interface{}, error) {
+	l, err := eval(x.l, row)
+	if err != nil {
+		return nil, err
+	}
+
+	switch x.op {
+	case "AND", "OR":
+		lb, lok := truth(l)
+		// short circuit
+		if lok && lb == (x.op == "OR") {
+			return lb, nil
+		}
+		r, err := eval(x.r, row)
+		if err != nil {
+			return nil, err
+		}
+		rb, rok := truth(r)
+		if rok && rb == (x.op == "OR") {
+			return rb, nil
+		}
+		if !lok || !rok {
+			return nil, nil
+		}
+		return rb, nil
+	}
+
+	r, err := eval(x.r, row)
+	if err != nil || l == nil || r == nil {
+		return nil, err
+	}
+
+	switch x.op {
+	case "=":
+		return compare(l, r) == 0, nil
+	case "!=":
+		return compare(l, r) != 0, nil
+	case "<":
+		return compare(l, r) < 0, nil
+	case "<=":
+		return compare(l, r) <= 0, nil
+	case ">":
+		return compare(l, r) > 0, nil
+	case ">=":
+		return compare(l, r) >= 0, nil
+	case "||":
+		return text(l) + text(r), nil
+	}
+
+	a, b := number(l), number(r)
+	if a == nil || b == nil {
+		return nil, nil
+	}
+	ai, aInt := a.(int64)
+	bi, bInt := b.(int64)
+	if aInt && bInt {
+		switch x.op {
+		case "+":
+			return addInts(ai, bi)
+		case "-":
+			return subInts(ai, bi)
+		case "*":
+			return mulInts(ai, bi)
+		case "/":
+			if bi == 0 {
+				return nil, nil
+			}
+			if ai == math.MinInt64 && bi == -1 {
+				return nil, errIntegerOverflow
+			}
+			return ai / bi, nil
+		case "%":
+			if bi == 0 {
+				return nil, nil
+			}
+			return ai % bi, nil
+		}
+	}
+	af, bf := float(a), float(b)
+	switch x.op {
+	case "+":
+		return af + bf, nil
+	case "-":
+		return af - bf, nil
+	case "*":
+		return af * bf, nil
+	case "/":
+		if bf == 0 {
+			return nil, nil
+		}
+		return af / bf, nil
+	case "%":
+		if bf == 0 {
+			return nil, nil
+		}
+		return math.Mod(af, bf), nil
+	}
+	return nil, fmt.Errorf("unknown operator %s", x.op)
+}
+
+// addInts adds integers, erroring if the sum overflows
+func addInts(a, b int64) (interface{}, error) {
+	c := a + b
+	if (c > a) != (b > 0) {
+		return nil, errIntegerOverflow
+	}
+	return c, nil
+}
+
+// subInts subtracts integers, erroring if the difference overflows
+func subInts(a, b int64) (interface{}, error) {
+	c := a - b
+	if (c < a) != (b > 0) {
+		return nil, errIntegerOverflow
+	}
+	return c, nil
+}
+
+// mulInts multiplies integers, erroring if the product overflows
+func mulInts(a, b int64) (interface{}, error) {
+	if a == 0 || b == 0 {
+		return int64(0), nil
+	}
+	c := a * b
+	if c/b != a || (a == math.MinInt64 && b == -1) {
+		return nil, errIntegerOverflow
+	}
+	return c, nil
+}
+
+// normalize converts numbers to int64 or float64
+func normalize(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int:
+		return int64(x)
+	case int8:
+		return int64(x)
+	case int16:
+		return int64(x)
+	case int32:
+		return int64(x)
+	case uint8:
+		return int64(x)
+	case uint16:
+		return int64(x)
+	case uint32:
+		return int64(x)
+	case uint64:
+		if x <= math.MaxInt64 {
+			return int64(x)
+		}
+		return float64(x)
+	case float32:
+		return float64(x)
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i
+		}
+		if f, err := x.Float64(); err == nil {
+			return f
+		}
+		return x.String()
+	}
+	return v
+}
+
+// number gives a value as an int64 or float64, or nil if it isn't a number.
+// strings holding numbers are converted
+func number(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int64, float64:
+		return v
+	case bool:
+		if x {
+			return int64(1)
+		}
+		return int64(0)
+	case string:
+		s := strings.TrimSpace(x)
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i
+		}
+		if f, err := strconv.ParseFloat(s, 64); err == nil {
+			return f
+		}
+	}
+	return nil
+}
+
+func float(v interface{}) float64 {
+	switch x := v.(type) {
+	case int64:
+		return float64(x)
+	case float64:
+		return x
+	}
+	return 0
+}
+
+// truth gives the boolean value of a condition, ok is false for null
+func truth(v interface{}) (b, ok bool) {
+	switch x := v.(type) {
+	case nil:
+		return false, false
+	case bool:
+		return x, true
+	}
+	if n := number(v); n != nil {
+		return float(n) != 0, true
+	}
+	return false, true
+}
+
+// text formats a value as a string, non-scalar values are formatted as JSON
+func text(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(x)
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+// rank orders values of different types: null, then booleans, numbers,
+// strings, and finally arrays & objects
+func rank(v interface{}) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case bool:
+		return 1
+	case int64, float64:
+		return 2
+	case string:
+		return 3
+	}
+	return 4
+}
+
+// compare orders two values, giving -1, 0 or 1. values of different types are
+// ordered by type
+func compare(a, b interface{}) int {
+	ra, rb := rank(a), rank(b)
+	if ra != rb {
+		if ra < rb {
+			return -1
+		}
+		return 1
+	}
+	switch x := a.(type) {
+	case nil:
+		return 0
+	case bool:
+		y := b.(bool)
+		switch {
+		case x == y:
+			return 0
+		case !x:
+			return -1
+		}
+		return 1
+	case int64:
+		if y, ok := b.(int64); ok {
+			return compareInts(x, y)
+		}
+	case string:
+		return strings.Compare(x, b.(string))
+	}
+	if ra == 2 {
+		fa, fb := float(a), float(b)
+		switch {
+		case fa < fb:
+			return -1
+		case fa > fb:
+			return 1
+		}
+		return 0
+	}
+	return strings.Compare(text(a), text(b))
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// key encodes a value for use as a map key. values that compare as equal have
+// the same key
+func key(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "n"
+	case bool:
+		if x {
+			return "b1"
+		}
+		return "b0"
+	case int64:
+		return "i" + strconv.FormatInt(x, 10)
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1<<63 {
+			return "i" + strconv.FormatInt(int64(x), 10)
+		}
+		return "f" + strconv.FormatFloat(x, 'g', -1, 64)
+	case string:
+		return "s" + x
+	}
+	return "j" + text(v)
+}
+
+// rowKey encodes a list of values as a map key
+func rowKey(vals []interface{}) string {
+	var b strings.Builder
+	for _, v := range vals {
+		k := key(v)
+		b.WriteString(strconv.Itoa(len(k)))
+		b.WriteByte(':')
+		b.WriteString(k)
+	}
+	return b.String()
+}
+
+// function describes a function callable from queries. maxArgs is -1 for any
+// number of arguments
+type function struct {
+	minArgs, maxArgs int
+	aggregate        bool
+	scalar           func(args []interface{}) (interface{}, error)
+	accumulator      func() accumulator
+}
+
+var functions = map[string]function{
+	"COUNT": {minArgs: 1, maxArgs: 1, aggregate: true, accumulator: func() accumulator { return &count{} }},
+	"SUM":   {minArgs: 1, maxArgs: 1, aggregate: true, accumulator: func() accumulator { return &sum{} }},
+	"AVG":   {minArgs: 1, maxArgs: 1, aggregate: true, accumulator: func() accumulator { return &avg{} }},
+	"MIN":   {minArgs: 1, maxArgs: 1, aggregate: true, accumulator: func() accumulator { return &extreme{sign: -1} }},
+	"MAX":   {minArgs: 1, maxArgs: 1, aggregate: true, accumulator: func() accumulator { return &extreme{sign: 1} }},
+
+	"LOWER": {minArgs: 1, maxArgs: 1, scalar: stringFunc(strings.ToLower)},
+	"UPPER": {minArgs: 1, maxArgs: 1, scalar: stringFunc(strings.ToUpper)},
+	"TRIM":  {minArgs: 1, maxArgs: 1, scalar: stringFunc(strings.TrimSpace)},
+	"LENGTH": {minArgs: 1, maxArgs: 1, scalar: func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return int64(utf8.RuneCountInString(text(args[0]))), nil
+	}},
+	"ABS": {minArgs: 1, maxArgs: 1, scalar: func(args []interface{}) (interface{}, error) {
+		switch n := number(args[0]).(type) {
+		case int64:
+			if n == math.MinInt64 {
+				return nil, errIntegerOverflow
+			}
+			if n < 0 {
+				return -n, nil
+			}
+			return n, nil
+		case float64:
+			return math.Abs(n), nil
+		}
+		return nil, nil
+	}},
+	"ROUND": {minArgs: 1, maxArgs: 2, scalar: func(args []interface{}) (interface{}, error) {
+		n := number(args[0])
+		if n == nil {
+			return nil, nil
+		}
+		digits := int64(0)
+		if len(args) == 2 {
+			d, ok := number(args[1]).(int64)
+			if !ok {
+				return nil, nil
+			}
+			digits = d
+		}
+		scale := math.Pow(10, float64(digits))
+		return math.Round(float(n)*scale) / scale, nil
+	}},
+	"COALESCE": {minArgs: 1, maxArgs: -1, scalar: func(args []interface{}) (interface{}, error) {
+		for _, a := range args {
+			if a != nil {
+				return a, nil
+			}
+		}
+		return nil, nil
+	}},
+}
+
+func stringFunc(fn func(string) string) func([]interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		if args[0] == nil {
+			return nil, nil
+		}
+		return fn(text(args[0])), nil
+	}
+}
+
+// accumulator computes an aggregate function over the rows of a group
+type accumulator interface {
+	add(v interface{}) error
+	result() interface{}
+}
+
+// count counts values that aren't null
+type count struct {
+	n int64
+}
+
+func (a *count) add(v interface{}) error {
+	if v != nil {
+		a.n++
+	}
+	return nil
+}
+
+func (a *count) result() interface{} { return a.n }
+
+// sum adds numbers, ignoring other values. sums of integers are integers,
+// erroring if they overflow
+type sum struct {
+	i     int64
+	f     float64
+	float bool
+	any   bool
+}
+
+func (a *sum) add(v interface{}) error {
+	switch n := number(v).(type) {
+	case int64:
+		i, err := addInts(a.i, n)
+		if err != nil {
+			return err
+		}
+		a.i = i.(int64)
+		a.any = true
+	case float64:
+		a.f += n
+		a.float, a.any = true, true
+	}
+	return nil
+}
+
+func (a *sum) result() interface{} {
+	switch {
+	case !a.any:
+		return nil
+	case a.float:
+		return a.f + float64(a.i)
+	}
+	return a.i
+}
+
+// avg gives the mean of numbers, ignoring other values
+type avg struct {
+	total float64
+	n     int64
+}
+
+func (a *avg) add(v interface{}) error {
+	if n := number(v); n != nil {
+		a.total += float(n)
+		a.n++
+	}
+	return nil
+}
+
+func (a *avg) result() interface{} {
+	if a.n == 0 {
+		return nil
+	}
+	return a.total / float64(a.n)
+}
+
+// extreme keeps the smallest (sign -1) or largest (sign 1) value
+type extreme struct {
+	v    interface{}
+	sign int
+}
+
+func (a *extreme) add(v interface{}) error {
+	if v != nil && (a.v == nil || compare(v, a.v) == a.sign) {
+		a.v = v
+	}
+	return nil
+}
+
+func (a *extreme) result() interface{} { return a.v }
+
+// distinct passes each value to an accumulator once
+type distinct struct {
+	accumulator
+	seen map[string]bool
+}
+
+func (a *distinct) add(v interface{}) error {
+	k := key(v)
+	if a.seen[k] {
+		return nil
+	}
+	a.seen[k] = true
+	return a.accumulator.add(v)
+}
@@ -0,0 +1,1142 @@
+package sql
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// Opener opens the body of a dataset for reading, given a dataset reference
+// from a FROM or JOIN clause
+type Opener func(ctx context.Context, ref string) (dsio.EntryReader, error)
+
+// Rows are the results of a query. Rows is a dsio.EntryReader, every entry is
+// an array holding the values of a result row. The structure of Rows names
+// the result columns & has no format, assign one before writing results
+type Rows struct {
+	st      *dataset.Structure
+	it      iterator
+	width   int
+	readers []dsio.EntryReader
+	index   int
+}
+
+var _ dsio.EntryReader = (*Rows)(nil)
+
+// Structure gives the structure of the results
+func (r *Rows) Structure() *dataset.Structure {
+	return r.st
+}
+
+// Columns lists the names of the result columns
+func (r *Rows) Columns() []string {
+	items := r.st.Schema["items"].(map[string]interface{})["items"].([]interface{})
+	names := make([]string, len(items))
+	for i, it := range items {
+		names[i] = it.(map[string]interface{})["title"].(string)
+	}
+	return names
+}
+
+// ReadEntry reads the next result row, returning io.EOF when there are no more
+// rows
+func (r *Rows) ReadEntry() (dsio.Entry, error) {
+	row, err := r.it.next()
+	if err != nil {
+		return dsio.Entry{}, err
+	}
+	ent := dsio.Entry{Index: r.index, Value: row[:r.width]}
+	r.index++
+	return ent, nil
+}
+
+// Close closes the datasets being read
+func (r *Rows) Close() error {
+	var err error
+	for _, rdr := range r.readers {
+		if e := rdr.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	r.readers = nil
+	return err
+}
+
+// Query parses & executes a query
+func Query(ctx context.Context, query string, open Opener) (*Rows, error) {
+	stmt, err := Parse(query)
+	if err != nil {
+		return nil, err
+	}
+	return Exec(ctx, stmt, open)
+}
+
+// Exec executes a statement. Rows of the first dataset in the FROM clause are
+// streamed, joined datasets are read into memory. Queries that group or sort
+// read all rows before returning the first result
+func Exec(ctx context.Context, stmt *Statement, open Opener) (rows *Rows, err error) {
+	rows = &Rows{}
+	defer func() {
+		if err != nil {
+			rows.Close()
+			rows = nil
+		}
+	}()
+
+	sc := &scope{}
+	var it iterator
+	aliases := map[string]bool{}
+	for i, src := range stmt.from {
+		alias := src.alias
+		if alias == "" {
+			alias = defaultAlias(src.ref)
+		}
+		if alias == "" {
+			return rows, fmt.Errorf("dataset %q needs a name, use AS to give it one", src.ref)
+		}
+		if aliases[strings.ToLower(alias)] {
+			return rows, fmt.Errorf("table name %q is used more than once, use AS to rename one", alias)
+		}
+		aliases[strings.ToLower(alias)] = true
+
+		r, err := open(ctx, src.ref)
+		if err != nil {
+			return rows, err
+		}
+		rows.readers = append(rows.readers, r)
+		t, err := newScan(r)
+		if err != nil {
+			return rows, fmt.Errorf("reading %s: %s", src.ref, err)
+		}
+		left := len(sc.cols)
+		for _, c := range t.cols {
+			c.table = alias
+			sc.cols = append(sc.cols, c)
+		}
+		if i == 0 {
+			it = t
+			continue
+		}
+
+		if src.on != nil {
+			if err := sc.bind(src.on, nil, "ON"); err != nil {
+				return rows, err
+			}
+		}
+		j := &join{left: it, kind: src.join, width: len(t.cols)}
+		j.plan(src.on, left)
+		if err := j.load(t, len(sc.cols)); err != nil {
+			return rows, fmt.Errorf("reading %s: %s", src.ref, err)
+		}
+		it = j
+	}
+
+	if stmt.where != nil {
+		if err := sc.bind(stmt.where, nil, "WHERE"); err != nil {
+			return rows, err
+		}
+		it = &filter{in: it, cond: stmt.where}
+	}
+
+	// expand stars & name the result columns
+	type output struct {
+		x    expr
+		name string
+		typ  string
+	}
+	var outs []output
+	for _, item := range stmt.items {
+		if !item.star {
+			name := item.alias
+			if name == "" {
+				name = item.text
+				if c, ok := item.x.(*column); ok {
+					name = c.name
+				}
+			}
+			outs = append(outs, output{x: item.x, name: name})
+			continue
+		}
+		found := false
+		for i, c := range sc.cols {
+			if item.table == "" || strings.EqualFold(item.table, c.table) {
+				outs = append(outs, output{x: &column{table: c.table, name: c.name, index: i}, name: c.name, typ: c.typ})
+				found = true
+			}
+		}
+		if !found && item.table != "" {
+			return rows, fmt.Errorf("no such table: %s", item.table)
+		}
+	}
+
+	// GROUP BY can refer to result columns by position or alias
+	groupBy := make([]expr, len(stmt.groupBy))
+	for i, x := range stmt.groupBy {
+		groupBy[i] = aliased(x, stmt.items, sc)
+		if n, ok := position(x); ok {
+			if n < 1 || n > len(stmt.items) || stmt.items[n-1].star {
+				return rows, fmt.Errorf("GROUP BY position %d doesn't refer to a result column", n)
+			}
+			groupBy[i] = stmt.items[n-1].x
+		}
+		if hasAggregate(groupBy[i]) {
+			return rows, fmt.Errorf("can't GROUP BY an aggregate function")
+		}
+		if err := sc.bind(groupBy[i], nil, "GROUP BY"); err != nil {
+			return rows, err
+		}
+	}
+
+	// ORDER BY can refer to result columns by position or alias, anything else
+	// is computed alongside the result columns
+	sortKeys := make([]sortKey, len(stmt.orderBy))
+	var extra []expr
+	for i, o := range stmt.orderBy {
+		sortKeys[i] = sortKey{index: -1, desc: o.desc}
+		if n, ok := position(o.x); ok {
+			if n < 1 || n > len(outs) {
+				return rows, fmt.Errorf("ORDER BY position %d doesn't refer to a result column", n)
+			}
+			sortKeys[i].index = n - 1
+		} else if c, ok := o.x.(*column); ok && c.table == "" {
+			for j, item := range stmt.items {
+				if item.alias != "" && strings.EqualFold(item.alias, c.name) {
+					sortKeys[i].index = outIndex(stmt, j, sc)
+				}
+			}
+		}
+		if sortKeys[i].index < 0 {
+			sortKeys[i].index = len(outs) + len(extra)
+			extra = append(extra, o.x)
+		}
+	}
+
+	// bind result expressions, collecting aggregate function calls
+	var aggs []*call
+	for _, out := range outs {
+		if err := sc.bind(out.x, &aggs, ""); err != nil {
+			return rows, err
+		}
+	}
+	if stmt.having != nil {
+		stmt.having = aliased(stmt.having, stmt.items, sc)
+		if err := sc.bind(stmt.having, &aggs, ""); err != nil {
+			return rows, err
+		}
+	}
+	for _, x := range extra {
+		if err := sc.bind(x, &aggs, ""); err != nil {
+			return rows, err
+		}
+	}
+
+	exprs := make([]expr, 0, len(outs)+len(extra))
+	for _, out := range outs {
+		exprs = append(exprs, out.x)
+	}
+	exprs = append(exprs, extra...)
+
+	// grouped rows only have values for grouped expressions & aggregates
+	if len(groupBy) > 0 {
+		checks := exprs
+		if stmt.having != nil {
+			checks = append([]expr{stmt.having}, exprs...)
+		}
+		for _, x := range checks {
+			if err := grouped(x, groupBy); err != nil {
+				return rows, err
+			}
+		}
+	}
+
+	if len(aggs) > 0 || len(groupBy) > 0 || stmt.having != nil {
+		for i, c := range aggs {
+			c.agg = len(sc.cols) + i
+		}
+		it = &aggregate{in: it, width: len(sc.cols), groupBy: groupBy, aggs: aggs}
+		if stmt.having != nil {
+			it = &filter{in: it, cond: stmt.having}
+		}
+	}
+
+	it = &project{in: it, exprs: exprs}
+	if stmt.distinct {
+		it = &unique{in: it, width: len(outs), seen: map[string]bool{}}
+	}
+	if len(sortKeys) > 0 {
+		it = &sorter{in: it, keys: sortKeys}
+	}
+	if stmt.limit >= 0 || stmt.offset > 0 {
+		it = &limit{in: it, limit: stmt.limit, offset: stmt.offset}
+	}
+
+	items := make([]interface{}, len(outs))
+	for i, out := range outs {
+		item := map[string]interface{}{"title": out.name}
+		typ := out.typ
+		if typ == "" {
+			typ = exprType(out.x, sc)
+		}
+		if typ != "" {
+			item["type"] = typ
+		}
+		items[i] = item
+	}
+	rows.st = &dataset.Structure{
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type":  "array",
+				"items": items,
+			},
+		},
+	}
+	rows.it = it
+	rows.width = len(outs)
+	return rows, nil
+}
+
+// defaultAlias names a dataset by the name part of its reference
+func defaultAlias(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+// position gives the value of an integer literal, used to refer to result
+// columns by position
+func position(x expr) (int, bool) {
+	if lit, ok := x.(*literal); ok {
+		if n, ok := lit.v.(int64); ok {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
+
+// aliased replaces references to result column aliases with the expressions
+// of those columns. dataset columns take precedence over aliases
+func aliased(x expr, items []*selectItem, sc *scope) expr {
+	switch x := x.(type) {
+	case *column:
+		if x.table != "" || sc.has(x.name) {
+			return x
+		}
+		for _, item := range items {
+			if item.alias != "" && strings.EqualFold(item.alias, x.name) {
+				return item.x
+			}
+		}
+	case *binary:
+		x.l, x.r = aliased(x.l, items, sc), aliased(x.r, items, sc)
+	case *unary:
+		x.x = aliased(x.x, items, sc)
+	case *isNull:
+		x.x = aliased(x.x, items, sc)
+	case *in:
+		x.x = aliased(x.x, items, sc)
+		for i, item := range x.list {
+			x.list[i] = aliased(item, items, sc)
+		}
+	case *match:
+		x.x, x.pattern = aliased(x.x, items, sc), aliased(x.pattern, items, sc)
+	case *call:
+		for i, arg := range x.args {
+			x.args[i] = aliased(arg, items, sc)
+		}
+	}
+	return x
+}
+
+// outIndex gives the index of the first result column produced by a select
+// item, accounting for stars that expand to many columns
+func outIndex(stmt *Statement, item int, sc *scope) int {
+	n := 0
+	for _, it := range stmt.items[:item] {
+		if !it.star {
+			n++
+			continue
+		}
+		for _, c := range sc.cols {
+			if it.table == "" || strings.EqualFold(it.table, c.table) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// exprType gives the jsonschema type of the values of an expression, if known
+func exprType(x expr, sc *scope) string {
+	switch x := x.(type) {
+	case *literal:
+		switch x.v.(type) {
+		case int64:
+			return "integer"
+		case float64:
+			return "number"
+		case string:
+			return "string"
+		case bool:
+			return "boolean"
+		}
+	case *column:
+		return sc.cols[x.index].typ
+	case *isNull, *in, *match:
+		return "boolean"
+	case *unary:
+		if x.op == "NOT" {
+			return "boolean"
+		}
+	case *binary:
+		switch x.op {
+		case "AND", "OR", "=", "!=", "<", "<=", ">", ">=":
+			return "boolean"
+		case "||":
+			return "string"
+		}
+	case *call:
+		switch x.name {
+		case "COUNT", "LENGTH":
+			return "integer"
+		case "AVG", "ROUND":
+			return "number"
+		case "LOWER", "UPPER", "TRIM":
+			return "string"
+		case "MIN", "MAX":
+			return exprType(x.args[0], sc)
+		}
+	}
+	return ""
+}
+
+// scopeCol is a column of the rows being queried
+type scopeCol struct {
+	table, name string
+	// typ is the jsonschema type of the column, if it has a single type
+	typ string
+}
+
+// scope lists the columns available to expressions
+type scope struct {
+	cols []scopeCol
+}
+
+func (sc *scope) has(name string) bool {
+	for _, c := range sc.cols {
+		if strings.EqualFold(c.name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve finds the column an expression refers to. exact matches are
+// preferred to case-insensitive matches
+func (sc *scope) resolve(c *column) error {
+	for _, fold := range []bool{false, true} {
+		found := -1
+		for i, sCol := range sc.cols {
+			if c.table != "" && !strings.EqualFold(c.table, sCol.table) {
+				continue
+			}
+			if sCol.name == c.name || (fold && strings.EqualFold(sCol.name, c.name)) {
+				if found >= 0 {
+					return fmt.Errorf("ambiguous column name: %s", c)
+				}
+				found = i
+			}
+		}
+		if found >= 0 {
+			c.index = found
+			return nil
+		}
+	}
+	return fmt.Errorf("no such column: %s", c)
+}
+
+// bind resolves the columns of an expression. aggregate calls are added to
+// aggs, clause names where aggregates aren't allowed
+func (sc *scope) bind(x expr, aggs *[]*call, clause string) error {
+	switch x := x.(type) {
+	case *column:
+		return sc.resolve(x)
+	case *call:
+		if functions[x.name].aggregate {
+			if aggs == nil {
+				if clause == "" {
+					return fmt.Errorf("aggregate functions can't be nested")
+				}
+				return fmt.Errorf("aggregate functions aren't allowed in %s", clause)
+			}
+			for _, arg := range x.args {
+				if err := sc.bind(arg, nil, ""); err != nil {
+					return err
+				}
+			}
+			*aggs = append(*aggs, x)
+			return nil
+		}
+	}
+	for _, c := range x.children() {
+		if err := sc.bind(c, aggs, clause); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// grouped checks an expression only uses columns through grouped expressions
+// or aggregate functions
+func grouped(x expr, groupBy []expr) error {
+	for _, g := range groupBy {
+		if sameExpr(x, g) {
+			return nil
+		}
+	}
+	switch x := x.(type) {
+	case *column:
+		return fmt.Errorf("column %s must appear in GROUP BY or be used in an aggregate function", x)
+	case *call:
+		if functions[x.name].aggregate {
+			return nil
+		}
+	}
+	for _, c := range x.children() {
+		if err := grouped(c, groupBy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sameExpr reports whether bound expressions compute the same value
+func sameExpr(a, b expr) bool {
+	switch x := a.(type) {
+	case *literal:
+		y, ok := b.(*literal)
+		return ok && x.v == y.v
+	case *column:
+		y, ok := b.(*column)
+		return ok && x.index == y.index
+	case *binary:
+		y, ok := b.(*binary)
+		if !ok || x.op != y.op {
+			return false
+		}
+	case *unary:
+		y, ok := b.(*unary)
+		if !ok || x.op != y.op {
+			return false
+		}
+	case *isNull:
+		y, ok := b.(*isNull)
+		if !ok || x.not != y.not {
+			return false
+		}
+	case *in:
+		y, ok := b.(*in)
+		if !ok || x.not != y.not {
+			return false
+		}
+	case *match:
+		y, ok := b.(*match)
+		if !ok || x.like != y.like || x.not != y.not {
+			return false
+		}
+	case *call:
+		y, ok := b.(*call)
+		if !ok || x.name != y.name || x.star != y.star || x.distinct != y.distinct {
+			return false
+		}
+	default:
+		return false
+	}
+	ac, bc := a.children(), b.children()
+	if len(ac) != len(bc) {
+		return false
+	}
+	for i := range ac {
+		if !sameExpr(ac[i], bc[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *column) String() string {
+	if c.table != "" {
+		return c.table + "." + c.name
+	}
+	return c.name
+}
+
+// iterator produces rows, returning io.EOF after the last row
+type iterator interface {
+	next() ([]interface{}, error)
+}
+
+// row shapes read by scans
+const (
+	// arrays of values, in column order
+	shapeArray = iota
+	// objects with a property per column
+	shapeObject
+	// the keys & values of a body that's an object
+	shapeKeyed
+	// a whole entry per row
+	shapeWhole
+)
+
+// scan reads the rows of a dataset body
+type scan struct {
+	r      dsio.EntryReader
+	cols   []scopeCol
+	shape  int
+	peeked *dsio.Entry
+	// ent is the entry the last row was read from
+	ent dsio.Entry
+	// eof is set after the last entry, some readers fail when read past the end
+	eof bool
+}
+
+// newScan picks columns from the schema of a body. When the schema doesn't
+// describe rows, the first entry is used instead
+func newScan(r dsio.EntryReader) (*scan, error) {
+	s := &scan{r: r}
+	sch := map[string]interface{}{}
+	if st := r.Structure(); st != nil && st.Schema != nil {
+		sch = st.Schema
+	}
+	if sch["type"] == "object" {
+		s.shape = shapeKeyed
+		s.cols = []scopeCol{{name: "key", typ: "string"}, {name: "value"}}
+		return s, nil
+	}
+
+	row, _ := sch["items"].(map[string]interface{})
+	switch row["type"] {
+	case "array":
+		if items, _ := row["items"].([]interface{}); len(items) > 0 {
+			s.shape = shapeArray
+			used := map[string]bool{}
+			for i, it := range items {
+				col, _ := it.(map[string]interface{})
+				name, _ := col["title"].(string)
+				if name == "" || used[strings.ToLower(name)] {
+					name = fmt.Sprintf("field_%d", i+1)
+				}
+				used[strings.ToLower(name)] = true
+				s.cols = append(s.cols, scopeCol{name: name, typ: columnType(col)})
+			}
+			return s, nil
+		}
+	case "object":
+		if props, _ := row["properties"].(map[string]interface{}); len(props) > 0 {
+			s.shape = shapeObject
+			for _, name := range sortedKeys(props) {
+				col, _ := props[name].(map[string]interface{})
+				s.cols = append(s.cols, scopeCol{name: name, typ: columnType(col)})
+			}
+			return s, nil
+		}
+	}
+
+	ent, err := r.ReadEntry()
+	if err != nil && !isEOF(err) {
+		return nil, err
+	}
+	if err == nil {
+		s.peeked = &ent
+	} else {
+		s.eof = true
+	}
+	switch v := ent.Value.(type) {
+	case []interface{}:
+		s.shape = shapeArray
+		for i := range v {
+			s.cols = append(s.cols, scopeCol{name: fmt.Sprintf("field_%d", i+1)})
+		}
+	case map[string]interface{}:
+		s.shape = shapeObject
+		for _, name := range sortedKeys(v) {
+			s.cols = append(s.cols, scopeCol{name: name})
+		}
+	}
+	if len(s.cols) == 0 {
+		s.shape = shapeWhole
+		s.cols = []scopeCol{{name: "value", typ: columnType(row)}}
+	}
+	return s, nil
+}
+
+func (s *scan) next() ([]interface{}, error) {
+	var ent dsio.Entry
+	switch {
+	case s.peeked != nil:
+		ent, s.peeked = *s.peeked, nil
+	case s.eof:
+		return nil, io.EOF
+	default:
+		var err error
+		if ent, err = s.r.ReadEntry(); err != nil {
+			if isEOF(err) {
+				s.eof = true
+				return nil, io.EOF
+			}
+			return nil, err
+		}
+	}
+	s.ent = ent
+
+	row := make([]interface{}, len(s.cols))
+	switch s.shape {
+	case shapeKeyed:
+		row[0], row[1] = ent.Key, ent.Value
+	case shapeWhole:
+		row[0] = ent.Value
+	case shapeArray:
+		if v, ok := ent.Value.([]interface{}); ok {
+			copy(row, v)
+		}
+	case shapeObject:
+		if v, ok := ent.Value.(map[string]interface{}); ok {
+			for i, c := range s.cols {
+				row[i] = v[c.name]
+			}
+		}
+	}
+	for i, v := range row {
+		v = normalize(v)
+		// empty cells of tabular formats are nulls
+		if v == "" && s.cols[i].typ != "" && s.cols[i].typ != "string" {
+			v = nil
+		}
+		row[i] = v
+	}
+	return row, nil
+}
+
+// columnType gives the type of a column if its schema has a single type
+func columnType(sch map[string]interface{}) string {
+	var types []string
+	switch t := sch["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				types = append(types, s)
+			}
+		}
+	}
+	if len(types) != 1 {
+		return ""
+	}
+	return types[0]
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isEOF(err error) bool {
+	return err == io.EOF || err.Error() == "EOF"
+}
+
+// join combines rows of the left side with the rows of a table read into
+// memory. When the join condition compares a left expression with a right
+// expression for equality, rows are matched through a hash table
+type join struct {
+	left  iterator
+	kind  int
+	width int
+	rows  [][]interface{}
+	// cond is the part of the join condition not handled by the hash table
+	cond              expr
+	leftKey, rightKey expr
+	table             map[string][]int
+
+	cur     []interface{}
+	matches []int
+	pos     int
+	matched bool
+}
+
+// plan splits a join condition into a hash key & the remaining condition.
+// columns with an index below left belong to the left side
+func (j *join) plan(on expr, left int) {
+	var rest []expr
+	for _, x := range conjuncts(on) {
+		b, ok := x.(*binary)
+		if ok && b.op == "=" && j.leftKey == nil {
+			l, r := side(b.l, left), side(b.r, left)
+			switch {
+			case l <= 0 && r == 1:
+				j.leftKey, j.rightKey = b.l, b.r
+				continue
+			case r <= 0 && l == 1:
+				j.leftKey, j.rightKey = b.r, b.l
+				continue
+			}
+		}
+		rest = append(rest, x)
+	}
+	for _, x := range rest {
+		if j.cond == nil {
+			j.cond = x
+		} else {
+			j.cond = &binary{op: "AND", l: j.cond, r: x}
+		}
+	}
+}
+
+// conjuncts splits an expression into the parts joined by AND
+func conjuncts(x expr) []expr {
+	if x == nil {
+		return nil
+	}
+	if b, ok := x.(*binary); ok && b.op == "AND" {
+		return append(conjuncts(b.l), conjuncts(b.r)...)
+	}
+	return []expr{x}
+}
+
+// side reports which side of a join an expression reads from: -1 for the left
+// side, 1 for the right, 2 for both & 0 for neither
+func side(x expr, left int) int {
+	s := 0
+	walk(x, func(x expr) error {
+		if c, ok := x.(*column); ok {
+			cs := -1
+			if c.index >= left {
+				cs = 1
+			}
+			if s == 0 {
+				s = cs
+			} else if s != cs {
+				s = 2
+			}
+		}
+		return nil
+	})
+	return s
+}
+
+// load reads the right side of the join into memory. width is the number of
+// columns of joined rows
+func (j *join) load(t *scan, width int) error {
+	if j.rightKey != nil {
+		j.table = map[string][]int{}
+	}
+	scratch := make([]interface{}, width)
+	for {
+		row, err := t.next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		j.rows = append(j.rows, row)
+		if j.rightKey == nil {
+			continue
+		}
+		copy(scratch[width-j.width:], row)
+		v, err := eval(j.rightKey, scratch)
+		if err != nil {
+			return err
+		}
+		// nulls never match
+		if v != nil {
+			k := key(v)
+			j.table[k] = append(j.table[k], len(j.rows)-1)
+		}
+	}
+}
+
+func (j *join) next() ([]interface{}, error) {
+	for {
+		if j.cur == nil {
+			row, err := j.left.next()
+			if err != nil {
+				return nil, err
+			}
+			j.cur, j.pos, j.matched, j.matches = row, 0, false, nil
+			if j.rightKey != nil {
+				v, err := eval(j.leftKey, row)
+				if err != nil {
+					return nil, err
+				}
+				if v != nil {
+					j.matches = j.table[key(v)]
+				}
+			}
+		}
+
+		n := len(j.rows)
+		if j.rightKey != nil {
+			n = len(j.matches)
+		}
+		for j.pos < n {
+			i := j.pos
+			if j.rightKey != nil {
+				i = j.matches[j.pos]
+			}
+			j.pos++
+			row := append(append(make([]interface{}, 0, len(j.cur)+j.width), j.cur...), j.rows[i]...)
+			if j.cond != nil {
+				v, err := eval(j.cond, row)
+				if err != nil {
+					return nil, err
+				}
+				if ok, _ := truth(v); !ok {
+					continue
+				}
+			}
+			j.matched = true
+			return row, nil
+		}
+
+		cur := j.cur
+		j.cur = nil
+		if j.kind == joinLeft && !j.matched {
+			return append(append(make([]interface{}, 0, len(cur)+j.width), cur...), make([]interface{}, j.width)...), nil
+		}
+	}
+}
+
+// filter skips rows that don't meet a condition
+type filter struct {
+	in   iterator
+	cond expr
+}
+
+func (f *filter) next() ([]interface{}, error) {
+	for {
+		row, err := f.in.next()
+		if err != nil {
+			return nil, err
+		}
+		v, err := eval(f.cond, row)
+		if err != nil {
+			return nil, err
+		}
+		if ok, _ := truth(v); ok {
+			return row, nil
+		}
+	}
+}
+
+// aggregate groups rows, producing a row per group: the first row of the
+// group followed by the results of aggregate functions. Expressions that
+// aren't aggregates read the values of the first row of their group
+type aggregate struct {
+	in      iterator
+	width   int
+	groupBy []expr
+	aggs    []*call
+	out     [][]interface{}
+	done    bool
+}
+
+type group struct {
+	row  []interface{}
+	accs []accumulator
+}
+
+func (a *aggregate) newGroup(row []interface{}) *group {
+	g := &group{row: row, accs: make([]accumulator, len(a.aggs))}
+	for i, c := range a.aggs {
+		g.accs[i] = functions[c.name].accumulator()
+		if c.distinct {
+			g.accs[i] = &distinct{accumulator: g.accs[i], seen: map[string]bool{}}
+		}
+	}
+	return g
+}
+
+func (a *aggregate) next() ([]interface{}, error) {
+	if !a.done {
+		if err := a.run(); err != nil {
+			return nil, err
+		}
+		a.done = true
+	}
+	if len(a.out) == 0 {
+		return nil, io.EOF
+	}
+	row := a.out[0]
+	a.out = a.out[1:]
+	return row, nil
+}
+
+func (a *aggregate) run() error {
+	var groups []*group
+	index := map[string]*group{}
+	vals := make([]interface{}, len(a.groupBy))
+	for {
+		row, err := a.in.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		for i, x := range a.groupBy {
+			if vals[i], err = eval(x, row); err != nil {
+				return err
+			}
+		}
+		k := rowKey(vals)
+		g := index[k]
+		if g == nil {
+			g = a.newGroup(row)
+			index[k] = g
+			groups = append(groups, g)
+		}
+		for i, c := range a.aggs {
+			var v interface{} = true
+			if !c.star {
+				if v, err = eval(c.args[0], row); err != nil {
+					return err
+				}
+			}
+			if err := g.accs[i].add(v); err != nil {
+				return err
+			}
+		}
+	}
+
+	// aggregating without groups always gives a row
+	if len(groups) == 0 && len(a.groupBy) == 0 {
+		groups = append(groups, a.newGroup(make([]interface{}, a.width)))
+	}
+	for _, g := range groups {
+		row := make([]interface{}, a.width+len(a.aggs))
+		copy(row, g.row)
+		for i, acc := range g.accs {
+			row[a.width+i] = acc.result()
+		}
+		a.out = append(a.out, row)
+	}
+	return nil
+}
+
+// project computes result columns
+type project struct {
+	in    iterator
+	exprs []expr
+}
+
+func (p *project) next() ([]interface{}, error) {
+	row, err := p.in.next()
+	if err != nil {
+		return nil, err
+	}
+	out := make([]interface{}, len(p.exprs))
+	for i, x := range p.exprs {
+		if out[i], err = eval(x, row); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// unique skips rows that repeat the first width values of an earlier row
+type unique struct {
+	in    iterator
+	width int
+	seen  map[string]bool
+}
+
+func (u *unique) next() ([]interface{}, error) {
+	for {
+		row, err := u.in.next()
+		if err != nil {
+			return nil, err
+		}
+		k := rowKey(row[:u.width])
+		if !u.seen[k] {
+			u.seen[k] = true
+			return row, nil
+		}
+	}
+}
+
+type sortKey struct {
+	index int
+	desc  bool
+}
+
+// sorter reads all rows & sorts them. nulls sort first
+type sorter struct {
+	in   iterator
+	keys []sortKey
+	rows [][]interface{}
+	done bool
+}
+
+func (s *sorter) next() ([]interface{}, error) {
+	if !s.done {
+		for {
+			row, err := s.in.next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			s.rows = append(s.rows, row)
+		}
+		sort.SliceStable(s.rows, func(i, j int) bool {
+			for _, k := range s.keys {
+				c := compare(s.rows[i][k.index], s.rows[j][k.index])
+				if c == 0 {
+					continue
+				}
+				return (c < 0) != k.desc
+			}
+			return false
+		})
+		s.done = true
+	}
+	if len(s.rows) == 0 {
+		return nil, io.EOF
+	}
+	row := s.rows[0]
+	s.rows = s.rows[1:]
+	return row, nil
+}
+
+// limit skips the first offset rows & stops after limit rows. a negative limit
+// doesn't limit rows
+type limit struct {
+	in            iterator
+	limit, offset int64
+	n             int64
+}
+
+func (l *limit) next() ([]interface{}, error) {
+	for ; l.offset > 0; l.offset-- {
+		if _, err := l.in.next(); err != nil {
+			return nil, err
+		}
+	}
+	if l.limit >= 0 && l.n >= l.limit {
+		return nil, io.EOF
+	}
+	l.n++
+	return l.in.next()
+}
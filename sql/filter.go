@@ -0,0 +1,213 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// Filter wraps a reader of a dataset body, selecting fields, filtering &
+// sorting entries as they're read. fields lists the columns of each entry to
+// keep, where is an expression entries must match, with strings in either
+// single or double quotes. sort lists the columns to order entries by, a
+// column prefixed with "-" sorts descending. Filtering streams entries,
+// sorting reads all matching entries into memory
+//
+// Entries keep the shape of the body they're read from, selecting fields
+// trims the arrays or objects of each entry & the schema that describes them
+func Filter(r dsio.EntryReader, fields []string, where string, sort []string) (dsio.EntryReader, error) {
+	s, err := newScan(r)
+	if err != nil {
+		return nil, err
+	}
+	sc := &scope{cols: s.cols}
+	f := &filtered{r: r, s: s, st: r.Structure(), it: &entries{s}}
+	if s.eof {
+		// an empty body has no columns to check fields & expressions against
+		return f, nil
+	}
+
+	if strings.TrimSpace(where) != "" {
+		x, err := parseExpr(where, true)
+		if err != nil {
+			return nil, err
+		}
+		if err := sc.bind(x, nil, "where"); err != nil {
+			return nil, err
+		}
+		f.it = &filter{in: f.it, cond: x}
+	}
+
+	var keys []sortKey
+	for _, name := range sort {
+		name = strings.TrimSpace(name)
+		desc := strings.HasPrefix(name, "-")
+		if name = strings.TrimPrefix(name, "-"); name == "" {
+			continue
+		}
+		c := &column{name: name}
+		if err := sc.resolve(c); err != nil {
+			return nil, err
+		}
+		keys = append(keys, sortKey{index: c.index, desc: desc})
+	}
+	if len(keys) > 0 {
+		f.it = &sorter{in: f.it, keys: keys}
+	}
+
+	if len(fields) > 0 {
+		if s.shape != shapeArray && s.shape != shapeObject {
+			return nil, fmt.Errorf("fields can only be selected from entries that are arrays or objects")
+		}
+		for _, name := range fields {
+			c := &column{name: strings.TrimSpace(name)}
+			if err := sc.resolve(c); err != nil {
+				return nil, err
+			}
+			f.fields = append(f.fields, c.index)
+		}
+		f.st = f.selectSchema()
+	}
+	return f, nil
+}
+
+// entries adds the entry each row was read from as a last column
+type entries struct {
+	s *scan
+}
+
+func (e *entries) next() ([]interface{}, error) {
+	row, err := e.s.next()
+	if err != nil {
+		return nil, err
+	}
+	return append(row, e.s.ent), nil
+}
+
+// filtered reads the entries produced by Filter
+type filtered struct {
+	r      dsio.EntryReader
+	s      *scan
+	st     *dataset.Structure
+	it     iterator
+	fields []int
+	index  int
+}
+
+var _ dsio.EntryReader = (*filtered)(nil)
+
+// Structure gives the structure of filtered entries
+func (f *filtered) Structure() *dataset.Structure {
+	return f.st
+}
+
+// ReadEntry reads the next matching entry, returning io.EOF when there are no
+// more entries
+func (f *filtered) ReadEntry() (dsio.Entry, error) {
+	row, err := f.it.next()
+	if err != nil {
+		return dsio.Entry{}, err
+	}
+	ent := row[len(f.s.cols)].(dsio.Entry)
+	ent.Index = f.index
+	f.index++
+	if f.fields != nil {
+		ent.Value = f.selectFields(ent.Value)
+	}
+	return ent, nil
+}
+
+// Close closes the underlying reader
+func (f *filtered) Close() error {
+	return f.r.Close()
+}
+
+func (f *filtered) selectFields(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		sel := make([]interface{}, len(f.fields))
+		for i, idx := range f.fields {
+			if idx < len(v) {
+				sel[i] = v[idx]
+			}
+		}
+		return sel
+	case map[string]interface{}:
+		sel := make(map[string]interface{}, len(f.fields))
+		for _, idx := range f.fields {
+			name := f.s.cols[idx].name
+			if val, ok := v[name]; ok {
+				sel[name] = val
+			}
+		}
+		return sel
+	}
+	return v
+}
+
+// selectSchema trims the schema of the body to selected fields. schemas that
+// don't describe entries are left as they are
+func (f *filtered) selectSchema() *dataset.Structure {
+	in := f.r.Structure()
+	if in == nil {
+		return nil
+	}
+	st := &dataset.Structure{}
+	st.Assign(in)
+
+	row, _ := in.Schema["items"].(map[string]interface{})
+	if row == nil {
+		return st
+	}
+	sel := copyMap(row)
+	switch f.s.shape {
+	case shapeArray:
+		items, _ := row["items"].([]interface{})
+		if len(items) == 0 {
+			return st
+		}
+		selItems := make([]interface{}, len(f.fields))
+		for i, idx := range f.fields {
+			if idx < len(items) {
+				selItems[i] = items[idx]
+			}
+		}
+		sel["items"] = selItems
+	case shapeObject:
+		props, _ := row["properties"].(map[string]interface{})
+		if len(props) == 0 {
+			return st
+		}
+		selProps := map[string]interface{}{}
+		used := map[string]bool{}
+		for _, idx := range f.fields {
+			name := f.s.cols[idx].name
+			selProps[name] = props[name]
+			used[name] = true
+		}
+		sel["properties"] = selProps
+		if required, ok := row["required"].([]interface{}); ok {
+			selRequired := []interface{}{}
+			for _, name := range required {
+				if s, ok := name.(string); ok && used[s] {
+					selRequired = append(selRequired, name)
+				}
+			}
+			sel["required"] = selRequired
+		}
+	}
+
+	st.Schema = copyMap(in.Schema)
+	st.Schema["items"] = sel
+	return st
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	cp := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		cp[k] = v
+	}
+	return cp
+}
@@ -0,0 +1,124 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func TestFilter(t *testing.T) {
+	cases := []struct {
+		ref    string
+		fields []string
+		where  string
+		sort   []string
+		expect string
+	}{
+		{"me/cities", nil, "", nil,
+			`[["toronto",40000000,55.5,false],["new york",8500000,44.4,true],["chicago",300000,44.4,true],["chatham",35000,65.25,true],["raleigh",250000,50.65,true]]`},
+		{"me/cities", []string{"pop", "city"}, `pop > 100000 and city ~ "^[ct]"`, nil,
+			`[[40000000,"toronto"],[300000,"chicago"]]`},
+		{"me/cities", []string{"city"}, "in_usa", []string{"-avg_age", "city"},
+			`[["chatham"],["raleigh"],["chicago"],["new york"]]`},
+		{"me/cities", nil, "avg_age < 50", []string{"pop"},
+			`[["chicago",300000,44.4,true],["new york",8500000,44.4,true]]`},
+		{"me/states", []string{"state"}, `state = "NY"`, []string{"-city"},
+			`[{"state":"NY"},{"state":"NY"}]`},
+		{"me/counts", nil, "value >= 2 or value is null", []string{"-key"},
+			`{"c":null,"b":2}`},
+		{"me/empty", []string{"city"}, "city = 'x'", []string{"city"},
+			`[]`},
+	}
+
+	for _, c := range cases {
+		r, err := testOpener(context.Background(), c.ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := Filter(r, c.fields, c.where, c.sort)
+		if err != nil {
+			t.Errorf("%s %q: unexpected error: %s", c.ref, c.where, err)
+			continue
+		}
+		got := writeJSON(t, f)
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("%s %q: result mismatch (-want +got):\n%s", c.ref, c.where, diff)
+		}
+	}
+}
+
+func TestFilterStructure(t *testing.T) {
+	r, err := testOpener(context.Background(), "me/cities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Filter(r, []string{"in_usa", "city"}, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []interface{}{
+		map[string]interface{}{"title": "in_usa", "type": "boolean"},
+		map[string]interface{}{"title": "city", "type": "string"},
+	}
+	items := f.Structure().Schema["items"].(map[string]interface{})["items"]
+	if diff := cmp.Diff(expect, items); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if len(citiesStructure.Schema["items"].(map[string]interface{})["items"].([]interface{})) != 4 {
+		t.Errorf("selecting fields modified the schema of the body")
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	cases := []struct {
+		ref    string
+		fields []string
+		where  string
+		sort   []string
+		err    string
+	}{
+		{"me/cities", []string{"nope"}, "", nil, "no such column: nope"},
+		{"me/cities", nil, "nope > 1", nil, "no such column: nope"},
+		{"me/cities", nil, "", []string{"-nope"}, "no such column: nope"},
+		{"me/cities", nil, "pop >", nil, "syntax error at position 6: unexpected end of query"},
+		{"me/cities", nil, "count(*) > 1", nil, "aggregate functions aren't allowed in where"},
+		{"me/counts", []string{"key"}, "", nil, "fields can only be selected from entries that are arrays or objects"},
+	}
+
+	for _, c := range cases {
+		r, err := testOpener(context.Background(), c.ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = Filter(r, c.fields, c.where, c.sort)
+		if err == nil {
+			t.Errorf("%s %q: expected error", c.ref, c.where)
+			continue
+		}
+		if err.Error() != c.err {
+			t.Errorf("%s %q: error mismatch. expected: %q, got: %q", c.ref, c.where, c.err, err.Error())
+		}
+	}
+}
+
+// writeJSON writes all entries of a reader as compact JSON
+func writeJSON(t *testing.T, r dsio.EntryReader) string {
+	t.Helper()
+	st := &dataset.Structure{Format: "json", Schema: r.Structure().Schema}
+	buf := &bytes.Buffer{}
+	w, err := dsio.NewEntryWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dsio.Copy(r, w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
@@ -0,0 +1,205 @@
+package sql
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// token kinds
+const (
+	tkEOF = iota
+	tkIdent
+	// tkQuoted is a quoted identifier, which is never a keyword
+	tkQuoted
+	tkNumber
+	tkString
+	tkSymbol
+)
+
+type token struct {
+	kind int
+	text string
+	pos  int
+}
+
+// is reports whether a token is a keyword or symbol, keywords are case
+// insensitive
+func (t token) is(s string) bool {
+	switch t.kind {
+	case tkIdent:
+		return strings.EqualFold(t.text, s)
+	case tkSymbol:
+		return t.text == s
+	}
+	return false
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tkEOF:
+		return "end of query"
+	case tkString:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// keywords can't be used as implicit aliases
+var keywords = map[string]bool{
+	"SELECT": true, "DISTINCT": true, "FROM": true, "WHERE": true, "GROUP": true,
+	"BY": true, "HAVING": true, "ORDER": true, "ASC": true, "DESC": true,
+	"LIMIT": true, "OFFSET": true, "AS": true, "JOIN": true, "INNER": true,
+	"LEFT": true, "OUTER": true, "CROSS": true, "ON": true, "AND": true,
+	"OR": true, "NOT": true, "IS": true, "NULL": true, "TRUE": true,
+	"FALSE": true, "LIKE": true, "IN": true, "BETWEEN": true,
+}
+
+// symbols are listed longest first so the longest match wins
+var symbols = []string{"<=", ">=", "<>", "!=", "==", "||", "=", "<", ">", "+", "-", "*", "/", "%", "(", ")", ",", ".", ";", "~"}
+
+// lexer scans tokens on demand. The parser never reads more than one token
+// ahead, so it can switch to scanning dataset references after FROM & JOIN
+type lexer struct {
+	src string
+	pos int
+	// doubleQuotedStrings lexes "text" as a string instead of an identifier
+	doubleQuotedStrings bool
+}
+
+func (l *lexer) skipSpace() {
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if unicode.IsSpace(r) {
+			l.pos += size
+			continue
+		}
+		// line comments
+		if strings.HasPrefix(l.src[l.pos:], "--") {
+			if i := strings.IndexByte(l.src[l.pos:], '\n'); i >= 0 {
+				l.pos += i + 1
+				continue
+			}
+			l.pos = len(l.src)
+		}
+		return
+	}
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipSpace()
+	start := l.pos
+	if l.pos >= len(l.src) {
+		return token{kind: tkEOF, pos: start}, nil
+	}
+
+	c := l.src[l.pos]
+	switch {
+	case c == '\'':
+		s, err := l.quoted('\'')
+		return token{kind: tkString, text: s, pos: start}, err
+	case c == '"' && l.doubleQuotedStrings:
+		s, err := l.quoted('"')
+		return token{kind: tkString, text: s, pos: start}, err
+	case c == '"' || c == '`':
+		s, err := l.quoted(c)
+		return token{kind: tkQuoted, text: s, pos: start}, err
+	case isDigit(c) || (c == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1])):
+		return token{kind: tkNumber, text: l.number(), pos: start}, nil
+	case isIdentStart(c):
+		for l.pos < len(l.src) && isIdentPart(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tkIdent, text: l.src[start:l.pos], pos: start}, nil
+	}
+
+	for _, s := range symbols {
+		if strings.HasPrefix(l.src[l.pos:], s) {
+			l.pos += len(s)
+			return token{kind: tkSymbol, text: s, pos: start}, nil
+		}
+	}
+	return token{}, fmt.Errorf("syntax error at position %d: unexpected character %q", start+1, c)
+}
+
+// quoted scans text between quotes, a doubled quote is an escaped quote
+func (l *lexer) quoted(q byte) (string, error) {
+	start := l.pos
+	l.pos++
+	var b strings.Builder
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		l.pos++
+		if c != q {
+			b.WriteByte(c)
+			continue
+		}
+		if l.pos < len(l.src) && l.src[l.pos] == q {
+			b.WriteByte(q)
+			l.pos++
+			continue
+		}
+		return b.String(), nil
+	}
+	return "", fmt.Errorf("syntax error at position %d: unterminated quote", start+1)
+}
+
+func (l *lexer) number() string {
+	start := l.pos
+	for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+		l.pos++
+	}
+	if l.pos < len(l.src) && l.src[l.pos] == '.' {
+		l.pos++
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		end := l.pos + 1
+		if end < len(l.src) && (l.src[end] == '+' || l.src[end] == '-') {
+			end++
+		}
+		if end < len(l.src) && isDigit(l.src[end]) {
+			for l.pos = end; l.pos < len(l.src) && isDigit(l.src[l.pos]); l.pos++ {
+			}
+		}
+	}
+	return l.src[start:l.pos]
+}
+
+// ref scans a dataset reference like me/dataset or me/dataset@/ipfs/Qm...
+// references end at whitespace, a comma, a parenthesis or a semicolon. quoted
+// references may contain any character
+func (l *lexer) ref() (token, error) {
+	l.skipSpace()
+	start := l.pos
+	if l.pos < len(l.src) && (l.src[l.pos] == '"' || l.src[l.pos] == '`') {
+		s, err := l.quoted(l.src[l.pos])
+		return token{kind: tkQuoted, text: s, pos: start}, err
+	}
+	for l.pos < len(l.src) {
+		r, size := utf8.DecodeRuneInString(l.src[l.pos:])
+		if unicode.IsSpace(r) || strings.ContainsRune(",();", r) {
+			break
+		}
+		l.pos += size
+	}
+	if l.pos == start {
+		return token{}, fmt.Errorf("syntax error at position %d: expected a dataset reference", start+1)
+	}
+	return token{kind: tkQuoted, text: l.src[start:l.pos], pos: start}, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= utf8.RuneSelf
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
@@ -0,0 +1,694 @@
+package sql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Statement is a parsed SELECT statement
+type Statement struct {
+	distinct bool
+	items    []*selectItem
+	from     []*source
+	where    expr
+	groupBy  []expr
+	having   expr
+	orderBy  []*ordering
+	limit    int64
+	offset   int64
+}
+
+// Refs lists the dataset references a statement reads from, in the order
+// they appear
+func (s *Statement) Refs() []string {
+	refs := make([]string, len(s.from))
+	for i, src := range s.from {
+		refs[i] = src.ref
+	}
+	return refs
+}
+
+// selectItem is an output column. star items expand to every column of a
+// table, or of all tables if table is empty
+type selectItem struct {
+	star  bool
+	table string
+	x     expr
+	alias string
+	text  string
+}
+
+// join kinds
+const (
+	joinInner = iota
+	joinLeft
+	joinCross
+)
+
+// source is a dataset in the FROM clause. every source after the first is
+// joined to the sources before it
+type source struct {
+	ref   string
+	alias string
+	join  int
+	on    expr
+}
+
+type ordering struct {
+	x    expr
+	desc bool
+}
+
+// Parse parses a SELECT statement
+func Parse(query string) (*Statement, error) {
+	p := &parser{lex: &lexer{src: query}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	stmt, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// parseExpr parses a standalone expression
+func parseExpr(src string, doubleQuotedStrings bool) (expr, error) {
+	p := &parser{lex: &lexer{src: src, doubleQuotedStrings: doubleQuotedStrings}}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	x, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tkEOF {
+		return nil, p.unexpected()
+	}
+	return x, nil
+}
+
+type parser struct {
+	lex *lexer
+	tok token
+	// end is the position just past the last consumed token
+	end int
+}
+
+func (p *parser) advance() (err error) {
+	p.end = p.lex.pos
+	p.tok, err = p.lex.next()
+	return err
+}
+
+func (p *parser) unexpected() error {
+	return fmt.Errorf("syntax error at position %d: unexpected %s", p.tok.pos+1, p.tok)
+}
+
+// accept consumes the current token if it matches a keyword or symbol
+func (p *parser) accept(s string) (bool, error) {
+	if !p.tok.is(s) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) expect(s string) error {
+	if !p.tok.is(s) {
+		return fmt.Errorf("syntax error at position %d: expected %s, got %s", p.tok.pos+1, s, p.tok)
+	}
+	return p.advance()
+}
+
+func (p *parser) statement() (*Statement, error) {
+	if err := p.expect("SELECT"); err != nil {
+		return nil, err
+	}
+	s := &Statement{limit: -1}
+	var err error
+	if s.distinct, err = p.accept("DISTINCT"); err != nil {
+		return nil, err
+	}
+	for {
+		item, err := p.selectItem()
+		if err != nil {
+			return nil, err
+		}
+		s.items = append(s.items, item)
+		if ok, err := p.accept(","); err != nil {
+			return nil, err
+		} else if !ok {
+			break
+		}
+	}
+
+	if !p.tok.is("FROM") {
+		return nil, fmt.Errorf("syntax error at position %d: expected FROM, got %s", p.tok.pos+1, p.tok)
+	}
+	if s.from, err = p.from(); err != nil {
+		return nil, err
+	}
+
+	if ok, err := p.accept("WHERE"); err != nil {
+		return nil, err
+	} else if ok {
+		if s.where, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.tok.is("GROUP") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		if s.groupBy, err = p.exprList(); err != nil {
+			return nil, err
+		}
+	}
+	if ok, err := p.accept("HAVING"); err != nil {
+		return nil, err
+	} else if ok {
+		if s.having, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.tok.is("ORDER") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		if s.orderBy, err = p.orderings(); err != nil {
+			return nil, err
+		}
+	}
+	if ok, err := p.accept("LIMIT"); err != nil {
+		return nil, err
+	} else if ok {
+		if s.limit, err = p.count("LIMIT"); err != nil {
+			return nil, err
+		}
+		if ok, err := p.accept("OFFSET"); err != nil {
+			return nil, err
+		} else if ok {
+			if s.offset, err = p.count("OFFSET"); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if _, err := p.accept(";"); err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tkEOF {
+		return nil, p.unexpected()
+	}
+	return s, nil
+}
+
+func (p *parser) selectItem() (*selectItem, error) {
+	start := p.tok.pos
+	if p.tok.is("*") {
+		return &selectItem{star: true}, p.advance()
+	}
+	// table.* is an identifier followed by a dot & a star
+	if p.tok.kind == tkIdent || p.tok.kind == tkQuoted {
+		save, lexPos, end := p.tok, p.lex.pos, p.end
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if p.tok.is(".") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if p.tok.is("*") {
+				return &selectItem{star: true, table: save.text}, p.advance()
+			}
+		}
+		// not a star, rewind
+		p.tok, p.lex.pos, p.end = save, lexPos, end
+	}
+
+	x, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	item := &selectItem{x: x, text: strings.TrimSpace(p.lex.src[start:p.end])}
+	if item.alias, err = p.alias(); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// alias parses an optional [AS] name
+func (p *parser) alias() (string, error) {
+	as, err := p.accept("AS")
+	if err != nil {
+		return "", err
+	}
+	if p.tok.kind == tkQuoted || (p.tok.kind == tkIdent && !keywords[strings.ToUpper(p.tok.text)]) {
+		name := p.tok.text
+		return name, p.advance()
+	}
+	if as {
+		return "", fmt.Errorf("syntax error at position %d: expected a name after AS, got %s", p.tok.pos+1, p.tok)
+	}
+	return "", nil
+}
+
+// from parses the FROM clause, the current token is FROM. dataset references
+// are scanned directly from the source text, they aren't valid tokens
+func (p *parser) from() ([]*source, error) {
+	var sources []*source
+	join := joinInner
+	for {
+		tok, err := p.lex.ref()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		src := &source{ref: tok.text, join: join}
+		if src.alias, err = p.alias(); err != nil {
+			return nil, err
+		}
+		if len(sources) > 0 && join != joinCross {
+			if err := p.expect("ON"); err != nil {
+				return nil, err
+			}
+			if src.on, err = p.expr(); err != nil {
+				return nil, err
+			}
+		}
+		sources = append(sources, src)
+
+		switch {
+		case p.tok.is(","):
+			join = joinCross
+		case p.tok.is("JOIN"):
+			join = joinInner
+		case p.tok.is("INNER"):
+			join = joinInner
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if !p.tok.is("JOIN") {
+				return nil, fmt.Errorf("syntax error at position %d: expected JOIN, got %s", p.tok.pos+1, p.tok)
+			}
+		case p.tok.is("CROSS"):
+			join = joinCross
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if !p.tok.is("JOIN") {
+				return nil, fmt.Errorf("syntax error at position %d: expected JOIN, got %s", p.tok.pos+1, p.tok)
+			}
+		case p.tok.is("LEFT"):
+			join = joinLeft
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			if _, err := p.accept("OUTER"); err != nil {
+				return nil, err
+			}
+			if !p.tok.is("JOIN") {
+				return nil, fmt.Errorf("syntax error at position %d: expected JOIN, got %s", p.tok.pos+1, p.tok)
+			}
+		default:
+			return sources, nil
+		}
+	}
+}
+
+func (p *parser) exprList() ([]expr, error) {
+	var list []expr
+	for {
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, x)
+		if ok, err := p.accept(","); err != nil {
+			return nil, err
+		} else if !ok {
+			return list, nil
+		}
+	}
+}
+
+func (p *parser) orderings() ([]*ordering, error) {
+	var list []*ordering
+	for {
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		o := &ordering{x: x}
+		if o.desc, err = p.accept("DESC"); err != nil {
+			return nil, err
+		} else if !o.desc {
+			if _, err := p.accept("ASC"); err != nil {
+				return nil, err
+			}
+		}
+		list = append(list, o)
+		if ok, err := p.accept(","); err != nil {
+			return nil, err
+		} else if !ok {
+			return list, nil
+		}
+	}
+}
+
+// count parses the non-negative integer argument of LIMIT & OFFSET
+func (p *parser) count(clause string) (int64, error) {
+	n, err := strconv.ParseInt(p.tok.text, 10, 64)
+	if p.tok.kind != tkNumber || err != nil || n < 0 {
+		return 0, fmt.Errorf("syntax error at position %d: %s must be a non-negative integer", p.tok.pos+1, clause)
+	}
+	return n, p.advance()
+}
+
+// expr parses an expression. operators bind from loosest to tightest: OR,
+// AND, NOT, comparisons, addition & concatenation, multiplication, negation
+func (p *parser) expr() (expr, error) {
+	return p.or()
+}
+
+func (p *parser) or() (expr, error) {
+	x, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.is("OR") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		y, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{op: "OR", l: x, r: y}
+	}
+	return x, nil
+}
+
+func (p *parser) and() (expr, error) {
+	x, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.is("AND") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		y, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{op: "AND", l: x, r: y}
+	}
+	return x, nil
+}
+
+func (p *parser) not() (expr, error) {
+	if p.tok.is("NOT") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		x, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{op: "NOT", x: x}, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (expr, error) {
+	x, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, op := range []string{"=", "==", "!=", "<>", "<", "<=", ">", ">=", "~"} {
+		if !p.tok.is(op) {
+			continue
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		y, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case "==":
+			op = "="
+		case "<>":
+			op = "!="
+		case "~":
+			return newMatch(x, y, false, false)
+		}
+		return &binary{op: op, l: x, r: y}, nil
+	}
+
+	if p.tok.is("IS") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		not, err := p.accept("NOT")
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("NULL"); err != nil {
+			return nil, err
+		}
+		return &isNull{x: x, not: not}, nil
+	}
+
+	not, err := p.accept("NOT")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.tok.is("LIKE"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		y, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		return newMatch(x, y, true, not)
+	case p.tok.is("IN"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		list, err := p.exprList()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return &in{x: x, list: list, not: not}, nil
+	case p.tok.is("BETWEEN"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		lo, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		hi, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		var b expr = &binary{op: "AND", l: &binary{op: ">=", l: x, r: lo}, r: &binary{op: "<=", l: x, r: hi}}
+		if not {
+			b = &unary{op: "NOT", x: b}
+		}
+		return b, nil
+	case not:
+		return nil, fmt.Errorf("syntax error at position %d: expected LIKE, IN or BETWEEN after NOT, got %s", p.tok.pos+1, p.tok)
+	}
+	return x, nil
+}
+
+func (p *parser) additive() (expr, error) {
+	x, err := p.multiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.is("+") || p.tok.is("-") || p.tok.is("||") {
+		op := p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		y, err := p.multiplicative()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{op: op, l: x, r: y}
+	}
+	return x, nil
+}
+
+func (p *parser) multiplicative() (expr, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok.is("*") || p.tok.is("/") || p.tok.is("%") {
+		op := p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		y, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		x = &binary{op: op, l: x, r: y}
+	}
+	return x, nil
+}
+
+func (p *parser) unary() (expr, error) {
+	if p.tok.is("-") || p.tok.is("+") {
+		op := p.tok.text
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if op == "+" {
+			return x, nil
+		}
+		return &unary{op: op, x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (expr, error) {
+	tok := p.tok
+	switch tok.kind {
+	case tkNumber:
+		var v interface{}
+		if i, err := strconv.ParseInt(tok.text, 10, 64); err == nil {
+			v = i
+		} else if f, err := strconv.ParseFloat(tok.text, 64); err == nil {
+			v = f
+		} else {
+			return nil, fmt.Errorf("syntax error at position %d: invalid number %s", tok.pos+1, tok)
+		}
+		return &literal{v: v}, p.advance()
+	case tkString:
+		return &literal{v: tok.text}, p.advance()
+	case tkSymbol:
+		if tok.is("(") {
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+			x, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		}
+		return nil, p.unexpected()
+	case tkQuoted:
+		return p.column()
+	case tkIdent:
+		switch {
+		case tok.is("NULL"):
+			return &literal{}, p.advance()
+		case tok.is("TRUE"):
+			return &literal{v: true}, p.advance()
+		case tok.is("FALSE"):
+			return &literal{v: false}, p.advance()
+		case keywords[strings.ToUpper(tok.text)]:
+			return nil, p.unexpected()
+		}
+		return p.column()
+	}
+	return nil, p.unexpected()
+}
+
+// column parses a column name, table.column, or a function call
+func (p *parser) column() (expr, error) {
+	name := p.tok
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if name.kind == tkIdent && p.tok.is("(") {
+		return p.call(name)
+	}
+	if !p.tok.is(".") {
+		return &column{name: name.text}, nil
+	}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	if p.tok.kind != tkIdent && p.tok.kind != tkQuoted {
+		return nil, fmt.Errorf("syntax error at position %d: expected a column name, got %s", p.tok.pos+1, p.tok)
+	}
+	col := &column{table: name.text, name: p.tok.text}
+	return col, p.advance()
+}
+
+// call parses the arguments of a function call, the current token is the
+// opening parenthesis
+func (p *parser) call(name token) (expr, error) {
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	c := &call{name: strings.ToUpper(name.text), agg: -1}
+	if _, ok := functions[c.name]; !ok {
+		return nil, fmt.Errorf("syntax error at position %d: unknown function %s", name.pos+1, c.name)
+	}
+
+	var err error
+	switch {
+	case p.tok.is("*"):
+		if c.name != "COUNT" {
+			return nil, fmt.Errorf("syntax error at position %d: only COUNT accepts *", p.tok.pos+1)
+		}
+		c.star = true
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	case p.tok.is(")"):
+	default:
+		if c.distinct, err = p.accept("DISTINCT"); err != nil {
+			return nil, err
+		}
+		if c.args, err = p.exprList(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+
+	fn := functions[c.name]
+	if c.distinct && !fn.aggregate {
+		return nil, fmt.Errorf("syntax error at position %d: DISTINCT is only allowed in aggregate functions", name.pos+1)
+	}
+	if !c.star && (len(c.args) < fn.minArgs || (fn.maxArgs >= 0 && len(c.args) > fn.maxArgs)) {
+		return nil, fmt.Errorf("syntax error at position %d: wrong number of arguments to %s", name.pos+1, c.name)
+	}
+	return c, nil
+}
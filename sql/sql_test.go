@@ -0,0 +1,304 @@
+package sql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+const citiesCSV = `city,pop,avg_age,in_usa
+toronto,40000000,55.5,false
+new york,8500000,44.4,true
+chicago,300000,44.4,true
+chatham,35000,65.25,true
+raleigh,250000,50.65,true
+`
+
+const statesJSON = `[
+	{"city": "new york", "state": "NY"},
+	{"city": "chicago", "state": "IL"},
+	{"city": "raleigh", "state": "NC"},
+	{"city": "albany", "state": "NY"}
+]`
+
+var citiesStructure = &dataset.Structure{
+	Format:       "csv",
+	FormatConfig: map[string]interface{}{"headerRow": true},
+	Schema: map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type": "array",
+			"items": []interface{}{
+				map[string]interface{}{"title": "city", "type": "string"},
+				map[string]interface{}{"title": "pop", "type": "integer"},
+				map[string]interface{}{"title": "avg_age", "type": "number"},
+				map[string]interface{}{"title": "in_usa", "type": "boolean"},
+			},
+		},
+	},
+}
+
+// testOpener opens test datasets. me/cities@/ipfs/QmOld is an older version of
+// me/cities holding only the first two rows
+func testOpener(ctx context.Context, ref string) (dsio.EntryReader, error) {
+	switch ref {
+	case "me/cities":
+		return dsio.NewCSVReader(citiesStructure, strings.NewReader(citiesCSV)), nil
+	case "me/cities@/ipfs/QmOld":
+		lines := strings.SplitAfter(citiesCSV, "\n")
+		return dsio.NewCSVReader(citiesStructure, strings.NewReader(strings.Join(lines[:3], ""))), nil
+	case "me/states":
+		st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+		return dsio.NewJSONReader(st, strings.NewReader(statesJSON))
+	case "me/counts":
+		st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaObject}
+		return dsio.NewJSONReader(st, strings.NewReader(`{"a": 1, "b": 2, "c": null}`))
+	case "me/empty":
+		st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+		return dsio.NewJSONReader(st, strings.NewReader(`[]`))
+	}
+	return nil, fmt.Errorf("dataset %q not found", ref)
+}
+
+// query runs a query, encoding results as JSON
+func query(t *testing.T, q string) (string, error) {
+	t.Helper()
+	rows, err := Query(context.Background(), q, testOpener)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var res []interface{}
+	for {
+		ent, err := rows.ReadEntry()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		res = append(res, ent.Value)
+	}
+	data, err := json.Marshal(res)
+	return string(data), err
+}
+
+func TestQuery(t *testing.T) {
+	cases := []struct {
+		query, expect string
+	}{
+		{"SELECT * FROM me/cities LIMIT 2",
+			`[["toronto",40000000,55.5,false],["new york",8500000,44.4,true]]`},
+		{"select city, pop from me/cities where pop > 250000 and in_usa",
+			`[["new york",8500000],["chicago",300000]]`},
+		{"SELECT city FROM me/cities WHERE city LIKE 'CH%' ORDER BY city DESC",
+			`[["chicago"],["chatham"]]`},
+		{"SELECT city FROM me/cities WHERE city ~ '^[rt]' ORDER BY 1",
+			`[["raleigh"],["toronto"]]`},
+		{"SELECT city FROM me/cities WHERE city NOT IN ('toronto', 'chicago') AND pop BETWEEN 35000 AND 300000",
+			`[["chatham"],["raleigh"]]`},
+		{"SELECT city, pop / 1000 AS thousands FROM me/cities ORDER BY thousands LIMIT 2 OFFSET 1",
+			`[["raleigh",250],["chicago",300]]`},
+		{"SELECT in_usa, COUNT(*), SUM(pop) total, MAX(avg_age) FROM me/cities GROUP BY in_usa ORDER BY total",
+			`[[true,4,9085000,65.25],[false,1,40000000,55.5]]`},
+		{"SELECT avg_age, COUNT(*) AS n FROM me/cities GROUP BY 1 HAVING n > 1",
+			`[[44.4,2]]`},
+		{"SELECT pop / 1000000 AS millions, NOT in_usa, COUNT(*) FROM me/cities GROUP BY pop / 1000000, in_usa ORDER BY millions",
+			`[[0,false,3],[8,false,1],[40,true,1]]`},
+		{"SELECT COUNT(DISTINCT avg_age), AVG(pop) > 1000000, MIN(city) FROM me/cities",
+			`[[4,true,"chatham"]]`},
+		{"SELECT COUNT(*), SUM(value) FROM me/empty",
+			`[[0,null]]`},
+		{"SELECT DISTINCT avg_age FROM me/cities ORDER BY avg_age",
+			`[[44.4],[50.65],[55.5],[65.25]]`},
+		{"SELECT UPPER(city) || '!', LENGTH(city), ROUND(avg_age), ABS(-pop) FROM me/cities LIMIT 1",
+			`[["TORONTO!",7,56,40000000]]`},
+		{"SELECT c.city, s.state FROM me/cities AS c JOIN me/states s ON c.city = s.city ORDER BY s.state, c.city",
+			`[["chicago","IL"],["raleigh","NC"],["new york","NY"]]`},
+		{"SELECT cities.city, state FROM me/cities LEFT JOIN me/states ON states.city = cities.city AND state != 'NY'",
+			`[["toronto",null],["new york",null],["chicago","IL"],["chatham",null],["raleigh","NC"]]`},
+		{"SELECT state, COUNT(*) FROM me/states JOIN me/cities ON pop > 1000000 GROUP BY state ORDER BY state",
+			`[["IL",2],["NC",2],["NY",4]]`},
+		{"SELECT COUNT(*) FROM me/cities, me/states",
+			`[[20]]`},
+		{"SELECT old.city, cities.pop - old.pop FROM me/cities@/ipfs/QmOld AS old JOIN me/cities ON old.city = cities.city",
+			`[["toronto",0],["new york",0]]`},
+		{"SELECT key, value FROM me/counts WHERE value IS NOT NULL ORDER BY value DESC",
+			`[["b",2],["a",1]]`},
+		{"SELECT state, city FROM me/states WHERE state = 'NY' AND (city = 'albany' OR NULL)",
+			`[["NY","albany"]]`},
+		{"SELECT COALESCE(NULL, 1 + 2 * 3, 2), 7 / 2, 7 / 2.0, 7 % 0, NOT TRUE FROM me/counts LIMIT 1",
+			`[[7,3,3.5,null,false]]`},
+	}
+
+	for _, c := range cases {
+		got, err := query(t, c.query)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %s", c.query, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("%q: result mismatch (-want +got):\n%s", c.query, diff)
+		}
+	}
+}
+
+func TestExpressions(t *testing.T) {
+	cases := []struct {
+		expr   string
+		expect interface{}
+	}{
+		{"1 + 2 * 3", int64(7)},
+		{"(1 + 2) * 3", int64(9)},
+		{"7 / 2", int64(3)},
+		{"7 / 2.0", 3.5},
+		{"7 % 0", nil},
+		{"-'3' + 1", int64(-2)},
+		{"'a' + 1", nil},
+		{"NULL = NULL", nil},
+		{"NULL IS NULL", true},
+		{"1 IS NOT NULL", true},
+		{"NULL OR TRUE", true},
+		{"NULL AND FALSE", false},
+		{"NULL AND TRUE", nil},
+		{"NOT NULL", nil},
+		{"1 = 1.0", true},
+		{"'10' < '9'", true},
+		{"1 < 'a'", true},
+		{"2 IN (1, NULL)", nil},
+		{"2 NOT IN (1, 3)", true},
+		{"'abc' LIKE 'A_C'", true},
+		{"'abc' NOT LIKE '%d%'", true},
+		{"'Abc' ~ '^A'", true},
+		{"'a' || 1 || TRUE", "a1true"},
+		{"COALESCE(NULL, 'x')", "x"},
+		{"ROUND(2.345, 2)", 2.35},
+		{"LOWER('ABC')", "abc"},
+		{"TRIM('  a ')", "a"},
+		{"LENGTH('héllo')", int64(5)},
+		{"9223372036854775806 + 1", int64(9223372036854775807)},
+		{"-9223372036854775807 - 1", int64(-9223372036854775807 - 1)},
+		{"-4611686018427387904 * 2", int64(-9223372036854775807 - 1)},
+	}
+
+	for _, c := range cases {
+		x, err := parseExpr(c.expr, false)
+		if err != nil {
+			t.Errorf("%q: parse error: %s", c.expr, err)
+			continue
+		}
+		got, err := eval(x, nil)
+		if err != nil {
+			t.Errorf("%q: eval error: %s", c.expr, err)
+			continue
+		}
+		if got != c.expect {
+			t.Errorf("%q: expected %v (%T), got %v (%T)", c.expr, c.expect, c.expect, got, got)
+		}
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	cases := []struct {
+		query, err string
+	}{
+		{"", `syntax error at position 1: expected SELECT, got end of query`},
+		{"SELECT city", `syntax error at position 12: expected FROM, got end of query`},
+		{"SELECT city FROM", `syntax error at position 17: expected a dataset reference`},
+		{"SELECT city FROM me/cities WHERE", `syntax error at position 33: unexpected end of query`},
+		{"SELECT city FROM me/cities LIMIT -1", `syntax error at position 34: LIMIT must be a non-negative integer`},
+		{"SELECT city FROM me/cities extra stuff", `syntax error at position 34: unexpected "stuff"`},
+		{"SELECT nope(city) FROM me/cities", `syntax error at position 8: unknown function NOPE`},
+		{"SELECT SUM(*) FROM me/cities", `syntax error at position 12: only COUNT accepts *`},
+		{"SELECT ROUND(1, 2, 3) FROM me/cities", `syntax error at position 8: wrong number of arguments to ROUND`},
+		{"SELECT 'city FROM me/cities", `syntax error at position 8: unterminated quote`},
+		{"SELECT city FROM me/cities WHERE city ~ '('", "invalid regular expression \"(\": error parsing regexp: missing closing ): `(`"},
+		{"SELECT city FROM me/nope", `dataset "me/nope" not found`},
+		{"SELECT town FROM me/cities", `no such column: town`},
+		{"SELECT city FROM me/cities JOIN me/states ON cities.city = states.city", `ambiguous column name: city`},
+		{"SELECT nope.* FROM me/cities", `no such table: nope`},
+		{"SELECT * FROM me/cities JOIN me/cities ON pop = 1", `table name "cities" is used more than once, use AS to rename one`},
+		{"SELECT * FROM me/cities WHERE COUNT(*) > 1", `aggregate functions aren't allowed in WHERE`},
+		{"SELECT SUM(COUNT(*)) FROM me/cities", `aggregate functions can't be nested`},
+		{"SELECT COUNT(*) FROM me/cities GROUP BY 1", `can't GROUP BY an aggregate function`},
+		{"SELECT city FROM me/cities ORDER BY 2", `ORDER BY position 2 doesn't refer to a result column`},
+		{"SELECT city, COUNT(*) FROM me/cities GROUP BY in_usa", `column city must appear in GROUP BY or be used in an aggregate function`},
+		{"SELECT in_usa FROM me/cities GROUP BY in_usa HAVING pop > 1", `column pop must appear in GROUP BY or be used in an aggregate function`},
+		{"SELECT in_usa FROM me/cities GROUP BY in_usa ORDER BY cities.city", `column cities.city must appear in GROUP BY or be used in an aggregate function`},
+		{"SELECT * FROM me/cities GROUP BY city", `column cities.pop must appear in GROUP BY or be used in an aggregate function`},
+		{"SELECT pop * 9223372036854775807 FROM me/cities", `integer overflow`},
+		{"SELECT -9223372036854775807 - pop FROM me/cities", `integer overflow`},
+		{"SELECT SUM(pop + 9223372036800000000) FROM me/cities", `integer overflow`},
+	}
+
+	for _, c := range cases {
+		_, err := query(t, c.query)
+		if err == nil {
+			t.Errorf("%q: expected error", c.query)
+			continue
+		}
+		if err.Error() != c.err {
+			t.Errorf("%q: error mismatch. expected: %q, got: %q", c.query, c.err, err.Error())
+		}
+	}
+}
+
+func TestRowsStructure(t *testing.T) {
+	rows, err := Query(context.Background(), "SELECT city, pop AS people, COUNT(*), in_usa AND pop > 1, pop + 1 FROM me/cities", testOpener)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	expect := []interface{}{
+		map[string]interface{}{"title": "city", "type": "string"},
+		map[string]interface{}{"title": "people", "type": "integer"},
+		map[string]interface{}{"title": "COUNT(*)", "type": "integer"},
+		map[string]interface{}{"title": "in_usa AND pop > 1", "type": "boolean"},
+		map[string]interface{}{"title": "pop + 1"},
+	}
+	items := rows.Structure().Schema["items"].(map[string]interface{})["items"]
+	if diff := cmp.Diff(expect, items); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"city", "people", "COUNT(*)", "in_usa AND pop > 1", "pop + 1"}, rows.Columns()); diff != "" {
+		t.Errorf("columns mismatch (-want +got):\n%s", diff)
+	}
+
+	// rows are entry readers, results can be written in any format
+	st := &dataset.Structure{Format: "csv", FormatConfig: map[string]interface{}{"headerRow": true}, Schema: rows.Structure().Schema}
+	buf := &bytes.Buffer{}
+	w, err := dsio.NewEntryWriter(st, buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := dsio.Copy(rows, w); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	expectCSV := "city,people,COUNT(*),in_usa AND pop > 1,pop + 1\ntoronto,40000000,5,false,40000001\n"
+	if diff := cmp.Diff(expectCSV, buf.String()); diff != "" {
+		t.Errorf("csv mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStatementRefs(t *testing.T) {
+	stmt, err := Parse(`SELECT * FROM me/a@/ipfs/QmA AS a LEFT OUTER JOIN "me/b" ON a.x = b.x CROSS JOIN me/c, me/d;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"me/a@/ipfs/QmA", "me/b", "me/c", "me/d"}, stmt.Refs()); diff != "" {
+		t.Errorf("refs mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,338 @@
+package sqlite
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// b-tree page types
+const (
+	pageInterior byte = 0x05
+	pageLeaf     byte = 0x0d
+)
+
+const (
+	// maxLocal is the largest payload stored entirely on a leaf page
+	maxLocal = pageSize - 35
+	// minLocal is the smallest part of a payload kept on a leaf page when the
+	// payload spills onto overflow pages
+	minLocal = (pageSize-12)*32/255 - 23
+	// interiorCellSize is the largest size of an interior cell: a page number,
+	// a rowid varint & a cell pointer
+	interiorCellSize = 4 + 9 + 2
+)
+
+// child is a page pointed to by an interior page. maxRowid is the largest
+// rowid stored under the page
+type child struct {
+	pgno     uint32
+	maxRowid int64
+}
+
+// page is a table b-tree page being built. offset is where the page header
+// starts, 100 bytes into page 1 and 0 for every other page
+type page struct {
+	offset   int
+	typ      byte
+	cells    [][]byte
+	used     int
+	maxRowid int64
+	right    uint32
+}
+
+func newPage(offset int, typ byte) *page {
+	return &page{offset: offset, typ: typ}
+}
+
+func (p *page) headerLen() int {
+	if p.typ == pageInterior {
+		return 12
+	}
+	return 8
+}
+
+// add appends a cell if it fits on the page
+func (p *page) add(cell []byte, rowid int64) bool {
+	if p.offset+p.headerLen()+2*(len(p.cells)+1)+p.used+len(cell) > pageSize {
+		return false
+	}
+	p.cells = append(p.cells, cell)
+	p.used += len(cell)
+	p.maxRowid = rowid
+	return true
+}
+
+// encode lays out a page: the header, then cell pointers, with cell content
+// packed at the end of the page
+func (p *page) encode() []byte {
+	buf := make([]byte, pageSize)
+	h := buf[p.offset:]
+	h[0] = p.typ
+	binary.BigEndian.PutUint16(h[3:], uint16(len(p.cells)))
+	if p.typ == pageInterior {
+		binary.BigEndian.PutUint32(h[8:], p.right)
+	}
+	ptrs := h[p.headerLen():]
+	end := pageSize
+	for i, c := range p.cells {
+		end -= len(c)
+		copy(buf[end:], c)
+		binary.BigEndian.PutUint16(ptrs[2*i:], uint16(end))
+	}
+	binary.BigEndian.PutUint16(h[5:], uint16(end))
+	return buf
+}
+
+// cell encodes a row as a table leaf cell. Payloads too large to fit on a
+// page are split, writing the rest of the payload to overflow pages
+func (db *Writer) cell(rowid int64, values []interface{}) ([]byte, error) {
+	payload, err := encodeRecord(values)
+	if err != nil {
+		return nil, err
+	}
+
+	local := len(payload)
+	if local > maxLocal {
+		local = minLocal + (len(payload)-minLocal)%(pageSize-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+
+	cell := appendVarint(nil, uint64(len(payload)))
+	cell = appendVarint(cell, uint64(rowid))
+	cell = append(cell, payload[:local]...)
+	if local == len(payload) {
+		return cell, nil
+	}
+
+	rest := payload[local:]
+	n := (len(rest) + pageSize - 5) / (pageSize - 4)
+	first := db.pages + 1
+	for i := 0; i < n; i++ {
+		db.allocate()
+	}
+	var ptr [4]byte
+	binary.BigEndian.PutUint32(ptr[:], first)
+	cell = append(cell, ptr[:]...)
+
+	for i := 0; i < n; i++ {
+		buf := make([]byte, pageSize)
+		if i < n-1 {
+			binary.BigEndian.PutUint32(buf, first+uint32(i)+1)
+		}
+		rest = rest[copy(buf[4:], rest):]
+		if _, err := db.w.WriteAt(buf, int64(first+uint32(i)-1)*pageSize); err != nil {
+			return nil, err
+		}
+	}
+	return cell, nil
+}
+
+// writeInterior writes the interior pages of a table b-tree above children,
+// returning the root page
+func (db *Writer) writeInterior(children []child) (uint32, error) {
+	children, err := db.reduce(children, 1, (pageSize-12)/interiorCellSize+1)
+	if err != nil {
+		return 0, err
+	}
+	return children[0].pgno, nil
+}
+
+// writeSchema writes the sqlite_master table, which is always rooted at page 1
+func (db *Writer) writeSchema(cells [][]byte) error {
+	root := newPage(headerSize, pageLeaf)
+	fits := true
+	for i, c := range cells {
+		if fits = root.add(c, int64(i+1)); !fits {
+			break
+		}
+	}
+	if fits {
+		return db.writePage(1, root)
+	}
+
+	var children []child
+	leaf := newPage(0, pageLeaf)
+	for i, c := range cells {
+		if leaf.add(c, int64(i+1)) {
+			continue
+		}
+		pgno := db.allocate()
+		if err := db.writePage(pgno, leaf); err != nil {
+			return err
+		}
+		children = append(children, child{pgno: pgno, maxRowid: leaf.maxRowid})
+		leaf = newPage(0, pageLeaf)
+		leaf.add(c, int64(i+1))
+	}
+	pgno := db.allocate()
+	if err := db.writePage(pgno, leaf); err != nil {
+		return err
+	}
+	children = append(children, child{pgno: pgno, maxRowid: leaf.maxRowid})
+
+	rootChildren := (pageSize-headerSize-12)/interiorCellSize + 1
+	children, err := db.reduce(children, rootChildren, (pageSize-12)/interiorCellSize+1)
+	if err != nil {
+		return err
+	}
+	return db.writePage(1, interiorPage(headerSize, children))
+}
+
+// reduce adds levels of interior pages above children until there are no more
+// than limit pages at the top level. Pages are split evenly, so every interior
+// page has at least two children
+func (db *Writer) reduce(children []child, limit, capacity int) ([]child, error) {
+	for len(children) > limit {
+		n := (len(children) + capacity - 1) / capacity
+		if n == 1 && limit > 1 {
+			n = 2
+		}
+		level := make([]child, 0, n)
+		for i := 0; i < n; i++ {
+			group := children[len(children)*i/n : len(children)*(i+1)/n]
+			pgno := db.allocate()
+			if err := db.writePage(pgno, interiorPage(0, group)); err != nil {
+				return nil, err
+			}
+			level = append(level, child{pgno: pgno, maxRowid: group[len(group)-1].maxRowid})
+		}
+		children = level
+	}
+	return children, nil
+}
+
+// interiorPage points to children, the last child is the right-most pointer
+func interiorPage(offset int, children []child) *page {
+	p := newPage(offset, pageInterior)
+	for _, c := range children[:len(children)-1] {
+		var cell [4]byte
+		binary.BigEndian.PutUint32(cell[:], c.pgno)
+		p.add(appendVarint(cell[:], uint64(c.maxRowid)), c.maxRowid)
+	}
+	last := children[len(children)-1]
+	p.right, p.maxRowid = last.pgno, last.maxRowid
+	return p
+}
+
+// encodeRecord encodes values in the record format: a header of serial types
+// followed by the value of each column
+func encodeRecord(values []interface{}) ([]byte, error) {
+	var types, body []byte
+	for _, v := range values {
+		var typ uint64
+		switch x := v.(type) {
+		case nil:
+			typ = 0
+		case bool:
+			typ = 8
+			if x {
+				typ = 9
+			}
+		case int:
+			typ, body = appendInt(body, int64(x))
+		case int32:
+			typ, body = appendInt(body, int64(x))
+		case int64:
+			typ, body = appendInt(body, x)
+		case float32:
+			typ, body = appendFloat(body, float64(x))
+		case float64:
+			typ, body = appendFloat(body, x)
+		case string:
+			typ = uint64(len(x))*2 + 13
+			body = append(body, x...)
+		case []byte:
+			typ = uint64(len(x))*2 + 12
+			body = append(body, x...)
+		default:
+			return nil, fmt.Errorf("unsupported value type %T", v)
+		}
+		types = appendVarint(types, typ)
+	}
+
+	// the header length includes the varint that encodes it
+	n := len(types) + 1
+	for n != len(types)+varintLen(uint64(n)) {
+		n = len(types) + varintLen(uint64(n))
+	}
+	rec := appendVarint(make([]byte, 0, n+len(body)), uint64(n))
+	rec = append(rec, types...)
+	return append(rec, body...), nil
+}
+
+// appendInt writes an integer with the smallest serial type that holds it
+func appendInt(b []byte, v int64) (uint64, []byte) {
+	var typ uint64
+	var size uint
+	switch {
+	case v == 0:
+		return 8, b
+	case v == 1:
+		return 9, b
+	case v >= math.MinInt8 && v <= math.MaxInt8:
+		typ, size = 1, 1
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		typ, size = 2, 2
+	case v >= -1<<23 && v < 1<<23:
+		typ, size = 3, 3
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		typ, size = 4, 4
+	case v >= -1<<47 && v < 1<<47:
+		typ, size = 5, 6
+	default:
+		typ, size = 6, 8
+	}
+	for i := size; i > 0; i-- {
+		b = append(b, byte(v>>(8*(i-1))))
+	}
+	return typ, b
+}
+
+// appendFloat writes a big-endian float. NaN is stored as null, as sqlite does
+func appendFloat(b []byte, v float64) (uint64, []byte) {
+	if math.IsNaN(v) {
+		return 0, b
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], math.Float64bits(v))
+	return 7, append(b, buf[:]...)
+}
+
+// appendVarint writes a sqlite varint: big-endian groups of 7 bits, with a
+// ninth byte holding 8 bits
+func appendVarint(b []byte, v uint64) []byte {
+	if v > 1<<56-1 {
+		var buf [9]byte
+		buf[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			buf[i] = byte(v&0x7f) | 0x80
+			v >>= 7
+		}
+		return append(b, buf[:]...)
+	}
+	var buf [8]byte
+	n := 0
+	for {
+		buf[n] = byte(v & 0x7f)
+		if n > 0 {
+			buf[n] |= 0x80
+		}
+		n++
+		v >>= 7
+		if v == 0 {
+			break
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		b = append(b, buf[i])
+	}
+	return b
+}
+
+func varintLen(v uint64) int {
+	return len(appendVarint(nil, v))
+}
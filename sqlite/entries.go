@@ -0,0 +1,231 @@
+package sqlite
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// EntryWriter writes dataset body entries as the rows of a table. Array rows
+// map items to columns in order, object rows map properties to columns sorted
+// by name. Bodies that are objects are written as a table of keys & values
+type EntryWriter struct {
+	st         *dataset.Structure
+	table      *Table
+	objectRows bool
+	// keyed tables hold the keys & values of an object body
+	keyed bool
+	// whole tables store each entry in a single column
+	whole bool
+	index map[string]int
+}
+
+var _ dsio.EntryWriter = (*EntryWriter)(nil)
+
+// NewEntryWriter creates a table in db for a dataset body, picking column names
+// & types from the structure's schema
+func NewEntryWriter(db *Writer, name string, st *dataset.Structure) (*EntryWriter, error) {
+	if st == nil {
+		st = &dataset.Structure{}
+	}
+	w := &EntryWriter{st: st}
+	cols, err := w.columns(st.Schema)
+	if err != nil {
+		return nil, err
+	}
+	if w.table, err = db.CreateTable(name, cols); err != nil {
+		return nil, err
+	}
+	w.index = make(map[string]int, len(cols))
+	for i, c := range cols {
+		w.index[c.Name] = i
+	}
+	return w, nil
+}
+
+// columns picks the columns of a table from a body schema
+func (w *EntryWriter) columns(sch map[string]interface{}) ([]Column, error) {
+	typ, _ := sch["type"].(string)
+	switch typ {
+	case "object":
+		w.keyed = true
+		return []Column{{Name: "key", Type: "TEXT"}, {Name: "value"}}, nil
+	case "array":
+	default:
+		return nil, fmt.Errorf("a schema with a top level type of array or object is required")
+	}
+
+	row, _ := sch["items"].(map[string]interface{})
+	switch row["type"] {
+	case "array":
+		items, _ := row["items"].([]interface{})
+		if len(items) == 0 {
+			break
+		}
+		cols := make([]Column, len(items))
+		used := map[string]bool{}
+		for i, it := range items {
+			col, _ := it.(map[string]interface{})
+			name, _ := col["title"].(string)
+			if name == "" || used[strings.ToLower(name)] {
+				name = fmt.Sprintf("field_%d", i+1)
+			}
+			used[strings.ToLower(name)] = true
+			cols[i] = Column{Name: name, Type: columnType(col)}
+		}
+		return cols, nil
+	case "object":
+		props, _ := row["properties"].(map[string]interface{})
+		if len(props) == 0 {
+			break
+		}
+		w.objectRows = true
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		cols := make([]Column, len(names))
+		for i, name := range names {
+			col, _ := props[name].(map[string]interface{})
+			cols[i] = Column{Name: name, Type: columnType(col)}
+		}
+		return cols, nil
+	}
+
+	// rows that aren't described by the schema are stored in a single column
+	w.whole = true
+	return []Column{{Name: "value", Type: columnType(row)}}, nil
+}
+
+// columnType picks the declared type of a column from its jsonschema
+func columnType(sch map[string]interface{}) string {
+	var types []string
+	switch t := sch["type"].(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				types = append(types, s)
+			}
+		}
+	}
+	if len(types) != 1 {
+		return ""
+	}
+	switch types[0] {
+	case "integer", "boolean":
+		return "INTEGER"
+	case "number":
+		return "REAL"
+	case "string":
+		return "TEXT"
+	}
+	return ""
+}
+
+// Structure gives the structure being written
+func (w *EntryWriter) Structure() *dataset.Structure {
+	return w.st
+}
+
+// Table gives the table being written
+func (w *EntryWriter) Table() *Table {
+	return w.table
+}
+
+// WriteEntry inserts an entry as a row
+func (w *EntryWriter) WriteEntry(ent dsio.Entry) error {
+	cols := w.table.Columns()
+	row := make([]interface{}, len(cols))
+
+	switch {
+	case w.keyed:
+		row[0], row[1] = ent.Key, ent.Value
+	case w.whole:
+		row[0] = ent.Value
+	default:
+		switch v := ent.Value.(type) {
+		case []interface{}:
+			if w.objectRows {
+				return fmt.Errorf("entry %d: expected an object", ent.Index)
+			}
+			if len(v) > len(cols) {
+				return fmt.Errorf("entry %d: has %d values, table %q has %d columns", ent.Index, len(v), w.table.Name(), len(cols))
+			}
+			copy(row, v)
+		case map[string]interface{}:
+			if !w.objectRows {
+				return fmt.Errorf("entry %d: expected an array", ent.Index)
+			}
+			for key, val := range v {
+				i, ok := w.index[key]
+				if !ok {
+					return fmt.Errorf("entry %d: key %q isn't a column of table %q", ent.Index, key, w.table.Name())
+				}
+				row[i] = val
+			}
+		default:
+			return fmt.Errorf("entry %d: expected an array or object", ent.Index)
+		}
+	}
+
+	for i, c := range cols {
+		v, err := columnValue(c, row[i])
+		if err != nil {
+			return fmt.Errorf("entry %d, column %q: %s", ent.Index, c.Name, err)
+		}
+		row[i] = v
+	}
+	return w.table.Insert(row...)
+}
+
+// Close finishes writing the table. Close doesn't close the database
+func (w *EntryWriter) Close() error {
+	return w.table.Close()
+}
+
+// columnValue converts a body value to the value stored in a column. Values
+// are kept as-is if they don't match the column type, sqlite columns accept
+// values of any type
+func columnValue(c Column, v interface{}) (interface{}, error) {
+	switch x := v.(type) {
+	case nil, bool, int, int32, int64, float32:
+		return v, nil
+	case float64:
+		if c.Type == "INTEGER" && x == math.Trunc(x) && math.Abs(x) < 1<<63 {
+			return int64(x), nil
+		}
+		return v, nil
+	case string:
+		if x == "" && c.Type != "TEXT" && c.Type != "" {
+			// empty cells of tabular formats are nulls
+			return nil, nil
+		}
+		switch c.Type {
+		case "INTEGER":
+			if i, err := strconv.ParseInt(x, 10, 64); err == nil {
+				return i, nil
+			}
+		case "REAL":
+			if f, err := strconv.ParseFloat(x, 64); err == nil {
+				return f, nil
+			}
+		}
+		return v, nil
+	}
+
+	// nested values are stored as JSON text
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
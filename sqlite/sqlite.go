@@ -0,0 +1,276 @@
+// Package sqlite writes dataset bodies as tables of a SQLite database file.
+// Databases are written once, front to back: tables are created one at a time,
+// rows are appended to the open table, and the database schema is written when
+// the database is closed. Reading & modifying existing databases isn't
+// supported.
+//
+// jsonschema types map to column types as follows:
+//
+//	integer     INTEGER
+//	number      REAL
+//	boolean     INTEGER (0 or 1)
+//	string      TEXT
+//	other       no type, values are stored as JSON text
+package sqlite
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// pageSize is the size of every page in written databases
+	pageSize = 4096
+	// headerSize is the size of the database header at the start of page 1
+	headerSize = 100
+	// sqliteVersion is the SQLITE_VERSION_NUMBER written to database headers,
+	// the earliest version that reads every feature of written files
+	sqliteVersion = 3008000
+)
+
+// Column is a column of a table
+type Column struct {
+	Name string
+	// Type is the declared type of the column: INTEGER, REAL, TEXT, BLOB or
+	// empty for no type
+	Type string
+}
+
+// Writer writes a SQLite database
+type Writer struct {
+	w     io.WriterAt
+	pages uint32
+	// master holds rows of the sqlite_master table
+	master [][]interface{}
+	names  map[string]bool
+	open   *Table
+	err    error
+}
+
+// NewWriter creates a database Writer. writes go to page offsets, so the
+// destination must support writing at arbitrary offsets, like an *os.File
+func NewWriter(w io.WriterAt) *Writer {
+	return &Writer{
+		w: w,
+		// page 1 is reserved for the database header & schema, written on close
+		pages: 1,
+		names: map[string]bool{},
+	}
+}
+
+// CreateTable adds a table to the database. Only one table can be written at
+// a time, the previous table must be closed before creating another
+func (db *Writer) CreateTable(name string, cols []Column) (*Table, error) {
+	if db.err != nil {
+		return nil, db.err
+	}
+	if db.open != nil {
+		return nil, fmt.Errorf("table %q must be closed before creating another", db.open.name)
+	}
+	if name == "" {
+		return nil, fmt.Errorf("table name is required")
+	}
+	if Reserved(name) {
+		return nil, fmt.Errorf("table name %q is reserved", name)
+	}
+	if db.HasTable(name) {
+		return nil, fmt.Errorf("table %q already exists", name)
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("table %q has no columns", name)
+	}
+	seen := map[string]bool{}
+	for _, c := range cols {
+		if seen[strings.ToLower(c.Name)] {
+			return nil, fmt.Errorf("table %q has duplicate column %q", name, c.Name)
+		}
+		seen[strings.ToLower(c.Name)] = true
+	}
+
+	db.names[strings.ToLower(name)] = true
+	db.open = &Table{db: db, name: name, cols: cols, leaf: newPage(0, pageLeaf)}
+	return db.open, nil
+}
+
+// Reserved reports whether a table name is reserved for sqlite's own tables
+func Reserved(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), "sqlite_")
+}
+
+// HasTable reports whether a table name is already used. Table names are case
+// insensitive
+func (db *Writer) HasTable(name string) bool {
+	return db.names[strings.ToLower(name)]
+}
+
+// Close writes the database schema & header. Close doesn't close the
+// underlying writer
+func (db *Writer) Close() error {
+	if db.err != nil {
+		return db.err
+	}
+	if db.open != nil {
+		if err := db.open.Close(); err != nil {
+			return err
+		}
+	}
+
+	// the schema table is rooted at page 1, after the database header
+	cells := make([][]byte, len(db.master))
+	for i, row := range db.master {
+		cell, err := db.cell(int64(i+1), row)
+		if err != nil {
+			return db.fail(err)
+		}
+		cells[i] = cell
+	}
+	if err := db.writeSchema(cells); err != nil {
+		return db.fail(err)
+	}
+
+	if _, err := db.w.WriteAt(db.header(), 0); err != nil {
+		return db.fail(err)
+	}
+	db.err = fmt.Errorf("database is closed")
+	return nil
+}
+
+// header encodes the 100 byte database header
+func (db *Writer) header() []byte {
+	h := make([]byte, headerSize)
+	copy(h, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(h[16:], pageSize)
+	// legacy journal mode file format versions
+	h[18], h[19] = 1, 1
+	// embedded payload fractions, which must be 64, 32 & 32
+	h[21], h[22], h[23] = 64, 32, 32
+	// file change counter
+	binary.BigEndian.PutUint32(h[24:], 1)
+	binary.BigEndian.PutUint32(h[28:], db.pages)
+	// schema cookie
+	binary.BigEndian.PutUint32(h[40:], 1)
+	// schema format 4 allows the 0 & 1 integer serial types
+	binary.BigEndian.PutUint32(h[44:], 4)
+	// UTF-8 text encoding
+	binary.BigEndian.PutUint32(h[56:], 1)
+	// version-valid-for matches the change counter, making the page count valid
+	binary.BigEndian.PutUint32(h[92:], 1)
+	binary.BigEndian.PutUint32(h[96:], sqliteVersion)
+	return h
+}
+
+func (db *Writer) fail(err error) error {
+	db.err = err
+	return err
+}
+
+// allocate reserves the next page number
+func (db *Writer) allocate() uint32 {
+	db.pages++
+	return db.pages
+}
+
+func (db *Writer) writePage(pgno uint32, p *page) error {
+	_, err := db.w.WriteAt(p.encode(), int64(pgno-1)*pageSize)
+	return err
+}
+
+// Table writes the rows of a table
+type Table struct {
+	db       *Writer
+	name     string
+	cols     []Column
+	leaf     *page
+	children []child
+	rowid    int64
+	closed   bool
+}
+
+// Name gives the name of the table
+func (t *Table) Name() string {
+	return t.name
+}
+
+// Columns gives the columns of the table
+func (t *Table) Columns() []Column {
+	return t.cols
+}
+
+// Insert appends a row to the table. values must be nil, integers, floats,
+// bools, strings or []byte, and there can't be more values than columns.
+// missing values are null
+func (t *Table) Insert(values ...interface{}) error {
+	if t.closed {
+		return fmt.Errorf("table %q is closed", t.name)
+	}
+	if t.db.err != nil {
+		return t.db.err
+	}
+	if len(values) > len(t.cols) {
+		return fmt.Errorf("table %q has %d columns, got %d values", t.name, len(t.cols), len(values))
+	}
+	t.rowid++
+	cell, err := t.db.cell(t.rowid, values)
+	if err != nil {
+		return t.db.fail(err)
+	}
+	if !t.leaf.add(cell, t.rowid) {
+		// the leaf is full, write it out
+		pgno := t.db.allocate()
+		if err := t.db.writePage(pgno, t.leaf); err != nil {
+			return t.db.fail(err)
+		}
+		t.children = append(t.children, child{pgno: pgno, maxRowid: t.leaf.maxRowid})
+		t.leaf = newPage(0, pageLeaf)
+		t.leaf.add(cell, t.rowid)
+	}
+	return nil
+}
+
+// Close writes the rest of the table & adds it to the database schema
+func (t *Table) Close() error {
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	t.db.open = nil
+	if t.db.err != nil {
+		return t.db.err
+	}
+
+	pgno := t.db.allocate()
+	if err := t.db.writePage(pgno, t.leaf); err != nil {
+		return t.db.fail(err)
+	}
+	root := pgno
+	if len(t.children) > 0 {
+		var err error
+		children := append(t.children, child{pgno: pgno, maxRowid: t.leaf.maxRowid})
+		if root, err = t.db.writeInterior(children); err != nil {
+			return t.db.fail(err)
+		}
+	}
+
+	t.db.master = append(t.db.master, []interface{}{"table", t.name, t.name, int64(root), createTableSQL(t.name, t.cols)})
+	t.leaf, t.children = nil, nil
+	return nil
+}
+
+// createTableSQL gives the statement that creates a table
+func createTableSQL(name string, cols []Column) string {
+	defs := make([]string, len(cols))
+	for i, c := range cols {
+		defs[i] = QuoteIdentifier(c.Name)
+		if c.Type != "" {
+			defs[i] += " " + c.Type
+		}
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", QuoteIdentifier(name), strings.Join(defs, ", "))
+}
+
+// QuoteIdentifier quotes a table or column name for use in SQL
+func QuoteIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
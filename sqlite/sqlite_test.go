@@ -0,0 +1,383 @@
+package sqlite
+
+import (
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+// memFile is an in-memory io.WriterAt
+type memFile struct {
+	data []byte
+}
+
+func (f *memFile) WriteAt(p []byte, off int64) (int, error) {
+	if end := int(off) + len(p); end > len(f.data) {
+		f.data = append(f.data, make([]byte, end-len(f.data))...)
+	}
+	return copy(f.data[off:], p), nil
+}
+
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 8; i++ {
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return v<<8 | uint64(b[8]), 9
+}
+
+// readDatabase opens a database with sqlite, failing if sqlite's integrity
+// check finds problems, and reads the rows of every table by name
+func readDatabase(t *testing.T, data []byte) map[string][][]interface{} {
+	if !strings.HasPrefix(string(data), "SQLite format 3\x00") {
+		t.Fatal("missing database header")
+	}
+	if pages := binary.BigEndian.Uint32(data[28:]); int(pages)*pageSize != len(data) {
+		t.Fatalf("header lists %d pages, file has %d", pages, len(data)/pageSize)
+	}
+
+	dir, err := ioutil.TempDir("", "sqlite_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.db")
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var check string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&check); err != nil {
+		t.Fatal(err)
+	}
+	if check != "ok" {
+		t.Fatalf("integrity check failed: %s", check)
+	}
+
+	master := queryRows(t, db, "SELECT name, sql FROM sqlite_master WHERE type = 'table'")
+	tables := map[string][][]interface{}{}
+	for _, row := range master {
+		name := row[0].(string)
+		tables[name] = queryRows(t, db, fmt.Sprintf("SELECT * FROM %s ORDER BY rowid", QuoteIdentifier(name)))
+		tables["sql:"+name] = [][]interface{}{{row[1]}}
+	}
+	return tables
+}
+
+func queryRows(t *testing.T, db *sql.DB, query string) (rows [][]interface{}) {
+	res, err := db.Query(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Close()
+	cols, err := res.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for res.Next() {
+		row := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := res.Scan(ptrs...); err != nil {
+			t.Fatal(err)
+		}
+		rows = append(rows, row)
+	}
+	if err := res.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return rows
+}
+
+func TestWriter(t *testing.T) {
+	f := &memFile{}
+	db := NewWriter(f)
+	tbl, err := db.CreateTable("values", []Column{{"n", "INTEGER"}, {"s", "TEXT"}, {"f", "REAL"}, {"b", "BLOB"}, {"any", ""}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	long := strings.Repeat("overflow ", 2000)
+	rows := [][]interface{}{
+		{int64(0), "", 0.5, []byte{1, 2}, nil},
+		{int64(1), "a", -1.25, []byte{}, "text"},
+		{int64(-129), long, math.MaxFloat64, nil, int64(math.MaxInt64)},
+		{int64(1 << 40), "b", nil, nil, int64(math.MinInt64)},
+		{int64(-1 << 23), "c"},
+	}
+	for _, row := range rows {
+		if err := tbl.Insert(row...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tbl.Insert(1, 2, 3, 4, 5, 6); err == nil {
+		t.Error("expected error inserting too many values")
+	}
+	if _, err := db.CreateTable("other", []Column{{"a", ""}}); err == nil {
+		t.Error("expected error creating a table while another is open")
+	}
+	if err := tbl.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// enough rows for interior pages
+	big, err := db.CreateTable("big", []Column{{"i", "INTEGER"}, {"s", "TEXT"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100000; i++ {
+		if err := big.Insert(i, fmt.Sprintf("row %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := big.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.CreateTable("BIG", []Column{{"a", ""}}); err == nil {
+		t.Error("expected error reusing a table name")
+	}
+	if _, err := db.CreateTable("dupes", []Column{{"a", ""}, {"A", ""}}); err == nil {
+		t.Error("expected error for duplicate columns")
+	}
+	if _, err := db.CreateTable("sqlite_stat1", []Column{{"a", ""}}); err == nil {
+		t.Error("expected error using a reserved table name")
+	}
+	if _, err := db.CreateTable(`quote"d`, []Column{{"a", ""}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tables := readDatabase(t, f.data)
+	expect := [][]interface{}{
+		{int64(0), "", 0.5, []byte{1, 2}, nil},
+		{int64(1), "a", -1.25, []byte{}, "text"},
+		{int64(-129), long, math.MaxFloat64, nil, int64(math.MaxInt64)},
+		{int64(1 << 40), "b", nil, nil, int64(math.MinInt64)},
+		{int64(-1 << 23), "c", nil, nil, nil},
+	}
+	if diff := cmp.Diff(expect, tables["values"]); diff != "" {
+		t.Errorf("values mismatch (-want +got):\n%s", diff)
+	}
+	if len(tables["big"]) != 100000 {
+		t.Fatalf("expected 100000 rows, got %d", len(tables["big"]))
+	}
+	for i, row := range tables["big"] {
+		if row[0] != int64(i) {
+			t.Fatalf("row %d out of order: %v", i, row)
+		}
+	}
+	if sql := tables[`sql:quote"d`][0][0]; sql != `CREATE TABLE "quote""d" ("a")` {
+		t.Errorf("unexpected create statement: %s", sql)
+	}
+
+}
+
+func TestLargeSchema(t *testing.T) {
+	f := &memFile{}
+	db := NewWriter(f)
+	cols := make([]Column, 30)
+	for i := range cols {
+		cols[i] = Column{Name: fmt.Sprintf("a fairly long column name %d", i), Type: "TEXT"}
+	}
+	for i := 0; i < 400; i++ {
+		tbl, err := db.CreateTable(fmt.Sprintf("table_%d", i), cols)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := tbl.Insert(fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+		if err := tbl.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tables := readDatabase(t, f.data)
+	if len(tables) != 800 {
+		t.Errorf("expected 400 tables, got %d", len(tables)/2)
+	}
+	if row := tables["table_399"][0]; row[0] != "399" {
+		t.Errorf("unexpected row: %v", row)
+	}
+}
+
+func TestEntryWriter(t *testing.T) {
+	cases := []struct {
+		description string
+		schema      map[string]interface{}
+		entries     []dsio.Entry
+		sql         string
+		rows        [][]interface{}
+	}{
+		{
+			"array rows",
+			map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "array",
+					"items": []interface{}{
+						map[string]interface{}{"title": "city", "type": "string"},
+						map[string]interface{}{"title": "pop", "type": "integer"},
+						map[string]interface{}{"title": "avg_age", "type": []interface{}{"number", "null"}},
+						map[string]interface{}{"title": "in_usa", "type": "boolean"},
+						map[string]interface{}{"title": "city"},
+					},
+				},
+			},
+			[]dsio.Entry{
+				{Index: 0, Value: []interface{}{"toronto", int64(40000000), 55.5, false, map[string]interface{}{"a": 1}}},
+				{Index: 1, Value: []interface{}{"chatham", "", nil, true}},
+				{Index: 2, Value: []interface{}{"raleigh", float64(250000), "12", "false", []interface{}{1, "b"}}},
+			},
+			`CREATE TABLE "cities" ("city" TEXT, "pop" INTEGER, "avg_age" REAL, "in_usa" INTEGER, "field_5")`,
+			[][]interface{}{
+				{"toronto", int64(40000000), 55.5, int64(0), `{"a":1}`},
+				{"chatham", nil, nil, int64(1), nil},
+				{"raleigh", int64(250000), float64(12), "false", `[1,"b"]`},
+			},
+		},
+		{
+			"object rows",
+			map[string]interface{}{
+				"type": "array",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"name": map[string]interface{}{"type": "string"},
+						"age":  map[string]interface{}{"type": "integer"},
+					},
+				},
+			},
+			[]dsio.Entry{
+				{Index: 0, Value: map[string]interface{}{"name": "a", "age": int64(3)}},
+				{Index: 1, Value: map[string]interface{}{"name": "b"}},
+			},
+			`CREATE TABLE "cities" ("age" INTEGER, "name" TEXT)`,
+			[][]interface{}{{int64(3), "a"}, {nil, "b"}},
+		},
+		{
+			"object body",
+			dataset.BaseSchemaObject,
+			[]dsio.Entry{
+				{Key: "a", Value: int64(1)},
+				{Key: "b", Value: []interface{}{"x"}},
+			},
+			`CREATE TABLE "cities" ("key" TEXT, "value")`,
+			[][]interface{}{{"a", int64(1)}, {"b", `["x"]`}},
+		},
+		{
+			"rows without columns",
+			dataset.BaseSchemaArray,
+			[]dsio.Entry{
+				{Index: 0, Value: "a"},
+				{Index: 1, Value: []interface{}{"b", "c"}},
+			},
+			`CREATE TABLE "cities" ("value")`,
+			[][]interface{}{{"a"}, {`["b","c"]`}},
+		},
+	}
+
+	for _, c := range cases {
+		f := &memFile{}
+		db := NewWriter(f)
+		w, err := NewEntryWriter(db, "cities", &dataset.Structure{Format: "json", Schema: c.schema})
+		if err != nil {
+			t.Fatalf("case %q: %s", c.description, err)
+		}
+		for _, ent := range c.entries {
+			if err := w.WriteEntry(ent); err != nil {
+				t.Fatalf("case %q: %s", c.description, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		tables := readDatabase(t, f.data)
+		if sql := tables["sql:cities"][0][0]; sql != c.sql {
+			t.Errorf("case %q: create statement mismatch. expected: %s, got: %s", c.description, c.sql, sql)
+		}
+		if diff := cmp.Diff(c.rows, tables["cities"]); diff != "" {
+			t.Errorf("case %q: rows mismatch (-want +got):\n%s", c.description, diff)
+		}
+	}
+}
+
+func TestEntryWriterErrors(t *testing.T) {
+	db := NewWriter(&memFile{})
+	if _, err := NewEntryWriter(db, "t", &dataset.Structure{}); err == nil {
+		t.Error("expected error for a missing schema")
+	}
+
+	sch := map[string]interface{}{
+		"type": "array",
+		"items": map[string]interface{}{
+			"type":       "object",
+			"properties": map[string]interface{}{"a": map[string]interface{}{"type": "string"}},
+		},
+	}
+	w, err := NewEntryWriter(db, "t", &dataset.Structure{Schema: sch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `entry 0: key "b" isn't a column of table "t"`
+	if err := w.WriteEntry(dsio.Entry{Value: map[string]interface{}{"b": "x"}}); err == nil || err.Error() != expect {
+		t.Errorf("expected error %q, got: %v", expect, err)
+	}
+	expect = "entry 1: expected an object"
+	if err := w.WriteEntry(dsio.Entry{Index: 1, Value: []interface{}{"x"}}); err == nil || err.Error() != expect {
+		t.Errorf("expected error %q, got: %v", expect, err)
+	}
+}
+
+func TestAppendVarint(t *testing.T) {
+	cases := []struct {
+		v      uint64
+		expect []byte
+	}{
+		{0, []byte{0}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x81, 0x00}},
+		{240, []byte{0x81, 0x70}},
+		{1<<56 - 1, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f}},
+		{math.MaxUint64, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+	}
+	for _, c := range cases {
+		got := appendVarint(nil, c.v)
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("varint %d mismatch (-want +got):\n%s", c.v, diff)
+		}
+		if v, n := readVarint(append(got, 0)); v != c.v || n != len(got) {
+			t.Errorf("varint %d read back as %d", c.v, v)
+		}
+	}
+}
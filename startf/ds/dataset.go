@@ -49,6 +49,10 @@ type Dataset struct {
 	bodyCache starlark.Iterable
 	check     MutateFieldCheck
 	modBody   bool
+	rows      *rowWriter
+	maxRows   int
+	// set when append_rows stops at maxRows
+	maxRowsExceeded bool
 }
 
 // NewDataset creates a dataset object, intended to be called from go-land to prepare datasets
@@ -68,7 +72,8 @@ func (d *Dataset) SetMutable(ds *dataset.Dataset) {
 	d.write = ds
 }
 
-// IsBodyModified returns whether the body has been modified by set_body
+// IsBodyModified returns whether the body has been modified by set_body or
+// append_rows
 func (d *Dataset) IsBodyModified() bool {
 	return d.modBody
 }
@@ -82,6 +87,7 @@ func (d *Dataset) Methods() *starlarkstruct.Struct {
 		"set_structure": starlark.NewBuiltin("set_structure", d.SetStructure),
 		"get_body":      starlark.NewBuiltin("get_body", d.GetBody),
 		"set_body":      starlark.NewBuiltin("set_body", d.SetBody),
+		"append_rows":   starlark.NewBuiltin("append_rows", d.AppendRows),
 	})
 }
 
@@ -218,6 +224,9 @@ func (d *Dataset) GetBody(thread *starlark.Thread, _ *starlark.Builtin, args sta
 	if d.bodyCache != nil {
 		return d.bodyCache, nil
 	}
+	if d.rows != nil {
+		return starlark.None, fmt.Errorf("cannot call get_body after append_rows, appended rows are written when the transform completes")
+	}
 
 	var valx starlark.Value
 	if err := starlark.UnpackArgs("get_body", args, kwargs, "default?", &valx); err != nil {
@@ -290,6 +299,10 @@ func (d *Dataset) SetBody(thread *starlark.Thread, _ *starlark.Builtin, args sta
 		return starlark.None, fmt.Errorf("cannot call set_body on read-only dataset")
 	}
 
+	if d.rows != nil {
+		return starlark.None, fmt.Errorf("cannot call set_body after append_rows")
+	}
+
 	if err := d.checkField("body"); err != nil {
 		return starlark.None, err
 	}
@@ -2,10 +2,13 @@ package ds
 
 import (
 	"fmt"
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/starlib/testdata"
 	"go.starlark.net/resolve"
 	"go.starlark.net/starlark"
@@ -172,6 +175,98 @@ func TestChangeBodyEvenIfTheSame(t *testing.T) {
 	}
 }
 
+func TestAppendRows(t *testing.T) {
+	ds := NewDataset(&dataset.Dataset{}, nil)
+	ds.SetMutable(&dataset.Dataset{
+		Structure: &dataset.Structure{
+			Format: "csv",
+			Schema: dataset.BaseSchemaArray,
+		},
+	})
+	thread := &starlark.Thread{}
+
+	row := func(vals ...starlark.Value) starlark.Value { return starlark.NewList(vals) }
+	batches := []starlark.Value{
+		starlark.NewList([]starlark.Value{row(starlark.String("a"), starlark.MakeInt(1)), row(starlark.String("b"), starlark.MakeInt(2))}),
+		starlark.Tuple{row(starlark.String("c"), starlark.MakeInt(3))},
+	}
+	for _, batch := range batches {
+		if _, err := ds.AppendRows(thread, nil, starlark.Tuple{batch}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !ds.IsBodyModified() {
+		t.Errorf("expected body to have been modified")
+	}
+
+	expectErr := "cannot call set_body after append_rows"
+	if _, err := ds.SetBody(thread, nil, starlark.Tuple{starlark.NewList(nil)}, nil); err == nil || err.Error() != expectErr {
+		t.Errorf("expected error: %s, got: %v", expectErr, err)
+	}
+	if _, err := ds.GetBody(thread, nil, starlark.Tuple{}, nil); err == nil {
+		t.Errorf("expected calling get_body after append_rows to error")
+	}
+
+	path := ds.rows.f.Name()
+	if err := ds.Close(); err != nil {
+		t.Fatal(err)
+	}
+	body, ok := ds.write.BodyFile().(*dsfs.TempBodyFile)
+	if !ok {
+		t.Fatalf("expected body file to be a *dsfs.TempBodyFile. got: %T", ds.write.BodyFile())
+	}
+	if body.FileName() != "body.csv" {
+		t.Errorf("expected body filename: body.csv, got: %s", body.FileName())
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "a,1\nb,2\nc,3\n"
+	if string(data) != expect {
+		t.Errorf("expected body: %q, got: %q", expect, string(data))
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected temp file to be removed once read. got: %v", err)
+	}
+
+	ds = NewDataset(&dataset.Dataset{}, nil)
+	ds.SetMutable(&dataset.Dataset{})
+	if _, err := ds.SetBody(thread, nil, starlark.Tuple{starlark.NewList(nil)}, nil); err != nil {
+		t.Fatal(err)
+	}
+	expectErr = "cannot call append_rows after set_body"
+	if _, err := ds.AppendRows(thread, nil, starlark.Tuple{starlark.NewList(nil)}, nil); err == nil || err.Error() != expectErr {
+		t.Errorf("expected error: %s, got: %v", expectErr, err)
+	}
+
+	ds = NewDataset(&dataset.Dataset{}, nil)
+	ds.SetMutable(&dataset.Dataset{Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}})
+	ds.SetMaxRows(2)
+	expectErr = "append_rows: body exceeds the limit of 2 entries"
+	if _, err := ds.AppendRows(thread, nil, starlark.Tuple{batches[0]}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ds.AppendRows(thread, nil, starlark.Tuple{batches[1]}, nil); err == nil || err.Error() != expectErr {
+		t.Errorf("expected error: %s, got: %v", expectErr, err)
+	}
+	if !ds.MaxRowsExceeded() {
+		t.Errorf("expected max rows to be exceeded")
+	}
+	if err := ds.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err = ioutil.ReadAll(ds.write.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect = `[["a",1],["b",2]]`
+	if string(data) != expect {
+		t.Errorf("expected rows past the limit not to be written. got: %s", data)
+	}
+}
+
 func TestFile(t *testing.T) {
 	resolve.AllowFloat = true
 	thread := &starlark.Thread{Load: newLoader()}
@@ -24,5 +24,12 @@
             structure (tuple, set, list, dict). When parse_as is set, set_body assumes the provided body value will
             be a string of serialized structured data in the given format. valid parse_as values are "json", "csv",
             "cbor", "xlsx".
+          append_rows(rows list|tuple)
+            append rows to the dataset body. Rows are written to disk as they're appended instead of being held in
+            memory, so calling append_rows repeatedly with batches of rows can produce bodies larger than available
+            memory. Private datasets are encrypted in memory when they're saved, so their bodies must fit in memory.
+            append_rows requires a body with an array top level type, and uses the structure set with
+            set_structure if one is defined. Appended rows become the body when the transform completes, so
+            append_rows can't be combined with get_body or set_body in the same transform.
 */
 package ds
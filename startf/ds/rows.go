@@ -0,0 +1,149 @@
+package ds
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qri/base/dsfs"
+	"github.com/qri-io/starlib/util"
+	"go.starlark.net/starlark"
+)
+
+// rowWriter streams rows appended with append_rows to a temp file
+type rowWriter struct {
+	st      *dataset.Structure
+	f       *os.File
+	w       dsio.EntryWriter
+	entries int
+}
+
+// AppendRows writes each value of an iterable as an entry in the dataset
+// body. Unlike set_body, rows are written to disk as they're appended instead
+// of being held in memory, so transforms can produce bodies larger than
+// available memory by calling append_rows repeatedly with batches of rows.
+// The body is assigned once the transform completes, see Close
+func (d *Dataset) AppendRows(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var data starlark.Value
+	if err := starlark.UnpackPositionalArgs("append_rows", args, kwargs, 1, &data); err != nil {
+		return starlark.None, err
+	}
+
+	if d.write == nil {
+		return starlark.None, fmt.Errorf("cannot call append_rows on read-only dataset")
+	}
+
+	if err := d.checkField("body"); err != nil {
+		return starlark.None, err
+	}
+
+	if err := d.checkField("structure"); err != nil {
+		err = fmt.Errorf("cannot use a transform to set the body of a dataset and manually adjust structure at the same time")
+		return starlark.None, err
+	}
+
+	iter, ok := data.(starlark.Iterable)
+	if !ok {
+		return starlark.None, fmt.Errorf("expected rows to be iterable")
+	}
+
+	if d.rows == nil {
+		if d.modBody {
+			return starlark.None, fmt.Errorf("cannot call append_rows after set_body")
+		}
+		if err := d.openRows(d.writeStructure(data)); err != nil {
+			return starlark.None, err
+		}
+	}
+
+	it := iter.Iterate()
+	defer it.Done()
+	var row starlark.Value
+	for it.Next(&row) {
+		if d.maxRows > 0 && d.rows.entries >= d.maxRows {
+			d.maxRowsExceeded = true
+			return starlark.None, fmt.Errorf("append_rows: body exceeds the limit of %d entries", d.maxRows)
+		}
+		val, err := util.Unmarshal(row)
+		if err != nil {
+			return starlark.None, err
+		}
+		if err := d.rows.w.WriteEntry(dsio.Entry{Index: d.rows.entries, Value: val}); err != nil {
+			return starlark.None, err
+		}
+		d.rows.entries++
+	}
+
+	return starlark.None, nil
+}
+
+// SetMaxRows caps the number of rows append_rows can write, so a runaway
+// transform stops before filling the disk. 0 is unlimited
+func (d *Dataset) SetMaxRows(max int) {
+	d.maxRows = max
+}
+
+// MaxRowsExceeded reports whether append_rows was stopped by the limit set
+// with SetMaxRows
+func (d *Dataset) MaxRowsExceeded() bool {
+	return d.maxRowsExceeded
+}
+
+// openRows creates the temp file appended rows are written to
+func (d *Dataset) openRows(st *dataset.Structure) error {
+	tlt, err := dsio.GetTopLevelType(st)
+	if err != nil {
+		return err
+	}
+	if tlt != "array" {
+		return fmt.Errorf("append_rows requires a body with an array top level type, got: %s", tlt)
+	}
+
+	f, err := ioutil.TempFile("", fmt.Sprintf("qri_body_*.%s", st.Format))
+	if err != nil {
+		return err
+	}
+	w, err := dsio.NewEntryWriter(st, f)
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	d.write.Structure = st
+	d.rows = &rowWriter{st: st, f: f, w: w}
+	d.modBody = true
+	d.bodyCache = nil
+	return nil
+}
+
+// Close finishes writing rows appended with append_rows, assigning them as
+// the body of the mutable dataset. Close must be called once the transform
+// completes, and is a no-op if append_rows wasn't called
+func (d *Dataset) Close() error {
+	if d.rows == nil {
+		return nil
+	}
+	rows := d.rows
+	d.rows = nil
+
+	if err := rows.w.Close(); err != nil {
+		rows.f.Close()
+		os.Remove(rows.f.Name())
+		return err
+	}
+	if err := rows.f.Close(); err != nil {
+		os.Remove(rows.f.Name())
+		return err
+	}
+
+	f, err := os.Open(rows.f.Name())
+	if err != nil {
+		os.Remove(rows.f.Name())
+		return err
+	}
+	d.write.SetBodyFile(dsfs.NewTempBodyFile(f, fmt.Sprintf("body.%s", rows.st.Format)))
+	return nil
+}
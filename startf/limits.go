@@ -0,0 +1,231 @@
+package startf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/detect"
+	"github.com/qri-io/dataset/dsio"
+	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// Limits caps the resources a single script execution can use. The zero
+// value is unlimited
+type Limits struct {
+	// MaxSteps caps the number of execution steps. starlark has no while
+	// loops or recursion, so steps count loop & comprehension iterations.
+	// 0 is unlimited
+	MaxSteps uint64
+	// Timeout caps wall-clock execution time, including time spent in the
+	// download step. 0 is unlimited. Execution always stops when the context
+	// passed to ExecScript is cancelled
+	Timeout time.Duration
+	// MaxBodyEntries caps the number of entries the script can produce in
+	// the dataset body. 0 is unlimited
+	MaxBodyEntries int
+}
+
+// LimitKind names a resource limit
+type LimitKind string
+
+const (
+	// LimitSteps is the execution steps limit
+	LimitSteps LimitKind = "steps"
+	// LimitTimeout is the wall-clock limit, or cancellation of the execution
+	// context
+	LimitTimeout LimitKind = "timeout"
+	// LimitBodyEntries is the body entries limit
+	LimitBodyEntries LimitKind = "body entries"
+)
+
+// LimitError is returned by ExecScript when a script is stopped for exceeding
+// a resource limit
+type LimitError struct {
+	Kind LimitKind
+	// Limit is the maximum that was exceeded, or the timeout duration
+	Limit string
+	// Err is the context error for timeouts & cancellation
+	Err error
+}
+
+// Error implements the error interface
+func (e *LimitError) Error() string {
+	switch e.Kind {
+	case LimitTimeout:
+		if e.Err == context.Canceled {
+			return "transform cancelled"
+		}
+		if e.Limit != "" {
+			return fmt.Sprintf("transform exceeded its time limit of %s", e.Limit)
+		}
+		return "transform exceeded its deadline"
+	default:
+		return fmt.Sprintf("transform exceeded its limit of %s %s", e.Limit, e.Kind)
+	}
+}
+
+// Unwrap gives the underlying context error, if any
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// stepFuncName is the name of the builtin called on each loop iteration,
+// chosen to avoid colliding with names scripts declare
+const stepFuncName = "__qri_step__"
+
+// stepper counts execution steps, stopping execution once limits are
+// exceeded or the context is done
+type stepper struct {
+	ctx     context.Context
+	timeout time.Duration
+	max     uint64
+	steps   uint64
+	err     *LimitError
+}
+
+// check returns a LimitError if execution should stop
+func (s *stepper) check() error {
+	if s.err != nil {
+		return s.err
+	}
+	select {
+	case <-s.ctx.Done():
+		s.err = &LimitError{Kind: LimitTimeout, Err: s.ctx.Err()}
+		if s.timeout > 0 && s.ctx.Err() == context.DeadlineExceeded {
+			s.err.Limit = s.timeout.String()
+		}
+		return s.err
+	default:
+	}
+	if s.max > 0 && s.steps > s.max {
+		s.err = &LimitError{Kind: LimitSteps, Limit: fmt.Sprintf("%d", s.max)}
+		return s.err
+	}
+	return nil
+}
+
+// scriptError prefers the LimitError that stopped execution over the error
+// starlark reports, which loses error types
+func (s *stepper) scriptError(err error) error {
+	if s.err != nil {
+		return s.err
+	}
+	if lerr := s.check(); lerr != nil {
+		return lerr
+	}
+	if lerr, ok := err.(*LimitError); ok {
+		return lerr
+	}
+	return newScriptError(err)
+}
+
+// step is the builtin called on each loop iteration
+func (s *stepper) step(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	s.steps++
+	if err := s.check(); err != nil {
+		return starlark.None, err
+	}
+	return starlark.True, nil
+}
+
+// instrumentLoops adds a call to the step builtin to the top of every for
+// loop body and a conditional step call to every comprehension
+func instrumentLoops(f *syntax.File) {
+	call := func(pos syntax.Position) *syntax.CallExpr {
+		return &syntax.CallExpr{
+			Fn:     &syntax.Ident{NamePos: pos, Name: stepFuncName},
+			Lparen: pos,
+			Rparen: pos,
+		}
+	}
+
+	syntax.Walk(f, func(n syntax.Node) bool {
+		switch x := n.(type) {
+		case *syntax.ForStmt:
+			x.Body = append([]syntax.Stmt{&syntax.ExprStmt{X: call(x.For)}}, x.Body...)
+		case *syntax.Comprehension:
+			clauses := make([]syntax.Node, 0, len(x.Clauses)*2)
+			for _, c := range x.Clauses {
+				clauses = append(clauses, c)
+				if fc, ok := c.(*syntax.ForClause); ok {
+					clauses = append(clauses, &syntax.IfClause{If: fc.For, Cond: call(fc.For)})
+				}
+			}
+			x.Clauses = clauses
+		}
+		return true
+	})
+}
+
+// execFile is starlark.ExecFile with loops instrumented to count steps
+func execFile(thread *starlark.Thread, filename string, src io.Reader, predeclared starlark.StringDict, s *stepper) (starlark.StringDict, error) {
+	f, err := syntax.Parse(filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	instrumentLoops(f)
+
+	predeclared[stepFuncName] = starlark.NewBuiltin(stepFuncName, s.step)
+	prog, err := starlark.FileProgram(f, predeclared.Has)
+	if err != nil {
+		return nil, err
+	}
+
+	g, err := prog.Init(thread, predeclared)
+	g.Freeze()
+	return g, err
+}
+
+// checkBodyEntries returns a LimitError if the body of a dataset has more
+// than max entries. The body file is consumed & replaced, unless it was
+// written by append_rows, which stops appending rows at the limit
+func checkBodyEntries(ds *dataset.Dataset, max int) error {
+	body := ds.BodyFile()
+	if max <= 0 || body == nil {
+		return nil
+	}
+	if _, ok := body.(*dsfs.TempBodyFile); ok {
+		return nil
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	ds.SetBodyFile(qfs.NewMemfileBytes(body.FileName(), data))
+
+	st := ds.Structure
+	if st == nil || st.Schema == nil {
+		df, err := detect.ExtensionDataFormat(body.FileName())
+		if err != nil {
+			return err
+		}
+		if st, _, err = detect.FromReader(df, bytes.NewReader(data)); err != nil {
+			return err
+		}
+	}
+
+	rdr, err := dsio.NewEntryReader(st, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	entries := 0
+	for {
+		if _, err := rdr.ReadEntry(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if entries++; entries > max {
+			return &LimitError{Kind: LimitBodyEntries, Limit: fmt.Sprintf("%d", max)}
+		}
+	}
+}
@@ -1,11 +1,16 @@
 package qri
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
-	"sync"
+	"time"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/dsref"
 	"github.com/qri-io/qri/repo"
+	"github.com/qri-io/starlib/util"
 	"go.starlark.net/starlark"
 	"go.starlark.net/starlarkstruct"
 )
@@ -14,20 +19,34 @@ import (
 // in starlark's load() function, eg: load('qri.star', 'qri')
 const ModuleName = "qri.star"
 
-var (
-	once      sync.Once
-	qriModule starlark.StringDict
-)
+// Reader is read-only access to the datasets in a qri repo. All qri module
+// builtins are routed through a Reader
+type Reader interface {
+	// ListDatasets lists references to all local datasets
+	ListDatasets(ctx context.Context) ([]string, error)
+	// GetDataset loads a dataset without its body. A non-zero at time loads
+	// the version that was the latest at that time
+	GetDataset(ctx context.Context, ref string, at time.Time) (*dataset.Dataset, error)
+	// GetBody reads entries from a dataset body. A limit of -1 reads all
+	// entries after offset
+	GetBody(ctx context.Context, ref string, offset, limit int) (interface{}, error)
+	// Log lists the versions of a dataset, newest first
+	Log(ctx context.Context, ref string) ([]dsref.VersionInfo, error)
+	// Diff describes the changes from dataset a to dataset b
+	Diff(ctx context.Context, a, b string) (interface{}, error)
+	// Stats calculates statistics about a dataset body
+	Stats(ctx context.Context, ref string) (interface{}, error)
+}
 
 // NewModule creates a new qri module instance
-func NewModule(repo repo.Repo) *Module {
-	return &Module{repo: repo}
+func NewModule(ctx context.Context, r Reader) *Module {
+	return &Module{ctx: ctx, r: r}
 }
 
 // Module encapsulates state for a qri starlark module
 type Module struct {
-	repo repo.Repo
-	ds   *dataset.Dataset
+	ctx context.Context
+	r   Reader
 }
 
 // Namespace produces this module's exported namespace
@@ -45,24 +64,168 @@ func (m *Module) Struct() *starlarkstruct.Struct {
 // AddAllMethods augments a starlark.StringDict with all qri builtins. Should really only be used during "transform" step
 func (m *Module) AddAllMethods(sd starlark.StringDict) starlark.StringDict {
 	sd["list_datasets"] = starlark.NewBuiltin("list_datasets", m.ListDatasets)
+	sd["get_dataset"] = starlark.NewBuiltin("get_dataset", m.GetDataset)
+	sd["get_body"] = starlark.NewBuiltin("get_body", m.GetBody)
+	sd["log"] = starlark.NewBuiltin("log", m.Log)
+	sd["diff"] = starlark.NewBuiltin("diff", m.Diff)
+	sd["stats"] = starlark.NewBuiltin("stats", m.Stats)
 	return sd
 }
 
 // ListDatasets shows current local datasets
 func (m *Module) ListDatasets(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	if m.repo == nil {
+	if m.r == nil {
 		return starlark.None, fmt.Errorf("no qri repo available to list datasets")
 	}
 
-	// TODO (b5) - remove hardcoded limit
-	refs, err := m.repo.References(0, 100000)
+	refs, err := m.r.ListDatasets(m.ctx)
 	if err != nil {
 		return starlark.None, fmt.Errorf("error getting dataset list: %s", err.Error())
 	}
 
 	l := &starlark.List{}
 	for _, ref := range refs {
-		l.Append(starlark.String(ref.String()))
+		l.Append(starlark.String(ref))
 	}
 	return l, nil
 }
+
+// GetDataset loads a dataset as a dictionary, without its body. An optional
+// at timestamp loads the version that was the latest at that time
+func (m *Module) GetDataset(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var refstr, atStr starlark.String
+	if err := starlark.UnpackArgs("get_dataset", args, kwargs, "ref", &refstr, "at?", &atStr); err != nil {
+		return starlark.None, err
+	}
+	if m.r == nil {
+		return starlark.None, fmt.Errorf("no qri repo available to get dataset: %s", refstr.GoString())
+	}
+
+	var at time.Time
+	if atStr.GoString() != "" {
+		var err error
+		if at, err = repo.ParseAsOf(atStr.GoString()); err != nil {
+			return starlark.None, err
+		}
+	}
+
+	ds, err := m.r.GetDataset(m.ctx, refstr.GoString(), at)
+	if err != nil {
+		return starlark.None, err
+	}
+	return toStarlark(ds)
+}
+
+// GetBody reads entries from a dataset body, returning a list or dictionary
+// depending on the shape of the body. offset & limit page through entries,
+// the default limit of -1 reads all entries
+func (m *Module) GetBody(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var (
+		refstr starlark.String
+		offset = 0
+		limit  = -1
+	)
+	if err := starlark.UnpackArgs("get_body", args, kwargs, "ref", &refstr, "offset?", &offset, "limit?", &limit); err != nil {
+		return starlark.None, err
+	}
+	if m.r == nil {
+		return starlark.None, fmt.Errorf("no qri repo available to get body: %s", refstr.GoString())
+	}
+	if offset < 0 {
+		return starlark.None, fmt.Errorf("get_body: offset can't be negative")
+	}
+
+	body, err := m.r.GetBody(m.ctx, refstr.GoString(), offset, limit)
+	if err != nil {
+		return starlark.None, err
+	}
+	return toStarlark(body)
+}
+
+// Log lists the versions of a dataset as dictionaries, newest first
+func (m *Module) Log(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var refstr starlark.String
+	if err := starlark.UnpackArgs("log", args, kwargs, "ref", &refstr); err != nil {
+		return starlark.None, err
+	}
+	if m.r == nil {
+		return starlark.None, fmt.Errorf("no qri repo available to get log: %s", refstr.GoString())
+	}
+
+	versions, err := m.r.Log(m.ctx, refstr.GoString())
+	if err != nil {
+		return starlark.None, err
+	}
+	return toStarlark(versions)
+}
+
+// Diff describes the changes from dataset a to dataset b as a dictionary
+func (m *Module) Diff(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var a, b starlark.String
+	if err := starlark.UnpackArgs("diff", args, kwargs, "a", &a, "b", &b); err != nil {
+		return starlark.None, err
+	}
+	if m.r == nil {
+		return starlark.None, fmt.Errorf("no qri repo available to diff: %s %s", a.GoString(), b.GoString())
+	}
+
+	changes, err := m.r.Diff(m.ctx, a.GoString(), b.GoString())
+	if err != nil {
+		return starlark.None, err
+	}
+	return toStarlark(changes)
+}
+
+// Stats calculates statistics about each column of a dataset body
+func (m *Module) Stats(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var refstr starlark.String
+	if err := starlark.UnpackArgs("stats", args, kwargs, "ref", &refstr); err != nil {
+		return starlark.None, err
+	}
+	if m.r == nil {
+		return starlark.None, fmt.Errorf("no qri repo available to get stats: %s", refstr.GoString())
+	}
+
+	stats, err := m.r.Stats(m.ctx, refstr.GoString())
+	if err != nil {
+		return starlark.None, err
+	}
+	return toStarlark(stats)
+}
+
+// toStarlark converts a go value to starlark by way of JSON. Whole numbers
+// stay integers
+func toStarlark(v interface{}) (starlark.Value, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return starlark.None, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var val interface{}
+	if err := dec.Decode(&val); err != nil {
+		return starlark.None, err
+	}
+	return util.Marshal(fromNumbers(val))
+}
+
+// fromNumbers replaces json.Number values with ints or floats
+func fromNumbers(v interface{}) interface{} {
+	switch x := v.(type) {
+	case json.Number:
+		if i, err := x.Int64(); err == nil {
+			return i
+		}
+		f, _ := x.Float64()
+		return f
+	case []interface{}:
+		for i, val := range x {
+			x[i] = fromNumbers(val)
+		}
+	case map[string]interface{}:
+		for key, val := range x {
+			x[key] = fromNumbers(val)
+		}
+	}
+	return v
+}
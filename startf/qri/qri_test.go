@@ -1,10 +1,13 @@
 package qri
 
 import (
+	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/qri-io/dataset"
+	"github.com/qri-io/qri/dsref"
 	"go.starlark.net/starlark"
 )
 
@@ -29,11 +32,85 @@ func TestNewModule(t *testing.T) {
 	}
 }
 
+func TestModuleBuiltins(t *testing.T) {
+	ctx := context.Background()
+	m := NewModule(ctx, fakeReader{})
+	thread := &starlark.Thread{Load: func(thread *starlark.Thread, module string) (starlark.StringDict, error) {
+		return m.Namespace(), nil
+	}}
+
+	script := `load('qri.star', 'qri')
+datasets = qri.list_datasets()
+title = qri.get_dataset("peer/cities", at="2020-01-02")["meta"]["title"]
+body = qri.get_body("peer/cities", offset=1, limit=1)
+versions = len(qri.log("peer/cities"))
+added = qri.diff("peer/cities@/map/a", "peer/cities")["rowsAdded"]
+count = qri.stats("peer/cities")[0]["count"]
+`
+	globals, err := starlark.ExecFile(thread, "test.star", script, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string]string{
+		"datasets": `["peer/cities"]`,
+		"title":    `"cities as of 2020-01-02"`,
+		"body":     `[["new york", 8500000]]`,
+		"versions": "2",
+		"added":    "1",
+		"count":    "5",
+	}
+	for name, val := range expect {
+		if got := globals[name].String(); got != val {
+			t.Errorf("%s mismatch. expected: %s, got: %s", name, val, got)
+		}
+	}
+
+	if _, err := starlark.ExecFile(thread, "test.star", "load('qri.star', 'qri')\nqri.get_body('peer/cities', offset=-1)", nil); err == nil {
+		t.Error("expected negative offset to error")
+	}
+
+	m = NewModule(ctx, nil)
+	if _, err := starlark.ExecFile(thread, "test.star", "load('qri.star', 'qri')\nqri.list_datasets()", nil); err == nil {
+		t.Error("expected listing datasets without a reader to error")
+	}
+}
+
+type fakeReader struct{}
+
+func (fakeReader) ListDatasets(ctx context.Context) ([]string, error) {
+	return []string{"peer/cities"}, nil
+}
+
+func (fakeReader) GetDataset(ctx context.Context, ref string, at time.Time) (*dataset.Dataset, error) {
+	return &dataset.Dataset{Meta: &dataset.Meta{Title: "cities as of " + at.Format("2006-01-02")}}, nil
+}
+
+func (fakeReader) GetBody(ctx context.Context, ref string, offset, limit int) (interface{}, error) {
+	rows := []interface{}{
+		[]interface{}{"toronto", 40000000},
+		[]interface{}{"new york", 8500000},
+	}
+	return rows[offset : offset+limit], nil
+}
+
+func (fakeReader) Log(ctx context.Context, ref string) ([]dsref.VersionInfo, error) {
+	return []dsref.VersionInfo{{Path: "/map/b"}, {Path: "/map/a"}}, nil
+}
+
+func (fakeReader) Diff(ctx context.Context, a, b string) (interface{}, error) {
+	return map[string]interface{}{"rowsAdded": 1}, nil
+}
+
+func (fakeReader) Stats(ctx context.Context, ref string) (interface{}, error) {
+	return []map[string]interface{}{{"type": "string", "count": 5}}, nil
+}
+
 // load implements the 'load' operation as used in the evaluator tests.
 func newLoader(ds *dataset.Dataset) func(thread *starlark.Thread, module string) (starlark.StringDict, error) {
 	return func(thread *starlark.Thread, module string) (starlark.StringDict, error) {
 		if module == ModuleName {
-			return starlark.StringDict{"qri": NewModule(nil).Struct()}, nil
+			return starlark.StringDict{"qri": NewModule(context.Background(), nil).Struct()}, nil
 		}
 
 		return nil, fmt.Errorf("invalid module")
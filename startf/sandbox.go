@@ -1,42 +1,307 @@
 package startf
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strings"
+	"sync"
 
+	"github.com/qri-io/qri/base/dsfs"
 	starhttp "github.com/qri-io/starlib/http"
+	"go.starlark.net/starlark"
 )
 
 var (
-	httpGuard = &HTTPGuard{}
-	// ErrNtwkDisabled is returned whenever a network call is attempted but h.NetworkEnabled is false
+	// ErrNtwkDisabled is returned whenever a network call is attempted outside
+	// of the download step
 	ErrNtwkDisabled = fmt.Errorf("network use is disabled. http can only be used during download step")
+	// ErrNtwkNotAllowed is returned when a request doesn't match the network
+	// policy allowlist
+	ErrNtwkNotAllowed = fmt.Errorf("network request not allowed")
+	// ErrNtwkBudgetExceeded is returned when a script makes more requests or
+	// reads more bytes than the network policy allows
+	ErrNtwkBudgetExceeded = fmt.Errorf("network budget exceeded")
+	// ErrNoFixture is returned when replaying fixtures and a script makes a
+	// request that wasn't recorded
+	ErrNoFixture = fmt.Errorf("no recorded fixture for request")
 )
 
-// HTTPGuard protects network requests, only allowing when network is enabled
-type HTTPGuard struct {
-	NetworkEnabled bool
+// FetchManifestConfigKey is the transform config key the manifest of fetched
+// URLs is recorded under
+const FetchManifestConfigKey = "fetched"
+
+// NetworkPolicy limits the network access of a single script execution.
+// Network access is only ever available during the download step. The zero
+// value allows requests to any host, without limits
+type NetworkPolicy struct {
+	// Allow lists hosts (eg: "example.com") or URL prefixes
+	// (eg: "https://example.com/api/") requests must match. An empty list
+	// allows all requests
+	Allow []string
+	// MaxRequests caps the number of requests, including redirects. 0 is
+	// unlimited
+	MaxRequests int
+	// MaxBytes caps the total size of all response bodies. 0 is unlimited
+	MaxBytes int64
+}
+
+// allows checks a request URL against the allowlist
+func (p NetworkPolicy) allows(req *http.Request) bool {
+	if len(p.Allow) == 0 {
+		return true
+	}
+	url := req.URL.String()
+	for _, a := range p.Allow {
+		if strings.Contains(a, "/") {
+			if strings.HasPrefix(url, a) {
+				return true
+			}
+		} else if strings.EqualFold(req.URL.Hostname(), a) {
+			return true
+		}
+	}
+	return false
+}
+
+// FetchRecord describes a response read during script execution, recording
+// the hash of the body for reproducibility
+type FetchRecord struct {
+	URL    string `json:"url"`
+	Status int    `json:"status"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// sandbox enforces a network policy for a single script execution. sandbox
+// acts as both the starlib http request guard and the round tripper for the
+// http client, so policy applies to redirects as well. A sandbox can record
+// responses as fixtures, or serve responses from fixtures instead of the
+// network
+type sandbox struct {
+	ctx       context.Context
+	policy    NetworkPolicy
+	transport http.RoundTripper
+	record    bool
+	replay    *dsfs.Fixtures
+
+	lk       sync.Mutex
+	enabled  bool
+	requests int
+	bytes    int64
+	manifest []*fetch
+}
+
+// fetch is a manifest entry that's still being read
+type fetch struct {
+	rec    FetchRecord
+	hash   hash.Hash
+	method string
+	header http.Header
+	// body buffers response bytes when recording fixtures
+	body *bytes.Buffer
+}
+
+func newSandbox(ctx context.Context, policy NetworkPolicy, record bool, replay *dsfs.Fixtures) *sandbox {
+	httpModuleLk.Lock()
+	defer httpModuleLk.Unlock()
+	transport := http.DefaultTransport
+	if starhttp.Client != nil && starhttp.Client.Transport != nil {
+		transport = starhttp.Client.Transport
+	}
+	return &sandbox{ctx: ctx, policy: policy, transport: transport, record: record, replay: replay}
+}
+
+// enableNtwk allows network calls
+func (s *sandbox) enableNtwk() {
+	s.lk.Lock()
+	s.enabled = true
+	s.lk.Unlock()
+}
+
+// disableNtwk prevents network calls from succeeding
+func (s *sandbox) disableNtwk() {
+	s.lk.Lock()
+	s.enabled = false
+	s.lk.Unlock()
 }
 
 // Allowed implements starlib/http RequestGuard
-func (h *HTTPGuard) Allowed(req *http.Request) error {
-	if !h.NetworkEnabled {
+func (s *sandbox) Allowed(req *http.Request) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	return s.allowed(req)
+}
+
+// allowed checks a request against policy. callers must hold the lock
+func (s *sandbox) allowed(req *http.Request) error {
+	if !s.enabled {
 		return ErrNtwkDisabled
 	}
+	if !s.policy.allows(req) {
+		return fmt.Errorf("%w: %s", ErrNtwkNotAllowed, req.URL)
+	}
+	if s.policy.MaxRequests > 0 && s.requests >= s.policy.MaxRequests {
+		return fmt.Errorf("%w: more than %d requests", ErrNtwkBudgetExceeded, s.policy.MaxRequests)
+	}
 	return nil
 }
 
-// EnableNtwk allows network calls
-func (h *HTTPGuard) EnableNtwk() {
-	h.NetworkEnabled = true
+// RoundTrip implements http.RoundTripper, counting requests and recording
+// responses in the manifest
+func (s *sandbox) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.lk.Lock()
+	if err := s.allowed(req); err != nil {
+		s.lk.Unlock()
+		return nil, err
+	}
+	s.requests++
+	s.lk.Unlock()
+
+	// requests are bound to the execution context, stopping downloads when
+	// execution times out or is cancelled
+	req = req.WithContext(s.ctx)
+
+	var (
+		res *http.Response
+		err error
+	)
+	if s.replay != nil {
+		res, err = s.replayResponse(req)
+	} else {
+		res, err = s.transport.RoundTrip(req)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f := &fetch{
+		rec:    FetchRecord{URL: req.URL.String(), Status: res.StatusCode},
+		hash:   sha256.New(),
+		method: req.Method,
+		header: res.Header,
+	}
+	if s.record {
+		f.body = &bytes.Buffer{}
+	}
+	s.lk.Lock()
+	s.manifest = append(s.manifest, f)
+	s.lk.Unlock()
+	res.Body = &budgetReader{ReadCloser: res.Body, s: s, f: f}
+	return res, nil
+}
+
+// Manifest lists all responses, hashing the bytes read so far
+func (s *sandbox) Manifest() []FetchRecord {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	recs := make([]FetchRecord, len(s.manifest))
+	for i, f := range s.manifest {
+		recs[i] = f.rec
+		recs[i].SHA256 = hex.EncodeToString(f.hash.Sum(nil))
+	}
+	return recs
+}
+
+// replayResponse serves a request from fixtures
+func (s *sandbox) replayResponse(req *http.Request) (*http.Response, error) {
+	fr, body, ok := s.replay.Get(req.Method, req.URL.String())
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoFixture, req.Method, req.URL)
+	}
+	header := fr.Header
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", fr.Status, http.StatusText(fr.Status)),
+		StatusCode:    fr.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// Fixtures bundles all recorded responses. Only bytes read by the script are
+// recorded
+func (s *sandbox) Fixtures() *dsfs.Fixtures {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	fx := dsfs.NewFixtures()
+	for _, f := range s.manifest {
+		if f.body != nil {
+			fx.Add(f.method, f.rec.URL, f.rec.Status, f.header, f.body.Bytes())
+		}
+	}
+	return fx
+}
+
+// budgetReader counts & hashes response bytes as they're read, failing once
+// the sandbox byte budget is exceeded
+type budgetReader struct {
+	io.ReadCloser
+	s *sandbox
+	f *fetch
+}
+
+func (r *budgetReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+
+	r.s.lk.Lock()
+	defer r.s.lk.Unlock()
+	r.f.hash.Write(p[:n])
+	if r.f.body != nil {
+		r.f.body.Write(p[:n])
+	}
+	r.f.rec.Size += int64(n)
+	r.s.bytes += int64(n)
+	if max := r.s.policy.MaxBytes; max > 0 && r.s.bytes > max {
+		return n, fmt.Errorf("%w: more than %d bytes", ErrNtwkBudgetExceeded, max)
+	}
+	return n, err
+}
+
+// loadHTTPModule creates an http module bound to the sandbox. starlib reads
+// the guard & client from package variables when a module is loaded, so
+// loads are serialized and variables are restored afterward
+func (s *sandbox) loadHTTPModule() (starlark.StringDict, error) {
+	httpModuleLk.Lock()
+	defer httpModuleLk.Unlock()
+
+	prevGuard, prevClient := starhttp.Guard, starhttp.Client
+	defer func() {
+		starhttp.Guard, starhttp.Client = prevGuard, prevClient
+	}()
+
+	cli := &http.Client{Transport: s}
+	if prevClient != nil {
+		cli.Timeout = prevClient.Timeout
+	}
+	starhttp.Guard, starhttp.Client = s, cli
+	return starhttp.LoadModule()
 }
 
-// DisableNtwk prevents network calls from succeeding
-func (h *HTTPGuard) DisableNtwk() {
-	h.NetworkEnabled = false
+var httpModuleLk sync.Mutex
+
+// closedGuard denies all requests
+type closedGuard struct{}
+
+// Allowed implements starlib/http RequestGuard
+func (closedGuard) Allowed(req *http.Request) error {
+	return ErrNtwkDisabled
 }
 
 func init() {
-	// connect httpGuard instance to starlib http guard
-	starhttp.Guard = httpGuard
+	// http modules loaded outside of ExecScript never have network access
+	starhttp.Guard = closedGuard{}
 }
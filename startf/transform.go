@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"time"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/qfs"
@@ -18,8 +19,10 @@ import (
 	skyqri "github.com/qri-io/qri/startf/qri"
 	"github.com/qri-io/qri/version"
 	"github.com/qri-io/starlib"
+	starhttp "github.com/qri-io/starlib/http"
 	"go.starlark.net/resolve"
 	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
 )
 
 // Version is the version of qri that this transform was run with
@@ -27,16 +30,22 @@ var Version = version.String
 
 // ExecOpts defines options for execution
 type ExecOpts struct {
-	Repo             repo.Repo                  // supply a repo to make the 'qri' module available in starlark
-	AllowFloat       bool                       // allow floating-point numbers
-	AllowSet         bool                       // allow set data type
-	AllowLambda      bool                       // allow lambda expressions
-	AllowNestedDef   bool                       // allow nested def statements
-	Secrets          map[string]interface{}     // passed-in secrets (eg: API keys)
-	Globals          starlark.StringDict        // global values to pass for script execution
-	MutateFieldCheck func(path ...string) error // func that errors if field specified by path is mutated
-	OutWriter        io.Writer                  // provide a writer to record script "stdout" to
-	ModuleLoader     ModuleLoader               // starlark module loader function
+	Repo             repo.Repo                      // supply a repo to make the 'qri' module available in starlark
+	AllowFloat       bool                           // allow floating-point numbers
+	AllowSet         bool                           // allow set data type
+	AllowLambda      bool                           // allow lambda expressions
+	AllowNestedDef   bool                           // allow nested def statements
+	Secrets          map[string]interface{}         // passed-in secrets (eg: API keys)
+	Globals          starlark.StringDict            // global values to pass for script execution
+	MutateFieldCheck func(path ...string) error     // func that errors if field specified by path is mutated
+	OutWriter        io.Writer                      // provide a writer to record script "stdout" to
+	ModuleLoader     ModuleLoader                   // starlark module loader function
+	QriReader        skyqri.Reader                  // supply a reader to make 'qri.star' builtins available in starlark
+	Modules          map[string]starlark.StringDict // modules to make available to load(), keyed by module name
+	Network          NetworkPolicy                  // limits on network access during the download step
+	RecordFixtures   bool                           // record responses read during the download step as fixtures
+	ReplayFixtures   *dsfs.Fixtures                 // serve download step requests from fixtures instead of the network
+	Limits           Limits                         // caps on execution steps, time & body entries
 }
 
 // AddQriRepo adds a qri repo to execution options, providing scripted access
@@ -47,6 +56,59 @@ func AddQriRepo(repo repo.Repo) func(o *ExecOpts) {
 	}
 }
 
+// AddQriReader provides read-only access to datasets for builtins in the
+// 'qri.star' module
+func AddQriReader(r skyqri.Reader) func(o *ExecOpts) {
+	return func(o *ExecOpts) {
+		o.QriReader = r
+	}
+}
+
+// AddModule registers a starlark module that scripts can load by name, eg:
+// AddModule("geo.star", starlark.StringDict{"geo": geoStruct}) is loaded with
+// load("geo.star", "geo"). Registered modules take precedence over modules
+// from ModuleLoader, but can't replace the 'qri.star' module
+func AddModule(name string, module starlark.StringDict) func(o *ExecOpts) {
+	return func(o *ExecOpts) {
+		if o.Modules == nil {
+			o.Modules = map[string]starlark.StringDict{}
+		}
+		o.Modules[name] = module
+	}
+}
+
+// SetNetworkPolicy limits network access for a single script execution
+func SetNetworkPolicy(p NetworkPolicy) func(o *ExecOpts) {
+	return func(o *ExecOpts) {
+		o.Network = p
+	}
+}
+
+// SetLimits caps the resources a single script execution can use
+func SetLimits(l Limits) func(o *ExecOpts) {
+	return func(o *ExecOpts) {
+		o.Limits = l
+	}
+}
+
+// RecordFixtures records all responses read during the download step in a
+// fixture bundle that's saved alongside the transform, making it possible to
+// replay the transform without network access
+func RecordFixtures(record bool) func(o *ExecOpts) {
+	return func(o *ExecOpts) {
+		o.RecordFixtures = record
+	}
+}
+
+// ReplayFixtures serves requests made during the download step from a fixture
+// bundle instead of the network. Requests without a recorded response fail
+// with ErrNoFixture
+func ReplayFixtures(f *dsfs.Fixtures) func(o *ExecOpts) {
+	return func(o *ExecOpts) {
+		o.ReplayFixtures = f
+	}
+}
+
 // AddMutateFieldCheck provides a checkFunc to ExecScript
 func AddMutateFieldCheck(check func(path ...string) error) func(o *ExecOpts) {
 	return func(o *ExecOpts) {
@@ -98,7 +160,10 @@ type transform struct {
 	globals      starlark.StringDict
 	bodyFile     qfs.File
 	stderr       io.Writer
+	sandbox      *sandbox
+	modules      map[string]starlark.StringDict
 	moduleLoader ModuleLoader
+	maxEntries   int
 
 	download starlark.Iterable
 }
@@ -111,6 +176,11 @@ var DefaultModuleLoader = func(thread *starlark.Thread, module string) (dict sta
 	return starlib.Loader(thread, module)
 }
 
+// RepoReader builds the reader for 'qri.star' builtins when execution options
+// supply a repo but no reader. The base package sets it, which startf can't
+// import without an import cycle
+var RepoReader func(r repo.Repo) skyqri.Reader
+
 // ExecScript executes a transformation against a starlark script file. The next dataset pointer
 // may be modified, while the prev dataset point is read-only. At a bare minimum this function
 // will set transformation details, but starlark scripts can modify many parts of the dataset
@@ -127,6 +197,9 @@ func ExecScript(ctx context.Context, next, prev *dataset.Dataset, opts ...func(o
 	for _, opt := range opts {
 		opt(o)
 	}
+	if o.QriReader == nil && o.Repo != nil && RepoReader != nil {
+		o.QriReader = RepoReader(o.Repo)
+	}
 
 	// hoist execution settings to resolve package settings
 	resolve.AllowFloat = o.AllowFloat
@@ -140,6 +213,13 @@ func ExecScript(ctx context.Context, next, prev *dataset.Dataset, opts ...func(o
 		starlark.Universe[key] = val
 	}
 
+	if o.Limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Limits.Timeout)
+		defer cancel()
+	}
+	steps := &stepper{ctx: ctx, timeout: o.Limits.Timeout, max: o.Limits.MaxSteps}
+
 	// set transform details
 	next.Transform.Syntax = "starlark"
 	next.Transform.SyntaxVersion = Version
@@ -156,12 +236,19 @@ func ExecScript(ctx context.Context, next, prev *dataset.Dataset, opts ...func(o
 		repo:         o.Repo,
 		next:         next,
 		prev:         prev,
-		skyqri:       skyqri.NewModule(o.Repo),
+		skyqri:       skyqri.NewModule(ctx, o.QriReader),
 		checkFunc:    o.MutateFieldCheck,
 		stderr:       o.OutWriter,
+		sandbox:      newSandbox(ctx, o.Network, o.RecordFixtures, o.ReplayFixtures),
+		modules:      o.Modules,
 		moduleLoader: o.ModuleLoader,
+		maxEntries:   o.Limits.MaxBodyEntries,
 	}
 
+	// drop any manifest & fixtures recorded by a previous execution before the
+	// script can see config
+	delete(next.Transform.Config, FetchManifestConfigKey)
+	delete(next.Transform.Config, dsfs.FixturesConfigKey)
 	skyCtx := skyctx.NewContext(next.Transform.Config, o.Secrets)
 
 	thread := &starlark.Thread{
@@ -173,12 +260,9 @@ func ExecScript(ctx context.Context, next, prev *dataset.Dataset, opts ...func(o
 	}
 
 	// execute the transformation
-	t.globals, err = starlark.ExecFile(thread, pipeScript.FileName(), pipeScript, t.locals())
+	t.globals, err = execFile(thread, pipeScript.FileName(), pipeScript, t.locals(), steps)
 	if err != nil {
-		if evalErr, ok := err.(*starlark.EvalError); ok {
-			return fmt.Errorf(evalErr.Backtrace())
-		}
-		return err
+		return steps.scriptError(err)
 	}
 
 	funcs, err := t.specialFuncs()
@@ -190,23 +274,88 @@ func ExecScript(ctx context.Context, next, prev *dataset.Dataset, opts ...func(o
 		val, err := fn(t, thread, skyCtx)
 
 		if err != nil {
-			if evalErr, ok := err.(*starlark.EvalError); ok {
-				return fmt.Errorf(evalErr.Backtrace())
-			}
-			return err
+			return steps.scriptError(err)
 		}
 
 		skyCtx.SetResult(name, val)
 	}
 
-	err = callTransformFunc(t, thread, skyCtx)
-	if evalErr, ok := err.(*starlark.EvalError); ok {
-		return fmt.Errorf(evalErr.Backtrace())
+	if err = steps.check(); err == nil {
+		err = callTransformFunc(t, thread, skyCtx)
 	}
 
 	// restore consumed script file
 	next.Transform.SetScriptFile(qfs.NewMemfileBytes("transform.star", buf.Bytes()))
+	t.recordManifest()
+	t.recordFixtures(o.RecordFixtures, o.ReplayFixtures)
+
+	if err == nil {
+		err = checkBodyEntries(next, o.Limits.MaxBodyEntries)
+	} else {
+		err = steps.scriptError(err)
+	}
+	if err != nil {
+		// drop temp files backing bodies written with append_rows
+		if body, ok := next.BodyFile().(*dsfs.TempBodyFile); ok {
+			body.Close()
+		}
+	}
+	return err
+}
+
+// ScriptError is returned by ExecScript when a transform script fails,
+// recording the position in the script where execution stopped
+type ScriptError struct {
+	Msg       string // error message without position details
+	Filename  string // name of the script file that failed
+	Line      int    // 1-based line number, 0 if unknown
+	Col       int    // 1-based column number, 0 if unknown
+	Backtrace string // user-friendly description of the starlark call stack
+}
+
+// Error implements the error interface, giving the full backtrace
+func (e *ScriptError) Error() string {
+	if e.Backtrace != "" {
+		return e.Backtrace
+	}
+	return fmt.Sprintf("%s: %s", e.Position(), e.Msg)
+}
+
+// Position gives the "filename:line:col" location of the error
+func (e *ScriptError) Position() string {
+	return fmt.Sprintf("%s:%d:%d", e.Filename, e.Line, e.Col)
+}
 
+// newScriptError adds script position details to errors returned by starlark.
+// errors that don't come from the starlark interpreter are returned unchanged
+func newScriptError(err error) error {
+	setPos := func(se *ScriptError, pos syntax.Position) {
+		se.Filename = pos.Filename()
+		se.Line = int(pos.Line)
+		se.Col = int(pos.Col)
+	}
+
+	switch e := err.(type) {
+	case *starlark.EvalError:
+		se := &ScriptError{Msg: e.Msg, Backtrace: e.Backtrace()}
+		// use the innermost frame that has a position within a script. calls
+		// to builtins don't have a line number
+		for i := range e.CallStack {
+			if fr := e.CallStack.At(i); fr.Pos.Line > 0 {
+				setPos(se, fr.Pos)
+				break
+			}
+		}
+		return se
+	case syntax.Error:
+		se := &ScriptError{Msg: e.Msg, Backtrace: e.Error()}
+		setPos(se, e.Pos)
+		return se
+	case resolve.ErrorList:
+		se := &ScriptError{Msg: e[0].Msg, Backtrace: e.Error()}
+		setPos(se, e[0].Pos)
+		return se
+	}
 	return err
 }
 
@@ -259,8 +408,8 @@ func (t *transform) specialFuncs() (defined map[string]specialFunc, err error) {
 type specialFunc func(t *transform, thread *starlark.Thread, ctx *skyctx.Context) (result starlark.Value, err error)
 
 func callDownloadFunc(t *transform, thread *starlark.Thread, ctx *skyctx.Context) (result starlark.Value, err error) {
-	httpGuard.EnableNtwk()
-	defer httpGuard.DisableNtwk()
+	t.sandbox.enableNtwk()
+	defer t.sandbox.disableNtwk()
 	t.print("📡 running download...\n")
 
 	var download *starlark.Function
@@ -286,10 +435,17 @@ func callTransformFunc(t *transform, thread *starlark.Thread, ctx *skyctx.Contex
 
 	d := skyds.NewDataset(t.prev, t.checkFunc)
 	d.SetMutable(t.next)
-	if _, err = starlark.Call(thread, transform, starlark.Tuple{d.Methods(), ctx.Struct()}, nil); err != nil {
-		return err
+	d.SetMaxRows(t.maxEntries)
+	_, err = starlark.Call(thread, transform, starlark.Tuple{d.Methods(), ctx.Struct()}, nil)
+	if d.MaxRowsExceeded() {
+		err = &LimitError{Kind: LimitBodyEntries, Limit: fmt.Sprintf("%d", t.maxEntries)}
 	}
-	return nil
+	// write any rows added with append_rows to the body, even on error so the
+	// body file can be cleaned up
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
 }
 
 // print writes output only if a node is specified
@@ -308,6 +464,12 @@ func (t *transform) ModuleLoader(thread *starlark.Thread, module string) (dict s
 	if module == skyqri.ModuleName && t.skyqri != nil {
 		return t.skyqri.Namespace(), nil
 	}
+	if module == starhttp.ModuleName {
+		return t.sandbox.loadHTTPModule()
+	}
+	if module, ok := t.modules[module]; ok {
+		return module, nil
+	}
 
 	if t.moduleLoader == nil {
 		return nil, fmt.Errorf("couldn't load module: %s", module)
@@ -316,14 +478,53 @@ func (t *transform) ModuleLoader(thread *starlark.Thread, module string) (dict s
 	return t.moduleLoader(thread, module)
 }
 
-// LoadDataset is a function
+// recordManifest stores every URL fetched during execution in transform
+// config, making it possible to check if a transform would fetch the same
+// data again
+func (t *transform) recordManifest() {
+	manifest := t.sandbox.Manifest()
+	if len(manifest) == 0 {
+		return
+	}
+	if t.next.Transform.Config == nil {
+		t.next.Transform.Config = map[string]interface{}{}
+	}
+	t.next.Transform.Config[FetchManifestConfigKey] = manifest
+}
+
+// recordFixtures stores the fixture bundle in transform config for dsfs to
+// write alongside the transform. replayed fixtures are kept as-is
+func (t *transform) recordFixtures(record bool, replay *dsfs.Fixtures) {
+	fixtures := replay
+	if replay == nil && record {
+		fixtures = t.sandbox.Fixtures()
+	}
+	if fixtures == nil || fixtures.Len() == 0 {
+		return
+	}
+	if t.next.Transform.Config == nil {
+		t.next.Transform.Config = map[string]interface{}{}
+	}
+	t.next.Transform.Config[dsfs.FixturesConfigKey] = fixtures
+}
+
+// LoadDataset is a function. An optional as_of timestamp loads the version
+// of the dataset that was the latest at that time
 func (t *transform) LoadDataset(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
-	var refstr starlark.String
-	if err := starlark.UnpackArgs("load_dataset", args, kwargs, "ref", &refstr); err != nil {
+	var refstr, asOfStr starlark.String
+	if err := starlark.UnpackArgs("load_dataset", args, kwargs, "ref", &refstr, "as_of?", &asOfStr); err != nil {
 		return starlark.None, err
 	}
 
-	ds, err := t.loadDataset(t.ctx, refstr.GoString())
+	var asOf time.Time
+	if asOfStr.GoString() != "" {
+		var err error
+		if asOf, err = repo.ParseAsOf(asOfStr.GoString()); err != nil {
+			return starlark.None, err
+		}
+	}
+
+	ds, err := t.loadDataset(t.ctx, refstr.GoString(), asOf)
 	if err != nil {
 		return starlark.None, err
 	}
@@ -331,7 +532,7 @@ func (t *transform) LoadDataset(thread *starlark.Thread, _ *starlark.Builtin, ar
 	return skyds.NewDataset(ds, nil).Methods(), nil
 }
 
-func (t *transform) loadDataset(ctx context.Context, refstr string) (*dataset.Dataset, error) {
+func (t *transform) loadDataset(ctx context.Context, refstr string, asOf time.Time) (*dataset.Dataset, error) {
 	if t.repo == nil {
 		return nil, fmt.Errorf("no qri repo available to load dataset: %s", refstr)
 	}
@@ -340,9 +541,17 @@ func (t *transform) loadDataset(ctx context.Context, refstr string) (*dataset.Da
 	if err != nil {
 		return nil, err
 	}
+	if err := repo.CheckAsOf(ref, asOf); err != nil {
+		return nil, err
+	}
 	if err := repo.CanonicalizeDatasetRef(t.repo, &ref); err != nil {
 		return nil, err
 	}
+	if !asOf.IsZero() {
+		if err := repo.ResolveAsOf(ctx, t.repo, &ref, asOf); err != nil {
+			return nil, err
+		}
+	}
 
 	ds, err := dsfs.LoadDataset(ctx, t.repo.Store(), ref.Path)
 	if err != nil {
@@ -350,9 +559,11 @@ func (t *transform) loadDataset(ctx context.Context, refstr string) (*dataset.Da
 	}
 
 	if ds.BodyFile() == nil {
-		if err = ds.OpenBodyFile(ctx, t.repo.Filesystem()); err != nil {
+		body, err := dsfs.LoadBody(ctx, t.repo.Store(), ds)
+		if err != nil {
 			return nil, err
 		}
+		ds.SetBodyFile(body)
 	}
 
 	if t.next.Transform.Resources == nil {
@@ -3,20 +3,26 @@ package startf
 import (
 	"bytes"
 	"context"
+	"errors"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/dataset/dsio"
 	"github.com/qri-io/qfs"
+	"github.com/qri-io/qri/base/dsfs"
 	"github.com/qri-io/qri/repo"
 	repoTest "github.com/qri-io/qri/repo/test"
 	"github.com/qri-io/starlib"
 	"github.com/qri-io/starlib/testdata"
 	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
 	"go.starlark.net/starlarktest"
 )
 
@@ -117,6 +123,229 @@ func TestExecScript2(t *testing.T) {
 	}
 }
 
+func TestNetworkPolicy(t *testing.T) {
+	ctx := context.Background()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`["a","b"]`))
+	}))
+	defer s.Close()
+
+	exec := func(script string, policy NetworkPolicy) (*dataset.Dataset, error) {
+		ds := &dataset.Dataset{Transform: &dataset.Transform{}}
+		ds.Transform.SetScriptFile(qfs.NewMemfileBytes("tf.star", []byte(script)))
+		err := ExecScript(ctx, ds, nil, SetNetworkPolicy(policy), func(o *ExecOpts) {
+			o.Globals["test_server_url"] = starlark.String(s.URL)
+		})
+		return ds, err
+	}
+
+	fetchTwice := `
+load("http.star", "http")
+
+def download(ctx):
+  http.get(test_server_url + "/a").json()
+  return http.get(test_server_url + "/b").json()
+
+def transform(ds, ctx):
+  ds.set_body(ctx.download)
+`
+	ds, err := exec(fetchTwice, NetworkPolicy{Allow: []string{s.URL + "/"}, MaxRequests: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, ok := ds.Transform.Config[FetchManifestConfigKey].([]FetchRecord)
+	if !ok || len(manifest) != 2 {
+		t.Fatalf("expected manifest of 2 fetches. got: %v", ds.Transform.Config[FetchManifestConfigKey])
+	}
+	expect := FetchRecord{
+		URL:    s.URL + "/b",
+		Status: 200,
+		Size:   9,
+		SHA256: "0473ef2dc0d324ab659d3580c1134e9d812035905c4781fdd6d529b0c6860e13",
+	}
+	if diff := cmp.Diff(expect, manifest[1]); diff != "" {
+		t.Errorf("fetch record mismatch (-want +got):\n%s", diff)
+	}
+
+	errCases := []struct {
+		description string
+		script      string
+		policy      NetworkPolicy
+		err         error
+	}{
+		{"host not in allowlist", fetchTwice, NetworkPolicy{Allow: []string{"example.com"}}, ErrNtwkNotAllowed},
+		{"too many requests", fetchTwice, NetworkPolicy{MaxRequests: 1}, ErrNtwkBudgetExceeded},
+		{"too many bytes", fetchTwice, NetworkPolicy{MaxBytes: 12}, ErrNtwkBudgetExceeded},
+		{"network outside of download", `
+load("http.star", "http")
+
+def transform(ds, ctx):
+  http.get(test_server_url)
+`, NetworkPolicy{}, ErrNtwkDisabled},
+	}
+	for _, c := range errCases {
+		_, err := exec(c.script, c.policy)
+		if err == nil || !strings.Contains(err.Error(), c.err.Error()) {
+			t.Errorf("case %q: expected error containing %q. got: %v", c.description, c.err, err)
+		}
+	}
+
+	// policies of concurrent executions don't interfere
+	var wg sync.WaitGroup
+	errs := make([]error, 20)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			policy := NetworkPolicy{}
+			if i%2 == 1 {
+				policy.Allow = []string{"example.com"}
+			}
+			_, errs[i] = exec(fetchTwice, policy)
+		}(i)
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if denied := err != nil; denied != (i%2 == 1) {
+			t.Errorf("execution %d: expected denied to be %t. got error: %v", i, i%2 == 1, err)
+		}
+	}
+}
+
+func TestRecordReplayFixtures(t *testing.T) {
+	ctx := context.Background()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Set-Cookie", "session=secret")
+		w.Header().Set("Authorization", "Bearer secret")
+		w.Write([]byte(`["` + r.URL.Path + `"]`))
+	}))
+	serverURL := s.URL
+
+	exec := func(path string, opts ...func(o *ExecOpts)) (*dataset.Dataset, error) {
+		script := `
+load("http.star", "http")
+
+def download(ctx):
+  return http.get(server_url + "` + path + `").json()
+
+def transform(ds, ctx):
+  ds.set_body(ctx.download)
+`
+		ds := &dataset.Dataset{Transform: &dataset.Transform{}}
+		ds.Transform.SetScriptFile(qfs.NewMemfileBytes("tf.star", []byte(script)))
+		opts = append(opts, func(o *ExecOpts) {
+			o.Globals["server_url"] = starlark.String(serverURL)
+		})
+		err := ExecScript(ctx, ds, nil, opts...)
+		return ds, err
+	}
+
+	ds, err := exec("/a", RecordFixtures(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures, ok := ds.Transform.Config[dsfs.FixturesConfigKey].(*dsfs.Fixtures)
+	if !ok || fixtures.Len() != 1 {
+		t.Fatalf("expected fixtures with 1 response. got: %v", ds.Transform.Config[dsfs.FixturesConfigKey])
+	}
+	res, body, _ := fixtures.Get("GET", serverURL+"/a")
+	if string(body) != `["/a"]` {
+		t.Errorf("recorded body mismatch. expected: %s, got: %s", `["/a"]`, body)
+	}
+	expectHeader := http.Header{"Content-Type": {"application/json"}}
+	if diff := cmp.Diff(expectHeader, res.Header); diff != "" {
+		t.Errorf("recorded headers mismatch (-want +got):\n%s", diff)
+	}
+
+	// replays never touch the network
+	s.Close()
+	ds, err = exec("/a", ReplayFixtures(fixtures))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(ds.BodyFile())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `["/a"]` {
+		t.Errorf("replayed body mismatch. expected: %s, got: %s", `["/a"]`, data)
+	}
+	if ds.Transform.Config[dsfs.FixturesConfigKey] != fixtures {
+		t.Errorf("expected replayed fixtures to be kept in transform config")
+	}
+
+	if _, err = exec("/b", ReplayFixtures(fixtures)); err == nil || !strings.Contains(err.Error(), ErrNoFixture.Error()) {
+		t.Errorf("expected error containing %q replaying an unrecorded request. got: %v", ErrNoFixture, err)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	exec := func(ctx context.Context, script string, limits Limits) error {
+		ds := &dataset.Dataset{Transform: &dataset.Transform{}}
+		ds.Transform.SetScriptFile(qfs.NewMemfileBytes("tf.star", []byte(script)))
+		return ExecScript(ctx, ds, nil, SetLimits(limits))
+	}
+
+	loop := `
+def transform(ds, ctx):
+  total = 0
+  for i in range(1000000000):
+    total += i
+  ds.set_body([total])
+`
+	comprehension := `
+def transform(ds, ctx):
+  ds.set_body([[a, b] for a in range(10) for b in range(10)])
+`
+	appended := `
+def transform(ds, ctx):
+  for i in range(10):
+    ds.append_rows([[i, j] for j in range(10)])
+`
+	runawayAppend := `
+def transform(ds, ctx):
+  for i in range(1000000000):
+    ds.append_rows([[i]])
+`
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	cases := []struct {
+		description string
+		ctx         context.Context
+		script      string
+		limits      Limits
+		kind        LimitKind
+	}{
+		{"loop exceeds steps", context.Background(), loop, Limits{MaxSteps: 1000}, LimitSteps},
+		{"comprehension exceeds steps", context.Background(), comprehension, Limits{MaxSteps: 50}, LimitSteps},
+		{"loop exceeds timeout", context.Background(), loop, Limits{Timeout: time.Millisecond * 50}, LimitTimeout},
+		{"context cancelled", cancelled, loop, Limits{}, LimitTimeout},
+		{"too many body entries", context.Background(), comprehension, Limits{MaxBodyEntries: 99}, LimitBodyEntries},
+		{"too many appended body entries", context.Background(), appended, Limits{MaxBodyEntries: 99}, LimitBodyEntries},
+		{"appended body entries stop at the limit", context.Background(), runawayAppend, Limits{MaxBodyEntries: 99}, LimitBodyEntries},
+	}
+	for _, c := range cases {
+		err := exec(c.ctx, c.script, c.limits)
+		limitErr := &LimitError{}
+		if !errors.As(err, &limitErr) {
+			t.Errorf("case %q: expected LimitError. got: %v", c.description, err)
+			continue
+		}
+		if limitErr.Kind != c.kind {
+			t.Errorf("case %q: expected %q limit error. got: %q", c.description, c.kind, limitErr.Kind)
+		}
+	}
+
+	if !errors.Is(exec(cancelled, loop, Limits{}), context.Canceled) {
+		t.Errorf("expected cancelled execution error to wrap context.Canceled")
+	}
+	if err := exec(context.Background(), comprehension, Limits{MaxSteps: 200, MaxBodyEntries: 100}); err != nil {
+		t.Errorf("expected script within limits to succeed. got: %s", err)
+	}
+}
+
 func TestScriptError(t *testing.T) {
 	ctx := context.Background()
 	script := `
@@ -129,8 +358,28 @@ def transform(ds, ctx):
 		Transform: &dataset.Transform{},
 	}
 	ds.Transform.SetScriptFile(scriptFile)
-	if err := ExecScript(ctx, ds, nil); err == nil {
-		t.Errorf("expected script to error. got nil")
+	err := ExecScript(ctx, ds, nil)
+	if err == nil {
+		t.Fatal("expected script to error. got nil")
+	}
+	scriptErr, ok := err.(*ScriptError)
+	if !ok {
+		t.Fatalf("expected error to be a *ScriptError. got: %T", err)
+	}
+	if scriptErr.Position() != "tf.star:3:7" {
+		t.Errorf("error position mismatch. expected: 'tf.star:3:7', got: '%s'", scriptErr.Position())
+	}
+	if expect := `transform error: "script error"`; scriptErr.Msg != expect {
+		t.Errorf("error message mismatch. expected: '%s', got: '%s'", expect, scriptErr.Msg)
+	}
+
+	ds.Transform.SetScriptFile(qfs.NewMemfileBytes("tf.star", []byte("x = )\n")))
+	err = ExecScript(ctx, ds, nil)
+	if scriptErr, ok = err.(*ScriptError); !ok {
+		t.Fatalf("expected syntax error to be a *ScriptError. got: %T", err)
+	}
+	if scriptErr.Line != 1 {
+		t.Errorf("expected syntax error on line 1. got: %d", scriptErr.Line)
 	}
 }
 
@@ -152,6 +401,42 @@ func TestLoadDataset(t *testing.T) {
 	}
 }
 
+func TestLoadDatasetAsOf(t *testing.T) {
+	ctx := context.Background()
+	repo := testRepo(t)
+
+	ds := &dataset.Dataset{
+		Transform: &dataset.Transform{},
+	}
+	ds.Transform.SetScriptFile(scriptFile(t, "testdata/load_ds_as_of.star"))
+
+	err := ExecScript(ctx, ds, nil, func(o *ExecOpts) {
+		o.Repo = repo
+		o.ModuleLoader = testModuleLoader(t)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddModule(t *testing.T) {
+	ctx := context.Background()
+	ds := &dataset.Dataset{
+		Transform: &dataset.Transform{},
+	}
+	ds.Transform.SetScriptFile(scriptFile(t, "testdata/custom_module.star"))
+
+	geo := starlark.StringDict{"geo": starlarkstruct.FromStringDict(starlarkstruct.Default, starlark.StringDict{
+		"continent": starlark.String("antarctica"),
+	})}
+	err := ExecScript(ctx, ds, nil, AddModule("geo.star", geo), func(o *ExecOpts) {
+		o.ModuleLoader = testModuleLoader(t)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestGetMetaNilPrev(t *testing.T) {
 	ctx := context.Background()
 	ds := &dataset.Dataset{
@@ -1,12 +1,18 @@
 package stats
 
 import (
+	"bytes"
 	"context"
 	"encoding/base32"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
 var (
@@ -14,6 +20,9 @@ var (
 	ErrCacheMiss = fmt.Errorf("stats: cache miss")
 	// ErrNoCache indicates there is no cache
 	ErrNoCache = fmt.Errorf("stats: no cache")
+	// ErrCacheEntryTooLarge indicates a value is larger than the maximum size
+	// of the cache, and cannot be stored
+	ErrCacheEntryTooLarge = fmt.Errorf("stats: cache entry exceeds cache max size")
 )
 
 // Cache is a store of JSON-formated stats data, keyed by path
@@ -30,36 +39,178 @@ type Cache interface {
 }
 
 // osCache is a stats cache stored in a directory on the local operating system
+// entries are evicted least-recently-used first once the total size of all
+// entries exceeds maxSize. File modification times record recency of use, so
+// eviction order survives restarts
 type osCache struct {
 	root    string
 	maxSize uint64
+
+	lk      sync.Mutex
+	size    uint64
+	entries map[string]*cacheEntry
+}
+
+// cacheEntry tracks the size and last use of a file in the cache
+type cacheEntry struct {
+	filename string
+	size     uint64
+	used     time.Time
 }
 
 var _ Cache = (*osCache)(nil)
 
-// NewOSCache creates a cache in a local direcory
+// NewOSCache creates a cache in a local direcory. Any entries already stored in
+// rootDir are loaded into the cache
 func NewOSCache(rootDir string, maxSize uint64) Cache {
-	if err := os.MkdirAll(rootDir, os.ModePerm); err != nil {
-		// log.Errorf("stat: %s", args ...interface{})
-	}
-	return osCache{
+	c := &osCache{
 		root:    rootDir,
 		maxSize: maxSize,
+		entries: map[string]*cacheEntry{},
 	}
+	if err := os.MkdirAll(rootDir, os.ModePerm); err != nil {
+		log.Errorf("creating stats cache directory: %s", err)
+		return c
+	}
+	if err := c.load(); err != nil {
+		log.Errorf("loading stats cache: %s", err)
+	}
+	return c
 }
 
-// Put places stats in the cache, keyed by path
-func (c osCache) PutJSON(ctx context.Context, path string, r io.Reader) error {
-	filename := fmt.Sprintf("%s.json", b32Enc.EncodeToString([]byte(path)))
-	// TODO (b5) - use this
-	_ = filepath.Join(c.root, filename)
+// load reads the cache index from the filesystem
+func (c *osCache) load() error {
+	infos, err := ioutil.ReadDir(c.root)
+	if err != nil {
+		return err
+	}
+
+	for _, fi := range infos {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".json") {
+			continue
+		}
+		key, err := b32Enc.DecodeString(strings.TrimSuffix(fi.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		c.entries[string(key)] = &cacheEntry{
+			filename: fi.Name(),
+			size:     uint64(fi.Size()),
+			used:     fi.ModTime(),
+		}
+		c.size += uint64(fi.Size())
+	}
+
+	return c.evict()
+}
+
+// PutJSON places stats in the cache, keyed by path
+func (c *osCache) PutJSON(ctx context.Context, path string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	size := uint64(len(data))
+	if size > c.maxSize {
+		return ErrCacheEntryTooLarge
+	}
+
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	filename := cacheFilename(path)
+	// write to a temp file & rename so readers never see a partial write
+	tmp, err := ioutil.TempFile(c.root, "put_")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.root, filename)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if prev, ok := c.entries[path]; ok {
+		c.size -= prev.size
+	}
+	c.entries[path] = &cacheEntry{
+		filename: filename,
+		size:     size,
+		used:     time.Now(),
+	}
+	c.size += size
 
-	return fmt.Errorf("not finished")
+	return c.evict()
 }
 
 // JSON gets cached byte data for a path
-func (c osCache) JSON(ctx context.Context, path string) (r io.Reader, err error) {
-	return nil, ErrCacheMiss
+func (c *osCache) JSON(ctx context.Context, path string) (r io.Reader, err error) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+
+	e, ok := c.entries[path]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+
+	fp := filepath.Join(c.root, e.filename)
+	data, err := ioutil.ReadFile(fp)
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.size -= e.size
+			delete(c.entries, path)
+			return nil, ErrCacheMiss
+		}
+		return nil, err
+	}
+
+	e.used = time.Now()
+	if err := os.Chtimes(fp, e.used, e.used); err != nil {
+		log.Debugf("updating stats cache access time: %s", err)
+	}
+
+	return bytes.NewReader(data), nil
+}
+
+// evict drops least-recently-used entries until the cache is within its max
+// size. callers must hold the cache lock
+func (c *osCache) evict() error {
+	if c.size <= c.maxSize {
+		return nil
+	}
+
+	keys := make([]string, 0, len(c.entries))
+	for key := range c.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.entries[keys[i]].used.Before(c.entries[keys[j]].used)
+	})
+
+	for _, key := range keys {
+		if c.size <= c.maxSize {
+			break
+		}
+		e := c.entries[key]
+		if err := os.Remove(filepath.Join(c.root, e.filename)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		c.size -= e.size
+		delete(c.entries, key)
+	}
+	return nil
+}
+
+func cacheFilename(path string) string {
+	return fmt.Sprintf("%s.json", b32Enc.EncodeToString([]byte(path)))
 }
 
 var b32Enc = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
@@ -6,11 +6,10 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestOSCache(t *testing.T) {
-	t.Skip("skipping caching for now")
-
 	tmp, err := ioutil.TempDir("", "test_os_cache")
 	if err != nil {
 		t.Fatal(err)
@@ -33,7 +32,7 @@ func TestOSCache(t *testing.T) {
 
 	// overwrite data at path "statsA"
 	statsA2 := bytes.Repeat([]byte{'p'}, 50)
-	if err = cache.PutJSON(ctx, "statsA", bytes.NewReader(statsA)); err != nil {
+	if err = cache.PutJSON(ctx, "statsA", bytes.NewReader(statsA2)); err != nil {
 		t.Errorf("expected putting json data to not fail. got: %s", err)
 	}
 	got = cacheBytes(t, cache, "statsA")
@@ -52,6 +51,61 @@ func TestOSCache(t *testing.T) {
 	if getAErr != ErrCacheMiss && getBErr != ErrCacheMiss {
 		t.Errorf("expected at least one cache in an overflow state to ErrCacheMiss. got:\n\tstatA: %v\n\tstatB: %v", getAErr, getBErr)
 	}
+
+	// data larger than the entire cache should be rejected
+	statsC := bytes.Repeat([]byte{'o'}, 101)
+	if err = cache.PutJSON(ctx, "statsC", bytes.NewReader(statsC)); err != ErrCacheEntryTooLarge {
+		t.Errorf("expected putting oversized data to return ErrCacheEntryTooLarge. got: %v", err)
+	}
+}
+
+func TestOSCacheLRU(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "test_os_cache_lru")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+
+	ctx := context.Background()
+	cache := NewOSCache(tmp, 100)
+
+	data := bytes.Repeat([]byte{'o'}, 40)
+	for _, path := range []string{"statsA", "statsB"} {
+		if err = cache.PutJSON(ctx, path, bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	// reading statsA makes statsB the least recently used entry
+	cacheBytes(t, cache, "statsA")
+	time.Sleep(time.Millisecond * 10)
+
+	if err = cache.PutJSON(ctx, "statsC", bytes.NewReader(data)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.JSON(ctx, "statsB"); err != ErrCacheMiss {
+		t.Errorf("expected least recently used entry to be evicted. got: %v", err)
+	}
+
+	// a new cache in the same directory should pick up existing entries
+	cache = NewOSCache(tmp, 100)
+	for _, path := range []string{"statsA", "statsC"} {
+		if got := cacheBytes(t, cache, path); !bytes.Equal(data, got) {
+			t.Errorf("path %q: response bytes don't match input bytes after reload", path)
+		}
+	}
+	if _, err := cache.JSON(ctx, "statsB"); err != ErrCacheMiss {
+		t.Errorf("expected evicted entry to stay evicted after reload. got: %v", err)
+	}
+
+	// shrinking the max size evicts on load
+	cache = NewOSCache(tmp, 50)
+	_, getAErr := cache.JSON(ctx, "statsA")
+	_, getCErr := cache.JSON(ctx, "statsC")
+	if getAErr == nil && getCErr == nil {
+		t.Errorf("expected loading a cache larger than max size to evict entries")
+	}
 }
 
 func cacheBytes(t *testing.T, c Cache, path string) []byte {
@@ -0,0 +1,385 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// DriftThresholds sets how much a column can change between two versions
+// before the change is flagged
+type DriftThresholds struct {
+	// MeanChange is the largest change in a numeric column mean, relative to
+	// the previous mean
+	MeanChange float64 `json:"meanChange"`
+	// NullRatioChange is the largest absolute change in the fraction of null
+	// values
+	NullRatioChange float64 `json:"nullRatioChange"`
+	// DistinctChange is the largest change in distinct value count, relative
+	// to the previous count
+	DistinctChange float64 `json:"distinctChange"`
+	// PSI is the largest population stability index of a column's value
+	// distribution. Values above 0.2 are conventionally a significant shift
+	PSI float64 `json:"psi"`
+}
+
+// DefaultDriftThresholds are the thresholds used when none are given
+var DefaultDriftThresholds = DriftThresholds{
+	MeanChange:      0.1,
+	NullRatioChange: 0.05,
+	DistinctChange:  0.2,
+	PSI:             0.2,
+}
+
+// Drift flags
+const (
+	// DriftAdded flags a column that only exists in the next version
+	DriftAdded = "added"
+	// DriftRemoved flags a column that only exists in the previous version
+	DriftRemoved = "removed"
+	// DriftType flags a column that changed type
+	DriftType = "type"
+	// DriftMean flags a column whose mean moved past threshold
+	DriftMean = "mean"
+	// DriftNullRatio flags a column whose null ratio moved past threshold
+	DriftNullRatio = "nullRatio"
+	// DriftDistinct flags a column whose distinct count moved past threshold
+	DriftDistinct = "distinct"
+	// DriftPSI flags a column whose value distribution shifted past threshold
+	DriftPSI = "psi"
+)
+
+// DriftReport describes per-column changes in statistics between two versions
+// of a dataset
+type DriftReport struct {
+	Thresholds DriftThresholds `json:"thresholds"`
+	Columns    []*ColumnDrift  `json:"columns"`
+	// Flagged is the number of columns with at least one flag
+	Flagged int `json:"flagged"`
+}
+
+// ColumnDrift describes the changes to one column
+type ColumnDrift struct {
+	// Column is the key of the column for object rows, or its index for
+	// array rows
+	Column string `json:"column"`
+	// Type is the stat type of the column in the next version
+	Type string `json:"type,omitempty"`
+	// PrevType is the stat type of the column in the previous version
+	PrevType  string  `json:"prevType,omitempty"`
+	Mean      *Change `json:"mean,omitempty"`
+	NullRatio *Change `json:"nullRatio,omitempty"`
+	Distinct  *Change `json:"distinct,omitempty"`
+	// PSI is the population stability index of the column value distribution:
+	// category frequencies for strings & booleans, histograms for numbers
+	PSI *float64 `json:"psi,omitempty"`
+	// Flags lists the changes that are past threshold
+	Flags []string `json:"flags,omitempty"`
+}
+
+// Change describes a value in two versions
+type Change struct {
+	Prev float64 `json:"prev"`
+	Next float64 `json:"next"`
+	// Delta is the size of the change from Prev to Next. For means & distinct
+	// counts the delta is relative to Prev, unless Prev is zero
+	Delta float64 `json:"delta"`
+}
+
+// Drift compares stats of two versions of a dataset as returned by ToMap,
+// or decoded from the JSON output of Stats.JSON
+func Drift(prev, next []map[string]interface{}, th DriftThresholds) *DriftReport {
+	report := &DriftReport{Thresholds: th, Columns: []*ColumnDrift{}}
+	prevCols := map[string]map[string]interface{}{}
+	for i, col := range prev {
+		prevCols[columnName(i, col)] = col
+	}
+
+	seen := map[string]bool{}
+	for i, col := range next {
+		name := columnName(i, col)
+		seen[name] = true
+		p, ok := prevCols[name]
+		if !ok {
+			report.add(&ColumnDrift{Column: name, Type: statType(col), Flags: []string{DriftAdded}})
+			continue
+		}
+		report.add(columnDrift(name, p, col, th))
+	}
+
+	removed := []string{}
+	for name := range prevCols {
+		if !seen[name] {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		report.add(&ColumnDrift{Column: name, PrevType: statType(prevCols[name]), Flags: []string{DriftRemoved}})
+	}
+	return report
+}
+
+func (r *DriftReport) add(cd *ColumnDrift) {
+	r.Columns = append(r.Columns, cd)
+	if len(cd.Flags) > 0 {
+		r.Flagged++
+	}
+}
+
+func columnName(i int, col map[string]interface{}) string {
+	if key, ok := col["key"].(string); ok {
+		return key
+	}
+	return fmt.Sprintf("%d", i)
+}
+
+func statType(col map[string]interface{}) string {
+	typ, _ := col["type"].(string)
+	return typ
+}
+
+func columnDrift(name string, prev, next map[string]interface{}, th DriftThresholds) *ColumnDrift {
+	cd := &ColumnDrift{Column: name, Type: statType(next)}
+	if pt := statType(prev); pt != cd.Type {
+		cd.PrevType = pt
+		cd.Flags = append(cd.Flags, DriftType)
+		return cd
+	}
+
+	if c := relativeChange(prev, next, "mean"); c != nil {
+		cd.Mean = c
+		if c.Delta > th.MeanChange {
+			cd.Flags = append(cd.Flags, DriftMean)
+		}
+	}
+	if c := absoluteChange(prev, next, "nullRatio"); c != nil {
+		cd.NullRatio = c
+		if c.Delta > th.NullRatioChange {
+			cd.Flags = append(cd.Flags, DriftNullRatio)
+		}
+	}
+	if c := relativeChange(prev, next, "distinct"); c != nil {
+		cd.Distinct = c
+		if c.Delta > th.DistinctChange {
+			cd.Flags = append(cd.Flags, DriftDistinct)
+		}
+	}
+
+	var p, q []float64
+	switch cd.Type {
+	case "numeric":
+		p, q = histogramShares(prev, next)
+	case "string":
+		p, q = frequencyShares(prev, next)
+	case "boolean":
+		p, q = booleanShares(prev), booleanShares(next)
+	}
+	if p != nil && q != nil {
+		psi := populationStabilityIndex(p, q)
+		cd.PSI = &psi
+		if psi > th.PSI {
+			cd.Flags = append(cd.Flags, DriftPSI)
+		}
+	}
+	return cd
+}
+
+func number(col map[string]interface{}, key string) (float64, bool) {
+	switch v := col[key].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func absoluteChange(prev, next map[string]interface{}, key string) *Change {
+	p, ok := number(prev, key)
+	if !ok {
+		return nil
+	}
+	n, ok := number(next, key)
+	if !ok {
+		return nil
+	}
+	return &Change{Prev: p, Next: n, Delta: math.Abs(n - p)}
+}
+
+func relativeChange(prev, next map[string]interface{}, key string) *Change {
+	c := absoluteChange(prev, next, key)
+	if c != nil && c.Prev != 0 {
+		c.Delta = c.Delta / math.Abs(c.Prev)
+	}
+	return c
+}
+
+// histogramShares gives the share of values in each bin of the previous
+// histogram for both versions. Next version values are redistributed onto the
+// previous bins assuming values are spread evenly within a bin, with extra
+// bins for values outside the previous range
+func histogramShares(prev, next map[string]interface{}) (p, q []float64) {
+	pBins, pFreqs, ok := histogram(prev)
+	if !ok {
+		return nil, nil
+	}
+	nBins, nFreqs, ok := histogram(next)
+	if !ok {
+		return nil, nil
+	}
+
+	// bins are: below range, each previous bin, above range
+	p = append(append([]float64{0}, pFreqs...), 0)
+	q = make([]float64, len(p))
+	lo, hi := pBins[0], pBins[len(pBins)-1]
+	for i, freq := range nFreqs {
+		a, b := nBins[i], nBins[i+1]
+		width := b - a
+		if width <= 0 {
+			continue
+		}
+		if a < lo {
+			q[0] += freq * (math.Min(b, lo) - a) / width
+		}
+		if b > hi {
+			q[len(q)-1] += freq * (b - math.Max(a, hi)) / width
+		}
+		for j := 0; j < len(pBins)-1; j++ {
+			overlap := math.Min(b, pBins[j+1]) - math.Max(a, pBins[j])
+			if overlap > 0 {
+				q[j+1] += freq * overlap / width
+			}
+		}
+	}
+	return p, q
+}
+
+func histogram(col map[string]interface{}) (bins, freqs []float64, ok bool) {
+	var h map[string]interface{}
+	switch x := col["histogram"].(type) {
+	case map[string][]float64:
+		return x["bins"], x["frequencies"], len(x["bins"]) > 1 && len(x["frequencies"]) == len(x["bins"])-1
+	case map[string]interface{}:
+		h = x
+	default:
+		return nil, nil, false
+	}
+	bins, ok = floats(h["bins"])
+	if !ok {
+		return nil, nil, false
+	}
+	freqs, ok = floats(h["frequencies"])
+	return bins, freqs, ok && len(bins) > 1 && len(freqs) == len(bins)-1
+}
+
+func floats(v interface{}) ([]float64, bool) {
+	switch x := v.(type) {
+	case []float64:
+		return x, true
+	case []interface{}:
+		fs := make([]float64, len(x))
+		for i, f := range x {
+			n, ok := f.(float64)
+			if !ok {
+				return nil, false
+			}
+			fs[i] = n
+		}
+		return fs, true
+	}
+	return nil, false
+}
+
+// frequencyShares gives the share of each category for both versions of a
+// string column. Values that aren't in either frequency map share an "other"
+// category. Frequencies stop being kept for columns with many distinct
+// values, there's no distribution to compare for those columns
+func frequencyShares(prev, next map[string]interface{}) (p, q []float64) {
+	if prev["distinctEstimated"] == true || next["distinctEstimated"] == true {
+		return nil, nil
+	}
+	pFreqs, nFreqs := frequencies(prev), frequencies(next)
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, freqs := range []map[string]float64{pFreqs, nFreqs} {
+		for key := range freqs {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	shares := func(col map[string]interface{}, freqs map[string]float64) []float64 {
+		count, _ := number(col, "count")
+		s := make([]float64, 0, len(keys)+1)
+		other := count
+		for _, key := range keys {
+			s = append(s, freqs[key])
+			other -= freqs[key]
+		}
+		return append(s, other)
+	}
+	return shares(prev, pFreqs), shares(next, nFreqs)
+}
+
+func frequencies(col map[string]interface{}) map[string]float64 {
+	freqs := map[string]float64{}
+	switch x := col["frequencies"].(type) {
+	case map[string]int:
+		for key, n := range x {
+			freqs[key] = float64(n)
+		}
+	case map[string]interface{}:
+		for key, n := range x {
+			if f, ok := n.(float64); ok {
+				freqs[key] = f
+			}
+		}
+	}
+	return freqs
+}
+
+func booleanShares(col map[string]interface{}) []float64 {
+	t, ok := number(col, "trueCount")
+	if !ok {
+		return nil
+	}
+	f, ok := number(col, "falseCount")
+	if !ok {
+		return nil
+	}
+	return []float64{t, f}
+}
+
+// psiEpsilon stands in for empty shares, which would otherwise make the
+// index infinite
+const psiEpsilon = 0.0001
+
+// populationStabilityIndex measures the shift between two distributions given
+// as counts per category: sum((q - p) * ln(q / p)) over category shares
+func populationStabilityIndex(p, q []float64) float64 {
+	share := func(counts []float64) []float64 {
+		total := 0.0
+		for _, c := range counts {
+			total += c
+		}
+		s := make([]float64, len(counts))
+		for i, c := range counts {
+			if total > 0 {
+				s[i] = c / total
+			}
+			if s[i] < psiEpsilon {
+				s[i] = psiEpsilon
+			}
+		}
+		return s
+	}
+	ps, qs := share(p), share(q)
+	psi := 0.0
+	for i := range ps {
+		psi += (qs[i] - ps[i]) * math.Log(qs[i]/ps[i])
+	}
+	return psi
+}
@@ -0,0 +1,84 @@
+package stats
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func accumulate(t *testing.T, input string) []map[string]interface{} {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	r, err := dsio.NewJSONReader(st, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := NewAccumulator(r)
+	if err := ReadAllDiscard(acc); err != nil {
+		t.Fatal(err)
+	}
+	return ToMap(acc)
+}
+
+func TestDrift(t *testing.T) {
+	prev := accumulate(t, `[
+		{"city": "toronto", "pop": 10, "active": true, "code": "a"},
+		{"city": "toronto", "pop": 20, "active": true, "code": "b"},
+		{"city": "new york", "pop": 30, "active": false, "code": "c"},
+		{"city": "new york", "pop": 40, "active": true, "code": "d"}
+	]`)
+	next := accumulate(t, `[
+		{"city": "toronto", "pop": 100, "active": true, "zip": 1},
+		{"city": "toronto", "pop": 200, "active": true, "zip": 2},
+		{"city": "new york", "pop": 300, "active": false, "zip": 3},
+		{"city": "new york", "pop": 400, "active": true, "zip": 4},
+		{"city": null, "pop": 500, "active": true, "zip": 5}
+	]`)
+	report := Drift(prev, next, DefaultDriftThresholds)
+	flags := map[string][]string{}
+	for _, col := range report.Columns {
+		flags[col.Column] = col.Flags
+	}
+	expect := map[string][]string{
+		"active": nil,
+		"city":   {DriftNullRatio},
+		"pop":    {DriftMean, DriftDistinct, DriftPSI},
+		"zip":    {DriftAdded},
+		"code":   {DriftRemoved},
+	}
+	if diff := cmp.Diff(expect, flags); diff != "" {
+		t.Errorf("flags mismatch (-want +got):\n%s", diff)
+	}
+	if report.Flagged != 4 {
+		t.Errorf("expected 4 flagged columns. got: %d", report.Flagged)
+	}
+
+	// stats decoded from JSON give the same report
+	decode := func(stats []map[string]interface{}) []map[string]interface{} {
+		data, err := json.Marshal(stats)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var res []map[string]interface{}
+		if err := json.Unmarshal(data, &res); err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	if diff := cmp.Diff(report, Drift(decode(prev), decode(next), DefaultDriftThresholds)); diff != "" {
+		t.Errorf("decoded stats report mismatch (-want +got):\n%s", diff)
+	}
+
+	same := Drift(prev, prev, DefaultDriftThresholds)
+	if same.Flagged != 0 {
+		t.Errorf("expected comparing a version with itself to flag nothing. got: %d", same.Flagged)
+	}
+	for _, col := range same.Columns {
+		if col.PSI != nil && *col.PSI > 0.0001 {
+			t.Errorf("column %s: expected PSI of identical distributions to be 0. got: %f", col.Column, *col.PSI)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package stats
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Schema proposes a jsonschema for the body read by the accumulator, using
+// the tightest types that describe every value read. Consumers can only assume
+// the schema is final after a call to Close. Titles & descriptions of array
+// items in the reader's structure schema are kept
+func (r *Accumulator) Schema() map[string]interface{} {
+	var prev map[string]interface{}
+	if st := r.Structure(); st != nil {
+		prev = st.Schema
+	}
+
+	if typ, _ := prev["type"].(string); typ == "object" {
+		sch := map[string]interface{}{"type": "object"}
+		if r.stats != nil {
+			sch["additionalProperties"] = r.stats.schema()
+		}
+		return sch
+	}
+
+	sch := map[string]interface{}{"type": "array"}
+	if r.stats != nil {
+		entry := r.stats.schema()
+		if prevEntry, ok := prev["items"].(map[string]interface{}); ok {
+			keepTitles(entry, prevEntry)
+		}
+		sch["items"] = entry
+	}
+	return sch
+}
+
+// keepTitles copies titles & descriptions of array items from one schema to
+// another
+func keepTitles(sch, prev map[string]interface{}) {
+	items, ok := sch["items"].([]interface{})
+	if !ok {
+		return
+	}
+	prevItems, ok := prev["items"].([]interface{})
+	if !ok {
+		return
+	}
+	for i, item := range items {
+		if i >= len(prevItems) {
+			break
+		}
+		to, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		from, ok := prevItems[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, key := range []string{"title", "description"} {
+			if v, ok := from[key]; ok {
+				to[key] = v
+			}
+		}
+	}
+}
+
+// typed creates a schema for a type, optionally allowing null
+func typed(typ string, nullable bool) map[string]interface{} {
+	if nullable && typ != "null" {
+		return map[string]interface{}{"type": []interface{}{typ, "null"}}
+	}
+	return map[string]interface{}{"type": typ}
+}
+
+func (acc *objectAcc) schema() map[string]interface{} {
+	props := map[string]interface{}{}
+	for key, ch := range acc.children {
+		props[key] = ch.schema()
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": props,
+	}
+}
+
+func (acc *arrayAcc) schema() map[string]interface{} {
+	items := make([]interface{}, len(acc.children))
+	for i, ch := range acc.children {
+		items[i] = ch.schema()
+	}
+	return map[string]interface{}{
+		"type":  "array",
+		"items": items,
+	}
+}
+
+func (acc *numericAcc) schema() map[string]interface{} {
+	if acc.mixed {
+		return map[string]interface{}{}
+	}
+	if acc.fractional {
+		return typed("number", acc.nulls > 0)
+	}
+	return typed("integer", acc.nulls > 0)
+}
+
+func (acc *stringAcc) schema() map[string]interface{} {
+	if acc.mixed {
+		return map[string]interface{}{}
+	}
+	if acc.count == acc.empty {
+		return typed("string", acc.nulls > 0)
+	}
+
+	// when parsing strings empty values are nulls, columns that aren't plain
+	// strings need to allow them
+	nullable := acc.nulls > 0 || (acc.parse && acc.empty > 0)
+	if acc.parse {
+		switch {
+		case acc.shapes&shapeInteger != 0:
+			return typed("integer", nullable)
+		case acc.shapes&shapeNumber != 0:
+			return typed("number", nullable)
+		case acc.shapes&shapeBoolean != 0:
+			return typed("boolean", nullable)
+		}
+	}
+
+	var sch map[string]interface{}
+	switch {
+	case acc.shapes&shapeDateTime != 0:
+		sch = typed("string", nullable)
+		sch["format"] = "date-time"
+	case acc.shapes&shapeDate != 0:
+		sch = typed("string", nullable)
+		sch["format"] = "date"
+	case acc.enum != nil:
+		sch = typed("string", nullable)
+		enum := make([]interface{}, 0, len(acc.enum)+1)
+		for _, v := range acc.enum {
+			enum = append(enum, v)
+		}
+		if nullable {
+			enum = append(enum, nil)
+		}
+		sch["enum"] = enum
+	default:
+		sch = typed("string", acc.nulls > 0)
+	}
+	return sch
+}
+
+func (acc *boolAcc) schema() map[string]interface{} {
+	if acc.mixed {
+		return map[string]interface{}{}
+	}
+	return typed("boolean", acc.nulls > 0)
+}
+
+func (acc *nullAcc) schema() map[string]interface{} {
+	return typed("null", false)
+}
+
+// enumValues gives the sorted non-empty values of a string frequency count if
+// the values are few & repeated enough to be an enum, nil otherwise
+func enumValues(frequencies map[string]int, count int) []string {
+	vals := make([]string, 0, len(frequencies))
+	for v := range frequencies {
+		if v != "" {
+			vals = append(vals, v)
+		}
+	}
+	if len(vals) == 0 || len(vals) > EnumMaxValues || count < 2*len(vals) {
+		return nil
+	}
+	sort.Strings(vals)
+	return vals
+}
+
+// shape is a set of types a string value can be parsed as
+type shape uint8
+
+const (
+	shapeInteger shape = 1 << iota
+	shapeNumber
+	shapeBoolean
+	shapeDate
+	shapeDateTime
+
+	allShapes = shapeInteger | shapeNumber | shapeBoolean | shapeDate | shapeDateTime
+)
+
+// narrow removes the types str can't be parsed as
+func (s shape) narrow(str string) shape {
+	if s&shapeInteger != 0 {
+		if _, err := strconv.ParseInt(str, 10, 64); err != nil {
+			s &^= shapeInteger
+		}
+	}
+	if s&shapeNumber != 0 {
+		if _, err := strconv.ParseFloat(str, 64); err != nil {
+			s &^= shapeNumber
+		}
+	}
+	if s&shapeBoolean != 0 {
+		if _, err := strconv.ParseBool(str); err != nil {
+			s &^= shapeBoolean
+		}
+	}
+	// date & date-time formats match the jsonschema "date" & "date-time"
+	// formats, which are both RFC 3339
+	if s&shapeDate != 0 {
+		if _, err := time.Parse("2006-01-02", str); err != nil || len(str) != 10 {
+			s &^= shapeDate
+		}
+	}
+	if s&shapeDateTime != 0 {
+		if _, err := time.Parse(time.RFC3339, str); err != nil {
+			s &^= shapeDateTime
+		}
+	}
+	return s
+}
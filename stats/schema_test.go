@@ -0,0 +1,142 @@
+package stats
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/dataset"
+	"github.com/qri-io/dataset/dsio"
+)
+
+func TestAccumulatorSchemaCSV(t *testing.T) {
+	st := &dataset.Structure{
+		Format:       "csv",
+		FormatConfig: map[string]interface{}{"headerRow": true},
+		Schema: map[string]interface{}{
+			"type": "array",
+			"items": map[string]interface{}{
+				"type": "array",
+				"items": []interface{}{
+					map[string]interface{}{"title": "id", "type": "string"},
+					map[string]interface{}{"title": "score", "type": "string"},
+					map[string]interface{}{"title": "status", "type": "string"},
+					map[string]interface{}{"title": "day", "type": "string"},
+					map[string]interface{}{"title": "updated", "type": "string"},
+					map[string]interface{}{"title": "ok", "type": "string"},
+					map[string]interface{}{"title": "name", "type": "string"},
+					map[string]interface{}{"title": "count", "type": "string"},
+				},
+			},
+		},
+	}
+	input := `id,score,status,day,updated,ok,name,count
+1,1.5,open,2019-01-01,2019-01-01T10:00:00Z,true,alice,
+2,2,closed,2019-01-02,2019-01-02T10:00:00Z,false,bob,3
+3,3.25,open,,2019-01-03T10:00:00Z,true,carol,4
+4,4,open,2019-01-04,2019-01-04T10:00:00Z,false,dave,5
+`
+	r, err := dsio.NewEntryReader(st, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := NewAccumulator(r, OptParseStrings())
+	if err := ReadAllDiscard(acc); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := `{
+		"type": "array",
+		"items": {
+			"type": "array",
+			"items": [
+				{"title": "id", "type": "integer"},
+				{"title": "score", "type": "number"},
+				{"title": "status", "type": "string", "enum": ["closed", "open"]},
+				{"title": "day", "type": ["string", "null"], "format": "date"},
+				{"title": "updated", "type": "string", "format": "date-time"},
+				{"title": "ok", "type": "boolean"},
+				{"title": "name", "type": "string"},
+				{"title": "count", "type": ["integer", "null"]}
+			]
+		}
+	}`
+	assertSchema(t, expect, acc.Schema())
+}
+
+func TestAccumulatorSchemaJSON(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	input := `[
+		{"a": 1, "b": "1", "c": null, "d": 1},
+		{"a": 2, "b": "2", "c": true, "d": "one"},
+		{"a": null, "b": "3", "c": false, "d": 2.5}
+	]`
+	r, err := dsio.NewJSONReader(st, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := NewAccumulator(r)
+	if err := ReadAllDiscard(acc); err != nil {
+		t.Fatal(err)
+	}
+
+	// strings aren't parsed as numbers unless OptParseStrings is set
+	expect := `{
+		"type": "array",
+		"items": {
+			"type": "object",
+			"properties": {
+				"a": {"type": ["integer", "null"]},
+				"b": {"type": "string"},
+				"c": {"type": ["boolean", "null"]},
+				"d": {}
+			}
+		}
+	}`
+	assertSchema(t, expect, acc.Schema())
+}
+
+func TestShapeNarrow(t *testing.T) {
+	cases := []struct {
+		vals   []string
+		expect shape
+	}{
+		{[]string{"1", "0"}, shapeInteger | shapeNumber | shapeBoolean},
+		{[]string{"1", "2.5"}, shapeNumber},
+		{[]string{"true", "FALSE"}, shapeBoolean},
+		{[]string{"2019-01-01", "2019-12-31"}, shapeDate},
+		{[]string{"2019-01-01", "2019-13-01"}, 0},
+		{[]string{"2019-01-01T00:00:00Z", "2019-01-01T00:00:00+05:00"}, shapeDateTime},
+		{[]string{"2019-01-01 00:00:00"}, 0},
+	}
+	for i, c := range cases {
+		s := allShapes
+		for _, v := range c.vals {
+			s = s.narrow(v)
+		}
+		if s != c.expect {
+			t.Errorf("case %d: expected shape %b, got: %b", i, c.expect, s)
+		}
+	}
+}
+
+func assertSchema(t *testing.T, expect string, got map[string]interface{}) {
+	t.Helper()
+	var want map[string]interface{}
+	if err := json.Unmarshal([]byte(expect), &want); err != nil {
+		t.Fatal(err)
+	}
+	// round trip through JSON to compare with decoded values
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, decoded); diff != "" {
+		t.Errorf("schema mismatch (-want +got):\n%s", diff)
+	}
+}
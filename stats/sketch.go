@@ -0,0 +1,240 @@
+package stats
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// sketchK sets the size & accuracy of quantile sketches. sketches are exact
+// until they've seen sketchK values, after which rank error is roughly 1%
+const sketchK = 200
+
+// quantileSketch approximates the distribution of a stream of values in
+// bounded memory. It's a deterministic variant of the KLL sketch: values are
+// added to the lowest of a stack of compactors, when a compactor is full its
+// values are sorted and every other value is promoted to the next compactor
+// with double the weight
+type quantileSketch struct {
+	compactors [][]float64
+	count      int
+	// offset alternates between promoting odd & even values to avoid bias
+	offset int
+}
+
+func newQuantileSketch() *quantileSketch {
+	return &quantileSketch{compactors: make([][]float64, 1)}
+}
+
+// Add inserts a value into the sketch
+func (s *quantileSketch) Add(v float64) {
+	s.compactors[0] = append(s.compactors[0], v)
+	s.count++
+	s.compress()
+}
+
+// Count is the number of values added to the sketch
+func (s *quantileSketch) Count() int {
+	return s.count
+}
+
+// capacity of the compactor at height h. lower compactors get smaller
+// capacities as the sketch grows
+func (s *quantileSketch) capacity(h int) int {
+	depth := len(s.compactors) - h - 1
+	c := int(math.Ceil(sketchK * math.Pow(2.0/3.0, float64(depth))))
+	if c < 2 {
+		return 2
+	}
+	return c
+}
+
+func (s *quantileSketch) compress() {
+	for h := 0; h < len(s.compactors); h++ {
+		if len(s.compactors[h]) < s.capacity(h) {
+			continue
+		}
+		if h+1 == len(s.compactors) {
+			s.compactors = append(s.compactors, nil)
+		}
+
+		c := s.compactors[h]
+		sort.Float64s(c)
+		// with an odd number of values, keep the largest at this height so
+		// no weight is lost
+		var keep []float64
+		if len(c)%2 == 1 {
+			keep = []float64{c[len(c)-1]}
+			c = c[:len(c)-1]
+		}
+		s.offset = 1 - s.offset
+		for i := s.offset; i < len(c); i += 2 {
+			s.compactors[h+1] = append(s.compactors[h+1], c[i])
+		}
+		s.compactors[h] = keep
+	}
+}
+
+type weightedValue struct {
+	val    float64
+	weight int
+}
+
+// values gives all values in the sketch with their weights, sorted by value
+func (s *quantileSketch) values() []weightedValue {
+	var vals []weightedValue
+	for h, c := range s.compactors {
+		for _, v := range c {
+			vals = append(vals, weightedValue{val: v, weight: 1 << uint(h)})
+		}
+	}
+	sort.Slice(vals, func(i, j int) bool { return vals[i].val < vals[j].val })
+	return vals
+}
+
+// Quantile gives the value at quantile q, where 0 < q <= 1, using the
+// nearest-rank method
+func (s *quantileSketch) Quantile(q float64) float64 {
+	vals := s.values()
+	if len(vals) == 0 {
+		return 0
+	}
+	target := nearestRank(q, s.count)
+	cum := 0
+	for _, v := range vals {
+		if cum += v.weight; cum > target {
+			return v.val
+		}
+	}
+	return vals[len(vals)-1].val
+}
+
+// Histogram counts values into the bins described by dividers, where bin i
+// holds values in [dividers[i], dividers[i+1])
+func (s *quantileSketch) Histogram(dividers []float64) []float64 {
+	if len(dividers) < 2 {
+		return nil
+	}
+	counts := make([]float64, len(dividers)-1)
+	bin := 0
+	for _, v := range s.values() {
+		for bin < len(counts) && v.val >= dividers[bin+1] {
+			bin++
+		}
+		if bin == len(counts) {
+			break
+		}
+		if v.val >= dividers[bin] {
+			counts[bin] += float64(v.weight)
+		}
+	}
+	return counts
+}
+
+// nearestRank gives the 0-based index of quantile q in n sorted values
+func nearestRank(q float64, n int) int {
+	i := int(math.Ceil(q*float64(n))) - 1
+	if i < 0 {
+		return 0
+	}
+	if i >= n {
+		return n - 1
+	}
+	return i
+}
+
+// quantiles lists the quantiles reported for numeric values & string lengths
+var quantiles = []struct {
+	key string
+	q   float64
+}{
+	{"p5", 0.05},
+	{"p25", 0.25},
+	{"p50", 0.5},
+	{"p75", 0.75},
+	{"p95", 0.95},
+}
+
+// sketchQuantiles reports quantiles of a sketch as a map
+func sketchQuantiles(s *quantileSketch) map[string]float64 {
+	m := make(map[string]float64, len(quantiles))
+	for _, q := range quantiles {
+		m[q.key] = s.Quantile(q.q)
+	}
+	return m
+}
+
+// sortedQuantiles reports quantiles of a sorted slice as a map
+func sortedQuantiles(sorted []float64) map[string]float64 {
+	m := make(map[string]float64, len(quantiles))
+	for _, q := range quantiles {
+		m[q.key] = sorted[nearestRank(q.q, len(sorted))]
+	}
+	return m
+}
+
+// hllPrecision is the number of hash bits used to pick a HyperLogLog
+// register. 2^12 registers gives a standard error of about 1.6%
+const hllPrecision = 12
+
+// hyperLogLog estimates the number of distinct values in a stream in fixed
+// memory
+type hyperLogLog struct {
+	registers []uint8
+}
+
+func newHyperLogLog() *hyperLogLog {
+	return &hyperLogLog{registers: make([]uint8, 1<<hllPrecision)}
+}
+
+// Add inserts a value into the estimator
+func (h *hyperLogLog) Add(data []byte) {
+	x := hash64(data)
+	idx := x >> (64 - hllPrecision)
+	rho := uint8(bits.LeadingZeros64(x<<hllPrecision|1<<(hllPrecision-1))) + 1
+	if rho > h.registers[idx] {
+		h.registers[idx] = rho
+	}
+}
+
+// AddFloat inserts a number into the estimator
+func (h *hyperLogLog) AddFloat(v float64) {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+	h.Add(buf[:])
+}
+
+// Count estimates the number of distinct values added
+func (h *hyperLogLog) Count() int {
+	m := float64(len(h.registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range h.registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	est := 0.7213 / (1 + 1.079/m) * m * m / sum
+	// use linear counting for small cardinalities
+	if est <= 2.5*m && zeros > 0 {
+		est = m * math.Log(m/float64(zeros))
+	}
+	return int(est + 0.5)
+}
+
+// hash64 is 64-bit FNV-1a with a final mix, which FNV needs to spread short
+// inputs across all bits
+func hash64(data []byte) uint64 {
+	h := fnv.New64a()
+	h.Write(data)
+	x := h.Sum64()
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -0,0 +1,62 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func TestQuantileSketch(t *testing.T) {
+	s := newQuantileSketch()
+	for i := 0; i < 10; i++ {
+		s.Add(float64(i))
+	}
+	if got := s.Quantile(0.5); got != 4 {
+		t.Errorf("expected exact median of small sketch to be 4. got: %f", got)
+	}
+
+	s = newQuantileSketch()
+	n := 100000
+	// add values out of order so compaction sees unsorted input
+	for i := 0; i < n; i++ {
+		s.Add(float64((i * 7919) % n))
+	}
+	if s.Count() != n {
+		t.Errorf("expected count %d. got: %d", n, s.Count())
+	}
+	for _, q := range quantiles {
+		got := s.Quantile(q.q)
+		want := q.q * float64(n)
+		if math.Abs(got-want) > float64(n)*0.02 {
+			t.Errorf("%s: expected value within 2%% of %f. got: %f", q.key, want, got)
+		}
+	}
+
+	bins := []float64{0, 25000, 50000, 75000, 100000}
+	total := 0.0
+	for i, freq := range s.Histogram(bins) {
+		total += freq
+		if math.Abs(freq-25000) > float64(n)*0.02 {
+			t.Errorf("bin %d: expected frequency near 25000. got: %f", i, freq)
+		}
+	}
+	if int(total) != n {
+		t.Errorf("expected histogram frequencies to sum to %d. got: %f", n, total)
+	}
+}
+
+func TestHyperLogLog(t *testing.T) {
+	cases := []int{0, 1, 10, 1000, 100000}
+	for _, n := range cases {
+		h := newHyperLogLog()
+		for i := 0; i < n; i++ {
+			h.Add([]byte(fmt.Sprintf("value_%d", i)))
+			// duplicates shouldn't count
+			h.Add([]byte(fmt.Sprintf("value_%d", i)))
+		}
+		got := h.Count()
+		if math.Abs(float64(got-n)) > math.Max(1, float64(n)*0.05) {
+			t.Errorf("expected estimate within 5%% of %d. got: %d", n, got)
+		}
+	}
+}
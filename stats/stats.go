@@ -4,9 +4,11 @@ package stats
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"sort"
 
 	logger "github.com/ipfs/go-log"
@@ -16,30 +18,81 @@ import (
 	gonumstat "gonum.org/v1/gonum/stat"
 )
 
+// FormatVersion identifies the shape of calculated stats. Bump it whenever
+// stats change, stats cached under an older version are recalculated
+const FormatVersion = 2
+
 var (
 	// StopFreqCountThreshold is the number of unique values past which we will
 	// stop keeping frequencies. This is a simplistic line of defense against
 	// unweildly memory consumption
 	StopFreqCountThreshold = 10000
 
+	// DefaultHistogramBins is the number of histogram bins used if none are
+	// configured
+	DefaultHistogramBins = 10
+
+	// EnumMaxValues is the largest number of distinct values a string column
+	// can have to be inferred as an enum
+	EnumMaxValues = 10
+
 	// package logger
 	log = logger.Logger("stats")
 )
 
+// Options configures statistics calculation
+type Options struct {
+	// HistogramBins is the number of bins in numeric & string length
+	// histograms, defaults to DefaultHistogramBins
+	HistogramBins int
+	// ParseStrings treats string values as text that may encode numbers &
+	// booleans when inferring a schema, the way CSV cells do. Empty strings
+	// are treated as null
+	ParseStrings bool
+}
+
+// OptHistogramBins sets the number of histogram bins
+func OptHistogramBins(n int) func(*Options) {
+	return func(o *Options) {
+		o.HistogramBins = n
+	}
+}
+
+// OptParseStrings infers schema types from the text of string values
+func OptParseStrings() func(*Options) {
+	return func(o *Options) {
+		o.ParseStrings = true
+	}
+}
+
+func newOptions(opts []func(*Options)) *Options {
+	o := &Options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.HistogramBins <= 0 {
+		o.HistogramBins = DefaultHistogramBins
+	}
+	return o
+}
+
 // Stats can generate an array of statistical info for a dataset
 type Stats struct {
 	cache Cache
+	opts  []func(*Options)
 }
 
 // New allocates a Stats service
-func New(cache Cache) *Stats {
+func New(cache Cache, opts ...func(*Options)) *Stats {
 	if cache == nil {
 		return &Stats{
 			cache: nilCache(false),
+			opts:  opts,
 		}
 	}
 	return &Stats{
 		cache: cache,
+		opts:  opts,
 	}
 }
 
@@ -52,8 +105,9 @@ func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset) (r io.Reader, err
 	// a `dataset.Path`. This metric should perhaps come out of the
 	// `dataset.BodyFile()` since we must have a bodyFile in order to
 	// calculate the stats
+	key := s.cacheKey(ds.Path)
 	if ds.Path != "" {
-		if r, err := s.cache.JSON(ctx, ds.Path); err == nil {
+		if r, err := s.cache.JSON(ctx, key); err == nil {
 			return r, nil
 		}
 	}
@@ -71,7 +125,7 @@ func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset) (r io.Reader, err
 		return nil, err
 	}
 
-	acc := NewAccumulator(rdr)
+	acc := NewAccumulator(rdr, s.opts...)
 	for {
 		if _, err := acc.ReadEntry(); err != nil {
 			if err.Error() == "EOF" {
@@ -89,7 +143,7 @@ func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset) (r io.Reader, err
 
 	if ds.Path != "" {
 		go func() {
-			if err := s.cache.PutJSON(context.Background(), ds.Path, bytes.NewReader(data)); err != nil {
+			if err := s.cache.PutJSON(context.Background(), key, bytes.NewReader(data)); err != nil {
 				log.Debugf("putting stats in cache: %v", err.Error())
 			}
 		}()
@@ -98,6 +152,16 @@ func (s *Stats) JSON(ctx context.Context, ds *dataset.Dataset) (r io.Reader, err
 	return bytes.NewReader(data), nil
 }
 
+// cacheKey identifies stats of the dataset at path. Keys include the stats
+// format version & a hash of the options stats are calculated with, so stats
+// cached by older versions of qri or with other options aren't served
+func (s *Stats) cacheKey(path string) string {
+	o := newOptions(s.opts)
+	h := sha256.New()
+	fmt.Fprintf(h, "bins=%d,parseStrings=%t,freqThreshold=%d,enumMax=%d", o.HistogramBins, o.ParseStrings, StopFreqCountThreshold, EnumMaxValues)
+	return fmt.Sprintf("%s#stats.v%d.%x", path, FormatVersion, h.Sum(nil)[:8])
+}
+
 // Statser produces a slice of Stat objects
 type Statser interface {
 	Stats() []Stat
@@ -132,6 +196,7 @@ type Stat interface {
 // after a call to Close
 type Accumulator struct {
 	r     dsio.EntryReader
+	opts  *Options
 	stats accumulator
 }
 
@@ -142,8 +207,8 @@ var (
 )
 
 // NewAccumulator wraps an entry reader to create a stat accumulator
-func NewAccumulator(r dsio.EntryReader) *Accumulator {
-	return &Accumulator{r: r}
+func NewAccumulator(r dsio.EntryReader, opts ...func(*Options)) *Accumulator {
+	return &Accumulator{r: r, opts: newOptions(opts)}
 }
 
 // Stats gets the statistics created by the accumulator
@@ -169,7 +234,7 @@ func (r *Accumulator) ReadEntry() (dsio.Entry, error) {
 		return ent, err
 	}
 	if r.stats == nil {
-		r.stats = newAccumulator(ent.Value)
+		r.stats = newAccumulator(ent.Value, r.opts)
 	}
 	r.stats.Write(ent)
 	return ent, nil
@@ -177,7 +242,9 @@ func (r *Accumulator) ReadEntry() (dsio.Entry, error) {
 
 // Close finalizes the Reader
 func (r *Accumulator) Close() error {
-	r.stats.Close()
+	if r.stats != nil {
+		r.stats.Close()
+	}
 	return r.r.Close()
 }
 
@@ -188,28 +255,78 @@ type accumulator interface {
 	Stat
 	Write(ent dsio.Entry)
 	Close()
+	// schema proposes a jsonschema that describes all values written
+	schema() map[string]interface{}
 }
 
-func newAccumulator(val interface{}) accumulator {
+func newAccumulator(val interface{}, opts *Options) accumulator {
 	switch val.(type) {
 	default:
 		return &nullAcc{}
 	case float64, float32:
-		return newNumericAcc("number")
+		return newNumericAcc("number", opts)
 	case int, int32, int64:
-		return newNumericAcc("integer")
+		return newNumericAcc("integer", opts)
 	case string:
-		return newStringAcc()
+		return newStringAcc(opts)
 	case bool:
 		return &boolAcc{}
 	case map[string]interface{}:
-		return &objectAcc{children: map[string]accumulator{}}
+		return &objectAcc{opts: opts, children: map[string]accumulator{}}
 	case []interface{}:
-		return &arrayAcc{}
+		return &arrayAcc{opts: opts}
 	}
 }
 
+// childAccumulator gives the accumulator to write a child value to. Child
+// accumulators are picked by the type of the first value they see, so null
+// accumulators are replaced once a non-null value shows up. Nulls seen so far
+// count toward the null ratio of the replacement
+func childAccumulator(acc accumulator, val interface{}, opts *Options) accumulator {
+	if acc == nil {
+		return newAccumulator(val, opts)
+	}
+	if nulls, ok := acc.(*nullAcc); ok && val != nil {
+		acc = newAccumulator(val, opts)
+		if p, ok := acc.(interface{ addNulls(n int) }); ok {
+			p.addNulls(nulls.count)
+		}
+	}
+	return acc
+}
+
+// presence counts the entries an accumulator has seen & how many were null
+type presence struct {
+	total int
+	nulls int
+	// mixed is true if non-null values of another type have been seen
+	mixed bool
+}
+
+// see records an entry value
+func (p *presence) see(val interface{}) {
+	p.total++
+	if val == nil {
+		p.nulls++
+	}
+}
+
+// addNulls records null entries seen before the accumulator was created
+func (p *presence) addNulls(n int) {
+	p.total += n
+	p.nulls += n
+}
+
+// nullRatio is the fraction of entries that were null
+func (p *presence) nullRatio() float64 {
+	if p.total == 0 {
+		return 0
+	}
+	return float64(p.nulls) / float64(p.total)
+}
+
 type objectAcc struct {
+	opts     *Options
 	children map[string]accumulator
 }
 
@@ -241,9 +358,7 @@ func (acc *objectAcc) Type() string { return "object" }
 func (acc *objectAcc) Write(e dsio.Entry) {
 	if mapEntry, ok := e.Value.(map[string]interface{}); ok {
 		for key, val := range mapEntry {
-			if _, ok := acc.children[key]; !ok {
-				acc.children[key] = newAccumulator(val)
-			}
+			acc.children[key] = childAccumulator(acc.children[key], val, acc.opts)
 			acc.children[key].Write(dsio.Entry{Key: key, Value: val})
 		}
 	}
@@ -266,6 +381,7 @@ func (acc *objectAcc) Close() {
 }
 
 type arrayAcc struct {
+	opts     *Options
 	children []accumulator
 }
 
@@ -291,8 +407,9 @@ func (acc *arrayAcc) Write(e dsio.Entry) {
 	if arrayEntry, ok := e.Value.([]interface{}); ok {
 		for i, val := range arrayEntry {
 			if len(acc.children) == i {
-				acc.children = append(acc.children, newAccumulator(val))
+				acc.children = append(acc.children, nil)
 			}
+			acc.children[i] = childAccumulator(acc.children[i], val, acc.opts)
 			acc.children[i].Write(dsio.Entry{Index: i, Value: val})
 		}
 	}
@@ -323,7 +440,9 @@ const (
 )
 
 type numericAcc struct {
+	presence
 	typ       string
+	bins      int
 	count     int
 	min       float64
 	max       float64
@@ -331,18 +450,28 @@ type numericAcc struct {
 	median    float64
 	dividers  []float64
 	histogram []float64
+	quantiles map[string]float64
+	sketch    *quantileSketch
+	distinct  *hyperLogLog
+	unique    int
+	estimated bool
+	// fractional is true if any value isn't a whole number
+	fractional bool
 }
 
 var _ accumulator = (*numericAcc)(nil)
 
-func newNumericAcc(typ string) *numericAcc {
+func newNumericAcc(typ string, opts *Options) *numericAcc {
 	return &numericAcc{
 		typ:    typ,
+		bins:   opts.HistogramBins,
 		max:    float64(minInt),
 		min:    float64(maxInt),
 		median: maxFloat,
 		// use histogram to accumulate values
 		histogram: make([]float64, 0, StopFreqCountThreshold*100),
+		sketch:    newQuantileSketch(),
+		distinct:  newHyperLogLog(),
 	}
 }
 
@@ -351,6 +480,8 @@ func (acc *numericAcc) Type() string { return "numeric" }
 
 // Write adds an entry to the stat accumulator
 func (acc *numericAcc) Write(e dsio.Entry) {
+	acc.see(e.Value)
+
 	var v float64
 	switch x := e.Value.(type) {
 	case int:
@@ -364,15 +495,24 @@ func (acc *numericAcc) Write(e dsio.Entry) {
 	case float64:
 		v = x
 	default:
+		if e.Value != nil {
+			acc.mixed = true
+		}
 		return
 	}
 
+	if v != math.Trunc(v) {
+		acc.fractional = true
+	}
+
 	if acc.histogram != nil {
 		acc.histogram = append(acc.histogram, v)
 		if len(acc.histogram) == StopFreqCountThreshold*100 {
 			acc.histogram = nil
 		}
 	}
+	acc.sketch.Add(v)
+	acc.distinct.AddFloat(v)
 
 	acc.mean += v
 	acc.count++
@@ -392,16 +532,26 @@ func (acc *numericAcc) Map() map[string]interface{} {
 		return map[string]interface{}{"count": 0}
 	}
 	m := map[string]interface{}{
-		"mean":  acc.mean,
-		"count": acc.count,
-		"min":   acc.min,
-		"max":   acc.max,
+		"mean":      acc.mean,
+		"count":     acc.count,
+		"min":       acc.min,
+		"max":       acc.max,
+		"nullRatio": acc.nullRatio(),
+		"distinct":  acc.unique,
+	}
+
+	if acc.estimated {
+		m["distinctEstimated"] = true
 	}
 
 	if acc.median != maxFloat {
 		m["median"] = acc.median
 	}
 
+	if acc.quantiles != nil {
+		m["quantiles"] = acc.quantiles
+	}
+
 	if acc.histogram != nil {
 		m["histogram"] = map[string][]float64{
 			"bins":        acc.dividers,
@@ -414,10 +564,20 @@ func (acc *numericAcc) Map() map[string]interface{} {
 
 // Close finalizes the accumulator
 func (acc *numericAcc) Close() {
+	if acc.count == 0 {
+		return
+	}
 	// finalize avg
 	acc.mean = acc.mean / float64(acc.count)
 
+	// turn values into a histogram
+	acc.dividers = make([]float64, acc.bins+1)
+	// Increase the maximum divider so that the maximum value of x is contained
+	// within the last bucket.
+	gonumfloats.Span(acc.dividers, acc.min, acc.max+1)
+
 	if len(acc.histogram) > 0 {
+		// all values are still in memory, calculate exact figures
 		sort.Float64Slice(acc.histogram).Sort()
 
 		if len(acc.histogram)%2 == 0 && len(acc.histogram) > 1 {
@@ -427,32 +587,60 @@ func (acc *numericAcc) Close() {
 			acc.median = acc.histogram[len(acc.histogram)/2]
 		}
 
-		// turn values into a histogram
-		nBins := 10
-		acc.dividers = make([]float64, nBins+1)
-		// Increase the maximum divider so that the maximum value of x is contained
-		// within the last bucket.
-		gonumfloats.Span(acc.dividers, acc.min, acc.max+1)
-		// Span includes the min and the max. Trim the dividers to create 10 buckets
+		acc.quantiles = sortedQuantiles(acc.histogram)
+		for i, v := range acc.histogram {
+			if i == 0 || v != acc.histogram[i-1] {
+				acc.unique++
+			}
+		}
+
 		acc.histogram = gonumstat.Histogram(nil, acc.dividers, acc.histogram, nil)
+	} else {
+		// too many values to keep, fall back to streaming estimates
+		acc.median = acc.sketch.Quantile(0.5)
+		acc.quantiles = sketchQuantiles(acc.sketch)
+		acc.histogram = acc.sketch.Histogram(acc.dividers)
+		acc.unique = acc.distinct.Count()
+		acc.estimated = true
 	}
+	acc.sketch = nil
+	acc.distinct = nil
 }
 
 type stringAcc struct {
-	count       int
-	minLength   int
-	maxLength   int
-	unique      int
-	frequencies map[string]int
+	presence
+	bins         int
+	count        int
+	empty        int
+	minLength    int
+	maxLength    int
+	unique       int
+	distinct     int
+	estimated    bool
+	frequencies  map[string]int
+	hll          *hyperLogLog
+	lengths      *quantileSketch
+	lengthBins   []float64
+	lengthFreqs  []float64
+	lengthQuants map[string]float64
+	// shapes are the types all non-empty values can be parsed as
+	parse  bool
+	shapes shape
+	enum   []string
 }
 
 var _ accumulator = (*stringAcc)(nil)
 
-func newStringAcc() *stringAcc {
+func newStringAcc(opts *Options) *stringAcc {
 	return &stringAcc{
+		bins:        opts.HistogramBins,
 		maxLength:   minInt,
 		minLength:   maxInt,
 		frequencies: map[string]int{},
+		hll:         newHyperLogLog(),
+		lengths:     newQuantileSketch(),
+		parse:       opts.ParseStrings,
+		shapes:      allShapes,
 	}
 }
 
@@ -461,23 +649,38 @@ func (acc *stringAcc) Type() string { return "string" }
 
 // Write adds an entry to the stat accumulator
 func (acc *stringAcc) Write(e dsio.Entry) {
-	if str, ok := e.Value.(string); ok {
-		acc.count++
+	acc.see(e.Value)
 
-		if acc.frequencies != nil {
-			acc.frequencies[str]++
-			if len(acc.frequencies) >= StopFreqCountThreshold {
-				acc.frequencies = nil
-			}
+	str, ok := e.Value.(string)
+	if !ok {
+		if e.Value != nil {
+			acc.mixed = true
 		}
+		return
+	}
 
-		if len(str) < acc.minLength {
-			acc.minLength = len(str)
-		}
-		if len(str) > acc.maxLength {
-			acc.maxLength = len(str)
+	acc.count++
+	if str == "" {
+		acc.empty++
+	} else {
+		acc.shapes = acc.shapes.narrow(str)
+	}
+
+	if acc.frequencies != nil {
+		acc.frequencies[str]++
+		if len(acc.frequencies) >= StopFreqCountThreshold {
+			acc.frequencies = nil
 		}
 	}
+	acc.hll.Add([]byte(str))
+	acc.lengths.Add(float64(len(str)))
+
+	if len(str) < acc.minLength {
+		acc.minLength = len(str)
+	}
+	if len(str) > acc.maxLength {
+		acc.maxLength = len(str)
+	}
 }
 
 // Map formats stat values as a map
@@ -489,11 +692,22 @@ func (acc *stringAcc) Map() map[string]interface{} {
 	}
 
 	m := map[string]interface{}{
-		"count":     acc.count,
-		"minLength": acc.minLength,
-		"maxLength": acc.maxLength,
+		"count":           acc.count,
+		"minLength":       acc.minLength,
+		"maxLength":       acc.maxLength,
+		"distinct":        acc.distinct,
+		"nullRatio":       acc.nullRatio(),
+		"emptyRatio":      float64(acc.empty) / float64(acc.total),
+		"lengthQuantiles": acc.lengthQuants,
+		"lengthHistogram": map[string][]float64{
+			"bins":        acc.lengthBins,
+			"frequencies": acc.lengthFreqs,
+		},
+	}
+
+	if acc.estimated {
+		m["distinctEstimated"] = true
 	}
-
 	if acc.unique != 0 {
 		m["unique"] = acc.unique
 	}
@@ -507,6 +721,8 @@ func (acc *stringAcc) Map() map[string]interface{} {
 // Close finalizes the accumulator
 func (acc *stringAcc) Close() {
 	if acc.frequencies != nil {
+		acc.distinct = len(acc.frequencies)
+		acc.enum = enumValues(acc.frequencies, acc.count-acc.empty)
 		// determine unique values
 		for key, freq := range acc.frequencies {
 			if freq == 1 {
@@ -517,10 +733,24 @@ func (acc *stringAcc) Close() {
 		if len(acc.frequencies) == 0 {
 			acc.frequencies = nil
 		}
+	} else {
+		// frequencies stopped, estimate distinct values instead
+		acc.distinct = acc.hll.Count()
+		acc.estimated = true
+	}
+
+	if acc.count > 0 {
+		acc.lengthQuants = sketchQuantiles(acc.lengths)
+		acc.lengthBins = make([]float64, acc.bins+1)
+		gonumfloats.Span(acc.lengthBins, float64(acc.minLength), float64(acc.maxLength+1))
+		acc.lengthFreqs = acc.lengths.Histogram(acc.lengthBins)
 	}
+	acc.hll = nil
+	acc.lengths = nil
 }
 
 type boolAcc struct {
+	presence
 	count      int
 	trueCount  int
 	falseCount int
@@ -533,6 +763,7 @@ func (acc *boolAcc) Type() string { return "boolean" }
 
 // Write adds an entry to the stat accumulator
 func (acc *boolAcc) Write(e dsio.Entry) {
+	acc.see(e.Value)
 	if b, ok := e.Value.(bool); ok {
 		acc.count++
 		if b {
@@ -540,6 +771,8 @@ func (acc *boolAcc) Write(e dsio.Entry) {
 		} else {
 			acc.falseCount++
 		}
+	} else if e.Value != nil {
+		acc.mixed = true
 	}
 }
 
@@ -549,6 +782,7 @@ func (acc *boolAcc) Map() map[string]interface{} {
 		"count":      acc.count,
 		"trueCount":  acc.trueCount,
 		"falseCount": acc.falseCount,
+		"nullRatio":  acc.nullRatio(),
 	}
 }
 
@@ -1,11 +1,17 @@
 package stats
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/qri-io/dataset"
@@ -32,7 +38,17 @@ func TestStrings(t *testing.T) {
 				"minLength":   1,
 				"maxLength":   4,
 				"unique":      3,
+				"distinct":    4,
+				"nullRatio":   float64(0),
+				"emptyRatio":  float64(0),
 				"frequencies": map[string]int{"a": 2},
+				"lengthQuantiles": map[string]float64{
+					"p5": 1, "p25": 1, "p50": 2, "p75": 3, "p95": 4,
+				},
+				"lengthHistogram": map[string][]float64{
+					"bins":        {1, 1.4, 1.8, 2.2, 2.6, 3, 3.4000000000000004, 3.8000000000000003, 4.2, 4.6, 5},
+					"frequencies": {2, 0, 1, 0, 0, 1, 0, 1, 0, 0},
+				},
 			},
 		},
 	}
@@ -57,6 +73,7 @@ func TestAllTypesIdentitySchemaArray(t *testing.T) {
 				"count":      5,
 				"trueCount":  2,
 				"falseCount": 3,
+				"nullRatio":  float64(0),
 				"type":       "boolean",
 			},
 			{
@@ -67,6 +84,11 @@ func TestAllTypesIdentitySchemaArray(t *testing.T) {
 				"mean":   float64(3.08),
 				"median": float64(3.3),
 				"type":   "numeric",
+				"quantiles": map[string]float64{
+					"p5": 1.1, "p25": 1.1, "p50": 3.3, "p75": 4.4, "p95": 5.5,
+				},
+				"distinct":  4,
+				"nullRatio": float64(0),
 				"histogram": map[string][]float64{
 					"bins":        {1.1, 1.6400000000000001, 2.18, 2.72, 3.2600000000000002, 3.8000000000000003, 4.34, 4.880000000000001, 5.42, 5.960000000000001, 6.5},
 					"frequencies": {2, 0, 0, 0, 1, 0, 1, 0, 1, 0},
@@ -80,6 +102,11 @@ func TestAllTypesIdentitySchemaArray(t *testing.T) {
 				"mean":   float64(2.8),
 				"median": float64(3),
 				"type":   "numeric",
+				"quantiles": map[string]float64{
+					"p5": 1, "p25": 1, "p50": 3, "p75": 4, "p95": 5,
+				},
+				"distinct":  4,
+				"nullRatio": float64(0),
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6},
 					"frequencies": {2, 0, 0, 0, 1, 0, 1, 0, 1, 0},
@@ -98,6 +125,16 @@ func TestAllTypesIdentitySchemaArray(t *testing.T) {
 				"type":        "string",
 				"unique":      3,
 				"frequencies": map[string]int{"aaa": 2},
+				"distinct":    4,
+				"nullRatio":   float64(0),
+				"emptyRatio":  float64(0),
+				"lengthQuantiles": map[string]float64{
+					"p5": 1, "p25": 2, "p50": 3, "p75": 3, "p95": 5,
+				},
+				"lengthHistogram": map[string][]float64{
+					"bins":        {1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6},
+					"frequencies": {1, 0, 1, 0, 2, 0, 0, 0, 1, 0},
+				},
 			},
 		},
 	}
@@ -117,6 +154,11 @@ func TestAllTypesIdentitySchemaArray(t *testing.T) {
 				"mean":   float64(1.5),
 				"median": float64(1.5),
 				"type":   "numeric",
+				"quantiles": map[string]float64{
+					"p5": 1, "p25": 1, "p50": 1, "p75": 2, "p95": 2,
+				},
+				"distinct":  2,
+				"nullRatio": float64(0),
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.2, 1.4, 1.6, 1.8, 2, 2.2, 2.4000000000000004, 2.6, 2.8, 3},
 					"frequencies": {1, 0, 0, 0, 0, 1, 0, 0, 0, 0},
@@ -147,6 +189,11 @@ func TestAllTypesIdentitySchemaObject(t *testing.T) {
 				"mean":   float64(2.8),
 				"median": float64(3),
 				"type":   "numeric",
+				"quantiles": map[string]float64{
+					"p5": 1, "p25": 1, "p50": 3, "p75": 4, "p95": 5,
+				},
+				"distinct":  4,
+				"nullRatio": float64(0),
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6},
 					"frequencies": {2, 0, 0, 0, 1, 0, 1, 0, 1, 0},
@@ -159,6 +206,11 @@ func TestAllTypesIdentitySchemaObject(t *testing.T) {
 				"mean":   float64(3.08),
 				"median": float64(2.2),
 				"type":   "numeric",
+				"quantiles": map[string]float64{
+					"p5": 1.1, "p25": 2.2, "p50": 2.2, "p75": 4.4, "p95": 5.5,
+				},
+				"distinct":  4,
+				"nullRatio": float64(0),
 				"histogram": map[string][]float64{
 					"bins":        {1.1, 1.6400000000000001, 2.18, 2.72, 3.2600000000000002, 3.8000000000000003, 4.34, 4.880000000000001, 5.42, 5.960000000000001, 6.5},
 					"frequencies": {1, 0, 2, 0, 0, 0, 1, 0, 1, 0},
@@ -172,6 +224,7 @@ func TestAllTypesIdentitySchemaObject(t *testing.T) {
 				"count":      5,
 				"trueCount":  2,
 				"falseCount": 3,
+				"nullRatio":  float64(0),
 				"type":       "boolean",
 			},
 			{
@@ -181,6 +234,16 @@ func TestAllTypesIdentitySchemaObject(t *testing.T) {
 				"type":        "string",
 				"unique":      3,
 				"frequencies": map[string]int{"aaa": 2},
+				"distinct":    4,
+				"nullRatio":   float64(0),
+				"emptyRatio":  float64(0),
+				"lengthQuantiles": map[string]float64{
+					"p5": 1, "p25": 2, "p50": 3, "p75": 3, "p95": 5,
+				},
+				"lengthHistogram": map[string][]float64{
+					"bins":        {1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6},
+					"frequencies": {1, 0, 1, 0, 2, 0, 0, 0, 1, 0},
+				},
 			},
 		},
 	}
@@ -210,6 +273,16 @@ func TestFreqThreshold(t *testing.T) {
 				"maxLength":   11,
 				"type":        "string",
 				"frequencies": map[string]int{"abcdefghijk": 5},
+				"distinct":    1,
+				"nullRatio":   float64(0),
+				"emptyRatio":  float64(0),
+				"lengthQuantiles": map[string]float64{
+					"p5": 11, "p25": 11, "p50": 11, "p75": 11, "p95": 11,
+				},
+				"lengthHistogram": map[string][]float64{
+					"bins":        {11, 11.1, 11.2, 11.3, 11.4, 11.5, 11.6, 11.7, 11.8, 11.9, 12},
+					"frequencies": {5, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+				},
 			},
 			{
 				"count":  5,
@@ -217,6 +290,11 @@ func TestFreqThreshold(t *testing.T) {
 				"max":    float64(1),
 				"mean":   float64(1),
 				"median": float64(1),
+				"quantiles": map[string]float64{
+					"p5": 1, "p25": 1, "p50": 1, "p75": 1, "p95": 1,
+				},
+				"distinct":  1,
+				"nullRatio": float64(0),
 				// currently we're calculating historams at 100x the stop threshold, so this shows up
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.1, 1.2, 1.3, 1.4, 1.5, 1.6, 1.7000000000000002, 1.8, 1.9, 2},
@@ -243,6 +321,18 @@ func TestFreqThreshold(t *testing.T) {
 				"minLength": 1,
 				"maxLength": 1,
 				"type":      "string",
+				// frequencies stopped, distinct values are estimated
+				"distinct":          5,
+				"distinctEstimated": true,
+				"nullRatio":         float64(0),
+				"emptyRatio":        float64(0),
+				"lengthQuantiles": map[string]float64{
+					"p5": 1, "p25": 1, "p50": 1, "p75": 1, "p95": 1,
+				},
+				"lengthHistogram": map[string][]float64{
+					"bins":        {1, 1.1, 1.2, 1.3, 1.4, 1.5, 1.6, 1.7000000000000002, 1.8, 1.9, 2},
+					"frequencies": {5, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+				},
 			},
 			{
 				"count":  5,
@@ -250,6 +340,11 @@ func TestFreqThreshold(t *testing.T) {
 				"max":    float64(5),
 				"mean":   float64(3),
 				"median": float64(3),
+				"quantiles": map[string]float64{
+					"p5": 1, "p25": 2, "p50": 3, "p75": 4, "p95": 5,
+				},
+				"distinct":  5,
+				"nullRatio": float64(0),
 				// currently we're calculating historams at 100x the stop threshold, so this shows up
 				"histogram": map[string][]float64{
 					"bins":        {1, 1.5, 2, 2.5, 3, 3.5, 4, 4.5, 5, 5.5, 6},
@@ -263,6 +358,113 @@ func TestFreqThreshold(t *testing.T) {
 	runTestCases(t, less, more)
 }
 
+func TestNullAndEmptyRatios(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	input := `[
+		[null, "a", true],
+		[null, "", null],
+		[1, "", false],
+		[3, null, true]
+	]`
+	r, err := dsio.NewJSONReader(st, strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := NewAccumulator(r)
+	if err := ReadAllDiscard(acc); err != nil {
+		t.Fatal(err)
+	}
+
+	got := ToMap(acc)
+	expect := []struct {
+		typ        string
+		nullRatio  float64
+		emptyRatio interface{}
+	}{
+		{"numeric", 0.5, nil},
+		{"string", 0.25, 0.5},
+		{"boolean", 0.25, nil},
+	}
+	for i, e := range expect {
+		if got[i]["type"] != e.typ {
+			t.Errorf("column %d: expected type %q. got: %v", i, e.typ, got[i]["type"])
+		}
+		if got[i]["nullRatio"] != e.nullRatio {
+			t.Errorf("column %d: expected null ratio %v. got: %v", i, e.nullRatio, got[i]["nullRatio"])
+		}
+		if got[i]["emptyRatio"] != e.emptyRatio {
+			t.Errorf("column %d: expected empty ratio %v. got: %v", i, e.emptyRatio, got[i]["emptyRatio"])
+		}
+	}
+}
+
+func TestHistogramBinsOption(t *testing.T) {
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	r, err := dsio.NewJSONReader(st, strings.NewReader(`[1,2,3,4]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := NewAccumulator(r, OptHistogramBins(4))
+	if err := ReadAllDiscard(acc); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := map[string][]float64{
+		"bins":        {1, 2, 3, 4, 5},
+		"frequencies": {1, 1, 1, 1},
+	}
+	if diff := cmp.Diff(expect, ToMap(acc)[0]["histogram"]); diff != "" {
+		t.Errorf("histogram mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStreamingEstimates(t *testing.T) {
+	prev := StopFreqCountThreshold
+	StopFreqCountThreshold = 10
+	defer func() { StopFreqCountThreshold = prev }()
+
+	// more values than are kept in memory (100x the threshold)
+	n := 5000
+	vals := make([]string, n)
+	for i := range vals {
+		vals[i] = fmt.Sprintf(`[%d,"%d"]`, i, i)
+	}
+	st := &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}
+	r, err := dsio.NewJSONReader(st, strings.NewReader("["+strings.Join(vals, ",")+"]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	acc := NewAccumulator(r)
+	if err := ReadAllDiscard(acc); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, col := range ToMap(acc) {
+		if col["distinctEstimated"] != true {
+			t.Errorf("column %d: expected distinct count to be estimated", i)
+		}
+		if d, ok := col["distinct"].(int); !ok || math.Abs(float64(d-n)) > float64(n)*0.05 {
+			t.Errorf("column %d: expected distinct count near %d. got: %v", i, n, col["distinct"])
+		}
+	}
+
+	num := ToMap(acc)[0]
+	if median, ok := num["median"].(float64); !ok || math.Abs(median-float64(n)/2) > float64(n)*0.02 {
+		t.Errorf("expected estimated median near %d. got: %v", n/2, num["median"])
+	}
+	hist, ok := num["histogram"].(map[string][]float64)
+	if !ok {
+		t.Fatalf("expected histogram. got: %v", num["histogram"])
+	}
+	total := 0.0
+	for _, freq := range hist["frequencies"] {
+		total += freq
+	}
+	if int(total) != n {
+		t.Errorf("expected histogram frequencies to sum to %d. got: %f", n, total)
+	}
+}
+
 func TestDepth3(t *testing.T) {
 	t.SkipNow()
 
@@ -376,7 +578,7 @@ func TestJSON(t *testing.T) {
 			"json",
 			`{"type":"array"}`,
 			`["a","a","bb","ccc","dddd"]`,
-			[]byte(`[{"count":5,"frequencies":{"a":2},"maxLength":4,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"distinct":4,"emptyRatio":0,"frequencies":{"a":2},"lengthHistogram":{"bins":[1,1.4,1.8,2.2,2.6,3,3.4000000000000004,3.8000000000000003,4.2,4.6,5],"frequencies":[2,0,1,0,0,1,0,1,0,0]},"lengthQuantiles":{"p25":1,"p5":1,"p50":2,"p75":3,"p95":4},"maxLength":4,"minLength":1,"nullRatio":0,"type":"string","unique":3}]`),
 		}, {
 			"json: all types identity schema array of object entries",
 			"json",
@@ -388,13 +590,13 @@ func TestJSON(t *testing.T) {
 				{"int": 4, "float": 4.4, "nil": null, "bool": true, "string": "aaa"},
 				{"int": 5, "float": 5.5, "nil": null, "bool": false, "string": "aaaaa"}
 			]`,
-			[]byte(`[{"count":5,"falseCount":3,"key":"bool","trueCount":2,"type":"boolean"},{"count":5,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"key":"float","max":5.5,"mean":3.08,"median":3.3,"min":1.1,"type":"numeric"},{"count":5,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"key":"int","max":5,"mean":2.8,"median":3,"min":1,"type":"numeric"},{"count":5,"key":"nil","type":"null"},{"count":5,"frequencies":{"aaa":2},"key":"string","maxLength":5,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"falseCount":3,"key":"bool","nullRatio":0,"trueCount":2,"type":"boolean"},{"count":5,"distinct":4,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"key":"float","max":5.5,"mean":3.08,"median":3.3,"min":1.1,"nullRatio":0,"quantiles":{"p25":1.1,"p5":1.1,"p50":3.3,"p75":4.4,"p95":5.5},"type":"numeric"},{"count":5,"distinct":4,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"key":"int","max":5,"mean":2.8,"median":3,"min":1,"nullRatio":0,"quantiles":{"p25":1,"p5":1,"p50":3,"p75":4,"p95":5},"type":"numeric"},{"count":5,"key":"nil","type":"null"},{"count":5,"distinct":4,"emptyRatio":0,"frequencies":{"aaa":2},"key":"string","lengthHistogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[1,0,1,0,2,0,0,0,1,0]},"lengthQuantiles":{"p25":2,"p5":1,"p50":3,"p75":3,"p95":5},"maxLength":5,"minLength":1,"nullRatio":0,"type":"string","unique":3}]`),
 		}, {
 			"csv: an array of strings",
 			"csv",
 			`{"type":"array"}`,
 			"a\na\nbb\nccc\ndddd",
-			[]byte(`[{"count":5,"frequencies":{"a":2},"maxLength":4,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"distinct":4,"emptyRatio":0,"frequencies":{"a":2},"lengthHistogram":{"bins":[1,1.4,1.8,2.2,2.6,3,3.4000000000000004,3.8000000000000003,4.2,4.6,5],"frequencies":[2,0,1,0,0,1,0,1,0,0]},"lengthQuantiles":{"p25":1,"p5":1,"p50":2,"p75":3,"p95":4},"maxLength":4,"minLength":1,"nullRatio":0,"type":"string","unique":3}]`),
 		}, {
 			"csv: all types identity schema array of object entries",
 			"csv",
@@ -427,7 +629,7 @@ func TestJSON(t *testing.T) {
 				"type": "array"
 			 }`,
 			"1,1.1,,false,a\n1,1.1,,true,aa\n3,3.3,,false,aaa\n4,4.4,,true,aaa\n5,5.5,,false,aaaaa",
-			[]byte(`[{"count":5,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5,"mean":2.8,"median":3,"min":1,"type":"numeric"},{"count":5,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5.5,"mean":3.08,"median":3.3,"min":1.1,"type":"numeric"},{"count":5,"type":"null"},{"count":5,"falseCount":3,"trueCount":2,"type":"boolean"},{"count":5,"frequencies":{"aaa":2},"maxLength":5,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"distinct":4,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5,"mean":2.8,"median":3,"min":1,"nullRatio":0,"quantiles":{"p25":1,"p5":1,"p50":3,"p75":4,"p95":5},"type":"numeric"},{"count":5,"distinct":4,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5.5,"mean":3.08,"median":3.3,"min":1.1,"nullRatio":0,"quantiles":{"p25":1.1,"p5":1.1,"p50":3.3,"p75":4.4,"p95":5.5},"type":"numeric"},{"count":5,"type":"null"},{"count":5,"falseCount":3,"nullRatio":0,"trueCount":2,"type":"boolean"},{"count":5,"distinct":4,"emptyRatio":0,"frequencies":{"aaa":2},"lengthHistogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[1,0,1,0,2,0,0,0,1,0]},"lengthQuantiles":{"p25":2,"p5":1,"p50":3,"p75":3,"p95":5},"maxLength":5,"minLength":1,"nullRatio":0,"type":"string","unique":3}]`),
 		}, {
 			"json: all types identity schema object of array entries",
 			"json",
@@ -439,7 +641,7 @@ func TestJSON(t *testing.T) {
 					"d" : [4,4.4,null,true,"aaa"],
 					"e" : [5,5.5,null,false,"aaaaa"]
 				}`,
-			[]byte(`[{"count":5,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5,"mean":2.8,"median":3,"min":1,"type":"numeric"},{"count":5,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[1,0,2,0,0,0,1,0,1,0]},"max":5.5,"mean":3.08,"median":2.2,"min":1.1,"type":"numeric"},{"count":5,"type":"null"},{"count":5,"falseCount":3,"trueCount":2,"type":"boolean"},{"count":5,"frequencies":{"aaa":2},"maxLength":5,"minLength":1,"type":"string","unique":3}]`),
+			[]byte(`[{"count":5,"distinct":4,"histogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[2,0,0,0,1,0,1,0,1,0]},"max":5,"mean":2.8,"median":3,"min":1,"nullRatio":0,"quantiles":{"p25":1,"p5":1,"p50":3,"p75":4,"p95":5},"type":"numeric"},{"count":5,"distinct":4,"histogram":{"bins":[1.1,1.6400000000000001,2.18,2.72,3.2600000000000002,3.8000000000000003,4.34,4.880000000000001,5.42,5.960000000000001,6.5],"frequencies":[1,0,2,0,0,0,1,0,1,0]},"max":5.5,"mean":3.08,"median":2.2,"min":1.1,"nullRatio":0,"quantiles":{"p25":2.2,"p5":1.1,"p50":2.2,"p75":4.4,"p95":5.5},"type":"numeric"},{"count":5,"type":"null"},{"count":5,"falseCount":3,"nullRatio":0,"trueCount":2,"type":"boolean"},{"count":5,"distinct":4,"emptyRatio":0,"frequencies":{"aaa":2},"lengthHistogram":{"bins":[1,1.5,2,2.5,3,3.5,4,4.5,5,5.5,6],"frequencies":[1,0,1,0,2,0,0,0,1,0]},"lengthQuantiles":{"p25":2,"p5":1,"p50":3,"p75":3,"p95":5},"maxLength":5,"minLength":1,"nullRatio":0,"type":"string","unique":3}]`),
 		}, {
 			"json: array of object of array of strings",
 			"json",
@@ -449,7 +651,7 @@ func TestJSON(t *testing.T) {
 					{"ids": [1,2,3,4,5,6] },
 					{"ids": ["b",20,"c"] }
 				]`,
-			[]byte(`[{"key":"ids","type":"array","values":[{"count":2,"maxLength":1,"minLength":1,"unique":2},{"count":1,"maxLength":1,"minLength":1,"unique":1},{"count":2,"frequencies":{"c":2},"maxLength":1,"minLength":1},{"count":1,"histogram":{"bins":[4,4.1,4.2,4.3,4.4,4.5,4.6,4.7,4.8,4.9,5],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":4,"mean":4,"median":4,"min":4},{"count":1,"histogram":{"bins":[5,5.1,5.2,5.3,5.4,5.5,5.6,5.7,5.8,5.9,6],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":5,"mean":5,"median":5,"min":5},{"count":1,"histogram":{"bins":[6,6.1,6.2,6.3,6.4,6.5,6.6,6.7,6.8,6.9,7],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":6,"mean":6,"median":6,"min":6}]},{"count":1,"falseCount":0,"key":"is_great","trueCount":1,"type":"boolean"}]`),
+			[]byte(`[{"key":"ids","type":"array","values":[{"count":2,"distinct":2,"emptyRatio":0,"lengthHistogram":{"bins":[1,1.1,1.2,1.3,1.4,1.5,1.6,1.7000000000000002,1.8,1.9,2],"frequencies":[2,0,0,0,0,0,0,0,0,0]},"lengthQuantiles":{"p25":1,"p5":1,"p50":1,"p75":1,"p95":1},"maxLength":1,"minLength":1,"nullRatio":0,"unique":2},{"count":1,"distinct":1,"emptyRatio":0,"lengthHistogram":{"bins":[1,1.1,1.2,1.3,1.4,1.5,1.6,1.7000000000000002,1.8,1.9,2],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"lengthQuantiles":{"p25":1,"p5":1,"p50":1,"p75":1,"p95":1},"maxLength":1,"minLength":1,"nullRatio":0,"unique":1},{"count":2,"distinct":1,"emptyRatio":0,"frequencies":{"c":2},"lengthHistogram":{"bins":[1,1.1,1.2,1.3,1.4,1.5,1.6,1.7000000000000002,1.8,1.9,2],"frequencies":[2,0,0,0,0,0,0,0,0,0]},"lengthQuantiles":{"p25":1,"p5":1,"p50":1,"p75":1,"p95":1},"maxLength":1,"minLength":1,"nullRatio":0},{"count":1,"distinct":1,"histogram":{"bins":[4,4.1,4.2,4.3,4.4,4.5,4.6,4.7,4.8,4.9,5],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":4,"mean":4,"median":4,"min":4,"nullRatio":0,"quantiles":{"p25":4,"p5":4,"p50":4,"p75":4,"p95":4}},{"count":1,"distinct":1,"histogram":{"bins":[5,5.1,5.2,5.3,5.4,5.5,5.6,5.7,5.8,5.9,6],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":5,"mean":5,"median":5,"min":5,"nullRatio":0,"quantiles":{"p25":5,"p5":5,"p50":5,"p75":5,"p95":5}},{"count":1,"distinct":1,"histogram":{"bins":[6,6.1,6.2,6.3,6.4,6.5,6.6,6.7,6.8,6.9,7],"frequencies":[1,0,0,0,0,0,0,0,0,0]},"max":6,"mean":6,"median":6,"min":6,"nullRatio":0,"quantiles":{"p25":6,"p5":6,"p50":6,"p75":6,"p95":6}}]},{"count":1,"falseCount":0,"key":"is_great","nullRatio":0,"trueCount":1,"type":"boolean"}]`),
 		},
 	}
 	for i, c := range goodCases {
@@ -480,3 +682,93 @@ func TestJSON(t *testing.T) {
 		}
 	}
 }
+
+// putCache records stats put in the cache, signaling each put
+type putCache struct {
+	mu   sync.Mutex
+	data map[string][]byte
+	puts chan string
+}
+
+func (c *putCache) PutJSON(ctx context.Context, path string, r io.Reader) error {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.data[path] = data
+	c.mu.Unlock()
+	c.puts <- path
+	return nil
+}
+
+func (c *putCache) JSON(ctx context.Context, path string) (io.Reader, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if data, ok := c.data[path]; ok {
+		return bytes.NewReader(data), nil
+	}
+	return nil, ErrCacheMiss
+}
+
+func TestJSONCacheKey(t *testing.T) {
+	ctx := context.Background()
+	const path = "/map/QmStatsCacheKey"
+	stale := `[{"count":5,"type":"numeric"}]`
+	cache := &putCache{
+		// stats cached before the format version was part of the key
+		data: map[string][]byte{path: []byte(stale)},
+		puts: make(chan string, 1),
+	}
+	newDataset := func(body string) *dataset.Dataset {
+		ds := &dataset.Dataset{Path: path, Structure: &dataset.Structure{Format: "json", Schema: dataset.BaseSchemaArray}}
+		ds.SetBodyFile(qfs.NewMemfileBytes("body.json", []byte(body)))
+		return ds
+	}
+	read := func(s *Stats, ds *dataset.Dataset) string {
+		r, err := s.JSON(ctx, ds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ioutil.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+	waitForPut := func() string {
+		select {
+		case key := <-cache.puts:
+			return key
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for stats to be cached")
+		}
+		return ""
+	}
+
+	s := New(cache)
+	got := read(s, newDataset(`[1,2,3,4,5]`))
+	if got == stale {
+		t.Fatal("expected stats cached without a format version to be recalculated")
+	}
+	tenBins := waitForPut()
+
+	// cached stats are served when options match
+	if cached := read(New(cache), newDataset(`[bad body`)); cached != got {
+		t.Errorf("expected cached stats, got: %s", cached)
+	}
+
+	// changing options recalculates stats
+	fiveBins := New(cache, OptHistogramBins(5))
+	got = read(fiveBins, newDataset(`[1,2,3,4,5]`))
+	stats := []map[string]interface{}{}
+	if err := json.Unmarshal([]byte(got), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if bins := stats[0]["histogram"].(map[string]interface{})["bins"].([]interface{}); len(bins) != 6 {
+		t.Errorf("expected 5 histogram bins, got %d", len(bins)-1)
+	}
+	if key := waitForPut(); key == tenBins {
+		t.Errorf("expected stats calculated with different options to use a different cache key")
+	}
+}
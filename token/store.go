@@ -0,0 +1,170 @@
+package token
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Record is an issued token
+type Record struct {
+	Claims
+	// RevokedAt is the time the token was revoked in unix seconds, zero if
+	// the token hasn't been revoked
+	RevokedAt int64 `json:"revokedAt,omitempty"`
+}
+
+// Store records issued tokens so they can be listed & revoked. Only tokens
+// recorded in a store are accepted by it, so deleting a store revokes all
+// tokens. Stores are safe for concurrent use
+type Store struct {
+	filename string
+
+	lk      sync.Mutex
+	modTime time.Time
+	records map[string]*Record
+}
+
+// NewStore creates a store that persists to filename. Changes made to the file
+// by other processes are picked up, so tokens revoked from the command line
+// take effect on a running server. An empty filename creates an in-memory
+// store
+func NewStore(filename string) *Store {
+	return &Store{
+		filename: filename,
+		records:  map[string]*Record{},
+	}
+}
+
+// Put records an issued token
+func (s *Store) Put(c *Claims) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	s.records[c.ID] = &Record{Claims: *c}
+	return s.save()
+}
+
+// List gives all recorded tokens, oldest first
+func (s *Store) List() ([]Record, error) {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	recs := make([]Record, 0, len(s.records))
+	for _, r := range s.records {
+		recs = append(recs, *r)
+	}
+	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].IssuedAt == recs[j].IssuedAt {
+			return recs[i].ID < recs[j].ID
+		}
+		return recs[i].IssuedAt < recs[j].IssuedAt
+	})
+	return recs, nil
+}
+
+// Revoke stops a token from being accepted
+func (s *Store) Revoke(id string) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	r, ok := s.records[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if r.RevokedAt == 0 {
+		r.RevokedAt = Timestamp().Unix()
+	}
+	return s.save()
+}
+
+// Check confirms a token was issued by this store and hasn't been revoked
+func (s *Store) Check(c *Claims) error {
+	s.lk.Lock()
+	defer s.lk.Unlock()
+	if err := s.load(); err != nil {
+		return err
+	}
+	r, ok := s.records[c.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if r.RevokedAt != 0 {
+		return ErrRevoked
+	}
+	return nil
+}
+
+// storeFile is the persisted form of a store
+type storeFile struct {
+	Tokens []*Record `json:"tokens"`
+}
+
+// load reads the store file if it's changed since it was last read. callers
+// must hold the lock
+func (s *Store) load() error {
+	if s.filename == "" {
+		return nil
+	}
+	fi, err := os.Stat(s.filename)
+	if os.IsNotExist(err) {
+		s.records = map[string]*Record{}
+		s.modTime = time.Time{}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if fi.ModTime().Equal(s.modTime) {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+	f := storeFile{}
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+	s.records = make(map[string]*Record, len(f.Tokens))
+	for _, r := range f.Tokens {
+		s.records[r.ID] = r
+	}
+	s.modTime = fi.ModTime()
+	return nil
+}
+
+// save writes the store to disk. callers must hold the lock
+func (s *Store) save() error {
+	if s.filename == "" {
+		return nil
+	}
+	f := storeFile{Tokens: make([]*Record, 0, len(s.records))}
+	for _, r := range s.records {
+		f.Tokens = append(f.Tokens, r)
+	}
+	sort.Slice(f.Tokens, func(i, j int) bool { return f.Tokens[i].ID < f.Tokens[j].ID })
+	data, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	// the store lists who can access the api, keep it private to the user
+	if err := ioutil.WriteFile(s.filename, data, 0600); err != nil {
+		return err
+	}
+	fi, err := os.Stat(s.filename)
+	if err != nil {
+		return err
+	}
+	s.modTime = fi.ModTime()
+	return nil
+}
@@ -0,0 +1,208 @@
+// Package token issues & verifies signed bearer tokens that grant scoped
+// access to a qri node's HTTP API. Tokens use the JSON Web Token layout:
+// base64url-encoded header, claims and signature joined by dots, signed with
+// the private key of the node that issued them
+package token
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+	pb "github.com/libp2p/go-libp2p-core/crypto/pb"
+)
+
+var (
+	// ErrInvalid indicates a token is malformed, or wasn't signed by the
+	// expected key
+	ErrInvalid = errors.New("invalid token")
+	// ErrExpired indicates a token is past its expiry time
+	ErrExpired = errors.New("token has expired")
+	// ErrRevoked indicates a token has been revoked
+	ErrRevoked = errors.New("token has been revoked")
+	// ErrNotFound indicates a token wasn't issued by a store
+	ErrNotFound = errors.New("token not found")
+)
+
+// Timestamp is the function used to get the current time when issuing &
+// checking tokens, replace it to control time in tests
+var Timestamp = time.Now
+
+// Scope is a set of actions a token grants
+type Scope string
+
+const (
+	// ScopeRead allows reading datasets, peers & profiles
+	ScopeRead Scope = "read"
+	// ScopeWrite allows saving, removing & changing datasets, and implies
+	// ScopeRead
+	ScopeWrite Scope = "write"
+	// ScopePublish allows publishing & unpublishing datasets, and implies
+	// ScopeRead
+	ScopePublish Scope = "publish"
+	// ScopeAdmin allows everything, including connecting to peers & managing
+	// the node
+	ScopeAdmin Scope = "admin"
+)
+
+// ParseScopes converts strings to scopes, erroring on unknown scopes
+func ParseScopes(strs []string) ([]Scope, error) {
+	scopes := make([]Scope, 0, len(strs))
+	for _, s := range strs {
+		switch sc := Scope(strings.ToLower(strings.TrimSpace(s))); sc {
+		case ScopeRead, ScopeWrite, ScopePublish, ScopeAdmin:
+			scopes = append(scopes, sc)
+		default:
+			return nil, fmt.Errorf("unknown scope %q. scopes are read, write, publish & admin", s)
+		}
+	}
+	return scopes, nil
+}
+
+// Claims are the contents of a token
+type Claims struct {
+	// ID uniquely identifies a token, and is used to revoke it
+	ID string `json:"jti"`
+	// Subject is who or what the token was issued to
+	Subject string `json:"sub,omitempty"`
+	// Issuer is the key ID of the node that issued the token
+	Issuer string `json:"iss"`
+	// IssuedAt is the time the token was issued, in unix seconds
+	IssuedAt int64 `json:"iat"`
+	// ExpiresAt is the time the token expires, in unix seconds. Zero never
+	// expires
+	ExpiresAt int64 `json:"exp,omitempty"`
+	// Scopes granted by the token
+	Scopes []Scope `json:"scopes"`
+}
+
+// NewClaims creates claims with a random ID, expiring after ttl. A ttl of
+// zero never expires
+func NewClaims(issuer, subject string, scopes []Scope, ttl time.Duration) (*Claims, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	now := Timestamp()
+	c := &Claims{
+		ID:       hex.EncodeToString(id),
+		Subject:  subject,
+		Issuer:   issuer,
+		IssuedAt: now.Unix(),
+		Scopes:   scopes,
+	}
+	if ttl > 0 {
+		c.ExpiresAt = now.Add(ttl).Unix()
+	}
+	return c, nil
+}
+
+// Allows checks if claims grant a scope
+func (c *Claims) Allows(s Scope) bool {
+	for _, have := range c.Scopes {
+		if have == s || have == ScopeAdmin {
+			return true
+		}
+		if s == ScopeRead && (have == ScopeWrite || have == ScopePublish) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expired checks if claims are past their expiry time
+func (c *Claims) Expired() bool {
+	return c.ExpiresAt != 0 && Timestamp().Unix() >= c.ExpiresAt
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+// algorithm gives the JSON Web Signature algorithm name of a key type
+func algorithm(keyType pb.KeyType) (string, error) {
+	switch keyType {
+	case pb.KeyType_RSA:
+		return "RS256", nil
+	case pb.KeyType_Ed25519:
+		return "EdDSA", nil
+	case pb.KeyType_Secp256k1:
+		return "ES256K", nil
+	}
+	return "", fmt.Errorf("unsupported key type for signing tokens: %s", keyType)
+}
+
+var encoding = base64.RawURLEncoding
+
+// Sign encodes claims as a token signed by a private key
+func Sign(pk crypto.PrivKey, c *Claims) (string, error) {
+	alg, err := algorithm(pk.Type())
+	if err != nil {
+		return "", err
+	}
+	h, err := json.Marshal(header{Alg: alg, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	signed := encoding.EncodeToString(h) + "." + encoding.EncodeToString(body)
+	sig, err := pk.Sign([]byte(signed))
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + encoding.EncodeToString(sig), nil
+}
+
+// Parse decodes a token, checking it was signed by the private half of pub
+// and hasn't expired
+func Parse(tok string, pub crypto.PubKey) (*Claims, error) {
+	parts := strings.Split(tok, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalid
+	}
+
+	h := header{}
+	if err := decodePart(parts[0], &h); err != nil {
+		return nil, err
+	}
+	if alg, err := algorithm(pub.Type()); err != nil || h.Alg != alg {
+		return nil, ErrInvalid
+	}
+	sig, err := encoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	if ok, err := pub.Verify([]byte(parts[0]+"."+parts[1]), sig); err != nil || !ok {
+		return nil, ErrInvalid
+	}
+
+	c := &Claims{}
+	if err := decodePart(parts[1], c); err != nil {
+		return nil, err
+	}
+	if c.Expired() {
+		return c, ErrExpired
+	}
+	return c, nil
+}
+
+func decodePart(part string, v interface{}) error {
+	data, err := encoding.DecodeString(part)
+	if err != nil {
+		return ErrInvalid
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrInvalid
+	}
+	return nil
+}
@@ -0,0 +1,217 @@
+package token
+
+import (
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p-core/crypto"
+)
+
+func TestSignParse(t *testing.T) {
+	rsaKey, _, err := crypto.GenerateKeyPairWithReader(crypto.RSA, 2048, rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pk := range []crypto.PrivKey{rsaKey, edKey} {
+		c, err := NewClaims("issuer", "dashboard", []Scope{ScopeRead}, time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tok, err := Sign(pk, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Parse(tok, pk.GetPublic())
+		if err != nil {
+			t.Fatalf("%s key: %s", pk.Type(), err)
+		}
+		if got.ID != c.ID || got.Subject != "dashboard" || got.ExpiresAt != c.ExpiresAt {
+			t.Errorf("%s key: claims mismatch. expected: %v, got: %v", pk.Type(), c, got)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	pk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClaims("issuer", "", []Scope{ScopeRead}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := Sign(pk, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// swap in claims granting admin, keeping the original signature
+	admin := *c
+	admin.Scopes = []Scope{ScopeAdmin}
+	adminTok, err := Sign(pk, &admin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts, adminParts := strings.Split(tok, "."), strings.Split(adminTok, ".")
+	tampered := strings.Join([]string{parts[0], adminParts[1], parts[2]}, ".")
+
+	cases := []struct {
+		description string
+		tok         string
+		pub         crypto.PubKey
+	}{
+		{"empty", "", pk.GetPublic()},
+		{"not enough parts", "a.b", pk.GetPublic()},
+		{"garbage", "a.b.c", pk.GetPublic()},
+		{"wrong key", tok, other.GetPublic()},
+		{"tampered claims", tampered, pk.GetPublic()},
+	}
+	for _, c := range cases {
+		if _, err := Parse(c.tok, c.pub); err != ErrInvalid {
+			t.Errorf("case %q: expected ErrInvalid, got: %v", c.description, err)
+		}
+	}
+}
+
+func TestParseExpired(t *testing.T) {
+	prevTs := Timestamp
+	defer func() { Timestamp = prevTs }()
+	now := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	Timestamp = func() time.Time { return now }
+
+	pk, _, err := crypto.GenerateEd25519Key(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewClaims("issuer", "", []Scope{ScopeRead}, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := Sign(pk, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := Parse(tok, pk.GetPublic()); err != ErrExpired {
+		t.Errorf("expected ErrExpired, got: %v", err)
+	}
+}
+
+func TestClaimsAllows(t *testing.T) {
+	cases := []struct {
+		have   []Scope
+		want   Scope
+		expect bool
+	}{
+		{[]Scope{ScopeRead}, ScopeRead, true},
+		{[]Scope{ScopeRead}, ScopeWrite, false},
+		{[]Scope{ScopeWrite}, ScopeRead, true},
+		{[]Scope{ScopeWrite}, ScopePublish, false},
+		{[]Scope{ScopePublish}, ScopeRead, true},
+		{[]Scope{ScopeWrite, ScopePublish}, ScopePublish, true},
+		{[]Scope{ScopeWrite, ScopePublish}, ScopeAdmin, false},
+		{[]Scope{ScopeAdmin}, ScopePublish, true},
+		{nil, ScopeRead, false},
+	}
+	for i, c := range cases {
+		cl := &Claims{Scopes: c.have}
+		if got := cl.Allows(c.want); got != c.expect {
+			t.Errorf("case %d: %v allows %s: expected %t, got %t", i, c.have, c.want, c.expect, got)
+		}
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	got, err := ParseScopes([]string{"read", " Write"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != ScopeRead || got[1] != ScopeWrite {
+		t.Errorf("unexpected scopes: %v", got)
+	}
+	if _, err := ParseScopes([]string{"everything"}); err == nil {
+		t.Error("expected unknown scope to error")
+	}
+}
+
+func TestStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token_store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "tokens.json")
+
+	s := NewStore(filename)
+	a, _ := NewClaims("issuer", "a", []Scope{ScopeRead}, 0)
+	b, _ := NewClaims("issuer", "b", []Scope{ScopeWrite}, 0)
+	for _, c := range []*Claims{a, b} {
+		if err := s.Put(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Check(a); err != nil {
+		t.Errorf("expected issued token to check, got: %s", err)
+	}
+	unknown, _ := NewClaims("issuer", "", []Scope{ScopeRead}, 0)
+	if err := s.Check(unknown); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+
+	// revoking from another store on the same file should apply to the first
+	other := NewStore(filename)
+	if err := other.Revoke(a.ID); err != nil {
+		t.Fatal(err)
+	}
+	// make sure the modification time changes on filesystems with coarse times
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(filename, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Check(a); err != ErrRevoked {
+		t.Errorf("expected ErrRevoked, got: %v", err)
+	}
+	if err := s.Check(b); err != nil {
+		t.Errorf("expected unrevoked token to check, got: %s", err)
+	}
+	if err := s.Revoke("unknown"); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound, got: %v", err)
+	}
+
+	recs, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("expected 2 records, got: %d", len(recs))
+	}
+	for _, r := range recs {
+		if (r.ID == a.ID) != (r.RevokedAt != 0) {
+			t.Errorf("record %s has unexpected revoked time %d", r.Subject, r.RevokedAt)
+		}
+	}
+
+	// deleting the file revokes everything
+	if err := os.Remove(filename); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Check(b); err != ErrNotFound {
+		t.Errorf("expected ErrNotFound after removing store file, got: %v", err)
+	}
+}
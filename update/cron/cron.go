@@ -4,9 +4,11 @@ package cron
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"sync"
 	"time"
 
 	golog "github.com/ipfs/go-log"
@@ -20,6 +22,9 @@ var (
 	// not running updates more than once an hour for performance and storage
 	// consumption reasons, making a check every minute a reasonable default
 	DefaultCheckInterval = time.Minute
+	// ErrJobRunning indicates a job can't run because a run of the same job
+	// hasn't finished
+	ErrJobRunning = fmt.Errorf("job is already running")
 )
 
 // Scheduler is the generic interface for the Cron Scheduler, it's implemented
@@ -34,6 +39,14 @@ type Scheduler interface {
 	Schedule(ctx context.Context, job *Job) error
 	// Unschedule removes a job from the scheduler
 	Unschedule(ctx context.Context, name string) error
+	// Pause stops a job from running until it's resumed
+	Pause(ctx context.Context, name string) error
+	// Resume restarts a paused job, clearing any record of consecutive failures
+	Resume(ctx context.Context, name string) error
+	// Run executes a scheduled job immediately, recording the run the same way
+	// as a scheduled run. Run returns the logged job, which reports any error
+	// the job failed with in RunError
+	Run(ctx context.Context, name string) (*Job, error)
 
 	// ListLogs gives a log of executed jobs
 	ListLogs(ctx context.Context, offset, limit int) ([]*Job, error)
@@ -74,11 +87,21 @@ type Cron struct {
 	log      JobStore
 	interval time.Duration
 	factory  RunJobFactory
+	policy   FailurePolicy
+
+	lk      sync.Mutex
+	running map[string]bool
 }
 
 // assert Cron is a Scheduler at compile time
 var _ Scheduler = (*Cron)(nil)
 
+// SetFailurePolicy configures retries, auto-pausing & notifications for failed
+// jobs. SetFailurePolicy must be called before Start
+func (c *Cron) SetFailurePolicy(p FailurePolicy) {
+	c.policy = p
+}
+
 // ListJobs proxies to the schedule store for reading jobs
 func (c *Cron) ListJobs(ctx context.Context, offset, limit int) ([]*Job, error) {
 	return c.schedule.ListJobs(ctx, offset, limit)
@@ -131,20 +154,41 @@ func (c *Cron) Start(ctx context.Context) error {
 			return
 		}
 
-		run := []*Job{}
+		run := []string{}
 		for _, job := range jobs {
-			if now.After(job.NextExec()) {
-				run = append(run, job)
+			if due(job, now) {
+				run = append(run, job.Name)
 			}
 		}
 
 		if len(run) > 0 {
 			log.Infof("running %d job(s)", len(run))
 			runner := c.factory(ctx)
-			for _, job := range run {
+			for _, name := range run {
+				if !c.claim(name) {
+					log.Debugf("skipping job %s: %s", name, ErrJobRunning)
+					continue
+				}
+				// reload the job, a run that finished since the check started moves
+				// the schedule
+				job, err := c.schedule.Job(ctx, name)
+				if err != nil || !due(job, now) {
+					c.release(name)
+					continue
+				}
+				if job.Periodicity != zero && now.After(job.NextExec()) {
+					job.Retries = 0
+				} else {
+					job.Retries++
+				}
+
 				// TODO (b5) - if we want things like per-job timeout, we should create
 				// a new job-scoped context here
 				c.runJob(ctx, job, runner)
+				c.release(name)
+				if job.RunError == "" {
+					c.runDownstream(ctx, name, runner)
+				}
 			}
 		} else {
 			log.Debugf("no jobs to run")
@@ -162,6 +206,39 @@ func (c *Cron) Start(ctx context.Context) error {
 	}
 }
 
+// due checks if a job should run on schedule or for a retry at a given time.
+// Jobs without a periodicity only run when triggered by upstream jobs
+func due(job *Job, now time.Time) bool {
+	if job.Paused {
+		return false
+	}
+	if job.Periodicity != zero && now.After(job.NextExec()) {
+		return true
+	}
+	return !job.NextRetry.IsZero() && now.After(job.NextRetry)
+}
+
+// claim marks a job as running, returning false if it's already running.
+// Every run of a job must claim it first, and release it when it's done
+func (c *Cron) claim(name string) bool {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	if c.running[name] {
+		return false
+	}
+	if c.running == nil {
+		c.running = map[string]bool{}
+	}
+	c.running[name] = true
+	return true
+}
+
+func (c *Cron) release(name string) {
+	c.lk.Lock()
+	defer c.lk.Unlock()
+	delete(c.running, name)
+}
+
 func (c *Cron) runJob(ctx context.Context, job *Job, runner RunJobFunc) {
 	log.Debugf("run job: %s", job.Name)
 	job.RunStart = time.Now().In(time.UTC)
@@ -176,7 +253,8 @@ func (c *Cron) runJob(ctx context.Context, job *Job, runner RunJobFunc) {
 		}
 	}
 
-	if err := runner(ctx, streams, job); err != nil {
+	err := runner(ctx, streams, job)
+	if err != nil {
 		log.Errorf("run job: %s error: %s", job.Name, err.Error())
 		job.RunError = err.Error()
 	} else {
@@ -185,13 +263,17 @@ func (c *Cron) runJob(ctx context.Context, job *Job, runner RunJobFunc) {
 	}
 	job.RunStop = time.Now().In(time.UTC)
 	job.RunNumber++
+	c.applyFailurePolicy(job, err != nil)
 
 	// the updated job that goes to the schedule store shouldn't have a log path
 	scheduleJob := job.Copy()
 	scheduleJob.LogFilePath = ""
 	scheduleJob.RunStart = time.Time{}
 	scheduleJob.RunStop = time.Time{}
-	scheduleJob.PrevRunStart = job.RunStart
+	// retries don't move the schedule
+	if job.Retries == 0 {
+		scheduleJob.PrevRunStart = job.RunStart
+	}
 	if err := c.schedule.PutJob(ctx, scheduleJob); err != nil {
 		log.Error(err)
 	}
@@ -202,12 +284,115 @@ func (c *Cron) runJob(ctx context.Context, job *Job, runner RunJobFunc) {
 	}
 }
 
-// Schedule adds a job to the cron scheduler
+// applyFailurePolicy updates the retry & pause state of a job that just ran,
+// notifying when a job fails without a pending retry, is paused, or recovers.
+// A run counts as one failure once all of its retries have failed
+func (c *Cron) applyFailurePolicy(job *Job, failed bool) {
+	job.NextRetry = time.Time{}
+	if !failed {
+		if job.Failures > 0 {
+			job.Failures = 0
+			c.policy.notify(ETRecovery, job.Copy())
+		}
+		job.Retries = 0
+		return
+	}
+
+	if job.Retries < int64(c.policy.Retries) {
+		job.NextRetry = job.RunStop.Add(c.policy.retryDelay(job.Retries))
+		return
+	}
+
+	job.Failures++
+	if c.policy.MaxFailures > 0 && job.Failures >= int64(c.policy.MaxFailures) {
+		log.Infof("pausing job %s after %d consecutive failures", job.Name, job.Failures)
+		job.Paused = true
+		c.policy.notify(ETPaused, job.Copy())
+		return
+	}
+	c.policy.notify(ETFailure, job.Copy())
+}
+
+// runDownstream runs all jobs triggered by a successful run of the named job,
+// in dependency order. A downstream job runs once, after all of its upstream
+// jobs that ran succeed. Jobs downstream of a failure don't run
+func (c *Cron) runDownstream(ctx context.Context, name string, runner RunJobFunc) {
+	js, err := c.schedule.ListJobs(ctx, 0, -1)
+	if err != nil {
+		log.Errorf("getting jobs from store: %s", err)
+		return
+	}
+	graph, err := NewDependencyGraph(js)
+	if err != nil {
+		log.Errorf("running jobs downstream of %s: %s", name, err)
+		return
+	}
+
+	byName := map[string]*Job{}
+	for _, job := range js {
+		byName[job.Name] = job
+	}
+
+	descendants := graph.Descendants(name)
+	inRun := map[string]bool{}
+	for _, down := range descendants {
+		inRun[down] = true
+	}
+
+	succeeded := map[string]bool{name: true}
+	for _, down := range descendants {
+		job, ok := byName[down]
+		if !ok || job.Paused {
+			continue
+		}
+
+		triggered, blocked := false, false
+		for _, up := range job.Upstream {
+			if succeeded[up] {
+				triggered = true
+			} else if inRun[up] {
+				// an upstream job in this run failed or was skipped
+				blocked = true
+			}
+		}
+		if !triggered || blocked {
+			log.Debugf("skipping job %s downstream of %s", down, name)
+			continue
+		}
+
+		if !c.claim(down) {
+			log.Debugf("skipping job %s downstream of %s: %s", down, name, ErrJobRunning)
+			continue
+		}
+		log.Debugf("running job %s downstream of %s", down, name)
+		job.Retries = 0
+		c.runJob(ctx, job, runner)
+		c.release(down)
+		succeeded[down] = job.RunError == ""
+	}
+}
+
+// Schedule adds a job to the cron scheduler. Jobs that would create a cycle
+// of upstream dependencies are rejected
 func (c *Cron) Schedule(ctx context.Context, job *Job) error {
 	if err := job.Validate(); err != nil {
 		return err
 	}
 
+	js, err := c.schedule.ListJobs(ctx, 0, -1)
+	if err != nil {
+		return err
+	}
+	scheduled := []*Job{job}
+	for _, j := range js {
+		if j.Name != job.Name {
+			scheduled = append(scheduled, j)
+		}
+	}
+	if _, err := NewDependencyGraph(scheduled); err != nil {
+		return err
+	}
+
 	// TODO (b5) - check for prior job & inherit the previous run number
 
 	return c.schedule.PutJob(ctx, job)
@@ -218,3 +403,41 @@ func (c *Cron) Schedule(ctx context.Context, job *Job) error {
 func (c *Cron) Unschedule(ctx context.Context, name string) error {
 	return c.schedule.DeleteJob(ctx, name)
 }
+
+// Pause stops a job from running until it's resumed. Paused jobs don't run
+// on schedule or when triggered by upstream jobs
+func (c *Cron) Pause(ctx context.Context, name string) error {
+	return c.schedule.Pause(ctx, name)
+}
+
+// Resume restarts a paused job. Resumed jobs run at their next scheduled time
+func (c *Cron) Resume(ctx context.Context, name string) error {
+	return c.schedule.Resume(ctx, name)
+}
+
+// Run executes a scheduled job immediately. The run gets the next run number,
+// a log entry, & counts toward the failure policy like a scheduled run. Paused
+// jobs can be run, a successful run triggers downstream jobs. Run fails with
+// ErrJobRunning if the job is already running
+func (c *Cron) Run(ctx context.Context, name string) (*Job, error) {
+	if c.factory == nil {
+		return nil, fmt.Errorf("cron has no way to run jobs")
+	}
+	if !c.claim(name) {
+		return nil, fmt.Errorf("%w: %s", ErrJobRunning, name)
+	}
+	job, err := c.schedule.Job(ctx, name)
+	if err != nil {
+		c.release(name)
+		return nil, err
+	}
+
+	runner := c.factory(ctx)
+	job.Retries = 0
+	c.runJob(ctx, job, runner)
+	c.release(name)
+	if job.RunError == "" {
+		c.runDownstream(ctx, name, runner)
+	}
+	return job, nil
+}
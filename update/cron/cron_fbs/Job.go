@@ -143,8 +143,69 @@ func (rcv *Job) RepoPath() []byte {
 	return nil
 }
 
+func (rcv *Job) Upstream(j int) []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(30))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.ByteVector(a + flatbuffers.UOffsetT(j*4))
+	}
+	return nil
+}
+
+func (rcv *Job) UpstreamLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(30))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func (rcv *Job) Paused() bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(32))
+	if o != 0 {
+		return rcv._tab.GetBool(o + rcv._tab.Pos)
+	}
+	return false
+}
+
+func (rcv *Job) MutatePaused(n bool) bool {
+	return rcv._tab.MutateBoolSlot(32, n)
+}
+
+func (rcv *Job) Failures() int64 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(34))
+	if o != 0 {
+		return rcv._tab.GetInt64(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *Job) MutateFailures(n int64) bool {
+	return rcv._tab.MutateInt64Slot(34, n)
+}
+
+func (rcv *Job) Retries() int64 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(36))
+	if o != 0 {
+		return rcv._tab.GetInt64(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *Job) MutateRetries(n int64) bool {
+	return rcv._tab.MutateInt64Slot(36, n)
+}
+
+func (rcv *Job) NextRetry() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(38))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
 func JobStart(builder *flatbuffers.Builder) {
-	builder.StartObject(13)
+	builder.StartObject(18)
 }
 func JobAddName(builder *flatbuffers.Builder, name flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(name), 0)
@@ -185,6 +246,24 @@ func JobAddOptions(builder *flatbuffers.Builder, options flatbuffers.UOffsetT) {
 func JobAddRepoPath(builder *flatbuffers.Builder, repoPath flatbuffers.UOffsetT) {
 	builder.PrependUOffsetTSlot(12, flatbuffers.UOffsetT(repoPath), 0)
 }
+func JobAddUpstream(builder *flatbuffers.Builder, upstream flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(13, flatbuffers.UOffsetT(upstream), 0)
+}
+func JobStartUpstreamVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func JobAddPaused(builder *flatbuffers.Builder, paused bool) {
+	builder.PrependBoolSlot(14, paused, false)
+}
+func JobAddFailures(builder *flatbuffers.Builder, failures int64) {
+	builder.PrependInt64Slot(15, failures, 0)
+}
+func JobAddRetries(builder *flatbuffers.Builder, retries int64) {
+	builder.PrependInt64Slot(16, retries, 0)
+}
+func JobAddNextRetry(builder *flatbuffers.Builder, nextRetry flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(17, flatbuffers.UOffsetT(nextRetry), 0)
+}
 func JobEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
@@ -8,10 +8,12 @@ const (
 	OptionsNONE               Options = 0
 	OptionsDatasetOptions     Options = 1
 	OptionsShellScriptOptions Options = 2
+	OptionsTransformOptions   Options = 3
 )
 
 var EnumNamesOptions = map[Options]string{
 	OptionsNONE:               "NONE",
 	OptionsDatasetOptions:     "DatasetOptions",
 	OptionsShellScriptOptions: "ShellScriptOptions",
+	OptionsTransformOptions:   "TransformOptions",
 }
@@ -2,6 +2,8 @@ package cron
 
 import (
 	"context"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
@@ -152,3 +154,96 @@ func TestCronShellScript(t *testing.T) {
 		t.Errorf("log job mismatch: %s", err)
 	}
 }
+
+func TestCronPausedJob(t *testing.T) {
+	ran := 0
+	factory := func(outer context.Context) RunJobFunc {
+		return func(ctx context.Context, streams ioes.IOStreams, job *Job) error {
+			ran++
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*300)
+	defer cancel()
+
+	cron := NewCronInterval(&MemJobStore{}, &MemJobStore{}, factory, time.Millisecond*50)
+	job := &Job{Name: "b5/paused", Type: JTDataset, Periodicity: mustRepeatingInterval("R/P1W")}
+	if err := cron.Schedule(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	if err := cron.Pause(ctx, job.Name); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cron.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if ran != 0 {
+		t.Errorf("expected paused job not to run. ran %d times", ran)
+	}
+}
+
+func TestCronRunDuringTick(t *testing.T) {
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	var lk sync.Mutex
+	ran := 0
+	factory := func(outer context.Context) RunJobFunc {
+		return func(ctx context.Context, streams ioes.IOStreams, job *Job) error {
+			lk.Lock()
+			ran++
+			first := ran == 1
+			lk.Unlock()
+			if first {
+				close(started)
+				<-unblock
+			}
+			return nil
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logs := &MemJobStore{}
+	cron := NewCronInterval(&MemJobStore{}, logs, factory, time.Millisecond*10)
+	job := &Job{Name: "b5/slow", Type: JTDataset, Periodicity: mustRepeatingInterval("R/P1W")}
+	if err := cron.Schedule(ctx, job); err != nil {
+		t.Fatal(err)
+	}
+	go cron.Start(ctx)
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a scheduled run")
+	}
+
+	// manual runs & later ticks can't start a second run of the in-flight job
+	if _, err := cron.Run(ctx, job.Name); !errors.Is(err, ErrJobRunning) {
+		t.Errorf("expected running an in-flight job to fail with ErrJobRunning. got: %v", err)
+	}
+	time.Sleep(time.Millisecond * 50)
+	close(unblock)
+	time.Sleep(time.Millisecond * 50)
+	cancel()
+
+	lk.Lock()
+	if ran != 1 {
+		t.Errorf("expected job to run once. ran %d times", ran)
+	}
+	lk.Unlock()
+	runs, err := logs.ListJobs(context.Background(), 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 {
+		t.Errorf("expected 1 log entry. got: %d", len(runs))
+	}
+
+	// once the run finishes the job can be run manually
+	if _, err := cron.Run(context.Background(), job.Name); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,124 @@
+package cron
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrDependencyCycle indicates jobs depend on each other in a loop
+var ErrDependencyCycle = fmt.Errorf("job dependency cycle")
+
+// DependencyGraph describes the order jobs trigger each other in. Jobs are
+// nodes, edges point from upstream jobs to the downstream jobs they trigger.
+// Upstream names that don't match a scheduled job are included as nodes
+type DependencyGraph struct {
+	// Order lists job names so every job comes after all of its upstream jobs
+	Order []string `json:"order"`
+	// Upstream maps a job name to the names of the jobs it depends on
+	Upstream map[string][]string `json:"upstream"`
+	// Downstream maps a job name to the names of the jobs it triggers
+	Downstream map[string][]string `json:"downstream"`
+}
+
+// NewDependencyGraph creates a dependency graph from a set of jobs, returning
+// an error that wraps ErrDependencyCycle if the jobs don't form a DAG
+func NewDependencyGraph(js []*Job) (*DependencyGraph, error) {
+	g := &DependencyGraph{
+		Upstream:   map[string][]string{},
+		Downstream: map[string][]string{},
+	}
+
+	names := []string{}
+	addNode := func(name string) {
+		if _, ok := g.Upstream[name]; !ok {
+			g.Upstream[name] = []string{}
+			g.Downstream[name] = []string{}
+			names = append(names, name)
+		}
+	}
+
+	for _, job := range js {
+		addNode(job.Name)
+		for _, up := range job.Upstream {
+			addNode(up)
+			g.Upstream[job.Name] = append(g.Upstream[job.Name], up)
+			g.Downstream[up] = append(g.Downstream[up], job.Name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sort.Strings(g.Upstream[name])
+		sort.Strings(g.Downstream[name])
+	}
+
+	// depth-first topological sort, visiting upstream jobs before the jobs that
+	// depend on them. sorting names keeps output stable
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := map[string]int{}
+	path := []string{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visited:
+			return nil
+		case visiting:
+			start := 0
+			for i, n := range path {
+				if n == name {
+					start = i
+				}
+			}
+			cycle := append(append([]string{}, path[start:]...), name)
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(cycle, " -> "))
+		}
+
+		state[name] = visiting
+		path = append(path, name)
+		for _, up := range g.Upstream[name] {
+			if err := visit(up); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = visited
+		g.Order = append(g.Order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return g, nil
+}
+
+// Descendants lists all jobs triggered directly or indirectly by a job in
+// dependency order, not including the job itself
+func (g *DependencyGraph) Descendants(name string) []string {
+	reachable := map[string]bool{}
+	queue := []string{name}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, down := range g.Downstream[n] {
+			if !reachable[down] {
+				reachable[down] = true
+				queue = append(queue, down)
+			}
+		}
+	}
+
+	desc := []string{}
+	for _, n := range g.Order {
+		if reachable[n] {
+			desc = append(desc, n)
+		}
+	}
+	return desc
+}
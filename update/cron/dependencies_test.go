@@ -0,0 +1,114 @@
+package cron
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/ioes"
+)
+
+func TestDependencyGraph(t *testing.T) {
+	js := []*Job{
+		{Name: "d", Upstream: []string{"b", "c"}},
+		{Name: "c", Upstream: []string{"a"}},
+		{Name: "b", Upstream: []string{"a", "external/dataset"}},
+		{Name: "a"},
+		{Name: "unrelated"},
+	}
+
+	g, err := NewDependencyGraph(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expectOrder := []string{"a", "external/dataset", "b", "c", "d", "unrelated"}
+	if diff := cmp.Diff(expectOrder, g.Order); diff != "" {
+		t.Errorf("order mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"b", "c"}, g.Downstream["a"]); diff != "" {
+		t.Errorf("downstream mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{"b", "c", "d"}, g.Descendants("a")); diff != "" {
+		t.Errorf("descendants mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{}, g.Descendants("unrelated")); diff != "" {
+		t.Errorf("descendants mismatch (-want +got):\n%s", diff)
+	}
+
+	js[3].Upstream = []string{"d"}
+	_, err = NewDependencyGraph(js)
+	if !errors.Is(err, ErrDependencyCycle) {
+		t.Fatalf("expected cycle error. got: %v", err)
+	}
+	expect := "job dependency cycle: a -> d -> b -> a"
+	if err.Error() != expect {
+		t.Errorf("error mismatch. expected: %q, got: %q", expect, err.Error())
+	}
+}
+
+func TestCronScheduleCycle(t *testing.T) {
+	ctx := context.Background()
+	cron := NewCron(&MemJobStore{}, &MemJobStore{}, nil)
+
+	a := &Job{Name: "a", Type: JTDataset, Periodicity: mustRepeatingInterval("R/P1W")}
+	b := &Job{Name: "b", Type: JTDataset, Upstream: []string{"a"}}
+	if err := cron.Schedule(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := cron.Schedule(ctx, b); err != nil {
+		t.Fatal(err)
+	}
+
+	a = &Job{Name: "a", Type: JTDataset, Periodicity: mustRepeatingInterval("R/P1W"), Upstream: []string{"b"}}
+	if err := cron.Schedule(ctx, a); !errors.Is(err, ErrDependencyCycle) {
+		t.Errorf("expected scheduling a cycle to fail. got: %v", err)
+	}
+	if err := cron.Schedule(ctx, &Job{Name: "c", Type: JTDataset, Upstream: []string{"c"}}); err == nil {
+		t.Error("expected scheduling a job that depends on itself to fail")
+	}
+}
+
+func TestCronRunDownstream(t *testing.T) {
+	ctx := context.Background()
+	schedule := &MemJobStore{}
+	cron := NewCron(schedule, &MemJobStore{}, nil)
+
+	js := []*Job{
+		{Name: "a", Type: JTDataset, Periodicity: mustRepeatingInterval("R/P1W")},
+		{Name: "b", Type: JTDataset, Upstream: []string{"a"}},
+		{Name: "c", Type: JTDataset, Upstream: []string{"a"}},
+		{Name: "d", Type: JTDataset, Upstream: []string{"b", "c"}},
+		{Name: "fails", Type: JTDataset, Upstream: []string{"a"}},
+		{Name: "e", Type: JTDataset, Upstream: []string{"fails", "b"}},
+		{Name: "unrelated", Type: JTDataset, Upstream: []string{"z"}},
+	}
+	for _, job := range js {
+		if err := cron.Schedule(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ran := []string{}
+	runner := func(ctx context.Context, streams ioes.IOStreams, job *Job) error {
+		ran = append(ran, job.Name)
+		if job.Name == "fails" {
+			return fmt.Errorf("oh noes")
+		}
+		return nil
+	}
+
+	a, err := schedule.Job(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cron.runJob(ctx, a, runner)
+	cron.runDownstream(ctx, "a", runner)
+
+	expect := []string{"a", "b", "c", "d", "fails"}
+	if diff := cmp.Diff(expect, ran); diff != "" {
+		t.Errorf("ran jobs mismatch (-want +got):\n%s", diff)
+	}
+}
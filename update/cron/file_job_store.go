@@ -148,6 +148,35 @@ func (s *FlatbufferJobStore) DeleteJob(ctx context.Context, name string) error {
 	return s.saveJobs(js)
 }
 
+// Pause marks a job as paused
+func (s *FlatbufferJobStore) Pause(ctx context.Context, name string) error {
+	return s.updateJob(name, (*Job).pause)
+}
+
+// Resume clears the paused state of a job
+func (s *FlatbufferJobStore) Resume(ctx context.Context, name string) error {
+	return s.updateJob(name, (*Job).resume)
+}
+
+// updateJob applies a change to a stored job by name
+func (s *FlatbufferJobStore) updateJob(name string, change func(*Job)) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	js, err := s.loadJobs()
+	if err != nil {
+		return err
+	}
+
+	for _, j := range js {
+		if j.Name == name {
+			change(j)
+			return s.saveJobs(js)
+		}
+	}
+	return fmt.Errorf("not found")
+}
+
 const logsDirName = "logfiles"
 
 // CreateLogFile creates a log file in the specified logs directory
@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"strings"
 
 	flatbuffers "github.com/google/flatbuffers/go"
@@ -94,6 +95,30 @@ func (c HTTPClient) Unschedule(ctx context.Context, name string) error {
 	return maybeErrorResponse(res)
 }
 
+// Pause stops a job from running until it's resumed
+func (c HTTPClient) Pause(ctx context.Context, name string) error {
+	return c.postName("pause", name)
+}
+
+// Resume restarts a paused job
+func (c HTTPClient) Resume(ctx context.Context, name string) error {
+	return c.postName("resume", name)
+}
+
+// Run executes a scheduled job immediately
+func (c HTTPClient) Run(ctx context.Context, name string) (*Job, error) {
+	res, err := http.PostForm(fmt.Sprintf("http://%s/run", c.Addr), url.Values{"name": {name}})
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode == 200 {
+		return decodeJobResponse(res)
+	}
+
+	return nil, maybeErrorResponse(res)
+}
+
 // ListLogs gives a log of executed jobs
 func (c HTTPClient) ListLogs(ctx context.Context, offset, limit int) ([]*Job, error) {
 	res, err := http.Get(fmt.Sprintf("http://%s/logs?offset=%d&limit=%d", c.Addr, offset, limit))
@@ -146,6 +171,15 @@ func (c HTTPClient) postJob(job *Job) error {
 	return maybeErrorResponse(res)
 }
 
+func (c HTTPClient) postName(path, name string) error {
+	res, err := http.PostForm(fmt.Sprintf("http://%s/%s", c.Addr, path), url.Values{"name": {name}})
+	if err != nil {
+		return err
+	}
+
+	return maybeErrorResponse(res)
+}
+
 func maybeErrorResponse(res *http.Response) error {
 	if res.StatusCode == 200 {
 		return nil
@@ -214,6 +248,8 @@ func newCronRoutes(c *Cron) http.Handler {
 	m.HandleFunc("/log", c.loggedJobHandler)
 	m.HandleFunc("/log/output", c.loggedJobFileHandler)
 	m.HandleFunc("/run", c.runHandler)
+	m.HandleFunc("/pause", c.pauseHandler)
+	m.HandleFunc("/resume", c.resumeHandler)
 
 	return m
 }
@@ -254,7 +290,7 @@ func (c *Cron) jobsHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if err := c.schedule.PutJob(r.Context(), job); err != nil {
+		if err := c.Schedule(r.Context(), job); err != nil {
 			w.WriteHeader(http.StatusInternalServerError)
 			w.Write([]byte(err.Error()))
 			return
@@ -329,9 +365,44 @@ func (c *Cron) loggedJobFileHandler(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+func (c *Cron) pauseHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err := c.Pause(r.Context(), r.FormValue("name")); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+}
+
+func (c *Cron) resumeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err := c.Resume(r.Context(), r.FormValue("name")); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+}
+
 func (c *Cron) runHandler(w http.ResponseWriter, r *http.Request) {
-	// TODO (b5): implement an HTTP run handler
-	w.WriteHeader(http.StatusInternalServerError)
-	w.Write([]byte("not finished"))
-	// c.runJob(r.Context(), nil)
+	if r.Method != "POST" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	job, err := c.Run(r.Context(), r.FormValue("name"))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Write(job.FlatbufferBytes())
 }
@@ -2,6 +2,7 @@ package cron
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -12,8 +13,12 @@ func TestCronHTTP(t *testing.T) {
 	s := &MemJobStore{}
 	l := &MemJobStore{}
 
+	fail := false
 	factory := func(context.Context) RunJobFunc {
 		return func(ctx context.Context, streams ioes.IOStreams, job *Job) error {
+			if fail {
+				return fmt.Errorf("oh noes")
+			}
 			return nil
 		}
 	}
@@ -66,6 +71,46 @@ func TestCronHTTP(t *testing.T) {
 		t.Fatal(err.Error())
 	}
 
+	if err := cli.Pause(cliCtx, dsJob.Name); err != nil {
+		t.Fatal(err)
+	}
+	if job, err := cli.Job(cliCtx, dsJob.Name); err != nil || !job.Paused {
+		t.Errorf("expected job to be paused. err: %v", err)
+	}
+	if err := cli.Resume(cliCtx, dsJob.Name); err != nil {
+		t.Fatal(err)
+	}
+	if job, err := cli.Job(cliCtx, dsJob.Name); err != nil || job.Paused {
+		t.Errorf("expected job to be resumed. err: %v", err)
+	}
+	if err := cli.Pause(cliCtx, "unknown"); err == nil {
+		t.Error("expected pausing an unknown job to error")
+	}
+
+	run, err := cli.Run(cliCtx, dsJob.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.RunNumber != 1 || run.RunError != "" {
+		t.Errorf("expected successful first run to be logged. got run number: %d, error: %q", run.RunNumber, run.RunError)
+	}
+	fail = true
+	if run, err = cli.Run(cliCtx, dsJob.Name); err != nil {
+		t.Fatal(err)
+	}
+	if run.RunNumber != 2 || run.RunError != "oh noes" {
+		t.Errorf("expected failed second run to be logged. got run number: %d, error: %q", run.RunNumber, run.RunError)
+	}
+	if job, err := cli.Job(cliCtx, dsJob.Name); err != nil || job.Failures != 1 {
+		t.Errorf("expected failed manual run to count as a failure. err: %v", err)
+	}
+	if logs, err := cli.ListLogs(cliCtx, 0, -1); err != nil || len(logs) != 2 {
+		t.Errorf("expected 2 logged runs. got: %d, err: %v", len(logs), err)
+	}
+	if _, err := cli.Run(cliCtx, "unknown"); err == nil {
+		t.Error("expected running an unknown job to error")
+	}
+
 	if err := cli.Unschedule(cliCtx, dsJob.Name); err != nil {
 		t.Fatal(err)
 	}
@@ -24,6 +24,11 @@ const (
 	// update one or more datasets. A non-zero exit code from shell script
 	// indicates the job failed to execute properly
 	JTShellScript JobType = "shell"
+	// JTTransform indicates a job that runs the transform script of a dataset
+	// specified by Job Name in-process, saving the result as a new version.
+	// Unlike JTDataset, transform jobs don't require a qri binary, and script
+	// errors are reported with the position in the script that failed
+	JTTransform JobType = "transform"
 )
 
 // Enum returns the enumerated representation of a JobType
@@ -33,6 +38,8 @@ func (jt JobType) Enum() int8 {
 		return 1
 	case JTShellScript:
 		return 2
+	case JTTransform:
+		return 3
 	}
 	// "unknown"
 	return 0
@@ -61,6 +68,23 @@ type Job struct {
 
 	RepoPath string `json:"repoPath,omitempty"`
 
+	// Upstream lists the names of jobs this job depends on. A successful run
+	// of an upstream job triggers a run of this job. Jobs with upstream jobs
+	// don't need a periodicity
+	Upstream []string `json:"upstream,omitempty"`
+
+	// Paused jobs don't run, either on schedule or when triggered by upstream
+	// jobs. Jobs are paused automatically after too many consecutive failures
+	Paused bool `json:"paused,omitempty"`
+	// Failures counts consecutive failed runs, resetting on success
+	Failures int64 `json:"failures,omitempty"`
+	// Retries counts retries of a failed run attempted since the last scheduled
+	// run
+	Retries int64 `json:"retries,omitempty"`
+	// NextRetry is the time a failed job will be retried. zero when no retry
+	// is pending
+	NextRetry time.Time `json:"nextRetry,omitempty"`
+
 	Options Options `json:"options,omitempty"`
 }
 
@@ -74,21 +98,45 @@ func (job *Job) Validate() error {
 		return fmt.Errorf("name is required")
 	}
 
-	if job.Periodicity == zero {
+	if job.Periodicity == zero && len(job.Upstream) == 0 {
 		return fmt.Errorf("period is required")
 	}
-	if job.Type != JTDataset && job.Type != JTShellScript {
+	for _, name := range job.Upstream {
+		if name == job.Name {
+			return fmt.Errorf("job cannot depend on itself")
+		}
+	}
+	if job.Type != JTDataset && job.Type != JTShellScript && job.Type != JTTransform {
 		return fmt.Errorf("invalid job type: %s", job.Type)
 	}
 	return nil
 }
 
 // NextExec returns the next time execution horizon. If job periodicity is
-// improperly configured, the returned time will be zero
+// improperly configured, the returned time will be zero. Jobs without a
+// periodicity only run when triggered by upstream jobs, and always return zero
 func (job *Job) NextExec() time.Time {
+	if job.Periodicity == zero {
+		return time.Time{}
+	}
 	return job.Periodicity.After(job.PrevRunStart)
 }
 
+// pause stops a job from running
+func (job *Job) pause() {
+	job.Paused = true
+	job.NextRetry = time.Time{}
+}
+
+// resume clears the paused state of a job, along with any record of past
+// failures that might have paused it
+func (job *Job) resume() {
+	job.Paused = false
+	job.Failures = 0
+	job.Retries = 0
+	job.NextRetry = time.Time{}
+}
+
 // LogName returns a canonical name string for a job that's executed and saved
 // to a logging system
 func (job *Job) LogName() string {
@@ -110,6 +158,16 @@ func (job *Job) Copy() *Job {
 		RunError:    job.RunError,
 		LogFilePath: job.LogFilePath,
 		RepoPath:    job.RepoPath,
+
+		Paused:    job.Paused,
+		Failures:  job.Failures,
+		Retries:   job.Retries,
+		NextRetry: job.NextRetry,
+	}
+
+	if job.Upstream != nil {
+		cp.Upstream = make([]string, len(job.Upstream))
+		copy(cp.Upstream, job.Upstream)
 	}
 
 	if job.Options != nil {
@@ -138,7 +196,27 @@ func (job *Job) MarshalFlatbuffer(builder *flatbuffers.Builder) flatbuffers.UOff
 	lastError := builder.CreateString(job.RunError)
 	logPath := builder.CreateString(job.LogFilePath)
 	repoPath := builder.CreateString(job.RepoPath)
-	p := builder.CreateString(job.Periodicity.String())
+	var p flatbuffers.UOffsetT
+	if job.Periodicity != zero {
+		p = builder.CreateString(job.Periodicity.String())
+	}
+	var nextRetry flatbuffers.UOffsetT
+	if !job.NextRetry.IsZero() {
+		nextRetry = builder.CreateString(job.NextRetry.Format(time.RFC3339))
+	}
+
+	var upstream flatbuffers.UOffsetT
+	if n := len(job.Upstream); n > 0 {
+		offsets := make([]flatbuffers.UOffsetT, n)
+		for i, name := range job.Upstream {
+			offsets[i] = builder.CreateString(name)
+		}
+		cronfb.JobStartUpstreamVector(builder, n)
+		for i := n - 1; i >= 0; i-- {
+			builder.PrependUOffsetT(offsets[i])
+		}
+		upstream = builder.EndVector(n)
+	}
 
 	var opts flatbuffers.UOffsetT
 	if job.Options != nil {
@@ -159,6 +237,11 @@ func (job *Job) MarshalFlatbuffer(builder *flatbuffers.Builder) flatbuffers.UOff
 	cronfb.JobAddRunError(builder, lastError)
 	cronfb.JobAddLogFilePath(builder, logPath)
 	cronfb.JobAddRepoPath(builder, repoPath)
+	cronfb.JobAddUpstream(builder, upstream)
+	cronfb.JobAddPaused(builder, job.Paused)
+	cronfb.JobAddFailures(builder, job.Failures)
+	cronfb.JobAddRetries(builder, job.Retries)
+	cronfb.JobAddNextRetry(builder, nextRetry)
 	cronfb.JobAddOptionsType(builder, job.fbOptionsType())
 	if opts != 0 {
 		cronfb.JobAddOptions(builder, opts)
@@ -170,6 +253,8 @@ func (job *Job) fbOptionsType() byte {
 	switch job.Options.(type) {
 	case *DatasetOptions:
 		return cronfb.OptionsDatasetOptions
+	case *TransformOptions:
+		return cronfb.OptionsTransformOptions
 	default:
 		return 0 // will fire for nil case
 	}
@@ -190,9 +275,17 @@ func (job *Job) UnmarshalFlatbuffer(j *cronfb.Job) error {
 		return err
 	}
 
-	p, err := iso8601.ParseRepeatingInterval(string(j.Periodicity()))
-	if err != nil {
-		return err
+	var p iso8601.RepeatingInterval
+	if str := string(j.Periodicity()); str != "" {
+		if p, err = iso8601.ParseRepeatingInterval(str); err != nil {
+			return err
+		}
+	}
+	var nextRetry time.Time
+	if str := string(j.NextRetry()); str != "" {
+		if nextRetry, err = time.Parse(time.RFC3339, str); err != nil {
+			return err
+		}
 	}
 
 	*job = Job{
@@ -208,16 +301,35 @@ func (job *Job) UnmarshalFlatbuffer(j *cronfb.Job) error {
 		RunError:    string(j.RunError()),
 		LogFilePath: string(j.LogFilePath()),
 		RepoPath:    string(j.RepoPath()),
+
+		Paused:    j.Paused(),
+		Failures:  j.Failures(),
+		Retries:   j.Retries(),
+		NextRetry: nextRetry,
+	}
+
+	if j.UpstreamLength() > 0 {
+		job.Upstream = make([]string, j.UpstreamLength())
+		for i := range job.Upstream {
+			job.Upstream[i] = string(j.Upstream(i))
+		}
 	}
 
 	unionTable := new(flatbuffers.Table)
 	if j.Options(unionTable) {
-		if j.OptionsType() == cronfb.OptionsDatasetOptions {
+		switch j.OptionsType() {
+		case cronfb.OptionsDatasetOptions:
 			fbOpts := &cronfb.DatasetOptions{}
 			fbOpts.Init(unionTable.Bytes, unionTable.Pos)
 			opts := &DatasetOptions{}
 			opts.UnmarshalFlatbuffer(fbOpts)
 			job.Options = opts
+		case cronfb.OptionsTransformOptions:
+			fbOpts := &cronfb.TransformOptions{}
+			fbOpts.Init(unionTable.Bytes, unionTable.Pos)
+			opts := &TransformOptions{}
+			opts.UnmarshalFlatbuffer(fbOpts)
+			job.Options = opts
 		}
 	}
 
@@ -387,3 +499,93 @@ func (o *DatasetOptions) UnmarshalFlatbuffer(fbo *cronfb.DatasetOptions) {
 		}
 	}
 }
+
+// TransformOptions encapsulates options for running a dataset transform
+// in-process
+type TransformOptions struct {
+	Title   string
+	Message string
+
+	Publish bool
+	Force   bool
+
+	Config  map[string]string
+	Secrets map[string]string
+}
+
+// MarshalFlatbuffer writes to a builder
+func (o *TransformOptions) MarshalFlatbuffer(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	commitTitle := builder.CreateString(o.Title)
+	commitMessage := builder.CreateString(o.Message)
+
+	var config flatbuffers.UOffsetT
+	if offsets := marshalStringMapVals(builder, o.Config); len(offsets) > 0 {
+		cronfb.TransformOptionsStartConfigVector(builder, len(offsets))
+		for i := len(offsets) - 1; i >= 0; i-- {
+			builder.PrependUOffsetT(offsets[i])
+		}
+		config = builder.EndVector(len(offsets))
+	}
+
+	var secrets flatbuffers.UOffsetT
+	if offsets := marshalStringMapVals(builder, o.Secrets); len(offsets) > 0 {
+		cronfb.TransformOptionsStartSecretsVector(builder, len(offsets))
+		for i := len(offsets) - 1; i >= 0; i-- {
+			builder.PrependUOffsetT(offsets[i])
+		}
+		secrets = builder.EndVector(len(offsets))
+	}
+
+	cronfb.TransformOptionsStart(builder)
+	cronfb.TransformOptionsAddTitle(builder, commitTitle)
+	cronfb.TransformOptionsAddMessage(builder, commitMessage)
+	cronfb.TransformOptionsAddPublish(builder, o.Publish)
+	cronfb.TransformOptionsAddForce(builder, o.Force)
+	cronfb.TransformOptionsAddConfig(builder, config)
+	cronfb.TransformOptionsAddSecrets(builder, secrets)
+	return cronfb.TransformOptionsEnd(builder)
+}
+
+// UnmarshalFlatbuffer reads flatbuffer data into TransformOptions
+func (o *TransformOptions) UnmarshalFlatbuffer(fbo *cronfb.TransformOptions) {
+	o.Title = string(fbo.Title())
+	o.Message = string(fbo.Message())
+	o.Publish = fbo.Publish()
+	o.Force = fbo.Force()
+
+	if fbo.ConfigLength() > 0 {
+		o.Config = map[string]string{}
+		var val cronfb.StringMapVal
+		for i := 0; i < fbo.ConfigLength(); i++ {
+			if fbo.Config(&val, i) {
+				o.Config[string(val.Key())] = string(val.Val())
+			}
+		}
+	}
+
+	if fbo.SecretsLength() > 0 {
+		o.Secrets = map[string]string{}
+		var val cronfb.StringMapVal
+		for i := 0; i < fbo.SecretsLength(); i++ {
+			if fbo.Secrets(&val, i) {
+				o.Secrets[string(val.Key())] = string(val.Val())
+			}
+		}
+	}
+}
+
+// marshalStringMapVals writes each key-value pair in a map to a builder as a
+// StringMapVal table, returning table offsets ready to write to a vector
+func marshalStringMapVals(builder *flatbuffers.Builder, m map[string]string) []flatbuffers.UOffsetT {
+	offsets := make([]flatbuffers.UOffsetT, 0, len(m))
+	for key, val := range m {
+		keyOff := builder.CreateString(key)
+		valOff := builder.CreateString(val)
+
+		cronfb.StringMapValStart(builder)
+		cronfb.StringMapValAddKey(builder, keyOff)
+		cronfb.StringMapValAddVal(builder, valOff)
+		offsets = append(offsets, cronfb.StringMapValEnd(builder))
+	}
+	return offsets
+}
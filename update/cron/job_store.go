@@ -34,6 +34,11 @@ type JobStore interface {
 	PutJob(context.Context, *Job) error
 	// DeleteJob removes a job from the store
 	DeleteJob(ctx context.Context, name string) error
+	// Pause marks a stored job as paused
+	Pause(ctx context.Context, name string) error
+	// Resume clears the paused state of a stored job, resetting failure & retry
+	// counts
+	Resume(ctx context.Context, name string) error
 }
 
 // LogFileCreator is an interface for generating log files to write to,
@@ -52,8 +57,11 @@ type MemJobStore struct {
 	jobs jobs
 }
 
-// ListJobs lists jobs currently in the store
+// ListJobs lists jobs currently in the store. Listed jobs are copies, changes
+// to them aren't stored until they're put
 func (s *MemJobStore) ListJobs(ctx context.Context, offset, limit int) ([]*Job, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	if limit < 0 {
 		limit = len(s.jobs)
 	}
@@ -66,7 +74,7 @@ func (s *MemJobStore) ListJobs(ctx context.Context, offset, limit int) ([]*Job,
 			break
 		}
 
-		jobs = append(jobs, job)
+		jobs = append(jobs, job.Copy())
 	}
 	return jobs, nil
 }
@@ -77,7 +85,7 @@ func (s *MemJobStore) Job(ctx context.Context, name string) (*Job, error) {
 	defer s.lock.Unlock()
 	for _, job := range s.jobs {
 		if job.Name == name {
-			return job, nil
+			return job.Copy(), nil
 		}
 	}
 	return nil, fmt.Errorf("not found")
@@ -152,3 +160,31 @@ func (s *MemJobStore) DeleteJob(ctx context.Context, name string) error {
 	}
 	return nil
 }
+
+// Pause marks a job as paused
+func (s *MemJobStore) Pause(ctx context.Context, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, j := range s.jobs {
+		if j.Name == name {
+			j.pause()
+			return nil
+		}
+	}
+	return fmt.Errorf("not found")
+}
+
+// Resume clears the paused state of a job
+func (s *MemJobStore) Resume(ctx context.Context, name string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, j := range s.jobs {
+		if j.Name == name {
+			j.resume()
+			return nil
+		}
+	}
+	return fmt.Errorf("not found")
+}
@@ -159,6 +159,54 @@ func RunJobStoreTests(t *testing.T, newStore func() JobStore) {
 		}
 	})
 
+	t.Run("TestJobStorePause", func(t *testing.T) {
+		store := newStore()
+		job := &Job{
+			Name:        "job_one",
+			Periodicity: mustRepeatingInterval("R/PT1H"),
+			Type:        JTDataset,
+			Failures:    3,
+			NextRetry:   time.Date(2001, 1, 1, 1, 1, 1, 1, time.UTC),
+		}
+		if err := store.PutJob(ctx, job); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := store.Pause(ctx, job.Name); err != nil {
+			t.Fatal(err)
+		}
+		got, err := store.Job(ctx, job.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Paused || !got.NextRetry.IsZero() {
+			t.Errorf("expected paused job without a pending retry. got paused: %t, next retry: %s", got.Paused, got.NextRetry)
+		}
+
+		if err := store.Resume(ctx, job.Name); err != nil {
+			t.Fatal(err)
+		}
+		if got, err = store.Job(ctx, job.Name); err != nil {
+			t.Fatal(err)
+		}
+		if got.Paused || got.Failures != 0 {
+			t.Errorf("expected resumed job to reset failures. got paused: %t, failures: %d", got.Paused, got.Failures)
+		}
+
+		if err := store.Pause(ctx, "missing"); err == nil {
+			t.Error("expected pausing a missing job to error")
+		}
+		if err := store.Resume(ctx, "missing"); err == nil {
+			t.Error("expected resuming a missing job to error")
+		}
+
+		if dest, ok := store.(qfs.Destroyer); ok {
+			if err := dest.Destroy(); err != nil {
+				t.Log(err)
+			}
+		}
+	})
+
 	t.Run("TestJobStoreConcurrentUse", func(t *testing.T) {
 		t.Skip("TODO (b5)")
 	})
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/google/go-cmp/cmp"
 	cronfb "github.com/qri-io/qri/update/cron/cron_fbs"
 )
 
@@ -44,6 +45,57 @@ func TestDatasetOptionsFlatbuffer(t *testing.T) {
 	}
 }
 
+func TestTransformOptionsFlatbuffer(t *testing.T) {
+	src := &TransformOptions{
+		Title:   "A_Title",
+		Message: "A_Message",
+		Publish: true,
+		Force:   true,
+		Config:  map[string]string{"a": "a", "b": "b"},
+		Secrets: map[string]string{"c": "c"},
+	}
+
+	builder := flatbuffers.NewBuilder(0)
+	off := src.MarshalFlatbuffer(builder)
+	if off == 0 {
+		t.Errorf("expected returned offset to not equal zero")
+	}
+	builder.Finish(off)
+
+	cronOpts := cronfb.GetRootAsTransformOptions(builder.FinishedBytes(), 0)
+
+	got := &TransformOptions{}
+	got.UnmarshalFlatbuffer(cronOpts)
+
+	if diff := cmp.Diff(src, got); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestTransformJobFlatbuffer(t *testing.T) {
+	src := &Job{
+		Name:        "me/dataset",
+		Type:        JTTransform,
+		Periodicity: mustRepeatingInterval("R/P1W"),
+		RunError:    "transform.star:3:7: transform error: \"oh noes\"",
+		Failures:    2,
+		Retries:     1,
+		NextRetry:   time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
+		Options:     &TransformOptions{Title: "hallo"},
+	}
+	if err := src.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := &Job{}
+	if err := got.UnmarshalFlatbuffer(cronfb.GetRootAsJob(src.FlatbufferBytes(), 0)); err != nil {
+		t.Fatal(err)
+	}
+	if err := CompareJobs(src, got); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestJobCopy(t *testing.T) {
 	a := &Job{
 		Name:         "name",
@@ -57,6 +109,10 @@ func TestJobCopy(t *testing.T) {
 		RunError:     "oh noes it broke",
 		LogFilePath:  "such filepath",
 		RepoPath:     "such repo path",
+		Paused:       true,
+		Failures:     3,
+		Retries:      2,
+		NextRetry:    time.Now(),
 		Options: &DatasetOptions{
 			FilePaths: []string{"the", "file", "paths"},
 		},
@@ -104,6 +160,19 @@ func CompareJobs(a, b *Job) error {
 		return fmt.Errorf("RepoPath mistmatch. %s != %s", a.RepoPath, b.RepoPath)
 	}
 
+	if a.Paused != b.Paused {
+		return fmt.Errorf("Paused mismatch. %t != %t", a.Paused, b.Paused)
+	}
+	if a.Failures != b.Failures {
+		return fmt.Errorf("Failures mismatch. %d != %d", a.Failures, b.Failures)
+	}
+	if a.Retries != b.Retries {
+		return fmt.Errorf("Retries mismatch. %d != %d", a.Retries, b.Retries)
+	}
+	if a.NextRetry.Unix() != b.NextRetry.Unix() {
+		return fmt.Errorf("NextRetry mismatch. %s != %s", a.NextRetry, b.NextRetry)
+	}
+
 	if err := CompareOptions(a.Options, b.Options); err != nil {
 		return fmt.Errorf("Options: %s", err)
 	}
@@ -128,6 +197,15 @@ func CompareOptions(a, b Options) error {
 		return nil
 	}
 
+	aTo, aOk := a.(*TransformOptions)
+	bTo, bOk := b.(*TransformOptions)
+	if aOk && bOk {
+		if diff := cmp.Diff(aTo, bTo); diff != "" {
+			return fmt.Errorf("TransformOptions (-want +got):\n%s", diff)
+		}
+		return nil
+	}
+
 	// TODO (b5) - more option comparison
 	return fmt.Errorf("TODO - can't compare option types: %#v %#v", a, b)
 }
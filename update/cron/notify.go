@@ -0,0 +1,149 @@
+package cron
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// EventType distinguishes the job state changes a Notifier is told about
+type EventType string
+
+const (
+	// ETFailure indicates a job run failed and won't be retried before the
+	// next scheduled run
+	ETFailure EventType = "failure"
+	// ETRecovery indicates a job succeeded after one or more failed runs
+	ETRecovery EventType = "recovery"
+	// ETPaused indicates a job was paused after too many consecutive failures
+	ETPaused EventType = "paused"
+)
+
+// Event describes a change in job health
+type Event struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+	Job  *Job      `json:"job"`
+}
+
+// Notifier is told when jobs fail & recover
+type Notifier interface {
+	Notify(ctx context.Context, e Event) error
+}
+
+// NotifyTimeout limits how long a notifier can take to deliver an event
+var NotifyTimeout = time.Second * 30
+
+// webhookClient is the HTTP client webhooks are sent with. unlike the default
+// client, requests time out
+var webhookClient = &http.Client{Timeout: NotifyTimeout}
+
+// WebhookNotifier POSTs events as JSON to a URL
+type WebhookNotifier struct {
+	URL string
+}
+
+// assert WebhookNotifier is a Notifier at compile time
+var _ Notifier = (*WebhookNotifier)(nil)
+
+// Notify sends an event to the webhook URL
+func (n *WebhookNotifier) Notify(ctx context.Context, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", n.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return fmt.Errorf("webhook %s responded with status %d", n.URL, res.StatusCode)
+	}
+	return nil
+}
+
+// CommandNotifier runs a local shell command for each event. The event is
+// written to the command's standard input as JSON, and summarized in the
+// QRI_UPDATE_EVENT, QRI_UPDATE_JOB & QRI_UPDATE_ERROR environment variables
+type CommandNotifier struct {
+	Command string
+}
+
+// assert CommandNotifier is a Notifier at compile time
+var _ Notifier = (*CommandNotifier)(nil)
+
+// Notify runs the notifier command
+func (n *CommandNotifier) Notify(ctx context.Context, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", n.Command)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Env = append(os.Environ(),
+		"QRI_UPDATE_EVENT="+string(e.Type),
+		"QRI_UPDATE_JOB="+e.Job.Name,
+		"QRI_UPDATE_ERROR="+e.Job.RunError,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("running notify command: %s: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// FailurePolicy configures how cron handles failed jobs. The zero value
+// doesn't retry, never pauses jobs & sends no notifications
+type FailurePolicy struct {
+	// Retries is the number of times a failed run is retried before waiting
+	// for the next scheduled run
+	Retries int
+	// Backoff is the delay before the first retry. Each following retry waits
+	// twice as long as the one before it
+	Backoff time.Duration
+	// MaxFailures is the number of consecutive failed runs that pauses a job.
+	// Zero never pauses jobs
+	MaxFailures int
+	// Notifiers are told when jobs fail, recover, or are paused
+	Notifiers []Notifier
+}
+
+// retryDelay returns the time to wait before a retry, given the number of
+// retries already attempted
+func (p FailurePolicy) retryDelay(retries int64) time.Duration {
+	return p.Backoff * time.Duration(int64(1)<<uint(retries))
+}
+
+// notify sends an event to all notifiers in the background, so slow
+// notifiers don't hold up the scheduler. Each notification gets NotifyTimeout
+// to complete, independent of the context of the run that caused it. Notifier
+// errors are logged
+func (p FailurePolicy) notify(et EventType, job *Job) {
+	if len(p.Notifiers) == 0 {
+		return
+	}
+	e := Event{Type: et, Time: time.Now().In(time.UTC), Job: job}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), NotifyTimeout)
+		defer cancel()
+		for _, n := range p.Notifiers {
+			if err := n.Notify(ctx, e); err != nil {
+				log.Errorf("notifying %s of job %s: %s", et, job.Name, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,181 @@
+package cron
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/qri-io/ioes"
+)
+
+type recordNotifier struct {
+	events chan EventType
+}
+
+func (n *recordNotifier) Notify(ctx context.Context, e Event) error {
+	n.events <- e.Type
+	return nil
+}
+
+// expect waits for notifications, failing if they don't match
+func (n *recordNotifier) expect(t *testing.T, events ...EventType) {
+	t.Helper()
+	got := []EventType{}
+	for range events {
+		select {
+		case et := <-n.events:
+			got = append(got, et)
+		case <-time.After(time.Second):
+		}
+	}
+	select {
+	case et := <-n.events:
+		got = append(got, et)
+	default:
+	}
+	if diff := cmp.Diff(events, got); diff != "" {
+		t.Errorf("events mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestCronFailurePolicy(t *testing.T) {
+	ctx := context.Background()
+	schedule := &MemJobStore{}
+	notifier := &recordNotifier{events: make(chan EventType, 10)}
+	cron := NewCron(schedule, &MemJobStore{}, nil)
+	cron.SetFailurePolicy(FailurePolicy{
+		Retries:     2,
+		Backoff:     time.Minute,
+		MaxFailures: 2,
+		Notifiers:   []Notifier{notifier},
+	})
+
+	if err := cron.Schedule(ctx, &Job{Name: "a", Type: JTDataset, Periodicity: mustRepeatingInterval("R/P1W")}); err != nil {
+		t.Fatal(err)
+	}
+
+	fail := true
+	runner := func(ctx context.Context, streams ioes.IOStreams, job *Job) error {
+		if fail {
+			return fmt.Errorf("oh noes")
+		}
+		return nil
+	}
+	run := func(retry bool) *Job {
+		job, err := schedule.Job(ctx, "a")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if retry {
+			job.Retries++
+		} else {
+			job.Retries = 0
+		}
+		cron.runJob(ctx, job, runner)
+		if job, err = schedule.Job(ctx, "a"); err != nil {
+			t.Fatal(err)
+		}
+		return job
+	}
+
+	job := run(false)
+	if job.Failures != 0 {
+		t.Errorf("expected failures not to count while retries are pending. got: %d", job.Failures)
+	}
+	if delay := job.NextRetry.Sub(job.PrevRunStart); delay < time.Minute || delay > 2*time.Minute {
+		t.Errorf("expected first retry after one minute. got: %s", delay)
+	}
+	prevRunStart := job.PrevRunStart
+
+	job = run(true)
+	if !job.PrevRunStart.Equal(prevRunStart) {
+		t.Errorf("expected retries not to change the previous scheduled run start")
+	}
+	if delay := time.Until(job.NextRetry); delay < time.Minute || delay > 2*time.Minute {
+		t.Errorf("expected second retry to back off to two minutes. got: %s", delay)
+	}
+
+	job = run(true)
+	if !job.NextRetry.IsZero() {
+		t.Errorf("expected no retry after retries are exhausted. got: %s", job.NextRetry)
+	}
+	if job.Failures != 1 {
+		t.Errorf("expected a run with failed retries to count as 1 failure. got: %d", job.Failures)
+	}
+	notifier.expect(t, ETFailure)
+
+	run(false)
+	run(true)
+	if job = run(true); !job.Paused {
+		t.Errorf("expected job to pause after 2 consecutive failed runs")
+	}
+	notifier.expect(t, ETPaused)
+
+	fail = false
+	job = run(false)
+	if job.Failures != 0 || job.RunError != "" {
+		t.Errorf("expected success to reset failures. got failures: %d, error: %q", job.Failures, job.RunError)
+	}
+	notifier.expect(t, ETRecovery)
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var got Event
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer s.Close()
+
+	n := &WebhookNotifier{URL: s.URL}
+	if err := n.Notify(context.Background(), Event{Type: ETFailure, Job: &Job{Name: "a"}}); err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != ETFailure || got.Job.Name != "a" {
+		t.Errorf("webhook received unexpected event: %v", got)
+	}
+
+	n.URL = s.URL + "/missing"
+	if err := n.Notify(context.Background(), Event{Type: ETFailure, Job: &Job{Name: "a"}}); err == nil {
+		t.Error("expected non-2XX webhook response to error")
+	}
+}
+
+func TestCommandNotifier(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cron_command_notifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "event.txt")
+
+	n := &CommandNotifier{Command: fmt.Sprintf(`echo "$QRI_UPDATE_EVENT $QRI_UPDATE_JOB $QRI_UPDATE_ERROR" > %s`, path)}
+	if err := n.Notify(context.Background(), Event{Type: ETRecovery, Job: &Job{Name: "a", RunError: "oh noes"}}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "recovery a oh noes\n" {
+		t.Errorf("command output mismatch. got: %q", string(data))
+	}
+
+	n.Command = "exit 1"
+	if err := n.Notify(context.Background(), Event{Type: ETRecovery, Job: &Job{Name: "a"}}); err == nil {
+		t.Error("expected failing command to error")
+	}
+}
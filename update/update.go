@@ -4,18 +4,21 @@ package update
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	golog "github.com/ipfs/go-log"
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/ioes"
 	"github.com/qri-io/iso8601"
 	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/startf"
 	"github.com/qri-io/qri/update/cron"
 )
 
@@ -33,8 +36,9 @@ func Path(repoPath string) (path string, err error) {
 	return
 }
 
-// Start starts the update service
-func Start(ctx context.Context, repoPath string, updateCfg *config.Update, daemonize bool) error {
+// Start starts the update service. runTransform executes transform jobs
+// in-process, and is required to run jobs of type cron.JTTransform
+func Start(ctx context.Context, repoPath string, updateCfg *config.Update, daemonize bool, runTransform cron.RunJobFunc) error {
 	if updateCfg == nil {
 		updateCfg = config.DefaultUpdate()
 	}
@@ -48,7 +52,7 @@ func Start(ctx context.Context, repoPath string, updateCfg *config.Update, daemo
 		return daemonInstall(repoPath)
 	}
 
-	return start(ctx, repoPath, updateCfg)
+	return start(ctx, repoPath, updateCfg, runTransform)
 }
 
 // StopDaemon checks for a running daemon, uninstalling it if one exists
@@ -61,7 +65,7 @@ func Status() (string, error) {
 	return daemonShow()
 }
 
-func start(ctx context.Context, repoPath string, updateCfg *config.Update) error {
+func start(ctx context.Context, repoPath string, updateCfg *config.Update, runTransform cron.RunJobFunc) error {
 	path, err := Path(repoPath)
 	if err != nil {
 		return err
@@ -79,7 +83,8 @@ func start(ctx context.Context, repoPath string, updateCfg *config.Update) error
 		return fmt.Errorf("unknown cron type: %s", updateCfg.Type)
 	}
 
-	svc := cron.NewCron(jobStore, logStore, Factory)
+	svc := cron.NewCron(jobStore, logStore, NewFactory(TransformLimits(updateCfg), runTransform))
+	svc.SetFailurePolicy(FailurePolicy(updateCfg))
 	log.Debug("starting update service")
 	go func() {
 		if err := svc.ServeHTTP(updateCfg.Address); err != nil {
@@ -90,38 +95,108 @@ func start(ctx context.Context, repoPath string, updateCfg *config.Update) error
 	return svc.Start(ctx)
 }
 
-// Factory returns a function that can run jobs
-func Factory(context.Context) cron.RunJobFunc {
-	return func(ctx context.Context, streams ioes.IOStreams, job *cron.Job) error {
-		log.Debugf("running update: %s", job.Name)
-
-		var errBuf *bytes.Buffer
-		// if the job type is a dataset, error output is semi-predictable
-		// write to a buffer for better error reporting
-		if job.Type == cron.JTDataset {
-			errBuf = &bytes.Buffer{}
-			teedErrOut := io.MultiWriter(streams.ErrOut, errBuf)
-			streams = ioes.NewIOStreams(streams.In, streams.Out, teedErrOut)
-		}
+// FailurePolicy creates a cron failure policy from update configuration
+func FailurePolicy(updateCfg *config.Update) cron.FailurePolicy {
+	p := cron.FailurePolicy{
+		Retries:     updateCfg.Retries,
+		Backoff:     time.Duration(updateCfg.RetryBackoffMs) * time.Millisecond,
+		MaxFailures: updateCfg.MaxFailures,
+	}
+	if updateCfg.NotifyWebhook != "" {
+		p.Notifiers = append(p.Notifiers, &cron.WebhookNotifier{URL: updateCfg.NotifyWebhook})
+	}
+	if updateCfg.NotifyCommand != "" {
+		p.Notifiers = append(p.Notifiers, &cron.CommandNotifier{Command: updateCfg.NotifyCommand})
+	}
+	return p
+}
 
-		cmd := JobToCmd(streams, job)
-		if cmd == nil {
-			return fmt.Errorf("unrecognized update type: %s", job.Type)
+// TransformLimits creates transform limits from update configuration
+func TransformLimits(updateCfg *config.Update) startf.Limits {
+	if updateCfg == nil {
+		return startf.Limits{}
+	}
+	return startf.Limits{
+		Timeout:        time.Duration(updateCfg.TransformTimeoutMs) * time.Millisecond,
+		MaxSteps:       updateCfg.TransformMaxSteps,
+		MaxBodyEntries: updateCfg.TransformMaxBodyEntries,
+	}
+}
+
+// Factory returns a function that can run jobs. Factory can't run transform
+// jobs, which need access to a qri repo. Use NewFactory to run all job types
+func Factory(ctx context.Context) cron.RunJobFunc {
+	return NewFactory(startf.Limits{}, nil)(ctx)
+}
+
+// NewFactory creates a job runner factory that delegates transform jobs to
+// runTransform. Dataset & shell script jobs execute as operating system
+// commands. Dataset jobs pass limits on to the save they run, and are stopped
+// if they outlive the limit timeout
+func NewFactory(limits startf.Limits, runTransform cron.RunJobFunc) cron.RunJobFactory {
+	return func(context.Context) cron.RunJobFunc {
+		return func(ctx context.Context, streams ioes.IOStreams, job *cron.Job) error {
+			if job.Type == cron.JTTransform {
+				log.Debugf("running transform update: %s", job.Name)
+				if runTransform == nil {
+					return fmt.Errorf("transform updates require a qri repo to run")
+				}
+				return processTransformError(streams, runTransform(ctx, streams, job))
+			}
+			return runCmdJob(ctx, streams, job, limits)
 		}
+	}
+}
+
+// cmdTimeoutGrace is how long a dataset job can outlive its transform timeout
+// before it's killed, giving the save time to report the limit & exit
+var cmdTimeoutGrace = time.Second * 30
+
+// runCmdJob executes a job as an operating system command
+func runCmdJob(ctx context.Context, streams ioes.IOStreams, job *cron.Job, limits startf.Limits) error {
+	log.Debugf("running update: %s", job.Name)
+
+	var errBuf *bytes.Buffer
+	// if the job type is a dataset, error output is semi-predictable
+	// write to a buffer for better error reporting
+	if job.Type == cron.JTDataset {
+		errBuf = &bytes.Buffer{}
+		teedErrOut := io.MultiWriter(streams.ErrOut, errBuf)
+		streams = ioes.NewIOStreams(streams.In, streams.Out, teedErrOut)
+	}
+
+	if job.Type == cron.JTDataset && limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, limits.Timeout+cmdTimeoutGrace)
+		defer cancel()
+	}
 
-		err := cmd.Run()
-		return processJobError(job, errBuf, err)
+	cmd := JobToCmdContext(ctx, streams, job, limits)
+	if cmd == nil {
+		return fmt.Errorf("unrecognized update type: %s", job.Type)
 	}
+
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("update exceeded its time limit of %s", limits.Timeout)
+	}
+	return processJobError(job, errBuf, err)
 }
 
 // JobToCmd returns an operating system command that will execute the given job
 // wiring operating system in/out/errout to the provided iostreams.
 func JobToCmd(streams ioes.IOStreams, job *cron.Job) *exec.Cmd {
+	return JobToCmdContext(context.Background(), streams, job, startf.Limits{})
+}
+
+// JobToCmdContext is like JobToCmd, but the command is killed when ctx is
+// done. Dataset jobs pass limits to the save command as flags
+func JobToCmdContext(ctx context.Context, streams ioes.IOStreams, job *cron.Job, limits startf.Limits) *exec.Cmd {
 	switch job.Type {
 	case cron.JTDataset:
-		return datasetSaveCmd(streams, job)
+		return datasetSaveCmd(ctx, streams, job, limits)
 	case cron.JTShellScript:
-		return shellScriptCmd(streams, job)
+		return shellScriptCmd(ctx, streams, job)
 	default:
 		return nil
 	}
@@ -129,7 +204,7 @@ func JobToCmd(streams ioes.IOStreams, job *cron.Job) *exec.Cmd {
 
 // datasetSaveCmd configures a "qri save" command based on job details
 // wiring operating system in/out/errout to the provided iostreams.
-func datasetSaveCmd(streams ioes.IOStreams, job *cron.Job) *exec.Cmd {
+func datasetSaveCmd(ctx context.Context, streams ioes.IOStreams, job *cron.Job, limits startf.Limits) *exec.Cmd {
 	args := []string{"save", job.Name}
 
 	if job.RepoPath != "" {
@@ -171,7 +246,17 @@ func datasetSaveCmd(streams ioes.IOStreams, job *cron.Job) *exec.Cmd {
 		}
 	}
 
-	cmd := exec.Command("qri", args...)
+	if limits.Timeout > 0 {
+		args = append(args, fmt.Sprintf(`--transform-timeout=%s`, limits.Timeout))
+	}
+	if limits.MaxSteps > 0 {
+		args = append(args, fmt.Sprintf(`--transform-max-steps=%d`, limits.MaxSteps))
+	}
+	if limits.MaxBodyEntries > 0 {
+		args = append(args, fmt.Sprintf(`--transform-max-body-entries=%d`, limits.MaxBodyEntries))
+	}
+
+	cmd := exec.CommandContext(ctx, "qri", args...)
 	cmd.Stderr = streams.ErrOut
 	cmd.Stdout = streams.Out
 	cmd.Stdin = streams.In
@@ -182,10 +267,10 @@ func datasetSaveCmd(streams ioes.IOStreams, job *cron.Job) *exec.Cmd {
 // to the provided iostreams.
 // Commands are executed with access to the same enviornment variables as the
 // process the runner is executing in
-func shellScriptCmd(streams ioes.IOStreams, job *cron.Job) *exec.Cmd {
+func shellScriptCmd(ctx context.Context, streams ioes.IOStreams, job *cron.Job) *exec.Cmd {
 	// TODO (b5) - config and secrets as env vars
 
-	cmd := exec.Command(job.Name)
+	cmd := exec.CommandContext(ctx, job.Name)
 	cmd.Stderr = streams.ErrOut
 	cmd.Stdout = streams.Out
 	cmd.Stdin = streams.In
@@ -198,17 +283,19 @@ func PossibleShellScript(path string) bool {
 	return filepath.Ext(path) == ".sh"
 }
 
-// DatasetToJob converts a dataset to cron.Job
-func DatasetToJob(ds *dataset.Dataset, periodicity string, opts *cron.DatasetOptions) (job *cron.Job, err error) {
+// DatasetToJob converts a dataset to cron.Job. upstream names jobs that
+// trigger this job when they succeed. Jobs with upstream jobs don't require a
+// periodicity
+func DatasetToJob(ds *dataset.Dataset, periodicity string, upstream []string, opts *cron.DatasetOptions) (job *cron.Job, err error) {
 	if periodicity == "" && ds.Meta != nil && ds.Meta.AccrualPeriodicity != "" {
 		periodicity = ds.Meta.AccrualPeriodicity
 	}
 
-	if periodicity == "" {
+	if periodicity == "" && len(upstream) == 0 {
 		return nil, fmt.Errorf("scheduling dataset updates requires a meta component with accrualPeriodicity set")
 	}
 
-	p, err := iso8601.ParseRepeatingInterval(periodicity)
+	p, err := parsePeriodicity(periodicity)
 	if err != nil {
 		return nil, err
 	}
@@ -219,6 +306,7 @@ func DatasetToJob(ds *dataset.Dataset, periodicity string, opts *cron.DatasetOpt
 		Periodicity:  p,
 		Type:         cron.JTDataset,
 		PrevRunStart: ds.Commit.Timestamp,
+		Upstream:     upstream,
 	}
 	if opts != nil {
 		job.Options = opts
@@ -228,9 +316,31 @@ func DatasetToJob(ds *dataset.Dataset, periodicity string, opts *cron.DatasetOpt
 	return
 }
 
+// TransformToJob creates a cron.Job that runs the transform of a dataset
+// in-process. The dataset must have a transform
+func TransformToJob(ds *dataset.Dataset, periodicity string, upstream []string, opts *cron.TransformOptions) (job *cron.Job, err error) {
+	if ds.Transform == nil {
+		return nil, fmt.Errorf("scheduling transform updates requires a dataset with a transform")
+	}
+
+	if job, err = DatasetToJob(ds, periodicity, upstream, nil); err != nil {
+		return nil, err
+	}
+	job.Type = cron.JTTransform
+	if opts != nil {
+		job.Options = opts
+	}
+	err = job.Validate()
+	return
+}
+
 // ShellScriptToJob turns a shell script into cron.Job
-func ShellScriptToJob(path string, periodicity string, opts *cron.ShellScriptOptions) (job *cron.Job, err error) {
-	p, err := iso8601.ParseRepeatingInterval(periodicity)
+func ShellScriptToJob(path string, periodicity string, upstream []string, opts *cron.ShellScriptOptions) (job *cron.Job, err error) {
+	if periodicity == "" && len(upstream) == 0 {
+		return nil, fmt.Errorf("scheduling shell scripts requires a periodicity")
+	}
+
+	p, err := parsePeriodicity(periodicity)
 	if err != nil {
 		return nil, err
 	}
@@ -241,6 +351,7 @@ func ShellScriptToJob(path string, periodicity string, opts *cron.ShellScriptOpt
 		Name:        path,
 		Periodicity: p,
 		Type:        cron.JTShellScript,
+		Upstream:    upstream,
 	}
 	if opts != nil {
 		job.Options = opts
@@ -249,6 +360,15 @@ func ShellScriptToJob(path string, periodicity string, opts *cron.ShellScriptOpt
 	return
 }
 
+// parsePeriodicity reads an ISO 8601 repeating interval string. An empty
+// string gives a zero interval, for jobs that are only run by upstream jobs
+func parsePeriodicity(periodicity string) (p iso8601.RepeatingInterval, err error) {
+	if periodicity == "" {
+		return p, nil
+	}
+	return iso8601.ParseRepeatingInterval(periodicity)
+}
+
 func processJobError(job *cron.Job, errOut *bytes.Buffer, err error) error {
 	if err == nil {
 		return nil
@@ -265,3 +385,20 @@ func processJobError(job *cron.Job, errOut *bytes.Buffer, err error) error {
 
 	return err
 }
+
+// processTransformError reduces errors from transform scripts to the position
+// in the script that failed, writing the full backtrace to the job output.
+// transforms stopped for exceeding a resource limit report the limit
+func processTransformError(streams ioes.IOStreams, err error) error {
+	var limitErr *startf.LimitError
+	if errors.As(err, &limitErr) {
+		fmt.Fprintf(streams.ErrOut, "transform stopped: %s\n", limitErr)
+		return limitErr
+	}
+	var scriptErr *startf.ScriptError
+	if errors.As(err, &scriptErr) {
+		fmt.Fprintln(streams.ErrOut, scriptErr.Backtrace)
+		return fmt.Errorf("%s: %s", scriptErr.Position(), scriptErr.Msg)
+	}
+	return err
+}
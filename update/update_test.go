@@ -2,13 +2,18 @@ package update
 
 import (
 	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/qri-io/dataset"
 	"github.com/qri-io/ioes"
+	"github.com/qri-io/qfs"
 	"github.com/qri-io/qri/config"
+	"github.com/qri-io/qri/startf"
 	"github.com/qri-io/qri/update/cron"
 )
 
@@ -26,10 +31,92 @@ func TestJobFromDataset(t *testing.T) {
 		},
 	}
 
-	_, err := DatasetToJob(ds, "", nil)
+	_, err := DatasetToJob(ds, "", nil, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
+
+	ds.Meta = nil
+	if _, err := DatasetToJob(ds, "", nil, nil); err == nil {
+		t.Error("expected a dataset without a periodicity to error")
+	}
+	job, err := DatasetToJob(ds, "", []string{"b5/upstream"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !job.NextExec().IsZero() {
+		t.Errorf("expected a job that only runs after upstream jobs to have no next execution time. got: %s", job.NextExec())
+	}
+}
+
+func TestTransformToJob(t *testing.T) {
+	ds := &dataset.Dataset{
+		Peername: "b5",
+		Name:     "libp2p_node_count",
+		Commit: &dataset.Commit{
+			Timestamp: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	if _, err := TransformToJob(ds, "R/P1W", nil, nil); err == nil {
+		t.Error("expected scheduling a dataset without a transform to error")
+	}
+
+	ds.Transform = &dataset.Transform{ScriptPath: "transform.star"}
+	job, err := TransformToJob(ds, "R/P1W", nil, &cron.TransformOptions{Title: "hallo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Type != cron.JTTransform {
+		t.Errorf("expected job type to be %q. got: %q", cron.JTTransform, job.Type)
+	}
+	if job.Name != "b5/libp2p_node_count" {
+		t.Errorf("job name mismatch. expected: 'b5/libp2p_node_count', got: '%s'", job.Name)
+	}
+}
+
+func TestTransformJobRunner(t *testing.T) {
+	ctx := context.Background()
+	job := &cron.Job{Type: cron.JTTransform, Name: "me/foo"}
+	streams, _, _, errOut := ioes.NewTestIOStreams()
+
+	if err := Factory(ctx)(ctx, streams, job); err == nil {
+		t.Error("expected running a transform job without a transform runner to error")
+	}
+
+	script := "def transform(ds, ctx):\n  error(\"oh noes\")\n"
+	runTransform := func(ctx context.Context, streams ioes.IOStreams, job *cron.Job) error {
+		ds := &dataset.Dataset{Transform: &dataset.Transform{}}
+		ds.Transform.SetScriptFile(qfs.NewMemfileBytes("transform.star", []byte(script)))
+		return startf.ExecScript(ctx, ds, nil)
+	}
+
+	err := NewFactory(startf.Limits{}, runTransform)(ctx)(ctx, streams, job)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	expect := `transform.star:2:8: transform error: "oh noes"`
+	if err.Error() != expect {
+		t.Errorf("error mismatch. expected: '%s', got: '%s'", expect, err.Error())
+	}
+	if !strings.Contains(errOut.String(), "Traceback") {
+		t.Errorf("expected backtrace to be written to job output. got: %q", errOut.String())
+	}
+
+	script = "def transform(ds, ctx):\n  ds.set_body([x for x in range(100)])\n"
+	runLimitedTransform := func(ctx context.Context, streams ioes.IOStreams, job *cron.Job) error {
+		ds := &dataset.Dataset{Transform: &dataset.Transform{}}
+		ds.Transform.SetScriptFile(qfs.NewMemfileBytes("transform.star", []byte(script)))
+		return startf.ExecScript(ctx, ds, nil, startf.SetLimits(startf.Limits{MaxSteps: 10}))
+	}
+	err = NewFactory(startf.Limits{}, runLimitedTransform)(ctx)(ctx, streams, job)
+	expect = "transform exceeded its limit of 10 steps"
+	if err == nil || err.Error() != expect {
+		t.Errorf("error mismatch. expected: '%s', got: '%v'", expect, err)
+	}
+	if !strings.Contains(errOut.String(), "transform stopped: "+expect) {
+		t.Errorf("expected limit to be written to job output. got: %q", errOut.String())
+	}
 }
 
 func TestJobFromShellScript(t *testing.T) {
@@ -66,6 +153,49 @@ func TestDatasetJobToCmd(t *testing.T) {
 	}
 }
 
+func TestDatasetJobLimits(t *testing.T) {
+	limits := TransformLimits(config.DefaultUpdate())
+	if limits.Timeout != time.Duration(config.DefaultTransformTimeoutMs)*time.Millisecond || limits.Timeout == 0 {
+		t.Errorf("expected default update config to limit transform time. got: %s", limits.Timeout)
+	}
+
+	job := &cron.Job{Type: cron.JTDataset, Name: "me/foo"}
+	limits = startf.Limits{Timeout: time.Minute, MaxSteps: 100, MaxBodyEntries: 10}
+	cmd := JobToCmdContext(context.Background(), ioes.NewDiscardIOStreams(), job, limits)
+	expect := "qri save me/foo --transform-timeout=1m0s --transform-max-steps=100 --transform-max-body-entries=10"
+	if got := strings.Join(cmd.Args, " "); got != expect {
+		t.Errorf("job string mismatch. expected:\n'%s'\ngot:\n'%s'", expect, got)
+	}
+
+	// a qri binary that hangs
+	dir, err := ioutil.TempDir("", "update_limits")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := ioutil.WriteFile(filepath.Join(dir, "qri"), []byte("#!/bin/sh\nexec sleep 30\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	prevPath := os.Getenv("PATH")
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+prevPath)
+	defer os.Setenv("PATH", prevPath)
+	prevGrace := cmdTimeoutGrace
+	cmdTimeoutGrace = 0
+	defer func() { cmdTimeoutGrace = prevGrace }()
+
+	ctx := context.Background()
+	limits = startf.Limits{Timeout: time.Millisecond * 100}
+	start := time.Now()
+	err = NewFactory(limits, nil)(ctx)(ctx, ioes.NewDiscardIOStreams(), job)
+	expectErr := "update exceeded its time limit of 100ms"
+	if err == nil || err.Error() != expectErr {
+		t.Errorf("error mismatch. expected: '%s', got: '%v'", expectErr, err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second*10 {
+		t.Errorf("expected hung dataset job to be stopped. took %s", elapsed)
+	}
+}
+
 func TestShellScriptJobToCmd(t *testing.T) {
 	dsj := &cron.Job{
 		Type: cron.JTShellScript,
@@ -82,15 +212,33 @@ func TestShellScriptJobToCmd(t *testing.T) {
 }
 
 func TestShellScriptToJob(t *testing.T) {
-	if _, err := ShellScriptToJob("", "", nil); err == nil {
+	if _, err := ShellScriptToJob("", "", nil, nil); err == nil {
 		t.Errorf("expected error")
 	}
 
-	if _, err := ShellScriptToJob("testdata/hello.sh", "R/P1Y", nil); err != nil {
+	if _, err := ShellScriptToJob("testdata/hello.sh", "R/P1Y", nil, nil); err != nil {
 		t.Errorf("unexpected error: %s", err)
 	}
 }
 
+func TestFailurePolicy(t *testing.T) {
+	p := FailurePolicy(config.DefaultUpdate())
+	if p.Retries != 0 || p.MaxFailures != 0 {
+		t.Errorf("expected default policy not to retry or pause updates. got retries: %d, max failures: %d", p.Retries, p.MaxFailures)
+	}
+	if len(p.Notifiers) != 0 {
+		t.Errorf("expected no notifiers by default. got: %d", len(p.Notifiers))
+	}
+
+	p = FailurePolicy(&config.Update{RetryBackoffMs: 60000, NotifyWebhook: "http://example.com", NotifyCommand: "echo failed"})
+	if p.Backoff != time.Minute {
+		t.Errorf("expected backoff of one minute. got: %s", p.Backoff)
+	}
+	if len(p.Notifiers) != 2 {
+		t.Errorf("expected webhook & command notifiers. got: %d", len(p.Notifiers))
+	}
+}
+
 func TestStart(t *testing.T) {
 	ctx, done := context.WithDeadline(context.Background(), time.Now().Add(time.Millisecond*200))
 	defer done()
@@ -98,7 +246,7 @@ func TestStart(t *testing.T) {
 	// call factory here to ensure we can create a factory with this context
 	Factory(ctx)
 
-	if err := Start(ctx, "", &config.Update{Type: "mem"}, false); err != nil {
+	if err := Start(ctx, "", &config.Update{Type: "mem"}, false, nil); err != nil {
 		t.Error(err)
 	}
 }